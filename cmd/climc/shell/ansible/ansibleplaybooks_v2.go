@@ -59,6 +59,36 @@ func init() {
 			return nil
 		},
 	)
+	R(&AnsiblePlaybookV2IdOptions{}, "ansibleplaybookv2-plan", "Dry run ansible playbook with diff of changes recorded as plan",
+		func(s *mcclient.ClientSession, opts *AnsiblePlaybookV2IdOptions) error {
+			apb, err := modules.AnsiblePlaybooksV2.PerformAction(s, opts.ID, "plan", nil)
+			if err != nil {
+				return err
+			}
+			printObject(apb)
+			return nil
+		},
+	)
+	R(&AnsiblePlaybookV2IdOptions{}, "ansibleplaybookv2-plan-show", "Show changes planned by ansible playbook on each host",
+		func(s *mcclient.ClientSession, opts *AnsiblePlaybookV2IdOptions) error {
+			plan, err := modules.AnsiblePlaybooksV2.GetSpecific(s, opts.ID, "plan", nil)
+			if err != nil {
+				return err
+			}
+			printObject(plan)
+			return nil
+		},
+	)
+	R(&AnsiblePlaybookV2IdOptions{}, "ansibleplaybookv2-approve", "Approve plan of ansible playbook and run it",
+		func(s *mcclient.ClientSession, opts *AnsiblePlaybookV2IdOptions) error {
+			apb, err := modules.AnsiblePlaybooksV2.PerformAction(s, opts.ID, "approve", nil)
+			if err != nil {
+				return err
+			}
+			printObject(apb)
+			return nil
+		},
+	)
 	R(&AnsiblePlaybookV2IdOptions{}, "ansibleplaybookv2-stop", "Stop ansible playbook",
 		func(s *mcclient.ClientSession, opts *AnsiblePlaybookV2IdOptions) error {
 			apb, err := modules.AnsiblePlaybooksV2.PerformAction(s, opts.ID, "stop", nil)
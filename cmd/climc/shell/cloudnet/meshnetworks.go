@@ -79,4 +79,12 @@ func init() {
 		printObject(mn)
 		return nil
 	})
+	R(&options.MeshNetworkActionRotateKeysOptions{}, "meshnetwork-rotate-keys", "Rotate wireguard keys of mesh network members", func(s *mcclient.ClientSession, opts *options.MeshNetworkActionRotateKeysOptions) error {
+		mn, err := modules.MeshNetworks.PerformAction(s, opts.ID, "rotate-keys", nil)
+		if err != nil {
+			return err
+		}
+		printObject(mn)
+		return nil
+	})
 }
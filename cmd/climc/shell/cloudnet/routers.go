@@ -131,4 +131,12 @@ func init() {
 		printObject(router)
 		return nil
 	})
+	R(&options.RouterWireguardPeersOptions{}, "router-wireguard-peers", "Show wireguard peer states of router", func(s *mcclient.ClientSession, opts *options.RouterWireguardPeersOptions) error {
+		result, err := modules.Routers.GetSpecific(s, opts.ID, "wireguard-peers", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
 }
@@ -16,6 +16,7 @@ package compute
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -44,7 +45,7 @@ func init() {
 	type ScalingPolicyListOptions struct {
 		options.BaseListOptions
 		ScalingGroup string `help:"ScalingGroup ID or Name"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking|metric"`
 	}
 	R(&ScalingPolicyListOptions{}, "scaling-policy-list", "List Scaling Policy", func(s *mcclient.ClientSession,
 		args *ScalingPolicyListOptions) error {
@@ -81,14 +82,32 @@ func init() {
 		AlarmValue     float64 `help:"Value of Indicator" json:"value"`
 	}
 
+	type ScalingMetric struct {
+		MetricDatabase           string   `help:"Tsdb database of metric, default telegraf"`
+		MetricIndicator          string   `help:"Preset indicator, instead of measurement and field" choices:"cpu|mem|disk_read|disk_write|flow_into|flow_out"`
+		MetricMeasurement        string   `help:"Measurement of metric"`
+		MetricField              string   `help:"Field of metric"`
+		MetricTag                []string `help:"Tag filter of metric, format: key=value, default filter by scaling group"`
+		MetricWrapper            string   `help:"Wrapper for metric" choices:"max|min|average"`
+		MetricWindow             int      `help:"Window the metric is aggregated over, unit: s"`
+		MetricOperator           string   `help:"Operator of 'metric' trigger" choices:"gt|lt"`
+		MetricValue              float64  `help:"Threshold of 'metric' trigger"`
+		MetricTargetValue        float64  `help:"Target value of 'target_tracking' trigger"`
+		MetricPredictiveMode     string   `help:"Predictive mode of 'target_tracking' trigger" choices:"off|forecast_only|forecast_and_scale"`
+		MetricPredictiveWeeks    int      `help:"Number of previous weeks the forecast is based on"`
+		MetricPredictiveLeadTime int      `help:"Minutes the forecast looks ahead"`
+		MetricDryRun             bool     `help:"Only record the evaluations without scaling"`
+	}
+
 	type ScalingPolicyCreateOptions struct {
 		NAME         string `help:"ScalingPolicy Name" json:"name"`
 		ScalingGroup string `help:"ScalingGroup ID or Name" json:"scaling_group"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle" json:"trigger_type"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking|metric" json:"trigger_type"`
 
 		Timer
 		CycleTimer
 		ScalingAlarm
+		ScalingMetric
 
 		Action      string `help:"Action for scaling policy" choices:"add|remove|set" json:"action"`
 		Number      int    `help:"Instance number for action" json:"number"`
@@ -111,6 +130,14 @@ func init() {
 			if err != nil {
 				return fmt.Errorf("invalid time format for 'end_time'")
 			}
+			tags := make(map[string]string, len(args.MetricTag))
+			for _, tag := range args.MetricTag {
+				parts := strings.SplitN(tag, "=", 2)
+				if len(parts) != 2 {
+					return fmt.Errorf("invalid metric tag %q, format: key=value", tag)
+				}
+				tags[parts[0]] = parts[1]
+			}
 			spCreateInput := api.ScalingPolicyCreateInput{
 				ScalingGroup: args.ScalingGroup,
 				TriggerType:  args.TriggerType,
@@ -134,6 +161,22 @@ func init() {
 					Operator:  args.AlarmOperator,
 					Value:     args.AlarmValue,
 				},
+				Metric: api.ScalingMetricCreateInput{
+					Database:           args.MetricDatabase,
+					Indicator:          args.MetricIndicator,
+					Measurement:        args.MetricMeasurement,
+					Field:              args.MetricField,
+					Tags:               tags,
+					Wrapper:            args.MetricWrapper,
+					Window:             args.MetricWindow,
+					Operator:           args.MetricOperator,
+					Value:              args.MetricValue,
+					TargetValue:        args.MetricTargetValue,
+					PredictiveMode:     args.MetricPredictiveMode,
+					PredictiveWeeks:    args.MetricPredictiveWeeks,
+					PredictiveLeadTime: args.MetricPredictiveLeadTime,
+					DryRun:             args.MetricDryRun,
+				},
 				Action:      args.Action,
 				Number:      args.Number,
 				Unit:        args.Unit,
@@ -186,6 +229,20 @@ func init() {
 		},
 	)
 
+	type ScalingPolicyDryRunOptions struct {
+		ID string `help:"ScalingPolicy ID or Name"`
+	}
+	R(&ScalingPolicyDryRunOptions{}, "scaling-policy-dry-run", "Show what the metric driven ScalingPolicy would do now",
+		func(s *mcclient.ClientSession, args *ScalingPolicyDryRunOptions) error {
+			ret, err := modules.ScalingPolicy.GetSpecific(s, args.ID, "dry-run", nil)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
 	type ScalingPolicyDeleteOptions struct {
 		ID string `help:"ScalingPolicy ID or Name"`
 	}
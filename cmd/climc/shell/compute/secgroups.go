@@ -33,4 +33,5 @@ func init() {
 	cmd.Perform("add-rule", &options.SecgroupsAddRuleOptions{})
 	cmd.Perform("change-owner", &options.SecgroupChangeOwnerOptions{})
 	cmd.Perform("import-rules", &options.SecgroupImportRulesOptions{})
+	cmd.Perform("set-flow-log", &options.SecgroupSetFlowLogOptions{})
 }
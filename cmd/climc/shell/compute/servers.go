@@ -131,6 +131,8 @@ func init() {
 	cmd.Get("desc", new(options.ServerIdOptions))
 	cmd.Get("status", new(options.ServerIdOptions))
 	cmd.Get("iso", new(options.ServerIdOptions))
+	cmd.Get("history", new(options.ServerHistoryOptions))
+	cmd.Get("flow-logs", new(options.ServerFlowLogsOptions))
 	cmd.Get("create-params", new(options.ServerIdOptions))
 	cmd.Get("sshable", new(options.ServerIdOptions))
 	cmd.Get("make-sshable-cmd", new(options.ServerIdOptions))
@@ -710,6 +712,13 @@ func init() {
 		DisablePvpanic    string `help:"disable pvpanic device" choices:"true|false"`
 		DisableUsbKbd     string `help:"disable usb kbd" choices:"true|false"`
 		UsbControllerType string `help:"usb controller type" choices:"usb-ehci|qemu-xhci"`
+		EnableTpm         string `help:"enable vTPM 2.0 device" choices:"true|false"`
+		SecureBoot        string `help:"enable UEFI secure boot" choices:"true|false"`
+		EnableBalloon     string `help:"enable virtio-balloon device" choices:"true|false"`
+		BalloonMinMem     int    `help:"minimum memory in MB guaranteed when host inflates balloon"`
+		EnableVirtioMem   string `help:"provide memory by virtio-mem, take effect on next start" choices:"true|false"`
+		Watchdog          string `help:"hardware watchdog model, none to remove, take effect on next start" choices:"i6300esb|ib700|none"`
+		CrashAction       string `help:"action on watchdog timeout or guest panic" choices:"reset|dump|poweroff|none"`
 	}
 
 	R(&ServerQemuParams{}, "server-set-qemu-params", "config qemu params", func(s *mcclient.ClientSession,
@@ -727,6 +736,29 @@ func init() {
 		if len(opts.UsbControllerType) > 0 {
 			params.Set("usb_controller_type", jsonutils.NewString(opts.UsbControllerType))
 		}
+		if len(opts.EnableTpm) > 0 {
+			params.Set("enable_tpm", jsonutils.NewString(opts.EnableTpm))
+		}
+		if len(opts.SecureBoot) > 0 {
+			params.Set("secure_boot", jsonutils.NewString(opts.SecureBoot))
+		}
+		if len(opts.EnableBalloon) > 0 {
+			params.Set("enable_balloon", jsonutils.NewString(opts.EnableBalloon))
+		}
+		if len(opts.EnableVirtioMem) > 0 {
+			params.Set("enable_virtio_mem", jsonutils.NewString(opts.EnableVirtioMem))
+		}
+		if opts.BalloonMinMem > 0 {
+			params.Set("balloon_min_mem", jsonutils.NewInt(int64(opts.BalloonMinMem)))
+		}
+		if opts.Watchdog == "none" {
+			params.Set("watchdog", jsonutils.NewString(""))
+		} else if len(opts.Watchdog) > 0 {
+			params.Set("watchdog", jsonutils.NewString(opts.Watchdog))
+		}
+		if len(opts.CrashAction) > 0 {
+			params.Set("crash_action", jsonutils.NewString(opts.CrashAction))
+		}
 		result, err := modules.Servers.PerformAction(s, opts.ID, "set-qemu-params", params)
 		if err != nil {
 			return err
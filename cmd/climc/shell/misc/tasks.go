@@ -15,8 +15,12 @@
 package misc
 
 import (
+	"strings"
+
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/util/printutils"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/modules/compute"
 )
@@ -82,4 +86,63 @@ func init() {
 		return nil
 	})
 
+	type TaskTreeOptions struct {
+		ObjType     string `help:"object type, e.g. server"`
+		ObjId       string `help:"object id or name"`
+		TaskId      string `help:"show the task tree of this task"`
+		Since       string `help:"show tasks created since this time point"`
+		ServiceType string `choices:"image|cloudid|cloudevent|devtool|ansible|identity|notify|log|compute|compute_v2"`
+	}
+	R(&TaskTreeOptions{}, "task-tree", "Show task trees of an object or a task", func(s *mcclient.ClientSession, args *TaskTreeOptions) error {
+		man := compute.TasksManager{}
+		params := jsonutils.Marshal(args)
+		result, err := man.GetTaskTree(s, params)
+		if err != nil {
+			return err
+		}
+		trees := struct {
+			Tasks []apis.TaskTreeNode
+		}{}
+		err = result.Unmarshal(&trees)
+		if err != nil {
+			return err
+		}
+		list := &printutils.ListResult{}
+		for i := range trees.Tasks {
+			list.Data = appendTaskTreeRows(list.Data, &trees.Tasks[i], 0)
+		}
+		list.Total = len(list.Data)
+		printList(list, []string{"id", "task_name", "obj_name", "obj_id", "stage", "status", "failed_stage", "can_retry", "created_at"})
+		return nil
+	})
+
+	type TaskRetryOptions struct {
+		ID          string `help:"ID of the failed task"`
+		ServiceType string `choices:"image|cloudid|cloudevent|devtool|ansible|identity|notify|log|compute|compute_v2"`
+	}
+	R(&TaskRetryOptions{}, "task-retry", "Retry a failed task from its failed stage", func(s *mcclient.ClientSession, args *TaskRetryOptions) error {
+		man := compute.TasksManager{}
+		params := jsonutils.Marshal(args)
+		result, err := man.Retry(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
+
+// appendTaskTreeRows flattens task tree into rows, subtasks are indented under their parent
+func appendTaskTreeRows(rows []jsonutils.JSONObject, node *apis.TaskTreeNode, depth int) []jsonutils.JSONObject {
+	row := jsonutils.Marshal(node).(*jsonutils.JSONDict)
+	row.Remove("stages")
+	row.Remove("subtasks")
+	if depth > 0 {
+		row.Set("task_name", jsonutils.NewString(strings.Repeat("  ", depth-1)+"└─"+node.TaskName))
+	}
+	rows = append(rows, row)
+	for i := range node.Subtasks {
+		rows = appendTaskTreeRows(rows, &node.Subtasks[i], depth+1)
+	}
+	return rows
 }
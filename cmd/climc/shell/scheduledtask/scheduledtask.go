@@ -16,6 +16,7 @@ package compute
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -38,7 +39,7 @@ func init() {
 	type ScheduledTaskListOptions struct {
 		options.BaseListOptions
 
-		ScheduledType string `help:"scheduled type" choices:"timing|cycle"`
+		ScheduledType string `help:"scheduled type" choices:"timing|cycle|dependent"`
 		ResourceType  string `help:"resource type"`
 		Operation     string `help:"operation"`
 		UtcOffset     int    `help:"utc offset"`
@@ -85,7 +86,7 @@ func init() {
 
 	type ScheduledTaskCreateOptions struct {
 		NAME          string `help:"ScheduledTask Name" json:"name"`
-		ScheduledType string `help:"Scheudled Type" choices:"timing|cycle" json:"scheduled_type"`
+		ScheduledType string `help:"Scheudled Type" choices:"timing|cycle|dependent" json:"scheduled_type"`
 
 		Timer
 		CycleTimer
@@ -94,6 +95,10 @@ func init() {
 		Operation    string   `help:"operation"`
 		LabelType    string   `help:"label type"`
 		Labels       []string `help:"labels"`
+
+		DependsOn           []string `help:"scheduled tasks to run after"`
+		DependencyCondition string   `help:"condition of scheduled tasks depended on" choices:"succeed|complete"`
+		Calendar            string   `help:"calendar whose blackouts skip the runs"`
 	}
 	R(&ScheduledTaskCreateOptions{}, "scheduledtask-create", "Create Scheduled Task", func(s *mcclient.ClientSession, args *ScheduledTaskCreateOptions) error {
 		formatStr := "2006-01-02 15:04:05"
@@ -136,6 +141,10 @@ func init() {
 			Operation:    args.Operation,
 			LabelType:    args.LabelType,
 			Labels:       args.Labels,
+
+			DependsOn:           args.DependsOn,
+			DependencyCondition: args.DependencyCondition,
+			Calendar:            args.Calendar,
 		}
 		stCreateInput.Name = args.NAME
 		ret, err := modules.ScheduledTask.Create(s, jsonutils.Marshal(stCreateInput))
@@ -186,6 +195,75 @@ func init() {
 		},
 	)
 
+	type ScheduledTaskSetDependenciesOptions struct {
+		ID        string   `help:"ScheduledTask ID or Name"`
+		DependsOn []string `help:"scheduled tasks to run after"`
+		Condition string   `help:"condition of scheduled tasks depended on" choices:"succeed|complete"`
+	}
+	R(&ScheduledTaskSetDependenciesOptions{}, "scheduledtask-set-dependencies", "Set scheduled tasks which ScheduledTask runs after",
+		func(s *mcclient.ClientSession, args *ScheduledTaskSetDependenciesOptions) error {
+			input := apis.ScheduledTaskSetDependenciesInput{
+				DependsOn: args.DependsOn,
+				Condition: args.Condition,
+			}
+			ret, err := modules.ScheduledTask.PerformAction(s, args.ID, "set-dependencies", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScheduledTaskSetCalendarOptions struct {
+		ID       string `help:"ScheduledTask ID or Name"`
+		Calendar string `help:"calendar ID or Name, empty to unset"`
+	}
+	R(&ScheduledTaskSetCalendarOptions{}, "scheduledtask-set-calendar", "Set blackout calendar of ScheduledTask",
+		func(s *mcclient.ClientSession, args *ScheduledTaskSetCalendarOptions) error {
+			params := jsonutils.NewDict()
+			params.Set("calendar", jsonutils.NewString(args.Calendar))
+			ret, err := modules.ScheduledTask.PerformAction(s, args.ID, "set-calendar", params)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScheduledTaskSetLabelConcurrencyOptions struct {
+		LABEL          string `help:"label"`
+		MAXCONCURRENCY int    `help:"maximum number of resources operated at the same time, 0 to remove the limit"`
+	}
+	R(&ScheduledTaskSetLabelConcurrencyOptions{}, "scheduledtask-set-label-concurrency", "Set concurrency limit of label",
+		func(s *mcclient.ClientSession, args *ScheduledTaskSetLabelConcurrencyOptions) error {
+			input := apis.ScheduledTaskSetLabelConcurrencyInput{
+				Label:          args.LABEL,
+				MaxConcurrency: args.MAXCONCURRENCY,
+			}
+			ret, err := modules.ScheduledTask.PerformClassAction(s, "set-label-concurrency", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScheduledTaskLabelConcurrencyOptions struct {
+	}
+	R(&ScheduledTaskLabelConcurrencyOptions{}, "scheduledtask-label-concurrency", "Show concurrency limits of labels",
+		func(s *mcclient.ClientSession, args *ScheduledTaskLabelConcurrencyOptions) error {
+			ret, err := modules.ScheduledTask.Get(s, "label-concurrency", nil)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
 	type ScheduledTaskTriggerOptions struct {
 		ID string `help:"ScheduledTask ID or Name"`
 	}
@@ -233,4 +311,126 @@ func init() {
 			return nil
 		},
 	)
+
+	type ScheduledTaskCalendarListOptions struct {
+		options.BaseListOptions
+	}
+	R(&ScheduledTaskCalendarListOptions{}, "scheduledtask-calendar-list", "List Scheduled Task Calendar",
+		func(s *mcclient.ClientSession, args *ScheduledTaskCalendarListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			list, err := modules.ScheduledTaskCalendar.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(list, modules.ScheduledTaskCalendar.GetColumns(s))
+			return nil
+		},
+	)
+
+	type ScheduledTaskCalendarShowOptions struct {
+		ID string `help:"Calendar ID or Name"`
+	}
+	R(&ScheduledTaskCalendarShowOptions{}, "scheduledtask-calendar-show", "Show Scheduled Task Calendar",
+		func(s *mcclient.ClientSession, args *ScheduledTaskCalendarShowOptions) error {
+			ret, err := modules.ScheduledTaskCalendar.Get(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	parsePeriods := func(periods []string) ([]apis.CalendarPeriod, error) {
+		formatStr := "2006-01-02 15:04:05"
+		ret := make([]apis.CalendarPeriod, 0, len(periods))
+		for _, period := range periods {
+			parts := strings.SplitN(period, ",", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid period %q, format:'2006-01-02 15:04:05,2006-01-02 15:04:05'", period)
+			}
+			start, err := time.Parse(formatStr, parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid time format for period start %q", parts[0])
+			}
+			end, err := time.Parse(formatStr, parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid time format for period end %q", parts[1])
+			}
+			ret = append(ret, apis.CalendarPeriod{StartTime: start, EndTime: end})
+		}
+		return ret, nil
+	}
+
+	type ScheduledTaskCalendarCreateOptions struct {
+		NAME     string   `help:"Calendar Name"`
+		Timezone string   `help:"timezone of dates, e.g. Asia/Shanghai"`
+		Date     []string `help:"whole day to skip, format:'2006-01-02'"`
+		Period   []string `help:"period to skip in UTC, format:'2006-01-02 15:04:05,2006-01-02 15:04:05'"`
+	}
+	R(&ScheduledTaskCalendarCreateOptions{}, "scheduledtask-calendar-create", "Create Scheduled Task Calendar",
+		func(s *mcclient.ClientSession, args *ScheduledTaskCalendarCreateOptions) error {
+			periods, err := parsePeriods(args.Period)
+			if err != nil {
+				return err
+			}
+			input := apis.ScheduledTaskCalendarCreateInput{
+				Timezone: args.Timezone,
+				Dates:    args.Date,
+				Periods:  periods,
+			}
+			input.Name = args.NAME
+			ret, err := modules.ScheduledTaskCalendar.Create(s, jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScheduledTaskCalendarUpdateOptions struct {
+		ID       string   `help:"Calendar ID or Name"`
+		Timezone string   `help:"timezone of dates, e.g. Asia/Shanghai"`
+		Date     []string `help:"whole day to skip, format:'2006-01-02'"`
+		Period   []string `help:"period to skip in UTC, format:'2006-01-02 15:04:05,2006-01-02 15:04:05'"`
+	}
+	R(&ScheduledTaskCalendarUpdateOptions{}, "scheduledtask-calendar-update", "Update Scheduled Task Calendar",
+		func(s *mcclient.ClientSession, args *ScheduledTaskCalendarUpdateOptions) error {
+			params := jsonutils.NewDict()
+			if len(args.Timezone) > 0 {
+				params.Set("timezone", jsonutils.NewString(args.Timezone))
+			}
+			if len(args.Date) > 0 {
+				params.Set("dates", jsonutils.NewStringArray(args.Date))
+			}
+			if len(args.Period) > 0 {
+				periods, err := parsePeriods(args.Period)
+				if err != nil {
+					return err
+				}
+				params.Set("periods", jsonutils.Marshal(periods))
+			}
+			ret, err := modules.ScheduledTaskCalendar.Update(s, args.ID, params)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	R(&ScheduledTaskCalendarShowOptions{}, "scheduledtask-calendar-delete", "Delete Scheduled Task Calendar",
+		func(s *mcclient.ClientSession, args *ScheduledTaskCalendarShowOptions) error {
+			ret, err := modules.ScheduledTaskCalendar.Delete(s, args.ID, nil)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
 }
@@ -0,0 +1,97 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/webconsole"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type AccessRequestListOptions struct {
+		options.BaseListOptions
+
+		SessionId string   `help:"filter by webconsole session id"`
+		ObjId     string   `help:"filter by id of the requested resource"`
+		UserId    string   `help:"filter by id of the requester"`
+		Status    []string `help:"filter by status" choices:"pending|approved|rejected|expired"`
+	}
+	R(&AccessRequestListOptions{}, "webconsole-access-request-list", "List webconsole access requests", func(s *mcclient.ClientSession, args *AccessRequestListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		ret, err := webconsole.AccessRequest.List(s, params)
+		if err != nil {
+			return err
+		}
+		shell.PrintList(ret, webconsole.AccessRequest.GetColumns(s))
+		return nil
+	})
+
+	type AccessRequestIdOptions struct {
+		ID string `help:"ID of the access request"`
+	}
+	R(&AccessRequestIdOptions{}, "webconsole-access-request-show", "Show webconsole access request", func(s *mcclient.ClientSession, args *AccessRequestIdOptions) error {
+		ret, err := webconsole.AccessRequest.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		shell.PrintObject(ret)
+		return nil
+	})
+
+	type AccessRequestApproveOptions struct {
+		ID            string `help:"ID of the access request"`
+		Comment       string `help:"comment of the approval"`
+		AccessMinutes int    `help:"minutes the session is allowed to last"`
+	}
+	R(&AccessRequestApproveOptions{}, "webconsole-access-request-approve", "Approve webconsole access request", func(s *mcclient.ClientSession, args *AccessRequestApproveOptions) error {
+		params := jsonutils.NewDict()
+		if len(args.Comment) > 0 {
+			params.Add(jsonutils.NewString(args.Comment), "comment")
+		}
+		if args.AccessMinutes > 0 {
+			params.Add(jsonutils.NewInt(int64(args.AccessMinutes)), "access_minutes")
+		}
+		ret, err := webconsole.AccessRequest.PerformAction(s, args.ID, "approve", params)
+		if err != nil {
+			return err
+		}
+		shell.PrintObject(ret)
+		return nil
+	})
+
+	type AccessRequestRejectOptions struct {
+		ID      string `help:"ID of the access request"`
+		Comment string `help:"comment of the rejection"`
+	}
+	R(&AccessRequestRejectOptions{}, "webconsole-access-request-reject", "Reject webconsole access request", func(s *mcclient.ClientSession, args *AccessRequestRejectOptions) error {
+		params := jsonutils.NewDict()
+		if len(args.Comment) > 0 {
+			params.Add(jsonutils.NewString(args.Comment), "comment")
+		}
+		ret, err := webconsole.AccessRequest.PerformAction(s, args.ID, "reject", params)
+		if err != nil {
+			return err
+		}
+		shell.PrintObject(ret)
+		return nil
+	})
+}
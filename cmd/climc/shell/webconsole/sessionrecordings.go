@@ -0,0 +1,95 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"io"
+	"os"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/webconsole"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type SessionRecordingListOptions struct {
+		options.BaseListOptions
+
+		SessionId string   `help:"filter by webconsole session id"`
+		ObjId     string   `help:"filter by id of the connected resource"`
+		ObjType   string   `help:"filter by type of the connected resource"`
+		UserId    string   `help:"filter by id of the user"`
+		Format    []string `help:"filter by recording format" choices:"asciicast|guacamole|fbs"`
+		Protocol  []string `help:"filter by connection protocol"`
+	}
+	R(&SessionRecordingListOptions{}, "webconsole-session-recording-list", "List webconsole session recordings", func(s *mcclient.ClientSession, args *SessionRecordingListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		ret, err := webconsole.SessionRecording.List(s, params)
+		if err != nil {
+			return err
+		}
+		shell.PrintList(ret, webconsole.SessionRecording.GetColumns(s))
+		return nil
+	})
+
+	type SessionRecordingIdOptions struct {
+		ID string `help:"ID of the session recording"`
+	}
+	R(&SessionRecordingIdOptions{}, "webconsole-session-recording-show", "Show webconsole session recording", func(s *mcclient.ClientSession, args *SessionRecordingIdOptions) error {
+		ret, err := webconsole.SessionRecording.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		shell.PrintObject(ret)
+		return nil
+	})
+
+	R(&SessionRecordingIdOptions{}, "webconsole-session-recording-delete", "Delete webconsole session recording", func(s *mcclient.ClientSession, args *SessionRecordingIdOptions) error {
+		ret, err := webconsole.SessionRecording.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		shell.PrintObject(ret)
+		return nil
+	})
+
+	type SessionRecordingPlaybackOptions struct {
+		ID     string `help:"ID of the session recording"`
+		OUTPUT string `help:"file to save the recording, asciicast can be played by 'asciinema play'"`
+	}
+	R(&SessionRecordingPlaybackOptions{}, "webconsole-session-recording-playback", "Download the recorded stream of a webconsole session", func(s *mcclient.ClientSession, args *SessionRecordingPlaybackOptions) error {
+		reader, err := webconsole.SessionRecording.Playback(s, args.ID)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		f, err := os.Create(args.OUTPUT)
+		if err != nil {
+			return errors.Wrapf(err, "os.Create(%s)", args.OUTPUT)
+		}
+		defer f.Close()
+		if _, err := io.Copy(f, reader); err != nil {
+			return errors.Wrapf(err, "save recording to %s", args.OUTPUT)
+		}
+		return nil
+	})
+}
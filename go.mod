@@ -26,6 +26,7 @@ require (
 	github.com/go-yaml/yaml v2.1.0+incompatible
 	github.com/golang-plus/uuid v1.0.0
 	github.com/golang/mock v1.4.4
+	github.com/golang/snappy v0.0.1
 	github.com/google/gopacket v1.1.17
 	github.com/google/uuid v1.3.0
 	github.com/googollee/go-socket.io v0.0.0-20181214084611-0ad7206c347a
@@ -178,7 +179,6 @@ require (
 	github.com/golang-plus/errors v1.0.0 // indirect
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/golang/snappy v0.0.1 // indirect
 	github.com/google/btree v1.0.1 // indirect
 	github.com/google/go-querystring v1.0.0 // indirect
 	github.com/google/gofuzz v1.1.0 // indirect
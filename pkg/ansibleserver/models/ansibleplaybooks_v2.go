@@ -15,6 +15,7 @@
 package models
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
@@ -50,6 +51,19 @@ type SAnsiblePlaybookV2 struct {
 	EndTime      time.Time `list:"user"`
 
 	CreatorMark string `length:"32" nullable:"false" create:"optional" get:"user"`
+
+	// RequireApproval makes the playbook planned on creation and run only
+	// after the plan is approved
+	RequireApproval bool      `nullable:"false" default:"false" create:"optional" list:"user"`
+	Plan            string    `length:"medium" get:"user"`
+	PlannedAt       time.Time `list:"user"`
+	// RequesterId is the user requesting the plan, who is not allowed to
+	// approve it
+	RequesterId string    `width:"128" charset:"ascii" list:"user"`
+	Requester   string    `width:"128" charset:"utf8" list:"user"`
+	ApproverId  string    `width:"128" charset:"ascii" list:"user"`
+	Approver    string    `width:"128" charset:"utf8" list:"user"`
+	ApprovedAt  time.Time `list:"user"`
 }
 
 type SAnsiblePlaybookV2Manager struct {
@@ -92,7 +106,7 @@ func (man *SAnsiblePlaybookV2Manager) ValidateCreateData(ctx context.Context, us
 
 func (apb *SAnsiblePlaybookV2) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	apb.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
-	err := apb.runPlaybook(ctx, userCred)
+	err := apb.runPlaybook(ctx, userCred, apb.RequireApproval)
 	if err != nil {
 		log.Errorf("postCreate: runPlaybook: %v", err)
 	}
@@ -101,7 +115,10 @@ func (apb *SAnsiblePlaybookV2) PostCreate(ctx context.Context, userCred mcclient
 func (man *SAnsiblePlaybookV2Manager) InitializeData() error {
 	pbs := []SAnsiblePlaybookV2{}
 	q := AnsiblePlaybookV2Manager.Query()
-	q = q.Filter(sqlchemy.Equals(q.Field("status"), api.AnsiblePlaybookStatusRunning))
+	q = q.Filter(sqlchemy.In(q.Field("status"), []string{
+		api.AnsiblePlaybookStatusRunning,
+		api.AnsiblePlaybookStatusPlanning,
+	}))
 	if err := db.FetchModelObjects(AnsiblePlaybookV2Manager, q, &pbs); err != nil {
 		return errors.WithMessage(err, "fetch running playbooks")
 	}
@@ -119,20 +136,82 @@ func (man *SAnsiblePlaybookV2Manager) InitializeData() error {
 }
 
 func (apb *SAnsiblePlaybookV2) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
-	if apb.Status == api.AnsiblePlaybookStatusRunning {
-		return httperrors.NewConflictError("playbook is in running state")
+	if apb.Status == api.AnsiblePlaybookStatusRunning || apb.Status == api.AnsiblePlaybookStatusPlanning {
+		return httperrors.NewConflictError("playbook is in %s state", apb.Status)
 	}
 	return nil
 }
 
 func (apb *SAnsiblePlaybookV2) PerformRun(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	err := apb.runPlaybook(ctx, userCred)
+	if apb.RequireApproval {
+		return nil, httperrors.NewForbiddenError("playbook requires approval of its plan to run")
+	}
+	err := apb.runPlaybook(ctx, userCred, false)
 	if err != nil {
 		return nil, httperrors.NewConflictError("%s", err.Error())
 	}
 	return nil, nil
 }
 
+// PerformPlan runs the playbook with --check --diff and records the changes
+// it would make on each host
+func (apb *SAnsiblePlaybookV2) PerformPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := apb.runPlaybook(ctx, userCred, true)
+	if err != nil {
+		return nil, httperrors.NewConflictError("%s", err.Error())
+	}
+	return nil, nil
+}
+
+// PerformApprove runs the playbook for real after its plan is reviewed
+func (apb *SAnsiblePlaybookV2) PerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if apb.Status != api.AnsiblePlaybookStatusPlanned {
+		return nil, httperrors.NewInvalidStatusError("playbook is in %s state, only planned playbook can be approved", apb.Status)
+	}
+	if apb.RequesterId == userCred.GetUserId() {
+		return nil, httperrors.NewForbiddenError("playbook plan can not be approved by its requester %s", apb.Requester)
+	}
+	_, err := db.Update(apb, func() error {
+		apb.ApproverId = userCred.GetUserId()
+		apb.Approver = userCred.GetUserName()
+		apb.ApprovedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "record approval")
+	}
+	db.OpsLog.LogEvent(apb, db.ACT_APPROVE, apb.PlannedAt, userCred)
+	err = apb.runPlaybook(ctx, userCred, false)
+	if err != nil {
+		return nil, httperrors.NewConflictError("%s", err.Error())
+	}
+	return nil, nil
+}
+
+func (apb *SAnsiblePlaybookV2) GetDetailsPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (api.AnsiblePlaybookV2PlanOutput, error) {
+	output := api.AnsiblePlaybookV2PlanOutput{
+		Status:      apb.Status,
+		PlannedAt:   apb.PlannedAt,
+		RequesterId: apb.RequesterId,
+		Requester:   apb.Requester,
+		ApproverId:  apb.ApproverId,
+		Approver:    apb.Approver,
+		ApprovedAt:  apb.ApprovedAt,
+		Hosts:       []ansiblev2.PlaybookHostResult{},
+	}
+	if apb.Plan == "" {
+		return output, httperrors.NewNotFoundError("playbook has not been planned")
+	}
+	obj, err := jsonutils.ParseString(apb.Plan)
+	if err != nil {
+		return output, errors.WithMessage(err, "parse plan")
+	}
+	if err := obj.Unmarshal(&output.Hosts); err != nil {
+		return output, errors.WithMessage(err, "unmarshal plan")
+	}
+	return output, nil
+}
+
 func (apb *SAnsiblePlaybookV2) PerformStop(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
 	err := apb.stopPlaybook(ctx, userCred)
 	if err != nil {
@@ -141,7 +220,9 @@ func (apb *SAnsiblePlaybookV2) PerformStop(ctx context.Context, userCred mcclien
 	return nil, nil
 }
 
-func (apb *SAnsiblePlaybookV2) runPlaybook(ctx context.Context, userCred mcclient.TokenCredential) error {
+// runPlaybook runs the playbook in background.  In check mode the per host
+// changes reported by the dry run are recorded as plan of the playbook
+func (apb *SAnsiblePlaybookV2) runPlaybook(ctx context.Context, userCred mcclient.TokenCredential, checkMode bool) error {
 	man := AnsiblePlaybookV2Manager
 	man.sessionsMux.Lock()
 	defer man.sessionsMux.Unlock()
@@ -183,7 +264,18 @@ func (apb *SAnsiblePlaybookV2) runPlaybook(ctx context.Context, userCred mcclien
 		apb.StartTime = time.Now()
 		apb.EndTime = time.Time{}
 		apb.Output = ""
-		apb.Status = api.AnsiblePlaybookStatusRunning
+		if checkMode {
+			apb.Status = api.AnsiblePlaybookStatusPlanning
+			apb.Plan = ""
+			apb.PlannedAt = time.Time{}
+			apb.RequesterId = userCred.GetUserId()
+			apb.Requester = userCred.GetUserName()
+			apb.ApproverId = ""
+			apb.Approver = ""
+			apb.ApprovedAt = time.Time{}
+		} else {
+			apb.Status = api.AnsiblePlaybookStatusRunning
+		}
 		return nil
 	})
 	if err != nil {
@@ -200,6 +292,10 @@ func (apb *SAnsiblePlaybookV2) runPlaybook(ctx context.Context, userCred mcclien
 		KeepTmpdir(options.Options.KeepTmpdir).
 		RolePublic(options.Options.RolePublic).
 		Timeout(options.Options.Timeout)
+	result := &bytes.Buffer{}
+	if checkMode {
+		sess.CheckMode(true).ResultWriter(result)
+	}
 	man.sessions.Add(apb.Id, sess)
 
 	// NOTE host state check? run only on online hosts and running guests, skip others
@@ -211,9 +307,39 @@ func (apb *SAnsiblePlaybookV2) runPlaybook(ctx context.Context, userCred mcclien
 		}()
 		runErr := man.sessions.Run(apb.Id)
 
+		var (
+			plan    []ansiblev2.PlaybookHostResult
+			planErr error
+		)
+		if checkMode {
+			// stdout of check mode is json result.  Render it as
+			// summary of changes for the output, or keep it as is for
+			// debugging when it can not be parsed
+			summary := result.String()
+			plan, planErr = ansiblev2.ParsePlaybookResult(result.Bytes())
+			if planErr != nil {
+				log.Warningf("playbook %s(%s) plan: %v", apb.Name, apb.Id, planErr)
+			} else {
+				summary = ansiblev2.FormatPlaybookResult(plan)
+			}
+			w := &ansiblePlaybookOutputWriter{apb}
+			w.Write([]byte(summary))
+		}
 		_, err := db.Update(apb, func() error {
 			err := man.sessions.Err(apb.Id)
-			if err != nil {
+			if checkMode && err == nil {
+				// failed tasks of the dry run are kept in plan for review
+				if planErr == nil {
+					apb.Plan = jsonutils.Marshal(plan).String()
+					apb.PlannedAt = time.Now()
+				}
+				if runErr != nil || planErr != nil {
+					log.Warningf("playbook %s(%s) plan failed: %v", apb.Name, apb.Id, runErr)
+					apb.Status = api.AnsiblePlaybookStatusPlanFailed
+				} else {
+					apb.Status = api.AnsiblePlaybookStatusPlanned
+				}
+			} else if err != nil {
 				apb.Status = api.AnsiblePlaybookStatusCanceled
 			} else if runErr != nil {
 				log.Warningf("playbook %s(%s) failed: %v", apb.Name, apb.Id, runErr)
@@ -120,12 +120,20 @@ func (h *APIHelper) Start(ctx context.Context, app *appsrv.Application, prefix s
 func (h *APIHelper) scheduleSync() {
 	if h.tick != nil {
 		if !h.tick.Stop() {
-			<-h.tick.C
+			select {
+			case <-h.tick.C:
+			default:
+			}
 		}
 		h.tick.Reset(h.getRunDelay())
 	}
 }
 
+// ScheduleSync requests a sync from api after the run delay
+func (h *APIHelper) ScheduleSync() {
+	h.scheduleSync()
+}
+
 func (h *APIHelper) ModelSets() <-chan IModelSets {
 	return h.modelSetsCh
 }
@@ -186,6 +186,16 @@ type ModelSetUpdateResult struct {
 //   - PendingDeleted in bSet are removed from aSet
 //   - Newer models in bSet are updated in aSet
 func ModelSetApplyUpdates(aSet, bSet IModelSet) *ModelSetUpdateResult {
+	return modelSetApply(aSet, bSet, true)
+}
+
+// ModelSetApplyPatch is like ModelSetApplyUpdates except that members of aSet
+// absent from bSet are kept.  bSet is usually made of changed models only
+func ModelSetApplyPatch(aSet, bSet IModelSet) *ModelSetUpdateResult {
+	return modelSetApply(aSet, bSet, false)
+}
+
+func modelSetApply(aSet, bSet IModelSet, sweep bool) *ModelSetUpdateResult {
 	r := &ModelSetUpdateResult{
 		Changed: false,
 	}
@@ -236,6 +246,9 @@ func ModelSetApplyUpdates(aSet, bSet IModelSet) *ModelSetUpdateResult {
 			r.Changed = true
 		}
 	}
+	if !sweep {
+		return r
+	}
 	for _, kRv := range aSetRv.MapKeys() {
 		bMRv := bSetRv.MapIndex(kRv)
 		if !bMRv.IsValid() { // alread deleted
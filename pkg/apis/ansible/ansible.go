@@ -15,10 +15,13 @@
 package ansible
 
 import (
+	"time"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/util/ansible"
+	"yunion.io/x/onecloud/pkg/util/ansiblev2"
 )
 
 type AnsiblePlaybookCreateInput struct {
@@ -66,3 +69,15 @@ type AnsiblePlaybookInstanceListInput struct {
 	apis.StatusStandaloneResourceListInput
 	AnsiblePlayboookReferenceId string
 }
+
+type AnsiblePlaybookV2PlanOutput struct {
+	Status      string    `json:"status"`
+	PlannedAt   time.Time `json:"planned_at"`
+	RequesterId string    `json:"requester_id"`
+	Requester   string    `json:"requester"`
+	ApproverId  string    `json:"approver_id"`
+	Approver    string    `json:"approver"`
+	ApprovedAt  time.Time `json:"approved_at"`
+
+	Hosts []ansiblev2.PlaybookHostResult `json:"hosts"`
+}
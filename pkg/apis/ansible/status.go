@@ -21,4 +21,9 @@ const (
 	AnsiblePlaybookStatusFailed    = "failed"
 	AnsiblePlaybookStatusCanceled  = "canceled"
 	AnsiblePlaybookStatusUnknown   = "unknown"
+
+	// plan is a dry run of playbook with --check --diff
+	AnsiblePlaybookStatusPlanning   = "planning"
+	AnsiblePlaybookStatusPlanned    = "planned"
+	AnsiblePlaybookStatusPlanFailed = "plan_failed"
 )
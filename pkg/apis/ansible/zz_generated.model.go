@@ -60,4 +60,14 @@ type SAnsiblePlaybookV2 struct {
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	CreatorMark  string    `json:"creator_mark"`
+	// RequireApproval makes the playbook planned on creation and run only
+	// after the plan is approved
+	RequireApproval bool      `json:"require_approval"`
+	Plan            string    `json:"plan"`
+	PlannedAt       time.Time `json:"planned_at"`
+	RequesterId     string    `json:"requester_id"`
+	Requester       string    `json:"requester"`
+	ApproverId      string    `json:"approver_id"`
+	Approver        string    `json:"approver"`
+	ApprovedAt      time.Time `json:"approved_at"`
 }
@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudnet
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	WG_HEALTH_STATUS_OK      = "ok"
+	WG_HEALTH_STATUS_STALE   = "stale"
+	WG_HEALTH_STATUS_UNKNOWN = "unknown"
+	// peer without persistent keepalive and traffic only handshakes on demand
+	WG_HEALTH_STATUS_IDLE = "idle"
+
+	WG_PEER_STALE = "wireguard_peer_stale"
+)
+
+type MeshNetworkUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	KeyRotationIntervalDays *int `json:"key_rotation_interval_days"`
+}
+
+type RouterWireguardPeer struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	IfaceId      string `json:"iface_id"`
+	PeerRouterId string `json:"peer_router_id"`
+	Endpoint     string `json:"endpoint"`
+
+	LatestHandshake time.Time `json:"latest_handshake"`
+	// seconds since latest handshake, -1 if no handshake ever
+	HandshakeAge int64  `json:"handshake_age"`
+	RxBytes      int64  `json:"rx_bytes"`
+	TxBytes      int64  `json:"tx_bytes"`
+	HealthStatus string `json:"health_status"`
+}
+
+type RouterWireguardPeersOutput struct {
+	HealthStatus    string    `json:"health_status"`
+	HealthCheckedAt time.Time `json:"health_checked_at"`
+
+	Peers []RouterWireguardPeer `json:"peers"`
+}
@@ -438,6 +438,23 @@ type ServerCreateInput struct {
 	VmemSize       int  `json:"vmem_size"`
 	EnableMemclean bool `json:"enable_memclean"`
 
+	// 启用虚拟TPM 2.0设备, 仅KVM支持
+	EnableTpm bool `json:"enable_tpm"`
+	// 启用UEFI安全启动, 需要bios为UEFI, 仅KVM支持
+	SecureBoot bool `json:"secure_boot"`
+	// 启用virtio-balloon及free page reporting, 宿主机内存紧张时回收虚拟机空闲内存, 仅KVM支持
+	EnableBalloon bool `json:"enable_balloon"`
+	// 气球回收时保证虚拟机的最小内存,单位Mb, 默认由宿主机配置决定
+	BalloonMinMem int `json:"balloon_min_mem"`
+	// 使用virtio-mem提供内存, 支持在线扩容及缩容内存, 仅KVM支持
+	EnableVirtioMem bool `json:"enable_virtio_mem"`
+	// 硬件看门狗型号, 仅KVM支持
+	// enum: i6300esb, ib700
+	Watchdog string `json:"watchdog"`
+	// 看门狗超时或虚拟机内核崩溃时宿主机执行的动作, 默认none仅通知
+	// enum: reset, dump, poweroff, none
+	CrashAction string `json:"crash_action"`
+
 	// 虚拟机Cpu大小,若未指定instance_type,此参数为必传项
 	// default: 1
 	VcpuCount int `json:"vcpu_count"`
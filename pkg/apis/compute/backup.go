@@ -25,6 +25,7 @@ type TBackupStorageType string
 const (
 	BACKUPSTORAGE_TYPE_NFS            = TBackupStorageType("nfs")
 	BACKUPSTORAGE_TYPE_OBJECT_STORAGE = TBackupStorageType("object")
+	BACKUPSTORAGE_TYPE_DEDUP          = TBackupStorageType("dedup")
 
 	BACKUPSTORAGE_STATUS_ONLINE  = "online"
 	BACKUPSTORAGE_STATUS_OFFLINE = "offline"
@@ -46,6 +47,9 @@ const (
 
 	BACKUP_EXIST     = "exist"
 	BACKUP_NOT_EXIST = "not_exist"
+
+	BACKUP_MODE_FULL        = "full"
+	BACKUP_MODE_INCREMENTAL = "incremental"
 )
 
 const (
@@ -56,7 +60,7 @@ type BackupStorageCreateInput struct {
 	apis.EnabledStatusInfrasResourceBaseCreateInput
 
 	// description: storage type
-	// enum: nfs
+	// enum: nfs,object,dedup
 	StorageType string `json:"storage_type"`
 
 	SBackupStorageAccessInfo
@@ -98,6 +102,11 @@ type DiskBackupListInput struct {
 	IsInstanceBackup *bool `json:"is_instance_backup"`
 	// 按硬盘名称排序
 	OrderByDiskName string `json:"order_by_disk_name"`
+	// description: parent backup id of incremental backup
+	ParentBackupId string `json:"parent_backup_id"`
+	// description: backup mode
+	// enum: full,incremental
+	BackupMode string `json:"backup_mode"`
 }
 
 type DiskBackupDetails struct {
@@ -112,6 +121,10 @@ type DiskBackupDetails struct {
 	BackupStorageName string `json:"backup_storage_name"`
 	// description: 是否是子备份
 	IsSubBackup bool `json:"is_sub_backup"`
+	// description: parent backup name of incremental backup
+	ParentBackupName string `json:"parent_backup_name"`
+	// description: backup ids from the full backup to this backup
+	BackupChain []string `json:"backup_chain"`
 }
 
 type DiskBackupCreateInput struct {
@@ -122,6 +135,10 @@ type DiskBackupCreateInput struct {
 	DiskId string `json:"disk_id"`
 	// description: backup storage id
 	BackupStorageId string `json:"back_storage_id"`
+	// description: 增量备份的父备份, 只保存相对父备份变化的数据块
+	ParentBackupId string `json:"parent_backup_id"`
+	// description: 增量备份, 未指定parent_backup_id时以该磁盘在同一备份存储上最近的备份为父备份
+	Incremental bool `json:"incremental"`
 	// swagger: ignore
 	CloudregionId string `json:"cloudregion_id"`
 	// swagger:ignore
@@ -146,6 +163,16 @@ type DiskBackupPackMetadata struct {
 	DiskConfig *SBackupDiskConfig
 }
 
+// InstanceBackupVtpmInput locates vTPM state of guest in backup storage
+type InstanceBackupVtpmInput struct {
+	// name of the state in backup storage
+	VtpmBackupId            string              `json:"vtpm_backup_id"`
+	BackupStorageId         string              `json:"backup_storage_id"`
+	BackupStorageAccessInfo *jsonutils.JSONDict `json:"backup_storage_access_info"`
+	// key of encrypted instance backup, the state is encrypted with it
+	EncryptInfo *apis.SEncryptInfo `json:"encrypt_info"`
+}
+
 type InstanceBackupPackMetadata struct {
 	OsArch         string
 	ServerConfig   jsonutils.JSONObject
@@ -167,11 +194,11 @@ type InstanceBackupManagerSyncstatusInput struct {
 }
 
 type SBackupStorageAccessInfo struct {
-	// description: host of nfs, storage_type 为 nfs 时, 此参数必传
+	// description: host of nfs, storage_type 为 nfs 或 dedup 时, 此参数必传
 	// example: 192.168.222.2
 	NfsHost string `json:"nfs_host"`
 
-	// description: shared dir of nfs, storage_type 为 nfs 时, 此参数必传
+	// description: shared dir of nfs, storage_type 为 nfs 或 dedup 时, 此参数必传
 	// example: /nfs_root/
 	NfsSharedDir string `json:"nfs_shared_dir"`
 
@@ -182,6 +209,9 @@ type SBackupStorageAccessInfo struct {
 	ObjectAccessKey string `json:"object_access_key"`
 	// description: secret of object storage
 	ObjectSecret string `json:"object_secret"`
+
+	// description: key to encrypt chunks of dedup backup storage, generated on creation
+	DedupEncryptKey string `json:"dedup_encrypt_key"`
 }
 
 func (ba *SBackupStorageAccessInfo) String() string {
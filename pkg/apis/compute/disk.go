@@ -313,6 +313,8 @@ type DiskAllocateFromBackupInput struct {
 	BackupId                string
 	BackupStorageId         string
 	BackupStorageAccessInfo *jsonutils.JSONDict
+	// backup ids from the full backup to BackupId, empty for full backup
+	BackupChain []string
 }
 
 type DiskDeleteInput struct {
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "time"
+
+const (
+	FLOW_LOG_VERDICT_ALLOW  = "allow"
+	FLOW_LOG_VERDICT_DROP   = "drop"
+	FLOW_LOG_VERDICT_REJECT = "reject"
+
+	FLOW_LOG_DEFAULT_LIMIT = 100
+	// max number of hosts the flow logs of a server are queried from
+	FLOW_LOG_MAX_HOSTS = 16
+)
+
+// ServerFlowLog is a flow matched by the acl of a security group rule with
+// flow log enabled
+type ServerFlowLog struct {
+	Time time.Time `json:"time"`
+	// 匹配的安全组规则
+	SecgroupRuleId string `json:"secgrouprule_id"`
+	// allow|drop|reject
+	Verdict  string `json:"verdict"`
+	Severity string `json:"severity"`
+	// 相对于虚拟机的方向
+	// in|out
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+
+	SrcMac  string `json:"src_mac"`
+	DstMac  string `json:"dst_mac"`
+	SrcIp   string `json:"src_ip"`
+	DstIp   string `json:"dst_ip"`
+	SrcPort int    `json:"src_port,omitzero"`
+	DstPort int    `json:"dst_port,omitzero"`
+}
+
+type ServerFlowLogInput struct {
+	// 按结果过滤
+	// enum: allow,drop,reject
+	Verdict string `json:"verdict"`
+	// 只返回此时间之后的流量
+	Since time.Time `json:"since"`
+	// 最多返回的条数, 默认100
+	Limit int `json:"limit"`
+	// 虚拟机的mac地址, 由控制节点在查询虚拟机迁移前所在宿主机时填写
+	Macs []string `json:"macs"`
+}
+
+type ServerFlowLogOutput struct {
+	Data []ServerFlowLog `json:"data"`
+}
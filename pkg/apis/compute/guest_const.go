@@ -438,8 +438,39 @@ const (
 	VM_METADATA_HOT_REMOVE_NIC      = "hot_remove_nic"
 	VM_METADATA_START_VMEM_MB       = "start_vmem_mb"
 	VM_METADATA_START_VCPU_COUNT    = "start_vcpu_count"
+	VM_METADATA_ENABLE_TPM          = "enable_tpm"
+	VM_METADATA_SECURE_BOOT         = "secure_boot"
+	VM_METADATA_ENABLE_BALLOON      = "enable_balloon"
+	// minimum memory in MB guaranteed to guest when host inflates its balloon
+	VM_METADATA_BALLOON_MIN_MEM   = "balloon_min_mem"
+	VM_METADATA_ENABLE_VIRTIO_MEM = "enable_virtio_mem"
+	// reported by host, enable when guest memory can be resized online through virtio-mem
+	VM_METADATA_HOT_RESIZE_MEM = "hot_resize_mem"
+	// hardware watchdog device model
+	VM_METADATA_WATCHDOG = "watchdog"
+	// action taken by host when guest watchdog expires or guest panicked
+	VM_METADATA_CRASH_ACTION = "crash_action"
+	// on instance backup, name of the vTPM state saved in its backup storage
+	VM_METADATA_VTPM_BACKUP = "__vtpm_backup"
+	// on guest, instance backup whose vTPM state is restored on creation
+	VM_METADATA_VTPM_RESTORE_FROM = "__vtpm_restore_from"
+	// hosts the guest ran on before, latest first, where its flow logs are kept
+	VM_METADATA_FLOW_LOG_HOSTS = "__flow_log_hosts"
 )
 
+const (
+	GUEST_WATCHDOG_I6300ESB = "i6300esb"
+	GUEST_WATCHDOG_IB700    = "ib700"
+
+	GUEST_CRASH_ACTION_RESET    = "reset"
+	GUEST_CRASH_ACTION_DUMP     = "dump"
+	GUEST_CRASH_ACTION_POWEROFF = "poweroff"
+	GUEST_CRASH_ACTION_NONE     = "none"
+)
+
+var GUEST_WATCHDOG_MODELS = []string{GUEST_WATCHDOG_I6300ESB, GUEST_WATCHDOG_IB700}
+var GUEST_CRASH_ACTIONS = []string{GUEST_CRASH_ACTION_RESET, GUEST_CRASH_ACTION_DUMP, GUEST_CRASH_ACTION_POWEROFF, GUEST_CRASH_ACTION_NONE}
+
 func Hypervisors2HostTypes(hypervisors []string) []string {
 	hostTypes := make([]string, len(hypervisors))
 	for i := range hypervisors {
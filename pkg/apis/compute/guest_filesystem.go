@@ -0,0 +1,79 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	GUEST_FILESYSTEM_SOURCE_HOST_PATH = "host_path"
+	GUEST_FILESYSTEM_SOURCE_STORAGE   = "storage"
+
+	GUEST_FILESYSTEM_STATUS_READY     = "ready"
+	GUEST_FILESYSTEM_STATUS_ATTACHING = "attaching"
+	GUEST_FILESYSTEM_STATUS_DETACHING = "detaching"
+
+	// directory of storage holding per project shared filesystems
+	GUEST_FILESYSTEM_PROJECT_DIR = "guest_filesystems"
+
+	// virtio-fs mount tag is limited to 36 bytes inside the guest kernel
+	GUEST_FILESYSTEM_MOUNT_TAG_MAX_LEN = 36
+)
+
+type GuestFilesystemCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 挂载的虚拟机
+	GuestId string `json:"guest_id"`
+	// 共享目录来源, host_path 或 storage
+	// enum: ["host_path", "storage"]
+	SourceType string `json:"source_type"`
+	// 宿主机上的共享目录(仅管理员可用), source_type 为 host_path 时必填
+	HostPath string `json:"host_path"`
+	// 共享文件存储(NFS/GPFS), source_type 为 storage 时必填
+	StorageId string `json:"storage_id"`
+	// 存储内的子目录, 非管理员的子目录位于存储的 guest_filesystems/<项目ID> 目录下
+	SubPath string `json:"sub_path"`
+	// virtio-fs mount tag, 默认使用资源名称
+	MountTag string `json:"mount_tag"`
+	// 虚拟机内自动挂载的目录, 为空则不自动挂载
+	MountPoint string `json:"mount_point"`
+	// 只读共享
+	ReadOnly bool `json:"read_only"`
+}
+
+type GuestFilesystemListInput struct {
+	apis.VirtualResourceListInput
+
+	GuestId   string `json:"guest_id"`
+	StorageId string `json:"storage_id"`
+}
+
+type GuestFilesystemDetails struct {
+	apis.VirtualResourceDetails
+
+	Guest   string `json:"guest"`
+	Storage string `json:"storage"`
+}
+
+type GuestFilesystemJsonDesc struct {
+	Id         string `json:"id"`
+	SourceType string `json:"source_type"`
+	HostPath   string `json:"host_path"`
+	StorageId  string `json:"storage_id"`
+	SubPath    string `json:"sub_path"`
+	MountTag   string `json:"mount_tag"`
+	MountPoint string `json:"mount_point"`
+	ReadOnly   bool   `json:"read_only"`
+}
@@ -862,6 +862,8 @@ type GuestJsonDesc struct {
 
 	Floppys []*GuestfloppyJsonDesc `json:"floppys"`
 
+	Filesystems []*GuestFilesystemJsonDesc `json:"filesystems"`
+
 	Tenant        string `json:"tenant"`
 	TenantId      string `json:"tenant_id"`
 	DomainId      string `json:"domain_id"`
@@ -515,6 +515,28 @@ type HostOfflineInput struct {
 	Reason             string
 }
 
+type HostHardwareComponent struct {
+	// 组件类型, dimm|drive|firmware|power_supply
+	Type         string `json:"type"`
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	SerialNumber string `json:"serial_number"`
+	Version      string `json:"version"`
+	CapacityMb   int64  `json:"capacity_mb"`
+	State        string `json:"state"`
+	Health       string `json:"health"`
+}
+
+type HostSyncHardwareInventoryInput struct {
+	// 硬件整体健康状态, OK|Warning|Critical
+	Health     string                  `json:"health"`
+	Components []HostHardwareComponent `json:"components"`
+	// 采集失败的组件类型, 这些类型保留上次同步的组件及健康状态
+	FailedTypes []string `json:"failed_types"`
+}
+
 type SHostStorageStat struct {
 	StorageId string `json:"storage_id"`
 
@@ -526,6 +548,8 @@ type SHostPingInput struct {
 	WithData bool `json:"with_data"`
 
 	MemoryUsedMb int `json:"memory_used_mb"`
+	// memory reclaimable from guests by inflating balloons, zero if host is under memory pressure
+	MemoryBalloonHeadroomMb int `json:"memory_balloon_headroom_mb"`
 
 	RootPartitionUsedCapacityMb int `json:"root_partition_used_capacity_mb"`
 
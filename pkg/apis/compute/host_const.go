@@ -184,3 +184,23 @@ const (
 const (
 	HOSTMETA_RESERVED_CPUS_INFO = "reserved_cpus_info"
 )
+
+const (
+	HOSTMETA_HARDWARE_INVENTORY = "__hardware_inventory"
+	HOSTMETA_HARDWARE_HEALTH    = "hardware_health"
+
+	HOST_HARDWARE_HEALTH_OK       = "OK"
+	HOST_HARDWARE_HEALTH_WARNING  = "Warning"
+	HOST_HARDWARE_HEALTH_CRITICAL = "Critical"
+
+	HOST_HARDWARE_DIMM         = "dimm"
+	HOST_HARDWARE_DRIVE        = "drive"
+	HOST_HARDWARE_FIRMWARE     = "firmware"
+	HOST_HARDWARE_POWER_SUPPLY = "power_supply"
+)
+
+var HOST_HARDWARE_HEALTHS = []string{
+	HOST_HARDWARE_HEALTH_OK,
+	HOST_HARDWARE_HEALTH_WARNING,
+	HOST_HARDWARE_HEALTH_CRITICAL,
+}
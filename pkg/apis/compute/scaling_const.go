@@ -28,6 +28,18 @@ const (
 	TRIGGER_ALARM  = "alarm"  // 告警
 	TRIGGER_TIMING = "timing" // 定时
 	TRIGGER_CYCLE  = "cycle"  // 周期定时
+	// keep the metric of the scaling group around the target value
+	TRIGGER_TARGET_TRACKING = "target_tracking" // 目标追踪
+	// threshold on any measurement in the monitor tsdb
+	TRIGGER_METRIC = "metric" // 自定义指标
+
+	PREDICTIVE_MODE_OFF                = "off"                // 关闭预测
+	PREDICTIVE_MODE_FORECAST_ONLY      = "forecast_only"      // 仅预测, 不扩容
+	PREDICTIVE_MODE_FORECAST_AND_SCALE = "forecast_and_scale" // 预测并提前扩容
+
+	SCALING_DECISION_NONE      = "none"
+	SCALING_DECISION_SCALE_OUT = "scale_out"
+	SCALING_DECISION_SCALE_IN  = "scale_in"
 
 	ACTION_ADD    = "add"    // 增加
 	ACTION_REMOVE = "remove" // 减少
@@ -14,7 +14,11 @@
 
 package compute
 
-import "yunion.io/x/onecloud/pkg/apis"
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
 
 type ScalingPolicyDetails struct {
 	apis.VirtualResourceDetails
@@ -26,6 +30,8 @@ type ScalingPolicyDetails struct {
 	CycleTimer CycleTimerDetails `json:"cycle_timer"`
 	//  告警方式触发
 	Alarm ScalingAlarmDetails `json:"alarm"`
+	// 指标方式触发(目标追踪或自定义指标)
+	Metric ScalingMetricDetails `json:"metric"`
 }
 
 type ScalingPolicyCreateInput struct {
@@ -40,12 +46,13 @@ type ScalingPolicyCreateInput struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 
 	// description: trigger type
-	// enum: timing,cycle,alarm
+	// enum: timing,cycle,alarm,target_tracking,metric
 	TriggerType string `json:"trigger_type"`
 
-	Timer      TimerCreateInput        `json:"timer"`
-	CycleTimer CycleTimerCreateInput   `json:"cycle_timer"`
-	Alarm      ScalingAlarmCreateInput `json:"alarm"`
+	Timer      TimerCreateInput         `json:"timer"`
+	CycleTimer CycleTimerCreateInput    `json:"cycle_timer"`
+	Alarm      ScalingAlarmCreateInput  `json:"alarm"`
+	Metric     ScalingMetricCreateInput `json:"metric"`
 
 	// desciption: 伸缩策略的行为(增加还是删除或者调整为)
 	// enum: add,remove,set
@@ -75,7 +82,32 @@ type ScalingPolicyListInput struct {
 	ScalingGroupFilterListInput
 
 	// description: trigger type
-	// enum: timing,cycel,alarm
+	// enum: timing,cycel,alarm,target_tracking,metric
 	// example: alarm
 	TriggerType string `json:"trigger_type"`
 }
+
+// ScalingPolicyEvaluation is the report of one evaluation of a metric driven
+// scaling policy, it tells what the controller did or would have done
+type ScalingPolicyEvaluation struct {
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	// description: 当前期望实例数
+	InstanceNumber int `json:"instance_number"`
+	// description: 指标当前值
+	MetricValue float64 `json:"metric_value"`
+	// description: 根据当前指标计算出的实例数
+	ReactiveNumber int `json:"reactive_number"`
+	// description: 根据历史同期负载预测的总负载
+	PredictedLoad float64 `json:"predicted_load"`
+	// description: 根据预测负载计算出的实例数, 0表示无预测
+	PredictiveNumber int `json:"predictive_number"`
+	// description: 最终的期望实例数, 已限制在最小和最大实例数之间
+	DesireNumber int `json:"desire_number"`
+	// enum: none,scale_out,scale_in
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+	// description: 是否只评估不执行
+	DryRun bool `json:"dry_run"`
+	// description: 是否已触发伸缩活动
+	Scaled bool `json:"scaled"`
+}
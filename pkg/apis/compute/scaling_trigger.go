@@ -82,6 +82,84 @@ type ScalingAlarmCreateInput struct {
 	Value float64 `json:"value"`
 }
 
+type ScalingMetricCreateInput struct {
+	// description: 时序数据库, 默认telegraf
+	// example: telegraf
+	Database string `json:"database"`
+
+	// description: 预置指标, 设置后可省略measurement和field
+	// example: cpu
+	// enum: cpu,mem,disk_read,disk_write,flow_into,flow_out
+	Indicator string `json:"indicator"`
+
+	// description: 指标所在的measurement
+	// example: vm_cpu
+	Measurement string `json:"measurement"`
+
+	// description: 指标字段
+	// example: usage_active
+	Field string `json:"field"`
+
+	// description: 过滤指标的tag, 总是按伸缩组过滤, 不能设置 vm_scaling_group_id
+	// example: {"host": "host-1"}
+	Tags map[string]string `json:"tags"`
+
+	// description: 聚合方式
+	// enum: max,min,average
+	// example: average
+	Wrapper string `json:"wrapper"`
+
+	// description: 统计窗口, 单位s
+	// example: 300
+	Window int `json:"window"`
+
+	// description: 比较符, 仅用于自定义指标
+	// enum: gt,lt
+	Operator string `json:"operator"`
+
+	// description: 阈值, 仅用于自定义指标
+	// example: 80
+	Value float64 `json:"value"`
+
+	// description: 目标值, 仅用于目标追踪
+	// example: 60
+	TargetValue float64 `json:"target_value"`
+
+	// description: 预测模式, 仅用于目标追踪
+	// enum: off,forecast_only,forecast_and_scale
+	PredictiveMode string `json:"predictive_mode"`
+
+	// description: 参考历史的周数
+	// example: 4
+	PredictiveWeeks int `json:"predictive_weeks"`
+
+	// description: 提前扩容的时间, 单位min
+	// example: 60
+	PredictiveLeadTime int `json:"predictive_lead_time"`
+
+	// description: 只评估并记录决策, 不执行伸缩
+	DryRun bool `json:"dry_run"`
+}
+
+type ScalingMetricDetails struct {
+	Database           string            `json:"database"`
+	Indicator          string            `json:"indicator"`
+	Measurement        string            `json:"measurement"`
+	Field              string            `json:"field"`
+	Tags               map[string]string `json:"tags"`
+	Wrapper            string            `json:"wrapper"`
+	Window             int               `json:"window"`
+	Operator           string            `json:"operator"`
+	Value              float64           `json:"value"`
+	TargetValue        float64           `json:"target_value"`
+	PredictiveMode     string            `json:"predictive_mode"`
+	PredictiveWeeks    int               `json:"predictive_weeks"`
+	PredictiveLeadTime int               `json:"predictive_lead_time"`
+	DryRun             bool              `json:"dry_run"`
+	// description: 最近一次评估的结果
+	LastEvaluation *ScalingPolicyEvaluation `json:"last_evaluation"`
+}
+
 type TimerDetails struct {
 	// description: 执行时间
 	ExecTime time.Time `json:"exec_time"`
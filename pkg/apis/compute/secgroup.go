@@ -355,3 +355,8 @@ func (self *SSecurityGroupRef) Sum() {
 
 type SecurityGroupSyncstatusInput struct {
 }
+
+type SecgroupSetFlowLogInput struct {
+	// 是否记录安全组规则放行和拒绝的流量日志, 仅支持本地VPC虚拟机
+	Enable bool `json:"enable"`
+}
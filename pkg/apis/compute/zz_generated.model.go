@@ -645,6 +645,10 @@ type SDiskBackup struct {
 	// 操作系统类型
 	OsType     string             `json:"os_type"`
 	DiskConfig *SBackupDiskConfig `json:"disk_config"`
+	// 增量备份的父备份
+	ParentBackupId string `json:"parent_backup_id"`
+	// 备份模式
+	BackupMode string `json:"backup_mode"`
 }
 
 // SDiskResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskResourceBase.
@@ -1116,6 +1120,23 @@ type SGuest struct {
 	RescueMode bool `json:"rescue_mode"`
 }
 
+// SGuestFilesystem is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestFilesystem.
+type SGuestFilesystem struct {
+	apis.SVirtualResourceBase
+	GuestId    string `json:"guest_id"`
+	SourceType string `json:"source_type"`
+	// 宿主机共享目录
+	HostPath string `json:"host_path"`
+	// 共享文件存储
+	StorageId string `json:"storage_id"`
+	SubPath   string `json:"sub_path"`
+	// virtio-fs mount tag
+	MountTag string `json:"mount_tag"`
+	// 虚拟机内挂载点
+	MountPoint string `json:"mount_point"`
+	ReadOnly   bool   `json:"read_only"`
+}
+
 // SGuestJointsBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SGuestJointsBase.
 type SGuestJointsBase struct {
 	apis.SVirtualJointResourceBase
@@ -1261,6 +1282,8 @@ type SHost struct {
 	MemReserved int `json:"mem_reserved"`
 	// 内存超分比
 	MemCmtbound float32 `json:"mem_cmtbound"`
+	// 虚拟机气球可回收的内存大小,单位Mb, 由宿主机上报, 宿主机内存紧张时为0, 调度时作为内存超分余量
+	MemBalloonHeadroom int `json:"mem_balloon_headroom"`
 	// 页大小
 	PageSizeKB         int  `json:"page_size_kb"`
 	EnableNumaAllocate bool `json:"enable_numa_allocate"`
@@ -2501,6 +2524,35 @@ type SScalingGroupResourceBase struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 }
 
+// SScalingMetric is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingMetric.
+type SScalingMetric struct {
+	apis.SStandaloneResourceBase
+	SScalingPolicyBase
+	Database    string `json:"database"`
+	Indicator   string `json:"indicator"`
+	Measurement string `json:"measurement"`
+	Field       string `json:"field"`
+	// Tags filter the series, the series of the scaling group are used if empty
+	Tags jsonutils.JSONObject `json:"tags"`
+	// Wrapper instruct how to calculate collective data based on individual data
+	Wrapper string `json:"wrapper"`
+	// Window in seconds the metric is aggregated over
+	Window int `json:"window"`
+	// Operator and Value are the threshold of custom metric trigger
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+	// TargetValue is the value target tracking trigger keeps the metric at
+	TargetValue    float64 `json:"target_value"`
+	PredictiveMode string  `json:"predictive_mode"`
+	// Number of previous weeks the forecast is based on
+	PredictiveWeeks int `json:"predictive_weeks"`
+	// Minutes the forecast looks ahead
+	PredictiveLeadTime int `json:"predictive_lead_time"`
+	// DryRun only records the evaluations without scaling
+	DryRun         bool                 `json:"dry_run"`
+	LastEvaluation jsonutils.JSONObject `json:"last_evaluation"`
+}
+
 // SScalingPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingPolicy.
 type SScalingPolicy struct {
 	apis.SVirtualResourceBase
@@ -2564,6 +2616,8 @@ type SSecurityGroup struct {
 	apis.SSharableVirtualResourceBase
 	apis.SExternalizedResourceBase
 	IsDirty bool `json:"is_dirty"`
+	// 是否记录安全组规则匹配的流量日志
+	EnableFlowLog bool `json:"enable_flow_log"`
 	SManagedResourceBase
 	SCloudregionResourceBase
 	SGlobalVpcResourceBase
@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+type ResourceHistoryInput struct {
+	// 查询资源在该时间点的字段值
+	At time.Time `json:"at"`
+}
+
+// 操作日志中记录的单个字段变更
+type OpsLogFieldDiff struct {
+	Old jsonutils.JSONObject `json:"old"`
+	New jsonutils.JSONObject `json:"new"`
+}
+
+type ResourceHistoryChange struct {
+	// 操作日志ID
+	Id string `json:"id"`
+	// 操作类型
+	Action string `json:"action"`
+	// 操作时间
+	OpsTime time.Time `json:"ops_time"`
+	// 操作人
+	User string `json:"user"`
+	// 被回退的字段
+	Fields []string `json:"fields"`
+	// 该操作修改了资源但没有记录字段变更, 无法回退
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+type ResourceHistoryOutput struct {
+	// 查询的时间点
+	At time.Time `json:"at"`
+	// 资源在该时间点的字段值
+	Fields jsonutils.JSONObject `json:"fields"`
+	// 为 false 时, 部分变更没有记录字段值, 返回的字段值可能与该时间点不符
+	Complete bool `json:"complete"`
+	// 无法还原的字段, 不在返回的字段值中
+	UnknownFields []string `json:"unknown_fields,omitempty"`
+	// 该时间点之后发生的、被回退的变更, 按时间倒序
+	Changes []ResourceHistoryChange `json:"changes"`
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	SILENCE_MATCH_EQUAL     = "="
+	SILENCE_MATCH_NOT_EQUAL = "!="
+	SILENCE_MATCH_REGEX     = "=~"
+	SILENCE_MATCH_NOT_REGEX = "!~"
+
+	// 除告警数据本身的 tags 之外，静默规则额外可以匹配的 label
+	SILENCE_LABEL_ALERT_ID   = "alert_id"
+	SILENCE_LABEL_ALERT_NAME = "alert_name"
+	SILENCE_LABEL_LEVEL      = "level"
+	SILENCE_LABEL_RES_TYPE   = "res_type"
+	SILENCE_LABEL_METRIC     = "metric"
+	SILENCE_LABEL_PROJECT    = "project"
+	SILENCE_LABEL_PROJECT_ID = "project_id"
+	// 资源标签以 tags. 为前缀，例如 tags.rack
+	SILENCE_LABEL_TAG_PREFIX = "tags."
+)
+
+var SilenceMatchOperators = []string{
+	SILENCE_MATCH_EQUAL,
+	SILENCE_MATCH_NOT_EQUAL,
+	SILENCE_MATCH_REGEX,
+	SILENCE_MATCH_NOT_REGEX,
+}
+
+type AlertSilenceMatcher struct {
+	// 匹配的 label，例如 host, zone, project, alert_name, tags.rack
+	Key string `json:"key"`
+	// 匹配方式，可选 =, !=, =~, !~，默认为 =
+	Operator string `json:"operator"`
+	// 匹配的值，正则匹配时为完整匹配
+	Value string `json:"value"`
+}
+
+type AlertSilenceCreateInput struct {
+	apis.ScopedResourceCreateInput
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// 匹配条件，所有条件都满足时静默
+	Matchers []AlertSilenceMatcher `json:"matchers"`
+
+	// 生效开始时间，默认为当前时间
+	StartTime string `json:"start_time"`
+	// 生效结束时间，为空时一直有效
+	EndTime string `json:"end_time"`
+
+	// 周期性维护窗口的 cron 表达式，例如 "0 2 * * 6" 表示每周六凌晨2点开始
+	Schedule string `json:"schedule"`
+	// 每个维护窗口持续的时间，例如 2h, 30m，设置 schedule 时必填
+	Duration string `json:"duration"`
+	// schedule 使用的时区，例如 Asia/Shanghai，默认为服务所在时区
+	Timezone string `json:"timezone"`
+}
+
+type AlertSilenceUpdateInput struct {
+	apis.StatusStandaloneResourceBaseUpdateInput
+
+	Matchers []AlertSilenceMatcher `json:"matchers"`
+
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+
+	Schedule *string `json:"schedule"`
+	Duration string  `json:"duration"`
+	Timezone *string `json:"timezone"`
+}
+
+type AlertSilenceListInput struct {
+	apis.Meta
+
+	apis.ScopedResourceBaseListInput
+	apis.EnabledResourceBaseListInput
+	apis.StatusStandaloneResourceListInput
+
+	// 只列出当前处于静默中的规则
+	Active *bool `json:"active"`
+	// 是否为周期性维护窗口
+	Recurring *bool `json:"recurring"`
+}
+
+type AlertSilenceDetails struct {
+	apis.StatusStandaloneResourceDetails
+	apis.ScopedResourceBaseInfo
+
+	// 当前是否处于静默中
+	Active bool `json:"active"`
+	// 是否已过期
+	Expired bool `json:"expired"`
+	// 下一个维护窗口的开始时间
+	NextWindowStart time.Time `json:"next_window_start,omitempty"`
+}
+
+type AlertSilenceRecordListInput struct {
+	apis.Meta
+
+	apis.ScopedResourceBaseListInput
+	apis.StandaloneAnonResourceListInput
+
+	SilenceId string `json:"silence_id"`
+	AlertId   string `json:"alert_id"`
+	ResType   string `json:"res_type"`
+	ResId     string `json:"res_id"`
+}
+
+type AlertSilenceRecordDetails struct {
+	apis.StandaloneAnonResourceDetails
+	apis.ScopedResourceBaseInfo
+
+	Silence string `json:"silence"`
+}
@@ -130,6 +130,38 @@ type SAlertResourceJointsBase struct {
 	AlertResourceId string `json:"alert_resource_id"`
 }
 
+// SAlertSilence is an autogenerated struct via yunion.io/x/onecloud/pkg/monitor/models.SAlertSilence.
+type SAlertSilence struct {
+	apis.SEnabledResourceBase
+	apis.SStatusStandaloneResourceBase
+	SMonitorScopedResource
+	Matchers  jsonutils.JSONObject `json:"matchers"`
+	StartTime time.Time            `json:"start_time"`
+	EndTime   time.Time            `json:"end_time"`
+	// Schedule is a cron expression marking the start of each maintenance window
+	Schedule string `json:"schedule"`
+	// Duration is the length of each maintenance window, e.g. 2h
+	Duration         string    `json:"duration"`
+	Timezone         string    `json:"timezone"`
+	SuppressedCount  int       `json:"suppressed_count"`
+	LastSuppressedAt time.Time `json:"last_suppressed_at"`
+}
+
+// SAlertSilenceRecord is an autogenerated struct via yunion.io/x/onecloud/pkg/monitor/models.SAlertSilenceRecord.
+type SAlertSilenceRecord struct {
+	apis.SStandaloneAnonResourceBase
+	SMonitorScopedResource
+	SilenceId string               `json:"silence_id"`
+	AlertId   string               `json:"alert_id"`
+	AlertName string               `json:"alert_name"`
+	Level     string               `json:"level"`
+	State     string               `json:"state"`
+	ResType   string               `json:"res_type"`
+	ResId     string               `json:"res_id"`
+	Metric    string               `json:"metric"`
+	Labels    jsonutils.JSONObject `json:"labels"`
+}
+
 // SAlertnotification is an autogenerated struct via yunion.io/x/onecloud/pkg/monitor/models.SAlertnotification.
 type SAlertnotification struct {
 	SAlertJointsBase
@@ -33,6 +33,16 @@ type ScheduledTaskDetails struct {
 	// 绑定的所有标示
 	Labels       []string      `json:"labels,allowempty"`
 	LabelDetails []LabelDetail `json:"label_details,allowempty"`
+	// 依赖的定时任务
+	DependsOn []ScheduledTaskDependencyDetail `json:"depends_on,allowempty"`
+	// 黑名单日历
+	Calendar string `json:"calendar"`
+}
+
+type ScheduledTaskDependencyDetail struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Condition string `json:"condition"`
 }
 
 type TimerDetails struct {
@@ -90,7 +100,7 @@ type ScheduledTaskCreateInput struct {
 	apis.EnabledBaseResourceCreateInput
 
 	// description: scheduled type
-	// enum: cycle,timing
+	// enum: cycle,timing,dependent
 	// example: timing
 	ScheduledType string                `json:"scheduled_type"`
 	Timer         TimerCreateInput      `json:"timer"`
@@ -111,6 +121,20 @@ type ScheduledTaskCreateInput struct {
 	// description: labels
 	// example: {g-12345}
 	Labels []string
+
+	// description: scheduled tasks to run after, required by dependent type
+	// example: {st-stop-dev-vms}
+	DependsOn []string `json:"depends_on"`
+	// description: condition of scheduled tasks depended on
+	// enum: succeed,complete
+	// example: succeed
+	DependencyCondition string `json:"dependency_condition"`
+
+	// description: calendar whose blackouts skip the runs
+	// example: holidays
+	Calendar string `json:"calendar"`
+	// swagger:ignore
+	CalendarId string `json:"calendar_id"`
 }
 
 type TimerCreateInput struct {
@@ -186,3 +210,62 @@ type ScheduledTaskSetLabelsInput struct {
 
 type ScheduledTaskTriggerInput struct {
 }
+
+type ScheduledTaskSetDependenciesInput struct {
+	// description: scheduled tasks to run after
+	DependsOn []string `json:"depends_on"`
+	// description: condition of scheduled tasks depended on
+	// enum: succeed,complete
+	Condition string `json:"condition"`
+}
+
+type ScheduledTaskSetCalendarInput struct {
+	// description: calendar id or name, empty to unset
+	Calendar string `json:"calendar"`
+}
+
+type ScheduledTaskSetLabelConcurrencyInput struct {
+	Label string `json:"label"`
+	// description: maximum number of resources operated at the same time
+	// by all scheduled tasks with the label, 0 to remove the limit
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+type ScheduledTaskLabelConcurrencyOutput struct {
+	Limits []ScheduledTaskSetLabelConcurrencyInput `json:"limits"`
+}
+
+type CalendarPeriod struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ScheduledTaskCalendarCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// description: timezone of dates, e.g. Asia/Shanghai
+	// example: UTC
+	Timezone string `json:"timezone"`
+	// description: whole days to skip, format 2006-01-02
+	// example: {2021-10-01}
+	Dates []string `json:"dates"`
+	// description: periods to skip
+	Periods []CalendarPeriod `json:"periods"`
+}
+
+type ScheduledTaskCalendarUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	Timezone string           `json:"timezone"`
+	Dates    []string         `json:"dates"`
+	Periods  []CalendarPeriod `json:"periods"`
+}
+
+type ScheduledTaskCalendarListInput struct {
+	apis.VirtualResourceListInput
+}
+
+type ScheduledTaskCalendarDetails struct {
+	apis.VirtualResourceDetails
+	SScheduledTaskCalendar
+}
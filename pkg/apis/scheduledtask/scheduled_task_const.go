@@ -17,6 +17,8 @@ package scheduledtask
 const (
 	ST_TYPE_TIMING = "timing" // 定时
 	ST_TYPE_CYCLE  = "cycle"  // 周期
+	// triggered when the tasks it depends on finish
+	ST_TYPE_DEPENDENT = "dependent" // 依赖
 
 	ST_STATUS_READY         = "ready"
 	ST_STATUS_CREATE_FAILED = "create_failed"
@@ -37,6 +39,12 @@ const (
 	ST_ACTIVITY_STATUS_PART_SUCCEED = "part_succeed" // 部分成功
 	ST_ACTIVITY_STATUS_FAILED       = "failed"       // 失败
 	ST_ACTIVITY_STATUS_REJECT       = "reject"       // 拒绝
+	ST_ACTIVITY_STATUS_SKIP         = "skip"         // 日历跳过
+
+	// downstream task runs only if upstream task succeeded
+	ST_DEPENDENCY_CONDITION_SUCCEED = "succeed"
+	// downstream task runs once upstream task finished, regardless of result
+	ST_DEPENDENCY_CONDITION_COMPLETE = "complete"
 
 	TIMER_TYPE_ONCE  = "once"
 	TIMER_TYPE_HOUR  = "hour"
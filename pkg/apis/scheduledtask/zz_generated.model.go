@@ -17,6 +17,8 @@ package scheduledtask
 import (
 	time "time"
 
+	jsonutils "yunion.io/x/jsonutils"
+
 	"yunion.io/x/onecloud/pkg/apis"
 )
 
@@ -29,6 +31,8 @@ type SScheduledTask struct {
 	ResourceType string `json:"resource_type"`
 	Operation    string `json:"operation"`
 	LabelType    string `json:"label_type"`
+	// 黑名单日历, 落在其中的执行会被跳过
+	CalendarId string `json:"calendar_id"`
 }
 
 // SScheduledTaskActivity is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.SScheduledTaskActivity.
@@ -38,6 +42,28 @@ type SScheduledTaskActivity struct {
 	StartTime       time.Time `json:"start_time"`
 	EndTime         time.Time `json:"end_time"`
 	Reason          string    `json:"reason"`
+	// ids of resources operated successfully, dependent tasks operate only
+	// these resources
+	SucceedResources *jsonutils.JSONArray `json:"succeed_resources"`
+}
+
+// SScheduledTaskCalendar is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.SScheduledTaskCalendar.
+type SScheduledTaskCalendar struct {
+	apis.SVirtualResourceBase
+	// 日期所在时区
+	Timezone string `json:"timezone"`
+	// 跳过的日期, 格式为2006-01-02
+	Dates jsonutils.JSONObject `json:"dates"`
+	// 跳过的时间段
+	Periods jsonutils.JSONObject `json:"periods"`
+}
+
+// SScheduledTaskDependency is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.SScheduledTaskDependency.
+type SScheduledTaskDependency struct {
+	apis.SResourceBase
+	ScheduledTaskId string `json:"scheduled_task_id"`
+	DependsOnId     string `json:"depends_on_id"`
+	Condition       string `json:"condition"`
 }
 
 // SScheduledTaskLabel is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.SScheduledTaskLabel.
@@ -47,6 +73,13 @@ type SScheduledTaskLabel struct {
 	Label           string `json:"label"`
 }
 
+// SScheduledTaskLabelConcurrency is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.SScheduledTaskLabelConcurrency.
+type SScheduledTaskLabelConcurrency struct {
+	apis.SResourceBase
+	Label          string `json:"label"`
+	MaxConcurrency int    `json:"max_concurrency"`
+}
+
 // STimer is an autogenerated struct via yunion.io/x/onecloud/pkg/scheduledtask/models.STimer.
 type STimer struct {
 	// Cycle type
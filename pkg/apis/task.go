@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+const (
+	TASK_STATUS_RUNNING  = "running"
+	TASK_STATUS_COMPLETE = "complete"
+	TASK_STATUS_FAILED   = "failed"
+)
+
+type TaskTreeInput struct {
+	// 对象类型, 例如 server
+	ObjType string `json:"obj_type"`
+	// 对象ID或名称
+	ObjId string `json:"obj_id"`
+	// 指定任务ID, 返回以该任务为根的任务树
+	TaskId string `json:"task_id"`
+	// 只返回该时间之后创建的任务
+	Since time.Time `json:"since"`
+}
+
+type TaskRetryInput struct {
+	// 重新执行失败阶段时传入的数据, 默认为空
+	Data jsonutils.JSONObject `json:"data"`
+}
+
+type TaskStageDetails struct {
+	Name       string    `json:"name"`
+	StartAt    time.Time `json:"start_at"`
+	CompleteAt time.Time `json:"complete_at"`
+	// 该阶段对任务参数的修改
+	ParamsDiff jsonutils.JSONObject `json:"params_diff,omitempty"`
+}
+
+type TaskTreeNode struct {
+	Id        string    `json:"id"`
+	TaskName  string    `json:"task_name"`
+	ObjName   string    `json:"obj_name"`
+	ObjId     string    `json:"obj_id"`
+	Stage     string    `json:"stage"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 失败的阶段及原因
+	FailedStage  string               `json:"failed_stage,omitempty"`
+	FailedReason jsonutils.JSONObject `json:"failed_reason,omitempty"`
+
+	// 任务可以从失败阶段重试
+	CanRetry bool `json:"can_retry"`
+
+	Stages   []TaskStageDetails `json:"stages"`
+	Subtasks []TaskTreeNode     `json:"subtasks"`
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	ACCESS_REQUEST_STATUS_PENDING  = "pending"
+	ACCESS_REQUEST_STATUS_APPROVED = "approved"
+	ACCESS_REQUEST_STATUS_REJECTED = "rejected"
+	ACCESS_REQUEST_STATUS_EXPIRED  = "expired"
+
+	// notification event sent to approvers when an access request is created
+	ACCESS_REQUEST_EVENT = "WEBCONSOLE_ACCESS_REQUEST"
+)
+
+type AccessRequestListInput struct {
+	apis.StandaloneAnonResourceListInput
+
+	// 以会话ID过滤
+	SessionId string `json:"session_id"`
+	// 以资源ID过滤
+	ObjId string `json:"obj_id"`
+	// 以申请人ID过滤
+	UserId string `json:"user_id"`
+	// 以状态过滤
+	Status []string `json:"status"`
+}
+
+type AccessRequestDetails struct {
+	apis.StandaloneAnonResourceDetails
+}
+
+type AccessRequestApproveInput struct {
+	// 审批意见
+	Comment string `json:"comment"`
+	// 会话允许持续的分钟数，不能超过 session_approval_access_minutes
+	AccessMinutes int `json:"access_minutes"`
+}
+
+type AccessRequestRejectInput struct {
+	// 审批意见
+	Comment string `json:"comment"`
+}
+
+type AccessRequestNotifyData struct {
+	Id        string    `json:"id"`
+	SessionId string    `json:"session_id"`
+	ObjId     string    `json:"obj_id"`
+	ObjName   string    `json:"obj_name"`
+	ObjType   string    `json:"obj_type"`
+	Tag       string    `json:"tag"`
+	User      string    `json:"user"`
+	Project   string    `json:"project"`
+	CreatedAt time.Time `json:"created_at"`
+}
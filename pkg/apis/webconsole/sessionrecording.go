@@ -0,0 +1,52 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// asciinema v2 recording of ssh and pty sessions
+	SESSION_RECORDING_FORMAT_ASCIICAST = "asciicast"
+	// guacamole protocol dump of rdp sessions
+	SESSION_RECORDING_FORMAT_GUACAMOLE = "guacamole"
+	// rfb stream of vnc sessions in fbs 001.000 format
+	SESSION_RECORDING_FORMAT_FBS = "fbs"
+
+	SESSION_RECORDING_STATUS_RECORDING = "recording"
+	SESSION_RECORDING_STATUS_FINISHED  = "finished"
+)
+
+type SessionRecordingListInput struct {
+	apis.StandaloneAnonResourceListInput
+
+	// 以会话ID过滤
+	SessionId string `json:"session_id"`
+	// 以资源ID过滤
+	ObjId string `json:"obj_id"`
+	// 以资源类型过滤
+	ObjType string `json:"obj_type"`
+	// 以用户ID过滤
+	UserId string `json:"user_id"`
+	// 以录制格式过滤
+	Format []string `json:"format"`
+	// 以连接协议过滤
+	Protocol []string `json:"protocol"`
+}
+
+type SessionRecordingDetails struct {
+	apis.StandaloneAnonResourceDetails
+}
@@ -25,6 +25,7 @@ type ServerRemoteConsoleResponse struct {
 	AccessUrl     string `json:"access_url"`
 	ConnectParams string `json:"connect_params"`
 	Session       string `json:"session,omitempty"`
+	AccessRequest string `json:"access_request,omitempty"`
 
 	apis.Meta
 }
@@ -261,3 +261,90 @@ func (job *SSendMetricsJob) Do(ctx context.Context, now time.Time) error {
 	job.lastTime = now
 	return nil
 }
+
+type SRedfishEventSubscribeJob struct {
+	SBaseBaremetalCronJob
+}
+
+func NewRedfishEventSubscribeJob(baremetal *SBaremetalInstance, interval time.Duration) IBaremetalCronJob {
+	return &SRedfishEventSubscribeJob{
+		SBaseBaremetalCronJob: SBaseBaremetalCronJob{
+			baremetal: baremetal,
+			interval:  interval,
+		},
+	}
+}
+
+func (job *SRedfishEventSubscribeJob) Name() string {
+	return "RedfishEventSubscribeJob"
+}
+
+func (job *SRedfishEventSubscribeJob) Do(ctx context.Context, now time.Time) error {
+	if !job.baremetal.isRedfishCapable() {
+		return nil
+	}
+	// BMC without event service would fail every time, do not retry before next interval
+	job.lastTime = now
+	switch o.Options.RedfishEventMode {
+	case redfish.EVENT_MODE_WEBHOOK:
+		err := job.baremetal.SubscribeRedfishEvents(ctx)
+		if err != nil {
+			return errors.Wrap(err, "SubscribeRedfishEvents")
+		}
+	case redfish.EVENT_MODE_SSE:
+		job.baremetal.StartRedfishEventStream()
+	}
+	return nil
+}
+
+type SInventorySyncJob struct {
+	SBaseBaremetalCronJob
+}
+
+func NewInventorySyncJob(baremetal *SBaremetalInstance, interval time.Duration) IBaremetalCronJob {
+	return &SInventorySyncJob{
+		SBaseBaremetalCronJob: SBaseBaremetalCronJob{
+			baremetal: baremetal,
+			interval:  interval,
+		},
+	}
+}
+
+func (job *SInventorySyncJob) Name() string {
+	return "InventorySyncJob"
+}
+
+func (job *SInventorySyncJob) Do(ctx context.Context, now time.Time) error {
+	if !job.baremetal.isRedfishCapable() {
+		return nil
+	}
+	job.lastTime = now
+	inv, err := job.baremetal.SyncHardwareInventory(ctx)
+	if err != nil {
+		return errors.Wrap(err, "SyncHardwareInventory")
+	}
+	if inv == nil {
+		return nil
+	}
+	s := auth.GetAdminSession(ctx, consts.GetRegion())
+	src, err := tsdb.GetDefaultServiceSource(s, o.Options.SessionEndpointType)
+	if err != nil {
+		return errors.Wrap(err, "tsdb.GetDefaultServiceSource")
+	}
+	if len(src.URLs) == 0 {
+		return nil
+	}
+	metrics := []influxdb.SMetricData{
+		{
+			Name:      "hardware_health",
+			Tags:      job.baremetal.getTags(),
+			Metrics:   inv.ToMetrics(),
+			Timestamp: now,
+		},
+	}
+	err = influxdb.SendMetrics(src.URLs, "telegraf", metrics, false)
+	if err != nil {
+		return errors.Wrapf(err, "tsdb.SendMetrics %q", src.Type)
+	}
+	return nil
+}
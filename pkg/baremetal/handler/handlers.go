@@ -32,6 +32,7 @@ import (
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/util/redfish"
 )
 
 var registerWorkMan *appsrv.SWorkerManager
@@ -77,6 +78,8 @@ func initBaremetalsHandler(app *appsrv.Application) {
 	AddHandler(app, "POST", bmActionPrefix("ipmi-probe"), bmObjMiddleware(handleBaremetalIpmiProbe))
 	AddHandler(app, "POST", bmActionPrefix("cdrom"), bmObjMiddleware(handleBaremetalCdromTask))
 	AddHandler(app, "POST", bmActionPrefix("jnlp"), bmObjMiddleware(handleBaremetalJnlpTask))
+	// called by BMC without token, authenticated by the context of event subscription
+	app.AddHandler("POST", bmActionPrefix("redfish-events"), bmObjMiddlewareWithFetch(handleBaremetalRedfishEvents, false))
 
 	// server actions handler
 	AddHandler(app, "POST", srvActionPrefix("create"), srvClassMiddleware(handleServerCreate))
@@ -116,6 +119,24 @@ func handleBaremetalNotify(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	ctx.ResponseOk()
 }
 
+func handleBaremetalRedfishEvents(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	if ctx.Data() == nil {
+		ctx.ResponseError(httperrors.NewInputParameterError("empty redfish event"))
+		return
+	}
+	payload, err := redfish.ParseEventPayload(ctx.Data())
+	if err != nil {
+		ctx.ResponseError(httperrors.NewInputParameterError("invalid redfish event: %v", err))
+		return
+	}
+	if !bm.VerifyRedfishEventContext(payload.Context) {
+		ctx.ResponseError(httperrors.NewForbiddenError("invalid redfish event context"))
+		return
+	}
+	bm.HandleRedfishEvents(ctx, payload)
+	ctx.ResponseOk()
+}
+
 func handleBaremetalMaintenance(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	bm.StartBaremetalMaintenanceTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
 	ctx.ResponseOk()
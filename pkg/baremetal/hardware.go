@@ -0,0 +1,246 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+	logger "yunion.io/x/onecloud/pkg/mcclient/modules/logger"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+const redfishEventStreamRetryInterval = 30 * time.Second
+
+func (b *SBaremetalInstance) GetRedfishEventUrl() string {
+	return fmt.Sprintf("%s/baremetals/%s/redfish-events", b.manager.Agent.GetListenUri(), b.GetId())
+}
+
+// SubscribeRedfishEvents registers the agent webhook to the BMC event service,
+// events are only accepted if they carry the context of the latest subscription
+func (b *SBaremetalInstance) SubscribeRedfishEvents(ctx context.Context) error {
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return errors.Wrap(httperrors.ErrNotSupported, "no valid redfish api")
+	}
+	eventContext := utils.GenRequestId(16)
+	_, err := redfishApi.SubscribeEvents(ctx, b.GetRedfishEventUrl(), eventContext)
+	if err != nil {
+		return errors.Wrap(err, "redfishApi.SubscribeEvents")
+	}
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+	b.eventContext = eventContext
+	return nil
+}
+
+func (b *SBaremetalInstance) VerifyRedfishEventContext(eventContext string) bool {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+	return len(b.eventContext) > 0 && b.eventContext == eventContext
+}
+
+// StartRedfishEventStream keeps a server sent event connection to the BMC
+// in background, reconnecting until the baremetal is stopped
+func (b *SBaremetalInstance) StartRedfishEventStream() {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+	if b.eventStreamCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.eventStreamCancel = cancel
+	go func() {
+		for {
+			redfishApi := b.GetRedfishCli(ctx)
+			if redfishApi != nil {
+				err := redfishApi.ReadEventStream(ctx, func(payload redfish.SEventPayload) {
+					b.HandleRedfishEvents(ctx, payload)
+				})
+				if err != nil && ctx.Err() == nil {
+					log.Errorf("baremetal %s read redfish event stream: %s", b.GetName(), err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(redfishEventStreamRetryInterval):
+			}
+		}
+	}()
+}
+
+func (b *SBaremetalInstance) StopRedfishEventStream() {
+	b.eventLock.Lock()
+	defer b.eventLock.Unlock()
+	if b.eventStreamCancel != nil {
+		b.eventStreamCancel()
+		b.eventStreamCancel = nil
+	}
+}
+
+// HandleRedfishEvents records the events received from the BMC as baremetal
+// events and resyncs the hardware inventory on alerts, so that the host health
+// is updated without waiting for the next inventory sync
+func (b *SBaremetalInstance) HandleRedfishEvents(ctx context.Context, payload redfish.SEventPayload) {
+	s := auth.GetAdminSession(ctx, consts.GetRegion())
+	now := time.Now().UTC()
+	alert := false
+	for i := range payload.Events {
+		event := payload.Events[i].ToEvent(now)
+		if event.IsAlert() {
+			alert = true
+		}
+		eventData := eventToJson(event)
+		eventData.Add(jsonutils.NewString(b.GetId()), "host_id")
+		eventData.Add(jsonutils.NewString(b.GetName()), "host_name")
+		eventData.Add(jsonutils.NewString(b.GetIPMINicIPAddr()), "ipmi_ip")
+		_, err := logger.BaremetalEvents.Create(s, eventData)
+		if err != nil {
+			log.Errorf("baremetal %s save redfish event: %s", b.GetName(), err)
+		}
+	}
+	if alert {
+		go func() {
+			_, err := b.SyncHardwareInventory(context.Background())
+			if err != nil {
+				log.Errorf("baremetal %s sync hardware inventory on alert: %s", b.GetName(), err)
+			}
+		}()
+	}
+}
+
+func hardwareHealth(health string) string {
+	switch health {
+	case redfish.HEALTH_CRITICAL:
+		return api.HOST_HARDWARE_HEALTH_CRITICAL
+	case redfish.HEALTH_WARNING:
+		return api.HOST_HARDWARE_HEALTH_WARNING
+	}
+	return api.HOST_HARDWARE_HEALTH_OK
+}
+
+func inventoryToInput(inv redfish.SHardwareInventory) api.HostSyncHardwareInventoryInput {
+	input := api.HostSyncHardwareInventoryInput{
+		Health:     hardwareHealth(inv.Health()),
+		Components: make([]api.HostHardwareComponent, 0),
+	}
+	for _, c := range inv.FailedCollections {
+		switch c {
+		case redfish.INVENTORY_DIMMS:
+			input.FailedTypes = append(input.FailedTypes, api.HOST_HARDWARE_DIMM)
+		case redfish.INVENTORY_DRIVES:
+			input.FailedTypes = append(input.FailedTypes, api.HOST_HARDWARE_DRIVE)
+		case redfish.INVENTORY_FIRMWARES:
+			input.FailedTypes = append(input.FailedTypes, api.HOST_HARDWARE_FIRMWARE)
+		case redfish.INVENTORY_POWER_SUPPLIES:
+			input.FailedTypes = append(input.FailedTypes, api.HOST_HARDWARE_POWER_SUPPLY)
+		}
+	}
+	for _, d := range inv.Dimms {
+		if !d.Status.IsPresent() {
+			continue
+		}
+		input.Components = append(input.Components, api.HostHardwareComponent{
+			Type:         api.HOST_HARDWARE_DIMM,
+			Id:           d.Id,
+			Name:         d.Name,
+			Manufacturer: d.Manufacturer,
+			Model:        d.PartNumber,
+			SerialNumber: d.SerialNumber,
+			CapacityMb:   int64(d.CapacityMiB),
+			State:        d.Status.State,
+			Health:       hardwareHealth(d.Status.Health),
+		})
+	}
+	for _, d := range inv.Drives {
+		if !d.Status.IsPresent() {
+			continue
+		}
+		input.Components = append(input.Components, api.HostHardwareComponent{
+			Type:         api.HOST_HARDWARE_DRIVE,
+			Id:           d.Id,
+			Name:         d.Name,
+			Manufacturer: d.Manufacturer,
+			Model:        d.Model,
+			SerialNumber: d.SerialNumber,
+			Version:      d.Revision,
+			CapacityMb:   d.CapacityBytes / 1024 / 1024,
+			State:        d.Status.State,
+			Health:       hardwareHealth(d.Status.Health),
+		})
+	}
+	for _, f := range inv.Firmwares {
+		input.Components = append(input.Components, api.HostHardwareComponent{
+			Type:    api.HOST_HARDWARE_FIRMWARE,
+			Id:      f.Id,
+			Name:    f.Name,
+			Version: f.Version,
+			State:   f.Status.State,
+			Health:  hardwareHealth(f.Status.Health),
+		})
+	}
+	for _, p := range inv.PowerSupplies {
+		if !p.Status.IsPresent() {
+			continue
+		}
+		input.Components = append(input.Components, api.HostHardwareComponent{
+			Type:         api.HOST_HARDWARE_POWER_SUPPLY,
+			Id:           p.MemberId,
+			Name:         p.Name,
+			Model:        p.Model,
+			SerialNumber: p.SerialNumber,
+			Version:      p.FirmwareVersion,
+			State:        p.Status.State,
+			Health:       hardwareHealth(p.Status.Health),
+		})
+	}
+	return input
+}
+
+// SyncHardwareInventory reads the full inventory from the BMC and reports it
+// to the region, which raises host health events on health changes
+func (b *SBaremetalInstance) SyncHardwareInventory(ctx context.Context) (*redfish.SHardwareInventory, error) {
+	if !b.inventoryLock.TryLock() {
+		log.Infof("baremetal %s hardware inventory sync in progress, skip", b.GetName())
+		return nil, nil
+	}
+	defer b.inventoryLock.Unlock()
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return nil, errors.Wrap(httperrors.ErrNotSupported, "no valid redfish api")
+	}
+	inv, err := redfishApi.GetInventory(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "redfishApi.GetInventory")
+	}
+	input := inventoryToInput(inv)
+	_, err = modules.Hosts.PerformAction(b.GetClientSession(), b.GetId(), "sync-hardware-inventory", jsonutils.Marshal(input))
+	if err != nil {
+		return nil, errors.Wrap(err, "perform sync-hardware-inventory")
+	}
+	return &inv, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+func TestInventoryToInput(t *testing.T) {
+	inv := redfish.SHardwareInventory{
+		Dimms: []redfish.SMemoryDimm{
+			{
+				Id:          "DIMM1",
+				CapacityMiB: 32768,
+				Status:      redfish.SStatus{State: "Enabled", Health: redfish.HEALTH_OK},
+			},
+			{
+				Id:     "DIMM2",
+				Status: redfish.SStatus{State: redfish.STATE_ABSENT, Health: redfish.HEALTH_CRITICAL},
+			},
+		},
+		Drives: []redfish.SStorageDrive{
+			{
+				Id:            "Disk.0",
+				CapacityBytes: 480 * 1024 * 1024 * 1024,
+				Status:        redfish.SStatus{State: "Enabled", Health: redfish.HEALTH_WARNING},
+			},
+		},
+		Firmwares: []redfish.SFirmware{
+			{
+				Id:      "BIOS",
+				Version: "2.10.2",
+			},
+		},
+	}
+	input := inventoryToInput(inv)
+	if input.Health != api.HOST_HARDWARE_HEALTH_WARNING {
+		t.Errorf("health got %s want %s", input.Health, api.HOST_HARDWARE_HEALTH_WARNING)
+	}
+	if len(input.Components) != 3 {
+		t.Fatalf("components got %d want 3", len(input.Components))
+	}
+	if input.Components[0].CapacityMb != 32768 {
+		t.Errorf("dimm capacity got %d", input.Components[0].CapacityMb)
+	}
+	if input.Components[1].CapacityMb != 480*1024 || input.Components[1].Health != api.HOST_HARDWARE_HEALTH_WARNING {
+		t.Errorf("drive got %#v", input.Components[1])
+	}
+	if input.Components[2].Health != api.HOST_HARDWARE_HEALTH_OK {
+		t.Errorf("firmware health got %s", input.Components[2].Health)
+	}
+}
+
+func TestInventoryToInputFailedCollections(t *testing.T) {
+	inv := redfish.SHardwareInventory{
+		FailedCollections: []string{redfish.INVENTORY_DRIVES, redfish.INVENTORY_POWER_SUPPLIES},
+	}
+	input := inventoryToInput(inv)
+	if len(input.FailedTypes) != 2 || input.FailedTypes[0] != api.HOST_HARDWARE_DRIVE || input.FailedTypes[1] != api.HOST_HARDWARE_POWER_SUPPLY {
+		t.Errorf("failed types got %v", input.FailedTypes)
+	}
+}
@@ -522,6 +522,11 @@ type SBaremetalInstance struct {
 	serverLock *sync.Mutex
 
 	cronJobs []IBaremetalCronJob
+
+	eventLock         *sync.Mutex
+	eventContext      string
+	eventStreamCancel context.CancelFunc
+	inventoryLock     *sync.Mutex
 }
 
 func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*SBaremetalInstance, error) {
@@ -531,11 +536,16 @@ func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*S
 		descLock:   new(sync.Mutex),
 		taskQueue:  tasks.NewTaskQueue(),
 		serverLock: new(sync.Mutex),
+
+		eventLock:     new(sync.Mutex),
+		inventoryLock: new(sync.Mutex),
 	}
 	bm.cronJobs = []IBaremetalCronJob{
 		NewLogFetchJob(bm, time.Duration(o.Options.LogFetchIntervalSeconds)*time.Second),
 		NewSendMetricsJob(bm, time.Duration(o.Options.SendMetricsIntervalSeconds)*time.Second),
 		NewStatusProbeJob(bm, time.Duration(o.Options.StatusProbeIntervalSeconds)*time.Second),
+		NewRedfishEventSubscribeJob(bm, time.Duration(o.Options.RedfishEventSubscribeIntervalSeconds)*time.Second),
+		NewInventorySyncJob(bm, time.Duration(o.Options.InventorySyncIntervalSeconds)*time.Second),
 	}
 	err := os.MkdirAll(bm.GetDir(), 0755)
 	if err != nil {
@@ -587,7 +597,7 @@ func (b *SBaremetalInstance) GetName() string {
 }
 
 func (b *SBaremetalInstance) Stop() {
-	// TODO:
+	b.StopRedfishEventStream()
 }
 
 func (b *SBaremetalInstance) GetDir() string {
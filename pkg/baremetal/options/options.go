@@ -52,6 +52,10 @@ type BaremetalOptions struct {
 	LogFetchIntervalSeconds    int `help:"interval to fetch baremetal log, default is 900 seconds" default:"900"`
 	SendMetricsIntervalSeconds int `help:"interval to send baremetal metrics, default is 300 seconds" default:"300"`
 
+	InventorySyncIntervalSeconds         int    `help:"interval to sync baremetal hardware inventory, default is 3600 seconds" default:"3600"`
+	RedfishEventMode                     string `help:"how to receive redfish events from BMC" default:"webhook" choices:"webhook|sse|off"`
+	RedfishEventSubscribeIntervalSeconds int    `help:"interval to renew redfish event subscription, default is 3600 seconds" default:"3600"`
+
 	TftpFileMap            map[string]string `help:"map of filename to real file path for tftp"`
 	BootLoader             string            `help:"PXE boot loader" default:"grub"`
 	EnableGrubTftpDownload bool              `help:"Enable grub using tftp to download kernel and initrd"`
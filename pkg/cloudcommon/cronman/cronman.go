@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"time"
 
@@ -43,6 +44,9 @@ var (
 type TCronJobFunction func(ctx context.Context, userCred mcclient.TokenCredential, isStart bool)
 type TCronJobFunctionWithStartTime func(ctx context.Context, userCred mcclient.TokenCredential, start time.Time, isStart bool)
 
+// TCronJobFunctionWithError is a job whose error is recorded in the run history
+type TCronJobFunctionWithError func(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) error
+
 var manager *SCronJobManager
 
 type ICronTimer interface {
@@ -57,6 +61,10 @@ func (t *Timer1) Next(now time.Time) time.Time {
 	return now.Add(t.dur)
 }
 
+func (t *Timer1) String() string {
+	return fmt.Sprintf("every %s", t.dur)
+}
+
 type Timer2 struct {
 	day, hour, min, sec int
 }
@@ -70,6 +78,10 @@ func (t *Timer2) Next(now time.Time) time.Time {
 	return nextTime
 }
 
+func (t *Timer2) String() string {
+	return fmt.Sprintf("every %d days at %02d:%02d:%02d", t.day, t.hour, t.min, t.sec)
+}
+
 type TimerHour struct {
 	hour, min, sec int
 }
@@ -83,14 +95,61 @@ func (t *TimerHour) Next(now time.Time) time.Time {
 	return nextTime
 }
 
+func (t *TimerHour) String() string {
+	return fmt.Sprintf("every %d hours at xx:%02d:%02d", t.hour, t.min, t.sec)
+}
+
+// maxCronJobHistory is the number of recent runs kept for each job
+const maxCronJobHistory = 20
+
+type SCronJobRun struct {
+	Start time.Time `json:"start"`
+	// seconds
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+	// triggered by the admin API rather than the timer
+	Manual bool `json:"manual"`
+}
+
+type SCronJobStatus struct {
+	Name    string    `json:"name"`
+	Timer   string    `json:"timer"`
+	Next    time.Time `json:"next"`
+	Paused  bool      `json:"paused"`
+	Running int       `json:"running"`
+	// runs in this process since it started
+	RunCount  int64         `json:"run_count"`
+	FailCount int64         `json:"fail_count"`
+	LastRun   *SCronJobRun  `json:"last_run,omitempty"`
+	History   []SCronJobRun `json:"history"`
+}
+
+// ICronJobRunStore persists the run history of jobs, so that it survives
+// restarts and is shared by the replicas of a service
+type ICronJobRunStore interface {
+	SaveRun(name string, run SCronJobRun) error
+	// FetchRuns returns at most limit latest runs of job name, oldest first
+	FetchRuns(name string, limit int) ([]SCronJobRun, error)
+}
+
 type SCronJob struct {
 	Name             string
 	job              TCronJobFunction
 	jobWithStartTime TCronJobFunctionWithStartTime
+	jobWithError     TCronJobFunctionWithError
 	Timer            ICronTimer
 	Next             time.Time
 	StartRun         bool
 	times            []time.Time
+
+	// paused jobs are still scheduled but skipped when due
+	Paused bool
+
+	statusLock sync.Mutex
+	running    int
+	runCount   int64
+	failCount  int64
+	history    []SCronJobRun
 }
 
 type CronJobTimerHeap []*SCronJob
@@ -140,6 +199,7 @@ type SCronJobManager struct {
 	running  bool
 	workers  *appsrv.SWorkerManager
 	dataLock *sync.Mutex
+	runStore ICronJobRunStore
 }
 
 func InitCronJobManager(isDbWorker bool, workerCount int) *SCronJobManager {
@@ -158,6 +218,14 @@ func GetCronJobManager() *SCronJobManager {
 	return manager
 }
 
+// SetRunStore keeps the run history of jobs in store instead of memory
+func (self *SCronJobManager) SetRunStore(store ICronJobRunStore) {
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	self.runStore = store
+}
+
 func (self *SCronJobManager) IsNameUnique(name string) bool {
 	for i := 0; i < len(self.jobs); i++ {
 		if self.jobs[i].Name == name {
@@ -235,6 +303,36 @@ func (self *SCronJobManager) AddJobAtIntervalsWithStartRun(name string, interval
 	return nil
 }
 
+// AddJobAtIntervalsWithError adds a job whose returned error is recorded as
+// the failure of the run
+func (self *SCronJobManager) AddJobAtIntervalsWithError(name string, interval time.Duration, jobFunc TCronJobFunctionWithError, startRun bool) error {
+	if interval <= 0 {
+		return errors.Error("AddJobAtIntervals: interval must > 0")
+	}
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	if !self.IsNameUnique(name) {
+		return ErrCronJobNameConflict
+	}
+
+	t := Timer1{
+		dur: interval,
+	}
+	job := SCronJob{
+		Name:         name,
+		jobWithError: jobFunc,
+		Timer:        &t,
+		StartRun:     startRun,
+	}
+	if !self.running {
+		self.jobs = append(self.jobs, &job)
+	} else {
+		self.addJob(&job)
+	}
+	return nil
+}
+
 func (self *SCronJobManager) AddJobEveryFewDays(name string, day, hour, min, sec int, jobFunc TCronJobFunction, startRun bool) error {
 	switch {
 	case day <= 0:
@@ -310,6 +408,48 @@ func (self *SCronJobManager) AddJobEveryFewHour(name string, hour, min, sec int,
 	return nil
 }
 
+// AddJobWithCronExpression adds a job scheduled by a cron expression, see TimerCron for the syntax,
+// e.g. "0 2 * * MON#1" with timezone "Asia/Shanghai" runs at 02:00 on the first Monday of each month
+func (self *SCronJobManager) AddJobWithCronExpression(name string, expr string, timezone string, jobFunc TCronJobFunction, startRun bool) error {
+	return self.addJobWithCronExpression(expr, timezone, &SCronJob{
+		Name:     name,
+		job:      jobFunc,
+		StartRun: startRun,
+	})
+}
+
+// AddJobWithCronExpressionWithError is AddJobWithCronExpression of a job
+// whose returned error is recorded as the failure of the run
+func (self *SCronJobManager) AddJobWithCronExpressionWithError(name string, expr string, timezone string, jobFunc TCronJobFunctionWithError, startRun bool) error {
+	return self.addJobWithCronExpression(expr, timezone, &SCronJob{
+		Name:         name,
+		jobWithError: jobFunc,
+		StartRun:     startRun,
+	})
+}
+
+func (self *SCronJobManager) addJobWithCronExpression(expr string, timezone string, job *SCronJob) error {
+	t, err := NewTimerCron(expr, timezone)
+	if err != nil {
+		return errors.Wrap(err, "AddJobWithCronExpression")
+	}
+	job.Timer = t
+
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	if !self.IsNameUnique(job.Name) {
+		return ErrCronJobNameConflict
+	}
+
+	if !self.running {
+		self.jobs = append(self.jobs, job)
+	} else {
+		self.addJob(job)
+	}
+	return nil
+}
+
 func (self *SCronJobManager) addJob(newJob *SCronJob) {
 	now := time.Now()
 	newJob.Next = newJob.Timer.Next(now)
@@ -338,6 +478,77 @@ func (self *SCronJobManager) Remove(name string) error {
 	return nil
 }
 
+func (self *SCronJobManager) findJob(name string) *SCronJob {
+	for i := 0; i < len(self.jobs); i++ {
+		if self.jobs[i].Name == name {
+			return self.jobs[i]
+		}
+	}
+	return nil
+}
+
+// Pause skips the runs of job name until it is resumed
+func (self *SCronJobManager) Pause(name string) error {
+	return self.setPaused(name, true)
+}
+
+func (self *SCronJobManager) Resume(name string) error {
+	return self.setPaused(name, false)
+}
+
+func (self *SCronJobManager) setPaused(name string, paused bool) error {
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	job := self.findJob(name)
+	if job == nil {
+		return errors.Wrapf(errors.ErrNotFound, "job %s", name)
+	}
+	job.Paused = paused
+	return nil
+}
+
+// Trigger runs job name immediately, the schedule of the job is not changed
+func (self *SCronJobManager) Trigger(name string) error {
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+
+	job := self.findJob(name)
+	if job == nil {
+		return errors.Wrapf(errors.ErrNotFound, "job %s", name)
+	}
+	self.workers.Run(&sCronJobManualRun{job: job, start: time.Now()}, nil, nil)
+	return nil
+}
+
+func (self *SCronJobManager) GetJobStatus(name string) (*SCronJobStatus, error) {
+	self.dataLock.Lock()
+	job := self.findJob(name)
+	store := self.runStore
+	self.dataLock.Unlock()
+
+	if job == nil {
+		return nil, errors.Wrapf(errors.ErrNotFound, "job %s", name)
+	}
+	return job.getStatus(store), nil
+}
+
+// GetJobsStatus returns status of all jobs sorted by name
+func (self *SCronJobManager) GetJobsStatus() []SCronJobStatus {
+	self.dataLock.Lock()
+	jobs := make([]*SCronJob, len(self.jobs))
+	copy(jobs, self.jobs)
+	store := self.runStore
+	self.dataLock.Unlock()
+
+	ret := make([]SCronJobStatus, 0, len(jobs))
+	for i := 0; i < len(jobs); i++ {
+		ret = append(ret, *jobs[i].getStatus(store))
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
 func (self *SCronJobManager) next(now time.Time) {
 	for _, job := range self.jobs {
 		job.Next = job.Timer.Next(now)
@@ -415,7 +626,11 @@ func (self *SCronJobManager) runJobs(now time.Time) {
 	defer self.dataLock.Unlock()
 	for i := 0; i < len(self.jobs); i++ {
 		if !(self.jobs[i].Next.After(now) || self.jobs[i].Next.IsZero()) {
-			self.jobs[i].runJob(false, now)
+			if self.jobs[i].Paused {
+				log.Debugf("Cron job: %s is paused, skip", self.jobs[i].Name)
+			} else {
+				self.jobs[i].runJob(false, now)
+			}
 			self.jobs[i].Next = self.jobs[i].Timer.Next(now)
 			heap.Fix(&self.jobs, i)
 		}
@@ -428,7 +643,7 @@ func (job *SCronJob) Run() {
 		startTime = job.times[0]
 		job.times = job.times[1:]
 	}
-	job.runJobInWorker(job.StartRun, startTime)
+	job.runJobInWorker(job.StartRun, startTime, false)
 }
 
 func (job *SCronJob) Dump() string {
@@ -441,13 +656,102 @@ func (job *SCronJob) runJob(isStart bool, now time.Time) {
 	manager.workers.Run(job, nil, nil)
 }
 
-func (job *SCronJob) runJobInWorker(isStart bool, startTime time.Time) {
+type sCronJobManualRun struct {
+	job   *SCronJob
+	start time.Time
+}
+
+func (run *sCronJobManualRun) Run() {
+	run.job.runJobInWorker(false, run.start, true)
+}
+
+func (run *sCronJobManualRun) Dump() string {
+	return ""
+}
+
+func (job *SCronJob) beginRun() {
+	job.statusLock.Lock()
+	defer job.statusLock.Unlock()
+
+	job.running++
+}
+
+func (job *SCronJob) endRun(run SCronJobRun, store ICronJobRunStore) {
+	job.statusLock.Lock()
+	job.running--
+	job.runCount++
+	if len(run.Error) > 0 {
+		job.failCount++
+	}
+	job.history = append(job.history, run)
+	if len(job.history) > maxCronJobHistory {
+		job.history = job.history[len(job.history)-maxCronJobHistory:]
+	}
+	job.statusLock.Unlock()
+
+	if store != nil {
+		if err := store.SaveRun(job.Name, run); err != nil {
+			log.Errorf("Cron job: %s save run: %v", job.Name, err)
+		}
+	}
+}
+
+func (job *SCronJob) getStatus(store ICronJobRunStore) *SCronJobStatus {
+	var history []SCronJobRun
+	if store != nil {
+		runs, err := store.FetchRuns(job.Name, maxCronJobHistory)
+		if err != nil {
+			log.Errorf("Cron job: %s fetch runs: %v", job.Name, err)
+		} else {
+			history = runs
+		}
+	}
+
+	job.statusLock.Lock()
+	defer job.statusLock.Unlock()
+
+	if history == nil {
+		history = make([]SCronJobRun, len(job.history))
+		copy(history, job.history)
+	}
+	status := &SCronJobStatus{
+		Name:      job.Name,
+		Timer:     fmt.Sprintf("%s", job.Timer),
+		Next:      job.Next,
+		Paused:    job.Paused,
+		Running:   job.running,
+		RunCount:  job.runCount,
+		FailCount: job.failCount,
+		History:   history,
+	}
+	if len(status.History) > 0 {
+		last := status.History[len(status.History)-1]
+		status.LastRun = &last
+	}
+	return status
+}
+
+func (job *SCronJob) runJobInWorker(isStart bool, startTime time.Time, manual bool) {
+	run := SCronJobRun{
+		Start:  time.Now(),
+		Manual: manual,
+	}
+	var store ICronJobRunStore
+	if manager != nil {
+		manager.dataLock.Lock()
+		store = manager.runStore
+		manager.dataLock.Unlock()
+	}
+	job.beginRun()
 	defer func() {
 		if r := recover(); r != nil {
 			log.Errorf("CronJob task %s run error: %s", job.Name, r)
 			debug.PrintStack()
 			yunionconf.BugReport.SendBugReport(context.Background(), version.GetShortString(), string(debug.Stack()), errors.Errorf("%s", r))
+			run.Error = fmt.Sprintf("%s", r)
 		}
+		run.Duration = time.Since(run.Start).Seconds()
+		job.endRun(run, store)
 	}()
 
 	log.Debugf("Cron job: %s started, startTime: %s", job.Name, startTime.Format(time.RFC3339))
@@ -459,5 +763,10 @@ func (job *SCronJob) runJobInWorker(isStart bool, startTime time.Time) {
 		job.job(ctx, userCred, isStart)
 	} else if job.jobWithStartTime != nil {
 		job.jobWithStartTime(ctx, userCred, startTime, isStart)
+	} else if job.jobWithError != nil {
+		if err := job.jobWithError(ctx, userCred, isStart); err != nil {
+			log.Errorf("CronJob task %s run error: %v", job.Name, err)
+			run.Error = err.Error()
+		}
 	}
 }
@@ -16,9 +16,12 @@ package cronman
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"yunion.io/x/pkg/errors"
+
 	"yunion.io/x/onecloud/pkg/mcclient"
 )
 
@@ -40,3 +43,123 @@ func TestSCronJobManager_AddRemoveJobs(t *testing.T) {
 	manager.AddJobEveryFewDays("Test7", 1, 1, 1, 1, testFunc, false)
 	t.Logf("Jobs \n%s", manager.String())
 }
+
+func TestTimerCron_Next(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load time zone: %s", err)
+	}
+	cases := []struct {
+		expr string
+		tz   string
+		now  time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", "UTC", time.Date(2023, 5, 1, 10, 7, 30, 0, time.UTC), time.Date(2023, 5, 1, 10, 15, 0, 0, time.UTC)},
+		{"30 10 * * * *", "UTC", time.Date(2023, 5, 1, 10, 7, 30, 0, time.UTC), time.Date(2023, 5, 1, 10, 10, 30, 0, time.UTC)},
+		{"0 2 * * MON#1", "Asia/Shanghai", time.Date(2023, 5, 1, 0, 0, 0, 0, shanghai), time.Date(2023, 5, 1, 2, 0, 0, 0, shanghai)},
+		{"0 2 * * MON#1", "Asia/Shanghai", time.Date(2023, 5, 1, 2, 0, 0, 0, shanghai), time.Date(2023, 6, 5, 2, 0, 0, 0, shanghai)},
+		// 02:00 in Shanghai is 18:00 UTC of the previous day
+		{"0 2 * * *", "Asia/Shanghai", time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), time.Date(2023, 5, 1, 18, 0, 0, 0, time.UTC)},
+		{"0 0 L * *", "UTC", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 23 * * FRIL", "UTC", time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 5, 26, 23, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 0", "UTC", time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 5, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", "UTC", time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", "UTC", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, c := range cases {
+		timer, err := NewTimerCron(c.expr, c.tz)
+		if err != nil {
+			t.Fatalf("NewTimerCron %s: %s", c.expr, err)
+		}
+		got := timer.Next(c.now)
+		if !got.Equal(c.want) {
+			t.Errorf("%s next of %s: want %s got %s", c.expr, c.now, c.want, got)
+		}
+	}
+}
+
+func TestNewTimerCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * MON#6",
+		"*/0 * * * *",
+		"5-1 * * * *",
+	} {
+		if _, err := NewTimerCron(expr, ""); err == nil {
+			t.Errorf("%s: expect error", expr)
+		}
+	}
+	if _, err := NewTimerCron("* * * * *", "No/SuchZone"); err == nil {
+		t.Errorf("expect error of invalid time zone")
+	}
+}
+
+type fakeRunStore struct {
+	lock sync.Mutex
+	runs map[string][]SCronJobRun
+}
+
+func (s *fakeRunStore) SaveRun(name string, run SCronJobRun) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.runs[name] = append(s.runs[name], run)
+	return nil
+}
+
+func (s *fakeRunStore) FetchRuns(name string, limit int) ([]SCronJobRun, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	runs := s.runs[name]
+	if len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	return append([]SCronJobRun{}, runs...), nil
+}
+
+func TestSCronJob_RunWithError(t *testing.T) {
+	generator := DefaultAdminSessionGenerator
+	DefaultAdminSessionGenerator = func() mcclient.TokenCredential { return nil }
+	defer func() { DefaultAdminSessionGenerator = generator }()
+
+	man := InitCronJobManager(false, 1)
+	store := &fakeRunStore{runs: map[string][]SCronJobRun{}}
+	man.SetRunStore(store)
+	defer man.SetRunStore(nil)
+
+	fail := true
+	job := &SCronJob{
+		Name:  "TestError",
+		Timer: &Timer1{dur: time.Hour},
+		jobWithError: func(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) error {
+			if fail {
+				return errors.Error("boom")
+			}
+			return nil
+		},
+	}
+	job.runJobInWorker(false, time.Now(), true)
+	fail = false
+	job.runJobInWorker(false, time.Now(), false)
+
+	status := job.getStatus(store)
+	if status.RunCount != 2 || status.FailCount != 1 {
+		t.Errorf("run count %d, fail count %d", status.RunCount, status.FailCount)
+	}
+	if len(status.History) != 2 || status.History[0].Error != "boom" || !status.History[0].Manual {
+		t.Errorf("history %#v", status.History)
+	}
+	if status.LastRun == nil || status.LastRun.Error != "" {
+		t.Errorf("last run %#v", status.LastRun)
+	}
+
+	// history is read from the store, e.g. saved before restart
+	store.runs["TestError"] = append([]SCronJobRun{{Error: "before restart"}}, store.runs["TestError"]...)
+	if status := job.getStatus(store); len(status.History) != 3 || status.History[0].Error != "before restart" {
+		t.Errorf("history %#v", status.History)
+	}
+}
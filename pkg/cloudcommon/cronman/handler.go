@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronman
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/appctx"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+// AddCronJobHandler adds the admin API of cron jobs:
+//
+//	GET  <prefix>/cronjobs
+//	GET  <prefix>/cronjobs/<name>
+//	POST <prefix>/cronjobs/<name>/pause|resume|trigger
+func AddCronJobHandler(prefix string, app *appsrv.Application) {
+	prefix = fmt.Sprintf("%s/cronjobs", prefix)
+	app.AddHandler2("GET", prefix, auth.Authenticate(listCronJobsHandler), nil, "list_cronjobs", nil)
+	app.AddHandler2("GET", fmt.Sprintf("%s/<name>", prefix), auth.Authenticate(getCronJobHandler), nil, "get_cronjob", nil)
+	app.AddHandler2("POST", fmt.Sprintf("%s/<name>/<action>", prefix), auth.Authenticate(performCronJobHandler), nil, "perform_cronjob_action", nil)
+}
+
+func fetchCronJobManager(ctx context.Context, w http.ResponseWriter) *SCronJobManager {
+	userCred := auth.FetchUserCredential(ctx, nil)
+	if !userCred.HasSystemAdminPrivilege() {
+		httperrors.ForbiddenError(ctx, w, "Not allow to access cron jobs")
+		return nil
+	}
+	if manager == nil {
+		httperrors.NotFoundError(ctx, w, "Cron job manager not initialized")
+		return nil
+	}
+	return manager
+}
+
+func listCronJobsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	man := fetchCronJobManager(ctx, w)
+	if man == nil {
+		return
+	}
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.Marshal(man.GetJobsStatus()), "cronjobs")
+	appsrv.SendJSON(w, ret)
+}
+
+func getCronJobHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	man := fetchCronJobManager(ctx, w)
+	if man == nil {
+		return
+	}
+	params := appctx.AppContextParams(ctx)
+	status, err := man.GetJobStatus(params["<name>"])
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.Marshal(status), "cronjob")
+	appsrv.SendJSON(w, ret)
+}
+
+func performCronJobHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	man := fetchCronJobManager(ctx, w)
+	if man == nil {
+		return
+	}
+	params := appctx.AppContextParams(ctx)
+	name := params["<name>"]
+	var err error
+	switch action := params["<action>"]; action {
+	case "pause":
+		err = man.Pause(name)
+	case "resume":
+		err = man.Resume(name)
+	case "trigger":
+		err = man.Trigger(name)
+	default:
+		err = errors.Wrapf(httperrors.ErrBadRequest, "unsupported action %s", action)
+	}
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	status, err := man.GetJobStatus(name)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.Marshal(status), "cronjob")
+	appsrv.SendJSON(w, ret)
+}
@@ -0,0 +1,302 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	ErrInvalidCronExpression = errors.Error("invalid cron expression")
+
+	// Next gives up if no time matches within this many years, e.g. 0 0 30 2 *
+	cronSearchYears = 5
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+type cronBits uint64
+
+func (b cronBits) has(i int) bool {
+	return b&(1<<uint(i)) != 0
+}
+
+// TimerCron fires at the times matched by a cron expression in the given time zone
+//
+// Both five fields (minute hour day-of-month month day-of-week) and six fields
+// (second minute hour day-of-month month day-of-week) are supported. Besides the
+// standard syntax of *, lists, ranges and steps, the following extensions are accepted:
+//
+//	L in day-of-month: the last day of the month
+//	MON#1 in day-of-week: the first Monday of the month
+//	FRIL in day-of-week: the last Friday of the month
+//	@yearly, @monthly, @weekly, @daily and @hourly
+//
+// As standard cron, when both day-of-month and day-of-week are restricted,
+// a day matching either of them matches.
+type TimerCron struct {
+	expr string
+	loc  *time.Location
+
+	second, minute, hour, dom, month, dow cronBits
+
+	domLast bool
+	// bit n set for the n-th weekday of the month
+	dowNth [7]cronBits
+	// last weekday of the month
+	dowLast [7]bool
+
+	domAny, dowAny bool
+}
+
+// NewTimerCron parses expr, timezone is an IANA time zone name like Asia/Shanghai,
+// the local time zone is used when timezone is empty
+func NewTimerCron(expr string, timezone string) (*TimerCron, error) {
+	loc := time.Local
+	if len(timezone) > 0 {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "time.LoadLocation %s", timezone)
+		}
+	}
+	t := &TimerCron{expr: strings.TrimSpace(expr), loc: loc}
+	spec := t.expr
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Wrapf(ErrInvalidCronExpression, "%q: expect 5 or 6 fields, got %d", expr, len(fields))
+	}
+	var err error
+	if t.second, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.Wrapf(err, "second field of %q", expr)
+	}
+	if t.minute, _, err = parseCronField(fields[1], 0, 59, nil); err != nil {
+		return nil, errors.Wrapf(err, "minute field of %q", expr)
+	}
+	if t.hour, _, err = parseCronField(fields[2], 0, 23, nil); err != nil {
+		return nil, errors.Wrapf(err, "hour field of %q", expr)
+	}
+	if err := t.parseDom(fields[3]); err != nil {
+		return nil, errors.Wrapf(err, "day-of-month field of %q", expr)
+	}
+	if t.month, _, err = parseCronField(fields[4], 1, 12, cronMonthNames); err != nil {
+		return nil, errors.Wrapf(err, "month field of %q", expr)
+	}
+	if err := t.parseDow(fields[5]); err != nil {
+		return nil, errors.Wrapf(err, "day-of-week field of %q", expr)
+	}
+	return t, nil
+}
+
+func (t *TimerCron) parseDom(field string) error {
+	items := make([]string, 0)
+	for _, item := range strings.Split(field, ",") {
+		if strings.ToUpper(item) == "L" {
+			t.domLast = true
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	var err error
+	t.dom, t.domAny, err = parseCronField(strings.Join(items, ","), 1, 31, nil)
+	return err
+}
+
+func (t *TimerCron) parseDow(field string) error {
+	items := make([]string, 0)
+	for _, item := range strings.Split(field, ",") {
+		upper := strings.ToUpper(item)
+		if pos := strings.Index(upper, "#"); pos > 0 {
+			wd, err := parseCronValue(upper[:pos], 0, 7, cronWeekdayNames)
+			if err != nil {
+				return err
+			}
+			nth, err := strconv.Atoi(upper[pos+1:])
+			if err != nil || nth < 1 || nth > 5 {
+				return errors.Wrapf(ErrInvalidCronExpression, "invalid weekday ordinal %q", item)
+			}
+			t.dowNth[wd%7] |= 1 << uint(nth)
+			continue
+		}
+		if len(upper) > 1 && strings.HasSuffix(upper, "L") {
+			wd, err := parseCronValue(upper[:len(upper)-1], 0, 7, cronWeekdayNames)
+			if err != nil {
+				return err
+			}
+			t.dowLast[wd%7] = true
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	bits, isAny, err := parseCronField(strings.Join(items, ","), 0, 7, cronWeekdayNames)
+	if err != nil {
+		return err
+	}
+	// both 0 and 7 are Sunday
+	if bits.has(7) {
+		bits = (bits | 1) &^ (1 << 7)
+	}
+	t.dow, t.dowAny = bits, isAny
+	return nil
+}
+
+func parseCronValue(val string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(val)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(val)
+	if err != nil || v < min || v > max {
+		return 0, errors.Wrapf(ErrInvalidCronExpression, "%q out of range [%d, %d]", val, min, max)
+	}
+	return v, nil
+}
+
+// parseCronField parses a comma separated list of a, a-b, */n, a-b/n or a/n,
+// the returned bool is true if the field is * or ?
+func parseCronField(field string, min, max int, names map[string]int) (cronBits, bool, error) {
+	if field == "*" || field == "?" {
+		bits := cronBits(0)
+		for i := min; i <= max; i++ {
+			bits |= 1 << uint(i)
+		}
+		return bits, true, nil
+	}
+	bits := cronBits(0)
+	for _, item := range strings.Split(field, ",") {
+		rangeStr, step := item, 1
+		if pos := strings.Index(item, "/"); pos >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[pos+1:])
+			if err != nil || step <= 0 {
+				return 0, false, errors.Wrapf(ErrInvalidCronExpression, "invalid step %q", item)
+			}
+			rangeStr = item[:pos]
+		}
+		var start, end int
+		switch {
+		case rangeStr == "*" || rangeStr == "?":
+			start, end = min, max
+		case strings.Contains(rangeStr, "-"):
+			parts := strings.SplitN(rangeStr, "-", 2)
+			var err error
+			if start, err = parseCronValue(parts[0], min, max, names); err != nil {
+				return 0, false, err
+			}
+			if end, err = parseCronValue(parts[1], min, max, names); err != nil {
+				return 0, false, err
+			}
+			if start > end {
+				return 0, false, errors.Wrapf(ErrInvalidCronExpression, "invalid range %q", item)
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangeStr, min, max, names); err != nil {
+				return 0, false, err
+			}
+			end = start
+			if step > 1 {
+				// a/n means from a to max every n
+				end = max
+			}
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, false, nil
+}
+
+func (t *TimerCron) dayMatches(tm time.Time) bool {
+	day := tm.Day()
+	lastDay := time.Date(tm.Year(), tm.Month()+1, 0, 0, 0, 0, 0, t.loc).Day()
+	wd := int(tm.Weekday())
+
+	domMatch := t.dom.has(day) || (t.domLast && day == lastDay)
+	dowMatch := t.dow.has(wd) || t.dowNth[wd].has((day-1)/7+1) || (t.dowLast[wd] && day+7 > lastDay)
+	if t.domAny || t.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (t *TimerCron) Next(now time.Time) time.Time {
+	tm := now.In(t.loc)
+	tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second()+1, 0, t.loc)
+	yearLimit := tm.Year() + cronSearchYears
+
+	for tm.Year() <= yearLimit {
+		if !t.month.has(int(tm.Month())) {
+			tm = time.Date(tm.Year(), tm.Month()+1, 1, 0, 0, 0, 0, t.loc)
+			continue
+		}
+		if !t.dayMatches(tm) {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day()+1, 0, 0, 0, 0, t.loc)
+			continue
+		}
+		if !t.hour.has(tm.Hour()) {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour()+1, 0, 0, 0, t.loc)
+			continue
+		}
+		if !t.minute.has(tm.Minute()) {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute()+1, 0, 0, t.loc)
+			continue
+		}
+		if !t.second.has(tm.Second()) {
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second()+1, 0, t.loc)
+			continue
+		}
+		return tm.In(now.Location())
+	}
+	return time.Time{}
+}
+
+func (t *TimerCron) String() string {
+	return fmt.Sprintf("cron(%s, %s)", t.expr, t.loc.String())
+}
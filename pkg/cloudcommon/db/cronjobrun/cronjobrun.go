@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronjobrun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+// maxRunsPerJob is the number of latest runs kept in db for each job
+const maxRunsPerJob = 100
+
+type SCronJobRunManager struct {
+	db.SResourceBaseManager
+}
+
+var CronJobRunManager *SCronJobRunManager
+
+func init() {
+	CronJobRunManager = &SCronJobRunManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SCronJobRun{},
+			"cronjobruns_tbl",
+			"cronjobrun",
+			"cronjobruns",
+		),
+	}
+	CronJobRunManager.SetVirtualObject(CronJobRunManager)
+}
+
+// SCronJobRun is a run of cron job, see cronman.ICronJobRunStore
+type SCronJobRun struct {
+	db.SResourceBase
+
+	Id int64 `primary:"true" auto_increment:"true"`
+
+	Name  string    `width:"128" charset:"ascii" nullable:"false" index:"true"`
+	Start time.Time `nullable:"false"`
+	// seconds
+	Duration float64 `nullable:"false"`
+	Error    string  `charset:"utf8" nullable:"true"`
+	Manual   bool    `nullable:"false"`
+}
+
+// SaveRun implements cronman.ICronJobRunStore, runs but the latest
+// maxRunsPerJob ones of the job are removed
+func (man *SCronJobRunManager) SaveRun(name string, run cronman.SCronJobRun) error {
+	r := &SCronJobRun{
+		Name:     name,
+		Start:    run.Start,
+		Duration: run.Duration,
+		Error:    run.Error,
+		Manual:   run.Manual,
+	}
+	r.SetModelManager(man, r)
+	if err := man.TableSpec().Insert(context.Background(), r); err != nil {
+		return errors.Wrap(err, "Insert")
+	}
+
+	q := man.Query("id").Equals("name", name).Desc("id").Limit(1).Offset(maxRunsPerJob)
+	var id int64
+	if err := q.Row().Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return errors.Wrap(err, "query expired runs")
+	}
+	stmt := fmt.Sprintf("delete from %s where name = ? and id <= ?", man.TableSpec().Name())
+	if _, err := sqlchemy.GetDB().Exec(stmt, name, id); err != nil {
+		return errors.Wrap(err, "remove expired runs")
+	}
+	return nil
+}
+
+// FetchRuns implements cronman.ICronJobRunStore
+func (man *SCronJobRunManager) FetchRuns(name string, limit int) ([]cronman.SCronJobRun, error) {
+	q := man.Query().Equals("name", name).Desc("id").Limit(limit)
+	runs := make([]SCronJobRun, 0, limit)
+	if err := db.FetchModelObjects(man, q, &runs); err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := make([]cronman.SCronJobRun, len(runs))
+	for i := range runs {
+		ret[len(runs)-1-i] = cronman.SCronJobRun{
+			Start:    runs[i].Start,
+			Duration: runs[i].Duration,
+			Error:    runs[i].Error,
+			Manual:   runs[i].Manual,
+		}
+	}
+	return ret, nil
+}
@@ -263,6 +263,7 @@ const (
 	ACT_GUEST_CREATE_FROM_IMPORT_SUCC    = "guest_create_from_import_succ"
 	ACT_GUEST_CREATE_FROM_IMPORT_FAIL    = "guest_create_from_import_fail"
 	ACT_GUEST_PANICKED                   = "guest_panicked"
+	ACT_GUEST_WATCHDOG                   = "guest_watchdog"
 	ACT_HOST_MAINTENANCE                 = "host_maintenance"
 	ACT_HOST_DOWN                        = "host_down"
 
@@ -331,4 +332,11 @@ const (
 	ACT_STOP_RESCUE_FAILED  = "stop_rescue_failed"
 
 	ACT_RE_BILLING = "re_billing"
+
+	ACT_ROTATE_KEYS      = "rotate_keys"
+	ACT_ROTATE_KEYS_FAIL = "rotate_keys_fail"
+
+	ACT_APPROVE = "approve"
+
+	ACT_HARDWARE_HEALTH = "hardware_health"
 )
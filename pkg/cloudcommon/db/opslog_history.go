@@ -0,0 +1,257 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	// key of field diffs in opslog notes which carry other info as well
+	OPSLOG_FIELD_DIFFS_KEY = "field_diffs"
+)
+
+// SHistoryJointField is a field of resource in history that tracks joint resources
+type SHistoryJointField struct {
+	// keywords of resources whose attach or detach changes the field
+	Keywords []string
+	// current value of the field
+	Value jsonutils.JSONObject
+}
+
+// IHistoryJointFieldsModel is implemented by models whose history covers the
+// joint resources, e.g. networks of a server
+type IHistoryJointFieldsModel interface {
+	GetHistoryJointFields(ctx context.Context) (map[string]SHistoryJointField, error)
+}
+
+// historyTrackedActions change fields of resource, such an opslog without
+// field diffs makes the history incomplete
+var historyTrackedActions = []string{
+	ACT_UPDATE,
+	ACT_CHANGE_FLAVOR,
+	ACT_RESIZE,
+}
+
+// AddOpsLogFieldDiff records the old and new value of field in the notes of opslog,
+// so that the field can be replayed by history API
+func AddOpsLogFieldDiff(notes *jsonutils.JSONDict, field string, oldVal, newVal jsonutils.JSONObject) {
+	diff := jsonutils.NewDict()
+	diff.Set("old", oldVal)
+	diff.Set("new", newVal)
+	notes.Add(diff, OPSLOG_FIELD_DIFFS_KEY, field)
+}
+
+// AddOpsLogUpdateDiffs records the diffs returned by Update in the notes of opslog
+func AddOpsLogUpdateDiffs(notes *jsonutils.JSONDict, diffs sqlchemy.UpdateDiffs) {
+	if len(diffs) == 0 {
+		return
+	}
+	obj, err := jsonutils.ParseString(diffs.String())
+	if err != nil {
+		return
+	}
+	notes.Add(obj, OPSLOG_FIELD_DIFFS_KEY)
+}
+
+// ParseOpsLogFieldDiffs extracts the field level diff recorded in the notes of an opslog,
+// returns nil if the notes does not carry a field level diff
+func ParseOpsLogFieldDiffs(action string, notes string) map[string]apis.OpsLogFieldDiff {
+	if action == ACT_UPDATE_STATUS {
+		// notes of status update is in format of "old=>new[: reason]"
+		parts := strings.SplitN(notes, "=>", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		newStatus := parts[1]
+		if pos := strings.Index(newStatus, ": "); pos >= 0 {
+			newStatus = newStatus[:pos]
+		}
+		return map[string]apis.OpsLogFieldDiff{
+			"status": {
+				Old: jsonutils.NewString(parts[0]),
+				New: jsonutils.NewString(newStatus),
+			},
+		}
+	}
+	if !strings.HasPrefix(notes, "{") {
+		return nil
+	}
+	obj, err := jsonutils.ParseString(notes)
+	if err != nil {
+		return nil
+	}
+	dict, ok := obj.(*jsonutils.JSONDict)
+	if !ok || dict.Size() == 0 {
+		return nil
+	}
+	if diffs, err := dict.Get(OPSLOG_FIELD_DIFFS_KEY); err == nil {
+		dict, ok = diffs.(*jsonutils.JSONDict)
+		if !ok || dict.Size() == 0 {
+			return nil
+		}
+	}
+	ret := make(map[string]apis.OpsLogFieldDiff)
+	for field, val := range dict.Value() {
+		diff, ok := val.(*jsonutils.JSONDict)
+		if !ok || !diff.Contains("old") || !diff.Contains("new") {
+			// not a sqlchemy.UpdateDiffs
+			return nil
+		}
+		oldVal, _ := diff.Get("old")
+		newVal, _ := diff.Get("new")
+		ret[field] = apis.OpsLogFieldDiff{Old: oldVal, New: newVal}
+	}
+	return ret
+}
+
+// 获取资源在指定时间点的字段值, 通过从当前状态倒序回放操作日志中记录的字段变更得到
+func (model *SResourceBase) GetDetailsHistory(ctx context.Context, userCred mcclient.TokenCredential, input apis.ResourceHistoryInput) (apis.ResourceHistoryOutput, error) {
+	output := apis.ResourceHistoryOutput{}
+	if input.At.IsZero() {
+		return output, httperrors.NewMissingParameterError("at")
+	}
+	at := input.At.UTC()
+	if at.After(time.Now().UTC()) {
+		return output, httperrors.NewInputParameterError("at %s is in the future", input.At)
+	}
+	obj := model.GetIModel()
+	if at.Before(model.CreatedAt) {
+		return output, httperrors.NewResourceNotFoundError("%s %s does not exist at %s", obj.Keyword(), obj.GetId(), at)
+	}
+
+	manager := obj.GetModelManager()
+	includes, _ := GetDetailFields(manager, userCred)
+	current, ok := jsonutils.Marshal(obj).(*jsonutils.JSONDict)
+	if !ok {
+		return output, httperrors.NewInternalServerError("invalid marshal result of %s %s", obj.Keyword(), obj.GetId())
+	}
+	current = current.CopyIncludes(includes...)
+	jointKeywords := map[string]string{}
+	if jointModel, ok := obj.(IHistoryJointFieldsModel); ok {
+		jointFields, err := jointModel.GetHistoryJointFields(ctx)
+		if err != nil {
+			return output, errors.Wrap(err, "GetHistoryJointFields")
+		}
+		for field, jointField := range jointFields {
+			current.Set(field, jointField.Value)
+			includes = append(includes, field)
+			for _, keyword := range jointField.Keywords {
+				jointKeywords[keyword] = field
+			}
+		}
+	}
+
+	q := OpsLog.Query().Equals("obj_type", obj.Keyword()).Equals("obj_id", obj.GetId())
+	q = q.GT("ops_time", at).Desc("ops_time").Desc("id")
+	logs := make([]SOpsLog, 0)
+	err := FetchModelObjects(OpsLog, q, &logs)
+	if err != nil {
+		return output, errors.Wrap(err, "FetchModelObjects")
+	}
+
+	output.Changes, output.UnknownFields, output.Complete = replayOpsLogs(current, stringutils2.NewSortedStrings(includes), jointKeywords, logs)
+	output.At = at
+	output.Fields = current
+	return output, nil
+}
+
+// replayOpsLogs reverts the field diffs of logs in reverse order of time on current,
+// returns the unknown fields changed without recording diffs, complete is false if
+// any field may be unknown
+func replayOpsLogs(current *jsonutils.JSONDict, fields stringutils2.SSortedStrings, jointKeywords map[string]string, logs []SOpsLog) ([]apis.ResourceHistoryChange, []string, bool) {
+	changes := make([]apis.ResourceHistoryChange, 0)
+	unknown := map[string]bool{}
+	complete := true
+	for i := range logs {
+		change := apis.ResourceHistoryChange{
+			Id:      logs[i].GetId(),
+			Action:  logs[i].Action,
+			OpsTime: logs[i].OpsTime,
+			User:    logs[i].User,
+		}
+		diffs := ParseOpsLogFieldDiffs(logs[i].Action, logs[i].Notes)
+		if len(diffs) == 0 {
+			field, ok := getUntrackedChange(logs[i].Action, logs[i].Notes, jointKeywords)
+			if !ok {
+				continue
+			}
+			change.Incomplete = true
+			if len(field) > 0 {
+				// value of field is unknown until an earlier diff of it
+				current.Remove(field)
+				unknown[field] = true
+				change.Fields = []string{field}
+			} else {
+				complete = false
+			}
+			changes = append(changes, change)
+			continue
+		}
+		for field, diff := range diffs {
+			if !fields.Contains(field) {
+				continue
+			}
+			if diff.Old == nil || diff.Old == jsonutils.JSONNull {
+				current.Remove(field)
+			} else {
+				current.Set(field, diff.Old)
+			}
+			delete(unknown, field)
+			change.Fields = append(change.Fields, field)
+		}
+		if len(change.Fields) > 0 {
+			sort.Strings(change.Fields)
+			changes = append(changes, change)
+		}
+	}
+	unknownFields := make([]string, 0, len(unknown))
+	for field := range unknown {
+		unknownFields = append(unknownFields, field)
+	}
+	sort.Strings(unknownFields)
+	return changes, unknownFields, complete && len(unknownFields) == 0
+}
+
+// getUntrackedChange reports whether an opslog without field diffs may have changed
+// the fields, returns the joint field if it is the attach or detach of a joint resource
+func getUntrackedChange(action string, notes string, jointKeywords map[string]string) (string, bool) {
+	if utils.IsInStringArray(action, historyTrackedActions) {
+		return "", true
+	}
+	if action != ACT_ATTACH && action != ACT_DETACH {
+		return "", false
+	}
+	obj, err := jsonutils.ParseString(notes)
+	if err != nil {
+		return "", false
+	}
+	keyword, _ := obj.GetString("res_name")
+	field, ok := jointKeywords[keyword]
+	return field, ok
+}
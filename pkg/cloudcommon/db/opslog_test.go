@@ -16,7 +16,10 @@ package db
 import (
 	"testing"
 
+	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/util/timeutils"
+
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
 
 func TestCurrentTimestamp(t *testing.T) {
@@ -41,3 +44,120 @@ func TestCurrentTimestamp(t *testing.T) {
 		}
 	}
 }
+
+func TestParseOpsLogFieldDiffs(t *testing.T) {
+	cases := []struct {
+		action string
+		notes  string
+		want   map[string][2]string
+	}{
+		{
+			action: ACT_UPDATE,
+			notes:  `{"name":{"new":"vm-2","old":"vm-1"},"vcpu_count":{"new":4,"old":2}}`,
+			want: map[string][2]string{
+				"name":       {"vm-1", "vm-2"},
+				"vcpu_count": {"2", "4"},
+			},
+		},
+		{
+			action: ACT_UPDATE_STATUS,
+			notes:  "ready=>running: start by user",
+			want: map[string][2]string{
+				"status": {"ready", "running"},
+			},
+		},
+		{
+			action: ACT_UPDATE,
+			notes:  `{"name":"vm-1"}`,
+			want:   nil,
+		},
+		{
+			action: ACT_UPDATE,
+			notes:  "update by user",
+			want:   nil,
+		},
+		{
+			action: ACT_CHANGE_FLAVOR,
+			notes:  `{"name":"vm-1","res_name":"server","field_diffs":{"vcpu_count":{"new":4,"old":2}}}`,
+			want: map[string][2]string{
+				"vcpu_count": {"2", "4"},
+			},
+		},
+	}
+	for _, c := range cases {
+		got := ParseOpsLogFieldDiffs(c.action, c.notes)
+		if len(got) != len(c.want) {
+			t.Errorf("%s %s want %d diffs got %d", c.action, c.notes, len(c.want), len(got))
+			continue
+		}
+		for field, vals := range c.want {
+			diff, ok := got[field]
+			if !ok {
+				t.Errorf("%s %s missing field %s", c.action, c.notes, field)
+				continue
+			}
+			oldStr, _ := diff.Old.GetString()
+			newStr, _ := diff.New.GetString()
+			if oldStr != vals[0] || newStr != vals[1] {
+				t.Errorf("%s %s field %s want %s->%s got %s->%s", c.action, c.notes, field, vals[0], vals[1], oldStr, newStr)
+			}
+		}
+	}
+}
+
+func TestReplayOpsLogs(t *testing.T) {
+	current := jsonutils.NewDict()
+	current.Set("vcpu_count", jsonutils.NewInt(4))
+	current.Set("status", jsonutils.NewString("running"))
+	current.Set("networks", jsonutils.NewStringArray([]string{"net1", "net2"}))
+	fields := stringutils2.NewSortedStrings([]string{"vcpu_count", "status", "networks"})
+	jointKeywords := map[string]string{"network": "networks"}
+
+	logs := []SOpsLog{
+		{Action: ACT_ATTACH, Notes: `{"res_name":"network","field_diffs":{"networks":{"old":["net1"],"new":["net1","net2"]}}}`},
+		{Action: ACT_UPDATE_STATUS, Notes: "ready=>running"},
+		{Action: ACT_CHANGE_FLAVOR, Notes: `{"field_diffs":{"vcpu_count":{"old":2,"new":4}}}`},
+		// attach of resource not tracked by history
+		{Action: ACT_ATTACH, Notes: `{"res_name":"secgroup"}`},
+	}
+	changes, unknown, complete := replayOpsLogs(current, fields, jointKeywords, logs)
+	if !complete || len(unknown) > 0 {
+		t.Errorf("replay should be complete, unknown fields %v", unknown)
+	}
+	if len(changes) != 3 {
+		t.Errorf("want 3 changes got %d", len(changes))
+	}
+	want := `{"networks":["net1"],"status":"ready","vcpu_count":2}`
+	if current.String() != want {
+		t.Errorf("want %s got %s", want, current.String())
+	}
+
+	// detach without field diff, networks before it are unknown
+	current.Set("networks", jsonutils.NewStringArray([]string{"net1"}))
+	logs = []SOpsLog{
+		{Action: ACT_DETACH, Notes: `{"res_name":"network"}`},
+	}
+	_, unknown, complete = replayOpsLogs(current, fields, jointKeywords, logs)
+	if complete || len(unknown) != 1 || unknown[0] != "networks" || current.Contains("networks") {
+		t.Errorf("networks should be unknown, got %v %s", unknown, current)
+	}
+
+	// an earlier diff of the field makes it known again
+	logs = []SOpsLog{
+		{Action: ACT_DETACH, Notes: `{"res_name":"network"}`},
+		{Action: ACT_ATTACH, Notes: `{"res_name":"network","field_diffs":{"networks":{"old":[],"new":["net1","net2"]}}}`},
+	}
+	_, unknown, complete = replayOpsLogs(current, fields, jointKeywords, logs)
+	if !complete || len(unknown) > 0 {
+		t.Errorf("networks should be known, got %v", unknown)
+	}
+
+	// flavor changed without field diffs
+	logs = []SOpsLog{
+		{Action: ACT_CHANGE_FLAVOR, Notes: `{"add_cpu":2}`},
+	}
+	changes, _, complete = replayOpsLogs(current, fields, jointKeywords, logs)
+	if complete || len(changes) != 1 || !changes[0].Incomplete {
+		t.Errorf("change flavor without diffs should be incomplete")
+	}
+}
@@ -56,6 +56,10 @@ const (
 	PENDING_USAGE_KEY      = "__pending_usage__"
 	PARENT_TASK_NOTIFY_KEY = "__parent_task_notifyurl"
 	REQUEST_CONTEXT_KEY    = "__request_context"
+	STAGES_KEY             = "__stages"
+	FAILED_REASON_KEY      = "__failed_reason"
+	// params changed by SaveParams since the last stage transition
+	STAGE_PARAMS_DIFF_KEY = "__stage_params_diff"
 
 	TASK_STAGE_FAILED   = "failed"
 	TASK_STAGE_COMPLETE = "complete"
@@ -635,23 +639,52 @@ func (self *STask) SaveParams(data *jsonutils.JSONDict) error {
 	return self.SetStage("", data)
 }
 
+// paramsDiff returns the keys of data whose values differ from params, internal keys are ignored
+func paramsDiff(params *jsonutils.JSONDict, data *jsonutils.JSONDict) *jsonutils.JSONDict {
+	diff := jsonutils.NewDict()
+	for k, v := range data.Value() {
+		if strings.HasPrefix(k, "__") {
+			continue
+		}
+		prev, _ := params.Get(k)
+		if prev != nil && prev.Equals(v) {
+			continue
+		}
+		diff.Add(v, k)
+	}
+	return diff
+}
+
 func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
 	_, err := db.Update(self, func() error {
 		params := jsonutils.NewDict()
 		params.Update(self.Params)
 		if data != nil {
+			diff := paramsDiff(self.Params, data)
 			params.Update(data)
+			if diff.Size() > 0 {
+				pending, _ := params.Get(STAGE_PARAMS_DIFF_KEY)
+				if pendingDict, ok := pending.(*jsonutils.JSONDict); ok {
+					pendingDict.Update(diff)
+				} else {
+					params.Set(STAGE_PARAMS_DIFF_KEY, diff)
+				}
+			}
 		}
 		if len(stageName) > 0 {
-			stages, _ := params.Get("__stages")
+			stages, _ := params.Get(STAGES_KEY)
 			if stages == nil {
 				stages = jsonutils.NewArray()
-				params.Add(stages, "__stages")
+				params.Add(stages, STAGES_KEY)
 			}
 			stageList := stages.(*jsonutils.JSONArray)
 			stageData := jsonutils.NewDict()
 			stageData.Add(jsonutils.NewString(self.Stage), "name")
 			stageData.Add(jsonutils.NewTimeString(time.Now()), "complete_at")
+			if diff, _ := params.Get(STAGE_PARAMS_DIFF_KEY); diff != nil {
+				stageData.Add(diff, "params_diff")
+				params.Remove(STAGE_PARAMS_DIFF_KEY)
+			}
 			stageList.Add(stageData)
 			self.Stage = stageName
 		}
@@ -697,7 +730,7 @@ func (self *STask) SetStageFailed(ctx context.Context, reason jsonutils.JSONObje
 		reasonDict.Add(reason, "reason")
 	}
 	reason = reasonDict
-	prevFailed, _ := self.Params.Get("__failed_reason")
+	prevFailed, _ := self.Params.Get(FAILED_REASON_KEY)
 	if prevFailed != nil {
 		switch prevFailed.(type) {
 		case *jsonutils.JSONArray:
@@ -708,7 +741,7 @@ func (self *STask) SetStageFailed(ctx context.Context, reason jsonutils.JSONObje
 		}
 	}
 	data := jsonutils.NewDict()
-	data.Add(reason, "__failed_reason")
+	data.Add(reason, FAILED_REASON_KEY)
 	self.SetStage(TASK_STAGE_FAILED, data)
 	self.NotifyParentTaskFailure(ctx, reason)
 }
@@ -0,0 +1,318 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	// tasks of an object created in this period are returned when since is not specified
+	taskTreeDefaultPeriod = 7 * 24 * time.Hour
+	maxTaskTreeRoots      = 50
+	maxTaskTreeDepth      = 16
+)
+
+func (self *STask) isFinished() bool {
+	return self.Stage == TASK_STAGE_COMPLETE || self.Stage == TASK_STAGE_FAILED
+}
+
+// getFailedReason returns the stage and the reason of the latest failure
+func (self *STask) getFailedReason() (string, jsonutils.JSONObject) {
+	failed, _ := self.Params.Get(FAILED_REASON_KEY)
+	if failed == nil {
+		return "", nil
+	}
+	if arr, ok := failed.(*jsonutils.JSONArray); ok {
+		if arr.Length() == 0 {
+			return "", nil
+		}
+		failed, _ = arr.GetAt(arr.Length() - 1)
+	}
+	stage, _ := failed.GetString("stage")
+	reason, _ := failed.Get("reason")
+	return stage, reason
+}
+
+func (self *STask) getStages() []apis.TaskStageDetails {
+	ret := make([]apis.TaskStageDetails, 0)
+	stages, _ := self.Params.GetArray(STAGES_KEY)
+	startAt := self.CreatedAt
+	for i := range stages {
+		stage := apis.TaskStageDetails{StartAt: startAt}
+		stage.Name, _ = stages[i].GetString("name")
+		stage.CompleteAt, _ = stages[i].GetTime("complete_at")
+		stage.ParamsDiff, _ = stages[i].Get("params_diff")
+		ret = append(ret, stage)
+		startAt = stage.CompleteAt
+	}
+	if !self.isFinished() {
+		stage := apis.TaskStageDetails{Name: self.Stage, StartAt: startAt}
+		stage.ParamsDiff, _ = self.Params.Get(STAGE_PARAMS_DIFF_KEY)
+		ret = append(ret, stage)
+	}
+	return ret
+}
+
+func (self *STask) getSubtasks() ([]STask, error) {
+	subq := SubTaskManager.Query("subtask_id").Equals("task_id", self.Id).SubQuery()
+	q := TaskManager.Query().In("id", subq).Asc("created_at")
+	tasks := make([]STask, 0)
+	err := db.FetchModelObjects(TaskManager, q, &tasks)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return tasks, nil
+}
+
+func (self *STask) getTreeNode(depth int) apis.TaskTreeNode {
+	if self.Params == nil {
+		self.Params = jsonutils.NewDict()
+	}
+	node := apis.TaskTreeNode{
+		Id:        self.Id,
+		TaskName:  self.TaskName,
+		ObjName:   self.ObjName,
+		ObjId:     self.GetObjectIdStr(),
+		Stage:     self.Stage,
+		CreatedAt: self.CreatedAt,
+		UpdatedAt: self.UpdatedAt,
+		Stages:    self.getStages(),
+		Subtasks:  []apis.TaskTreeNode{},
+	}
+	switch self.Stage {
+	case TASK_STAGE_COMPLETE:
+		node.Status = apis.TASK_STATUS_COMPLETE
+	case TASK_STAGE_FAILED:
+		node.Status = apis.TASK_STATUS_FAILED
+		node.FailedStage, node.FailedReason = self.getFailedReason()
+		node.CanRetry = self.validateRetry() == nil
+	default:
+		node.Status = apis.TASK_STATUS_RUNNING
+	}
+	if depth >= maxTaskTreeDepth {
+		return node
+	}
+	subtasks, err := self.getSubtasks()
+	if err != nil {
+		log.Errorf("get subtasks of %s(%s): %s", self.TaskName, self.Id, err)
+		return node
+	}
+	for i := range subtasks {
+		node.Subtasks = append(node.Subtasks, subtasks[i].getTreeNode(depth+1))
+	}
+	return node
+}
+
+// getRootTask returns the topmost ancestor of the task
+func (self *STask) getRootTask() *STask {
+	root := self
+	visited := map[string]bool{root.Id: true}
+	for i := 0; i < maxTaskTreeDepth; i++ {
+		parent := root.GetParentTask()
+		if parent == nil || visited[parent.Id] {
+			break
+		}
+		visited[parent.Id] = true
+		root = parent
+	}
+	return root
+}
+
+func fetchTaskObjectModel(ctx context.Context, userCred mcclient.TokenCredential, objType string, objId string) (db.IStandaloneModel, error) {
+	manager := db.GetModelManager(objType)
+	if manager == nil {
+		return nil, httperrors.NewInputParameterError("unknown obj_type %s", objType)
+	}
+	obj, err := db.FetchByIdOrName(ctx, manager, userCred, objId)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "fetch %s %s", objType, objId))
+	}
+	model, ok := obj.(db.IStandaloneModel)
+	if !ok {
+		return nil, httperrors.NewInputParameterError("%s is not a standalone resource", objType)
+	}
+	return model, nil
+}
+
+// GetPropertyTree returns the task trees of an object or of a task, e.g.
+// GET /tasks/tree?obj_type=server&obj_id=vm1 or GET /tasks/tree?task_id=xxx
+func (manager *STaskManager) GetPropertyTree(ctx context.Context, userCred mcclient.TokenCredential, input apis.TaskTreeInput) (jsonutils.JSONObject, error) {
+	roots := make([]*STask, 0)
+	if len(input.TaskId) > 0 {
+		task := manager.fetchTask(input.TaskId)
+		if task == nil {
+			return nil, httperrors.NewResourceNotFoundError2(manager.Keyword(), input.TaskId)
+		}
+		if !userCred.HasSystemAdminPrivilege() {
+			// the task is visible to those who can see its object
+			objId := task.ObjId
+			if objId == MULTI_OBJECTS_ID {
+				objIds := TaskObjectManager.GetObjectIds(task)
+				if len(objIds) == 0 {
+					return nil, httperrors.NewForbiddenError("not allow to access task %s", task.Id)
+				}
+				objId = objIds[0]
+			}
+			if _, err := fetchTaskObjectModel(ctx, userCred, task.ObjName, objId); err != nil {
+				return nil, err
+			}
+		}
+		roots = append(roots, task)
+	} else {
+		if len(input.ObjType) == 0 || len(input.ObjId) == 0 {
+			return nil, httperrors.NewMissingParameterError("obj_type or task_id")
+		}
+		obj, err := fetchTaskObjectModel(ctx, userCred, input.ObjType, input.ObjId)
+		if err != nil {
+			return nil, err
+		}
+		since := input.Since
+		if since.IsZero() {
+			since = time.Now().UTC().Add(-taskTreeDefaultPeriod)
+		}
+		tasks, err := manager.FetchTasksOfObject(obj, since, nil)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrap(err, "FetchTasksOfObject"))
+		}
+		rootIds := map[string]bool{}
+		for i := range tasks {
+			task := &tasks[i]
+			if task.Params == nil {
+				task.Params = jsonutils.NewDict()
+			}
+			root := task.getRootTask()
+			if rootIds[root.Id] {
+				continue
+			}
+			rootIds[root.Id] = true
+			roots = append(roots, root)
+			if len(roots) >= maxTaskTreeRoots {
+				break
+			}
+		}
+	}
+	nodes := make([]apis.TaskTreeNode, 0, len(roots))
+	for i := range roots {
+		nodes = append(nodes, roots[i].getTreeNode(0))
+	}
+	ret := jsonutils.NewDict()
+	ret.Add(jsonutils.Marshal(nodes), "tasks")
+	return ret, nil
+}
+
+// validateRetry checks whether the task can be safely rescheduled at its failed stage
+func (self *STask) validateRetry() error {
+	stage, err := self.validateRetryStage()
+	if err != nil {
+		return err
+	}
+	if len(SubTaskManager.GetInitSubtasks(self.Id, stage)) > 0 {
+		return httperrors.NewInvalidStatusError("subtasks of stage %s are still running", stage)
+	}
+	if parentNotify, _ := self.Params.GetString(PARENT_TASK_NOTIFY_KEY); len(parentNotify) > 0 {
+		return httperrors.NewNotSupportedError("task %s is a subtask of a remote task, retry the remote task instead", self.Id)
+	}
+	if parent := self.GetParentTask(); parent != nil && parent.isFinished() {
+		return httperrors.NewNotSupportedError("parent task %s(%s) has finished, retry the parent task instead", parent.TaskName, parent.Id)
+	}
+	return nil
+}
+
+// validateRetryStage returns the failed stage of task if it can be run again
+func (self *STask) validateRetryStage() (string, error) {
+	if self.Stage != TASK_STAGE_FAILED {
+		return "", httperrors.NewInvalidStatusError("task %s is not failed", self.Id)
+	}
+	stage, _ := self.getFailedReason()
+	if len(stage) == 0 || stage == TASK_STAGE_COMPLETE || stage == TASK_STAGE_FAILED {
+		return "", httperrors.NewInvalidStatusError("failed stage of task %s unknown", self.Id)
+	}
+	taskType, ok := taskTable[self.TaskName]
+	if !ok {
+		return "", httperrors.NewNotSupportedError("task %s not registered in this service", self.TaskName)
+	}
+	stageFunc := stage
+	if strings.Contains(stageFunc, "_") {
+		stageFunc = utils.Kebab2Camel(stageFunc, "_")
+	}
+	if _, ok := reflect.PtrTo(taskType).MethodByName(stageFunc); !ok {
+		return "", httperrors.NewNotSupportedError("stage %s of task %s not found", stage, self.TaskName)
+	}
+	return stage, nil
+}
+
+// checkObjectIdle makes sure no other task is running on the object of task,
+// the retried task may conflict with it
+func (self *STask) checkObjectIdle(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if self.ObjId == MULTI_OBJECTS_ID {
+		return nil
+	}
+	obj, err := fetchTaskObjectModel(ctx, userCred, self.ObjName, self.ObjId)
+	if err != nil {
+		return err
+	}
+	tasks, err := TaskManager.FetchIncompleteTasksOfObject(obj)
+	if err != nil {
+		return httperrors.NewGeneralError(errors.Wrap(err, "FetchIncompleteTasksOfObject"))
+	}
+	if len(tasks) > 0 {
+		return httperrors.NewInvalidStatusError("%s %s is running task %s", self.ObjName, self.ObjId, tasks[0].TaskName)
+	}
+	return nil
+}
+
+// PerformRetry reschedules a failed task at the stage where it failed with the saved params
+func (self *STask) PerformRetry(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.TaskRetryInput) (jsonutils.JSONObject, error) {
+	if self.Params == nil {
+		self.Params = jsonutils.NewDict()
+	}
+	err := self.validateRetry()
+	if err != nil {
+		return nil, err
+	}
+	err = self.checkObjectIdle(ctx, userCred)
+	if err != nil {
+		return nil, err
+	}
+	stage, _ := self.getFailedReason()
+	err = self.SetStage(stage, nil)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrapf(err, "SetStage %s", stage))
+	}
+	log.Infof("Retry task %s(%s) from stage %s by %s", self.TaskName, self.Id, stage, userCred.GetUserName())
+	data := input.Data
+	if data == nil {
+		data = jsonutils.NewDict()
+	}
+	err = self.ScheduleRun(data)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "ScheduleRun"))
+	}
+	return jsonutils.Marshal(self.getTreeNode(0)), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+type RetryTestTask struct {
+	STask
+}
+
+func (self *RetryTestTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+}
+
+func (self *RetryTestTask) OnSyncComplete(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+}
+
+func init() {
+	RegisterTask(RetryTestTask{})
+}
+
+func newFailedTask(taskName, stage string) *STask {
+	params := jsonutils.NewDict()
+	reason := jsonutils.NewDict()
+	reason.Add(jsonutils.NewString(stage), "stage")
+	reason.Add(jsonutils.NewString("timeout"), "reason")
+	params.Add(jsonutils.NewArray(reason), FAILED_REASON_KEY)
+	task := &STask{
+		TaskName: taskName,
+		Stage:    TASK_STAGE_FAILED,
+		Params:   params,
+	}
+	task.Id = "task1"
+	return task
+}
+
+func TestValidateRetryStage(t *testing.T) {
+	running := newFailedTask("RetryTestTask", "OnInit")
+	running.Stage = "OnInit"
+	cases := []struct {
+		name    string
+		task    *STask
+		stage   string
+		wantErr bool
+	}{
+		{"failed at stage", newFailedTask("RetryTestTask", "OnInit"), "OnInit", false},
+		{"kebab stage", newFailedTask("RetryTestTask", "on_sync_complete"), "on_sync_complete", false},
+		{"not failed", running, "", true},
+		{"unknown stage", newFailedTask("RetryTestTask", ""), "", true},
+		{"failed at failed", newFailedTask("RetryTestTask", TASK_STAGE_FAILED), "", true},
+		{"stage not found", newFailedTask("RetryTestTask", "OnMissing"), "", true},
+		{"task not registered", newFailedTask("UnknownTask", "OnInit"), "", true},
+	}
+	for _, c := range cases {
+		stage, err := c.task.validateRetryStage()
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if stage != c.stage {
+			t.Errorf("%s: want stage %s got %s", c.name, c.stage, stage)
+		}
+	}
+}
+
+func TestCheckObjectIdle(t *testing.T) {
+	task := newFailedTask("RetryTestTask", "OnInit")
+	task.ObjId = MULTI_OBJECTS_ID
+	if err := task.checkObjectIdle(context.Background(), nil); err != nil {
+		t.Errorf("task of multiple objects: %s", err)
+	}
+	// object can't be fetched, it's unknown whether another task is running
+	task.ObjName = "no_such_resource"
+	task.ObjId = "obj1"
+	if err := task.checkObjectIdle(context.Background(), nil); err == nil {
+		t.Errorf("want error when object can't be fetched")
+	}
+}
+
+func TestPerformRetry(t *testing.T) {
+	task := newFailedTask("RetryTestTask", "OnInit")
+	task.Stage = TASK_STAGE_COMPLETE
+	if _, err := task.PerformRetry(context.Background(), nil, nil, apis.TaskRetryInput{}); err == nil {
+		t.Errorf("completed task should not be retried")
+	}
+	task = newFailedTask("RetryTestTask", "OnMissing")
+	if _, err := task.PerformRetry(context.Background(), nil, nil, apis.TaskRetryInput{}); err == nil {
+		t.Errorf("task without the failed stage should not be retried")
+	}
+	if task.Stage != TASK_STAGE_FAILED {
+		t.Errorf("stage of task should not be changed by rejected retry")
+	}
+}
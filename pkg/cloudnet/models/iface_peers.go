@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"yunion.io/x/log"
 	yerrors "yunion.io/x/pkg/util/errors"
@@ -38,9 +39,15 @@ type SIfacePeer struct {
 	PeerRouterId string
 
 	PublicKey           string
+	NextPublicKey       string `nullable:"true"`
 	AllowedIPs          string
 	Endpoint            string
 	PersistentKeepalive int
+
+	LatestHandshake time.Time `nullable:"true" list:"user"`
+	RxBytes         int64     `nullable:"false" default:"0" list:"user"`
+	TxBytes         int64     `nullable:"false" default:"0" list:"user"`
+	HealthStatus    string    `width:"16" charset:"ascii" nullable:"false" default:"unknown" list:"user"`
 }
 
 type SIfacePeerManager struct {
@@ -210,3 +217,27 @@ func (man *SIfacePeerManager) updateEndpointIPByPeerRouter(ctx context.Context,
 	}
 	return yerrors.NewAggregate(errs)
 }
+
+func (man *SIfacePeerManager) updateKeysByPeerIface(ctx context.Context, iface *SIface) error {
+	filter := map[string]string{
+		"peer_iface_id": iface.Id,
+	}
+	ifacePeers, err := man.getByFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range ifacePeers {
+		ifacePeer := &ifacePeers[i]
+		_, err = db.Update(ifacePeer, func() error {
+			ifacePeer.PublicKey = iface.PublicKey
+			ifacePeer.NextPublicKey = iface.NextPublicKey
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return yerrors.NewAggregate(errs)
+}
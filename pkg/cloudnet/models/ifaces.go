@@ -49,6 +49,11 @@ type SIface struct {
 	PublicKey  string
 	ListenPort int `nullable:"false"`
 
+	// key pair being rotated to or from, peers have its public key
+	// as an extra peer without allowed ips
+	NextPrivateKey string `nullable:"true"`
+	NextPublicKey  string `nullable:"true"`
+
 	IsSystem bool `nullable:"false"`
 }
 
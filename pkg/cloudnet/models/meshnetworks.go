@@ -17,19 +17,29 @@ package models
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"yunion.io/x/jsonutils"
 	yerrors "yunion.io/x/pkg/util/errors"
 
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/cloudnet"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 )
 
 type SMeshNetwork struct {
 	db.SStandaloneResourceBase
+
+	// 0 disables scheduled key rotation
+	KeyRotationIntervalDays int       `nullable:"false" default:"0" list:"user" create:"optional" update:"user"`
+	KeyRotatedAt            time.Time `nullable:"true" list:"user"`
+	// phase of the key rotation in progress, empty when idle
+	KeyRotationStatus string `width:"16" charset:"ascii" nullable:"true" list:"user"`
 }
 
 type SMeshNetworkManager struct {
@@ -50,6 +60,37 @@ func init() {
 	MeshNetworkManager.SetVirtualObject(MeshNetworkManager)
 }
 
+func (man *SMeshNetworkManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
+	input := apis.StandaloneResourceCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return nil, httperrors.NewInternalServerError("unmarshal StandaloneResourceCreateInput fail %s", err)
+	}
+	input, err = man.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input)
+	if err != nil {
+		return nil, err
+	}
+	data.Update(jsonutils.Marshal(input))
+
+	v := validators.NewNonNegativeValidator("key_rotation_interval_days").Default(0)
+	if err := v.Validate(ctx, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (mn *SMeshNetwork) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.MeshNetworkUpdateInput) (api.MeshNetworkUpdateInput, error) {
+	var err error
+	input.StandaloneResourceBaseUpdateInput, err = mn.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.WithMessage(err, "SStandaloneResourceBase.ValidateUpdateData")
+	}
+	if input.KeyRotationIntervalDays != nil && *input.KeyRotationIntervalDays < 0 {
+		return input, httperrors.NewInputParameterError("key_rotation_interval_days must not be negative")
+	}
+	return input, nil
+}
+
 func (mn *SMeshNetwork) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
 	var errs []error
 	if err := MeshNetworkMemberManager.removeByMeshNetwork(ctx, userCred, mn); err != nil {
@@ -65,9 +106,13 @@ func (mn *SMeshNetwork) CustomizeDelete(ctx context.Context, userCred mcclient.T
 }
 
 func (mn *SMeshNetwork) PerformRealize(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	return nil, mn.realize(ctx, userCred)
+}
+
+func (mn *SMeshNetwork) realize(ctx context.Context, userCred mcclient.TokenCredential) error {
 	members, err := MeshNetworkMemberManager.getMemebersByMeshNetwork(ctx, userCred, mn)
 	if err != nil {
-		return nil, httperrors.NewBadRequestError("fetch members: %v", err)
+		return httperrors.NewBadRequestError("fetch members: %v", err)
 	}
 	var errs []error
 	for i := range members {
@@ -85,7 +130,7 @@ func (mn *SMeshNetwork) PerformRealize(ctx context.Context, userCred mcclient.To
 	if err != nil {
 		err = httperrors.NewBadRequestError("some router realization failed: %s", err)
 	}
-	return nil, err
+	return err
 }
 
 func (mn *SMeshNetwork) addRouter(ctx context.Context, userCred mcclient.TokenCredential, router *SRouter, nets Subnets) error {
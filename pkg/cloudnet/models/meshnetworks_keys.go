@@ -0,0 +1,309 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	yerrors "yunion.io/x/pkg/util/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	cnutils "yunion.io/x/onecloud/pkg/cloudnet/utils"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// PerformRotateKeys starts key rotation, routers are realized in background
+func (mn *SMeshNetwork) PerformRotateKeys(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := mn.claimKeyRotation(ctx); err != nil {
+		return nil, httperrors.NewBadRequestError("%s", err)
+	}
+	go func() {
+		if err := mn.doRotateKeys(context.Background(), userCred); err != nil {
+			log.Errorf("rotate keys of mesh network %s: %v", mn.Name, err)
+		}
+	}()
+	return nil, nil
+}
+
+const (
+	keyRotationStatusStaged   = "staged"
+	keyRotationStatusSwitched = "switched"
+	keyRotationStatusCleaning = "cleaning"
+	keyRotationStatusReverted = "reverted"
+
+	// a rotation not updated in this long is taken as dead
+	keyRotationTimeout = time.Hour
+
+	keyRotationRealizeTimeout = 10 * time.Minute
+)
+
+type sKeyPair struct {
+	private string
+	public  string
+}
+
+func newKeyPair() sKeyPair {
+	k := cnutils.MustNewKey()
+	return sKeyPair{
+		private: k.String(),
+		public:  k.PublicKey().String(),
+	}
+}
+
+// sKeyRotation runs key rotation in phases.  New keys are first staged as the
+// next keys of ifaces, realizing it checks every router is reachable before
+// any key is switched.  Then new keys are made active with the old ones kept
+// as next keys for revert, then the old keys are removed.  Every phase is
+// realized on all routers before the next one starts.  When staging or
+// switching fails, keys are reverted to the old ones
+type sKeyRotation struct {
+	// apply sets keys of all ifaces for the phase
+	apply   func(status string) error
+	realize func() error
+}
+
+// run returns whether the new keys are in use
+func (r *sKeyRotation) run() (bool, error) {
+	revert := func(err error) (bool, error) {
+		if err1 := r.apply(keyRotationStatusReverted); err1 != nil {
+			return false, errors.WithMessagef(err, "revert keys: %v", err1)
+		}
+		if err1 := r.realize(); err1 != nil {
+			return false, errors.WithMessagef(err, "realize reverted keys: %v", err1)
+		}
+		return false, err
+	}
+	if err := r.apply(keyRotationStatusStaged); err != nil {
+		return revert(errors.WithMessage(err, "add new keys"))
+	}
+	if err := r.realize(); err != nil {
+		return revert(errors.WithMessage(err, "realize new keys"))
+	}
+	if err := r.apply(keyRotationStatusSwitched); err != nil {
+		return revert(errors.WithMessage(err, "switch to new keys"))
+	}
+	if err := r.realize(); err != nil {
+		return revert(errors.WithMessage(err, "realize switched keys"))
+	}
+	// old keys left as extra peers are harmless, they are removed on
+	// next realize
+	if err := r.apply(keyRotationStatusCleaning); err != nil {
+		return true, errors.WithMessage(err, "remove old keys")
+	}
+	if err := r.realize(); err != nil {
+		return true, errors.WithMessage(err, "realize removal of old keys")
+	}
+	return true, nil
+}
+
+// rotationKeys returns the active and next keys of iface in the phase
+func rotationKeys(status string, oldKey, newKey sKeyPair) (sKeyPair, sKeyPair) {
+	switch status {
+	case keyRotationStatusStaged:
+		return oldKey, newKey
+	case keyRotationStatusSwitched:
+		return newKey, oldKey
+	case keyRotationStatusCleaning:
+		return newKey, sKeyPair{}
+	default:
+		return oldKey, sKeyPair{}
+	}
+}
+
+// claimKeyRotation marks the mesh network as being rotated.  The lock is only
+// held for the claim, not for the realization of routers
+func (mn *SMeshNetwork) claimKeyRotation(ctx context.Context) error {
+	lockman.LockObject(ctx, mn)
+	defer lockman.ReleaseObject(ctx, mn)
+
+	obj, err := MeshNetworkManager.FetchById(mn.Id)
+	if err != nil {
+		return errors.WithMessage(err, "fetch mesh network")
+	}
+	if cur := obj.(*SMeshNetwork); cur.KeyRotationStatus != "" && time.Since(cur.UpdatedAt) < keyRotationTimeout {
+		return errors.Errorf("key rotation is in progress: %s", cur.KeyRotationStatus)
+	}
+	return mn.setKeyRotationStatus(keyRotationStatusStaged)
+}
+
+func (mn *SMeshNetwork) setKeyRotationStatus(status string) error {
+	_, err := db.Update(mn, func() error {
+		mn.KeyRotationStatus = status
+		return nil
+	})
+	return err
+}
+
+// realizeAndWait realizes all member routers in parallel and waits for them
+// to finish.  Tunnels between two routers are down from the moment one end
+// switches keys until the other end does, so the routers are not realized one
+// by one
+func (mn *SMeshNetwork) realizeAndWait(ctx context.Context, userCred mcclient.TokenCredential) error {
+	members, err := MeshNetworkMemberManager.getMemebersByMeshNetwork(ctx, userCred, mn)
+	if err != nil {
+		return errors.WithMessage(err, "fetch members")
+	}
+	var (
+		errs    []error
+		errLock sync.Mutex
+		wg      sync.WaitGroup
+	)
+	addErr := func(err error) {
+		errLock.Lock()
+		defer errLock.Unlock()
+		errs = append(errs, err)
+	}
+	for i := range members {
+		member := &members[i]
+		router, err := member.getRouter()
+		if err != nil {
+			addErr(errors.WithMessagef(err, "get router %s", member.RouterId))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := router.realizeAndWait(ctx, userCred, keyRotationRealizeTimeout); err != nil {
+				addErr(errors.WithMessagef(err, "realize router %s", router.Name))
+			}
+		}()
+	}
+	wg.Wait()
+	return yerrors.NewAggregate(errs)
+}
+
+// rotateKeys generates new keys for all member ifaces of the mesh network and
+// rotates to them in phases, see sKeyRotation.  Tunnels between two routers
+// are interrupted during switching until both ends are realized
+func (mn *SMeshNetwork) rotateKeys(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if err := mn.claimKeyRotation(ctx); err != nil {
+		return err
+	}
+	return mn.doRotateKeys(ctx, userCred)
+}
+
+func (mn *SMeshNetwork) doRotateKeys(ctx context.Context, userCred mcclient.TokenCredential) error {
+	members, err := MeshNetworkMemberManager.getMemebersByMeshNetwork(ctx, userCred, mn)
+	if err != nil {
+		mn.setKeyRotationStatus("")
+		return errors.WithMessage(err, "fetch members")
+	}
+	var (
+		ifaces  []*SIface
+		oldKeys = map[string]sKeyPair{}
+		newKeys = map[string]sKeyPair{}
+	)
+	for i := range members {
+		member := &members[i]
+		iface, err := IfaceManager.getByMeshNetworkMember(member)
+		if err != nil {
+			mn.setKeyRotationStatus("")
+			return errors.WithMessagef(err, "get iface of router %s", member.RouterId)
+		}
+		ifaces = append(ifaces, iface)
+		oldKeys[iface.Id] = sKeyPair{private: iface.PrivateKey, public: iface.PublicKey}
+		newKeys[iface.Id] = newKeyPair()
+	}
+
+	rotation := &sKeyRotation{
+		apply: func(status string) error {
+			var errs []error
+			for _, iface := range ifaces {
+				active, next := rotationKeys(status, oldKeys[iface.Id], newKeys[iface.Id])
+				if err := iface.setKeys(ctx, active, next); err != nil {
+					errs = append(errs, errors.WithMessagef(err, "iface %s", iface.Name))
+				}
+			}
+			if err := yerrors.NewAggregate(errs); err != nil {
+				return err
+			}
+			return mn.setKeyRotationStatus(status)
+		},
+		realize: func() error {
+			return mn.realizeAndWait(ctx, userCred)
+		},
+	}
+	rotated, err := rotation.run()
+	_, uerr := db.Update(mn, func() error {
+		mn.KeyRotationStatus = ""
+		if rotated {
+			mn.KeyRotatedAt = time.Now()
+		}
+		return nil
+	})
+	if err == nil {
+		err = uerr
+	}
+	if err != nil {
+		db.OpsLog.LogEvent(mn, db.ACT_ROTATE_KEYS_FAIL, err.Error(), userCred)
+		return err
+	}
+	db.OpsLog.LogEvent(mn, db.ACT_ROTATE_KEYS, nil, userCred)
+	return nil
+}
+
+func (mn *SMeshNetwork) isKeyRotationDue(now time.Time) bool {
+	if mn.KeyRotationIntervalDays <= 0 {
+		return false
+	}
+	since := mn.KeyRotatedAt
+	if since.IsZero() {
+		since = mn.CreatedAt
+	}
+	return now.Sub(since) >= time.Duration(mn.KeyRotationIntervalDays)*24*time.Hour
+}
+
+func (man *SMeshNetworkManager) RotateKeys(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	mns := []SMeshNetwork{}
+	q := man.Query().GT("key_rotation_interval_days", 0)
+	if err := db.FetchModelObjects(man, q, &mns); err != nil {
+		log.Errorf("fetch mesh networks: %v", err)
+		return
+	}
+	now := time.Now()
+	for i := range mns {
+		mn := &mns[i]
+		if !mn.isKeyRotationDue(now) {
+			continue
+		}
+		if err := mn.rotateKeys(ctx, userCred); err != nil {
+			log.Errorf("rotate keys of mesh network %s: %v", mn.Name, err)
+		}
+	}
+}
+
+// setKeys sets the active and next key pair of the iface and the public keys
+// recorded by peers of it
+func (iface *SIface) setKeys(ctx context.Context, active, next sKeyPair) error {
+	_, err := db.Update(iface, func() error {
+		iface.PrivateKey = active.private
+		iface.PublicKey = active.public
+		iface.NextPrivateKey = next.private
+		iface.NextPublicKey = next.public
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return IfacePeerManager.updateKeysByPeerIface(ctx, iface)
+}
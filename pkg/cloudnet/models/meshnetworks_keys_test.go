@@ -0,0 +1,169 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeyRotationRun(t *testing.T) {
+	cases := []struct {
+		name        string
+		failApply   string
+		failRealize string
+		wantRotated bool
+		wantErr     bool
+		wantSteps   []string
+	}{
+		{
+			name:        "ok",
+			wantRotated: true,
+			wantSteps: []string{
+				"apply staged", "realize staged",
+				"apply switched", "realize switched",
+				"apply cleaning", "realize cleaning",
+			},
+		},
+		{
+			name:        "realize new keys failed",
+			failRealize: keyRotationStatusStaged,
+			wantErr:     true,
+			wantSteps: []string{
+				"apply staged", "realize staged",
+				"apply reverted", "realize reverted",
+			},
+		},
+		{
+			name:        "realize switched keys failed",
+			failRealize: keyRotationStatusSwitched,
+			wantErr:     true,
+			wantSteps: []string{
+				"apply staged", "realize staged",
+				"apply switched", "realize switched",
+				"apply reverted", "realize reverted",
+			},
+		},
+		{
+			name:      "switch failed",
+			failApply: keyRotationStatusSwitched,
+			wantErr:   true,
+			wantSteps: []string{
+				"apply staged", "realize staged",
+				"apply switched",
+				"apply reverted", "realize reverted",
+			},
+		},
+		{
+			name:        "removal of old keys failed",
+			failRealize: keyRotationStatusCleaning,
+			wantRotated: true,
+			wantErr:     true,
+			wantSteps: []string{
+				"apply staged", "realize staged",
+				"apply switched", "realize switched",
+				"apply cleaning", "realize cleaning",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				steps  []string
+				status string
+			)
+			r := &sKeyRotation{
+				apply: func(s string) error {
+					steps = append(steps, "apply "+s)
+					if s == c.failApply {
+						return errors.New("apply failed")
+					}
+					status = s
+					return nil
+				},
+				realize: func() error {
+					steps = append(steps, "realize "+status)
+					if status == c.failRealize {
+						return errors.New("realize failed")
+					}
+					return nil
+				},
+			}
+			rotated, err := r.run()
+			if rotated != c.wantRotated {
+				t.Errorf("rotated %v, want %v", rotated, c.wantRotated)
+			}
+			if (err != nil) != c.wantErr {
+				t.Errorf("err %v, want err %v", err, c.wantErr)
+			}
+			if !reflect.DeepEqual(steps, c.wantSteps) {
+				t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(steps, "\n"), strings.Join(c.wantSteps, "\n"))
+			}
+		})
+	}
+}
+
+func TestRotationKeys(t *testing.T) {
+	var (
+		oldKey = sKeyPair{private: "old", public: "old.pub"}
+		newKey = sKeyPair{private: "new", public: "new.pub"}
+		none   = sKeyPair{}
+	)
+	cases := []struct {
+		status string
+		active sKeyPair
+		next   sKeyPair
+	}{
+		{keyRotationStatusStaged, oldKey, newKey},
+		{keyRotationStatusSwitched, newKey, oldKey},
+		{keyRotationStatusCleaning, newKey, none},
+		{keyRotationStatusReverted, oldKey, none},
+	}
+	for _, c := range cases {
+		active, next := rotationKeys(c.status, oldKey, newKey)
+		if active != c.active || next != c.next {
+			t.Errorf("%s: got %v %v, want %v %v", c.status, active, next, c.active, c.next)
+		}
+	}
+}
+
+func TestWireguardPeers(t *testing.T) {
+	ifacePeers := []SIfacePeer{
+		{PublicKey: "a.pub", AllowedIPs: "10.0.1.0/24", Endpoint: "1.1.1.1:51820"},
+		{PublicKey: "b.pub", NextPublicKey: "b.next.pub", AllowedIPs: "10.0.2.0/24", Endpoint: "2.2.2.2:51820", PersistentKeepalive: 25},
+		{PublicKey: ""},
+	}
+	ifacePeers[0].Name = "a"
+	ifacePeers[1].Name = "b"
+	got := wireguardPeers(ifacePeers)
+	want := wgPeers{
+		"a": wgPeer{
+			"public_key":  "a.pub",
+			"allowed_ips": "10.0.1.0/24",
+			"endpoint":    "1.1.1.1:51820",
+		},
+		"b": wgPeer{
+			"public_key":           "b.pub",
+			"allowed_ips":          "10.0.2.0/24",
+			"endpoint":             "2.2.2.2:51820",
+			"persistent_keepalive": 25,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
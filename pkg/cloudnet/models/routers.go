@@ -17,6 +17,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -44,6 +45,10 @@ type SRouter struct {
 	RealizeWgIfaces bool `width:"16" charset:"ascii" nullable:"false" list:"user" create:"optional" update:"user"`
 	RealizeRoutes   bool `width:"16" charset:"ascii" nullable:"false" list:"user" create:"optional" update:"user"`
 	RealizeRules    bool `width:"16" charset:"ascii" nullable:"false" list:"user" create:"optional" update:"user"`
+
+	WgHealthStatus    string    `width:"16" charset:"ascii" nullable:"false" default:"unknown" list:"user"`
+	WgHealthCheckedAt time.Time `nullable:"true" list:"user"`
+	WgStalePeerCount  int       `nullable:"false" default:"0" list:"user"`
 }
 
 type SRouterManager struct {
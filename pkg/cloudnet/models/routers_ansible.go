@@ -62,12 +62,39 @@ func (router *SRouter) playFilesStr() string {
 	return string(r)
 }
 
+type (
+	wgPeer  map[string]interface{}
+	wgPeers map[string]wgPeer
+)
+
+// wireguardPeers returns the peer vars of wgX.conf.  Only the active key of a
+// peer is rendered: wireguard routes an allowed ip to exactly one peer and
+// drops packets from a peer whose source is not in its allowed ips, so an
+// extra peer of the key being rotated could never carry traffic
+func wireguardPeers(ifacePeers []SIfacePeer) wgPeers {
+	wgpeers := wgPeers{}
+	for j := range ifacePeers {
+		ifacePeer := &ifacePeers[j]
+		if ifacePeer.PublicKey == "" {
+			continue
+		}
+		wgpeer := wgPeer{
+			"public_key":  ifacePeer.PublicKey,
+			"allowed_ips": ifacePeer.AllowedIPs,
+			"endpoint":    ifacePeer.Endpoint,
+		}
+		if ifacePeer.PersistentKeepalive > 0 {
+			wgpeer["persistent_keepalive"] = ifacePeer.PersistentKeepalive
+		}
+		wgpeers[ifacePeer.Name] = wgpeer
+	}
+	return wgpeers
+}
+
 func (router *SRouter) inventoryWireguardVars(vars map[string]interface{}) error {
 	type (
 		WgNetworks  []string
 		WgInterface map[string]interface{}
-		WgPeer      map[string]interface{}
-		WgPeers     map[string]WgPeer
 	)
 	ifaces, err := IfaceManager.getByRouter(router)
 	if err != nil {
@@ -83,22 +110,7 @@ func (router *SRouter) inventoryWireguardVars(vars map[string]interface{}) error
 		if err != nil {
 			return err
 		}
-		wgpeers := WgPeers{}
-		for j := range ifacePeers {
-			ifacePeer := &ifacePeers[j]
-			if ifacePeer.PublicKey == "" {
-				continue
-			}
-			wgpeer := WgPeer{
-				"public_key":  ifacePeer.PublicKey,
-				"allowed_ips": ifacePeer.AllowedIPs,
-				"endpoint":    ifacePeer.Endpoint,
-			}
-			if ifacePeer.PersistentKeepalive > 0 {
-				wgpeer["persistent_keepalive"] = ifacePeer.PersistentKeepalive
-			}
-			wgpeers[ifacePeer.Name] = wgpeer
-		}
+		wgpeers := wireguardPeers(ifacePeers)
 		if len(wgpeers) == 0 {
 			continue
 		}
@@ -231,12 +243,13 @@ func (router *SRouter) playDeployWireguardNetworks() *ansiblev2.Play {
 			WithPlugin:    "items",
 			WithPluginVal: "{{ wireguard_networks }}",
 		},
-		&ansiblev2.Task{
-			Name:       "Restart wg-quick@xx service",
-			ModuleName: "service",
+		&ansiblev2.ShellTask{
+			// reload running interfaces in place so that key rotation and
+			// peer changes do not bring down the tunnels
+			Name:   "Reload or start wg-quick@xx service",
+			Script: `if systemctl is-active --quiet wg-quick@{{ item.1 }}; then wg syncconf {{ item.1 }} <(wg-quick strip {{ item.1 }}); else systemctl restart wg-quick@{{ item.1 }}; fi`,
 			ModuleArgs: map[string]interface{}{
-				"name":  "wg-quick@{{ item.1 }}",
-				"state": "restarted",
+				"executable": "/bin/bash",
 			},
 			WithPlugin:    "indexed_items",
 			WithPluginVal: "{{ wireguard_networks }}",
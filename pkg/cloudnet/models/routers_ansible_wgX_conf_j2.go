@@ -33,10 +33,10 @@ const wgX_conf_j2 = `
    'save_config': 'SaveConfig'
 } -%}
 {% set peer_required_keys = {
-   'public_key': 'PublicKey',
-   'allowed_ips': 'AllowedIPs'
+   'public_key': 'PublicKey'
 } -%}
 {% set peer_optional_keys = {
+   'allowed_ips': 'AllowedIPs',
    'endpoint': 'EndPoint',
    'preshared_key': 'PresharedKey',
    'persistent_keepalive': 'PersistentKeepalive'
@@ -0,0 +1,221 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudnet"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/cloudnet/options"
+	cnutils "yunion.io/x/onecloud/pkg/cloudnet/utils"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/ssh"
+)
+
+func (man *SRouterManager) CheckWireguardHealth(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	routers := []SRouter{}
+	q := man.Query().IsTrue("realize_wg_ifaces")
+	if err := db.FetchModelObjects(man, q, &routers); err != nil {
+		log.Errorf("fetch routers: %v", err)
+		return
+	}
+	for i := range routers {
+		router := &routers[i]
+		if err := router.checkWireguardHealth(ctx, userCred); err != nil {
+			log.Warningf("check wireguard health of router %s: %v", router.Name, err)
+		}
+	}
+}
+
+func (router *SRouter) wireguardDump() ([]cnutils.WgPeerState, error) {
+	if router.PrivateKey == "" {
+		return nil, errors.New("no ssh private key")
+	}
+	cli, err := ssh.NewClient(router.Host, router.Port, router.User, "", router.PrivateKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "ssh")
+	}
+	defer cli.Close()
+
+	cmd := "wg show all dump"
+	if router.User != "root" {
+		cmd = "sudo -n " + cmd
+	}
+	lines, err := cli.Run(cmd)
+	if err != nil {
+		return nil, errors.WithMessage(err, cmd)
+	}
+	return cnutils.ParseWgDump(lines)
+}
+
+// wireguardPeerStatus returns health status of ifacePeer by its state in
+// wireguard dump.  Wireguard only handshakes when there is traffic to send
+// unless persistent keepalive is set, so a peer without keepalive and recent
+// handshake is stale only when the router kept sending to it since last check
+// without receiving anything, otherwise it is just idle
+func wireguardPeerStatus(ifacePeer *SIfacePeer, state cnutils.WgPeerState, now time.Time, staleAfter time.Duration) string {
+	if !state.LatestHandshake.IsZero() && now.Sub(state.LatestHandshake) <= staleAfter {
+		return api.WG_HEALTH_STATUS_OK
+	}
+	if ifacePeer.PersistentKeepalive > 0 {
+		return api.WG_HEALTH_STATUS_STALE
+	}
+	// counters are reset when the interface is restarted
+	if state.TxBytes > ifacePeer.TxBytes && state.RxBytes <= ifacePeer.RxBytes {
+		return api.WG_HEALTH_STATUS_STALE
+	}
+	return api.WG_HEALTH_STATUS_IDLE
+}
+
+// checkWireguardHealth collects handshake and transfer states of wireguard
+// peers from the router and records them in iface peers.  Peers without
+// handshake in WireguardPeerStaleSeconds are stale, see wireguardPeerStatus
+func (router *SRouter) checkWireguardHealth(ctx context.Context, userCred mcclient.TokenCredential) error {
+	ifaces, err := IfaceManager.getByRouter(router)
+	if err != nil {
+		return err
+	}
+	type peerKey struct {
+		ifname    string
+		publicKey string
+	}
+	ifnames := map[string]string{}
+	ifacePeers := []SIfacePeer{}
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if !iface.isTypeWireguard() {
+			continue
+		}
+		peers, err := IfacePeerManager.getByIface(iface)
+		if err != nil {
+			return err
+		}
+		ifnames[iface.Id] = iface.Ifname
+		ifacePeers = append(ifacePeers, peers...)
+	}
+	if len(ifacePeers) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	states, err := router.wireguardDump()
+	if err != nil {
+		router.setWireguardHealth(api.WG_HEALTH_STATUS_UNKNOWN, 0, now)
+		return err
+	}
+	stateMap := map[peerKey]cnutils.WgPeerState{}
+	for _, state := range states {
+		stateMap[peerKey{state.Ifname, state.PublicKey}] = state
+	}
+
+	staleAfter := time.Duration(options.Options.WireguardPeerStaleSeconds) * time.Second
+	staleCount := 0
+	for i := range ifacePeers {
+		ifacePeer := &ifacePeers[i]
+		state, ok := stateMap[peerKey{ifnames[ifacePeer.IfaceId], ifacePeer.PublicKey}]
+		status := api.WG_HEALTH_STATUS_UNKNOWN
+		if ok {
+			// peers not in dump are not realized yet
+			status = wireguardPeerStatus(ifacePeer, state, now, staleAfter)
+			if status == api.WG_HEALTH_STATUS_STALE {
+				staleCount += 1
+			}
+		}
+		oldStatus := ifacePeer.HealthStatus
+		_, err := db.Update(ifacePeer, func() error {
+			ifacePeer.HealthStatus = status
+			if ok {
+				ifacePeer.LatestHandshake = state.LatestHandshake
+				ifacePeer.RxBytes = state.RxBytes
+				ifacePeer.TxBytes = state.TxBytes
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("update iface peer %s health: %v", ifacePeer.Name, err)
+			continue
+		}
+		if status == api.WG_HEALTH_STATUS_STALE && oldStatus != api.WG_HEALTH_STATUS_STALE {
+			reason := fmt.Sprintf("wireguard peer %s of router %s has no handshake since %s", ifacePeer.Name, router.Name, state.LatestHandshake.Format(time.RFC3339))
+			if state.LatestHandshake.IsZero() {
+				reason = fmt.Sprintf("wireguard peer %s of router %s never handshaked", ifacePeer.Name, router.Name)
+			}
+			notifyclient.NotifySystemWarningWithCtx(ctx, ifacePeer.Id, ifacePeer.Name, api.WG_PEER_STALE, reason)
+		}
+	}
+
+	status := api.WG_HEALTH_STATUS_OK
+	if staleCount > 0 {
+		status = api.WG_HEALTH_STATUS_STALE
+	}
+	router.setWireguardHealth(status, staleCount, now)
+	return nil
+}
+
+func (router *SRouter) setWireguardHealth(status string, staleCount int, checkedAt time.Time) {
+	_, err := db.Update(router, func() error {
+		router.WgHealthStatus = status
+		router.WgStalePeerCount = staleCount
+		router.WgHealthCheckedAt = checkedAt
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update router %s wireguard health: %v", router.Name, err)
+	}
+}
+
+func (router *SRouter) GetDetailsWireguardPeers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (api.RouterWireguardPeersOutput, error) {
+	output := api.RouterWireguardPeersOutput{
+		HealthStatus:    router.WgHealthStatus,
+		HealthCheckedAt: router.WgHealthCheckedAt,
+		Peers:           []api.RouterWireguardPeer{},
+	}
+	ifacePeers, err := IfacePeerManager.getByFilter(map[string]string{
+		"router_id": router.Id,
+	})
+	if err != nil {
+		return output, err
+	}
+	now := time.Now()
+	for i := range ifacePeers {
+		ifacePeer := &ifacePeers[i]
+		peer := api.RouterWireguardPeer{
+			Id:              ifacePeer.Id,
+			Name:            ifacePeer.Name,
+			IfaceId:         ifacePeer.IfaceId,
+			PeerRouterId:    ifacePeer.PeerRouterId,
+			Endpoint:        ifacePeer.Endpoint,
+			LatestHandshake: ifacePeer.LatestHandshake,
+			HandshakeAge:    -1,
+			RxBytes:         ifacePeer.RxBytes,
+			TxBytes:         ifacePeer.TxBytes,
+			HealthStatus:    ifacePeer.HealthStatus,
+		}
+		if !ifacePeer.LatestHandshake.IsZero() {
+			peer.HandshakeAge = int64(now.Sub(ifacePeer.LatestHandshake) / time.Second)
+		}
+		output.Peers = append(output.Peers, peer)
+	}
+	return output, nil
+}
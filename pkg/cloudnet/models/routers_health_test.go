@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/cloudnet"
+	cnutils "yunion.io/x/onecloud/pkg/cloudnet/utils"
+)
+
+func TestWireguardPeerStatus(t *testing.T) {
+	now := time.Now()
+	staleAfter := 5 * time.Minute
+	cases := []struct {
+		name      string
+		keepalive int
+		prevRx    int64
+		prevTx    int64
+		state     cnutils.WgPeerState
+		want      string
+	}{
+		{
+			name:  "recent handshake",
+			state: cnutils.WgPeerState{LatestHandshake: now.Add(-time.Minute)},
+			want:  api.WG_HEALTH_STATUS_OK,
+		},
+		{
+			name:      "keepalive peer without recent handshake",
+			keepalive: 25,
+			state:     cnutils.WgPeerState{LatestHandshake: now.Add(-time.Hour)},
+			want:      api.WG_HEALTH_STATUS_STALE,
+		},
+		{
+			name:      "keepalive peer never handshaked",
+			keepalive: 25,
+			want:      api.WG_HEALTH_STATUS_STALE,
+		},
+		{
+			name:   "idle peer",
+			prevRx: 100,
+			prevTx: 200,
+			state:  cnutils.WgPeerState{LatestHandshake: now.Add(-time.Hour), RxBytes: 100, TxBytes: 200},
+			want:   api.WG_HEALTH_STATUS_IDLE,
+		},
+		{
+			name:   "sending without response",
+			prevRx: 100,
+			prevTx: 200,
+			state:  cnutils.WgPeerState{LatestHandshake: now.Add(-time.Hour), RxBytes: 100, TxBytes: 348},
+			want:   api.WG_HEALTH_STATUS_STALE,
+		},
+		{
+			name:   "counters reset by restart",
+			prevRx: 100,
+			prevTx: 200,
+			state:  cnutils.WgPeerState{TxBytes: 148},
+			want:   api.WG_HEALTH_STATUS_IDLE,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ifacePeer := &SIfacePeer{PersistentKeepalive: c.keepalive, RxBytes: c.prevRx, TxBytes: c.prevTx}
+			if got := wireguardPeerStatus(ifacePeer, c.state, now, staleAfter); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
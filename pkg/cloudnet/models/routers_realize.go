@@ -17,12 +17,14 @@ package models
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/util/rand"
 
+	ansible_api "yunion.io/x/onecloud/pkg/apis/ansible"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
@@ -30,14 +32,23 @@ import (
 	"yunion.io/x/onecloud/pkg/util/ansiblev2"
 )
 
+const realizePollInterval = 5 * time.Second
+
 func (router *SRouter) realize(ctx context.Context, userCred mcclient.TokenCredential) error {
+	_, err := router.startRealize(ctx, userCred)
+	return err
+}
+
+// startRealize creates the ansible playbook realizing the router and returns
+// its id
+func (router *SRouter) startRealize(ctx context.Context, userCred mcclient.TokenCredential) (string, error) {
 	plays := []*ansiblev2.Play{
 		router.playEssential(),
 	}
 
 	host, err := router.ansibleHost()
 	if err != nil {
-		return err
+		return "", err
 	}
 	if router.RealizeWgIfaces {
 		plays = append(plays,
@@ -49,14 +60,14 @@ func (router *SRouter) realize(ctx context.Context, userCred mcclient.TokenCrede
 	if router.RealizeRoutes {
 		playRoutes, err := router.playDeployRoutes()
 		if err != nil {
-			return err
+			return "", err
 		}
 		plays = append(plays, playRoutes)
 	}
 	if router.RealizeRules {
 		playRules, err := router.playDeployRules()
 		if err != nil {
-			return err
+			return "", err
 		}
 		plays = append(plays, playRules)
 	}
@@ -73,10 +84,45 @@ func (router *SRouter) realize(ctx context.Context, userCred mcclient.TokenCrede
 	params.Set("playbook", jsonutils.NewString(pb.String()))
 	params.Set("files", jsonutils.NewString(files))
 	cliSess := auth.GetSession(ctx, userCred, "")
-	if _, err := ansible.AnsiblePlaybooksV2.Create(cliSess, params); err != nil {
-		return errors.WithMessagef(err, "create ansible task")
+	ret, err := ansible.AnsiblePlaybooksV2.Create(cliSess, params)
+	if err != nil {
+		return "", errors.WithMessagef(err, "create ansible task")
+	}
+	id, _ := ret.GetString("id")
+	return id, nil
+}
+
+// realizeAndWait realizes the router and waits for the playbook to finish
+func (router *SRouter) realizeAndWait(ctx context.Context, userCred mcclient.TokenCredential, timeout time.Duration) error {
+	id, err := router.startRealize(ctx, userCred)
+	if err != nil {
+		return err
+	}
+	cliSess := auth.GetSession(ctx, userCred, "")
+	deadline := time.Now().Add(timeout)
+	for {
+		ret, err := ansible.AnsiblePlaybooksV2.GetById(cliSess, id, nil)
+		if err != nil {
+			return errors.WithMessagef(err, "get ansible task %s", id)
+		}
+		status, _ := ret.GetString("status")
+		switch status {
+		case ansible_api.AnsiblePlaybookStatusSucceeded:
+			return nil
+		case ansible_api.AnsiblePlaybookStatusFailed,
+			ansible_api.AnsiblePlaybookStatusCanceled,
+			ansible_api.AnsiblePlaybookStatusUnknown:
+			return errors.Errorf("ansible task %s %s", id, status)
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("ansible task %s not finished in %s, status %s", id, timeout, status)
+		}
+		select {
+		case <-time.After(realizePollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	return nil
 }
 
 func (router *SRouter) PerformRealize(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
@@ -19,6 +19,10 @@ import common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
 type CloudnetOptions struct {
 	common_options.CommonOptions
 	common_options.DBOptions
+
+	WireguardHealthCheckIntervalSeconds      int `help:"interval to collect wireguard peer states from routers" default:"60"`
+	WireguardPeerStaleSeconds                int `help:"seconds since latest handshake after which a wireguard peer is considered stale" default:"300"`
+	WireguardKeyRotationCheckIntervalMinutes int `help:"interval to check mesh networks due for wireguard key rotation" default:"60"`
 }
 
 var (
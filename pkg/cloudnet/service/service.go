@@ -17,6 +17,7 @@ package service
 import (
 	"context"
 	"os"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -25,6 +26,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon"
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
@@ -59,5 +61,14 @@ func StartService() {
 	db.EnsureAppSyncDB(app, dbOpts, models.InitDB)
 	defer cloudcommon.CloseDB()
 
+	if !opts.IsSlaveNode {
+		cron := cronman.InitCronJobManager(true, opts.CronJobWorkerCount)
+		cron.AddJobAtIntervalsWithStartRun("CheckWireguardHealth", time.Duration(opts.WireguardHealthCheckIntervalSeconds)*time.Second, models.RouterManager.CheckWireguardHealth, true)
+		cron.AddJobAtIntervalsWithStartRun("RotateWireguardKeys", time.Duration(opts.WireguardKeyRotationCheckIntervalMinutes)*time.Minute, models.MeshNetworkManager.RotateKeys, false)
+
+		cron.Start()
+		defer cron.Stop()
+	}
+
 	app_common.ServeForever(app, baseOpts)
 }
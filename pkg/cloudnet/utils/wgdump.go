@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WgPeerState is the runtime state of a wireguard peer
+type WgPeerState struct {
+	Ifname    string
+	PublicKey string
+	Endpoint  string
+
+	// zero if no handshake ever happened
+	LatestHandshake time.Time
+	RxBytes         int64
+	TxBytes         int64
+}
+
+// ParseWgDump parses peer states from output of "wg show all dump".
+//
+// Interface lines have 5 tab separated fields: ifname, private-key,
+// public-key, listen-port, fwmark.  Peer lines have 9: ifname, public-key,
+// preshared-key, endpoint, allowed-ips, latest-handshake, transfer-rx,
+// transfer-tx, persistent-keepalive
+func ParseWgDump(lines []string) ([]WgPeerState, error) {
+	r := []WgPeerState{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		switch len(fields) {
+		case 5:
+			continue
+		case 9:
+		default:
+			return nil, fmt.Errorf("unexpected wg dump line: %q", line)
+		}
+		state := WgPeerState{
+			Ifname:    fields[0],
+			PublicKey: fields[1],
+		}
+		if fields[3] != "(none)" {
+			state.Endpoint = fields[3]
+		}
+		ts, err := strconv.ParseInt(fields[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latest handshake %q: %v", fields[5], err)
+		}
+		if ts > 0 {
+			state.LatestHandshake = time.Unix(ts, 0)
+		}
+		if state.RxBytes, err = strconv.ParseInt(fields[6], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid transfer rx %q: %v", fields[6], err)
+		}
+		if state.TxBytes, err = strconv.ParseInt(fields[7], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid transfer tx %q: %v", fields[7], err)
+		}
+		r = append(r, state)
+	}
+	return r, nil
+}
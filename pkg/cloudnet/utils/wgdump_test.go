@@ -0,0 +1,55 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWgDump(t *testing.T) {
+	lines := []string{
+		"wg0\tWJYVsrTtAae1QS9YzefV4OmVM6mkJglR+GEgxQpTs2g=\tmOX0S5AuRqd8lQZWcqTlzOS+veo404gE7NyV4u3xVkg=\t20000\toff",
+		"wg0\tfE/wdxzl0klVp/IR8UcaoGUMjqaWi3jAd7KzHKFS6Ds=\t(none)\t192.168.1.2:20001\t10.1.0.0/16\t1623921118\t1234\t5678\t10",
+		"wg0\tgN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=\t(none)\t(none)\t10.2.0.0/16\t0\t0\t0\toff",
+		"",
+	}
+	got, err := ParseWgDump(lines)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []WgPeerState{
+		{
+			Ifname:          "wg0",
+			PublicKey:       "fE/wdxzl0klVp/IR8UcaoGUMjqaWi3jAd7KzHKFS6Ds=",
+			Endpoint:        "192.168.1.2:20001",
+			LatestHandshake: time.Unix(1623921118, 0),
+			RxBytes:         1234,
+			TxBytes:         5678,
+		},
+		{
+			Ifname:    "wg0",
+			PublicKey: "gN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+
+	if _, err := ParseWgDump([]string{"wg0\tbad"}); err == nil {
+		t.Errorf("want error for malformed line")
+	}
+}
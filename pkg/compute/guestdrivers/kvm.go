@@ -430,15 +430,22 @@ func (self *SKVMGuestDriver) RequestChangeVmConfig(ctx context.Context, guest *m
 	if jsonutils.QueryBoolean(task.GetParams(), "guest_online", false) {
 		addCpu := vcpuCount - int64(guest.VcpuCount)
 		addMem := vmemSize - int64(guest.VmemSize)
-		if addCpu < 0 || addMem < 0 {
-			return fmt.Errorf("KVM guest doesn't support online reduce cpu or mem")
+		hotResizeMem := guest.GetMetadata(ctx, api.VM_METADATA_HOT_RESIZE_MEM, nil) == "enable"
+		if addCpu < 0 {
+			return fmt.Errorf("KVM guest doesn't support online reduce cpu")
+		}
+		if addMem < 0 && !hotResizeMem {
+			return fmt.Errorf("KVM guest doesn't support online reduce mem without virtio-mem")
 		}
 		header := task.GetTaskRequestHeader()
 		body := jsonutils.NewDict()
 		if vcpuCount > int64(guest.VcpuCount) {
 			body.Set("add_cpu", jsonutils.NewInt(addCpu))
 		}
-		if vmemSize > int64(guest.VmemSize) {
+		if addMem != 0 && hotResizeMem {
+			// virtio-mem resizes guest memory to the requested total size
+			body.Set("resize_mem", jsonutils.NewInt(vmemSize))
+		} else if addMem > 0 {
 			body.Set("add_mem", jsonutils.NewInt(addMem))
 		}
 		host, _ := guest.GetHost()
@@ -719,26 +726,48 @@ func (self *SKVMGuestDriver) RequestSlaveBlockStreamDisks(ctx context.Context, g
 // if body has add_cpu_failed indicate dosen't exec add mem
 // 1. cpu added part of request --> add_cpu_failed: true && added_cpu: count
 // 2. cpu added all of request add mem failed --> add_mem_failed: true
+// 3. cpu added all of request virtio-mem resize failed --> resize_mem_failed: true && actual_mem: size
 func (self *SKVMGuestDriver) OnGuestChangeCpuMemFailed(ctx context.Context, guest *models.SGuest, data *jsonutils.JSONDict, task taskman.ITask) error {
 	var cpuAdded int64
 	if jsonutils.QueryBoolean(data, "add_cpu_failed", false) {
 		cpuAdded, _ = data.Int("added_cpu")
-	} else if jsonutils.QueryBoolean(data, "add_mem_failed", false) {
+	} else if jsonutils.QueryBoolean(data, "add_mem_failed", false) || jsonutils.QueryBoolean(data, "resize_mem_failed", false) {
 		vcpuCount, _ := task.GetParams().Int("vcpu_count")
 		if vcpuCount-int64(guest.VcpuCount) > 0 {
 			cpuAdded = vcpuCount - int64(guest.VcpuCount)
 		}
 	}
+	if actualMem, _ := data.Int("actual_mem"); actualMem > 0 && actualMem != int64(guest.VmemSize) {
+		// guest plugged or released only part of the requested memory
+		oldMem := guest.VmemSize
+		diffs, err := db.Update(guest, func() error {
+			guest.VmemSize = int(actualMem)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Change config task failed but guest memory resized from %dMB to %dMB", oldMem, actualMem)
+		notes := jsonutils.NewDict()
+		notes.Set("notes", jsonutils.NewString(msg))
+		db.AddOpsLogUpdateDiffs(notes, diffs)
+		db.OpsLog.LogEvent(guest, db.ACT_CHANGE_FLAVOR, notes, task.GetUserCred())
+		logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_CHANGE_FLAVOR, msg, task.GetUserCred(), false)
+
+		models.HostManager.ClearSchedDescCache(guest.HostId)
+	}
 	if cpuAdded > 0 {
-		_, err := db.Update(guest, func() error {
+		diffs, err := db.Update(guest, func() error {
 			guest.VcpuCount = guest.VcpuCount + int(cpuAdded)
 			return nil
 		})
 		if err != nil {
 			return err
 		}
-		db.OpsLog.LogEvent(guest, db.ACT_CHANGE_FLAVOR,
-			fmt.Sprintf("Change config task failed but added cpu count %d", cpuAdded), task.GetUserCred())
+		notes := jsonutils.NewDict()
+		notes.Set("notes", jsonutils.NewString(fmt.Sprintf("Change config task failed but added cpu count %d", cpuAdded)))
+		db.AddOpsLogUpdateDiffs(notes, diffs)
+		db.OpsLog.LogEvent(guest, db.ACT_CHANGE_FLAVOR, notes, task.GetUserCred())
 		logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_CHANGE_FLAVOR,
 			fmt.Sprintf("Change config task failed but added cpu count %d", cpuAdded), task.GetUserCred(), false)
 
@@ -810,6 +839,15 @@ func (self *SKVMGuestDriver) CheckMigrate(ctx context.Context, guest *models.SGu
 	if len(devices) > 0 {
 		return httperrors.NewBadRequestError("Cannot migrate with isolated devices")
 	}
+	filesystems, err := models.GuestFilesystemManager.GetGuestFilesystems(guest.Id)
+	if err != nil {
+		return errors.Wrapf(err, "GetGuestFilesystems")
+	}
+	for i := range filesystems {
+		if filesystems[i].SourceType == api.GUEST_FILESYSTEM_SOURCE_HOST_PATH {
+			return httperrors.NewBadRequestError("Cannot migrate with host path shared filesystem %s", filesystems[i].Name)
+		}
+	}
 	if len(input.PreferHostId) > 0 {
 		err := checkAssignHost(ctx, userCred, input.PreferHostId)
 		if err != nil {
@@ -838,6 +876,14 @@ func (self *SKVMGuestDriver) CheckLiveMigrate(ctx context.Context, guest *models
 		if len(devices) > 0 {
 			return httperrors.NewBadRequestError("Cannot live migrate with isolated devices")
 		}
+		filesystems, err := models.GuestFilesystemManager.GetGuestFilesystems(guest.Id)
+		if err != nil {
+			return errors.Wrapf(err, "GetGuestFilesystems")
+		}
+		if len(filesystems) > 0 {
+			// vhost-user-fs device state is not migratable
+			return httperrors.NewBadRequestError("Cannot live migrate with shared filesystems")
+		}
 		if !guest.CheckQemuVersion(guest.GetQemuVersion(userCred), "1.1.2") {
 			return httperrors.NewBadRequestError("Cannot do live migrate, too low qemu version")
 		}
@@ -1240,5 +1286,11 @@ func (kvm *SKVMGuestDriver) ValidateGuestHotChangeConfigInput(ctx context.Contex
 	if apis.IsARM(guest.OsArch) {
 		return confs, errors.Wrap(errors.ErrInvalidStatus, "cpu architecture is arm")
 	}
+	if confs.VcpuCount < confs.Old.VcpuCount {
+		return confs, errors.Wrap(errors.ErrInvalidStatus, "reduce cpu online is not supported")
+	}
+	if confs.VmemSize < confs.Old.VmemSize && guest.GetMetadata(ctx, api.VM_METADATA_HOT_RESIZE_MEM, nil) != "enable" {
+		return confs, errors.Wrap(errors.ErrInvalidStatus, "reduce memory online requires virtio-mem")
+	}
 	return confs, nil
 }
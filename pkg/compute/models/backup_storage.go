@@ -22,6 +22,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rand"
 	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
@@ -66,16 +67,18 @@ func (bs *SBackupStorageManager) ValidateCreateData(ctx context.Context, userCre
 	if err != nil {
 		return input, err
 	}
-	if !utils.IsInArray(input.StorageType, []string{string(api.BACKUPSTORAGE_TYPE_NFS), string(api.BACKUPSTORAGE_TYPE_OBJECT_STORAGE)}) {
+	if !utils.IsInArray(input.StorageType, []string{string(api.BACKUPSTORAGE_TYPE_NFS), string(api.BACKUPSTORAGE_TYPE_OBJECT_STORAGE), string(api.BACKUPSTORAGE_TYPE_DEDUP)}) {
 		return input, httperrors.NewInputParameterError("Invalid storage type %s", input.StorageType)
 	}
+	// the key of dedup storage is always generated
+	input.DedupEncryptKey = ""
 	switch input.StorageType {
-	case string(api.BACKUPSTORAGE_TYPE_NFS):
+	case string(api.BACKUPSTORAGE_TYPE_NFS), string(api.BACKUPSTORAGE_TYPE_DEDUP):
 		if input.NfsHost == "" {
-			return input, httperrors.NewInputParameterError("nfs_host is required when storage type is nfs")
+			return input, httperrors.NewInputParameterError("nfs_host is required when storage type is %s", input.StorageType)
 		}
 		if input.NfsSharedDir == "" {
-			return input, httperrors.NewInputParameterError("nfs_shared_dir is required when storage type is nfs")
+			return input, httperrors.NewInputParameterError("nfs_shared_dir is required when storage type is %s", input.StorageType)
 		}
 	case string(api.BACKUPSTORAGE_TYPE_OBJECT_STORAGE):
 		if input.ObjectBucketUrl == "" {
@@ -113,6 +116,18 @@ func (bs *SBackupStorage) CustomizeCreate(ctx context.Context, userCred mcclient
 		ObjectAccessKey: input.ObjectAccessKey,
 		ObjectSecret:    input.ObjectSecret,
 	}
+	if bs.StorageType == api.BACKUPSTORAGE_TYPE_DEDUP {
+		// the key is encrypted with the id of backup storage, which must be
+		// known before insert so that the plain key never lands in db
+		if len(bs.Id) == 0 {
+			bs.Id = db.DefaultUUIDGenerator()
+		}
+		key, err := utils.EncryptAESBase64(bs.Id, rand.String(32))
+		if err != nil {
+			return errors.Wrap(err, "EncryptAESBase64")
+		}
+		bs.AccessInfo.DedupEncryptKey = key
+	}
 	return bs.SEnabledStatusInfrasResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
 }
 
@@ -167,6 +182,7 @@ func (bs *SBackupStorage) getMoreDetails(ctx context.Context, out api.BackupStor
 	out.ObjectAccessKey = bs.AccessInfo.ObjectAccessKey
 	// should not return secret
 	out.ObjectSecret = "" // bs.AccessInfo.ObjectSecret
+	out.DedupEncryptKey = ""
 	return out
 }
 
@@ -239,7 +255,7 @@ func (bs *SBackupStorage) PostUpdate(ctx context.Context, userCred mcclient.Toke
 	accessInfoChanged := false
 	accessInfo := *bs.AccessInfo
 	switch bs.StorageType {
-	case api.BACKUPSTORAGE_TYPE_NFS:
+	case api.BACKUPSTORAGE_TYPE_NFS, api.BACKUPSTORAGE_TYPE_DEDUP:
 		if len(input.NfsHost) > 0 {
 			accessInfo.NfsHost = input.NfsHost
 			accessInfoChanged = true
@@ -292,6 +308,12 @@ func (bs *SBackupStorage) GetAccessInfo() (*api.SBackupStorageAccessInfo, error)
 			return nil, errors.Wrap(err, "DescryptAESBase64")
 		}
 		accessInfo.ObjectSecret = secret
+	case api.BACKUPSTORAGE_TYPE_DEDUP:
+		key, err := utils.DescryptAESBase64(bs.Id, accessInfo.DedupEncryptKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "DescryptAESBase64")
+		}
+		accessInfo.DedupEncryptKey = key
 	}
 	return &accessInfo, nil
 }
@@ -17,6 +17,7 @@ package models
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
 	"time"
 
@@ -24,14 +25,17 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/gotypes"
+	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
 
@@ -64,6 +68,11 @@ type SDiskBackup struct {
 	// 操作系统类型
 	OsType     string `width:"32" charset:"ascii" nullable:"true" list:"user" create:"optional"`
 	DiskConfig *SBackupDiskConfig
+
+	// 增量备份的父备份
+	ParentBackupId string `width:"36" charset:"ascii" nullable:"true" list:"user" create:"optional" index:"true"`
+	// 备份模式
+	BackupMode string `width:"16" charset:"ascii" nullable:"true" list:"user" default:"full"`
 }
 
 var DiskBackupManager *SDiskBackupManager
@@ -120,6 +129,12 @@ func (dm *SDiskBackupManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQ
 	if input.BackupStorageId != "" {
 		q = q.Equals("backup_storage_id", input.BackupStorageId)
 	}
+	if input.ParentBackupId != "" {
+		q = q.Equals("parent_backup_id", input.ParentBackupId)
+	}
+	if input.BackupMode != "" {
+		q = q.Equals("backup_mode", input.BackupMode)
+	}
 	if input.IsInstanceBackup != nil {
 		insjsq := InstanceBackupJointManager.Query().SubQuery()
 		if !*input.IsInstanceBackup {
@@ -143,6 +158,73 @@ func (self *SDiskBackup) ValidateDeleteCondition(ctx context.Context, info jsonu
 	if is {
 		return httperrors.NewBadRequestError("disk backup referenced by instance backup")
 	}
+	cnt, err := self.GetChildBackupCount()
+	if err != nil {
+		return errors.Wrap(err, "GetChildBackupCount")
+	}
+	if cnt > 0 {
+		return httperrors.NewBadRequestError("disk backup is parent of %d incremental backups", cnt)
+	}
+	return nil
+}
+
+func (self *SDiskBackup) GetChildBackupCount() (int, error) {
+	return DiskBackupManager.Query().Equals("parent_backup_id", self.Id).CountWithError()
+}
+
+func (self *SDiskBackup) IsIncremental() bool {
+	return len(self.ParentBackupId) > 0
+}
+
+func (self *SDiskBackup) GetParentBackup() (*SDiskBackup, error) {
+	ibackup, err := DiskBackupManager.FetchById(self.ParentBackupId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to fetch parent backup %s", self.ParentBackupId)
+	}
+	return ibackup.(*SDiskBackup), nil
+}
+
+// GetBackupChain returns backup ids from the full backup to this backup
+func (self *SDiskBackup) GetBackupChain() ([]string, error) {
+	return buildBackupChain(self.Id, self.ParentBackupId, func(backupId string) (string, error) {
+		backup, err := DiskBackupManager.FetchById(backupId)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to fetch parent backup %s", backupId)
+		}
+		return backup.(*SDiskBackup).ParentBackupId, nil
+	})
+}
+
+func buildBackupChain(backupId, parentId string, getParentId func(backupId string) (string, error)) ([]string, error) {
+	chain := []string{backupId}
+	for len(parentId) > 0 {
+		if utils.IsInStringArray(parentId, chain) {
+			return nil, errors.Wrapf(httperrors.ErrInvalidStatus, "backup chain of %s has loop", backupId)
+		}
+		chain = append([]string{parentId}, chain...)
+		var err error
+		parentId, err = getParentId(parentId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return chain, nil
+}
+
+// ConvertToFullBackup drops the parent of incremental backup when changed blocks
+// since the parent backup can't be tracked, the backup is taken as a full backup
+func (self *SDiskBackup) ConvertToFullBackup(ctx context.Context, userCred mcclient.TokenCredential, reason string) error {
+	_, err := db.Update(self, func() error {
+		self.ParentBackupId = ""
+		self.BackupMode = api.BACKUP_MODE_FULL
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update backup mode")
+	}
+	notes := fmt.Sprintf("take full backup instead of incremental backup: %s", reason)
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, notes, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, notes, userCred, true)
 	return nil
 }
 
@@ -182,6 +264,13 @@ func (db *SDiskBackup) getMoreDetails(out api.DiskBackupDetails) api.DiskBackupD
 	if t, _ := InstanceBackupJointManager.IsSubBackup(db.Id); t {
 		out.IsSubBackup = true
 	}
+	if db.IsIncremental() {
+		parent, _ := db.GetParentBackup()
+		if parent != nil {
+			out.ParentBackupName = parent.Name
+		}
+		out.BackupChain, _ = db.GetBackupChain()
+	}
 	return out
 }
 
@@ -268,6 +357,10 @@ func (dm *SDiskBackupManager) ValidateCreateData(
 	if bs.Status != api.BACKUPSTORAGE_STATUS_ONLINE {
 		return input, httperrors.NewForbiddenError("can't backup guest to backup storage with status %s", bs.Status)
 	}
+	if bs.StorageType == api.BACKUPSTORAGE_TYPE_DEDUP && (input.Incremental || len(input.ParentBackupId) > 0) {
+		// every backup of dedup storage is a full image whose unchanged chunks are shared with former backups
+		return input, httperrors.NewNotSupportedError("incremental backup is not supported by backup storage of type %s", bs.StorageType)
+	}
 	storage, err := disk.GetStorage()
 	if err != nil {
 		return input, errors.Wrapf(err, "unable to get storage of disk %s", disk.GetId())
@@ -279,9 +372,74 @@ func (dm *SDiskBackupManager) ValidateCreateData(
 	}
 	input.CloudregionId = region.Id
 
+	autoParent := false
+	if len(input.ParentBackupId) == 0 && input.Incremental {
+		parent, err := dm.getLatestBackup(disk.Id, bs.Id)
+		if err != nil {
+			return input, errors.Wrap(err, "getLatestBackup")
+		}
+		if parent != nil {
+			input.ParentBackupId = parent.Id
+			autoParent = true
+		}
+	}
+	if len(input.ParentBackupId) > 0 {
+		_parent, err := validators.ValidateModel(ctx, userCred, dm, &input.ParentBackupId)
+		if err != nil {
+			return input, err
+		}
+		parent := _parent.(*SDiskBackup)
+		if parent.Status != api.BACKUP_STATUS_READY {
+			return input, httperrors.NewInvalidStatusError("parent backup %s status is not %s", parent.Name, api.BACKUP_STATUS_READY)
+		}
+		if parent.DiskId != disk.Id {
+			return input, httperrors.NewInputParameterError("parent backup %s is not a backup of disk %s", parent.Name, disk.Name)
+		}
+		if parent.BackupStorageId != bs.Id {
+			return input, httperrors.NewInputParameterError("parent backup %s is not in backup storage %s", parent.Name, bs.Name)
+		}
+		if parent.DiskSizeMb != disk.DiskSize {
+			return input, httperrors.NewInputParameterError("disk %s has been resized since parent backup %s", disk.Name, parent.Name)
+		}
+		chain, err := parent.GetBackupChain()
+		if err != nil {
+			return input, errors.Wrap(err, "GetBackupChain")
+		}
+		if isBackupChainTooLong(chain) {
+			// too long chain slows down recovery
+			if !autoParent {
+				return input, httperrors.NewNotAcceptableError("backup chain of parent backup %s reaches max length %d, please take a full backup", parent.Name, options.Options.MaxDiskBackupChainLength)
+			}
+			// parent is chosen for user, start a new full backup explicitly
+			log.Infof("backup chain of %s reaches max length %d, take full backup of disk %s", parent.Name, options.Options.MaxDiskBackupChainLength, disk.Name)
+			input.ParentBackupId = ""
+			input.Incremental = false
+		}
+	}
+
 	return input, nil
 }
 
+// isBackupChainTooLong reports whether another incremental backup can't be chained on chain
+func isBackupChainTooLong(chain []string) bool {
+	return len(chain) > options.Options.MaxDiskBackupChainLength
+}
+
+// getLatestBackup returns the latest ready backup of disk in the backup storage
+func (dm *SDiskBackupManager) getLatestBackup(diskId, backupStorageId string) (*SDiskBackup, error) {
+	q := dm.Query().Equals("disk_id", diskId).Equals("backup_storage_id", backupStorageId).
+		Equals("status", api.BACKUP_STATUS_READY).Desc("created_at")
+	backups := make([]SDiskBackup, 0)
+	err := db.FetchModelObjects(dm, q.Limit(1), &backups)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, nil
+	}
+	return &backups[0], nil
+}
+
 func (db *SDiskBackup) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
 	err := db.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
 	if err != nil {
@@ -302,6 +460,11 @@ func (db *SDiskBackup) CustomizeCreate(ctx context.Context, userCred mcclient.To
 	db.StorageId = disk.StorageId
 	db.DomainId = disk.DomainId
 	db.ProjectId = disk.ProjectId
+	if db.IsIncremental() {
+		db.BackupMode = api.BACKUP_MODE_INCREMENTAL
+	} else {
+		db.BackupMode = api.BACKUP_MODE_FULL
+	}
 	return nil
 }
 
@@ -483,6 +646,7 @@ func (manager *SDiskBackupManager) CreateBackup(ctx context.Context, owner mccli
 	}
 	backup.BackupStorageId = backupStorageId
 	backup.Name = name
+	backup.BackupMode = api.BACKUP_MODE_FULL
 	backup.Status = api.BACKUP_STATUS_CREATING
 	err = DiskBackupManager.TableSpec().Insert(ctx, backup)
 	if err != nil {
@@ -538,6 +702,7 @@ func (manager *SDiskBackupManager) CreateFromPackMetadata(ctx context.Context, o
 	backup.BackupStorageId = backupStorageId
 	backup.Name = name
 	backup.Id = id
+	backup.BackupMode = api.BACKUP_MODE_FULL
 	backup.Status = api.BACKUP_STATUS_READY
 	err := DiskBackupManager.TableSpec().Insert(ctx, backup)
 	if err != nil {
@@ -0,0 +1,84 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/compute/options"
+)
+
+func TestBuildBackupChain(t *testing.T) {
+	parents := map[string]string{
+		"full":  "",
+		"inc1":  "full",
+		"inc2":  "inc1",
+		"loop1": "loop2",
+		"loop2": "loop1",
+	}
+	getParentId := func(id string) (string, error) {
+		parent, ok := parents[id]
+		if !ok {
+			return "", errors.ErrNotFound
+		}
+		return parent, nil
+	}
+	cases := []struct {
+		id      string
+		want    []string
+		wantErr bool
+	}{
+		{id: "full", want: []string{"full"}},
+		{id: "inc1", want: []string{"full", "inc1"}},
+		{id: "inc2", want: []string{"full", "inc1", "inc2"}},
+		{id: "inc3", want: []string{"full", "inc1", "inc2", "inc3"}},
+		{id: "loop1", wantErr: true},
+		{id: "orphan", wantErr: true},
+	}
+	parents["inc3"] = "inc2"
+	parents["orphan"] = "missing"
+	for _, c := range cases {
+		got, err := buildBackupChain(c.id, parents[c.id], getParentId)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error got chain %v", c.id, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.id, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %v got %v", c.id, c.want, got)
+		}
+	}
+}
+
+func TestIsBackupChainTooLong(t *testing.T) {
+	origin := options.Options.MaxDiskBackupChainLength
+	defer func() { options.Options.MaxDiskBackupChainLength = origin }()
+	options.Options.MaxDiskBackupChainLength = 2
+	// chain of parent backup, the new backup adds one more incremental backup
+	if isBackupChainTooLong([]string{"full", "inc1"}) {
+		t.Errorf("full backup with 1 incremental backup can be chained")
+	}
+	if !isBackupChainTooLong([]string{"full", "inc1", "inc2"}) {
+		t.Errorf("full backup with 2 incremental backups reaches max length")
+	}
+}
@@ -798,11 +798,18 @@ func (self *SDisk) getDiskAllocateFromBackupInput(ctx context.Context, backupId
 	if err != nil {
 		return nil, errors.Wrap(err, "backupStorage.GetAccessInfo")
 	}
-	return &api.DiskAllocateFromBackupInput{
+	input := &api.DiskAllocateFromBackupInput{
 		BackupId:                backupId,
 		BackupStorageId:         bs.GetId(),
 		BackupStorageAccessInfo: jsonutils.Marshal(accessInfo).(*jsonutils.JSONDict),
-	}, nil
+	}
+	if backup.IsIncremental() {
+		input.BackupChain, err = backup.GetBackupChain()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get backup chain of backup %s", backupId)
+		}
+	}
+	return input, nil
 }
 
 func (self *SDisk) StartAllocate(ctx context.Context, host *SHost, storage *SStorage, taskId string, userCred mcclient.TokenCredential, rebuild bool, snapshot string, task taskman.ITask) error {
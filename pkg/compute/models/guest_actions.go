@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -137,7 +138,8 @@ func (self *SGuest) PerformEvent(ctx context.Context, userCred mcclient.TokenCre
 	if err != nil {
 		return nil, httperrors.NewMissingParameterError("event")
 	}
-	if event == "GUEST_PANICKED" {
+	switch event {
+	case "GUEST_PANICKED":
 		kwargs := jsonutils.NewDict()
 		kwargs.Set("reason", data)
 
@@ -148,6 +150,15 @@ func (self *SGuest) PerformEvent(ctx context.Context, userCred mcclient.TokenCre
 			Action: notifyclient.ActionServerPanicked,
 			IsFail: true,
 		})
+	case "WATCHDOG":
+		// guest hung and hardware watchdog expired, recovery_action in data is taken by host
+		db.OpsLog.LogEvent(self, db.ACT_GUEST_WATCHDOG, data.String(), userCred)
+		logclient.AddSimpleActionLog(self, logclient.ACT_GUEST_WATCHDOG, data.String(), userCred, true)
+		notifyclient.EventNotify(ctx, userCred, notifyclient.SEventNotifyParam{
+			Obj:    self,
+			Action: notifyclient.ActionServerPanicked,
+			IsFail: true,
+		})
 	}
 	return nil, nil
 }
@@ -2469,6 +2480,12 @@ func (self *SGuest) PerformChangeIpaddr(
 		notes.Add(jsonutils.NewString(ngn.IpAddr), "ip")
 	}
 	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_VM_CHANGE_NIC, notes, userCred, true)
+	// the opslog without networks diff marks the history of guest incomplete
+	opsNotes := notes.Copy()
+	if gns, err := self.getHistoryNetworks(); err == nil {
+		addChangeNicHistoryDiff(opsNotes, gns, gn)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, opsNotes, userCred)
 
 	restartNetwork := (input.RestartNetwork != nil && *input.RestartNetwork)
 
@@ -3291,6 +3308,72 @@ func (self *SGuest) SendMonitorCommand(ctx context.Context, userCred mcclient.To
 	return ret, nil
 }
 
+// 查询虚拟机安全组流量日志
+func (self *SGuest) GetDetailsFlowLogs(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerFlowLogInput) (*api.ServerFlowLogOutput, error) {
+	if self.Hypervisor != api.HYPERVISOR_KVM {
+		return nil, httperrors.NewUnsupportOperationError("flow log is not supported by hypervisor %s", self.Hypervisor)
+	}
+	host, err := self.GetHost()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "GetHost"))
+	}
+	if input.Limit <= 0 {
+		input.Limit = api.FLOW_LOG_DEFAULT_LIMIT
+	}
+	gns, err := self.GetNetworks("")
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "GetNetworks"))
+	}
+	input.Macs = make([]string, 0, len(gns))
+	for i := range gns {
+		input.Macs = append(input.Macs, gns[i].MacAddr)
+	}
+
+	// flow logs are kept on the hosts guest ran on, the mac addresses are
+	// passed to the hosts where guest no longer exists
+	output := &api.ServerFlowLogOutput{}
+	output.Data, err = self.requestFlowLogs(ctx, host, input)
+	if err != nil {
+		return nil, err
+	}
+	for _, hostId := range self.getFlowLogHosts(ctx) {
+		if hostId == host.Id {
+			continue
+		}
+		prev := HostManager.FetchHostById(hostId)
+		if prev == nil {
+			continue
+		}
+		data, err := self.requestFlowLogs(ctx, prev, input)
+		if err != nil {
+			log.Warningf("query flow logs of %s on previous host %s: %v", self.Name, prev.Name, err)
+			continue
+		}
+		output.Data = append(output.Data, data...)
+	}
+	sort.SliceStable(output.Data, func(i, j int) bool {
+		return output.Data[i].Time.After(output.Data[j].Time)
+	})
+	if len(output.Data) > input.Limit {
+		output.Data = output.Data[:input.Limit]
+	}
+	return output, nil
+}
+
+func (self *SGuest) requestFlowLogs(ctx context.Context, host *SHost, input api.ServerFlowLogInput) ([]api.ServerFlowLog, error) {
+	url := fmt.Sprintf("%s/servers/%s/flow-logs?%s", host.ManagerUri, self.Id, jsonutils.Marshal(input).QueryString())
+	header := mcclient.GetTokenHeaders(auth.AdminCredential())
+	_, res, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "GET", url, header, nil, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request host %s", host.Name)
+	}
+	output := &api.ServerFlowLogOutput{}
+	if err := res.Unmarshal(output); err != nil {
+		return nil, errors.Wrap(err, "Unmarshal")
+	}
+	return output.Data, nil
+}
+
 func (self *SGuest) PerformAssociateEip(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerAssociateEipInput) (jsonutils.JSONObject, error) {
 	err := self.IsEipAssociable()
 	if err != nil {
@@ -3543,6 +3626,105 @@ func (self *SGuest) PerformSetQemuParams(ctx context.Context, userCred mcclient.
 			return nil, err
 		}
 	}
+	tpm, err := data.GetString(api.VM_METADATA_ENABLE_TPM)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("vTPM is only supported by %s", api.HYPERVISOR_KVM)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_ENABLE_TPM, tpm, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	secureBoot, err := data.GetString(api.VM_METADATA_SECURE_BOOT)
+	if err == nil {
+		if secureBoot == "true" {
+			if self.Hypervisor != api.HYPERVISOR_KVM {
+				return nil, httperrors.NewUnsupportOperationError("secure boot is only supported by %s", api.HYPERVISOR_KVM)
+			}
+			if self.Bios != "UEFI" {
+				return nil, httperrors.NewInputParameterError("secure boot requires UEFI boot mode")
+			}
+			if self.Machine == api.VM_MACHINE_TYPE_PC {
+				return nil, httperrors.NewInputParameterError("secure boot requires machine type %s", api.VM_MACHINE_TYPE_Q35)
+			}
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_SECURE_BOOT, secureBoot, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	balloon, err := data.GetString(api.VM_METADATA_ENABLE_BALLOON)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("memory balloon is only supported by %s", api.HYPERVISOR_KVM)
+		}
+		if balloon == "true" {
+			if err := self.validateBalloonSupported(); err != nil {
+				return nil, err
+			}
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_ENABLE_BALLOON, balloon, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	virtioMem, err := data.GetString(api.VM_METADATA_ENABLE_VIRTIO_MEM)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("virtio-mem is only supported by %s", api.HYPERVISOR_KVM)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_ENABLE_VIRTIO_MEM, virtioMem, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	watchdog, err := data.GetString(api.VM_METADATA_WATCHDOG)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("watchdog is only supported by %s", api.HYPERVISOR_KVM)
+		}
+		// empty watchdog removes the device on next start
+		if len(watchdog) > 0 && !utils.IsInStringArray(watchdog, api.GUEST_WATCHDOG_MODELS) {
+			return nil, httperrors.NewInputParameterError("invalid %s %s, choose from %s", api.VM_METADATA_WATCHDOG, watchdog, api.GUEST_WATCHDOG_MODELS)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_WATCHDOG, watchdog, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	needSync := false
+	crashAction, err := data.GetString(api.VM_METADATA_CRASH_ACTION)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("crash action is only supported by %s", api.HYPERVISOR_KVM)
+		}
+		if !utils.IsInStringArray(crashAction, api.GUEST_CRASH_ACTIONS) {
+			return nil, httperrors.NewInputParameterError("invalid %s %s, choose from %s", api.VM_METADATA_CRASH_ACTION, crashAction, api.GUEST_CRASH_ACTIONS)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_CRASH_ACTION, crashAction, userCred)
+		if err != nil {
+			return nil, err
+		}
+		needSync = true
+	}
+	if data.Contains(api.VM_METADATA_BALLOON_MIN_MEM) {
+		minMem, err := data.Int(api.VM_METADATA_BALLOON_MIN_MEM)
+		if err != nil || minMem < 0 {
+			return nil, httperrors.NewInputParameterError("invalid %s", api.VM_METADATA_BALLOON_MIN_MEM)
+		}
+		if int(minMem) > self.VmemSize {
+			return nil, httperrors.NewInputParameterError("%s %d exceeds guest memory %d", api.VM_METADATA_BALLOON_MIN_MEM, minMem, self.VmemSize)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_BALLOON_MIN_MEM, minMem, userCred)
+		if err != nil {
+			return nil, err
+		}
+		needSync = true
+	}
+	if needSync && len(self.HostId) > 0 {
+		return nil, self.StartSyncTask(ctx, userCred, false, "")
+	}
 	return nil, nil
 }
 
@@ -0,0 +1,320 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// +onecloud:swagger-gen-model-singular=guest_filesystem
+// +onecloud:swagger-gen-model-plural=guest_filesystems
+type SGuestFilesystemManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+var GuestFilesystemManager *SGuestFilesystemManager
+
+func init() {
+	GuestFilesystemManager = &SGuestFilesystemManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SGuestFilesystem{},
+			"guest_filesystems_tbl",
+			"guest_filesystem",
+			"guest_filesystems",
+		),
+	}
+	GuestFilesystemManager.SetVirtualObject(GuestFilesystemManager)
+	GuestFilesystemManager.TableSpec().AddIndex(true, "guest_id", "mount_tag", "deleted")
+}
+
+// SGuestFilesystem is a host directory or shared file storage exported to a kvm guest by virtio-fs
+type SGuestFilesystem struct {
+	db.SVirtualResourceBase
+
+	GuestId    string `width:"36" charset:"ascii" nullable:"false" create:"required" list:"user" index:"true"`
+	SourceType string `width:"16" charset:"ascii" nullable:"false" create:"required" list:"user"`
+	// 宿主机共享目录
+	HostPath string `width:"256" charset:"utf8" nullable:"true" create:"admin_optional" list:"admin"`
+	// 共享文件存储
+	StorageId string `width:"36" charset:"ascii" nullable:"true" create:"optional" list:"user" index:"true"`
+	SubPath   string `width:"256" charset:"utf8" nullable:"true" create:"optional" list:"user"`
+	// virtio-fs mount tag
+	MountTag string `width:"36" charset:"utf8" nullable:"false" create:"optional" list:"user"`
+	// 虚拟机内挂载点
+	MountPoint string `width:"256" charset:"utf8" nullable:"true" create:"optional" list:"user"`
+	ReadOnly   bool   `default:"false" create:"optional" list:"user"`
+}
+
+func (manager *SGuestFilesystemManager) FetchUniqValues(ctx context.Context, data jsonutils.JSONObject) jsonutils.JSONObject {
+	guestId, _ := data.GetString("guest_id")
+	return jsonutils.Marshal(map[string]string{"guest_id": guestId})
+}
+
+func (manager *SGuestFilesystemManager) FilterByUniqValues(q *sqlchemy.SQuery, values jsonutils.JSONObject) *sqlchemy.SQuery {
+	guestId, _ := values.GetString("guest_id")
+	if len(guestId) > 0 {
+		q = q.Equals("guest_id", guestId)
+	}
+	return q
+}
+
+func (manager *SGuestFilesystemManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, query api.GuestFilesystemListInput) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.GuestId) > 0 {
+		guest, err := GuestManager.FetchByIdOrName(ctx, userCred, query.GuestId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch guest by %s", query.GuestId)
+		}
+		q = q.Equals("guest_id", guest.GetId())
+	}
+	if len(query.StorageId) > 0 {
+		storage, err := StorageManager.FetchByIdOrName(ctx, userCred, query.StorageId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch storage by %s", query.StorageId)
+		}
+		q = q.Equals("storage_id", storage.GetId())
+	}
+	return q, nil
+}
+
+func (manager *SGuestFilesystemManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.GuestFilesystemDetails {
+	rows := make([]api.GuestFilesystemDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	guestIds := make([]string, len(objs))
+	storageIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.GuestFilesystemDetails{
+			VirtualResourceDetails: virtRows[i],
+		}
+		fs := objs[i].(*SGuestFilesystem)
+		guestIds[i] = fs.GuestId
+		storageIds[i] = fs.StorageId
+	}
+	guests, err := db.FetchIdNameMap2(GuestManager, guestIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 guests: %v", err)
+		return rows
+	}
+	storages, err := db.FetchIdNameMap2(StorageManager, storageIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 storages: %v", err)
+		return rows
+	}
+	for i := range rows {
+		rows[i].Guest = guests[guestIds[i]]
+		rows[i].Storage = storages[storageIds[i]]
+	}
+	return rows
+}
+
+func (manager *SGuestFilesystemManager) GetGuestFilesystems(guestId string) ([]SGuestFilesystem, error) {
+	q := manager.Query().Equals("guest_id", guestId).Asc("created_at")
+	ret := make([]SGuestFilesystem, 0)
+	if err := db.FetchModelObjects(manager, q, &ret); err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return ret, nil
+}
+
+func validateGuestFilesystemPath(key, path string) (string, error) {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return "", httperrors.NewInputParameterError("%s %s must be an absolute path", key, path)
+	}
+	return path, nil
+}
+
+func (manager *SGuestFilesystemManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input *api.GuestFilesystemCreateInput) (*api.GuestFilesystemCreateInput, error) {
+	if len(input.GuestId) == 0 {
+		return nil, httperrors.NewNotEmptyError("guest_id is required")
+	}
+	guestObj, err := validators.ValidateModel(ctx, userCred, GuestManager, &input.GuestId)
+	if err != nil {
+		return nil, err
+	}
+	guest := guestObj.(*SGuest)
+	if guest.GetHypervisor() != api.HYPERVISOR_KVM {
+		return nil, httperrors.NewUnsupportOperationError("shared filesystem is only supported by %s guest", api.HYPERVISOR_KVM)
+	}
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return nil, httperrors.NewInvalidStatusError("Can't attach shared filesystem to guest in status %s", guest.Status)
+	}
+	host, err := guest.GetHost()
+	if err != nil {
+		return nil, errors.Wrap(err, "guest.GetHost")
+	}
+
+	switch input.SourceType {
+	case api.GUEST_FILESYSTEM_SOURCE_HOST_PATH:
+		if db.IsAdminAllowCreate(userCred, manager).Result.IsDeny() {
+			return nil, httperrors.NewForbiddenError("only sysadmin can share host path")
+		}
+		if len(input.HostPath) == 0 {
+			return nil, httperrors.NewMissingParameterError("host_path")
+		}
+		if strings.Contains(input.HostPath, "..") {
+			return nil, httperrors.NewInputParameterError("invalid host_path %s", input.HostPath)
+		}
+		input.HostPath, err = validateGuestFilesystemPath("host_path", input.HostPath)
+		if err != nil {
+			return nil, err
+		}
+		if input.HostPath == "/" {
+			return nil, httperrors.NewInputParameterError("can't share host root directory")
+		}
+		input.StorageId = ""
+		input.SubPath = ""
+	case api.GUEST_FILESYSTEM_SOURCE_STORAGE:
+		if len(input.StorageId) == 0 {
+			return nil, httperrors.NewMissingParameterError("storage_id")
+		}
+		storageObj, err := validators.ValidateModel(ctx, userCred, StorageManager, &input.StorageId)
+		if err != nil {
+			return nil, err
+		}
+		storage := storageObj.(*SStorage)
+		if !utils.IsInStringArray(storage.StorageType, api.SHARED_FILE_STORAGE) {
+			return nil, httperrors.NewInputParameterError("storage %s type %s is not a shared file storage", storage.Name, storage.StorageType)
+		}
+		if host.GetHoststorageOfId(storage.Id) == nil {
+			return nil, httperrors.NewInputParameterError("storage %s is not attached to host %s", storage.Name, host.Name)
+		}
+		if strings.Contains(input.SubPath, "..") {
+			return nil, httperrors.NewInputParameterError("invalid sub_path %s", input.SubPath)
+		}
+		if len(input.SubPath) > 0 {
+			input.SubPath = strings.TrimPrefix(filepath.Clean("/"+input.SubPath), "/")
+		}
+		if db.IsAdminAllowCreate(userCred, manager).Result.IsDeny() {
+			// storage is shared by all tenants, confine users to their project directory
+			input.SubPath = filepath.Join(api.GUEST_FILESYSTEM_PROJECT_DIR, ownerId.GetProjectId(), input.SubPath)
+		} else if len(input.SubPath) == 0 {
+			return nil, httperrors.NewInputParameterError("can't share storage root directory")
+		}
+		input.HostPath = ""
+	default:
+		return nil, httperrors.NewInputParameterError("invalid source_type %q", input.SourceType)
+	}
+
+	if len(input.MountTag) == 0 {
+		input.MountTag = input.Name
+	}
+	if len(input.MountTag) == 0 {
+		return nil, httperrors.NewMissingParameterError("mount_tag")
+	}
+	if len(input.MountTag) > api.GUEST_FILESYSTEM_MOUNT_TAG_MAX_LEN {
+		return nil, httperrors.NewInputParameterError("mount_tag %s exceeds %d bytes", input.MountTag, api.GUEST_FILESYSTEM_MOUNT_TAG_MAX_LEN)
+	}
+	if strings.ContainsAny(input.MountTag, " \t,") {
+		return nil, httperrors.NewInputParameterError("invalid mount_tag %s", input.MountTag)
+	}
+	cnt, err := manager.Query().Equals("guest_id", guest.Id).Equals("mount_tag", input.MountTag).CountWithError()
+	if err != nil {
+		return nil, errors.Wrap(err, "count mount tag")
+	}
+	if cnt > 0 {
+		return nil, httperrors.NewDuplicateResourceError("mount_tag %s already used by guest %s", input.MountTag, guest.Name)
+	}
+	if len(input.MountPoint) > 0 {
+		input.MountPoint, err = validateGuestFilesystemPath("mount_point", input.MountPoint)
+		if err != nil {
+			return nil, err
+		}
+		if input.MountPoint == "/" {
+			return nil, httperrors.NewInputParameterError("can't mount shared filesystem at /")
+		}
+	}
+
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (fs *SGuestFilesystem) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	fs.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	fs.SetStatus(ctx, userCred, api.GUEST_FILESYSTEM_STATUS_READY, "")
+	guest := fs.GetGuest()
+	if guest == nil {
+		return
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_ATTACH, fs.GetShortDesc(ctx), userCred)
+	if err := guest.StartSyncTask(ctx, userCred, false, ""); err != nil {
+		log.Errorf("guest %s start sync task after attaching filesystem %s: %v", guest.Name, fs.Name, err)
+	}
+}
+
+func (fs *SGuestFilesystem) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	guest := fs.GetGuest()
+	if guest != nil && !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return httperrors.NewInvalidStatusError("Can't detach shared filesystem from guest in status %s", guest.Status)
+	}
+	return fs.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (fs *SGuestFilesystem) PostDelete(ctx context.Context, userCred mcclient.TokenCredential) {
+	fs.SVirtualResourceBase.PostDelete(ctx, userCred)
+	guest := fs.GetGuest()
+	if guest == nil {
+		return
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_DETACH, fs.GetShortDesc(ctx), userCred)
+	if err := guest.StartSyncTask(ctx, userCred, false, ""); err != nil {
+		log.Errorf("guest %s start sync task after detaching filesystem %s: %v", guest.Name, fs.Name, err)
+	}
+}
+
+func (fs *SGuestFilesystem) GetGuest() *SGuest {
+	return GuestManager.FetchGuestById(fs.GuestId)
+}
+
+func (fs *SGuestFilesystem) GetJsonDesc() *api.GuestFilesystemJsonDesc {
+	return &api.GuestFilesystemJsonDesc{
+		Id:         fs.Id,
+		SourceType: fs.SourceType,
+		HostPath:   fs.HostPath,
+		StorageId:  fs.StorageId,
+		SubPath:    fs.SubPath,
+		MountTag:   fs.MountTag,
+		MountPoint: fs.MountPoint,
+		ReadOnly:   fs.ReadOnly,
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+const (
+	// fields of joint resources in the history of server
+	HISTORY_FIELD_NETWORKS = "networks"
+	HISTORY_FIELD_DISKS    = "disks"
+)
+
+func guestnetworksHistory(gns []SGuestnetwork) jsonutils.JSONObject {
+	sort.SliceStable(gns, func(i, j int) bool {
+		return gns[i].Index < gns[j].Index
+	})
+	ret := jsonutils.NewArray()
+	for i := range gns {
+		entry := jsonutils.NewDict()
+		entry.Set("index", jsonutils.NewInt(int64(gns[i].Index)))
+		entry.Set("network_id", jsonutils.NewString(gns[i].NetworkId))
+		entry.Set("ip_addr", jsonutils.NewString(gns[i].IpAddr))
+		entry.Set("mac_addr", jsonutils.NewString(gns[i].MacAddr))
+		ret.Add(entry)
+	}
+	return ret
+}
+
+func guestdisksHistory(gds []SGuestdisk, diskSizes map[string]int) jsonutils.JSONObject {
+	sort.SliceStable(gds, func(i, j int) bool {
+		return gds[i].Index < gds[j].Index
+	})
+	ret := jsonutils.NewArray()
+	for i := range gds {
+		entry := jsonutils.NewDict()
+		entry.Set("index", jsonutils.NewInt(int64(gds[i].Index)))
+		entry.Set("disk_id", jsonutils.NewString(gds[i].DiskId))
+		entry.Set("disk_size", jsonutils.NewInt(int64(diskSizes[gds[i].DiskId])))
+		ret.Add(entry)
+	}
+	return ret
+}
+
+func (guest *SGuest) getHistoryNetworks() ([]SGuestnetwork, error) {
+	return guest.GetNetworks("")
+}
+
+func (guest *SGuest) getHistoryDisks(gds []SGuestdisk) jsonutils.JSONObject {
+	diskSizes := make(map[string]int)
+	for i := range gds {
+		if disk := gds[i].GetDisk(); disk != nil {
+			diskSizes[disk.Id] = disk.DiskSize
+		}
+	}
+	return guestdisksHistory(gds, diskSizes)
+}
+
+// GetHistoryDisks returns the disks of guest recorded in opslog for history
+func (guest *SGuest) GetHistoryDisks() (jsonutils.JSONObject, error) {
+	gds, err := guest.GetGuestDisks()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetGuestDisks")
+	}
+	return guest.getHistoryDisks(gds), nil
+}
+
+// GetHistoryJointFields implements db.IHistoryJointFieldsModel
+func (guest *SGuest) GetHistoryJointFields(ctx context.Context) (map[string]db.SHistoryJointField, error) {
+	gns, err := guest.getHistoryNetworks()
+	if err != nil {
+		return nil, errors.Wrap(err, "getHistoryNetworks")
+	}
+	disks, err := guest.GetHistoryDisks()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]db.SHistoryJointField{
+		HISTORY_FIELD_NETWORKS: {
+			Keywords: []string{NetworkManager.Keyword(), GuestnetworkManager.Keyword()},
+			Value:    guestnetworksHistory(gns),
+		},
+		HISTORY_FIELD_DISKS: {
+			Keywords: []string{DiskManager.Keyword(), GuestdiskManager.Keyword()},
+			Value:    disks,
+		},
+	}, nil
+}
+
+// addNetworksHistoryDiff records networks of guest before and after attaching
+// or detaching nic in notes, gns are networks after the change
+func addNetworksHistoryDiff(notes *jsonutils.JSONDict, gns []SGuestnetwork, nic *SGuestnetwork, attach bool) {
+	others := make([]SGuestnetwork, 0, len(gns))
+	for i := range gns {
+		if gns[i].RowId != nic.RowId {
+			others = append(others, gns[i])
+		}
+	}
+	withNic := append(append([]SGuestnetwork{}, others...), *nic)
+	if attach {
+		db.AddOpsLogFieldDiff(notes, HISTORY_FIELD_NETWORKS, guestnetworksHistory(others), guestnetworksHistory(withNic))
+	} else {
+		db.AddOpsLogFieldDiff(notes, HISTORY_FIELD_NETWORKS, guestnetworksHistory(withNic), guestnetworksHistory(others))
+	}
+}
+
+// addDisksHistoryDiff records disks of guest before and after attaching or
+// detaching gd in notes, gds are disks after the change
+func (guest *SGuest) addDisksHistoryDiff(notes *jsonutils.JSONDict, gds []SGuestdisk, gd *SGuestdisk, attach bool) {
+	others := make([]SGuestdisk, 0, len(gds))
+	for i := range gds {
+		if gds[i].DiskId != gd.DiskId {
+			others = append(others, gds[i])
+		}
+	}
+	withDisk := append(append([]SGuestdisk{}, others...), *gd)
+	if attach {
+		db.AddOpsLogFieldDiff(notes, HISTORY_FIELD_DISKS, guest.getHistoryDisks(others), guest.getHistoryDisks(withDisk))
+	} else {
+		db.AddOpsLogFieldDiff(notes, HISTORY_FIELD_DISKS, guest.getHistoryDisks(withDisk), guest.getHistoryDisks(others))
+	}
+}
+
+// addChangeNicHistoryDiff records networks of guest before and after changing
+// the address of a nic in notes, gns are networks after the change
+func addChangeNicHistoryDiff(notes *jsonutils.JSONDict, gns []SGuestnetwork, prevNic *SGuestnetwork) {
+	prev := make([]SGuestnetwork, len(gns))
+	copy(prev, gns)
+	for i := range prev {
+		if prev[i].RowId == prevNic.RowId {
+			prev[i] = *prevNic
+		}
+	}
+	db.AddOpsLogFieldDiff(notes, HISTORY_FIELD_NETWORKS, guestnetworksHistory(prev), guestnetworksHistory(gns))
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestAddNetworksHistoryDiff(t *testing.T) {
+	newNic := func(rowId int64, index int, netId, ip string) SGuestnetwork {
+		gn := SGuestnetwork{}
+		gn.RowId = rowId
+		gn.Index = index
+		gn.NetworkId = netId
+		gn.IpAddr = ip
+		return gn
+	}
+	eth0 := newNic(1, 0, "net1", "10.0.0.2")
+	eth1 := newNic(2, 1, "net2", "10.0.1.2")
+
+	notes := jsonutils.NewDict()
+	addNetworksHistoryDiff(notes, []SGuestnetwork{eth1, eth0}, &eth1, true)
+	oldNets, _ := notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "old")
+	newNets, _ := notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "new")
+	if len(oldNets) != 1 || len(newNets) != 2 {
+		t.Fatalf("attach: want 1 => 2 networks, got %s", notes)
+	}
+	if ip, _ := newNets[1].GetString("ip_addr"); ip != "10.0.1.2" {
+		t.Errorf("networks should be ordered by index, got %s", notes)
+	}
+
+	// nic is already deleted on detach
+	notes = jsonutils.NewDict()
+	addNetworksHistoryDiff(notes, []SGuestnetwork{eth0}, &eth1, false)
+	oldNets, _ = notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "old")
+	newNets, _ = notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "new")
+	if len(oldNets) != 2 || len(newNets) != 1 {
+		t.Errorf("detach: want 2 => 1 networks, got %s", notes)
+	}
+}
+
+func TestAddChangeNicHistoryDiff(t *testing.T) {
+	newNic := func(rowId int64, index int, ip string) SGuestnetwork {
+		gn := SGuestnetwork{}
+		gn.RowId = rowId
+		gn.Index = index
+		gn.NetworkId = "net1"
+		gn.IpAddr = ip
+		return gn
+	}
+	eth0 := newNic(1, 0, "10.0.0.2")
+	eth1 := newNic(2, 1, "10.0.0.3")
+	prevEth1 := newNic(2, 1, "10.0.0.9")
+
+	notes := jsonutils.NewDict()
+	addChangeNicHistoryDiff(notes, []SGuestnetwork{eth0, eth1}, &prevEth1)
+	oldNets, _ := notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "old")
+	newNets, _ := notes.GetArray("field_diffs", HISTORY_FIELD_NETWORKS, "new")
+	if len(oldNets) != 2 || len(newNets) != 2 {
+		t.Fatalf("want 2 => 2 networks, got %s", notes)
+	}
+	if ip, _ := oldNets[1].GetString("ip_addr"); ip != "10.0.0.9" {
+		t.Errorf("old address of nic should be recorded, got %s", notes)
+	}
+	if ip, _ := newNets[1].GetString("ip_addr"); ip != "10.0.0.3" {
+		t.Errorf("new address of nic should be recorded, got %s", notes)
+	}
+}
@@ -248,7 +248,23 @@ func (self *SGuestdisk) Delete(ctx context.Context, userCred mcclient.TokenCrede
 }
 
 func (self *SGuestdisk) Detach(ctx context.Context, userCred mcclient.TokenCredential) error {
-	return db.DetachJoint(ctx, userCred, self)
+	err := db.ValidateDeleteCondition(self, ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = self.Delete(ctx, userCred)
+	if err != nil {
+		return err
+	}
+	notes := self.GetShortDesc(ctx)
+	if obj, err := GuestManager.FetchById(self.GuestId); err == nil {
+		guest := obj.(*SGuest)
+		if gds, err := guest.GetGuestDisks(); err == nil {
+			guest.addDisksHistoryDiff(notes, gds, self, false)
+		}
+	}
+	db.OpsLog.LogDetachEvent(ctx, db.JointMaster(self), db.JointSlave(self), userCred, notes)
+	return nil
 }
 
 // DEPRECATE: will be remove in future, use ToDiskConfig
@@ -930,7 +930,11 @@ func (gn *SGuestnetwork) LogDetachEvent(ctx context.Context, userCred mcclient.T
 		}
 		network = netTmp.(*SNetwork)
 	}
-	db.OpsLog.LogDetachEvent(ctx, guest, network, userCred, nil)
+	notes := jsonutils.NewDict()
+	if gns, err := guest.getHistoryNetworks(); err == nil {
+		addNetworksHistoryDiff(notes, gns, gn, false)
+	}
+	db.OpsLog.LogDetachEvent(ctx, guest, network, userCred, notes)
 }
 
 func (gn *SGuestnetwork) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
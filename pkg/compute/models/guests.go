@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -1203,6 +1204,7 @@ func (guest *SGuest) GetHost() (*SHost, error) {
 
 func (guest *SGuest) SetHostId(userCred mcclient.TokenCredential, hostId string) error {
 	if guest.HostId != hostId {
+		prevHostId := guest.HostId
 		diff, err := db.Update(guest, func() error {
 			guest.HostId = hostId
 			return nil
@@ -1211,11 +1213,47 @@ func (guest *SGuest) SetHostId(userCred mcclient.TokenCredential, hostId string)
 			return err
 		}
 		db.OpsLog.LogEvent(guest, db.ACT_UPDATE, diff, userCred)
+		guest.addFlowLogHost(userCred, prevHostId)
 	}
 	return nil
 }
 
+// getFlowLogHosts returns the hosts guest ran on before, latest first
+func (guest *SGuest) getFlowLogHosts(ctx context.Context) []string {
+	hostIds := []string{}
+	if obj := guest.GetMetadataJson(ctx, api.VM_METADATA_FLOW_LOG_HOSTS, nil); obj != nil {
+		obj.Unmarshal(&hostIds)
+	}
+	return hostIds
+}
+
+// addFlowLogHost records the host guest leaves, so that flow logs kept on it
+// are still queried
+func (guest *SGuest) addFlowLogHost(userCred mcclient.TokenCredential, hostId string) {
+	if guest.Hypervisor != api.HYPERVISOR_KVM || len(hostId) == 0 || hostId == guest.HostId {
+		return
+	}
+	ctx := context.Background()
+	hostIds := prependFlowLogHost(guest.getFlowLogHosts(ctx), hostId)
+	if err := guest.SetMetadata(ctx, api.VM_METADATA_FLOW_LOG_HOSTS, hostIds, userCred); err != nil {
+		log.Errorf("guest %s record flow log host %s: %v", guest.Name, hostId, err)
+	}
+}
+
+// prependFlowLogHost puts hostId in front of hostIds, keeping at most
+// FLOW_LOG_MAX_HOSTS hosts
+func prependFlowLogHost(hostIds []string, hostId string) []string {
+	ret := []string{hostId}
+	for _, id := range hostIds {
+		if id != hostId && len(ret) < api.FLOW_LOG_MAX_HOSTS {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
 func (guest *SGuest) SetHostIdWithBackup(userCred mcclient.TokenCredential, master, slave string) error {
+	prevHostId := guest.HostId
 	diff, err := db.Update(guest, func() error {
 		guest.HostId = master
 		guest.BackupHostId = slave
@@ -1225,6 +1263,7 @@ func (guest *SGuest) SetHostIdWithBackup(userCred mcclient.TokenCredential, mast
 		return err
 	}
 	db.OpsLog.LogEvent(guest, db.ACT_UPDATE, diff, userCred)
+	guest.addFlowLogHost(userCred, prevHostId)
 	return err
 }
 
@@ -1652,6 +1691,31 @@ func (manager *SGuestManager) validateCreateData(
 			setDaemon := true
 			input.IsDaemon = &setDaemon
 		}
+	} else if input.EnableTpm || input.SecureBoot {
+		return nil, httperrors.NewUnsupportOperationError("vTPM and secure boot are only supported by %s", api.HYPERVISOR_KVM)
+	} else if input.EnableBalloon {
+		return nil, httperrors.NewUnsupportOperationError("memory balloon is only supported by %s", api.HYPERVISOR_KVM)
+	} else if input.EnableVirtioMem {
+		return nil, httperrors.NewUnsupportOperationError("virtio-mem is only supported by %s", api.HYPERVISOR_KVM)
+	} else if len(input.Watchdog) > 0 || len(input.CrashAction) > 0 {
+		return nil, httperrors.NewUnsupportOperationError("watchdog and crash action are only supported by %s", api.HYPERVISOR_KVM)
+	}
+	if len(input.Watchdog) > 0 && !utils.IsInStringArray(input.Watchdog, api.GUEST_WATCHDOG_MODELS) {
+		return nil, httperrors.NewInputParameterError("invalid watchdog %s, choose from %s", input.Watchdog, api.GUEST_WATCHDOG_MODELS)
+	}
+	if len(input.CrashAction) > 0 && !utils.IsInStringArray(input.CrashAction, api.GUEST_CRASH_ACTIONS) {
+		return nil, httperrors.NewInputParameterError("invalid crash_action %s, choose from %s", input.CrashAction, api.GUEST_CRASH_ACTIONS)
+	}
+	if input.BalloonMinMem < 0 {
+		return nil, httperrors.NewInputParameterError("invalid balloon_min_mem %d", input.BalloonMinMem)
+	}
+	if input.SecureBoot {
+		if input.Bios != "UEFI" {
+			return nil, httperrors.NewInputParameterError("secure boot requires UEFI boot mode")
+		}
+		if input.Machine == api.VM_MACHINE_TYPE_PC {
+			return nil, httperrors.NewInputParameterError("secure boot requires machine type %s", api.VM_MACHINE_TYPE_Q35)
+		}
 	}
 
 	hypervisor = input.Hypervisor
@@ -1684,6 +1748,9 @@ func (manager *SGuestManager) validateCreateData(
 			input.VmemSize = vmemSize
 			input.VcpuCount = vcpuCount
 		}
+		if input.BalloonMinMem > input.VmemSize {
+			return nil, httperrors.NewInputParameterError("balloon_min_mem %d exceeds vmem_size %d", input.BalloonMinMem, input.VmemSize)
+		}
 
 		dataDiskDefs := []*api.DiskConfig{}
 		if sku != nil && sku.AttachedDiskCount > 0 {
@@ -1905,6 +1972,13 @@ func (manager *SGuestManager) validateCreateData(
 	if nvidiaVgpuCnt > 0 && gpuCnt > 0 {
 		return nil, httperrors.NewBadRequestError("Nvidia vgpu can't passthrough with other gpus")
 	}
+	if input.EnableBalloon {
+		for i := 0; i < len(input.IsolatedDevices); i++ {
+			if input.IsolatedDevices[i].DevType != api.USB_TYPE {
+				return nil, httperrors.NewBadRequestError("memory balloon can't work with passthrough device of type %s", input.IsolatedDevices[i].DevType)
+			}
+		}
+	}
 
 	keypairId := input.KeypairId
 	if len(keypairId) > 0 {
@@ -2317,6 +2391,30 @@ func (guest *SGuest) PostCreate(ctx context.Context, userCred mcclient.TokenCred
 	if jsonutils.QueryBoolean(data, api.VM_METADATA_ENABLE_MEMCLEAN, false) {
 		guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_MEMCLEAN, "true", userCred)
 	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_ENABLE_TPM, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_TPM, "true", userCred)
+	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_SECURE_BOOT, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_SECURE_BOOT, "true", userCred)
+	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_ENABLE_BALLOON, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_BALLOON, "true", userCred)
+		if minMem, _ := data.Int(api.VM_METADATA_BALLOON_MIN_MEM); minMem > 0 {
+			guest.SetMetadata(ctx, api.VM_METADATA_BALLOON_MIN_MEM, minMem, userCred)
+		}
+	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_ENABLE_VIRTIO_MEM, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_VIRTIO_MEM, "true", userCred)
+	}
+	if watchdog, _ := data.GetString(api.VM_METADATA_WATCHDOG); len(watchdog) > 0 {
+		guest.SetMetadata(ctx, api.VM_METADATA_WATCHDOG, watchdog, userCred)
+	}
+	if crashAction, _ := data.GetString(api.VM_METADATA_CRASH_ACTION); len(crashAction) > 0 {
+		guest.SetMetadata(ctx, api.VM_METADATA_CRASH_ACTION, crashAction, userCred)
+	}
+	if ibId, _ := data.GetString("instance_backup_id"); len(ibId) > 0 {
+		guest.inheritVtpmState(ctx, userCred, ibId)
+	}
 	if jsonutils.QueryBoolean(data, imageapi.IMAGE_DISABLE_USB_KBD, false) {
 		guest.SetMetadata(ctx, imageapi.IMAGE_DISABLE_USB_KBD, "true", userCred)
 	}
@@ -3559,7 +3657,11 @@ func (self *SGuest) attach2NetworkOnce(
 			log.Warningf("QuotaManager.CancelPendingUsage fail %s", err)
 		}
 	}
-	db.OpsLog.LogAttachEvent(ctx, self, network, userCred, guestnic.GetShortDesc(ctx))
+	notes := guestnic.GetShortDesc(ctx)
+	if gns, err := self.getHistoryNetworks(); err == nil {
+		addNetworksHistoryDiff(notes, gns, guestnic, true)
+	}
+	db.OpsLog.LogAttachEvent(ctx, self, network, userCred, notes)
 	return guestnic, nil
 }
 
@@ -3837,7 +3939,11 @@ func (self *SGuest) attach2Disk(ctx context.Context, disk *SDisk, userCred mccli
 	}
 	err = guestdisk.DoSave(ctx, driver, cache, mountpoint)
 	if err == nil {
-		db.OpsLog.LogAttachEvent(ctx, self, disk, userCred, nil)
+		notes := jsonutils.NewDict()
+		if gds, err := self.GetGuestDisks(); err == nil {
+			self.addDisksHistoryDiff(notes, gds, &guestdisk, true)
+		}
+		db.OpsLog.LogAttachEvent(ctx, self, disk, userCred, notes)
 	}
 	return err
 }
@@ -4853,6 +4959,16 @@ func (self *SGuest) GetDeployConfigOnHost(ctx context.Context, userCred mcclient
 
 	config.Add(jsonutils.NewString(deployAction), "action")
 
+	if deployAction == "create" {
+		vtpm, err := self.getVtpmRestoreInput(ctx, userCred)
+		if err != nil {
+			return nil, errors.Wrap(err, "getVtpmRestoreInput")
+		}
+		if vtpm != nil {
+			config.Add(jsonutils.Marshal(vtpm), "vtpm_backup")
+		}
+	}
+
 	onFinish := "shutdown"
 	if jsonutils.QueryBoolean(params, "auto_start", false) || jsonutils.QueryBoolean(params, "restart", false) {
 		onFinish = "none"
@@ -4953,6 +5069,25 @@ func (self *SGuest) getExtraOptions(ctx context.Context) jsonutils.JSONObject {
 	return self.GetMetadataJson(ctx, "extra_options", nil)
 }
 
+// validateBalloonSupported checks memory released by balloon can really
+// be reused by host, which is not the case of hugepage backed memory or
+// memory pinned by passthrough devices
+func (self *SGuest) validateBalloonSupported() error {
+	devs, err := self.GetIsolatedDevices()
+	if err != nil {
+		return errors.Wrap(err, "GetIsolatedDevices")
+	}
+	for i := range devs {
+		if devs[i].DevType != api.USB_TYPE {
+			return httperrors.NewBadRequestError("memory balloon can't work with passthrough device %s", devs[i].Name)
+		}
+	}
+	if host, _ := self.GetHost(); host != nil && host.IsHugePage() {
+		return httperrors.NewBadRequestError("memory balloon can't work on hugepage host %s", host.Name)
+	}
+	return nil
+}
+
 func (self *SGuest) GetIsolatedDevices() ([]SIsolatedDevice, error) {
 	q := IsolatedDeviceManager.Query().Equals("guest_id", self.Id)
 	devs := []SIsolatedDevice{}
@@ -5087,6 +5222,12 @@ func (self *SGuest) GetJsonDescAtHypervisor(ctx context.Context, host *SHost) *a
 		desc.Floppys = append(desc.Floppys, floppyDesc)
 	}
 
+	// virtio-fs shared filesystems
+	filesystems, _ := GuestFilesystemManager.GetGuestFilesystems(self.Id)
+	for i := range filesystems {
+		desc.Filesystems = append(desc.Filesystems, filesystems[i].GetJsonDesc())
+	}
+
 	// tenant
 	tc, _ := self.GetTenantCache(ctx)
 	if tc != nil {
@@ -6166,6 +6307,38 @@ func (guest *SGuest) GetDynamicConditionInput() *jsonutils.JSONDict {
 	return guest.ToSchedDesc().ToConditionInput()
 }
 
+// inheritVtpmState records the instance backup whose vTPM state is restored
+// by host on creation of guest
+func (guest *SGuest) inheritVtpmState(ctx context.Context, userCred mcclient.TokenCredential, instanceBackupId string) {
+	ibObj, err := InstanceBackupManager.FetchById(instanceBackupId)
+	if err != nil {
+		log.Errorf("fetch instance backup %s: %v", instanceBackupId, err)
+		return
+	}
+	if len(ibObj.(*SInstanceBackup).GetVtpmBackupId(ctx)) > 0 {
+		guest.SetMetadata(ctx, api.VM_METADATA_VTPM_RESTORE_FROM, instanceBackupId, userCred)
+	}
+}
+
+// getVtpmRestoreInput returns location of the vTPM state to be restored on
+// creation of guest, or nil if there is none
+func (guest *SGuest) getVtpmRestoreInput(ctx context.Context, userCred mcclient.TokenCredential) (*api.InstanceBackupVtpmInput, error) {
+	ibId := guest.GetMetadata(ctx, api.VM_METADATA_VTPM_RESTORE_FROM, nil)
+	if len(ibId) == 0 {
+		return nil, nil
+	}
+	ibObj, err := InstanceBackupManager.FetchById(ibId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch instance backup %s", ibId)
+	}
+	ib := ibObj.(*SInstanceBackup)
+	vtpmBackupId := ib.GetVtpmBackupId(ctx)
+	if len(vtpmBackupId) == 0 {
+		return nil, nil
+	}
+	return ib.GetVtpmBackupInput(ctx, userCred, vtpmBackupId)
+}
+
 func (self *SGuest) ToCreateInput(ctx context.Context, userCred mcclient.TokenCredential) *api.ServerCreateInput {
 	genInput := self.toCreateInput()
 	userInput, err := self.GetCreateParams(ctx, userCred)
@@ -6221,6 +6394,15 @@ func (self *SGuest) ToCreateInput(ctx context.Context, userCred mcclient.TokenCr
 		userInput.PublicIpChargeType = genInput.PublicIpChargeType
 	}
 	userInput.AutoRenew = genInput.AutoRenew
+	userInput.EnableTpm = self.GetMetadata(ctx, api.VM_METADATA_ENABLE_TPM, nil) == "true"
+	userInput.SecureBoot = self.GetMetadata(ctx, api.VM_METADATA_SECURE_BOOT, nil) == "true"
+	userInput.EnableBalloon = self.GetMetadata(ctx, api.VM_METADATA_ENABLE_BALLOON, nil) == "true"
+	if minMem, _ := strconv.Atoi(self.GetMetadata(ctx, api.VM_METADATA_BALLOON_MIN_MEM, nil)); minMem > 0 {
+		userInput.BalloonMinMem = minMem
+	}
+	userInput.EnableVirtioMem = self.GetMetadata(ctx, api.VM_METADATA_ENABLE_VIRTIO_MEM, nil) == "true"
+	userInput.Watchdog = self.GetMetadata(ctx, api.VM_METADATA_WATCHDOG, nil)
+	userInput.CrashAction = self.GetMetadata(ctx, api.VM_METADATA_CRASH_ACTION, nil)
 	// cloned server should belongs to the project creating it
 	userInput.ProjectId = userCred.GetProjectId()
 	userInput.ProjectDomainId = userCred.GetProjectDomainId()
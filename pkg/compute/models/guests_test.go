@@ -0,0 +1,47 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestPrependFlowLogHost(t *testing.T) {
+	cases := []struct {
+		hostIds []string
+		hostId  string
+		want    []string
+	}{
+		{nil, "h1", []string{"h1"}},
+		{[]string{"h1", "h2"}, "h3", []string{"h3", "h1", "h2"}},
+		{[]string{"h1", "h2", "h3"}, "h2", []string{"h2", "h1", "h3"}},
+	}
+	for _, c := range cases {
+		if got := prependFlowLogHost(c.hostIds, c.hostId); fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("prepend %s to %v: got %v, want %v", c.hostId, c.hostIds, got, c.want)
+		}
+	}
+
+	hostIds := []string{}
+	for i := 0; i < api.FLOW_LOG_MAX_HOSTS+2; i++ {
+		hostIds = prependFlowLogHost(hostIds, fmt.Sprintf("h%d", i))
+	}
+	if len(hostIds) != api.FLOW_LOG_MAX_HOSTS || hostIds[0] != fmt.Sprintf("h%d", api.FLOW_LOG_MAX_HOSTS+1) {
+		t.Errorf("got %v", hostIds)
+	}
+}
@@ -146,6 +146,8 @@ type SHost struct {
 	MemReserved int `nullable:"true" default:"0" list:"domain" update:"domain" create:"domain_optional"`
 	// 内存超分比
 	MemCmtbound float32 `nullable:"true" default:"1" list:"domain" update:"domain" create:"domain_optional"`
+	// 虚拟机气球可回收的内存大小,单位Mb, 由宿主机上报, 宿主机内存紧张时为0, 调度时作为内存超分余量
+	MemBalloonHeadroom int `nullable:"true" default:"0" list:"domain"`
 	// 页大小
 	PageSizeKB         int  `nullable:"false" default:"4" list:"domain" update:"domain" create:"domain_optional"`
 	EnableNumaAllocate bool `nullable:"true" default:"false" list:"domain" update:"domain" create:"domain_optional"`
@@ -4209,6 +4211,76 @@ func (hh *SHost) PerformOnline(ctx context.Context, userCred mcclient.TokenCrede
 	return nil, nil
 }
 
+func hardwareHealthLevel(health string) int {
+	switch health {
+	case api.HOST_HARDWARE_HEALTH_CRITICAL:
+		return 2
+	case api.HOST_HARDWARE_HEALTH_WARNING:
+		return 1
+	}
+	return 0
+}
+
+// mergeHardwareInventory keeps the previously synced components of the types
+// failed to collect, so that a partial inventory neither drops them nor
+// clears their bad health
+func mergeHardwareInventory(prev []api.HostHardwareComponent, input api.HostSyncHardwareInventoryInput) api.HostSyncHardwareInventoryInput {
+	for _, c := range prev {
+		if !utils.IsInStringArray(c.Type, input.FailedTypes) {
+			continue
+		}
+		input.Components = append(input.Components, c)
+		// firmware health is not accounted in the overall health
+		if c.Type != api.HOST_HARDWARE_FIRMWARE && hardwareHealthLevel(c.Health) > hardwareHealthLevel(input.Health) {
+			input.Health = c.Health
+		}
+	}
+	return input
+}
+
+func (hh *SHost) PerformSyncHardwareInventory(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostSyncHardwareInventoryInput) (jsonutils.JSONObject, error) {
+	if !utils.IsInStringArray(input.Health, api.HOST_HARDWARE_HEALTHS) {
+		return nil, httperrors.NewInputParameterError("invalid health %q", input.Health)
+	}
+	prevHealth := hh.GetMetadata(ctx, api.HOSTMETA_HARDWARE_HEALTH, nil)
+	if len(input.FailedTypes) > 0 {
+		prevComponents := make([]api.HostHardwareComponent, 0)
+		if prevJson := hh.GetMetadataJson(ctx, api.HOSTMETA_HARDWARE_INVENTORY, nil); prevJson != nil {
+			prevJson.Unmarshal(&prevComponents)
+		}
+		input = mergeHardwareInventory(prevComponents, input)
+	}
+	meta := map[string]interface{}{
+		api.HOSTMETA_HARDWARE_INVENTORY: input.Components,
+		api.HOSTMETA_HARDWARE_HEALTH:    input.Health,
+	}
+	err := hh.SetAllMetadata(ctx, meta, userCred)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetAllMetadata")
+	}
+	if prevHealth == input.Health || (len(prevHealth) == 0 && input.Health == api.HOST_HARDWARE_HEALTH_OK) {
+		return nil, nil
+	}
+	reasons := make([]string, 0)
+	for _, c := range input.Components {
+		if c.Health == api.HOST_HARDWARE_HEALTH_WARNING || c.Health == api.HOST_HARDWARE_HEALTH_CRITICAL {
+			reasons = append(reasons, fmt.Sprintf("%s %s: %s", c.Type, c.Name, c.Health))
+		}
+	}
+	reason := fmt.Sprintf("hardware health %s => %s", prevHealth, input.Health)
+	if len(reasons) > 0 {
+		reason = fmt.Sprintf("%s (%s)", reason, strings.Join(reasons, "; "))
+	}
+	db.OpsLog.LogEvent(hh, db.ACT_HARDWARE_HEALTH, reason, userCred)
+	logclient.AddActionLogWithContext(ctx, hh, logclient.ACT_HARDWARE_HEALTH, reason, userCred, input.Health == api.HOST_HARDWARE_HEALTH_OK)
+	if input.Health != api.HOST_HARDWARE_HEALTH_OK {
+		ndata := jsonutils.Marshal(hh).(*jsonutils.JSONDict)
+		ndata.Add(jsonutils.NewString(reason), "reason")
+		notifyclient.SystemExceptionNotify(ctx, napi.ActionSystemException, HostManager.Keyword(), ndata)
+	}
+	return nil, nil
+}
+
 func (hh *SHost) PerformRestartHostAgent(
 	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject,
 ) (jsonutils.JSONObject, error) {
@@ -4287,6 +4359,15 @@ func (hh *SHost) PerformPing(ctx context.Context, userCred mcclient.TokenCredent
 		}
 		hh.SetMetadata(ctx, "root_partition_used_capacity_mb", input.RootPartitionUsedCapacityMb, userCred)
 		hh.SetMetadata(ctx, "memory_used_mb", input.MemoryUsedMb, userCred)
+		if hh.MemBalloonHeadroom != input.MemoryBalloonHeadroomMb {
+			_, err := db.Update(hh, func() error {
+				hh.MemBalloonHeadroom = input.MemoryBalloonHeadroomMb
+				return nil
+			})
+			if err != nil {
+				log.Errorf("update host %s mem_balloon_headroom error %s", hh.Name, err)
+			}
+		}
 	}
 	if hh.HostStatus != api.HOST_ONLINE {
 		hh.PerformOnline(ctx, userCred, query, nil)
@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestMergeHardwareInventory(t *testing.T) {
+	prev := []api.HostHardwareComponent{
+		{Type: api.HOST_HARDWARE_DIMM, Id: "DIMM1", Health: api.HOST_HARDWARE_HEALTH_OK},
+		{Type: api.HOST_HARDWARE_DRIVE, Id: "Disk.0", Health: api.HOST_HARDWARE_HEALTH_CRITICAL},
+		{Type: api.HOST_HARDWARE_FIRMWARE, Id: "BIOS", Health: api.HOST_HARDWARE_HEALTH_WARNING},
+	}
+	for _, c := range []struct {
+		name       string
+		input      api.HostSyncHardwareInventoryInput
+		wantIds    []string
+		wantHealth string
+	}{
+		{
+			name: "keep failed drives and their health",
+			input: api.HostSyncHardwareInventoryInput{
+				Health:      api.HOST_HARDWARE_HEALTH_OK,
+				Components:  []api.HostHardwareComponent{{Type: api.HOST_HARDWARE_DIMM, Id: "DIMM2", Health: api.HOST_HARDWARE_HEALTH_OK}},
+				FailedTypes: []string{api.HOST_HARDWARE_DRIVE},
+			},
+			wantIds:    []string{"DIMM2", "Disk.0"},
+			wantHealth: api.HOST_HARDWARE_HEALTH_CRITICAL,
+		},
+		{
+			name: "firmware health is not accounted",
+			input: api.HostSyncHardwareInventoryInput{
+				Health:      api.HOST_HARDWARE_HEALTH_OK,
+				Components:  []api.HostHardwareComponent{},
+				FailedTypes: []string{api.HOST_HARDWARE_FIRMWARE},
+			},
+			wantIds:    []string{"BIOS"},
+			wantHealth: api.HOST_HARDWARE_HEALTH_OK,
+		},
+		{
+			name: "no previous components of failed type",
+			input: api.HostSyncHardwareInventoryInput{
+				Health:      api.HOST_HARDWARE_HEALTH_WARNING,
+				Components:  []api.HostHardwareComponent{},
+				FailedTypes: []string{api.HOST_HARDWARE_POWER_SUPPLY},
+			},
+			wantIds:    []string{},
+			wantHealth: api.HOST_HARDWARE_HEALTH_WARNING,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := mergeHardwareInventory(prev, c.input)
+			if got.Health != c.wantHealth {
+				t.Errorf("health got %s want %s", got.Health, c.wantHealth)
+			}
+			if len(got.Components) != len(c.wantIds) {
+				t.Fatalf("components got %d want %d", len(got.Components), len(c.wantIds))
+			}
+			for i, id := range c.wantIds {
+				if got.Components[i].Id != id {
+					t.Errorf("component %d got %s want %s", i, got.Components[i].Id, id)
+				}
+			}
+		})
+	}
+}
@@ -201,6 +201,38 @@ func (self *SInstanceBackup) GetBackupStorage() (*SBackupStorage, error) {
 	return ibs.(*SBackupStorage), nil
 }
 
+// GetVtpmBackupId returns name of the vTPM state of guest saved in backup
+// storage of the instance backup, or empty if there is none
+func (self *SInstanceBackup) GetVtpmBackupId(ctx context.Context) string {
+	return self.GetMetadata(ctx, api.VM_METADATA_VTPM_BACKUP, nil)
+}
+
+// GetVtpmBackupInput returns the input for host to access vTPM state named
+// vtpmBackupId in backup storage of the instance backup
+func (self *SInstanceBackup) GetVtpmBackupInput(ctx context.Context, userCred mcclient.TokenCredential, vtpmBackupId string) (*api.InstanceBackupVtpmInput, error) {
+	bs, err := self.GetBackupStorage()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetBackupStorage")
+	}
+	accessInfo, err := bs.GetAccessInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAccessInfo")
+	}
+	input := &api.InstanceBackupVtpmInput{
+		VtpmBackupId:            vtpmBackupId,
+		BackupStorageId:         bs.Id,
+		BackupStorageAccessInfo: jsonutils.Marshal(accessInfo).(*jsonutils.JSONDict),
+	}
+	if self.IsEncrypted() {
+		encInfo, err := self.GetEncryptInfo(ctx, userCred)
+		if err != nil {
+			return nil, errors.Wrap(err, "GetEncryptInfo")
+		}
+		input.EncryptInfo = &encInfo
+	}
+	return input, nil
+}
+
 func (self *SInstanceBackup) getMoreDetails(userCred mcclient.TokenCredential, out api.InstanceBackupDetails) api.InstanceBackupDetails {
 	guest := GuestManager.FetchGuestById(self.GuestId)
 	if guest != nil {
@@ -424,6 +456,12 @@ func (self *SInstanceBackup) ToInstanceCreateInput(sourceInput *api.ServerCreate
 	if sourceInput.IsolatedDevices == nil {
 		sourceInput.IsolatedDevices = createInput.IsolatedDevices
 	}
+	if !sourceInput.EnableTpm {
+		sourceInput.EnableTpm = createInput.EnableTpm
+	}
+	if !sourceInput.SecureBoot {
+		sourceInput.SecureBoot = createInput.SecureBoot
+	}
 	if self.IsEncrypted() {
 		if sourceInput.EncryptKeyId != nil && *sourceInput.EncryptKeyId != self.EncryptKeyId {
 			return nil, errors.Wrap(httperrors.ErrConflict, "encrypt_key_id conflict with instance_backup's encrypt_key_id")
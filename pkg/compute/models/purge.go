@@ -680,6 +680,7 @@ func (self *SZone) purgeHosts(ctx context.Context, managerId string) error {
 	guestvfd := GuestFloppyManager.Query("row_id").In("id", guests.SubQuery())
 	guestgroups := GroupguestManager.Query("row_id").In("guest_id", guests.SubQuery())
 	guestsecgroups := GuestsecgroupManager.Query("row_id").In("guest_id", guests.SubQuery())
+	guestfilesystems := GuestFilesystemManager.Query("id").In("guest_id", guests.SubQuery())
 	instancesnapshots := InstanceSnapshotManager.Query("id").In("guest_id", guests.SubQuery())
 	instancebackups := InstanceBackupManager.Query("id").In("guest_id", guests.SubQuery())
 	publicIps := ElasticipManager.Query("id").Equals("mode", api.EIP_MODE_INSTANCE_PUBLICIP).
@@ -710,6 +711,7 @@ func (self *SZone) purgeHosts(ctx context.Context, managerId string) error {
 		{manager: NetTapFlowManager, key: "id", q: tapFlows},
 		{manager: NetTapServiceManager, key: "id", q: tapService},
 		{manager: ElasticipManager, key: "id", q: publicIps},
+		{manager: GuestFilesystemManager, key: "id", q: guestfilesystems},
 		{manager: GuestsecgroupManager, key: "row_id", q: guestsecgroups},
 		{manager: GroupguestManager, key: "row_id", q: guestgroups},
 		{manager: GuestcdromManager, key: "row_id", q: guestcdroms},
@@ -744,6 +746,7 @@ func (self *SHost) purge(ctx context.Context, userCred mcclient.TokenCredential)
 	guestvfd := GuestFloppyManager.Query("row_id").In("id", guests.SubQuery())
 	guestgroups := GroupguestManager.Query("row_id").In("guest_id", guests.SubQuery())
 	guestsecgroups := GuestsecgroupManager.Query("row_id").In("guest_id", guests.SubQuery())
+	guestfilesystems := GuestFilesystemManager.Query("id").In("guest_id", guests.SubQuery())
 	instancesnapshots := InstanceSnapshotManager.Query("id").In("guest_id", guests.SubQuery())
 	instancebackups := InstanceBackupManager.Query("id").In("guest_id", guests.SubQuery())
 	publicIps := ElasticipManager.Query("id").Equals("mode", api.EIP_MODE_INSTANCE_PUBLICIP).
@@ -766,6 +769,7 @@ func (self *SHost) purge(ctx context.Context, userCred mcclient.TokenCredential)
 		{manager: NetTapFlowManager, key: "id", q: tapFlows},
 		{manager: NetTapServiceManager, key: "id", q: tapService},
 		{manager: ElasticipManager, key: "id", q: publicIps},
+		{manager: GuestFilesystemManager, key: "id", q: guestfilesystems},
 		{manager: GuestsecgroupManager, key: "row_id", q: guestsecgroups},
 		{manager: GroupguestManager, key: "row_id", q: guestgroups},
 		{manager: GuestcdromManager, key: "row_id", q: guestcdroms},
@@ -798,6 +802,7 @@ func (self *SGuest) purge(ctx context.Context, userCred mcclient.TokenCredential
 	guestvfd := GuestFloppyManager.Query("row_id").Equals("id", self.Id)
 	guestgroups := GroupguestManager.Query("row_id").Equals("guest_id", self.Id)
 	guestsecgroups := GuestsecgroupManager.Query("row_id").Equals("guest_id", self.Id)
+	guestfilesystems := GuestFilesystemManager.Query("id").Equals("guest_id", self.Id)
 	// instancesnapshots := InstanceSnapshotManager.Query("id").Equals("guest_id", self.Id)
 	// instancebackups := InstanceBackupManager.Query("id").Equals("guest_id", self.Id)
 	publicIps := ElasticipManager.Query("id").Equals("mode", api.EIP_MODE_INSTANCE_PUBLICIP).
@@ -813,6 +818,7 @@ func (self *SGuest) purge(ctx context.Context, userCred mcclient.TokenCredential
 		{manager: NetTapFlowManager, key: "id", q: tapFlows},
 		{manager: NetTapServiceManager, key: "id", q: tapService},
 		{manager: ElasticipManager, key: "id", q: publicIps},
+		{manager: GuestFilesystemManager, key: "id", q: guestfilesystems},
 		{manager: GuestsecgroupManager, key: "row_id", q: guestsecgroups},
 		{manager: GroupguestManager, key: "row_id", q: guestgroups},
 		{manager: GuestcdromManager, key: "row_id", q: guestcdroms},
@@ -0,0 +1,582 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	monapi "yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/monitor"
+)
+
+const (
+	// the metric within this ratio of the target value does not cause scaling
+	targetTrackingTolerance = 0.1
+
+	scalingMetricDefaultDatabase = "telegraf"
+
+	// tag of the series of guests in scaling group, metric is always filtered by it
+	scalingGroupMetricTag = "vm_scaling_group_id"
+)
+
+type SScalingMetricManager struct {
+	db.SStandaloneResourceBaseManager
+}
+
+// SScalingMetric is the trigger of target tracking and custom metric scaling
+// policies, it is evaluated by the autoscaling controller periodically
+type SScalingMetric struct {
+	db.SStandaloneResourceBase
+
+	SScalingPolicyBase
+
+	Database    string `width:"64" charset:"ascii"`
+	Indicator   string `width:"32" charset:"ascii"`
+	Measurement string `width:"64" charset:"utf8"`
+	Field       string `width:"64" charset:"utf8"`
+	// Tags filter the series of the scaling group further
+	Tags jsonutils.JSONObject `nullable:"true"`
+
+	// Wrapper instruct how to calculate collective data based on individual data
+	Wrapper string `width:"16" charset:"ascii"`
+	// Window in seconds the metric is aggregated over
+	Window int `nullable:"false" default:"300"`
+
+	// Operator and Value are the threshold of custom metric trigger
+	Operator string `width:"2" charset:"ascii"`
+	Value    float64
+
+	// TargetValue is the value target tracking trigger keeps the metric at
+	TargetValue float64
+
+	PredictiveMode string `width:"32" charset:"ascii" default:"off"`
+	// Number of previous weeks the forecast is based on
+	PredictiveWeeks int `nullable:"false" default:"4"`
+	// Minutes the forecast looks ahead
+	PredictiveLeadTime int `nullable:"false" default:"60"`
+
+	// DryRun only records the evaluations without scaling
+	DryRun bool `nullable:"false" default:"false"`
+
+	LastEvaluation  jsonutils.JSONObject `nullable:"true"`
+	LastEvaluatedAt time.Time
+}
+
+var ScalingMetricManager *SScalingMetricManager
+
+func init() {
+	ScalingMetricManager = &SScalingMetricManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SScalingMetric{},
+			"scalingmetrics_tbl",
+			"scalingmetric",
+			"scalingmetrics",
+		),
+	}
+	ScalingMetricManager.SetVirtualObject(ScalingMetricManager)
+}
+
+func newScalingMetric(spId string, input api.ScalingMetricCreateInput) *SScalingMetric {
+	sm := &SScalingMetric{
+		SScalingPolicyBase: SScalingPolicyBase{spId},
+		Database:           input.Database,
+		Indicator:          input.Indicator,
+		Measurement:        input.Measurement,
+		Field:              input.Field,
+		Wrapper:            input.Wrapper,
+		Window:             input.Window,
+		Operator:           input.Operator,
+		Value:              input.Value,
+		TargetValue:        input.TargetValue,
+		PredictiveMode:     input.PredictiveMode,
+		PredictiveWeeks:    input.PredictiveWeeks,
+		PredictiveLeadTime: input.PredictiveLeadTime,
+		DryRun:             input.DryRun,
+	}
+	if len(input.Tags) > 0 {
+		sm.Tags = jsonutils.Marshal(input.Tags)
+	}
+	return sm
+}
+
+func (sm *SScalingMetric) ValidateCreateData(input api.ScalingPolicyCreateInput) (api.ScalingPolicyCreateInput, error) {
+	metric := &input.Metric
+	if len(metric.Database) == 0 {
+		metric.Database = scalingMetricDefaultDatabase
+	}
+	if len(metric.Indicator) > 0 {
+		field, ok := indicatorMap[metric.Indicator]
+		if !ok {
+			return input, httperrors.NewInputParameterError("unkown indicator in metric %s", metric.Indicator)
+		}
+		metric.Measurement, metric.Field = field.Table, field.Field
+	}
+	if len(metric.Measurement) == 0 || len(metric.Field) == 0 {
+		return input, httperrors.NewInputParameterError("indicator or measurement and field of metric is required")
+	}
+	if _, ok := metric.Tags[scalingGroupMetricTag]; ok {
+		return input, httperrors.NewInputParameterError("tag %s of metric is set by scaling group", scalingGroupMetricTag)
+	}
+	if len(metric.Wrapper) == 0 {
+		metric.Wrapper = api.WRAPPER_AVER
+	}
+	if !utils.IsInStringArray(metric.Wrapper, []string{api.WRAPPER_MIN, api.WRAPPER_MAX, api.WRAPPER_AVER}) {
+		return input, httperrors.NewInputParameterError("unkown wrapper in metric %s", metric.Wrapper)
+	}
+	if metric.Window == 0 {
+		metric.Window = 300
+	}
+	if metric.Window < 60 {
+		return input, httperrors.NewInputParameterError("the min value of window in metric is 60")
+	}
+	switch input.TriggerType {
+	case api.TRIGGER_METRIC:
+		if len(metric.Operator) == 0 {
+			metric.Operator = api.OPERATOR_GT
+		}
+		if !utils.IsInStringArray(metric.Operator, []string{api.OPERATOR_GT, api.OPERATOR_LT}) {
+			return input, httperrors.NewInputParameterError("unkown operator in metric %s", metric.Operator)
+		}
+		metric.PredictiveMode = api.PREDICTIVE_MODE_OFF
+	case api.TRIGGER_TARGET_TRACKING:
+		metric.Operator = ""
+		if metric.TargetValue <= 0 {
+			return input, httperrors.NewInputParameterError("target_value of target tracking should be positive")
+		}
+		if len(metric.PredictiveMode) == 0 {
+			metric.PredictiveMode = api.PREDICTIVE_MODE_OFF
+		}
+		if !utils.IsInStringArray(metric.PredictiveMode, []string{api.PREDICTIVE_MODE_OFF, api.PREDICTIVE_MODE_FORECAST_ONLY,
+			api.PREDICTIVE_MODE_FORECAST_AND_SCALE}) {
+			return input, httperrors.NewInputParameterError("unkown predictive mode %s", metric.PredictiveMode)
+		}
+		if metric.PredictiveWeeks == 0 {
+			metric.PredictiveWeeks = 4
+		}
+		if metric.PredictiveWeeks < 1 || metric.PredictiveWeeks > 8 {
+			return input, httperrors.NewInputParameterError("predictive_weeks should be between 1 and 8")
+		}
+		if metric.PredictiveLeadTime == 0 {
+			metric.PredictiveLeadTime = 60
+		}
+		if metric.PredictiveLeadTime < 0 || metric.PredictiveLeadTime > 24*60 {
+			return input, httperrors.NewInputParameterError("predictive_lead_time should be between 0 and 1440")
+		}
+	}
+	return input, nil
+}
+
+func (sm *SScalingMetric) Register(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := ScalingMetricManager.TableSpec().Insert(ctx, sm)
+	if err != nil {
+		return errors.Wrap(err, "STableSpec.Insert")
+	}
+	return nil
+}
+
+func (sm *SScalingMetric) UnRegister(ctx context.Context, userCred mcclient.TokenCredential) error {
+	err := sm.Delete(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "SScalingMetric.Delete")
+	}
+	return nil
+}
+
+func (sm *SScalingMetric) TriggerId() string {
+	return sm.GetId()
+}
+
+// IsTrigger is always false since metric triggers are evaluated by the
+// autoscaling controller rather than triggered by requests
+func (sm *SScalingMetric) IsTrigger() bool {
+	return false
+}
+
+func (sm *SScalingMetric) TriggerDescription() string {
+	name := sm.ScalingPolicyId
+	sp, _ := sm.ScalingPolicy()
+	if sp != nil {
+		name = sp.Name
+	}
+	if len(sm.Operator) == 0 {
+		return fmt.Sprintf(`Target tracking task(keep the %s of %s.%s at %v) execute scaling policy "%s"`,
+			descs[sm.Wrapper], sm.Measurement, sm.Field, sm.TargetValue, name)
+	}
+	return fmt.Sprintf(`Metric task(the %s of %s.%s is %s than %v) execute scaling policy "%s"`,
+		descs[sm.Wrapper], sm.Measurement, sm.Field, descs[sm.Operator], sm.Value, name)
+}
+
+func (sm *SScalingMetric) getTags() map[string]string {
+	tags := map[string]string{}
+	if sm.Tags != nil {
+		sm.Tags.Unmarshal(&tags)
+	}
+	return tags
+}
+
+func (sm *SScalingMetric) getLastEvaluation() *api.ScalingPolicyEvaluation {
+	if sm.LastEvaluation == nil {
+		return nil
+	}
+	ev := &api.ScalingPolicyEvaluation{}
+	if err := sm.LastEvaluation.Unmarshal(ev); err != nil {
+		return nil
+	}
+	return ev
+}
+
+func (sm *SScalingMetric) MetricDetails() api.ScalingMetricDetails {
+	return api.ScalingMetricDetails{
+		Database:           sm.Database,
+		Indicator:          sm.Indicator,
+		Measurement:        sm.Measurement,
+		Field:              sm.Field,
+		Tags:               sm.getTags(),
+		Wrapper:            sm.Wrapper,
+		Window:             sm.Window,
+		Operator:           sm.Operator,
+		Value:              sm.Value,
+		TargetValue:        sm.TargetValue,
+		PredictiveMode:     sm.PredictiveMode,
+		PredictiveWeeks:    sm.PredictiveWeeks,
+		PredictiveLeadTime: sm.PredictiveLeadTime,
+		DryRun:             sm.DryRun,
+		LastEvaluation:     sm.getLastEvaluation(),
+	}
+}
+
+func (sm *SScalingMetric) SaveEvaluation(ev api.ScalingPolicyEvaluation) error {
+	_, err := db.Update(sm, func() error {
+		sm.LastEvaluation = jsonutils.Marshal(ev)
+		sm.LastEvaluatedAt = ev.EvaluatedAt
+		return nil
+	})
+	return err
+}
+
+// queryTags returns the tag conditions of metric query in order, the series
+// are always limited to the scaling group and further filtered by custom tags
+func (sm *SScalingMetric) queryTags(sgId string) [][2]string {
+	ret := [][2]string{{scalingGroupMetricTag, sgId}}
+	tags := sm.getTags()
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		if k != scalingGroupMetricTag {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ret = append(ret, [2]string{k, tags[k]})
+	}
+	return ret
+}
+
+func (sm *SScalingMetric) newQuery(sg *SScalingGroup) *monitor.MetricQueryInput {
+	input := monitor.NewMetricQueryInputWithDB(sm.Database, sm.Measurement).
+		Scope("system").
+		SkipCheckSeries(true)
+	where := input.Where().AND()
+	for _, tag := range sm.queryTags(sg.Id) {
+		where.Equal(tag[0], tag[1])
+	}
+	return input
+}
+
+// currentValue queries the aggregated metric over the last window, ok is
+// false if there is no data
+func (sm *SScalingMetric) currentValue(s *mcclient.ClientSession, sg *SScalingGroup, now time.Time) (float64, bool, error) {
+	window := time.Duration(sm.Window) * time.Second
+	input := sm.newQuery(sg).
+		From(now.Add(-window)).
+		To(now).
+		Interval(fmt.Sprintf("%ds", sm.Window))
+	sel := input.Selects().Select(sm.Field)
+	switch sm.Wrapper {
+	case api.WRAPPER_MAX:
+		sel.MAX()
+	case api.WRAPPER_MIN:
+		sel.MIN()
+	default:
+		sel.MEAN()
+	}
+	ret, err := monitor.UnifiedMonitorManager.PerformQuery(s, input.ToQueryData())
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "query %s.%s", sm.Measurement, sm.Field)
+	}
+	series, err := parseMetricSeries(ret, "")
+	if err != nil {
+		return 0, false, err
+	}
+	var (
+		latest time.Time
+		value  float64
+		ok     bool
+	)
+	for _, points := range series {
+		for _, p := range points {
+			if !ok || p.Time.After(latest) {
+				latest, value, ok = p.Time, p.Value, true
+			}
+		}
+	}
+	return value, ok, nil
+}
+
+// loadHistory queries the hourly total load of the scaling group, which is
+// the sum of the hourly mean of every instance
+func (sm *SScalingMetric) loadHistory(s *mcclient.ClientSession, sg *SScalingGroup, now time.Time) (map[int64]float64, error) {
+	tagId := monapi.MEASUREMENT_TAG_ID[monapi.METRIC_RES_TYPE_GUEST]
+	weeks := time.Duration(sm.PredictiveWeeks) * 7 * 24 * time.Hour
+	input := sm.newQuery(sg).
+		From(now.Add(-weeks - time.Hour)).
+		To(now).
+		Interval("1h")
+	input.Selects().Select(sm.Field).MEAN()
+	input.GroupBy().TAG(tagId)
+	ret, err := monitor.UnifiedMonitorManager.PerformQuery(s, input.ToQueryData())
+	if err != nil {
+		return nil, errors.Wrapf(err, "query history of %s.%s", sm.Measurement, sm.Field)
+	}
+	series, err := parseMetricSeries(ret, tagId)
+	if err != nil {
+		return nil, err
+	}
+	return hourlyLoad(series), nil
+}
+
+type sMetricPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// parseMetricSeries parses the result of unified monitor query, the series
+// are keyed by the value of tag
+func parseMetricSeries(ret jsonutils.JSONObject, tag string) (map[string][]sMetricPoint, error) {
+	series, err := ret.GetArray("series")
+	if err != nil {
+		if errors.Cause(err) == jsonutils.ErrJsonDictKeyNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "get series")
+	}
+	out := make(map[string][]sMetricPoint)
+	for i, s := range series {
+		key := fmt.Sprintf("%d", i)
+		if len(tag) > 0 {
+			if val, _ := s.GetString("tags", tag); len(val) > 0 {
+				key = val
+			}
+		}
+		points, _ := s.GetArray("points")
+		for _, point := range points {
+			vals, _ := point.GetArray()
+			if len(vals) < 2 {
+				continue
+			}
+			val, err := vals[0].Float()
+			if err != nil {
+				// null value of a time bucket without data
+				continue
+			}
+			ts, err := vals[len(vals)-1].Float()
+			if err != nil {
+				continue
+			}
+			out[key] = append(out[key], sMetricPoint{
+				Time:  time.UnixMilli(int64(ts)),
+				Value: val,
+			})
+		}
+	}
+	return out, nil
+}
+
+// hourlyLoad sums the points of all series in the same hour
+func hourlyLoad(series map[string][]sMetricPoint) map[int64]float64 {
+	load := make(map[int64]float64)
+	for _, points := range series {
+		for _, p := range points {
+			load[p.Time.Truncate(time.Hour).Unix()] += p.Value
+		}
+	}
+	return load
+}
+
+// seasonalLoad averages the load at the same weekday and hour of at in the
+// previous weeks, n is the number of weeks having data
+func seasonalLoad(load map[int64]float64, at time.Time, weeks int) (float64, int) {
+	var (
+		sum float64
+		n   int
+	)
+	for k := 1; k <= weeks; k++ {
+		t := at.Add(-time.Duration(k) * 7 * 24 * time.Hour).Truncate(time.Hour)
+		if v, ok := load[t.Unix()]; ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / float64(n), n
+}
+
+// targetTrackingNumber returns the instance number which brings the metric
+// back to target, supposing the load is spread evenly over instances
+func targetTrackingNumber(current int, value, target float64) int {
+	if current <= 0 || target <= 0 {
+		return current
+	}
+	ratio := value / target
+	if math.Abs(ratio-1) <= targetTrackingTolerance {
+		return current
+	}
+	return int(math.Ceil(float64(current) * ratio))
+}
+
+func clampInstanceNumber(num, min, max int) int {
+	if num < min {
+		return min
+	}
+	if num > max {
+		return max
+	}
+	return num
+}
+
+// Evaluate computes what the policy would do to the scaling group now, it
+// changes nothing
+func (sm *SScalingMetric) Evaluate(s *mcclient.ClientSession, sp *SScalingPolicy, sg *SScalingGroup, now time.Time) (api.ScalingPolicyEvaluation, error) {
+	ev := api.ScalingPolicyEvaluation{
+		EvaluatedAt:    now,
+		InstanceNumber: sg.DesireInstanceNumber,
+		ReactiveNumber: sg.DesireInstanceNumber,
+		DesireNumber:   sg.DesireInstanceNumber,
+		Decision:       api.SCALING_DECISION_NONE,
+		DryRun:         sm.DryRun,
+	}
+	value, ok, err := sm.currentValue(s, sg, now)
+	if err != nil {
+		return ev, err
+	}
+	if !ok {
+		ev.Reason = fmt.Sprintf("no data of %s.%s in the last %ds", sm.Measurement, sm.Field, sm.Window)
+		return ev, nil
+	}
+	ev.MetricValue = value
+	desire := ev.InstanceNumber
+	switch sp.TriggerType {
+	case api.TRIGGER_METRIC:
+		hit := (sm.Operator == api.OPERATOR_GT && value > sm.Value) || (sm.Operator == api.OPERATOR_LT && value < sm.Value)
+		if hit {
+			ev.ReactiveNumber = sp.Exec(ev.InstanceNumber)
+			ev.Reason = fmt.Sprintf("%s %v is %s than %v", sm.Field, value, descs[sm.Operator], sm.Value)
+		} else {
+			ev.Reason = fmt.Sprintf("%s %v is not %s than %v", sm.Field, value, descs[sm.Operator], sm.Value)
+		}
+		desire = ev.ReactiveNumber
+	case api.TRIGGER_TARGET_TRACKING:
+		ev.ReactiveNumber = targetTrackingNumber(ev.InstanceNumber, value, sm.TargetValue)
+		ev.Reason = fmt.Sprintf("%s %v against target %v", sm.Field, value, sm.TargetValue)
+		desire = ev.ReactiveNumber
+		if sm.PredictiveMode != api.PREDICTIVE_MODE_OFF {
+			load, err := sm.loadHistory(s, sg, now)
+			if err != nil {
+				return ev, err
+			}
+			at := now.Add(time.Duration(sm.PredictiveLeadTime) * time.Minute)
+			predicted, weeks := seasonalLoad(load, at, sm.PredictiveWeeks)
+			if weeks > 0 {
+				ev.PredictedLoad = predicted
+				ev.PredictiveNumber = int(math.Ceil(predicted / sm.TargetValue))
+				ev.Reason += fmt.Sprintf(", predicted load %v at %s from %d weeks", predicted, at.Format(time.RFC3339), weeks)
+				// forecast only pre-scales out, scaling in is left to the metric
+				if sm.PredictiveMode == api.PREDICTIVE_MODE_FORECAST_AND_SCALE && ev.PredictiveNumber > desire {
+					desire = ev.PredictiveNumber
+				}
+			}
+		}
+	}
+	ev.DesireNumber = clampInstanceNumber(desire, sg.MinInstanceNumber, sg.MaxInstanceNumber)
+	switch {
+	case ev.DesireNumber > ev.InstanceNumber:
+		ev.Decision = api.SCALING_DECISION_SCALE_OUT
+	case ev.DesireNumber < ev.InstanceNumber:
+		ev.Decision = api.SCALING_DECISION_SCALE_IN
+	}
+	return ev, nil
+}
+
+// SScalingTarget sets the desire instance number of scaling group to Number
+type SScalingTarget struct {
+	Number int
+}
+
+func (st SScalingTarget) Exec(from int) int {
+	return st.Number
+}
+
+func (st SScalingTarget) CheckCoolTime() bool {
+	return true
+}
+
+func (sp *SScalingPolicy) GetDetailsDryRun(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (api.ScalingPolicyEvaluation, error) {
+	if !utils.IsInStringArray(sp.TriggerType, []string{api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC}) {
+		return api.ScalingPolicyEvaluation{}, httperrors.NewUnsupportOperationError("dry run is only supported by %s and %s policies",
+			api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC)
+	}
+	trigger, err := sp.Trigger(nil)
+	if err != nil {
+		return api.ScalingPolicyEvaluation{}, httperrors.NewGeneralError(errors.Wrap(err, "fetch trigger"))
+	}
+	sm, ok := trigger.(*SScalingMetric)
+	if !ok {
+		return api.ScalingPolicyEvaluation{}, httperrors.NewInternalServerError("scaling policy %s has no metric trigger", sp.Name)
+	}
+	sg, err := sp.ScalingGroup()
+	if err != nil {
+		return api.ScalingPolicyEvaluation{}, httperrors.NewGeneralError(errors.Wrap(err, "fetch scaling group"))
+	}
+	s := auth.GetAdminSession(ctx, consts.GetRegion())
+	ev, err := sm.Evaluate(s, sp, sg, time.Now())
+	if err != nil {
+		log.Errorf("evaluate scaling policy %s: %v", sp.Name, err)
+		if _, ok := errors.Cause(err).(*httputils.JSONClientError); ok {
+			// error returned by monitor service
+			return ev, httperrors.NewGeneralError(err)
+		}
+		return ev, httperrors.NewBadGatewayError("evaluate scaling policy %s: %v", sp.Name, err)
+	}
+	ev.DryRun = true
+	return ev, nil
+}
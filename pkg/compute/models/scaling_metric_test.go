@@ -0,0 +1,110 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestTargetTrackingNumber(t *testing.T) {
+	cases := []struct {
+		current int
+		value   float64
+		target  float64
+		want    int
+	}{
+		{current: 4, value: 90, target: 60, want: 6},
+		{current: 4, value: 30, target: 60, want: 2},
+		// within tolerance
+		{current: 4, value: 63, target: 60, want: 4},
+		{current: 3, value: 70, target: 60, want: 4},
+		{current: 0, value: 90, target: 60, want: 0},
+	}
+	for _, c := range cases {
+		got := targetTrackingNumber(c.current, c.value, c.target)
+		if got != c.want {
+			t.Errorf("targetTrackingNumber(%d, %v, %v) = %d, want %d", c.current, c.value, c.target, got, c.want)
+		}
+	}
+}
+
+func TestSeasonalLoad(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	at := time.Date(2021, 3, 15, 9, 30, 0, 0, time.UTC)
+	load := map[int64]float64{
+		time.Date(2021, 3, 8, 9, 0, 0, 0, time.UTC).Unix():  200,
+		time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC).Unix():  100,
+		time.Date(2021, 3, 8, 10, 0, 0, 0, time.UTC).Unix(): 1000,
+		at.Add(-5 * week).Truncate(time.Hour).Unix():        1000,
+	}
+	got, n := seasonalLoad(load, at, 4)
+	if n != 2 || got != 150 {
+		t.Errorf("seasonalLoad = %v from %d weeks, want 150 from 2 weeks", got, n)
+	}
+	if _, n := seasonalLoad(map[int64]float64{}, at, 4); n != 0 {
+		t.Errorf("seasonalLoad of empty load got %d weeks", n)
+	}
+}
+
+func TestParseMetricSeries(t *testing.T) {
+	ret, err := jsonutils.ParseString(`{"series":[
+		{"tags":{"vm_id":"a"},"points":[[40, 1615798800000],[null, 1615802400000]]},
+		{"tags":{"vm_id":"b"},"points":[[20.5, 1615798800000],[30, 1615802400000]]}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := parseMetricSeries(ret, "vm_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series["a"]) != 1 || len(series["b"]) != 2 {
+		t.Fatalf("unexpected series %#v", series)
+	}
+	load := hourlyLoad(series)
+	if got := load[time.UnixMilli(1615798800000).Unix()]; got != 60.5 {
+		t.Errorf("load of first hour = %v, want 60.5", got)
+	}
+	if got := load[time.UnixMilli(1615802400000).Unix()]; got != 30 {
+		t.Errorf("load of second hour = %v, want 30", got)
+	}
+}
+
+func TestScalingMetricQueryTags(t *testing.T) {
+	sm := &SScalingMetric{}
+	got := sm.queryTags("sg-1")
+	if len(got) != 1 || got[0] != [2]string{scalingGroupMetricTag, "sg-1"} {
+		t.Errorf("query tags without custom tags got %v", got)
+	}
+
+	sm.Tags = jsonutils.Marshal(map[string]string{
+		"zone":                "zone1",
+		"host":                "host1",
+		scalingGroupMetricTag: "sg-2",
+	})
+	got = sm.queryTags("sg-1")
+	want := [][2]string{{scalingGroupMetricTag, "sg-1"}, {"host", "host1"}, {"zone", "zone1"}}
+	if len(got) != len(want) {
+		t.Fatalf("query tags got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("query tags got %v, want %v", got, want)
+		}
+	}
+}
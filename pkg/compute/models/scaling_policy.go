@@ -189,6 +189,15 @@ func (sp *SScalingPolicy) getMoreDetails(ctx context.Context, userCred mcclient.
 			return out, errors.Wrap(err, "ScalingTimerManager.FetchById")
 		}
 		out.CycleTimer = model.(*SScalingTimer).CycleTimerDetails()
+	case api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC:
+		model, err := ScalingMetricManager.FetchById(sp.TriggerId)
+		if errors.Cause(err) == sql.ErrNoRows {
+			return out, nil
+		}
+		if err != nil {
+			return out, errors.Wrap(err, "ScalingMetricManager.FetchById")
+		}
+		out.Metric = model.(*SScalingMetric).MetricDetails()
 	}
 
 	return out, nil
@@ -219,9 +228,20 @@ func (spm *SScalingPolicyManager) ValidateCreateData(ctx context.Context, userCr
 	}
 	input.ScalingGroupId = model.GetId()
 
-	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM}) {
+	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM,
+		api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC}) {
 		return input, httperrors.NewInputParameterError("unkown trigger type %s", input.TriggerType)
 	}
+	if input.TriggerType == api.TRIGGER_TARGET_TRACKING {
+		// target tracking computes the instance number itself, action and
+		// unit only take effect when the policy is triggered manually
+		if len(input.Action) == 0 {
+			input.Action = api.ACTION_ADD
+		}
+		if len(input.Unit) == 0 {
+			input.Unit = api.UNIT_ONE
+		}
+	}
 	if !utils.IsInStringArray(input.Action, []string{api.ACTION_ADD, api.ACTION_REMOVE, api.ACTION_SET}) {
 		return input, httperrors.NewInputParameterError("unkown scaling policy action %s", input.Action)
 	}
@@ -341,6 +361,8 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 				RealCumulate:       0,
 				LastTriggerTime:    time.Now(),
 			}, nil
+		case api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC:
+			return newScalingMetric(sp.GetId(), input.Metric), nil
 		default:
 			return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 		}
@@ -361,6 +383,12 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 			return nil, errors.Wrap(err, "SScalingAlarmManager.FetchById")
 		}
 		return model.(*SScalingAlarm), nil
+	case api.TRIGGER_TARGET_TRACKING, api.TRIGGER_METRIC:
+		model, err := ScalingMetricManager.FetchById(sp.TriggerId)
+		if err != nil {
+			return nil, errors.Wrap(err, "SScalingMetricManager.FetchById")
+		}
+		return model.(*SScalingMetric), nil
 	default:
 		return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 	}
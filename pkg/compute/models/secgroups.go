@@ -83,6 +83,9 @@ type SSecurityGroup struct {
 	db.SExternalizedResourceBase
 	IsDirty bool `nullable:"false" default:"false"`
 
+	// 是否记录安全组规则匹配的流量日志
+	EnableFlowLog bool `nullable:"false" default:"false" list:"user"`
+
 	SManagedResourceBase
 
 	SCloudregionResourceBase `width:"36" charset:"ascii" nullable:"false" list:"domain" create:"domain_required" default:"default"`
@@ -653,6 +656,26 @@ func (self *SSecurityGroup) PerformAddRule(ctx context.Context, userCred mcclien
 	return nil, nil
 }
 
+// 开启或关闭安全组流量日志
+func (self *SSecurityGroup) PerformSetFlowLog(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecgroupSetFlowLogInput) (jsonutils.JSONObject, error) {
+	if len(self.ManagerId) > 0 {
+		return nil, httperrors.NewUnsupportOperationError("flow log is not supported by managed security group")
+	}
+	if self.EnableFlowLog == input.Enable {
+		return nil, nil
+	}
+	diff, err := db.Update(self, func() error {
+		self.EnableFlowLog = input.Enable
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, diff, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UPDATE, diff, userCred, true)
+	return nil, nil
+}
+
 func (self *SSecurityGroup) PerformClone(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecurityGroupCloneInput) (api.SecurityGroupCloneInput, error) {
 	if len(input.Name) == 0 {
 		return input, httperrors.NewMissingParameterError("name")
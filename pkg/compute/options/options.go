@@ -168,6 +168,7 @@ type ComputeOptions struct {
 	LockStorageFromCachedimage             bool `help:"must use storage in where selected cachedimage when creating vm"`
 
 	AutoReconcileBackupServers   bool `help:"auto reconcile backup servers" default:"false"`
+	MaxDiskBackupChainLength     int  `help:"max count of incremental disk backups chained on one full backup" default:"30"`
 	SetKVMServerAsDaemonOnCreate bool `help:"set kvm guest as daemon server on create" default:"false"`
 
 	SCapabilityOptions
@@ -239,6 +240,7 @@ type SASControllerOptions struct {
 	ConcurrentUpper     int `help:"This represents the upper limit of concurrent sacling sctivities" default:"500"`
 	CheckScaleInterval  int `help:"The interval between the two checks about scaling, unit: s" default:"60"`
 	CheckHealthInterval int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
+	CheckMetricInterval int `help:"The interval between the two evaluations of metric driven scaling policies, unit: s" default:"60"`
 }
 
 var (
@@ -672,6 +672,10 @@ func (self *SKVMRegionDriver) RequestDeleteInstanceBackup(ctx context.Context, i
 	if len(backups) == 0 {
 		task.SetStage("OnInstanceBackupDelete", nil)
 		taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+			err := self.requestDeleteInstanceBackupVtpm(ctx, ib, task.GetUserCred())
+			if err != nil {
+				return nil, errors.Wrap(err, "requestDeleteInstanceBackupVtpm")
+			}
 			return nil, nil
 		})
 		return nil
@@ -895,6 +899,11 @@ func (self *SKVMRegionDriver) RequestSyncDiskStatus(ctx context.Context, userCre
 }
 
 func (self *SKVMRegionDriver) RequestCreateInstanceBackup(ctx context.Context, guest *models.SGuest, ib *models.SInstanceBackup, task taskman.ITask, params *jsonutils.JSONDict) error {
+	if guest.GetMetadata(ctx, api.VM_METADATA_ENABLE_TPM, nil) == "true" {
+		if err := self.saveInstanceBackupVtpmState(ctx, guest, ib, task.GetUserCred()); err != nil {
+			return errors.Wrap(err, "saveInstanceBackupVtpmState")
+		}
+	}
 	disks, _ := guest.GetGuestDisks()
 	task.SetStage("OnKvmDisksSnapshot", params)
 	for i := range disks {
@@ -931,6 +940,54 @@ func (self *SKVMRegionDriver) RequestCreateInstanceBackup(ctx context.Context, g
 	return nil
 }
 
+func (self *SKVMRegionDriver) saveInstanceBackupVtpmState(ctx context.Context, guest *models.SGuest, ib *models.SInstanceBackup, userCred mcclient.TokenCredential) error {
+	host, err := guest.GetHost()
+	if err != nil {
+		return errors.Wrap(err, "GetHost")
+	}
+	// the state is saved beside disk backups, encrypted with the same key
+	input, err := ib.GetVtpmBackupInput(ctx, userCred, fmt.Sprintf("%s.vtpm", ib.Id))
+	if err != nil {
+		return errors.Wrap(err, "GetVtpmBackupInput")
+	}
+	url := fmt.Sprintf("/servers/%s/vtpm-backup", guest.Id)
+	ret, err := host.Request(ctx, userCred, "POST", url, nil, jsonutils.Marshal(input))
+	if err != nil {
+		return errors.Wrap(err, "request vtpm backup")
+	}
+	vtpmBackupId, _ := ret.GetString("vtpm_backup_id")
+	if len(vtpmBackupId) == 0 {
+		return nil
+	}
+	return ib.SetMetadata(ctx, api.VM_METADATA_VTPM_BACKUP, vtpmBackupId, userCred)
+}
+
+// requestDeleteInstanceBackupVtpm removes vTPM state saved by instance backup
+func (self *SKVMRegionDriver) requestDeleteInstanceBackupVtpm(ctx context.Context, ib *models.SInstanceBackup, userCred mcclient.TokenCredential) error {
+	vtpmBackupId := ib.GetVtpmBackupId(ctx)
+	if len(vtpmBackupId) == 0 {
+		return nil
+	}
+	host, err := models.HostManager.GetEnabledKvmHost()
+	if err != nil {
+		return errors.Wrap(err, "unable to GetEnabledKvmHost")
+	}
+	input, err := ib.GetVtpmBackupInput(ctx, userCred, vtpmBackupId)
+	if err != nil {
+		return errors.Wrap(err, "GetVtpmBackupInput")
+	}
+	body := jsonutils.NewDict()
+	body.Set("backup_id", jsonutils.NewString(vtpmBackupId))
+	body.Set("backup_storage_id", jsonutils.NewString(input.BackupStorageId))
+	body.Set("backup_storage_access_info", input.BackupStorageAccessInfo)
+	body.Set("backup_instance", jsonutils.JSONTrue)
+	_, err = host.Request(ctx, userCred, "POST", "/storages/delete-backup", nil, body)
+	if err != nil {
+		return errors.Wrap(err, "request delete vtpm backup")
+	}
+	return nil
+}
+
 func (self *SKVMRegionDriver) RequestPackInstanceBackup(ctx context.Context, ib *models.SInstanceBackup, task taskman.ITask, packageName string) error {
 	backupStorage, err := ib.GetBackupStorage()
 	if err != nil {
@@ -969,6 +1026,9 @@ func (self *SKVMRegionDriver) RequestPackInstanceBackup(ctx context.Context, ib
 	}
 	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 	body.Set("backup_ids", jsonutils.Marshal(backupIds))
+	if vtpmBackupId := ib.GetVtpmBackupId(ctx); len(vtpmBackupId) > 0 {
+		body.Set("vtpm_backup_id", jsonutils.NewString(vtpmBackupId))
+	}
 	body.Set("metadata", jsonutils.Marshal(metadata))
 	header := task.GetTaskRequestHeader()
 	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
@@ -1348,7 +1408,16 @@ func (self *SKVMRegionDriver) RequestCreateBackup(ctx context.Context, backup *m
 	host, _ := guest.GetHost()
 	url := fmt.Sprintf("%s/disks/%s/backup/%s", host.ManagerUri, storage.Id, disk.Id)
 	body := jsonutils.NewDict()
-	body.Set("snapshot_id", jsonutils.NewString(snapshotId))
+	if len(snapshotId) > 0 {
+		body.Set("snapshot_id", jsonutils.NewString(snapshotId))
+	} else {
+		// backup running guest disk by qemu backup job with dirty bitmap
+		url = fmt.Sprintf("%s/servers/%s/disk-backup", host.ManagerUri, guest.Id)
+		body.Set("disk_id", jsonutils.NewString(disk.Id))
+		if backup.IsIncremental() {
+			body.Set("parent_backup_id", jsonutils.NewString(backup.ParentBackupId))
+		}
+	}
 	body.Set("backup_id", jsonutils.NewString(backup.GetId()))
 	body.Set("backup_storage_id", jsonutils.NewString(backupStorage.GetId()))
 	accessInfo, err := backupStorage.GetAccessInfo()
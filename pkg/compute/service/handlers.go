@@ -18,7 +18,9 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/appsrv/dispatcher"
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/cronjobrun"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/proxy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
@@ -52,6 +54,7 @@ func InitHandlers(app *appsrv.Application) {
 	sshkeys.AddSshKeysHandler("", app)
 	taskman.AddTaskHandler("", app)
 	misc.AddMiscHandler("", app)
+	cronman.AddCronJobHandler("", app)
 
 	app_common.ExportOptionsHandler(app, &options.Options)
 
@@ -63,6 +66,7 @@ func InitHandlers(app *appsrv.Application) {
 		db.TenantCacheManager,
 		db.SharedResourceManager,
 		db.I18nManager,
+		cronjobrun.CronJobRunManager,
 		models.GuestcdromManager,
 		models.GuestFloppyManager,
 		models.NetInterfaceManager,
@@ -91,6 +95,7 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.ScalingTimerManager,
 		models.ScalingAlarmManager,
+		models.ScalingMetricManager,
 		models.ScalingGroupGuestManager,
 		models.ScalingGroupNetworkManager,
 
@@ -125,6 +130,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.SchedtagManager,
 		models.GuestManager,
 		models.GetContainerManager(),
+		models.GuestFilesystemManager,
 		models.GroupManager,
 		models.DiskManager,
 		models.NetworkManager,
@@ -22,6 +22,7 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/rand"
+	"yunion.io/x/pkg/utils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -75,6 +76,20 @@ func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandalone
 		self.OnSnapshot(ctx, backup, nil)
 		return
 	}
+	if self.isLiveBackup(backup) {
+		// running guest disk is backed up by qemu backup job, whose dirty bitmap
+		// tracks the blocks changed since this backup for next incremental backup
+		self.OnSnapshot(ctx, backup, nil)
+		return
+	}
+	if backup.IsIncremental() {
+		// changed blocks are only tracked by live backup
+		err := backup.ConvertToFullBackup(ctx, self.UserCred, "disk is not backed up live")
+		if err != nil {
+			self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_CREATE_FAILED)
+			return
+		}
+	}
 	backup.SetStatus(ctx, self.UserCred, api.BACKUP_STATUS_SNAPSHOT, "")
 	snapshot, err := self.CreateSnapshot(ctx, backup)
 	if err != nil {
@@ -91,6 +106,27 @@ func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandalone
 	}
 }
 
+func (self *DiskBackupCreateTask) isLiveBackup(backup *models.SDiskBackup) bool {
+	disk, err := backup.GetDisk()
+	if err != nil {
+		return false
+	}
+	guest := disk.GetGuest()
+	if guest == nil || guest.Hypervisor != api.HYPERVISOR_KVM || guest.Status != api.VM_RUNNING {
+		return false
+	}
+	// persistent dirty bitmap is stored in qcow2 image, other disks
+	// of running guest are backed up from snapshot as before
+	if disk.DiskFormat != "qcow2" {
+		return false
+	}
+	storage, err := disk.GetStorage()
+	if err != nil {
+		return false
+	}
+	return utils.IsInStringArray(storage.StorageType, api.FIEL_STORAGE)
+}
+
 func (self *DiskBackupCreateTask) OnSnapshot(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
 	snapshotId, _ := self.Params.GetString("snapshot_id")
 	if self.Params.Contains("only_snapshot") {
@@ -119,20 +155,31 @@ func (self *DiskBackupCreateTask) OnSnapshotFailed(ctx context.Context, backup *
 }
 
 func (self *DiskBackupCreateTask) OnSave(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	log.Infof("data from RequestCreateBackup: %s", data)
+	sizeMb, _ := data.Int("size_mb")
+	db.Update(backup, func() error {
+		backup.SizeMb = int(sizeMb)
+		return nil
+	})
+	if mode, _ := data.GetString("backup_mode"); mode == api.BACKUP_MODE_FULL && backup.IsIncremental() {
+		err := backup.ConvertToFullBackup(ctx, self.UserCred, "dirty bitmap of parent backup not found on guest disk")
+		if err != nil {
+			self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_SAVE_FAILED)
+			return
+		}
+	}
 	// cleanup snapshot
 	snapshotId, _ := self.Params.GetString("snapshot_id")
+	if len(snapshotId) == 0 {
+		self.taksSuccess(ctx, backup, nil)
+		return
+	}
 	self.SetStage("OnCleanupSnapshot", nil)
 	snapshotModel, err := models.SnapshotManager.FetchById(snapshotId)
 	if err != nil {
 		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_CLEANUP_SNAPSHOT_FAILED)
 		return
 	}
-	log.Infof("data from RequestCreateBackup: %s", data)
-	sizeMb, _ := data.Int("size_mb")
-	db.Update(backup, func() error {
-		backup.SizeMb = int(sizeMb)
-		return nil
-	})
 	snapshot := snapshotModel.(*models.SSnapshot)
 	err = snapshot.StartSnapshotDeleteTask(ctx, self.UserCred, false, self.GetId())
 	if err != nil {
@@ -143,6 +190,10 @@ func (self *DiskBackupCreateTask) OnSave(ctx context.Context, backup *models.SDi
 
 func (self *DiskBackupCreateTask) OnSaveFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
 	snapshotId, _ := self.Params.GetString("snapshot_id")
+	if len(snapshotId) == 0 {
+		self.taskFailed(ctx, backup, data, api.BACKUP_STATUS_SAVE_FAILED)
+		return
+	}
 	snapshotModel, err := models.SnapshotManager.FetchById(snapshotId)
 	if err != nil {
 		log.Errorf("unable to cleanup snapshot: %s", err.Error())
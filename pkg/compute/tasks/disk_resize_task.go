@@ -109,7 +109,7 @@ func (self *DiskResizeTask) OnDiskResizeComplete(ctx context.Context, disk *mode
 	}
 	diff := int(sizeMb) - disk.DiskSize
 	oldStatus := disk.Status
-	_, err = db.Update(disk, func() error {
+	resizeDiffs, err := db.Update(disk, func() error {
 		disk.Status = api.DISK_READY
 		disk.DiskSize = int(sizeMb)
 		return nil
@@ -138,7 +138,9 @@ func (self *DiskResizeTask) OnDiskResizeComplete(ctx context.Context, disk *mode
 	}
 
 	self.CleanHostSchedCache(disk)
-	db.OpsLog.LogEvent(disk, db.ACT_RESIZE, disk.GetShortDesc(ctx), self.UserCred)
+	resizeNotes := disk.GetShortDesc(ctx)
+	db.AddOpsLogUpdateDiffs(resizeNotes, resizeDiffs)
+	db.OpsLog.LogEvent(disk, db.ACT_RESIZE, resizeNotes, self.UserCred)
 	logclient.AddActionLogWithStartable(self, disk, logclient.ACT_RESIZE, nil, self.UserCred, true)
 	self.OnDiskResized(ctx, disk)
 }
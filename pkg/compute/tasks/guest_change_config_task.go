@@ -234,7 +234,7 @@ func (task *GuestChangeConfigTask) OnGuestChangeCpuMemSpecComplete(ctx context.C
 		}
 	}
 
-	_, err = db.Update(guest, func() error {
+	diffs, err := db.Update(guest, func() error {
 		if confs.VcpuCount > 0 {
 			guest.VcpuCount = confs.VcpuCount
 		}
@@ -264,6 +264,7 @@ func (task *GuestChangeConfigTask) OnGuestChangeCpuMemSpecComplete(ctx context.C
 		changeConfigSpec.Set("instance_type", jsonutils.NewString(confs.InstanceType))
 	}
 
+	db.AddOpsLogUpdateDiffs(changeConfigSpec, diffs)
 	db.OpsLog.LogEvent(guest, db.ACT_CHANGE_FLAVOR, changeConfigSpec.String(), task.UserCred)
 
 	var pendingUsage models.SQuota
@@ -57,7 +57,12 @@ func (task *GuestResizeDiskTask) OnInit(ctx context.Context, obj db.IStandaloneM
 		return
 	}
 
-	task.SetStage("OnDiskResizeComplete", nil)
+	// disks before resizing are recorded in opslog for history
+	params := jsonutils.NewDict()
+	if disks, err := guest.GetHistoryDisks(); err == nil {
+		params.Set("history_disks", disks)
+	}
+	task.SetStage("OnDiskResizeComplete", params)
 
 	diskObj.(*models.SDisk).StartDiskResizeTask(ctx, task.UserCred, sizeMb, task.GetId(), &pendingUsage)
 }
@@ -72,8 +77,14 @@ func (task *GuestResizeDiskTask) OnTaskFailed(ctx context.Context, guest *models
 
 func (task *GuestResizeDiskTask) OnDiskResizeComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	guest := obj.(*models.SGuest)
-	db.OpsLog.LogEvent(guest, db.ACT_RESIZE, task.Params, task.UserCred)
-	logclient.AddActionLogWithStartable(task, guest, logclient.ACT_RESIZE, task.Params, task.UserCred, true)
+	notes := task.Params.CopyExcludes("history_disks")
+	oldDisks, _ := task.Params.Get("history_disks")
+	newDisks, _ := guest.GetHistoryDisks()
+	if oldDisks != nil && newDisks != nil {
+		db.AddOpsLogFieldDiff(notes, models.HISTORY_FIELD_DISKS, oldDisks, newDisks)
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_RESIZE, notes, task.UserCred)
+	logclient.AddActionLogWithStartable(task, guest, logclient.ACT_RESIZE, task.Params.CopyExcludes("history_disks"), task.UserCred, true)
 	task.SetStage("TaskComplete", nil)
 	if task.HasParentTask() {
 		guest.StartSyncTaskWithoutSyncstatus(ctx, task.UserCred, false, task.GetId())
@@ -106,6 +106,14 @@ func (self *InstanceBackupUnpackTask) OnUnpackComplete(ctx context.Context, ib *
 		self.taskFailed(ctx, ib, jsonutils.NewString(err.Error()))
 		return
 	}
+	// vTPM state from package is saved under a new name, replacing the
+	// name recorded in metadata of the packed instance backup
+	vtpmBackupId, _ := data.GetString("vtpm_backup_id")
+	err = ib.SetMetadata(ctx, compute.VM_METADATA_VTPM_BACKUP, vtpmBackupId, self.GetUserCred())
+	if err != nil {
+		self.taskFailed(ctx, ib, jsonutils.NewString(err.Error()))
+		return
+	}
 	self.taskSuccess(ctx, ib)
 }
 
@@ -105,6 +105,7 @@ func (asc *SASController) Init(options options.SASControllerOptions, cronm *cron
 
 	cronm.AddJobAtIntervalsWithStartRun("CheckTimer", time.Duration(options.TimerInterval)*time.Second, asc.Timer, true)
 	cronm.AddJobAtIntervalsWithStartRun("CheckScale", time.Duration(options.CheckScaleInterval)*time.Second, asc.CheckScale, true)
+	cronm.AddJobAtIntervalsWithStartRun("CheckMetric", time.Duration(options.CheckMetricInterval)*time.Second, asc.CheckMetric, false)
 	cronm.AddJobAtIntervalsWithStartRun("CheckInstanceHealth", time.Duration(options.CheckHealthInterval)*time.Minute, asc.CheckInstanceHealth, true)
 
 	// check all scaling activity
@@ -0,0 +1,97 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+)
+
+// CheckMetric evaluates the target tracking and custom metric scaling
+// policies, and scales the scaling groups unless the policy is in dry run
+func (asc *SASController) CheckMetric(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := models.ScalingPolicyManager.Query().Equals("status", compute.SP_STATUS_READY).IsTrue("enabled").
+		In("trigger_type", []string{compute.TRIGGER_TARGET_TRACKING, compute.TRIGGER_METRIC})
+	policies := make([]models.SScalingPolicy, 0, 5)
+	err := db.FetchModelObjects(models.ScalingPolicyManager, q, &policies)
+	if err != nil {
+		log.Errorf("db.FetchModelObjects error: %s", err.Error())
+		return
+	}
+	session := auth.GetAdminSession(ctx, consts.GetRegion())
+	for i := range policies {
+		policy := policies[i]
+		asc.timerQueue <- struct{}{}
+		go func() {
+			defer func() {
+				<-asc.timerQueue
+			}()
+			asc.evaluate(ctx, userCred, session, &policy)
+		}()
+	}
+}
+
+func (asc *SASController) evaluate(ctx context.Context, userCred mcclient.TokenCredential, session *mcclient.ClientSession,
+	policy *models.SScalingPolicy) {
+	trigger, err := policy.Trigger(nil)
+	if err != nil {
+		log.Errorf("fetch trigger of ScalingPolicy '%s': %s", policy.Id, err.Error())
+		return
+	}
+	metric, ok := trigger.(*models.SScalingMetric)
+	if !ok {
+		return
+	}
+	sg, err := policy.ScalingGroup()
+	if err != nil {
+		log.Errorf("fetch ScalingGroup of ScalingPolicy '%s': %s", policy.Id, err.Error())
+		return
+	}
+	if sg.Enabled.IsFalse() {
+		return
+	}
+	ev, err := metric.Evaluate(session, policy, sg, time.Now())
+	if err != nil {
+		log.Errorf("evaluate ScalingPolicy '%s': %s", policy.Id, err.Error())
+		return
+	}
+	if ev.Decision != compute.SCALING_DECISION_NONE && !ev.DryRun {
+		if !sg.AllowScale() {
+			// avoid recording a rejected activity every check during the cooling time
+			ev.Reason += ", but the scaling group is in cooling time"
+		} else {
+			err = sg.Scale(ctx, metric, models.SScalingTarget{Number: ev.DesireNumber}, policy.CoolingTime)
+			if err != nil {
+				log.Errorf("scale for ScalingPolicy '%s': %s", policy.Id, err.Error())
+			} else {
+				ev.Scaled = true
+				policy.EventNotify(ctx, userCred)
+			}
+		}
+	}
+	err = metric.SaveEvaluation(ev)
+	if err != nil {
+		log.Errorf("save evaluation of ScalingPolicy '%s': %s", policy.Id, err.Error())
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/etcd/msg"
@@ -86,6 +87,17 @@ type SRegionDNS struct {
 
 	InCloudOnly bool
 
+	// Dnssec signs the answers of the local dns zones if configured
+	Dnssec *SDnssecSigner
+	// TsigKeys are the keys allowed to update the local dns zones, keyed by
+	// the canonical key name
+	TsigKeys map[string]*sTsigKey
+	// UpdateListen is the address serving the dynamic updates signed by TsigKeys
+	UpdateListen string
+
+	updateLock    sync.Mutex
+	updateServers []*dns.Server
+
 	// K8sSkip bool
 
 	// K8sManager *k8s.SKubeClusterManager
@@ -149,6 +161,17 @@ func (r *SRegionDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, rmsg *d
 	opt := plugin.Options{}
 	state := request.Request{W: w, Req: rmsg, Context: ctx}
 	zone := plugin.Zones(r.Zones).Matches(state.Name())
+	if r.Dnssec != nil && state.Do() {
+		// sign every response of this request, including the errors
+		state.W = &signingResponseWriter{ResponseWriter: w, r: r, state: state}
+		if state.QType() == dns.TypeDNSKEY {
+			if m, ok := r.Dnssec.DNSKEY(state); ok {
+				state.SizeAndDo(m)
+				state.W.WriteMsg(m)
+				return dns.RcodeSuccess, nil
+			}
+		}
+	}
 	switch state.QType() {
 	case dns.TypeA:
 		records, err = plugin.A(r, zone, state, nil, opt)
@@ -201,7 +224,7 @@ func (r *SRegionDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, rmsg *d
 
 	state.SizeAndDo(m)
 	m = state.Scrub(m)
-	w.WriteMsg(m)
+	state.W.WriteMsg(m)
 	return dns.RcodeSuccess, nil
 }
 
@@ -0,0 +1,492 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"crypto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+const (
+	dnssecAlgorithm = dns.ECDSAP256SHA256
+	dnssecKeyBits   = 256
+
+	dnskeyFlagZSK = 256
+	dnskeyFlagKSK = 257
+	dnskeyTTL     = 3600
+
+	// defaultZskRotateInterval is the lifetime of a zone signing key
+	defaultZskRotateInterval = 30 * 24 * time.Hour
+	// a new zone signing key is published this long before signing with it,
+	// so that resolvers caching the old DNSKEY set can validate the answers
+	zskPublishDelay = 2 * dnskeyTTL * time.Second
+
+	signatureInception = 3 * time.Hour
+	signatureValidity  = 7 * 24 * time.Hour
+
+	dnssecRefreshInterval = time.Minute
+	// keys of a zone generated within this window are generated by replicas
+	// racing with each other, only the first one is kept
+	keyGenerateRaceWindow = dnssecRefreshInterval
+)
+
+type sDnssecKey struct {
+	// id of the key in the key store
+	id      string
+	zone    string
+	key     *dns.DNSKEY
+	signer  crypto.Signer
+	created time.Time
+}
+
+func (k *sDnssecKey) activeAt() time.Time {
+	if k.key.Flags == dnskeyFlagKSK {
+		return k.created
+	}
+	return k.created.Add(zskPublishDelay)
+}
+
+type sZoneKeys struct {
+	ksk *sDnssecKey
+	// zone signing keys ordered by created time, the latest active one signs
+	zsks []*sDnssecKey
+}
+
+func (zk *sZoneKeys) activeZsk(now time.Time) *sDnssecKey {
+	var active *sDnssecKey
+	for _, k := range zk.zsks {
+		if !k.activeAt().After(now) {
+			active = k
+		}
+	}
+	if active == nil && len(zk.zsks) > 0 {
+		// the very first key is active right after generation
+		active = zk.zsks[0]
+	}
+	return active
+}
+
+func (zk *sZoneKeys) dnskeys(zone string) []dns.RR {
+	rrs := make([]dns.RR, 0, len(zk.zsks)+1)
+	for _, k := range append([]*sDnssecKey{zk.ksk}, zk.zsks...) {
+		rr := *k.key
+		rr.Hdr.Name = zone
+		rr.Hdr.Ttl = dnskeyTTL
+		rrs = append(rrs, &rr)
+	}
+	return rrs
+}
+
+// SDnssecSigner signs the answers of the local dns zones online, the keys of
+// each zone are kept in the key store shared by all the replicas
+type SDnssecSigner struct {
+	RotateInterval time.Duration
+
+	store iDnssecKeyStore
+
+	lock  *sync.RWMutex
+	zones map[string]*sZoneKeys
+	stop  chan struct{}
+}
+
+func newDnssecSigner(store iDnssecKeyStore, rotateInterval time.Duration) *SDnssecSigner {
+	if rotateInterval <= 0 {
+		rotateInterval = defaultZskRotateInterval
+	}
+	return &SDnssecSigner{
+		RotateInterval: rotateInterval,
+		store:          store,
+		lock:           new(sync.RWMutex),
+		zones:          make(map[string]*sZoneKeys),
+		stop:           make(chan struct{}),
+	}
+}
+
+// Start loads the keys of the signed zones and keeps rotating them
+func (s *SDnssecSigner) Start(primaryZone string) error {
+	if err := s.refresh(primaryZone); err != nil {
+		return err
+	}
+	go func() {
+		tick := time.NewTicker(dnssecRefreshInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				if err := s.refresh(primaryZone); err != nil {
+					log.Errorf("refresh dnssec keys: %v", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *SDnssecSigner) Stop() {
+	close(s.stop)
+}
+
+// signedZoneNames returns the local dns zones and the primary zone, the
+// zones managed by cloud providers are signed by the providers themselves
+func signedZoneNames(primaryZone string) ([]string, error) {
+	zones := make([]string, 0)
+	q := models.DnsZoneManager.Query("name").IsNullOrEmpty("manager_id").IsTrue("enabled")
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "query dns zones")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "scan dns zone")
+		}
+		zones = append(zones, dns.Fqdn(strings.ToLower(name)))
+	}
+	if primaryZone != "." {
+		zones = append(zones, dns.Fqdn(strings.ToLower(primaryZone)))
+	}
+	return zones, nil
+}
+
+func (s *SDnssecSigner) refresh(primaryZone string) error {
+	names, err := signedZoneNames(primaryZone)
+	if err != nil {
+		return err
+	}
+	keys, err := s.store.List()
+	if err != nil {
+		return errors.Wrap(err, "list dnssec keys")
+	}
+	zoneKeys := make(map[string][]*sDnssecKey)
+	for _, k := range keys {
+		zoneKeys[k.zone] = append(zoneKeys[k.zone], k)
+	}
+	now := time.Now()
+	zones := make(map[string]*sZoneKeys, len(names))
+	for _, name := range names {
+		if _, ok := zones[name]; ok {
+			continue
+		}
+		zk, err := s.loadZoneKeys(name, zoneKeys[name], now)
+		if err != nil {
+			log.Errorf("load dnssec keys of zone %s: %v", name, err)
+			continue
+		}
+		if err := s.rotateZoneKeys(name, zk, now); err != nil {
+			log.Errorf("rotate dnssec keys of zone %s: %v", name, err)
+		}
+		zones[name] = zk
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.zones = zones
+	return nil
+}
+
+// pickZoneKeys picks the keys of a zone, the keys generated by the replicas
+// racing with each other are returned as duplicates, every replica picks the
+// same keys as they are ordered by created time and id
+func pickZoneKeys(keys []*sDnssecKey) (*sZoneKeys, []*sDnssecKey) {
+	sorted := make([]*sDnssecKey, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].created.Equal(sorted[j].created) {
+			return sorted[i].created.Before(sorted[j].created)
+		}
+		return sorted[i].id < sorted[j].id
+	})
+	zk := &sZoneKeys{}
+	dups := make([]*sDnssecKey, 0)
+	for _, k := range sorted {
+		if k.key.Flags == dnskeyFlagKSK {
+			// KSK is not rotated, the first one is kept
+			if zk.ksk != nil {
+				dups = append(dups, k)
+				continue
+			}
+			zk.ksk = k
+			continue
+		}
+		if len(zk.zsks) > 0 && k.created.Sub(zk.zsks[len(zk.zsks)-1].created) < keyGenerateRaceWindow {
+			dups = append(dups, k)
+			continue
+		}
+		zk.zsks = append(zk.zsks, k)
+	}
+	return zk, dups
+}
+
+func (s *SDnssecSigner) loadZoneKeys(zone string, keys []*sDnssecKey, now time.Time) (*sZoneKeys, error) {
+	zk, dups := pickZoneKeys(keys)
+	for _, k := range dups {
+		log.Infof("dnssec key %d of zone %s duplicated, remove", k.key.KeyTag(), zone)
+		s.removeKey(k)
+	}
+	if zk.ksk == nil {
+		k, err := s.generateKey(zone, dnskeyFlagKSK, now)
+		if err != nil {
+			return nil, errors.Wrap(err, "generate KSK")
+		}
+		zk.ksk = k
+		// the DS record has to be published in the parent zone
+		log.Infof("dnssec KSK of zone %s generated, DS: %s", zone, k.key.ToDS(dns.SHA256))
+	}
+	return zk, nil
+}
+
+// rotateZoneKeys publishes a new zone signing key when the active one reaches
+// the rotate interval, and removes the keys whose signatures are all expired
+func (s *SDnssecSigner) rotateZoneKeys(zone string, zk *sZoneKeys, now time.Time) error {
+	if len(zk.zsks) == 0 || now.Sub(zk.zsks[len(zk.zsks)-1].created) >= s.RotateInterval {
+		k, err := s.generateKey(zone, dnskeyFlagZSK, now)
+		if err != nil {
+			return errors.Wrap(err, "generate ZSK")
+		}
+		zk.zsks = append(zk.zsks, k)
+		log.Infof("dnssec ZSK %d of zone %s published", k.key.KeyTag(), zone)
+	}
+	active := zk.activeZsk(now)
+	zsks := make([]*sDnssecKey, 0, len(zk.zsks))
+	for _, k := range zk.zsks {
+		if k.created.Before(active.created) && now.Sub(active.activeAt()) > signatureValidity {
+			log.Infof("dnssec ZSK %d of zone %s retired", k.key.KeyTag(), zone)
+			s.removeKey(k)
+			continue
+		}
+		zsks = append(zsks, k)
+	}
+	zk.zsks = zsks
+	return nil
+}
+
+func (s *SDnssecSigner) generateKey(zone string, flags uint16, now time.Time) (*sDnssecKey, error) {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: dnskeyTTL},
+		Flags:     flags,
+		Protocol:  3,
+		Algorithm: dnssecAlgorithm,
+	}
+	priv, err := key.Generate(dnssecKeyBits)
+	if err != nil {
+		return nil, errors.Wrap(err, "Generate")
+	}
+	k := &sDnssecKey{zone: zone, key: key, signer: priv.(crypto.Signer), created: now}
+	if err := s.store.Save(k); err != nil {
+		return nil, errors.Wrap(err, "save key")
+	}
+	return k, nil
+}
+
+func (s *SDnssecSigner) removeKey(k *sDnssecKey) {
+	if err := s.store.Delete(k); err != nil {
+		log.Errorf("remove dnssec key %d of zone %s: %v", k.key.KeyTag(), k.zone, err)
+	}
+}
+
+// zoneOf returns the longest signed zone containing the name
+func (s *SDnssecSigner) zoneOf(name string) (string, *sZoneKeys) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	name = strings.ToLower(dns.Fqdn(name))
+	var (
+		zone string
+		keys *sZoneKeys
+	)
+	for z, zk := range s.zones {
+		if dns.IsSubDomain(z, name) && len(z) > len(zone) {
+			zone, keys = z, zk
+		}
+	}
+	return zone, keys
+}
+
+// DNSKEY answers the DNSKEY query of the apex of a signed zone
+func (s *SDnssecSigner) DNSKEY(state request.Request) (*dns.Msg, bool) {
+	zone, zk := s.zoneOf(state.Name())
+	if zk == nil || zone != strings.ToLower(state.Name()) {
+		return nil, false
+	}
+	m := new(dns.Msg)
+	m.SetReply(state.Req)
+	m.Authoritative = true
+	m.Answer = zk.dnskeys(zone)
+	return m, true
+}
+
+func newRRSIG(zone string, k *sDnssecKey, now time.Time) *dns.RRSIG {
+	return &dns.RRSIG{
+		Hdr:        dns.RR_Header{Rrtype: dns.TypeRRSIG, Class: dns.ClassINET},
+		Algorithm:  k.key.Algorithm,
+		KeyTag:     k.key.KeyTag(),
+		SignerName: zone,
+		Inception:  uint32(now.Add(-signatureInception).Unix()),
+		Expiration: uint32(now.Add(signatureValidity).Unix()),
+	}
+}
+
+// signRRsets appends the signatures of every RRset in the zone, the DNSKEY
+// RRset is signed by the key signing key
+func signRRsets(zone string, zk *sZoneKeys, rrs []dns.RR, now time.Time) []dns.RR {
+	zsk := zk.activeZsk(now)
+	if zsk == nil {
+		return rrs
+	}
+	type rrsetKey struct {
+		name  string
+		rtype uint16
+	}
+	rrsets := make(map[rrsetKey][]dns.RR)
+	order := make([]rrsetKey, 0)
+	for _, rr := range rrs {
+		h := rr.Header()
+		switch h.Rrtype {
+		case dns.TypeRRSIG, dns.TypeOPT, dns.TypeTSIG:
+			continue
+		}
+		if !dns.IsSubDomain(zone, strings.ToLower(h.Name)) {
+			continue
+		}
+		key := rrsetKey{name: strings.ToLower(h.Name), rtype: h.Rrtype}
+		if _, ok := rrsets[key]; !ok {
+			order = append(order, key)
+		}
+		rrsets[key] = append(rrsets[key], rr)
+	}
+	for _, key := range order {
+		k := zsk
+		if key.rtype == dns.TypeDNSKEY {
+			k = zk.ksk
+		}
+		sig := newRRSIG(zone, k, now)
+		if err := sig.Sign(k.signer, rrsets[key]); err != nil {
+			log.Errorf("sign %s %s: %v", key.name, dns.TypeToString[key.rtype], err)
+			continue
+		}
+		sig.Hdr.Ttl = rrsets[key][0].Header().Ttl
+		rrs = append(rrs, sig)
+	}
+	return rrs
+}
+
+// blackLieNSEC denies the existence of the queried type with a minimal NSEC
+// listing the types owned by the name, which also turns NXDOMAIN into NODATA
+// as online signers can't enumerate the names of the zone
+func blackLieNSEC(qname string, ttl uint32, types []uint16) *dns.NSEC {
+	return &dns.NSEC{
+		Hdr:        dns.RR_Header{Name: qname, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: ttl},
+		NextDomain: "\\000." + qname,
+		TypeBitMap: nsecTypeBitMap(types),
+	}
+}
+
+func nsecTypeBitMap(types []uint16) []uint16 {
+	bitmap := append([]uint16{dns.TypeRRSIG, dns.TypeNSEC}, types...)
+	sort.Slice(bitmap, func(i, j int) bool { return bitmap[i] < bitmap[j] })
+	ret := bitmap[:0]
+	for i, t := range bitmap {
+		if i == 0 || t != bitmap[i-1] {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// nsecProbeTypes are the types looked up to find the types owned by a name
+var nsecProbeTypes = []uint16{
+	dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeMX,
+	dns.TypeTXT, dns.TypeSRV, dns.TypePTR,
+}
+
+// ownedTypes returns the types of the records owned by the queried name other
+// than the queried type, the name doesn't exist if nothing is returned
+func (r *SRegionDNS) ownedTypes(zone string, state request.Request) []uint16 {
+	types := make([]uint16, 0)
+	if strings.ToLower(state.Name()) == zone {
+		types = append(types, dns.TypeNS, dns.TypeSOA, dns.TypeDNSKEY)
+	}
+	for _, t := range nsecProbeTypes {
+		if t == state.QType() {
+			continue
+		}
+		req := state.Req.Copy()
+		req.Question[0].Qtype = t
+		services, err := r.Records(request.Request{W: state.W, Req: req, Context: state.Context}, false)
+		if err == nil && len(services) > 0 {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Sign adds the DNSSEC records to the response of a name in a signed zone
+func (s *SDnssecSigner) Sign(r *SRegionDNS, state request.Request, m *dns.Msg) *dns.Msg {
+	zone, zk := s.zoneOf(state.Name())
+	if zk == nil {
+		return m
+	}
+	now := time.Now()
+	if len(m.Answer) == 0 && (m.Rcode == dns.RcodeSuccess || m.Rcode == dns.RcodeNameError) {
+		soa, _ := r.zoneSOA(zone, state)
+		m.Rcode = dns.RcodeSuccess
+		m.Ns = []dns.RR{soa, blackLieNSEC(state.Name(), soa.Minttl, r.ownedTypes(zone, state))}
+	}
+	m.Answer = signRRsets(zone, zk, m.Answer, now)
+	m.Ns = signRRsets(zone, zk, m.Ns, now)
+	return m
+}
+
+// zoneSOA is the SOA of the signed zone, which is used in denial of existence
+// instead of the SOA of the server block
+func (r *SRegionDNS) zoneSOA(zone string, state request.Request) (*dns.SOA, error) {
+	minTTL := r.MinTTL(state)
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: minTTL},
+		Mbox:    "hostmaster." + zone,
+		Ns:      defaultNSName + zone,
+		Serial:  r.Serial(state),
+		Refresh: 7200,
+		Retry:   1800,
+		Expire:  86400,
+		Minttl:  minTTL,
+	}, nil
+}
+
+// signingResponseWriter signs the response written by the backend lookups
+type signingResponseWriter struct {
+	dns.ResponseWriter
+	r     *SRegionDNS
+	state request.Request
+}
+
+func (w *signingResponseWriter) WriteMsg(m *dns.Msg) error {
+	// the DO bit tells the client the response is signed
+	w.state.SizeAndDo(m)
+	return w.ResponseWriter.WriteMsg(w.r.Dnssec.Sign(w.r, w.state, m))
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	identity_api "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/identity"
+)
+
+const dnssecKeyListLimit = 1024
+
+// iDnssecKeyStore keeps the dnssec keys of all the signed zones, the replicas
+// of the dns server sign the answers with the same keys
+type iDnssecKeyStore interface {
+	List() ([]*sDnssecKey, error)
+	Save(k *sDnssecKey) error
+	Delete(k *sDnssecKey) error
+}
+
+// sDnssecKeyBlob is a dnssec key saved in the key store
+type sDnssecKeyBlob struct {
+	Zone string `json:"zone"`
+	// the DNSKEY record in presentation format
+	Public string `json:"public"`
+	// the private key in the format of dnssec-keygen
+	Private string    `json:"private"`
+	Created time.Time `json:"created"`
+}
+
+func newDnssecKeyBlob(k *sDnssecKey) sDnssecKeyBlob {
+	return sDnssecKeyBlob{
+		Zone:    k.zone,
+		Public:  k.key.String(),
+		Private: k.key.PrivateKeyString(k.signer),
+		Created: k.created,
+	}
+}
+
+func (b sDnssecKeyBlob) decode(id string) (*sDnssecKey, error) {
+	rr, err := dns.NewRR(b.Public)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse DNSKEY")
+	}
+	key, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, errors.Errorf("%s is not a DNSKEY", rr.Header().String())
+	}
+	priv, err := key.ReadPrivateKey(strings.NewReader(b.Private), b.Zone)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadPrivateKey")
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("private key of %s can't sign", b.Zone)
+	}
+	return &sDnssecKey{
+		id:      id,
+		zone:    b.Zone,
+		key:     key,
+		signer:  signer,
+		created: b.Created,
+	}, nil
+}
+
+// sCredentialKeyStore keeps the dnssec keys as keystone credentials, which
+// are encrypted at rest and shared by all the replicas
+type sCredentialKeyStore struct {
+	getSession func() *mcclient.ClientSession
+}
+
+func (s *sCredentialKeyStore) List() ([]*sDnssecKey, error) {
+	query := jsonutils.NewDict()
+	query.Add(jsonutils.NewString(identity_api.DNSSEC_KEY_TYPE), "type")
+	query.Add(jsonutils.NewString("system"), "scope")
+	query.Add(jsonutils.NewInt(dnssecKeyListLimit), "limit")
+	query.Add(jsonutils.JSONTrue, "details")
+	result, err := identity.Credentials.List(s.getSession(), query)
+	if err != nil {
+		return nil, errors.Wrap(err, "list credentials")
+	}
+	keys := make([]*sDnssecKey, 0, len(result.Data))
+	for _, obj := range result.Data {
+		id, _ := obj.GetString("id")
+		blobStr, _ := obj.GetString("blob")
+		blobJson, err := jsonutils.ParseString(blobStr)
+		if err != nil {
+			log.Errorf("parse dnssec key %s: %v", id, err)
+			continue
+		}
+		blob := sDnssecKeyBlob{}
+		if err := blobJson.Unmarshal(&blob); err != nil {
+			log.Errorf("unmarshal dnssec key %s: %v", id, err)
+			continue
+		}
+		k, err := blob.decode(id)
+		if err != nil {
+			log.Errorf("decode dnssec key %s: %v", id, err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (s *sCredentialKeyStore) Save(k *sDnssecKey) error {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(identity_api.DNSSEC_KEY_TYPE), "type")
+	params.Add(jsonutils.NewString(identity_api.DEFAULT_PROJECT), "project_id")
+	params.Add(jsonutils.NewString(jsonutils.Marshal(newDnssecKeyBlob(k)).String()), "blob")
+	params.Add(jsonutils.NewString(fmt.Sprintf("K%s+%03d+%05d", k.zone, k.key.Algorithm, k.key.KeyTag())), "generate_name")
+	result, err := identity.Credentials.Create(s.getSession(), params)
+	if err != nil {
+		return errors.Wrap(err, "create credential")
+	}
+	k.id, _ = result.GetString("id")
+	return nil
+}
+
+func (s *sCredentialKeyStore) Delete(k *sDnssecKey) error {
+	if _, err := identity.Credentials.Delete(s.getSession(), k.id, nil); err != nil {
+		return errors.Wrapf(err, "delete credential %s", k.id)
+	}
+	return nil
+}
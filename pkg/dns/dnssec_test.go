@@ -0,0 +1,202 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+type sMemKeyStore struct {
+	keys  map[string]*sDnssecKey
+	seq   int
+	saves int
+}
+
+func newMemKeyStore() *sMemKeyStore {
+	return &sMemKeyStore{keys: make(map[string]*sDnssecKey)}
+}
+
+func (s *sMemKeyStore) List() ([]*sDnssecKey, error) {
+	keys := make([]*sDnssecKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (s *sMemKeyStore) Save(k *sDnssecKey) error {
+	// round trip the blob as the credential store does
+	k2, err := newDnssecKeyBlob(k).decode(fmt.Sprintf("key-%d", s.seq))
+	if err != nil {
+		return err
+	}
+	s.seq++
+	s.saves++
+	k.id = k2.id
+	s.keys[k.id] = k2
+	return nil
+}
+
+func (s *sMemKeyStore) Delete(k *sDnssecKey) error {
+	delete(s.keys, k.id)
+	return nil
+}
+
+func TestSignRRsets(t *testing.T) {
+	zone := "example.com."
+	now := time.Now()
+	s := newDnssecSigner(newMemKeyStore(), 0)
+	zk, err := s.loadZoneKeys(zone, nil, now)
+	if err != nil {
+		t.Fatalf("loadZoneKeys: %v", err)
+	}
+	if err := s.rotateZoneKeys(zone, zk, now); err != nil {
+		t.Fatalf("rotateZoneKeys: %v", err)
+	}
+
+	a1, _ := dns.NewRR("www.example.com. 300 IN A 10.0.0.1")
+	a2, _ := dns.NewRR("www.example.com. 300 IN A 10.0.0.2")
+	other, _ := dns.NewRR("www.example.org. 300 IN A 10.0.0.3")
+	rrs := signRRsets(zone, zk, []dns.RR{a1, a2, other}, now)
+	if len(rrs) != 4 {
+		t.Fatalf("want 1 signature of the zone RRset, got %v", rrs)
+	}
+	sig := rrs[3].(*dns.RRSIG)
+	if sig.KeyTag != zk.activeZsk(now).key.KeyTag() || sig.Hdr.Ttl != 300 {
+		t.Errorf("unexpected signature %s", sig)
+	}
+	if err := sig.Verify(zk.activeZsk(now).key, []dns.RR{a1, a2}); err != nil {
+		t.Errorf("verify A RRset: %v", err)
+	}
+
+	dnskeys := zk.dnskeys(zone)
+	signed := signRRsets(zone, zk, dnskeys, now)
+	ksig := signed[len(signed)-1].(*dns.RRSIG)
+	if ksig.KeyTag != zk.ksk.key.KeyTag() {
+		t.Errorf("DNSKEY RRset should be signed by KSK, got key tag %d", ksig.KeyTag)
+	}
+	if err := ksig.Verify(zk.ksk.key, dnskeys); err != nil {
+		t.Errorf("verify DNSKEY RRset: %v", err)
+	}
+}
+
+func TestRotateZoneKeys(t *testing.T) {
+	zone := "example.com."
+	start := time.Now()
+	store := newMemKeyStore()
+	s := newDnssecSigner(store, 24*time.Hour)
+	zk, err := s.loadZoneKeys(zone, nil, start)
+	if err != nil {
+		t.Fatalf("loadZoneKeys: %v", err)
+	}
+	s.rotateZoneKeys(zone, zk, start)
+	first := zk.activeZsk(start)
+
+	// a new key is published after the rotate interval but not signing yet
+	rotated := start.Add(25 * time.Hour)
+	s.rotateZoneKeys(zone, zk, rotated)
+	if len(zk.zsks) != 2 || zk.activeZsk(rotated) != first {
+		t.Fatalf("new ZSK should be published before signing, zsks %d", len(zk.zsks))
+	}
+	if got := len(zk.dnskeys(zone)); got != 3 {
+		t.Errorf("DNSKEY RRset should have KSK and both ZSKs, got %d", got)
+	}
+	second := zk.zsks[1]
+	if active := zk.activeZsk(rotated.Add(zskPublishDelay)); active != second {
+		t.Errorf("new ZSK should sign after publish delay")
+	}
+
+	// the old key is retired after its signatures expired
+	retired := rotated.Add(zskPublishDelay + signatureValidity + time.Minute)
+	s.RotateInterval = 365 * 24 * time.Hour
+	s.rotateZoneKeys(zone, zk, retired)
+	if len(zk.zsks) != 1 || zk.zsks[0] != second {
+		t.Errorf("old ZSK should be retired, zsks %d", len(zk.zsks))
+	}
+	if _, ok := store.keys[first.id]; ok {
+		t.Errorf("retired ZSK should be removed from the store")
+	}
+}
+
+func TestPickZoneKeys(t *testing.T) {
+	now := time.Now()
+	newKey := func(id string, flags uint16, created time.Time) *sDnssecKey {
+		return &sDnssecKey{id: id, key: &dns.DNSKEY{Flags: flags}, created: created}
+	}
+	ksk1 := newKey("b", dnskeyFlagKSK, now)
+	ksk2 := newKey("a", dnskeyFlagKSK, now)
+	zsk1 := newKey("c", dnskeyFlagZSK, now)
+	zsk2 := newKey("d", dnskeyFlagZSK, now.Add(10*time.Second))
+	zsk3 := newKey("e", dnskeyFlagZSK, now.Add(24*time.Hour))
+
+	for _, keys := range [][]*sDnssecKey{
+		{ksk1, ksk2, zsk1, zsk2, zsk3},
+		{zsk3, zsk2, zsk1, ksk2, ksk1},
+	} {
+		zk, dups := pickZoneKeys(keys)
+		if zk.ksk != ksk2 {
+			t.Errorf("KSK with the smaller id should be picked")
+		}
+		if !reflect.DeepEqual(zk.zsks, []*sDnssecKey{zsk1, zsk3}) {
+			t.Errorf("ZSKs generated by racing replicas should be deduplicated")
+		}
+		if len(dups) != 2 {
+			t.Errorf("want 2 duplicated keys, got %d", len(dups))
+		}
+	}
+}
+
+func TestBlackLieNSEC(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []uint16
+		want  []uint16
+	}{
+		{
+			name: "nxdomain",
+			want: []uint16{dns.TypeRRSIG, dns.TypeNSEC},
+		},
+		{
+			name:  "nodata",
+			types: []uint16{dns.TypeTXT, dns.TypeA},
+			want:  []uint16{dns.TypeA, dns.TypeTXT, dns.TypeRRSIG, dns.TypeNSEC},
+		},
+		{
+			name:  "apex",
+			types: []uint16{dns.TypeNS, dns.TypeSOA, dns.TypeDNSKEY, dns.TypeMX},
+			want:  []uint16{dns.TypeNS, dns.TypeSOA, dns.TypeMX, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nsec := blackLieNSEC("www.example.com.", 300, tt.types)
+			if !reflect.DeepEqual(nsec.TypeBitMap, tt.want) {
+				t.Errorf("TypeBitMap = %v, want %v", nsec.TypeBitMap, tt.want)
+			}
+			if nsec.NextDomain != "\\000.www.example.com." {
+				t.Errorf("NextDomain = %s", nsec.NextDomain)
+			}
+			// the bitmap has to be packable
+			if _, err := dns.PackRR(nsec, make([]byte, 512), 0, nil, false); err != nil {
+				t.Errorf("PackRR: %v", err)
+			}
+		})
+	}
+}
@@ -16,6 +16,9 @@ package dns
 
 import (
 	"context"
+	"encoding/base64"
+	"strings"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -28,6 +31,7 @@ import (
 	"yunion.io/x/pkg/util/regutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/modules/identity"
 )
 
@@ -73,6 +77,26 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error(PluginName, err)
 	}
 
+	if rDNS.Dnssec != nil {
+		if err := rDNS.Dnssec.Start(rDNS.PrimaryZone); err != nil {
+			return plugin.Error(PluginName, errors.Wrap(err, "start dnssec signer"))
+		}
+		c.OnShutdown(func() error {
+			rDNS.Dnssec.Stop()
+			return nil
+		})
+	}
+
+	if len(rDNS.TsigKeys) > 0 {
+		if err := rDNS.startUpdateServer(); err != nil {
+			return plugin.Error(PluginName, errors.Wrap(err, "start dns update server"))
+		}
+		c.OnShutdown(func() error {
+			rDNS.stopUpdateServer()
+			return nil
+		})
+	}
+
 	/*if !rDNS.K8sSkip {
 		go rDNS.initK8s()
 	}*/
@@ -160,6 +184,51 @@ func parseConfig(c *caddy.Controller) (*SRegionDNS, error) {
 					rDNS.Upstream = u
 				case "in_cloud_only":
 					rDNS.InCloudOnly = true
+				case "dnssec":
+					// dnssec [ZSK_ROTATE_INTERVAL]
+					args := c.RemainingArgs()
+					if len(args) > 1 {
+						return nil, c.ArgErr()
+					}
+					var interval time.Duration
+					if len(args) == 1 {
+						d, err := time.ParseDuration(args[0])
+						if err != nil {
+							return nil, c.Errf("invalid dnssec rotate interval %q: %v", args[0], err)
+						}
+						interval = d
+					}
+					store := &sCredentialKeyStore{
+						getSession: func() *mcclient.ClientSession {
+							return rDNS.getAdminSession(context.Background())
+						},
+					}
+					rDNS.Dnssec = newDnssecSigner(store, interval)
+				case "tsig":
+					// tsig KEY_NAME BASE64_SECRET [ZONE...]
+					args := c.RemainingArgs()
+					if len(args) < 2 {
+						return nil, c.ArgErr()
+					}
+					if _, err := base64.StdEncoding.DecodeString(args[1]); err != nil {
+						return nil, c.Errf("invalid tsig secret of %s: %v", args[0], err)
+					}
+					key := &sTsigKey{
+						Name:   strings.ToLower(dns.Fqdn(args[0])),
+						Secret: args[1],
+					}
+					for _, zone := range args[2:] {
+						key.Zones = append(key.Zones, strings.ToLower(dns.Fqdn(zone)))
+					}
+					if rDNS.TsigKeys == nil {
+						rDNS.TsigKeys = make(map[string]*sTsigKey)
+					}
+					rDNS.TsigKeys[key.Name] = key
+				case "update_listen":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					rDNS.UpdateListen = c.Val()
 				// case "k8s_skip":
 				//	rDNS.K8sSkip = true
 				default:
@@ -174,5 +243,8 @@ func parseConfig(c *caddy.Controller) (*SRegionDNS, error) {
 			}
 		}
 	}
+	if len(rDNS.TsigKeys) > 0 && len(rDNS.UpdateListen) == 0 {
+		return nil, c.Err("tsig requires update_listen to serve dynamic updates")
+	}
 	return rDNS, nil
 }
@@ -0,0 +1,579 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+)
+
+const tsigFudge = 300
+
+// sTsigKey is a TSIG key allowed to update the given zones, or all the local
+// zones if no zone is given
+type sTsigKey struct {
+	Name   string
+	Secret string
+	Zones  []string
+}
+
+func (k *sTsigKey) allowZone(zone string) bool {
+	if len(k.Zones) == 0 {
+		return true
+	}
+	for _, z := range k.Zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	errTsigUnsigned = errors.Error("update is not signed by TSIG")
+	errTsigBadKey   = errors.Error("unknown TSIG key")
+)
+
+// startUpdateServer serves the dynamic updates on a dedicated listener, the
+// coredns server doesn't keep the raw request for plugins, while the dns
+// server with TsigSecret verifies TSIG against the bytes on the wire
+func (r *SRegionDNS) startUpdateServer() error {
+	secrets := make(map[string]string, len(r.TsigKeys))
+	for name, key := range r.TsigKeys {
+		secrets[name] = key.Secret
+	}
+	pc, err := net.ListenPacket("udp", r.UpdateListen)
+	if err != nil {
+		return errors.Wrapf(err, "listen udp %s", r.UpdateListen)
+	}
+	l, err := net.Listen("tcp", r.UpdateListen)
+	if err != nil {
+		pc.Close()
+		return errors.Wrapf(err, "listen tcp %s", r.UpdateListen)
+	}
+	handler := dns.HandlerFunc(r.serveUpdate)
+	r.updateServers = []*dns.Server{
+		{PacketConn: pc, Net: "udp", Handler: handler, TsigSecret: secrets, MsgAcceptFunc: acceptUpdateMsg},
+		{Listener: l, Net: "tcp", Handler: handler, TsigSecret: secrets, MsgAcceptFunc: acceptUpdateMsg},
+	}
+	for _, srv := range r.updateServers {
+		go func(srv *dns.Server) {
+			if err := srv.ActivateAndServe(); err != nil {
+				log.Errorf("dns update server %s stopped: %v", srv.Net, err)
+			}
+		}(srv)
+	}
+	log.Infof("serve dns dynamic update on %s", r.UpdateListen)
+	return nil
+}
+
+func (r *SRegionDNS) stopUpdateServer() {
+	for _, srv := range r.updateServers {
+		srv.Shutdown()
+	}
+	r.updateServers = nil
+}
+
+// acceptUpdateMsg only accepts the dynamic updates, which are rejected by the
+// default accept func of the dns server as their sections contain many RRs
+func acceptUpdateMsg(dh dns.Header) dns.MsgAcceptAction {
+	if dh.Bits&(1<<15) != 0 {
+		// QR bit of responses
+		return dns.MsgIgnore
+	}
+	if opcode := int(dh.Bits>>11) & 0xF; opcode != dns.OpcodeUpdate {
+		return dns.MsgRejectNotImplemented
+	}
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+	return dns.MsgAccept
+}
+
+// verifyTsig checks the TSIG of the update, the MAC has been verified by the
+// dns server with the raw request
+func (r *SRegionDNS) verifyTsig(w dns.ResponseWriter, req *dns.Msg) (*sTsigKey, *dns.TSIG, error) {
+	t := req.IsTsig()
+	if t == nil {
+		return nil, nil, errTsigUnsigned
+	}
+	key, ok := r.TsigKeys[strings.ToLower(t.Hdr.Name)]
+	if !ok {
+		return nil, t, errors.Wrap(errTsigBadKey, t.Hdr.Name)
+	}
+	if err := w.TsigStatus(); err != nil {
+		return nil, t, errors.Wrapf(err, "TsigVerify %s", key.Name)
+	}
+	return key, t, nil
+}
+
+// serveUpdate handles the dynamic update of RFC 2136, the records of the
+// local dns zones are changed through the region API
+func (r *SRegionDNS) serveUpdate(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+
+	key, t, err := r.verifyTsig(w, req)
+	if err != nil {
+		log.Warningf("%s update refused: %v", w.RemoteAddr(), err)
+		if err == errTsigUnsigned {
+			m.Rcode = dns.RcodeRefused
+		} else {
+			m.Rcode = dns.RcodeNotAuth
+		}
+		w.WriteMsg(m)
+		return
+	}
+
+	m.Rcode = r.applyUpdate(key, req)
+	// the response is signed by the dns server with the MAC of the request
+	m.SetTsig(t.Hdr.Name, t.Algorithm, tsigFudge, time.Now().Unix())
+	if err := w.WriteMsg(m); err != nil {
+		log.Errorf("write update response to %s: %v", w.RemoteAddr(), err)
+	}
+}
+
+// sUpdateZone is the zone being updated with its current records
+type sUpdateZone struct {
+	zone    *models.SDnsZone
+	name    string
+	records []models.SDnsRecord
+}
+
+func (z *sUpdateZone) ownerName(rec *models.SDnsRecord) string {
+	if rec.Name == "@" {
+		return z.name
+	}
+	return strings.ToLower(rec.Name) + "." + z.name
+}
+
+func (z *sUpdateZone) recordName(owner string) string {
+	owner = strings.ToLower(owner)
+	if owner == z.name {
+		return "@"
+	}
+	return strings.TrimSuffix(owner, "."+z.name)
+}
+
+func (z *sUpdateZone) find(owner string, rtype uint16) []models.SDnsRecord {
+	ret := make([]models.SDnsRecord, 0)
+	for _, rec := range z.records {
+		if z.ownerName(&rec) != strings.ToLower(owner) {
+			continue
+		}
+		if rtype != dns.TypeANY && rec.DnsType != dns.TypeToString[rtype] {
+			continue
+		}
+		ret = append(ret, rec)
+	}
+	return ret
+}
+
+func fetchUpdateZone(name string) (*sUpdateZone, error) {
+	zone := &models.SDnsZone{}
+	q := models.DnsZoneManager.Query().Equals("name", strings.TrimSuffix(name, ".")).IsNullOrEmpty("manager_id").IsTrue("enabled")
+	if err := q.First(zone); err != nil {
+		return nil, errors.Wrapf(err, "fetch local dns zone %s", name)
+	}
+	zone.SetModelManager(models.DnsZoneManager, zone)
+
+	records := make([]models.SDnsRecord, 0)
+	rq := models.DnsRecordManager.Query().Equals("dns_zone_id", zone.Id)
+	if err := db.FetchModelObjects(models.DnsRecordManager, rq, &records); err != nil {
+		return nil, errors.Wrap(err, "fetch dns records")
+	}
+	return &sUpdateZone{zone: zone, name: name, records: records}, nil
+}
+
+// updatableTypes are the types which can be saved as dns records
+var updatableTypes = map[uint16]bool{
+	dns.TypeA:     true,
+	dns.TypeAAAA:  true,
+	dns.TypeCNAME: true,
+	dns.TypeMX:    true,
+	dns.TypeTXT:   true,
+	dns.TypeSRV:   true,
+	dns.TypePTR:   true,
+}
+
+// rrToDnsRecord converts the rdata of the RR to the value of a dns record
+func rrToDnsRecord(rr dns.RR) (api.DnsRecordCreateInput, error) {
+	input := api.DnsRecordCreateInput{
+		DnsType: dns.TypeToString[rr.Header().Rrtype],
+		TTL:     int64(rr.Header().Ttl),
+	}
+	switch v := rr.(type) {
+	case *dns.A:
+		input.DnsValue = v.A.String()
+	case *dns.AAAA:
+		input.DnsValue = v.AAAA.String()
+	case *dns.CNAME:
+		input.DnsValue = strings.TrimSuffix(v.Target, ".")
+	case *dns.PTR:
+		input.DnsValue = strings.TrimSuffix(v.Ptr, ".")
+	case *dns.MX:
+		input.DnsValue = strings.TrimSuffix(v.Mx, ".")
+		input.MxPriority = int64(v.Preference)
+	case *dns.TXT:
+		input.DnsValue = strings.Join(v.Txt, "")
+	case *dns.SRV:
+		// priority weight port host
+		input.DnsValue = fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, strings.TrimSuffix(v.Target, "."))
+	default:
+		return input, errors.Errorf("unsupported type %s", input.DnsType)
+	}
+	return input, nil
+}
+
+func isSameRecord(rec *models.SDnsRecord, rr dns.RR) bool {
+	input, err := rrToDnsRecord(rr)
+	if err != nil {
+		return false
+	}
+	if rec.DnsType != input.DnsType || !strings.EqualFold(strings.TrimSuffix(rec.DnsValue, "."), input.DnsValue) {
+		return false
+	}
+	return rec.DnsType != "MX" || rec.MxPriority == input.MxPriority
+}
+
+// checkPrerequisites checks the prerequisite section of RFC 2136 3.2
+func (z *sUpdateZone) checkPrerequisites(prereqs []dns.RR) int {
+	// value dependent RRsets are compared as a whole
+	rrsets := make(map[string][]dns.RR)
+	for _, rr := range prereqs {
+		h := rr.Header()
+		if h.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+		if !dns.IsSubDomain(z.name, strings.ToLower(h.Name)) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case dns.ClassANY:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			if len(z.find(h.Name, h.Rrtype)) == 0 {
+				if h.Rrtype == dns.TypeANY {
+					return dns.RcodeNameError
+				}
+				return dns.RcodeNXRrset
+			}
+		case dns.ClassNONE:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			if len(z.find(h.Name, h.Rrtype)) > 0 {
+				if h.Rrtype == dns.TypeANY {
+					return dns.RcodeYXDomain
+				}
+				return dns.RcodeYXRrset
+			}
+		case dns.ClassINET:
+			key := strings.ToLower(h.Name) + "/" + dns.TypeToString[h.Rrtype]
+			rrsets[key] = append(rrsets[key], rr)
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+	for _, rrset := range rrsets {
+		h := rrset[0].Header()
+		recs := z.find(h.Name, h.Rrtype)
+		if len(recs) != len(rrset) {
+			return dns.RcodeNXRrset
+		}
+		for _, rr := range rrset {
+			found := false
+			for i := range recs {
+				if isSameRecord(&recs[i], rr) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return dns.RcodeNXRrset
+			}
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// prescanUpdates checks the update section of RFC 2136 3.4.1
+func (z *sUpdateZone) prescanUpdates(updates []dns.RR) int {
+	for _, rr := range updates {
+		h := rr.Header()
+		if !dns.IsSubDomain(z.name, strings.ToLower(h.Name)) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case dns.ClassINET:
+			if !updatableTypes[h.Rrtype] {
+				return dns.RcodeRefused
+			}
+		case dns.ClassANY:
+			if h.Ttl != 0 || h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+		case dns.ClassNONE:
+			if h.Ttl != 0 {
+				return dns.RcodeFormatError
+			}
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// iUpdateRecordClient changes the dns records of the zone being updated
+type iUpdateRecordClient interface {
+	Create(input api.DnsRecordCreateInput) (*models.SDnsRecord, error)
+	Delete(rec *models.SDnsRecord) error
+}
+
+type sRegionRecordClient struct {
+	s *mcclient.ClientSession
+}
+
+func (c *sRegionRecordClient) Create(input api.DnsRecordCreateInput) (*models.SDnsRecord, error) {
+	obj, err := modules.DnsRecords.Create(c.s, jsonutils.Marshal(input))
+	if err != nil {
+		return nil, errors.Wrap(err, "create dns record")
+	}
+	rec := &models.SDnsRecord{}
+	if err := obj.Unmarshal(rec); err != nil {
+		return nil, errors.Wrap(err, "unmarshal dns record")
+	}
+	return rec, nil
+}
+
+func (c *sRegionRecordClient) Delete(rec *models.SDnsRecord) error {
+	if _, err := modules.DnsRecords.Delete(c.s, rec.Id, nil); err != nil {
+		return errors.Wrapf(err, "delete dns record %s", rec.Id)
+	}
+	return nil
+}
+
+func (r *SRegionDNS) applyUpdate(key *sTsigKey, req *dns.Msg) int {
+	if len(req.Question) != 1 || req.Question[0].Qtype != dns.TypeSOA {
+		return dns.RcodeFormatError
+	}
+	zoneName := strings.ToLower(dns.Fqdn(req.Question[0].Name))
+	if !key.allowZone(zoneName) {
+		// permission denied of RFC 2136 3.3
+		log.Warningf("TSIG key %s is not allowed to update zone %s", key.Name, zoneName)
+		return dns.RcodeRefused
+	}
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+
+	zone, err := fetchUpdateZone(zoneName)
+	if err != nil {
+		log.Warningf("update zone %s: %v", zoneName, err)
+		return dns.RcodeNotAuth
+	}
+	if rcode := zone.checkPrerequisites(req.Answer); rcode != dns.RcodeSuccess {
+		return rcode
+	}
+	if rcode := zone.prescanUpdates(req.Ns); rcode != dns.RcodeSuccess {
+		return rcode
+	}
+	plan, err := zone.planUpdate(key, req.Ns)
+	if err != nil {
+		log.Warningf("update zone %s: %v", zoneName, err)
+		return dns.RcodeFormatError
+	}
+	cli := &sRegionRecordClient{s: r.getAdminSession(context.Background())}
+	if err := plan.apply(cli); err != nil {
+		log.Errorf("update zone %s: %v", zoneName, err)
+		return dns.RcodeServerFailure
+	}
+	return dns.RcodeSuccess
+}
+
+// sUpdatePlan is the records created and deleted by an update, which is
+// computed on a copy of the zone before any record is changed
+type sUpdatePlan struct {
+	creates []api.DnsRecordCreateInput
+	deletes []models.SDnsRecord
+}
+
+// planUpdate processes the update section of RFC 2136 3.4.2 on a copy of the
+// zone, so an update failing part-way changes nothing
+func (z *sUpdateZone) planUpdate(key *sTsigKey, updates []dns.RR) (*sUpdatePlan, error) {
+	sim := &sUpdateZone{zone: z.zone, name: z.name}
+	sim.records = append(sim.records, z.records...)
+	// records added by the update are keyed by a placeholder id
+	inputs := make(map[string]api.DnsRecordCreateInput)
+	for i, rr := range updates {
+		h := rr.Header()
+		switch h.Class {
+		case dns.ClassINET:
+			if sim.isAddIgnored(rr) {
+				continue
+			}
+			if h.Rrtype == dns.TypeCNAME {
+				// a name has at most one CNAME, the new one replaces the existing
+				sim.remove(sim.find(h.Name, dns.TypeCNAME))
+			}
+			input, err := rrToDnsRecord(rr)
+			if err != nil {
+				return nil, errors.Wrap(err, rr.String())
+			}
+			input.Name = sim.recordName(h.Name)
+			input.DnsZoneId = z.zone.Id
+			input.Description = fmt.Sprintf("dynamic update by TSIG key %s", key.Name)
+			rec := models.SDnsRecord{
+				DnsType:    input.DnsType,
+				DnsValue:   input.DnsValue,
+				TTL:        input.TTL,
+				MxPriority: input.MxPriority,
+			}
+			rec.Id = fmt.Sprintf("update-%d", i)
+			rec.Name = input.Name
+			inputs[rec.Id] = input
+			sim.records = append(sim.records, rec)
+		case dns.ClassANY:
+			sim.remove(sim.find(h.Name, h.Rrtype))
+		case dns.ClassNONE:
+			recs := make([]models.SDnsRecord, 0)
+			for _, rec := range sim.find(h.Name, h.Rrtype) {
+				if isSameRecord(&rec, rr) {
+					recs = append(recs, rec)
+				}
+			}
+			sim.remove(recs)
+		}
+	}
+
+	plan := &sUpdatePlan{}
+	kept := make(map[string]bool, len(sim.records))
+	for _, rec := range sim.records {
+		if input, ok := inputs[rec.Id]; ok {
+			plan.creates = append(plan.creates, input)
+		}
+		kept[rec.Id] = true
+	}
+	for _, rec := range z.records {
+		if !kept[rec.Id] {
+			plan.deletes = append(plan.deletes, rec)
+		}
+	}
+	return plan, nil
+}
+
+// isAddIgnored tells whether adding the RR changes nothing, a CNAME can't
+// coexist with other types and an existing record is not added twice.  A
+// CNAME differing from the existing one is not ignored but replaces it, see
+// RFC 2136 3.4.2.2
+func (z *sUpdateZone) isAddIgnored(rr dns.RR) bool {
+	h := rr.Header()
+	exists := z.find(h.Name, dns.TypeANY)
+	for i := range exists {
+		if (exists[i].DnsType == "CNAME") != (h.Rrtype == dns.TypeCNAME) {
+			return true
+		}
+		if isSameRecord(&exists[i], rr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (z *sUpdateZone) remove(recs []models.SDnsRecord) {
+	removed := make(map[string]bool, len(recs))
+	for _, rec := range recs {
+		removed[rec.Id] = true
+	}
+	records := make([]models.SDnsRecord, 0, len(z.records))
+	for _, rec := range z.records {
+		if !removed[rec.Id] {
+			records = append(records, rec)
+		}
+	}
+	z.records = records
+}
+
+// apply changes the records of the plan, the changes already made are rolled
+// back if any of them fails
+func (p *sUpdatePlan) apply(cli iUpdateRecordClient) error {
+	var (
+		created []*models.SDnsRecord
+		deleted []models.SDnsRecord
+		err     error
+	)
+	for _, input := range p.creates {
+		var rec *models.SDnsRecord
+		rec, err = cli.Create(input)
+		if err != nil {
+			break
+		}
+		created = append(created, rec)
+	}
+	if err == nil {
+		for i := range p.deletes {
+			if err = cli.Delete(&p.deletes[i]); err != nil {
+				break
+			}
+			deleted = append(deleted, p.deletes[i])
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	for i := len(deleted) - 1; i >= 0; i-- {
+		if _, rerr := cli.Create(dnsRecordToInput(&deleted[i])); rerr != nil {
+			log.Errorf("rollback: restore dns record %s(%s): %v", deleted[i].Name, deleted[i].Id, rerr)
+		}
+	}
+	for i := len(created) - 1; i >= 0; i-- {
+		if rerr := cli.Delete(created[i]); rerr != nil {
+			log.Errorf("rollback: delete dns record %s(%s): %v", created[i].Name, created[i].Id, rerr)
+		}
+	}
+	return err
+}
+
+func dnsRecordToInput(rec *models.SDnsRecord) api.DnsRecordCreateInput {
+	input := api.DnsRecordCreateInput{
+		DnsZoneId:   rec.DnsZoneId,
+		DnsType:     rec.DnsType,
+		DnsValue:    rec.DnsValue,
+		TTL:         rec.TTL,
+		MxPriority:  rec.MxPriority,
+		PolicyType:  rec.PolicyType,
+		PolicyValue: rec.PolicyValue,
+	}
+	input.Name = rec.Name
+	input.Description = rec.Description
+	return input
+}
@@ -0,0 +1,299 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+func newTestUpdateRecord(id, name, dnsType, dnsValue string) models.SDnsRecord {
+	rec := models.SDnsRecord{
+		DnsType:  dnsType,
+		DnsValue: dnsValue,
+		TTL:      300,
+	}
+	rec.Id = id
+	rec.Name = name
+	rec.DnsZoneId = "zone1"
+	return rec
+}
+
+func newTestUpdateZone() *sUpdateZone {
+	zone := &models.SDnsZone{}
+	zone.Id = "zone1"
+	return &sUpdateZone{
+		zone: zone,
+		name: "example.com.",
+		records: []models.SDnsRecord{
+			newTestUpdateRecord("r1", "www", "A", "10.0.0.1"),
+			newTestUpdateRecord("r2", "www", "A", "10.0.0.2"),
+			newTestUpdateRecord("r3", "alias", "CNAME", "www.example.com"),
+			newTestUpdateRecord("r4", "@", "TXT", "v=spf1 -all"),
+		},
+	}
+}
+
+// mustRR parses the RR, "NAME ANY TYPE" deletes the RRset of RFC 2136 2.5.2
+func mustRR(t *testing.T, s string) dns.RR {
+	if fields := strings.Fields(s); len(fields) == 3 && fields[1] == "ANY" {
+		return &dns.ANY{Hdr: dns.RR_Header{Name: fields[0], Rrtype: dns.StringToType[fields[2]], Class: dns.ClassANY}}
+	}
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("NewRR %q: %v", s, err)
+	}
+	return rr
+}
+
+func TestPlanUpdate(t *testing.T) {
+	key := &sTsigKey{Name: "key."}
+	tests := []struct {
+		name    string
+		updates []string
+		creates []string
+		deletes []string
+	}{
+		{
+			name:    "add",
+			updates: []string{"new.example.com. 60 IN A 10.0.0.9"},
+			creates: []string{"new A 10.0.0.9"},
+		},
+		{
+			name:    "add existing and conflicting cname",
+			updates: []string{"www.example.com. 60 IN A 10.0.0.1", "www.example.com. 60 IN CNAME other.example.com."},
+		},
+		{
+			name:    "replace cname",
+			updates: []string{"alias.example.com. 60 IN CNAME other.example.com."},
+			creates: []string{"alias CNAME other.example.com"},
+			deletes: []string{"r3"},
+		},
+		{
+			name:    "add existing cname",
+			updates: []string{"alias.example.com. 60 IN CNAME www.example.com."},
+		},
+		{
+			name:    "delete rrset",
+			updates: []string{"www.example.com. ANY A"},
+			deletes: []string{"r1", "r2"},
+		},
+		{
+			name:    "delete name",
+			updates: []string{"example.com. ANY ANY"},
+			deletes: []string{"r4"},
+		},
+		{
+			name:    "delete rr",
+			updates: []string{"www.example.com. 0 NONE A 10.0.0.2"},
+			deletes: []string{"r2"},
+		},
+		{
+			name:    "add then delete",
+			updates: []string{"new.example.com. 60 IN A 10.0.0.9", "new.example.com. ANY A"},
+		},
+		{
+			name:    "replace",
+			updates: []string{"www.example.com. ANY A", "www.example.com. 60 IN A 10.0.0.3"},
+			creates: []string{"www A 10.0.0.3"},
+			deletes: []string{"r1", "r2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newTestUpdateZone()
+			updates := make([]dns.RR, 0)
+			for _, u := range tt.updates {
+				updates = append(updates, mustRR(t, u))
+			}
+			plan, err := zone.planUpdate(key, updates)
+			if err != nil {
+				t.Fatalf("planUpdate: %v", err)
+			}
+			creates := make([]string, 0)
+			for _, input := range plan.creates {
+				creates = append(creates, fmt.Sprintf("%s %s %s", input.Name, input.DnsType, input.DnsValue))
+			}
+			deletes := make([]string, 0)
+			for _, rec := range plan.deletes {
+				deletes = append(deletes, rec.Id)
+			}
+			if fmt.Sprint(creates) != fmt.Sprint(append([]string{}, tt.creates...)) {
+				t.Errorf("creates = %v, want %v", creates, tt.creates)
+			}
+			if fmt.Sprint(deletes) != fmt.Sprint(append([]string{}, tt.deletes...)) {
+				t.Errorf("deletes = %v, want %v", deletes, tt.deletes)
+			}
+			if len(zone.records) != 4 {
+				t.Errorf("planning should not change the zone")
+			}
+		})
+	}
+}
+
+type sFakeRecordClient struct {
+	records map[string]models.SDnsRecord
+	seq     int
+	// the operation failing, counted from 1
+	failAt int
+	ops    int
+}
+
+func (c *sFakeRecordClient) fail() error {
+	c.ops++
+	if c.ops == c.failAt {
+		return fmt.Errorf("operation %d failed", c.ops)
+	}
+	return nil
+}
+
+func (c *sFakeRecordClient) Create(input api.DnsRecordCreateInput) (*models.SDnsRecord, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	c.seq++
+	rec := newTestUpdateRecord(fmt.Sprintf("n%d", c.seq), input.Name, input.DnsType, input.DnsValue)
+	c.records[rec.Id] = rec
+	return &rec, nil
+}
+
+func (c *sFakeRecordClient) Delete(rec *models.SDnsRecord) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	delete(c.records, rec.Id)
+	return nil
+}
+
+func (c *sFakeRecordClient) values() map[string]bool {
+	ret := make(map[string]bool)
+	for _, rec := range c.records {
+		ret[fmt.Sprintf("%s %s %s", rec.Name, rec.DnsType, rec.DnsValue)] = true
+	}
+	return ret
+}
+
+func TestUpdatePlanApply(t *testing.T) {
+	zone := newTestUpdateZone()
+	plan, err := zone.planUpdate(&sTsigKey{Name: "key."}, []dns.RR{
+		mustRR(t, "www.example.com. ANY A"),
+		mustRR(t, "www.example.com. 60 IN A 10.0.0.3"),
+		mustRR(t, "mail.example.com. 60 IN MX 10 mx.example.com."),
+	})
+	if err != nil {
+		t.Fatalf("planUpdate: %v", err)
+	}
+	// 2 creates and 2 deletes
+	for failAt := 0; failAt <= 4; failAt++ {
+		cli := &sFakeRecordClient{records: make(map[string]models.SDnsRecord), failAt: failAt}
+		for _, rec := range zone.records {
+			cli.records[rec.Id] = rec
+		}
+		before := cli.values()
+		err := plan.apply(cli)
+		after := cli.values()
+		if failAt == 0 {
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if after["www A 10.0.0.1"] || !after["www A 10.0.0.3"] || !after["mail MX mx.example.com"] {
+				t.Errorf("update not applied: %v", after)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("fail at %d: apply should fail", failAt)
+		}
+		if fmt.Sprint(after) != fmt.Sprint(before) {
+			t.Errorf("fail at %d: changes not rolled back, %v, want %v", failAt, after, before)
+		}
+	}
+}
+
+func TestServeUpdateTsig(t *testing.T) {
+	const (
+		keyName = "update-key."
+		secret  = "c2VjcmV0LXNoYXJlZC13aXRoLWNlcnQtbWFuYWdlcg=="
+	)
+	r := New()
+	r.UpdateListen = "127.0.0.1:0"
+	r.TsigKeys = map[string]*sTsigKey{
+		keyName: {Name: keyName, Secret: secret, Zones: []string{"allowed.com."}},
+	}
+	if err := r.startUpdateServer(); err != nil {
+		t.Fatalf("startUpdateServer: %v", err)
+	}
+	defer r.stopUpdateServer()
+	addr := r.updateServers[0].PacketConn.LocalAddr().String()
+
+	newUpdate := func(zone string) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetUpdate(zone)
+		m.Insert([]dns.RR{mustRR(t, "www."+zone+" 60 IN A 10.0.0.1")})
+		return m
+	}
+	tests := []struct {
+		name   string
+		secret string
+		signed bool
+		rcode  int
+	}{
+		// the key is verified but not allowed to update the zone
+		{"signed", secret, true, dns.RcodeRefused},
+		{"unsigned", "", false, dns.RcodeRefused},
+		{"bad secret", "YmFkLXNlY3JldA==", true, dns.RcodeNotAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpdate("other.com.")
+			c := new(dns.Client)
+			if tt.signed {
+				m.SetTsig(keyName, dns.HmacSHA256, tsigFudge, time.Now().Unix())
+				c.TsigSecret = map[string]string{keyName: tt.secret}
+			}
+			resp, _, err := c.Exchange(m, addr)
+			if err != nil && !(tt.name == "bad secret" && err == dns.ErrSig) {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if resp == nil {
+				t.Fatalf("no response")
+			}
+			if resp.Rcode != tt.rcode {
+				t.Errorf("rcode = %s, want %s", dns.RcodeToString[resp.Rcode], dns.RcodeToString[tt.rcode])
+			}
+			if tt.name == "signed" && resp.IsTsig() == nil {
+				t.Errorf("response of verified update should be signed")
+			}
+		})
+	}
+
+	// queries are not served on the update listener
+	q := new(dns.Msg)
+	q.SetQuestion("www.allowed.com.", dns.TypeA)
+	resp, _, err := new(dns.Client).Exchange(q, addr)
+	if err != nil {
+		t.Fatalf("Exchange query: %v", err)
+	}
+	if resp.Rcode != dns.RcodeNotImplemented {
+		t.Errorf("query rcode = %s, want NOTIMP", dns.RcodeToString[resp.Rcode])
+	}
+}
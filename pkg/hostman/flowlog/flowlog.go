@@ -0,0 +1,346 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/secrules"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	storeBatch   = 1000
+
+	collectorStateFile = "collector.state"
+)
+
+// SFlowLogCollector collects the acl logs of ovn-controller into flow records
+// stored on disk
+type SFlowLogCollector struct {
+	path  string
+	store *sFlowLogStore
+
+	started bool
+	file    *os.File
+	reader  *bufio.Reader
+	inode   uint64
+	offset  int64
+	saved   sCollectorState
+
+	stop chan struct{}
+}
+
+var flowLogCollector *SFlowLogCollector
+
+func Init() {
+	if flowLogCollector == nil && options.HostOptions.FlowLogRetentionDays > 0 {
+		flowLogCollector = NewFlowLogCollector(
+			options.HostOptions.OvnControllerLogPath,
+			options.HostOptions.FlowLogPath,
+			options.HostOptions.FlowLogRetentionDays,
+		)
+	}
+}
+
+func Start() {
+	if flowLogCollector != nil {
+		go flowLogCollector.Start()
+	}
+}
+
+func Stop() {
+	if flowLogCollector != nil {
+		flowLogCollector.Stop()
+	}
+}
+
+// Query returns the latest flow records of the given mac addresses
+func Query(macs []string, input *api.ServerFlowLogInput) []api.ServerFlowLog {
+	if flowLogCollector == nil {
+		return []api.ServerFlowLog{}
+	}
+	return flowLogCollector.Query(macs, input)
+}
+
+func NewFlowLogCollector(path string, storePath string, retentionDays int) *SFlowLogCollector {
+	return &SFlowLogCollector{
+		path:  path,
+		store: newFlowLogStore(storePath, retentionDays),
+		stop:  make(chan struct{}),
+	}
+}
+
+func (c *SFlowLogCollector) Start() {
+	tick := time.NewTicker(pollInterval)
+	defer tick.Stop()
+	defer c.close()
+	defer c.store.close()
+
+	for {
+		if err := c.collect(); err != nil {
+			log.Debugf("flowlog: collect %s: %v", c.path, err)
+		}
+		select {
+		case <-tick.C:
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *SFlowLogCollector) Stop() {
+	close(c.stop)
+}
+
+func (c *SFlowLogCollector) close() {
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+		c.reader = nil
+	}
+}
+
+// open opens the log file.  On start, reading resumes from the position saved
+// by last run, and the unread tail of the file rotated meanwhile is collected
+// first.  A file rotated while running is read to its end before the new one
+// is opened from start
+func (c *SFlowLogCollector) open() error {
+	fi, err := os.Stat(c.path)
+	if err != nil {
+		c.close()
+		c.offset = 0
+		return errors.Wrap(err, "stat")
+	}
+	if c.file != nil {
+		cur, err := c.file.Stat()
+		if err == nil && os.SameFile(fi, cur) && fi.Size() >= c.offset {
+			return nil
+		}
+		if err == nil && !os.SameFile(fi, cur) {
+			if err := c.drain(); err != nil {
+				log.Errorf("flowlog: read rotated %s: %v", c.path, err)
+			}
+		}
+		c.close()
+		c.offset = 0
+	}
+	if !c.started {
+		c.started = true
+		c.offset = c.resume(fi)
+	}
+
+	if err := c.openFile(c.path, c.offset); err != nil {
+		return err
+	}
+	c.saveState()
+	return nil
+}
+
+// resume returns the offset to read the log file from on start
+func (c *SFlowLogCollector) resume(fi os.FileInfo) int64 {
+	state, err := c.loadState()
+	if err != nil {
+		if !os.IsNotExist(errors.Cause(err)) {
+			log.Errorf("flowlog: load state: %v", err)
+		}
+		// logs before the collector is ever started are skipped
+		return fi.Size()
+	}
+	if fileInode(fi) == state.Inode {
+		if fi.Size() >= state.Offset {
+			return state.Offset
+		}
+		return 0
+	}
+	rotated, _ := filepath.Glob(c.path + "*")
+	for _, path := range rotated {
+		rfi, err := os.Stat(path)
+		if err != nil || fileInode(rfi) != state.Inode || rfi.Size() < state.Offset {
+			continue
+		}
+		if err := c.openFile(path, state.Offset); err != nil {
+			log.Errorf("flowlog: %v", err)
+			break
+		}
+		c.offset = state.Offset
+		if err := c.drain(); err != nil {
+			log.Errorf("flowlog: read rotated %s: %v", path, err)
+		}
+		c.close()
+		break
+	}
+	return 0
+}
+
+func (c *SFlowLogCollector) openFile(path string, offset int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "open")
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return errors.Wrap(err, "seek")
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrap(err, "stat")
+	}
+	c.file = file
+	c.inode = fileInode(fi)
+	c.reader = bufio.NewReader(file)
+	return nil
+}
+
+func (c *SFlowLogCollector) collect() error {
+	if err := c.open(); err != nil {
+		return err
+	}
+	return c.drain()
+}
+
+// drain collects the lines of the opened file till its end
+func (c *SFlowLogCollector) drain() error {
+	records := []*api.ServerFlowLog{}
+	for {
+		if len(records) >= storeBatch {
+			c.save(records)
+			records = records[:0]
+		}
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.save(records)
+			// partial line is read again on next poll
+			if err == io.EOF {
+				c.reader.Reset(c.file)
+				_, err = c.file.Seek(c.offset, io.SeekStart)
+				return err
+			}
+			return errors.Wrap(err, "read")
+		}
+		c.offset += int64(len(line))
+		if record, ok := parseAclLog(strings.TrimSpace(line)); ok {
+			records = append(records, record)
+		}
+	}
+}
+
+func (c *SFlowLogCollector) save(records []*api.ServerFlowLog) {
+	if err := c.store.append(records); err != nil {
+		log.Errorf("flowlog: store %d records: %v", len(records), err)
+		return
+	}
+	c.saveState()
+}
+
+// sCollectorState is the position of the log file collected, saved in the
+// store directory so logs written while the agent is down are not lost
+type sCollectorState struct {
+	Inode  uint64
+	Offset int64
+}
+
+func (c *SFlowLogCollector) statePath() string {
+	return filepath.Join(c.store.dir, collectorStateFile)
+}
+
+func (c *SFlowLogCollector) loadState() (*sCollectorState, error) {
+	content, err := os.ReadFile(c.statePath())
+	if err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+	obj, err := jsonutils.Parse(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse")
+	}
+	state := &sCollectorState{}
+	if err := obj.Unmarshal(state); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	return state, nil
+}
+
+func (c *SFlowLogCollector) saveState() {
+	state := sCollectorState{Inode: c.inode, Offset: c.offset}
+	if state == c.saved {
+		return
+	}
+	if err := os.MkdirAll(c.store.dir, 0755); err != nil {
+		log.Errorf("flowlog: mkdir %s: %v", c.store.dir, err)
+		return
+	}
+	path := c.statePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(jsonutils.Marshal(state).String()), 0644); err != nil {
+		log.Errorf("flowlog: write %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Errorf("flowlog: rename %s: %v", tmp, err)
+		return
+	}
+	c.saved = state
+}
+
+func fileInode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return st.Ino
+	}
+	return 0
+}
+
+// Query returns the flow records of the given mac addresses, latest first
+func (c *SFlowLogCollector) Query(macs []string, input *api.ServerFlowLogInput) []api.ServerFlowLog {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = api.FLOW_LOG_DEFAULT_LIMIT
+	}
+	macSet := map[string]struct{}{}
+	for _, mac := range macs {
+		macSet[strings.ToLower(mac)] = struct{}{}
+	}
+
+	ret, err := c.store.query(input.Since, limit, func(record *api.ServerFlowLog) bool {
+		if input.Verdict != "" && record.Verdict != input.Verdict {
+			return false
+		}
+		if _, ok := macSet[record.DstMac]; ok {
+			record.Direction = string(secrules.SecurityRuleIngress)
+		} else if _, ok := macSet[record.SrcMac]; ok {
+			record.Direction = string(secrules.SecurityRuleEgress)
+		} else {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		log.Errorf("flowlog: query: %v", err)
+		return []api.ServerFlowLog{}
+	}
+	return ret
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestFlowLogCollector(t *testing.T) {
+	dir, err := os.MkdirTemp("", "flowlog")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "ovn-controller.log")
+	storePath := filepath.Join(dir, "store")
+	write := func(path string, ports ...int) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer f.Close()
+		for _, port := range ports {
+			fmt.Fprintf(f, "2021-06-17T09:%02d:00.000Z|00025|acl_log(ovn_pinctrl0)|INFO|name=\"<unnamed>\", verdict=allow, severity=info: tcp,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=10.0.0.1,nw_dst=10.0.0.2,tp_src=%d,tp_dst=22\n", port, port)
+		}
+	}
+	rotate := func(suffix string) {
+		if err := os.Rename(logPath, logPath+suffix); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+	}
+	collect := func(c *SFlowLogCollector) {
+		if err := c.collect(); err != nil {
+			t.Fatalf("collect: %v", err)
+		}
+	}
+	check := func(c *SFlowLogCollector, want ...int) {
+		got := c.Query([]string{"00:22:00:00:00:01"}, &api.ServerFlowLogInput{})
+		ports := []int{}
+		for i := range got {
+			ports = append(ports, got[i].SrcPort)
+		}
+		if fmt.Sprint(ports) != fmt.Sprint(want) {
+			t.Fatalf("got ports %v, want %v", ports, want)
+		}
+	}
+
+	write(logPath, 1)
+	c := NewFlowLogCollector(logPath, storePath, 7)
+	collect(c)
+	// logs before the collector is ever started are skipped
+	check(c)
+	write(logPath, 2)
+	collect(c)
+	check(c, 2)
+	c.close()
+	c.store.close()
+
+	// logs written while the collector is down, before and after rotation
+	write(logPath, 3)
+	rotate(".1")
+	write(logPath, 4)
+	c = NewFlowLogCollector(logPath, storePath, 7)
+	collect(c)
+	check(c, 4, 3, 2)
+
+	// rotated while running
+	write(logPath, 5)
+	rotate(".2")
+	write(logPath, 6)
+	collect(c)
+	collect(c)
+	check(c, 6, 5, 4, 3, 2)
+	c.close()
+	c.store.close()
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+const (
+	aclLogModule  = "|acl_log("
+	aclLogUnnamed = "<unnamed>"
+
+	ovsLogTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// parseAclLog parses the acl log line of ovn-controller, e.g.
+//
+//	2021-06-17T09:11:58.545Z|00025|acl_log(ovn_pinctrl0)|INFO|name="rule-id", verdict=allow, severity=info, direction=to-lport: tcp,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=10.0.0.1,nw_dst=10.0.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=39754,tp_dst=22,tcp_flags=syn
+//
+// The direction of the record is left to be decided by the querier
+func parseAclLog(line string) (*api.ServerFlowLog, bool) {
+	if !strings.Contains(line, aclLogModule) {
+		return nil, false
+	}
+	parts := strings.SplitN(line, "|", 5)
+	if len(parts) != 5 {
+		return nil, false
+	}
+	t, err := time.Parse(ovsLogTimeFormat, parts[0])
+	if err != nil {
+		return nil, false
+	}
+	msg := strings.SplitN(parts[4], ": ", 2)
+	if len(msg) != 2 {
+		return nil, false
+	}
+
+	record := &api.ServerFlowLog{
+		Time: t,
+	}
+	for _, field := range strings.Split(msg[0], ", ") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "name":
+			if name := strings.Trim(kv[1], `"`); name != aclLogUnnamed {
+				record.SecgroupRuleId = name
+			}
+		case "verdict":
+			record.Verdict = kv[1]
+		case "severity":
+			record.Severity = kv[1]
+		}
+	}
+
+	flow := strings.Split(strings.TrimSpace(msg[1]), ",")
+	record.Protocol = flow[0]
+	for _, field := range flow[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "dl_src":
+			record.SrcMac = kv[1]
+		case "dl_dst":
+			record.DstMac = kv[1]
+		case "nw_src", "ipv6_src":
+			record.SrcIp = kv[1]
+		case "nw_dst", "ipv6_dst":
+			record.DstIp = kv[1]
+		case "tp_src":
+			record.SrcPort, _ = strconv.Atoi(kv[1])
+		case "tp_dst":
+			record.DstPort, _ = strconv.Atoi(kv[1])
+		}
+	}
+	if record.Verdict == "" || record.SrcMac == "" || record.DstMac == "" {
+		return nil, false
+	}
+	return record, true
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestParseAclLog(t *testing.T) {
+	cases := []struct {
+		line string
+		want *api.ServerFlowLog
+	}{
+		{
+			line: `2021-06-17T09:11:58.545Z|00025|acl_log(ovn_pinctrl0)|INFO|name="b3c3a1f2-8c43-4b4f-8b0c-3c2c5e0d4b1a", verdict=allow, severity=info: tcp,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=10.0.0.1,nw_dst=10.0.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=39754,tp_dst=22,tcp_flags=syn`,
+			want: &api.ServerFlowLog{
+				Time:           time.Date(2021, 6, 17, 9, 11, 58, 545000000, time.UTC),
+				SecgroupRuleId: "b3c3a1f2-8c43-4b4f-8b0c-3c2c5e0d4b1a",
+				Verdict:        "allow",
+				Severity:       "info",
+				Protocol:       "tcp",
+				SrcMac:         "00:22:00:00:00:01",
+				DstMac:         "00:22:00:00:00:02",
+				SrcIp:          "10.0.0.1",
+				DstIp:          "10.0.0.2",
+				SrcPort:        39754,
+				DstPort:        22,
+			},
+		},
+		{
+			line: `2021-06-17T09:12:00.001Z|00026|acl_log(ovn_pinctrl0)|INFO|name="<unnamed>", verdict=drop, severity=warning, direction=to-lport: icmp6,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,ipv6_src=fc00::1,ipv6_dst=fc00::2,ipv6_label=0x00000,nw_tos=0,nw_ecn=0,nw_ttl=64,icmp_type=128,icmp_code=0`,
+			want: &api.ServerFlowLog{
+				Time:     time.Date(2021, 6, 17, 9, 12, 0, 1000000, time.UTC),
+				Verdict:  "drop",
+				Severity: "warning",
+				Protocol: "icmp6",
+				SrcMac:   "00:22:00:00:00:01",
+				DstMac:   "00:22:00:00:00:02",
+				SrcIp:    "fc00::1",
+				DstIp:    "fc00::2",
+			},
+		},
+		{
+			line: `2021-06-17T09:12:00.001Z|00027|binding|INFO|Claiming lport vm1 for this chassis.`,
+		},
+	}
+	for _, c := range cases {
+		got, ok := parseAclLog(c.line)
+		if c.want == nil {
+			if ok {
+				t.Errorf("want not parsed, got %#v", got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("not parsed: %s", c.line)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("got %#v, want %#v", got, c.want)
+		}
+	}
+}
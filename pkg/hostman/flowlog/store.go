@@ -0,0 +1,280 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+const (
+	storeFilePrefix = "flowlog-"
+	storeFileSuffix = ".json"
+	storeDayLayout  = "20060102"
+	storeReadChunk  = 64 * 1024
+)
+
+// sFlowLogStore keeps flow records on disk in daily files of json lines.
+// Files of days older than retention days are removed
+type sFlowLogStore struct {
+	dir           string
+	retentionDays int
+
+	lock   *sync.Mutex
+	day    string
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func newFlowLogStore(dir string, retentionDays int) *sFlowLogStore {
+	return &sFlowLogStore{
+		dir:           dir,
+		retentionDays: retentionDays,
+		lock:          new(sync.Mutex),
+	}
+}
+
+func storeFileName(day string) string {
+	return storeFilePrefix + day + storeFileSuffix
+}
+
+// append writes records to the files of the days they happened
+func (s *sFlowLogStore) append(records []*api.ServerFlowLog) error {
+	if len(records) == 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, record := range records {
+		if err := s.openDay(record.Time); err != nil {
+			return err
+		}
+		if _, err := s.writer.WriteString(jsonutils.Marshal(record).String() + "\n"); err != nil {
+			return errors.Wrapf(err, "write %s", s.file.Name())
+		}
+	}
+	return s.flush()
+}
+
+func (s *sFlowLogStore) openDay(t time.Time) error {
+	day := t.UTC().Format(storeDayLayout)
+	if s.file != nil && s.day == day {
+		return nil
+	}
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", s.dir)
+	}
+	path := filepath.Join(s.dir, storeFileName(day))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", path)
+	}
+	s.day = day
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.removeExpired(t)
+	return nil
+}
+
+func (s *sFlowLogStore) flush() error {
+	if s.writer == nil {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return errors.Wrapf(err, "flush %s", s.file.Name())
+	}
+	return nil
+}
+
+func (s *sFlowLogStore) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.flush()
+	s.file.Close()
+	s.file = nil
+	s.writer = nil
+	return err
+}
+
+func (s *sFlowLogStore) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closeFile()
+}
+
+// days returns the days of files stored, latest first
+func (s *sFlowLogStore) days() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "read dir %s", s.dir)
+	}
+	days := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, storeFilePrefix) || !strings.HasSuffix(name, storeFileSuffix) {
+			continue
+		}
+		days = append(days, strings.TrimSuffix(strings.TrimPrefix(name, storeFilePrefix), storeFileSuffix))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	return days, nil
+}
+
+func (s *sFlowLogStore) removeExpired(now time.Time) {
+	days, err := s.days()
+	if err != nil {
+		log.Errorf("flowlog: %v", err)
+		return
+	}
+	expire := now.UTC().AddDate(0, 0, -s.retentionDays).Format(storeDayLayout)
+	for _, day := range days {
+		if day >= expire {
+			continue
+		}
+		path := filepath.Join(s.dir, storeFileName(day))
+		if err := os.Remove(path); err != nil {
+			log.Errorf("flowlog: remove expired %s: %v", path, err)
+		}
+	}
+}
+
+// query returns at most limit records accepted by filter, latest first.
+// filter may update the record passed in
+func (s *sFlowLogStore) query(since time.Time, limit int, filter func(record *api.ServerFlowLog) bool) ([]api.ServerFlowLog, error) {
+	s.lock.Lock()
+	days, err := s.days()
+	s.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []api.ServerFlowLog{}
+	sinceDay := ""
+	if !since.IsZero() {
+		sinceDay = since.UTC().Format(storeDayLayout)
+	}
+	for _, day := range days {
+		if day < sinceDay {
+			break
+		}
+		records, err := s.readDay(day, since, limit-len(ret), filter)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, records...)
+		if len(ret) >= limit {
+			break
+		}
+	}
+	return ret, nil
+}
+
+// readDay returns at most limit records of the day accepted by filter.  The
+// file is read backward in chunks so only the latest lines needed are read
+func (s *sFlowLogStore) readDay(day string, since time.Time, limit int, filter func(record *api.ServerFlowLog) bool) ([]api.ServerFlowLog, error) {
+	path := filepath.Join(s.dir, storeFileName(day))
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// removed as expired
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+	defer file.Close()
+
+	records := []api.ServerFlowLog{}
+	err = readLinesBackward(file, func(line []byte) bool {
+		obj, err := jsonutils.Parse(line)
+		if err != nil {
+			// the line being written
+			return true
+		}
+		record := api.ServerFlowLog{}
+		if err := obj.Unmarshal(&record); err != nil {
+			return true
+		}
+		if !since.IsZero() && record.Time.Before(since) {
+			return true
+		}
+		if filter(&record) {
+			records = append(records, record)
+		}
+		return len(records) < limit
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", path)
+	}
+	return records, nil
+}
+
+// readLinesBackward calls fn with the lines of file from the last one until
+// fn returns false
+func readLinesBackward(file *os.File, fn func(line []byte) bool) error {
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	offset := fi.Size()
+	chunk := make([]byte, storeReadChunk)
+	// the head of the line crossing the chunk boundary, read in the next chunk
+	tail := []byte{}
+	for offset > 0 {
+		size := int64(len(chunk))
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		if _, err := file.ReadAt(chunk[:size], offset); err != nil {
+			return err
+		}
+		buf := append(append([]byte{}, chunk[:size]...), tail...)
+		for {
+			idx := bytes.LastIndexByte(buf, '\n')
+			if idx < 0 {
+				break
+			}
+			if line := buf[idx+1:]; len(line) > 0 && !fn(line) {
+				return nil
+			}
+			buf = buf[:idx]
+		}
+		tail = buf
+	}
+	if len(tail) > 0 {
+		fn(tail)
+	}
+	return nil
+}
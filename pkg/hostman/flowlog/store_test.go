@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestFlowLogStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "flowlog")
+	if err != nil {
+		t.Fatalf("mkdir temp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	day0 := time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC)
+	day1 := day0.Add(2 * time.Hour)
+	record := func(t time.Time, verdict string) *api.ServerFlowLog {
+		return &api.ServerFlowLog{
+			Time:    t,
+			Verdict: verdict,
+			SrcMac:  "00:22:00:00:00:01",
+		}
+	}
+	all := func(record *api.ServerFlowLog) bool { return true }
+
+	store := newFlowLogStore(dir, 7)
+	if err := store.append([]*api.ServerFlowLog{
+		record(day0, "allow"),
+		record(day0.Add(time.Minute), "drop"),
+		record(day1, "allow"),
+	}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	t.Run("latest first", func(t *testing.T) {
+		got, err := store.query(time.Time{}, 10, all)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if len(got) != 3 || !got[0].Time.Equal(day1) || !got[2].Time.Equal(day0) {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		got, _ := store.query(time.Time{}, 2, all)
+		if len(got) != 2 || got[1].Verdict != "drop" {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("since and filter", func(t *testing.T) {
+		got, _ := store.query(day0.Add(time.Second), 10, func(record *api.ServerFlowLog) bool {
+			record.Direction = "out"
+			return record.Verdict == "allow"
+		})
+		if len(got) != 1 || !got[0].Time.Equal(day1) || got[0].Direction != "out" {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("partial line", func(t *testing.T) {
+		store.close()
+		path := filepath.Join(dir, storeFileName(day1.Format(storeDayLayout)))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		f.WriteString(`{"time":"2021-06-02T01:00:00Z","verd`)
+		f.Close()
+		got, _ := store.query(time.Time{}, 10, all)
+		if len(got) != 3 {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		later := day1.AddDate(0, 0, 7)
+		if err := store.append([]*api.ServerFlowLog{record(later, "allow")}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		days, _ := store.days()
+		want := []string{later.Format(storeDayLayout), day1.Format(storeDayLayout)}
+		if len(days) != 2 || days[0] != want[0] || days[1] != want[1] {
+			t.Errorf("days %v, want %v", days, want)
+		}
+	})
+}
+
+func TestReadLinesBackward(t *testing.T) {
+	f, err := os.CreateTemp("", "flowlog")
+	if err != nil {
+		t.Fatalf("create temp: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// lines crossing chunk boundaries and a partial last line
+	count := storeReadChunk/7 + 10
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(f, "%06d\n", i)
+	}
+	f.WriteString("partial")
+
+	got := []string{}
+	err = readLinesBackward(f, func(line []byte) bool {
+		got = append(got, string(line))
+		return len(got) < count
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != count || got[0] != "partial" || got[1] != fmt.Sprintf("%06d", count-1) || got[count-1] != "000001" {
+		t.Errorf("got %d lines, first %v, last %v", len(got), got[:2], got[len(got)-1])
+	}
+
+	got = got[:0]
+	readLinesBackward(f, func(line []byte) bool {
+		got = append(got, string(line))
+		return true
+	})
+	for i, line := range got[1:] {
+		if want := fmt.Sprintf("%06d", count-1-i); line != want {
+			t.Fatalf("line %d: got %q, want %q", i, line, want)
+		}
+	}
+}
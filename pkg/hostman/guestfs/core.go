@@ -153,6 +153,9 @@ func DoDeployGuestFs(rootfs fsdriver.IRootFsDriver, guestDesc *deployapi.GuestDe
 			return nil, errors.Wrap(err, "DeployFstabScripts")
 		}
 	}
+	if err := rootfs.DeployVirtiofsMounts(partition, guestDesc.Filesystems); err != nil {
+		return nil, errors.Wrap(err, "DeployVirtiofsMounts")
+	}
 
 	if len(deployInfo.Password) > 0 {
 		account, err := rootfs.GetLoginAccount(partition, deployInfo.LoginAccount,
@@ -116,6 +116,10 @@ func (d *sGuestRootFsDriver) DeployFstabScripts(_ IDiskPartition, _ []*deployapi
 	return nil
 }
 
+func (d *sGuestRootFsDriver) DeployVirtiofsMounts(_ IDiskPartition, _ []*deployapi.Filesystem) error {
+	return nil
+}
+
 func (d *sGuestRootFsDriver) EnableSerialConsole(rootfs IDiskPartition, sysInfo *jsonutils.JSONDict) error {
 	return nil
 }
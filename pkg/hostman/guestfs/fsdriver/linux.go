@@ -383,6 +383,39 @@ func (l *sLinuxRootFs) DeployFstabScripts(rootFs IDiskPartition, disks []*deploy
 	return rootFs.FilePutContents("/etc/fstab", cf, false, false)
 }
 
+// DeployVirtiofsMounts rewrites virtiofs records of fstab, so detached
+// shared filesystems are removed and won't block guest booting
+func (l *sLinuxRootFs) DeployVirtiofsMounts(rootFs IDiskPartition, filesystems []*deployapi.Filesystem) error {
+	fstabcont, err := rootFs.FileGetContents("/etc/fstab", false)
+	if err != nil {
+		return err
+	}
+	var modeRwxOwner = syscall.S_IRUSR | syscall.S_IWUSR | syscall.S_IXUSR
+	var fstab = fstabutils.FSTabFile(string(fstabcont))
+	if fstab != nil {
+		fstab = fstab.RemoveFsType("virtiofs")
+	} else {
+		_fstab := make(fstabutils.FsTab, 0)
+		fstab = &_fstab
+	}
+	for _, fs := range filesystems {
+		if len(fs.Mountpoint) == 0 {
+			continue
+		}
+		opts := "defaults,nofail"
+		if fs.Readonly {
+			opts += ",ro"
+		}
+		fstab.AddFsrec(fmt.Sprintf("%s %s virtiofs %s 0 0", fs.MountTag, fs.Mountpoint, opts))
+		if !rootFs.Exists(fs.Mountpoint, false) {
+			if err := rootFs.Mkdir(fs.Mountpoint, modeRwxOwner, false); err != nil {
+				return err
+			}
+		}
+	}
+	return rootFs.FilePutContents("/etc/fstab", fstab.ToConf(), false, false)
+}
+
 func (l *sLinuxRootFs) DeployNetworkingScripts(rootFs IDiskPartition, nics []*types.SServerNic) error {
 	netDevPrefix := GetNetDevPrefix(nics)
 	log.Infof("netdev prefix: %s", netDevPrefix)
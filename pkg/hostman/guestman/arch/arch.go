@@ -30,6 +30,7 @@ type Arch interface {
 	GenerateQgaDesc(qgaPath string) *desc.SGuestQga
 	GeneratePvpanicDesc() *desc.SGuestPvpanic
 	GenerateIsaSerialDesc() *desc.SGuestIsaSerial
+	GenerateTpmDesc(socketPath string) *desc.SGuestTpm
 }
 
 type KVMGuestInstance interface {
@@ -87,3 +88,15 @@ func (*archBase) GeneratePvpanicDesc() *desc.SGuestPvpanic {
 func (*archBase) GenerateIsaSerialDesc() *desc.SGuestIsaSerial {
 	return nil
 }
+
+func newTpmDesc(model, socketPath string) *desc.SGuestTpm {
+	socket := desc.NewCharDev("socket", "chrtpm", "")
+	socket.Options = map[string]string{
+		"path": socketPath,
+	}
+	return &desc.SGuestTpm{
+		Socket: socket,
+		Id:     "tpm0",
+		Model:  model,
+	}
+}
@@ -50,6 +50,10 @@ func (*ARM) GenerateCdromDesc(osName string, cdrom *desc.SGuestCdrom) {
 	cdrom.Id = id
 }
 
+func (*ARM) GenerateTpmDesc(socketPath string) *desc.SGuestTpm {
+	return newTpmDesc("tpm-tis-device", socketPath)
+}
+
 func (*ARM) GenerateFloppyDesc(osName string, floppy *desc.SGuestFloppy) {
 
 }
@@ -0,0 +1,343 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/mem"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+const (
+	BALLOON_DEVICE_ID = "balloon0"
+
+	// keep guest used memory plus this margin when inflating balloon
+	BALLOON_GUEST_MEM_MARGIN_MB = 256
+
+	// qemu monitor may hang, don't let it block the balloon controller
+	BALLOON_MONITOR_TIMEOUT = 10 * time.Second
+)
+
+func (s *SKVMGuestInstance) isBalloonEnabled() bool {
+	return s.Desc.Metadata[api.VM_METADATA_ENABLE_BALLOON] == "true"
+}
+
+// getBalloonMinMem returns memory size in MB guaranteed to guest when balloon inflated
+func (s *SKVMGuestInstance) getBalloonMinMem() int64 {
+	minMem, _ := strconv.ParseInt(s.Desc.Metadata[api.VM_METADATA_BALLOON_MIN_MEM], 10, 64)
+	if minMem <= 0 {
+		minMem = s.Desc.Mem * int64(options.HostOptions.BalloonGuestMinMemPercent) / 100
+	}
+	if minMem > s.Desc.Mem {
+		minMem = s.Desc.Mem
+	}
+	return minMem
+}
+
+// isBalloonSupported tells whether memory released by balloon is really
+// returned to host. Hugepage backed memory is never released, and VFIO
+// devices pin all guest memory for DMA, reclaiming them would make
+// scheduler overcommit host memory.
+func (s *SKVMGuestInstance) isBalloonSupported() bool {
+	if s.isMemHugepageBacked() {
+		return false
+	}
+	for i := range s.Desc.IsolatedDevices {
+		if s.Desc.IsolatedDevices[i].DevType != api.USB_TYPE {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SKVMGuestInstance) isMemHugepageBacked() bool {
+	if s.Desc.MemDesc != nil && s.Desc.MemDesc.Mem != nil && s.Desc.MemDesc.Mem.Object != nil {
+		return s.Desc.MemDesc.Mem.ObjType == "memory-backend-file"
+	}
+	return s.manager.host.IsHugepagesEnabled()
+}
+
+func newBalloonDesc(pciRoot *desc.PCIController) *desc.SGuestBalloon {
+	balloon := &desc.SGuestBalloon{
+		PCIDevice: desc.NewPCIDevice(pciRoot.CType, "virtio-balloon-pci", BALLOON_DEVICE_ID),
+	}
+	balloon.Options = map[string]string{
+		// free pages are reported to host and released, require guest kernel >= 5.7
+		"free-page-reporting": "on",
+		// release balloon pages when guest is about to oom
+		"deflate-on-oom": "on",
+	}
+	return balloon
+}
+
+func (s *SKVMGuestInstance) initBalloonDesc(pciRoot *desc.PCIController) {
+	if !s.isBalloonEnabled() {
+		return
+	}
+	if !s.isBalloonSupported() {
+		log.Warningf("guest %s memory is hugepage backed or pinned by vfio devices, balloon is not enabled", s.GetName())
+		return
+	}
+	s.Desc.Balloon = newBalloonDesc(pciRoot)
+}
+
+func (s *SKVMGuestInstance) enableBalloonStatsPolling() {
+	if s.Desc.Balloon == nil || options.HostOptions.BalloonStatsPollingIntervalSecond <= 0 {
+		return
+	}
+	s.Monitor.SetBalloonStatsPollingInterval(s.Desc.Balloon.Id, options.HostOptions.BalloonStatsPollingIntervalSecond, func(res string) {
+		if len(res) > 0 {
+			log.Errorf("guest %s enable balloon stats polling: %s", s.GetName(), res)
+		}
+	})
+}
+
+// waitBalloonMonitor waits monitor callback to send result to resChan.
+// resChan must be buffered, so the callback won't block after timeout.
+func waitBalloonMonitor(resChan chan error, timeout time.Duration) error {
+	select {
+	case err := <-resChan:
+		return err
+	case <-time.After(timeout):
+		return errors.Wrapf(errors.ErrTimeout, "wait monitor for %s", timeout)
+	}
+}
+
+// getBalloonActualMem returns current guest memory size in MB after ballooning
+func (s *SKVMGuestInstance) getBalloonActualMem() (int64, error) {
+	var (
+		actual  = make(chan int64, 1)
+		errChan = make(chan error, 1)
+	)
+	s.Monitor.QueryBalloon(func(info *monitor.BalloonInfo, err string) {
+		if len(err) > 0 {
+			errChan <- errors.Errorf(err)
+		} else {
+			actual <- info.Actual / 1024 / 1024
+			errChan <- nil
+		}
+	})
+	if err := waitBalloonMonitor(errChan, BALLOON_MONITOR_TIMEOUT); err != nil {
+		return 0, err
+	}
+	return <-actual, nil
+}
+
+func (s *SKVMGuestInstance) getBalloonStats() (*monitor.BalloonGuestStats, error) {
+	var (
+		stats   = make(chan *monitor.BalloonGuestStats, 1)
+		errChan = make(chan error, 1)
+	)
+	s.Monitor.GetBalloonStats(s.Desc.Balloon.Id, func(res *monitor.BalloonGuestStats, err string) {
+		if len(err) > 0 {
+			errChan <- errors.Errorf(err)
+		} else {
+			stats <- res
+			errChan <- nil
+		}
+	})
+	if err := waitBalloonMonitor(errChan, BALLOON_MONITOR_TIMEOUT); err != nil {
+		return nil, err
+	}
+	return <-stats, nil
+}
+
+func (s *SKVMGuestInstance) setBalloon(sizeMb int64) error {
+	var errChan = make(chan error, 1)
+	s.Monitor.SetBalloon(sizeMb, func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Errorf(res)
+		} else {
+			errChan <- nil
+		}
+	})
+	return waitBalloonMonitor(errChan, BALLOON_MONITOR_TIMEOUT)
+}
+
+type sBalloonGuest struct {
+	name string
+	// guest memory size in MB
+	mem int64
+	// current guest memory size in MB
+	actual int64
+	// guest memory size in MB can not inflate below
+	floor int64
+
+	setBalloon func(sizeMb int64) error
+}
+
+// getBalloonFloor returns the lowest memory size in MB that guest balloon
+// may be inflated to, it never goes below guest used memory.
+func (s *SKVMGuestInstance) getBalloonFloor(actual int64) int64 {
+	floor := s.getBalloonMinMem()
+	stats, err := s.getBalloonStats()
+	if err != nil || stats.LastUpdate == 0 || stats.Stats.AvailableMemory < 0 || stats.Stats.TotalMemory <= 0 {
+		// no guest memory stats, never inflate blindly
+		return actual
+	}
+	usedMb := (stats.Stats.TotalMemory - stats.Stats.AvailableMemory) / 1024 / 1024
+	if usedMb+BALLOON_GUEST_MEM_MARGIN_MB > floor {
+		floor = usedMb + BALLOON_GUEST_MEM_MARGIN_MB
+	}
+	return floor
+}
+
+func (m *SGuestManager) getBalloonGuests() []*sBalloonGuest {
+	guests := make([]*sBalloonGuest, 0)
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest, ok := v.(*SKVMGuestInstance)
+		if !ok || guest.Desc.Balloon == nil || guest.Desc.IsSlave {
+			return true
+		}
+		if !guest.IsRunning() || !guest.IsMonitorAlive() || guest.IsMigratingDestGuest() {
+			return true
+		}
+		// vfio devices may be hot plugged after balloon device added
+		if !guest.isBalloonSupported() {
+			return true
+		}
+		actual, err := guest.getBalloonActualMem()
+		if err != nil {
+			log.Errorf("guest %s query balloon: %s", guest.GetName(), err)
+			return true
+		}
+		guests = append(guests, &sBalloonGuest{
+			name:       guest.GetName(),
+			mem:        guest.Desc.Mem,
+			actual:     actual,
+			floor:      guest.getBalloonFloor(actual),
+			setBalloon: guest.setBalloon,
+		})
+		return true
+	})
+	return guests
+}
+
+func (m *SGuestManager) StartBalloonController() {
+	if !options.HostOptions.EnableBalloonController || options.HostOptions.BalloonControllerIntervalSecond <= 0 {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				debug.PrintStack()
+				log.Errorf("Balloon controller failed %s", r)
+			}
+		}()
+		for {
+			time.Sleep(time.Duration(options.HostOptions.BalloonControllerIntervalSecond) * time.Second)
+			if err := m.balloonAdjust(); err != nil {
+				log.Errorf("balloon adjust: %s", err)
+			}
+		}
+	}()
+}
+
+// balloonAdjust adjusts balloons of guests on this host by host available memory,
+// memory reclaimable by balloons is reported to region as overcommit headroom.
+func (m *SGuestManager) balloonAdjust() error {
+	info, err := mem.VirtualMemory()
+	if err != nil {
+		return errors.Wrap(err, "get host memory")
+	}
+	totalMb := int64(info.Total / 1024 / 1024)
+	availMb := int64(info.Available / 1024 / 1024)
+	lowMb := totalMb * int64(options.HostOptions.BalloonHostMemLowWatermarkPercent) / 100
+	highMb := totalMb * int64(options.HostOptions.BalloonHostMemHighWatermarkPercent) / 100
+	stepMb := int64(options.HostOptions.BalloonStepSizeMb)
+
+	headroom := adjustBalloons(m.getBalloonGuests(), availMb, lowMb, highMb, stepMb)
+	m.host.SetMemBalloonHeadroom(int(headroom))
+	return nil
+}
+
+// adjustBalloons inflates guest balloons when host available memory drops below
+// low watermark, and deflates them when it rises above high watermark.
+// It returns memory size in MB that can be reclaimed from guests, i.e. guest
+// memory above balloon floor, which is only reported while host available
+// memory is above high watermark. Memory already reclaimed is not counted, it
+// is handed back to guests as soon as host has spare memory or guest is
+// about to oom.
+func adjustBalloons(guests []*sBalloonGuest, availMb, lowMb, highMb, stepMb int64) int64 {
+	if availMb < lowMb {
+		// reclaim memory until available memory reaches high watermark
+		need := highMb - availMb
+		for i := 0; i < len(guests) && need > 0; i++ {
+			g := guests[i]
+			delta := g.actual - g.floor
+			if delta > stepMb {
+				delta = stepMb
+			}
+			if delta > need {
+				delta = need
+			}
+			if delta <= 0 {
+				continue
+			}
+			if err := g.setBalloon(g.actual - delta); err != nil {
+				log.Errorf("guest %s inflate balloon to %dMB: %s", g.name, g.actual-delta, err)
+				continue
+			}
+			log.Infof("guest %s inflate balloon to %dMB", g.name, g.actual-delta)
+			g.actual -= delta
+			need -= delta
+		}
+	} else if availMb > highMb {
+		// give memory back to guests as long as available memory stays above high watermark
+		budget := availMb - highMb
+		for i := 0; i < len(guests) && budget > 0; i++ {
+			g := guests[i]
+			delta := g.mem - g.actual
+			if delta > stepMb {
+				delta = stepMb
+			}
+			if delta > budget {
+				delta = budget
+			}
+			if delta <= 0 {
+				continue
+			}
+			if err := g.setBalloon(g.actual + delta); err != nil {
+				log.Errorf("guest %s deflate balloon to %dMB: %s", g.name, g.actual+delta, err)
+				continue
+			}
+			log.Infof("guest %s deflate balloon to %dMB", g.name, g.actual+delta)
+			g.actual += delta
+			budget -= delta
+		}
+	}
+
+	if availMb < highMb {
+		// host under memory pressure has no headroom for new guests
+		return 0
+	}
+	var headroom int64
+	for i := range guests {
+		if guests[i].actual > guests[i].floor {
+			headroom += guests[i].actual - guests[i].floor
+		}
+	}
+	return headroom
+}
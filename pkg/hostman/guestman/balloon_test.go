@@ -0,0 +1,203 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+)
+
+type sTestBalloon struct {
+	sizes []int64
+	err   error
+}
+
+func (b *sTestBalloon) set(sizeMb int64) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.sizes = append(b.sizes, sizeMb)
+	return nil
+}
+
+func newTestBalloonGuest(name string, mem, actual, floor int64, b *sTestBalloon) *sBalloonGuest {
+	return &sBalloonGuest{
+		name:       name,
+		mem:        mem,
+		actual:     actual,
+		floor:      floor,
+		setBalloon: b.set,
+	}
+}
+
+func TestAdjustBalloons(t *testing.T) {
+	const (
+		lowMb  = 1000
+		highMb = 2000
+		stepMb = 512
+	)
+	for _, c := range []struct {
+		name    string
+		availMb int64
+		// mem, actual, floor of each guest
+		guests       [][3]int64
+		failGuest    int
+		wantActual   []int64
+		wantHeadroom int64
+	}{
+		{
+			name:         "between watermarks keeps balloons",
+			availMb:      1500,
+			guests:       [][3]int64{{4096, 3072, 1024}},
+			failGuest:    -1,
+			wantActual:   []int64{3072},
+			wantHeadroom: 0,
+		},
+		{
+			name:         "below low watermark inflates by step",
+			availMb:      500,
+			guests:       [][3]int64{{4096, 4096, 1024}, {4096, 4096, 1024}, {4096, 4096, 1024}},
+			failGuest:    -1,
+			wantActual:   []int64{3584, 3584, 3620},
+			wantHeadroom: 0,
+		},
+		{
+			name:         "inflate never goes below floor",
+			availMb:      500,
+			guests:       [][3]int64{{4096, 1200, 1024}, {4096, 4096, 4096}, {4096, 4096, 3900}},
+			failGuest:    -1,
+			wantActual:   []int64{1024, 4096, 3900},
+			wantHeadroom: 0,
+		},
+		{
+			name:         "failed inflate is not counted",
+			availMb:      500,
+			guests:       [][3]int64{{4096, 4096, 1024}, {4096, 4096, 1024}},
+			failGuest:    0,
+			wantActual:   []int64{4096, 3584},
+			wantHeadroom: 0,
+		},
+		{
+			name:         "above high watermark deflates within budget",
+			availMb:      2600,
+			guests:       [][3]int64{{4096, 3072, 1024}, {4096, 3072, 1024}},
+			failGuest:    -1,
+			wantActual:   []int64{3584, 3160},
+			wantHeadroom: (3584 - 1024) + (3160 - 1024),
+		},
+		{
+			name:         "headroom above floor at high watermark",
+			availMb:      2000,
+			guests:       [][3]int64{{4096, 4096, 1024}, {4096, 3072, 3072}},
+			failGuest:    -1,
+			wantActual:   []int64{4096, 3072},
+			wantHeadroom: 3072,
+		},
+		{
+			name:         "deflate never exceeds guest memory",
+			availMb:      8000,
+			guests:       [][3]int64{{4096, 4000, 1024}, {2048, 1024, 1024}},
+			failGuest:    -1,
+			wantActual:   []int64{4096, 1536},
+			wantHeadroom: (4096 - 1024) + (1536 - 1024),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			balloons := make([]*sTestBalloon, len(c.guests))
+			guests := make([]*sBalloonGuest, len(c.guests))
+			for i, g := range c.guests {
+				balloons[i] = &sTestBalloon{}
+				if i == c.failGuest {
+					balloons[i].err = errors.ErrTimeout
+				}
+				guests[i] = newTestBalloonGuest("guest", g[0], g[1], g[2], balloons[i])
+			}
+			headroom := adjustBalloons(guests, c.availMb, lowMb, highMb, stepMb)
+			if headroom != c.wantHeadroom {
+				t.Errorf("headroom = %d, want %d", headroom, c.wantHeadroom)
+			}
+			for i := range guests {
+				if guests[i].actual != c.wantActual[i] {
+					t.Errorf("guest %d actual = %d, want %d", i, guests[i].actual, c.wantActual[i])
+				}
+				if guests[i].actual != c.guests[i][1] {
+					sizes := balloons[i].sizes
+					if len(sizes) != 1 || sizes[0] != guests[i].actual {
+						t.Errorf("guest %d balloon set to %v, want %d", i, sizes, guests[i].actual)
+					}
+				} else if len(balloons[i].sizes) > 0 {
+					t.Errorf("guest %d balloon should not be set: %v", i, balloons[i].sizes)
+				}
+			}
+		})
+	}
+}
+
+func TestIsBalloonSupported(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		hugepages bool
+		memObj    string
+		devTypes  []string
+		want      bool
+	}{
+		{name: "plain", memObj: "memory-backend-ram", want: true},
+		{name: "usb passthrough", memObj: "memory-backend-ram", devTypes: []string{api.USB_TYPE}, want: true},
+		{name: "gpu passthrough", memObj: "memory-backend-ram", devTypes: []string{api.USB_TYPE, api.GPU_HPC_TYPE}},
+		{name: "hugepage backed", memObj: "memory-backend-file"},
+		{name: "hugepage host without mem desc", hugepages: true},
+		{name: "host without mem desc", want: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			manager := &SGuestManager{host: &sFakeHugepageHost{hugepages: c.hugepages}}
+			s := &SKVMGuestInstance{
+				sBaseGuestInstance: newBaseGuestInstance("guest-id", manager, api.HYPERVISOR_KVM),
+			}
+			s.Desc = &desc.SGuestDesc{}
+			if len(c.memObj) > 0 {
+				s.Desc.MemDesc = &desc.SGuestMem{}
+				s.Desc.MemDesc.Mem = desc.NewMemsDesc(*desc.NewMemDesc(c.memObj, "mem", nil, nil), nil)
+			}
+			for _, devType := range c.devTypes {
+				dev := &desc.SGuestIsolatedDevice{}
+				dev.DevType = devType
+				s.Desc.IsolatedDevices = append(s.Desc.IsolatedDevices, dev)
+			}
+			if got := s.isBalloonSupported(); got != c.want {
+				t.Errorf("isBalloonSupported = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWaitBalloonMonitor(t *testing.T) {
+	resChan := make(chan error, 1)
+	if err := waitBalloonMonitor(resChan, 10*time.Millisecond); errors.Cause(err) != errors.ErrTimeout {
+		t.Errorf("wait hung monitor got %v, want timeout", err)
+	}
+	// late callback must not block
+	resChan <- nil
+
+	resChan = make(chan error, 1)
+	resChan <- errors.ErrNotFound
+	if err := waitBalloonMonitor(resChan, time.Second); err != errors.ErrNotFound {
+		t.Errorf("wait monitor got %v, want %v", err, errors.ErrNotFound)
+	}
+}
@@ -87,6 +87,19 @@ type SGuestMem struct {
 
 	// hotplug mem devices
 	MemSlots []*SMemSlot `json:",omitempty"`
+
+	// memory provided by virtio-mem device beyond boot memory
+	VirtioMem *SGuestVirtioMem `json:",omitempty"`
+}
+
+type SGuestVirtioMem struct {
+	*PCIDevice `json:",omitempty"`
+
+	MemObj *SMemDesc
+	// memory size plugged to guest
+	RequestedSizeMB int64
+	// memory size the device can provide at most
+	RegionSizeMB int64
 }
 
 type SGuestHardwareDesc struct {
@@ -124,12 +137,16 @@ type SGuestHardwareDesc struct {
 	Disks           []*SGuestDisk           `json:",omitempty"`
 	Nics            []*SGuestNetwork        `json:",omitempty"`
 	IsolatedDevices []*SGuestIsolatedDevice `json:",omitempty"`
+	Filesystems     []*SGuestFilesystem     `json:",omitempty"`
 
 	// Random Number Generator Device
 	Rng       *SGuestRng       `json:",omitempty"`
 	Qga       *SGuestQga       `json:",omitempty"`
 	Pvpanic   *SGuestPvpanic   `json:",omitempty"`
 	IsaSerial *SGuestIsaSerial `json:",omitempty"`
+	Tpm       *SGuestTpm       `json:",omitempty"`
+	Balloon   *SGuestBalloon   `json:",omitempty"`
+	Watchdog  *SGuestWatchdog  `json:",omitempty"`
 
 	Usb            *UsbController   `json:",omitempty"`
 	PCIControllers []*PCIController `json:",omitempty"`
@@ -148,6 +165,13 @@ type SGuestIsaSerial struct {
 	Id  string
 }
 
+// vTPM backed by an external swtpm process
+type SGuestTpm struct {
+	Socket *CharDev
+	Id     string
+	Model  string
+}
+
 type SGuestQga struct {
 	Socket     *CharDev
 	SerialPort *VirtSerialPort
@@ -165,6 +189,14 @@ type SGuestPvpanic struct {
 	Id     string
 }
 
+type SGuestWatchdog struct {
+	Id    string
+	Model string
+
+	// i6300esb is pci device, ib700 is isa device
+	Pci *PCIDevice `json:",omitempty"`
+}
+
 // -device pcie-pci-bridge,id=pci.1,bus=pcie.0 \
 // -device pci-bridge,id=pci.2,bus=pci.1,chassis_nr=1,addr=0x01 \
 
@@ -223,6 +255,16 @@ type SGuestNetwork struct {
 	Pci *PCIDevice `json:",omitempty"`
 }
 
+// virtio-fs shared directory served by an external virtiofsd process
+type SGuestFilesystem struct {
+	api.GuestFilesystemJsonDesc
+
+	Socket *CharDev
+	Pci    *PCIDevice `json:",omitempty"`
+	// host directory exported by virtiofsd
+	SourcePath string
+}
+
 type VFIODevice struct {
 	*PCIDevice
 
@@ -246,6 +288,10 @@ type SGuestRng struct {
 	RngRandom *Object
 }
 
+type SGuestBalloon struct {
+	*PCIDevice `json:",omitempty"`
+}
+
 type SoundCard struct {
 	*PCIDevice `json:",omitempty"`
 	Codec      *Codec
@@ -0,0 +1,272 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+)
+
+const (
+	// persistent dirty bitmap named by backup id tracks writes after the backup
+	DISK_BACKUP_BITMAP_PREFIX = "backup-"
+	DISK_BACKUP_JOB_PREFIX    = "backupjob-"
+
+	// timeout of monitor commands of backup, not the backup job itself
+	DISK_BACKUP_MONITOR_TIMEOUT = 30 * time.Second
+)
+
+type SDiskLiveBackup struct {
+	Sid        string
+	DiskBackup *storageman.SDiskBackup
+}
+
+func (m *SGuestManager) DoLiveDiskBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	input, ok := params.(*SDiskLiveBackup)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	guest, ok := m.GetKVMServer(input.Sid)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrNotFound, "guest %s", input.Sid)
+	}
+	return guest.ExecLiveDiskBackup(ctx, input.DiskBackup)
+}
+
+func getBackupBitmapName(backupId string) string {
+	return DISK_BACKUP_BITMAP_PREFIX + backupId
+}
+
+// getBackupSyncBitmap returns the dirty bitmap tracking writes since parent backup,
+// empty if there is no such bitmap and a full backup is required
+func getBackupSyncBitmap(parentBackupId string, bitmaps map[string]monitor.BlockDirtyBitmap) string {
+	if len(parentBackupId) == 0 {
+		return ""
+	}
+	name := getBackupBitmapName(parentBackupId)
+	if _, ok := bitmaps[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// getStaleBackupBitmaps returns backup bitmaps other than current one, bitmaps not
+// added by backup are left untouched
+func getStaleBackupBitmaps(bitmaps map[string]monitor.BlockDirtyBitmap, current string) []string {
+	ret := make([]string, 0)
+	for name := range bitmaps {
+		if name != current && strings.HasPrefix(name, DISK_BACKUP_BITMAP_PREFIX) {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (s *SKVMGuestInstance) getDiskDescById(diskId string) *desc.SGuestDisk {
+	for i := range s.Desc.Disks {
+		if s.Desc.Disks[i].DiskId == diskId {
+			return s.Desc.Disks[i]
+		}
+	}
+	return nil
+}
+
+// ExecLiveDiskBackup backups disk of running guest by a qemu backup job. Backup is
+// incremental when the dirty bitmap added by parent backup is still on the drive,
+// otherwise a full backup is taken and backup_mode in result tells region about it.
+func (s *SKVMGuestInstance) ExecLiveDiskBackup(ctx context.Context, diskBackup *storageman.SDiskBackup) (jsonutils.JSONObject, error) {
+	if !s.IsRunning() || !s.IsMonitorAlive() {
+		return nil, errors.Errorf("guest %s is not running", s.GetName())
+	}
+	disk := s.getDiskDescById(diskBackup.DiskId)
+	if disk == nil {
+		return nil, errors.Wrapf(errors.ErrNotFound, "disk %s of guest %s", diskBackup.DiskId, s.GetName())
+	}
+	drive := fmt.Sprintf("drive_%d", disk.Index)
+	bitmaps, err := s.getDriveDirtyBitmaps(drive)
+	if err != nil {
+		return nil, errors.Wrap(err, "get dirty bitmaps")
+	}
+
+	backupMode := api.BACKUP_MODE_FULL
+	syncBitmap := getBackupSyncBitmap(diskBackup.ParentBackupId, bitmaps)
+	if len(syncBitmap) > 0 {
+		backupMode = api.BACKUP_MODE_INCREMENTAL
+	} else if len(diskBackup.ParentBackupId) > 0 {
+		// bitmap is lost once the drive is reopened on another image, e.g. by a disk snapshot
+		log.Warningf("guest %s drive %s missing dirty bitmap of parent backup %s, take full backup instead",
+			s.GetName(), drive, diskBackup.ParentBackupId)
+	}
+
+	bitmap := getBackupBitmapName(diskBackup.BackupId)
+	sizeMb, err := storageman.DoLiveBackupDisk(ctx, diskBackup, disk.Size, func(target string) error {
+		// compressed clusters can't be written to encrypted qcow2
+		compress := len(diskBackup.EncryptKeyId) == 0
+		return s.driveBackupWithBitmap(ctx, drive, DISK_BACKUP_JOB_PREFIX+diskBackup.BackupId, target, bitmap, syncBitmap, compress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// only the bitmap of the latest backup is needed by next incremental backup
+	for _, name := range getStaleBackupBitmaps(bitmaps, bitmap) {
+		if err := s.removeDirtyBitmap(drive, name); err != nil {
+			log.Errorf("guest %s remove dirty bitmap %s: %s", s.GetName(), name, err)
+		}
+	}
+
+	res := jsonutils.NewDict()
+	res.Set("size_mb", jsonutils.NewInt(int64(sizeMb)))
+	res.Set("backup_mode", jsonutils.NewString(backupMode))
+	return res, nil
+}
+
+func (s *SKVMGuestInstance) getDriveDirtyBitmaps(drive string) (map[string]monitor.BlockDirtyBitmap, error) {
+	blocksChan := make(chan []monitor.QemuBlock)
+	s.Monitor.GetBlocks(func(blocks []monitor.QemuBlock) {
+		blocksChan <- blocks
+	})
+	blocks := <-blocksChan
+	if blocks == nil {
+		return nil, errors.Errorf("query block failed")
+	}
+	for i := range blocks {
+		if blocks[i].Device == drive {
+			ret := make(map[string]monitor.BlockDirtyBitmap)
+			for _, bitmap := range blocks[i].GetDirtyBitmaps() {
+				ret[bitmap.Name] = bitmap
+			}
+			return ret, nil
+		}
+	}
+	return nil, errors.Wrapf(errors.ErrNotFound, "drive %s", drive)
+}
+
+func (s *SKVMGuestInstance) driveBackupWithBitmap(ctx context.Context, drive, jobId, target, bitmap, syncBitmap string, compress bool) error {
+	jobChan := make(chan string, 1)
+	s.backupJobs.Store(jobId, jobChan)
+	defer s.backupJobs.Delete(jobId)
+
+	errChan := make(chan error, 1)
+	s.Monitor.DriveBackupWithBitmap(drive, jobId, target, bitmap, syncBitmap, compress, func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Errorf(res)
+		} else {
+			errChan <- nil
+		}
+	})
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return errors.Wrap(err, "start backup job")
+		}
+	case <-time.After(DISK_BACKUP_MONITOR_TIMEOUT):
+		// job may still be started later, cancel it anyway
+		s.cancelBackupJob(jobId, jobChan)
+		s.removeBackupBitmap(drive, bitmap)
+		return errors.Wrap(errors.ErrTimeout, "start backup job")
+	}
+
+	// block job completed event, empty on success
+	var reason string
+	select {
+	case reason = <-jobChan:
+	case <-ctx.Done():
+		reason = s.cancelBackupJob(jobId, jobChan)
+		reason = fmt.Sprintf("%s: %s", ctx.Err(), reason)
+	case <-time.After(time.Duration(options.HostOptions.LiveBackupTimeoutHours) * time.Hour):
+		reason = s.cancelBackupJob(jobId, jobChan)
+		reason = fmt.Sprintf("timeout after %d hours: %s", options.HostOptions.LiveBackupTimeoutHours, reason)
+	}
+	if len(reason) > 0 {
+		// incremental job merges synced bitmap back on failure,
+		// the newly added bitmap is useless as no backup refers to it
+		s.removeBackupBitmap(drive, bitmap)
+		return errors.Errorf("backup job %s failed: %s", jobId, reason)
+	}
+	return nil
+}
+
+// cancelBackupJob cancels backup job and waits it to finish, returns
+// the finish reason of job
+func (s *SKVMGuestInstance) cancelBackupJob(jobId string, jobChan chan string) string {
+	s.Monitor.CancelBlockJob(jobId, true, func(res string) {
+		if len(res) > 0 {
+			log.Errorf("guest %s cancel backup job %s: %s", s.GetName(), jobId, res)
+		}
+	})
+	select {
+	case reason := <-jobChan:
+		return reason
+	case <-time.After(DISK_BACKUP_MONITOR_TIMEOUT):
+		return "job not finished after cancelled"
+	}
+}
+
+func (s *SKVMGuestInstance) removeBackupBitmap(drive, bitmap string) {
+	if err := s.removeDirtyBitmap(drive, bitmap); err != nil {
+		log.Errorf("guest %s remove dirty bitmap %s: %s", s.GetName(), bitmap, err)
+	}
+}
+
+func (s *SKVMGuestInstance) removeDirtyBitmap(drive, bitmap string) error {
+	errChan := make(chan error)
+	s.Monitor.RemoveDirtyBitmap(drive, bitmap, func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Errorf(res)
+		} else {
+			errChan <- nil
+		}
+	})
+	return <-errChan
+}
+
+// eventBackupJobFinished notifies live disk backup waiting for the block job
+func (s *SKVMGuestInstance) eventBackupJobFinished(event *monitor.Event) {
+	jobId, _ := event.Data["device"].(string)
+	if !strings.HasPrefix(jobId, DISK_BACKUP_JOB_PREFIX) {
+		return
+	}
+	jobChan, ok := s.backupJobs.Load(jobId)
+	if !ok {
+		return
+	}
+	reason := ""
+	if event.Event == `"BLOCK_JOB_CANCELLED"` {
+		reason = "job cancelled"
+	} else if errMsg, ok := event.Data["error"].(string); ok {
+		reason = errMsg
+	}
+	select {
+	case jobChan.(chan string) <- reason:
+	default:
+		// waiter only takes the first finish event
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+)
+
+func TestGetBackupSyncBitmap(t *testing.T) {
+	bitmaps := map[string]monitor.BlockDirtyBitmap{
+		"backup-b1": {Name: "backup-b1", Persistent: true},
+		"other":     {Name: "other"},
+	}
+	cases := []struct {
+		parent string
+		want   string
+	}{
+		{"", ""},
+		{"b1", "backup-b1"},
+		// bitmap lost, e.g. drive reopened by disk snapshot
+		{"b0", ""},
+	}
+	for _, c := range cases {
+		if got := getBackupSyncBitmap(c.parent, bitmaps); got != c.want {
+			t.Errorf("parent %q: want %q got %q", c.parent, c.want, got)
+		}
+	}
+}
+
+func TestGetStaleBackupBitmaps(t *testing.T) {
+	bitmaps := map[string]monitor.BlockDirtyBitmap{
+		"backup-b1": {Name: "backup-b1"},
+		"backup-b0": {Name: "backup-b0"},
+		"backup-b2": {Name: "backup-b2"},
+		"migration": {Name: "migration"},
+	}
+	want := []string{"backup-b0", "backup-b1"}
+	if got := getStaleBackupBitmaps(bitmaps, "backup-b2"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
 	hostapi "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/hostman/flowlog"
 	"yunion.io/x/onecloud/pkg/hostman/guestman"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
@@ -49,6 +50,10 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			fmt.Sprintf("%s/%s/<sid>/status", prefix, keyWord),
 			auth.Authenticate(getStatus))
 
+		app.AddHandler("GET",
+			fmt.Sprintf("%s/%s/<sid>/flow-logs", prefix, keyWord),
+			auth.Authenticate(getFlowLogs))
+
 		app.AddHandler("POST",
 			fmt.Sprintf("%s/%s/cpu-node-balance", prefix, keyWord),
 			auth.Authenticate(cpusetBalance))
@@ -72,6 +77,7 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			"suspend":                  guestSuspend,
 			"io-throttle":              guestIoThrottle,
 			"snapshot":                 guestSnapshot,
+			"disk-backup":              guestDiskBackup,
 			"delete-snapshot":          guestDeleteSnapshot,
 			"reload-disk-snapshot":     guestReloadDiskSnapshot,
 			"src-prepare-migrate":      guestSrcPrepareMigrate,
@@ -106,6 +112,7 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			"qga-set-network":          qgaSetNetwork,
 			"qga-get-os-info":          qgaGetOsInfo,
 			"start-rescue":             guestStartRescue,
+			"vtpm-backup":              guestVtpmBackup,
 		} {
 			app.AddHandler("POST",
 				fmt.Sprintf("%s/%s/<sid>/%s", prefix, keyWord, action),
@@ -145,6 +152,40 @@ func getStatus(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	hostutils.ResponseOk(ctx, w)
 }
 
+func getFlowLogs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	params, query, _ := appsrv.FetchEnv(ctx, w, r)
+	sid := params["<sid>"]
+	input := &computeapi.ServerFlowLogInput{}
+	if query != nil {
+		if err := query.Unmarshal(input); err != nil {
+			hostutils.Response(ctx, w, httperrors.NewInputParameterError("unmarshal input: %v", err))
+			return
+		}
+	}
+	macs := []string{}
+	guest, ok := guestman.GetGuestManager().GetServer(sid)
+	if ok {
+		for _, nic := range guest.GetDesc().Nics {
+			macs = append(macs, nic.Mac)
+		}
+	} else {
+		// guest migrated away, its flow logs kept here are queried by
+		// region with the mac addresses
+		if len(input.Macs) == 0 {
+			hostutils.Response(ctx, w, httperrors.NewNotFoundError("Guest %s not found", sid))
+			return
+		}
+		if !auth.FetchUserCredential(ctx, nil).HasSystemAdminPrivilege() {
+			hostutils.Response(ctx, w, httperrors.NewForbiddenError("not allow to query flow logs by mac addresses"))
+			return
+		}
+		macs = input.Macs
+	}
+	hostutils.Response(ctx, w, &computeapi.ServerFlowLogOutput{
+		Data: flowlog.Query(macs, input),
+	})
+}
+
 func cpusetBalance(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	hostutils.DelayTask(ctx, guestman.GetGuestManager().CpusetBalance, nil)
 	hostutils.ResponseOk(ctx, w)
@@ -581,11 +622,13 @@ func guestHotplugCpuMem(ctx context.Context, userCred mcclient.TokenCredential,
 
 	addCpuCount, _ := body.Int("add_cpu")
 	addMemSize, _ := body.Int("add_mem")
+	resizeMemSize, _ := body.Int("resize_mem")
 	hostutils.DelayTaskWithoutReqctx(ctx, guestman.GetGuestManager().HotplugCpuMem,
 		&guestman.SGuestHotplugCpuMem{
-			Sid:         sid,
-			AddCpuCount: addCpuCount,
-			AddMemSize:  addMemSize,
+			Sid:           sid,
+			AddCpuCount:   addCpuCount,
+			AddMemSize:    addMemSize,
+			ResizeMemSize: resizeMemSize,
 		})
 	return nil, nil
 }
@@ -658,6 +701,36 @@ func guestSnapshot(ctx context.Context, userCred mcclient.TokenCredential, sid s
 	return nil, nil
 }
 
+func guestDiskBackup(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	backupInfo := &storageman.SDiskBackup{}
+	err := body.Unmarshal(backupInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "JsonUnmarshal")
+	}
+	if len(backupInfo.DiskId) == 0 {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	if len(backupInfo.BackupId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_id")
+	}
+	if len(backupInfo.BackupStorageId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_storage_id")
+	}
+	guest, ok := guestman.GetGuestManager().GetKVMServer(sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("guest %s not found", sid)
+	}
+	if !guest.IsRunning() {
+		return nil, httperrors.NewInvalidStatusError("guest %s is not running", sid)
+	}
+	backupInfo.UserCred = userCred
+	hostutils.DelayTask(ctx, guestman.GetGuestManager().DoLiveDiskBackup, &guestman.SDiskLiveBackup{
+		Sid:        sid,
+		DiskBackup: backupInfo,
+	})
+	return nil, nil
+}
+
 func guestDeleteSnapshot(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	deleteSnapshot, err := body.GetString("delete_snapshot")
 	if err != nil {
@@ -953,3 +1026,7 @@ func qgaGetOsInfo(ctx context.Context, userCred mcclient.TokenCredential, sid st
 func guestStartRescue(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	return guestman.GetGuestManager().GuestStartRescue(ctx, userCred, sid, body)
 }
+
+func guestVtpmBackup(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	return guestman.GetGuestManager().SaveGuestTpmStateBackup(ctx, sid, body)
+}
@@ -100,6 +100,8 @@ type SGuestHotplugCpuMem struct {
 	Sid         string
 	AddCpuCount int64
 	AddMemSize  int64
+	// total memory size to resize through virtio-mem
+	ResizeMemSize int64
 }
 
 type SReloadDisk struct {
@@ -347,6 +347,7 @@ func (m *SGuestManager) OnLoadExistingGuestsComplete() {
 	}
 
 	go m.verifyDirtyServers()
+	m.StartBalloonController()
 
 	if !options.HostOptions.EnableCpuBinding {
 		m.ClenaupCpuset()
@@ -825,6 +826,9 @@ func (m *SGuestManager) GuestDeploy(ctx context.Context, params interface{}) (js
 				return nil, errors.Wrap(err, "failed save desc")
 			}
 		}
+		if err := m.restoreGuestTpmState(ctx, guest, deployParams.Body); err != nil {
+			return nil, errors.Wrap(err, "restore vtpm state")
+		}
 		return m.startDeploy(ctx, deployParams, guest)
 	} else {
 		return nil, fmt.Errorf("Guest %s not found", deployParams.Sid)
@@ -1426,7 +1430,7 @@ func (m *SGuestManager) HotplugCpuMem(ctx context.Context, params interface{}) (
 		return nil, hostutils.ParamsError
 	}
 	guest, _ := m.GetKVMServer(hotplugParams.Sid)
-	NewGuestHotplugCpuMemTask(ctx, guest, int(hotplugParams.AddCpuCount), int(hotplugParams.AddMemSize)).Start()
+	NewGuestHotplugCpuMemTask(ctx, guest, int(hotplugParams.AddCpuCount), int(hotplugParams.AddMemSize), int(hotplugParams.ResizeMemSize)).Start()
 	return nil, nil
 }
 
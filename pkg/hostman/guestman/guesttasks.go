@@ -771,6 +771,140 @@ func NewGuestNetworkSyncTask(
 	return &SGuestNetworkSyncTask{guest, delNics, addNics, make([]error, 0), nil}
 }
 
+/**
+ *  GuestFilesystemSyncTask
+**/
+
+type SGuestFilesystemSyncTask struct {
+	guest *SKVMGuestInstance
+	delFs []*desc.SGuestFilesystem
+	addFs []*desc.SGuestFilesystem
+
+	errors   []error
+	callback func(...error)
+}
+
+func NewGuestFilesystemSyncTask(guest *SKVMGuestInstance, delFs, addFs []*desc.SGuestFilesystem) *SGuestFilesystemSyncTask {
+	return &SGuestFilesystemSyncTask{guest, delFs, addFs, make([]error, 0), nil}
+}
+
+func (t *SGuestFilesystemSyncTask) Start(callback func(...error)) {
+	t.callback = callback
+	t.syncFilesystems()
+}
+
+func (t *SGuestFilesystemSyncTask) syncFilesystems() {
+	if len(t.delFs) > 0 {
+		fs := t.delFs[len(t.delFs)-1]
+		t.delFs = t.delFs[:len(t.delFs)-1]
+		t.removeFilesystem(fs)
+	} else if len(t.addFs) > 0 {
+		fs := t.addFs[len(t.addFs)-1]
+		t.addFs = t.addFs[:len(t.addFs)-1]
+		t.addFilesystem(fs)
+	} else {
+		t.callback(t.errors...)
+	}
+}
+
+func (t *SGuestFilesystemSyncTask) onFail(err error) {
+	log.Errorln(err)
+	t.errors = append(t.errors, err)
+	t.syncFilesystems()
+}
+
+func (t *SGuestFilesystemSyncTask) removeFilesystem(fs *desc.SGuestFilesystem) {
+	callback := func(res string) {
+		if len(res) > 0 && !strings.Contains(res, "not found") {
+			t.onFail(fmt.Errorf("filesystem device del failed %s", res))
+			return
+		}
+		t.guest.Monitor.ChardevRemove(fs.Socket.Id, func(res string) {
+			if len(res) > 0 {
+				log.Errorf("chardev %s remove failed %s", fs.Socket.Id, res)
+			}
+			t.onFilesystemRemoved(fs)
+		})
+	}
+	t.guest.Monitor.DeviceDel(fs.Pci.Id, callback)
+}
+
+func (t *SGuestFilesystemSyncTask) onFilesystemRemoved(fs *desc.SGuestFilesystem) {
+	t.guest.stopVirtiofsd(fs)
+	var i = 0
+	for ; i < len(t.guest.Desc.Filesystems); i++ {
+		if t.guest.Desc.Filesystems[i].Id == fs.Id {
+			if fs.Pci != nil {
+				if err := t.guest.pciAddrs.ReleasePCIAddress(fs.Pci.PCIAddr); err != nil {
+					log.Errorf("failed release filesystem pci addr %s", fs.Pci.PCIAddr)
+				}
+			}
+			break
+		}
+	}
+	if i < len(t.guest.Desc.Filesystems) {
+		t.guest.Desc.Filesystems = append(t.guest.Desc.Filesystems[:i], t.guest.Desc.Filesystems[i+1:]...)
+	}
+	t.syncFilesystems()
+}
+
+func (t *SGuestFilesystemSyncTask) addFilesystem(fs *desc.SGuestFilesystem) {
+	if !t.guest.isGuestMemShared() {
+		// vhost-user-fs requires shared guest memory, which is only
+		// configured on guest start
+		t.onFail(errors.Errorf("guest memory is not shared, restart guest to attach filesystem %s", fs.MountTag))
+		return
+	}
+	cType := t.guest.getHotPlugPciControllerType()
+	if cType == nil {
+		t.onFail(errors.Errorf("no hotplugable pci controller found"))
+		return
+	}
+	if err := t.guest.initFilesystemDesc(fs, *cType); err != nil {
+		t.onFail(err)
+		return
+	}
+	if err := t.guest.ensureDevicePciAddress(fs.Pci, -1, nil); err != nil {
+		t.onFail(errors.Wrap(err, "ensure filesystem pci address"))
+		return
+	}
+	onFail := func(e error) {
+		t.guest.stopVirtiofsd(fs)
+		if err := t.guest.pciAddrs.ReleasePCIAddress(fs.Pci.PCIAddr); err != nil {
+			log.Errorf("failed release filesystem pci addr %s", fs.Pci.PCIAddr)
+		}
+		t.onFail(e)
+	}
+	if err := t.guest.startVirtiofsd(fs); err != nil {
+		onFail(err)
+		return
+	}
+
+	t.guest.Monitor.ChardevAdd(fs.Socket.Id, fs.Socket.Backend, fs.Socket.Options, func(res string) {
+		if len(res) > 0 {
+			onFail(fmt.Errorf("chardev add failed %s", res))
+			return
+		}
+		params := map[string]string{
+			"id":   fs.Pci.Id,
+			"bus":  fs.Pci.BusStr(),
+			"addr": fs.Pci.SlotFunc(),
+		}
+		for k, v := range fs.Pci.Options {
+			params[k] = v
+		}
+		t.guest.Monitor.DeviceAdd(fs.Pci.DevType, params, func(res string) {
+			if len(res) > 0 {
+				t.guest.Monitor.ChardevRemove(fs.Socket.Id, func(string) {})
+				onFail(fmt.Errorf("filesystem device add failed %s", res))
+				return
+			}
+			t.guest.Desc.Filesystems = append(t.guest.Desc.Filesystems, fs)
+			t.syncFilesystems()
+		})
+	})
+}
+
 /**
  *  GuestIsolatedDeviceSyncTask
 **/
@@ -2377,6 +2511,8 @@ type SGuestHotplugCpuMemTask struct {
 	ctx         context.Context
 	addCpuCount int
 	addMemSize  int
+	// total memory size guest resized to through virtio-mem
+	resizeMemSize int
 
 	originalCpuCount int
 	addedCpuCount    int
@@ -2385,16 +2521,21 @@ type SGuestHotplugCpuMemTask struct {
 	addedMemSize    int
 	memSlotNewIndex *int
 	memSlot         *desc.SMemSlot
+
+	// memory size plugged by virtio-mem after resize, -1 if not resized
+	virtioMemSize int64
 }
 
 func NewGuestHotplugCpuMemTask(
-	ctx context.Context, s *SKVMGuestInstance, addCpuCount, addMemSize int,
+	ctx context.Context, s *SKVMGuestInstance, addCpuCount, addMemSize, resizeMemSize int,
 ) *SGuestHotplugCpuMemTask {
 	return &SGuestHotplugCpuMemTask{
 		SKVMGuestInstance: s,
 		ctx:               ctx,
 		addCpuCount:       addCpuCount,
 		addMemSize:        addMemSize,
+		resizeMemSize:     resizeMemSize,
+		virtioMemSize:     -1,
 	}
 }
 
@@ -2402,7 +2543,7 @@ func NewGuestHotplugCpuMemTask(
 func (task *SGuestHotplugCpuMemTask) Start() {
 	if task.addCpuCount > 0 {
 		task.startAddCpu()
-	} else if task.addMemSize > 0 {
+	} else if task.addMemSize > 0 || task.resizeMemSize > 0 {
 		task.startAddMem()
 	} else {
 		task.onSucc()
@@ -2499,13 +2640,43 @@ func (task *SGuestHotplugCpuMemTask) onAddCpu(reason string) {
 }
 
 func (task *SGuestHotplugCpuMemTask) startAddMem() {
-	if task.addMemSize > 0 {
+	if task.resizeMemSize > 0 {
+		go task.startResizeVirtioMem()
+	} else if task.addMemSize > 0 {
 		task.Monitor.GeMemtSlotIndex(task.onGetSlotIndex)
 	} else {
 		task.onSucc()
 	}
 }
 
+func (task *SGuestHotplugCpuMemTask) startResizeVirtioMem() {
+	virtioMem := task.Desc.MemDesc.VirtioMem
+	if virtioMem == nil {
+		task.onFail("guest memory is not provided by virtio-mem")
+		return
+	}
+	requestedSize := alignVirtioMemSize(int64(task.resizeMemSize) - task.Desc.MemDesc.SizeMB)
+	if requestedSize < 0 {
+		task.onFail(fmt.Sprintf("memory size %dMB less than boot memory %dMB", task.resizeMemSize, task.Desc.MemDesc.SizeMB))
+		return
+	}
+	if requestedSize > virtioMem.RegionSizeMB {
+		task.onFail(fmt.Sprintf("memory size %dMB exceeds virtio-mem capacity %dMB",
+			task.resizeMemSize, task.Desc.MemDesc.SizeMB+virtioMem.RegionSizeMB))
+		return
+	}
+	size, err := task.resizeVirtioMem(requestedSize)
+	if size >= 0 {
+		task.virtioMemSize = size
+	}
+	if err != nil {
+		log.Errorf("guest %s resize virtio-mem: %s", task.GetName(), err)
+		task.onFail(err.Error())
+		return
+	}
+	task.onSucc()
+}
+
 func (task *SGuestHotplugCpuMemTask) onGetSlotIndex(index int) {
 	var newIndex = index + len(task.Desc.MemDesc.Mem.Mems)
 	task.memSlotNewIndex = &newIndex
@@ -2543,6 +2714,13 @@ func (task *SGuestHotplugCpuMemTask) onGetSlotIndex(index int) {
 			"share":    "on",
 			"prealloc": "on",
 		}
+	} else if task.isGuestMemShared() {
+		// keep hotplugged memory shared with vhost-user backends
+		objType = "memory-backend-memfd"
+		opts = map[string]string{
+			"size":  fmt.Sprintf("%dM", task.addMemSize),
+			"share": "on",
+		}
 	} else {
 		objType = "memory-backend-ram"
 		opts = map[string]string{
@@ -2605,6 +2783,10 @@ func (task *SGuestHotplugCpuMemTask) updateGuestDesc() {
 	task.Desc.Cpu += int64(task.addedCpuCount)
 	task.Desc.CpuDesc.Cpus += uint(task.addedCpuCount)
 	task.Desc.Mem += int64(task.addedMemSize)
+	if task.virtioMemSize >= 0 {
+		task.Desc.MemDesc.VirtioMem.RequestedSizeMB = task.virtioMemSize
+		task.Desc.Mem = task.Desc.MemDesc.SizeMB + task.virtioMemSize
+	}
 	if task.addedMemSize > 0 {
 		if task.Desc.MemDesc.MemSlots == nil {
 			task.Desc.MemDesc.MemSlots = make([]*desc.SMemSlot, 0)
@@ -2624,10 +2806,10 @@ func (task *SGuestHotplugCpuMemTask) updateGuestDesc() {
 		task.Desc.VcpuPin[0].Vcpus = fmt.Sprintf("0-%d", task.Desc.Cpu-1)
 	}
 
-	if task.addedCpuCount > 0 || task.addedMemSize > 0 {
+	if task.addedCpuCount > 0 || task.addedMemSize > 0 || task.virtioMemSize >= 0 {
 		SaveLiveDesc(task, task.Desc)
 	}
-	if task.addedMemSize > 0 {
+	if task.addedMemSize > 0 || task.virtioMemSize >= 0 {
 		vncPort := task.GetVncPort()
 		data := jsonutils.NewDict()
 		data.Set("vnc_port", jsonutils.NewInt(int64(vncPort)))
@@ -2645,8 +2827,13 @@ func (task *SGuestHotplugCpuMemTask) onFail(reason string) {
 		body.Set("added_cpu", jsonutils.NewInt(int64(task.addedCpuCount)))
 	} else if task.memSlotNewIndex != nil {
 		body.Set("add_mem_failed", jsonutils.JSONTrue)
+	} else if task.resizeMemSize > 0 {
+		body.Set("resize_mem_failed", jsonutils.JSONTrue)
 	}
 	task.updateGuestDesc()
+	if task.virtioMemSize >= 0 {
+		body.Set("actual_mem", jsonutils.NewInt(task.Desc.Mem))
+	}
 	hostutils.TaskFailed2(task.ctx, reason, body)
 }
 
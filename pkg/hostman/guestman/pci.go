@@ -22,6 +22,7 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
+	"yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/qemu"
 	"yunion.io/x/onecloud/pkg/hostman/monitor"
@@ -94,11 +95,18 @@ func (s *SKVMGuestInstance) initGuestDevicesDesc(pciRoot, pciBridge *desc.PCICon
 	}
 
 	s.initIsolatedDevices(pciRoot, pciBridge)
+	if err := s.initGuestFilesystems(pciRoot, pciBridge); err != nil {
+		return errors.Wrap(err, "init guest filesystems")
+	}
 	s.initUsbController(pciRoot)
 	s.initRandomDevice(pciRoot, options.HostOptions.EnableVirtioRngDevice)
+	s.initBalloonDesc(pciRoot)
+	s.initWatchdogDesc(pciRoot)
+	s.initVirtioMemDevice(pciRoot)
 	s.initQgaDesc()
 	s.initPvpanicDesc()
 	s.initIsaSerialDesc()
+	s.initTpmDesc()
 	return nil
 }
 
@@ -700,6 +708,15 @@ func (s *SKVMGuestInstance) ensurePciAddresses() error {
 		}
 	}
 
+	for i := 0; i < len(s.Desc.Filesystems); i++ {
+		if s.Desc.Filesystems[i].Pci != nil {
+			err = s.ensureDevicePciAddress(s.Desc.Filesystems[i].Pci, -1, nil)
+			if err != nil {
+				return errors.Wrapf(err, "ensure filesystem %s pci address", s.Desc.Filesystems[i].MountTag)
+			}
+		}
+	}
+
 	if s.Desc.Usb != nil {
 		err = s.ensureDevicePciAddress(s.Desc.Usb.PCIDevice, -1, nil)
 		if err != nil {
@@ -714,6 +731,27 @@ func (s *SKVMGuestInstance) ensurePciAddresses() error {
 		}
 	}
 
+	if s.Desc.Balloon != nil {
+		err = s.ensureDevicePciAddress(s.Desc.Balloon.PCIDevice, -1, nil)
+		if err != nil {
+			return errors.Wrap(err, "ensure balloon device pci address")
+		}
+	}
+
+	if s.Desc.Watchdog != nil && s.Desc.Watchdog.Pci != nil {
+		err = s.ensureDevicePciAddress(s.Desc.Watchdog.Pci, -1, nil)
+		if err != nil {
+			return errors.Wrap(err, "ensure watchdog device pci address")
+		}
+	}
+
+	if s.Desc.MemDesc.VirtioMem != nil && s.Desc.MemDesc.VirtioMem.PCIDevice != nil {
+		err = s.ensureDevicePciAddress(s.Desc.MemDesc.VirtioMem.PCIDevice, -1, nil)
+		if err != nil {
+			return errors.Wrap(err, "ensure virtio-mem device pci address")
+		}
+	}
+
 	anonymousPCIDevs := s.Desc.AnonymousPCIDevs[:0]
 	for i := 0; i < len(s.Desc.AnonymousPCIDevs); i++ {
 		if s.isMachineDefaultAddress(s.Desc.AnonymousPCIDevs[i].PCIAddr) {
@@ -822,6 +860,12 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 	s.initQgaDesc()
 	s.initPvpanicDesc()
 	s.initIsaSerialDesc()
+	// ib700 sits on isa bus and won't be found in pci devices
+	s.initWatchdogDesc(pciRoot)
+	s.initTpmDesc()
+	if err := s.initGuestFilesystems(pciRoot, nil); err != nil {
+		return errors.Wrap(err, "init guest filesystems")
+	}
 	s.Desc.VdiDevice = new(desc.SGuestVdi)
 
 	for i := 0; i < len(pciInfoList[0].Devices); i++ {
@@ -880,6 +924,31 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 			if err != nil {
 				return errors.Wrap(err, "ensure random device pci address")
 			}
+		case VIRTIO_MEM_DEVICE_ID:
+			if s.Desc.MemDesc.VirtioMem == nil {
+				return errors.Errorf("virtio-mem device not found in memory devices")
+			}
+			s.Desc.MemDesc.VirtioMem.PCIDevice = newVirtioMemPciDevice(pciRoot)
+			s.Desc.MemDesc.VirtioMem.PCIAddr = pciAddr
+			err = s.ensureDevicePciAddress(s.Desc.MemDesc.VirtioMem.PCIDevice, -1, nil)
+			if err != nil {
+				return errors.Wrap(err, "ensure virtio-mem device pci address")
+			}
+		case BALLOON_DEVICE_ID:
+			// the running guest has balloon device regardless of current metadata
+			s.Desc.Balloon = newBalloonDesc(pciRoot)
+			s.Desc.Balloon.PCIAddr = pciAddr
+			err = s.ensureDevicePciAddress(s.Desc.Balloon.PCIDevice, -1, nil)
+			if err != nil {
+				return errors.Wrap(err, "ensure balloon device pci address")
+			}
+		case WATCHDOG_DEVICE_ID:
+			s.Desc.Watchdog = newWatchdogDesc(pciRoot, compute.GUEST_WATCHDOG_I6300ESB)
+			s.Desc.Watchdog.Pci.PCIAddr = pciAddr
+			err = s.ensureDevicePciAddress(s.Desc.Watchdog.Pci, -1, nil)
+			if err != nil {
+				return errors.Wrap(err, "ensure watchdog device pci address")
+			}
 		case "usb":
 			if s.Desc.Usb == nil {
 				s.initUsbController(pciRoot)
@@ -990,6 +1059,16 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 						}
 					}
 				}
+			case strings.HasPrefix(pciInfoList[0].Devices[i].QdevID, "fs-"):
+				for j := 0; j < len(s.Desc.Filesystems); j++ {
+					if getFilesystemDeviceId(s.Desc.Filesystems[j]) == pciInfoList[0].Devices[i].QdevID {
+						s.Desc.Filesystems[j].Pci.PCIAddr = pciAddr
+						err = s.ensureDevicePciAddress(s.Desc.Filesystems[j].Pci, -1, nil)
+						if err != nil {
+							return errors.Wrapf(err, "ensure filesystem %s pci address", s.Desc.Filesystems[j].MountTag)
+						}
+					}
+				}
 			case strings.HasPrefix(pciInfoList[0].Devices[i].QdevID, "netdev-"):
 				ifname := strings.TrimPrefix(pciInfoList[0].Devices[i].QdevID, "netdev-")
 				for i := 0; i < len(s.Desc.Nics); i++ {
@@ -1027,6 +1106,13 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 					if err != nil {
 						return errors.Wrap(err, "ensure random device pci address")
 					}
+				case class == 255 && vendor == 6900 && device == 4098: // 0x00ff, 1af4:1002  memory balloon device (legacy)
+					s.Desc.Balloon = newBalloonDesc(pciRoot)
+					s.Desc.Balloon.PCIAddr = pciAddr
+					err = s.ensureDevicePciAddress(s.Desc.Balloon.PCIDevice, -1, nil)
+					if err != nil {
+						return errors.Wrap(err, "ensure balloon device pci address")
+					}
 				case class == 1920 && vendor == 6900 && device == 4099: // 0x0780, 1af4:1003  console device (legacy)
 					if s.Desc.VirtioSerial == nil {
 						s.initVirtioSerial(pciRoot)
@@ -1071,9 +1157,22 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 			}
 		}
 	}
+	if s.Desc.Watchdog != nil && s.Desc.Watchdog.Pci != nil && s.Desc.Watchdog.Pci.PCIAddr == nil {
+		// watchdog configured after guest started
+		s.Desc.Watchdog = nil
+	}
 
 	s.initGuestDisks(pciRoot, nil, true)
 
+	// filesystems not plugged in the running guest will be attached on next sync
+	filesystems := s.Desc.Filesystems[:0]
+	for i := 0; i < len(s.Desc.Filesystems); i++ {
+		if s.Desc.Filesystems[i].Pci.PCIAddr != nil {
+			filesystems = append(filesystems, s.Desc.Filesystems[i])
+		}
+	}
+	s.Desc.Filesystems = filesystems
+
 	for i := 0; i < len(unknownDevices); i++ {
 		if unknownDevices[i].Bus == 0 && unknownDevices[i].Slot == 0 {
 			continue // host bridge
@@ -24,6 +24,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -98,6 +99,9 @@ type SKVMInstanceRuntime struct {
 	needSyncStreamDisks bool
 	blockJobTigger      map[string]chan struct{}
 	quorumFailed        int32
+	crashHandling       int32
+	// live disk backup job id -> chan receiving job result
+	backupJobs sync.Map
 
 	StartupTask *SGuestResumeTask
 	MigrateTask *SGuestLiveMigrateTask
@@ -999,6 +1003,7 @@ func (s *SKVMGuestInstance) onImportGuestMonitorConnected(ctx context.Context) {
 		meta := jsonutils.NewDict()
 		meta.Set(api.VM_METADATA_HOTPLUG_CPU_MEM, jsonutils.NewString("disable"))
 		meta.Set(api.VM_METADATA_HOT_REMOVE_NIC, jsonutils.NewString("disable"))
+		meta.Set(api.VM_METADATA_HOT_RESIZE_MEM, jsonutils.NewString("disable"))
 		meta.Set("__qemu_version", jsonutils.NewString(s.GetQemuVersionStr()))
 		s.SyncMetadata(meta)
 		s.SyncStatus("")
@@ -1073,11 +1078,16 @@ func (s *SKVMGuestInstance) StartMonitor(ctx context.Context, cb func(), isScrip
 func (s *SKVMGuestInstance) onReceiveQMPEvent(event *monitor.Event) {
 	switch event.Event {
 	case `"BLOCK_JOB_READY"`, `"BLOCK_JOB_COMPLETED"`:
+		if event.Event == `"BLOCK_JOB_COMPLETED"` {
+			s.eventBackupJobFinished(event)
+		}
 		s.eventBlockJobReady(event)
+	case `"BLOCK_JOB_CANCELLED"`:
+		s.eventBackupJobFinished(event)
 	case `"BLOCK_JOB_ERROR"`:
 		s.eventBlockJobError(event)
-	case `"GUEST_PANICKED"`:
-		s.eventGuestPaniced(event)
+	case `"GUEST_PANICKED"`, `"WATCHDOG"`:
+		s.eventGuestCrashed(event)
 	case `"STOP"`:
 		s.eventGuestStop()
 	case `"QUORUM_REPORT_BAD"`:
@@ -1145,25 +1155,6 @@ func (s *SKVMGuestInstance) eventQuorumReportBad(event *monitor.Event) {
 	s.SyncMirrorJobFailed(event.String())
 }
 
-func (s *SKVMGuestInstance) eventGuestPaniced(event *monitor.Event) {
-	// qemu runc state event source qemu/src/qapi/run-state.json
-	params := jsonutils.NewDict()
-	if action, ok := event.Data["action"]; ok {
-		sAction, _ := action.(string)
-		params.Set("action", jsonutils.NewString(sAction))
-	}
-	if info, ok := event.Data["info"]; ok {
-		params.Set("info", jsonutils.Marshal(info))
-	}
-	params.Set("event", jsonutils.NewString(strings.Trim(event.Event, "\"")))
-	_, err := modules.Servers.PerformAction(
-		hostutils.GetComputeSession(context.Background()),
-		s.GetId(), "event", params)
-	if err != nil {
-		log.Errorf("Server %s send event guest paniced got error %s", s.GetId(), err)
-	}
-}
-
 func (s *SKVMGuestInstance) eventBlockJobReady(event *monitor.Event) {
 	if !s.IsSlave() {
 		return
@@ -2149,6 +2140,12 @@ func (s *SKVMGuestInstance) Delete(ctx context.Context, migrated bool) error {
 	if err := s.delTmpDisks(ctx, migrated); err != nil {
 		return errors.Wrap(err, "delTmpDisks")
 	}
+	if err := s.deleteTpmState(migrated); err != nil {
+		return errors.Wrap(err, "deleteTpmState")
+	}
+	if err := s.deleteCrashDumps(migrated); err != nil {
+		return errors.Wrap(err, "deleteCrashDumps")
+	}
 	return DeleteHomeDir(s)
 }
 
@@ -2510,6 +2507,7 @@ func (s *SKVMGuestInstance) SyncConfig(
 	var delNetworks, addNetworks []*desc.SGuestNetwork
 	var changedNetworks [][2]*desc.SGuestNetwork
 	var delDevs, addDevs []*desc.SGuestIsolatedDevice
+	var delFs, addFs []*desc.SGuestFilesystem
 	var cdroms []*desc.SGuestCdrom
 	var floppys []*desc.SGuestFloppy
 
@@ -2523,6 +2521,7 @@ func (s *SKVMGuestInstance) SyncConfig(
 		floppys = s.compareDescFloppys(guestDesc)
 		delNetworks, addNetworks, changedNetworks = s.compareDescNetworks(guestDesc)
 		delDevs, addDevs = s.compareDescIsolatedDevices(guestDesc)
+		delFs, addFs = s.compareDescFilesystems(guestDesc)
 	}
 
 	if len(changedNetworks) > 0 && s.IsRunning() {
@@ -2575,6 +2574,12 @@ func (s *SKVMGuestInstance) SyncConfig(
 		tasks = append(tasks, task)
 	}
 
+	if len(delFs)+len(addFs) > 0 {
+		task := NewGuestFilesystemSyncTask(s, delFs, addFs)
+		runTaskNames = append(runTaskNames, jsonutils.NewString("filesystem_sync"))
+		tasks = append(tasks, task)
+	}
+
 	lenTasks := len(tasks)
 	var callBack = func(errs []error) {
 		SaveLiveDesc(s, s.Desc)
@@ -2898,6 +2903,11 @@ func (s *SKVMGuestInstance) OnResumeSyncMetadataInfo() {
 	meta.Set("__enable_cgroup_cpuset", jsonutils.JSONTrue)
 	meta.Set(api.VM_METADATA_HOTPLUG_CPU_MEM, jsonutils.NewString("enable"))
 	meta.Set(api.VM_METADATA_HOT_REMOVE_NIC, jsonutils.NewString("enable"))
+	if s.Desc.MemDesc != nil && s.Desc.MemDesc.VirtioMem != nil {
+		meta.Set(api.VM_METADATA_HOT_RESIZE_MEM, jsonutils.NewString("enable"))
+	} else {
+		meta.Set(api.VM_METADATA_HOT_RESIZE_MEM, jsonutils.NewString("disable"))
+	}
 	if len(s.VncPassword) > 0 {
 		meta.Set("__vnc_password", jsonutils.NewString(s.VncPassword))
 	}
@@ -2993,6 +3003,7 @@ func (s *SKVMGuestInstance) onGuestPrelaunch() error {
 		}
 		s.OnResumeSyncMetadataInfo()
 		s.doBlockIoThrottle()
+		s.enableBalloonStatsPolling()
 	}
 	s.LiveMigrateDestPort = nil
 	return nil
@@ -348,7 +348,7 @@ func (s *SKVMGuestInstance) generateStartScript(data *jsonutils.JSONDict) (strin
 		OVNIntegrationBridge: options.HostOptions.OvnIntegrationBridge,
 		HomeDir:              s.HomeDir(),
 		HugepagesEnabled:     s.manager.host.IsHugepagesEnabled(),
-		EnableMemfd:          s.isMemcleanEnabled(),
+		EnableMemfd:          s.isMemShareRequired(),
 		PidFilePath:          s.GetPidFilePath(),
 	}
 
@@ -370,6 +370,8 @@ func (s *SKVMGuestInstance) generateStartScript(data *jsonutils.JSONDict) (strin
 	qemuVersion := options.HostOptions.DefaultQemuVersion
 	if data.Contains("qemu_version") {
 		qemuVersion, _ = data.GetString("qemu_version")
+	} else if s.Desc.MemDesc.VirtioMem != nil {
+		qemuVersion = options.HostOptions.VirtioMemQemuVersion
 	}
 	if qemuVersion == "latest" {
 		qemuVersion = ""
@@ -416,6 +418,12 @@ func (s *SKVMGuestInstance) generateStartScript(data *jsonutils.JSONDict) (strin
 	}
 	cmd += sriovInitScripts
 
+	if s.isTpmEnabled() {
+		cmd += s.generateSwtpmStartScript(jsonutils.QueryBoolean(data, "need_migrate", false))
+	}
+
+	cmd += s.generateFilesystemsStartScript()
+
 	// cmd += fmt.Sprintf("STATE_FILE=`ls -d %s* | head -n 1`\n", s.getStateFilePathRootPrefix())
 	cmd += fmt.Sprintf("PID_FILE=%s\n", input.PidFilePath)
 
@@ -500,6 +508,11 @@ function nic_mtu() {
 		if len(input.OVMFPath) == 0 {
 			input.OVMFPath = options.HostOptions.OvmfPath
 		}
+		if s.isSecureBootEnabled() {
+			input.SecureBoot = true
+			input.OVMFCodePath = options.HostOptions.SecbootOvmfCodePath
+			input.OVMFVarsTemplatePath = options.HostOptions.SecbootOvmfVarsPath
+		}
 	}
 
 	// inject usb devices
@@ -696,6 +709,11 @@ func (s *SKVMGuestInstance) generateStopScript(data *jsonutils.JSONDict) string
 	cmd += "  rm -f $PID_FILE\n"
 	cmd += "fi\n"
 
+	if s.isTpmEnabled() {
+		cmd += s.generateSwtpmStopScript()
+	}
+	cmd += s.generateFilesystemsStopScript()
+
 	cmd += fmt.Sprintf("for d in $(ls -d /dev/hugepages/%s*)\n", uuid)
 	cmd += "do\n"
 	cmd += "  if [ -d $d ]; then\n"
@@ -893,6 +911,9 @@ func (s *SKVMGuestInstance) initCpuDesc(cpuMax uint) error {
 
 func (s *SKVMGuestInstance) initMemDesc(memSizeMB int64) error {
 	s.Desc.MemDesc = s.archMan.GenerateMemDesc()
+	if s.isVirtioMemSupported() {
+		memSizeMB = s.initVirtioMemDesc(memSizeMB)
+	}
 	s.Desc.MemDesc.SizeMB = memSizeMB
 
 	return s.initGuestMemObjects(memSizeMB)
@@ -901,7 +922,7 @@ func (s *SKVMGuestInstance) initMemDesc(memSizeMB int64) error {
 func (s *SKVMGuestInstance) memObjectType() string {
 	if s.manager.host.IsHugepagesEnabled() {
 		return "memory-backend-file"
-	} else if s.isMemcleanEnabled() {
+	} else if s.isMemShareRequired() {
 		return "memory-backend-memfd"
 	} else {
 		return "memory-backend-ram"
@@ -973,10 +994,15 @@ func (s *SKVMGuestInstance) getMemObjectOptions(memSizeMB int64, memPathSuffix s
 			opts["host-nodes"] = fmt.Sprintf("%d", *hostNodes)
 			opts["policy"] = "bind"
 		}
-	} else if s.isMemcleanEnabled() {
+	} else if s.isMemShareRequired() {
 		opts = map[string]string{
 			"size":  fmt.Sprintf("%dM", memSizeMB),
-			"share": "on", "prealloc": "on",
+			"share": "on",
+		}
+		// virtio-fs only needs memory shared with virtiofsd,
+		// preallocating is left to memclean
+		if s.isMemcleanEnabled() {
+			opts["prealloc"] = "on"
 		}
 	} else {
 		opts = map[string]string{
@@ -1006,7 +1032,13 @@ func (s *SKVMGuestInstance) initMemDescFromMemoryInfo(
 ) error {
 	memSize := s.Desc.Mem
 	memSlots := make([]*desc.SMemSlot, 0)
+	var virtioMem *monitor.PcdimmDeviceInfo
 	for i := 0; i < len(memoryDevicesInfoList); i++ {
+		if memoryDevicesInfoList[i].Type == "virtio-mem" && virtioMem == nil {
+			virtioMem = &memoryDevicesInfoList[i].Data
+			memSize -= virtioMem.RequestedSize / 1024 / 1024
+			continue
+		}
 		if memoryDevicesInfoList[i].Type != "dimm" || memoryDevicesInfoList[i].Data.ID == nil {
 			return errors.Errorf("unsupported memory device type %s", memoryDevicesInfoList[i].Type)
 		}
@@ -1032,6 +1064,9 @@ func (s *SKVMGuestInstance) initMemDescFromMemoryInfo(
 		s.initDefaultMemObject(memSize)
 	}
 	s.Desc.MemDesc.MemSlots = memSlots
+	if virtioMem != nil {
+		s.initVirtioMemDescFromMemoryInfo(virtioMem)
+	}
 	return nil
 }
 
@@ -1040,6 +1075,11 @@ func (s *SKVMGuestInstance) fixGuestMachineType() {
 		s.Desc.Machine = api.VM_MACHINE_TYPE_Q35
 		s.Desc.Bios = qemu.BIOS_UEFI
 	}
+	// secure boot requires SMM which is only provided by q35 on x86
+	if s.isSecureBootEnabled() && s.manager.host.IsX8664() {
+		s.Desc.Machine = api.VM_MACHINE_TYPE_Q35
+		s.Desc.Bios = qemu.BIOS_UEFI
+	}
 	if s.manager.host.IsAarch64() {
 		if utils.IsInStringArray(s.Desc.Machine, []string{
 			"", api.VM_MACHINE_TYPE_PC, api.VM_MACHINE_TYPE_Q35,
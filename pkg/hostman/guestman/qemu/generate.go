@@ -175,14 +175,26 @@ func generateMemoryOption(memDesc *desc.SGuestMem) string {
 		cmds = append(cmds, generateObjectOption(memObj.Object))
 		cmds = append(cmds, fmt.Sprintf("-device %s,id=%s,memdev=%s", memDev.Type, memDev.Id, memObj.Id))
 	}
+	if memDesc.VirtioMem != nil {
+		cmds = append(cmds, generateObjectOption(memDesc.VirtioMem.MemObj.Object))
+	}
 	return strings.Join(cmds, " ")
 }
 
-func generateMachineOption(machine string, machineDesc *desc.SGuestMachine) string {
+func generateVirtioMemOption(virtioMem *desc.SGuestVirtioMem) string {
+	cmd := generatePCIDeviceOption(virtioMem.PCIDevice)
+	cmd += fmt.Sprintf(",memdev=%s,requested-size=%dM", virtioMem.MemObj.Id, virtioMem.RequestedSizeMB)
+	return cmd
+}
+
+func generateMachineOption(machine string, machineDesc *desc.SGuestMachine, smm bool) string {
 	cmd := fmt.Sprintf("-machine %s,accel=%s", machine, machineDesc.Accel)
 	if machineDesc.GicVersion != nil {
 		cmd += fmt.Sprintf(",gic-version=%s", *machineDesc.GicVersion)
 	}
+	if smm {
+		cmd += ",smm=on"
+	}
 
 	return cmd
 }
@@ -622,6 +634,39 @@ func generatePvpanicDeviceOption(pvpanic *desc.SGuestPvpanic) string {
 	return fmt.Sprintf("-device pvpanic,id=%s,ioport=0x%x", pvpanic.Id, pvpanic.Ioport)
 }
 
+func generateWatchdogOptions(watchdog *desc.SGuestWatchdog) []string {
+	opts := make([]string, 0)
+	if watchdog.Pci != nil {
+		opts = append(opts, generatePCIDeviceOption(watchdog.Pci))
+	} else {
+		opts = append(opts, fmt.Sprintf("-device %s,id=%s", watchdog.Model, watchdog.Id))
+	}
+	// watchdog expiration is handled by host on WATCHDOG event
+	opts = append(opts, "-watchdog-action none")
+	return opts
+}
+
+func generateTpmOptions(tpm *desc.SGuestTpm) []string {
+	opts := make([]string, 0)
+	opts = append(opts, chardevOption(tpm.Socket))
+	opts = append(opts, fmt.Sprintf("-tpmdev emulator,id=%s,chardev=%s", tpm.Id, tpm.Socket.Id))
+	opts = append(opts, fmt.Sprintf("-device %s,tpmdev=%s", tpm.Model, tpm.Id))
+	return opts
+}
+
+func generateFilesystemOptions(filesystems []*desc.SGuestFilesystem) []string {
+	opts := make([]string, 0)
+	for i := range filesystems {
+		opts = append(opts, chardevOption(filesystems[i].Socket))
+		opts = append(opts, generatePCIDeviceOption(filesystems[i].Pci))
+	}
+	return opts
+}
+
+func generateBalloonOption(balloon *desc.SGuestBalloon) string {
+	return generatePCIDeviceOption(balloon.PCIDevice)
+}
+
 func getMigrateOptions(drvOpt QemuOptions, input *GenerateStartOptionsInput) []string {
 	opts := make([]string, 0)
 	if input.NeedMigrate {
@@ -652,6 +697,9 @@ type GenerateStartOptionsInput struct {
 	OVNIntegrationBridge string
 	Devices              []string
 	OVMFPath             string
+	SecureBoot           bool
+	OVMFCodePath         string
+	OVMFVarsTemplatePath string
 	VNCPort              uint
 	VNCPassword          bool
 	EnableLog            bool
@@ -724,7 +772,7 @@ func GenerateStartOptions(
 		drvOpt.Nodefconfig(),
 		// drvOpt.NoKVMPitReinjection(),
 		drvOpt.Global(),
-		generateMachineOption(input.GuestDesc.Machine, input.GuestDesc.MachineDesc),
+		generateMachineOption(input.GuestDesc.Machine, input.GuestDesc.MachineDesc, input.SecureBoot && input.QemuArch == Arch_x86_64),
 		drvOpt.KeyboardLayoutLanguage("en-us"),
 		generateSMPOption(input.GuestDesc),
 		drvOpt.Name(input.GuestDesc.Name),
@@ -749,7 +797,16 @@ func GenerateStartOptions(
 	opts = append(opts, drvOpt.Boot(bootOrder, enableMenu))
 
 	// bios
-	if input.GuestDesc.Bios == BIOS_UEFI {
+	if input.GuestDesc.Bios == BIOS_UEFI && input.SecureBoot {
+		if input.OVMFCodePath == "" || input.OVMFVarsTemplatePath == "" {
+			return "", errors.Errorf("input secure boot OVMF path is empty")
+		}
+		fmOpt, err := drvOpt.SecureBootBIOS(input.OVMFCodePath, input.OVMFVarsTemplatePath, input.HomeDir)
+		if err != nil {
+			return "", errors.Wrap(err, "secure boot bios option")
+		}
+		opts = append(opts, fmOpt)
+	} else if input.GuestDesc.Bios == BIOS_UEFI {
 		if input.OVMFPath == "" {
 			return "", errors.Errorf("input OVMF path is empty")
 		}
@@ -870,6 +927,26 @@ func GenerateStartOptions(
 		opts = append(opts, generateISASerialOptions(input.GuestDesc.IsaSerial)...)
 	}
 
+	// vTPM device
+	if input.GuestDesc.Tpm != nil {
+		opts = append(opts, generateTpmOptions(input.GuestDesc.Tpm)...)
+	}
+
+	// virtio-fs shared filesystems
+	if len(input.GuestDesc.Filesystems) > 0 {
+		opts = append(opts, generateFilesystemOptions(input.GuestDesc.Filesystems)...)
+	}
+
+	// memory balloon device
+	if input.GuestDesc.Balloon != nil {
+		opts = append(opts, generateBalloonOption(input.GuestDesc.Balloon))
+	}
+
+	// virtio-mem device
+	if input.GuestDesc.MemDesc.VirtioMem != nil {
+		opts = append(opts, generateVirtioMemOption(input.GuestDesc.MemDesc.VirtioMem))
+	}
+
 	// migrate options
 	opts = append(opts, getMigrateOptions(drvOpt, input)...)
 
@@ -878,5 +955,10 @@ func GenerateStartOptions(
 		opts = append(opts, generatePvpanicDeviceOption(input.GuestDesc.Pvpanic))
 	}
 
+	// watchdog device
+	if input.GuestDesc.Watchdog != nil {
+		opts = append(opts, generateWatchdogOptions(input.GuestDesc.Watchdog)...)
+	}
+
 	return strings.Join(opts, " "), nil
 }
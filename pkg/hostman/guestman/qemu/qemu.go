@@ -97,6 +97,7 @@ type QemuOptions interface {
 	MemFd(sizeMB uint64) string
 	Boot(order *string, enableMenu bool) string
 	BIOS(ovmfPath, homedir string) (string, error)
+	SecureBootBIOS(codePath, varsTemplatePath, homedir string) (string, error)
 	Device(devStr string) string
 	Drive(driveStr string) string
 	Chardev(backend string, id string, name string) string
@@ -285,6 +286,23 @@ func (o baseOptions) BIOS(ovmfPath, homedir string) (string, error) {
 	), nil
 }
 
+// SecureBootBIOS use split OVMF code and per guest vars file copied from
+// template with Microsoft keys enrolled, pflash must be protected by SMM
+func (o baseOptions) SecureBootBIOS(codePath, varsTemplatePath, homedir string) (string, error) {
+	ovmfVarsPath := path.Join(homedir, "OVMF_VARS.secboot.fd")
+	if !fileutils2.Exists(ovmfVarsPath) {
+		err := procutils.NewRemoteCommandAsFarAsPossible("cp", "-f", varsTemplatePath, ovmfVarsPath).Run()
+		if err != nil {
+			return "", errors.Wrap(err, "failed copy secure boot ovmf vars")
+		}
+	}
+	return fmt.Sprintf(
+		"-global driver=cfi.pflash01,property=secure,value=on "+
+			"-drive if=pflash,format=raw,unit=0,file=%s,readonly=on -drive if=pflash,format=raw,unit=1,file=%s",
+		codePath, ovmfVarsPath,
+	), nil
+}
+
 func (o baseOptions) Device(devStr string) string {
 	return "-device " + devStr
 }
@@ -18,6 +18,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
 )
 
 func Test_baseOptions(t *testing.T) {
@@ -43,4 +45,48 @@ func Test_baseOptions(t *testing.T) {
 	// test vnc
 	assert.Equal("-vnc :5900,password", opt.VNC(5900, true))
 	assert.Equal("-vnc :5900", opt.VNC(5900, false))
+	// test tpm
+	socket := desc.NewCharDev("socket", "chrtpm", "")
+	socket.Options = map[string]string{"path": "/tmp/swtpm.sock"}
+	assert.Equal([]string{
+		"-chardev socket,id=chrtpm,path=/tmp/swtpm.sock",
+		"-tpmdev emulator,id=tpm0,chardev=chrtpm",
+		"-device tpm-crb,tpmdev=tpm0",
+	}, generateTpmOptions(&desc.SGuestTpm{Socket: socket, Id: "tpm0", Model: "tpm-crb"}))
+	// test virtio-fs
+	fsSocket := desc.NewCharDev("socket", "charfs0", "")
+	fsSocket.Options = map[string]string{"path": "/tmp/virtiofsd.sock"}
+	fsPci := desc.NewPCIDevice(desc.CONTROLLER_TYPE_PCI_ROOT, "vhost-user-fs-pci", "fs0")
+	fsPci.PCIAddr = &desc.PCIAddr{Slot: 5}
+	fsPci.Options = map[string]string{"tag": "share"}
+	assert.Equal([]string{
+		"-chardev socket,id=charfs0,path=/tmp/virtiofsd.sock",
+		"-device vhost-user-fs-pci,id=fs0,bus=pci.0,addr=0x05,tag=share",
+	}, generateFilesystemOptions([]*desc.SGuestFilesystem{{Socket: fsSocket, Pci: fsPci}}))
+	// test balloon
+	balloonPci := desc.NewPCIDevice(desc.CONTROLLER_TYPE_PCI_ROOT, "virtio-balloon-pci", "balloon0")
+	balloonPci.PCIAddr = &desc.PCIAddr{Slot: 6}
+	balloonPci.Options = map[string]string{"free-page-reporting": "on"}
+	assert.Equal("-device virtio-balloon-pci,id=balloon0,bus=pci.0,addr=0x06,free-page-reporting=on",
+		generateBalloonOption(&desc.SGuestBalloon{PCIDevice: balloonPci}))
+	// test virtio-mem
+	vmemPci := desc.NewPCIDevice(desc.CONTROLLER_TYPE_PCI_ROOT, "virtio-mem-pci", "vmem0")
+	vmemPci.PCIAddr = &desc.PCIAddr{Slot: 7}
+	assert.Equal("-device virtio-mem-pci,id=vmem0,bus=pci.0,addr=0x07,memdev=memvmem0,requested-size=3072M",
+		generateVirtioMemOption(&desc.SGuestVirtioMem{
+			PCIDevice:       vmemPci,
+			MemObj:          desc.NewMemDesc("memory-backend-ram", "memvmem0", nil, nil),
+			RequestedSizeMB: 3072,
+		}))
+	// test watchdog
+	assert.Equal([]string{
+		"-device ib700,id=watchdog0",
+		"-watchdog-action none",
+	}, generateWatchdogOptions(&desc.SGuestWatchdog{Id: "watchdog0", Model: "ib700"}))
+	watchdogPci := desc.NewPCIDevice(desc.CONTROLLER_TYPE_PCI_ROOT, "i6300esb", "watchdog0")
+	watchdogPci.PCIAddr = &desc.PCIAddr{Slot: 7}
+	assert.Equal([]string{
+		"-device i6300esb,id=watchdog0,bus=pci.0,addr=0x07",
+		"-watchdog-action none",
+	}, generateWatchdogOptions(&desc.SGuestWatchdog{Id: "watchdog0", Model: "i6300esb", Pci: watchdogPci}))
 }
@@ -0,0 +1,219 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// unix socket path is limited to 108 bytes, use a short id under guest home dir
+func getFilesystemShortId(fs *desc.SGuestFilesystem) string {
+	if len(fs.Id) > 8 {
+		return fs.Id[:8]
+	}
+	return fs.Id
+}
+
+func getFilesystemDeviceId(fs *desc.SGuestFilesystem) string {
+	return fmt.Sprintf("fs-%s", getFilesystemShortId(fs))
+}
+
+func getFilesystemChardevId(fs *desc.SGuestFilesystem) string {
+	return fmt.Sprintf("charfs-%s", getFilesystemShortId(fs))
+}
+
+func (s *SKVMGuestInstance) getVirtiofsdSocketPath(fs *desc.SGuestFilesystem) string {
+	return path.Join(s.HomeDir(), fmt.Sprintf("virtiofsd-%s.sock", getFilesystemShortId(fs)))
+}
+
+func (s *SKVMGuestInstance) getVirtiofsdPidFilePath(fs *desc.SGuestFilesystem) string {
+	return path.Join(s.HomeDir(), fmt.Sprintf("virtiofsd-%s.pid", getFilesystemShortId(fs)))
+}
+
+func (s *SKVMGuestInstance) getVirtiofsdLogPath(fs *desc.SGuestFilesystem) string {
+	return path.Join(s.HomeDir(), fmt.Sprintf("virtiofsd-%s.log", getFilesystemShortId(fs)))
+}
+
+// vhost-user devices require guest memory shared with the backend process
+func (s *SKVMGuestInstance) isMemShareRequired() bool {
+	return s.isMemcleanEnabled() || len(s.Desc.Filesystems) > 0
+}
+
+func (s *SKVMGuestInstance) isGuestMemShared() bool {
+	if s.Desc.MemDesc == nil || s.Desc.MemDesc.Mem == nil || s.Desc.MemDesc.Mem.Object == nil {
+		return false
+	}
+	return s.Desc.MemDesc.Mem.Options["share"] == "on"
+}
+
+func (s *SKVMGuestInstance) getFilesystemSourcePath(fs *desc.SGuestFilesystem) (string, error) {
+	switch fs.SourceType {
+	case api.GUEST_FILESYSTEM_SOURCE_HOST_PATH:
+		return fs.HostPath, nil
+	case api.GUEST_FILESYSTEM_SOURCE_STORAGE:
+		storage := storageman.GetManager().GetStorage(fs.StorageId)
+		if storage == nil {
+			return "", errors.Wrapf(errors.ErrNotFound, "storage %s", fs.StorageId)
+		}
+		return path.Join(storage.GetPath(), fs.SubPath), nil
+	default:
+		return "", errors.Errorf("unknown filesystem source type %q", fs.SourceType)
+	}
+}
+
+func (s *SKVMGuestInstance) initFilesystemDesc(fs *desc.SGuestFilesystem, cType desc.PCI_CONTROLLER_TYPE) error {
+	sourcePath, err := s.getFilesystemSourcePath(fs)
+	if err != nil {
+		return errors.Wrapf(err, "get filesystem %s source path", fs.MountTag)
+	}
+	fs.SourcePath = sourcePath
+
+	chardevId := getFilesystemChardevId(fs)
+	fs.Socket = desc.NewCharDev("socket", chardevId, "")
+	fs.Socket.Options = map[string]string{
+		"path": s.getVirtiofsdSocketPath(fs),
+	}
+	fs.Pci = desc.NewPCIDevice(cType, "vhost-user-fs-pci", getFilesystemDeviceId(fs))
+	fs.Pci.Options = map[string]string{
+		"chardev":    chardevId,
+		"tag":        fs.MountTag,
+		"queue-size": "1024",
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) initGuestFilesystems(pciRoot, pciBridge *desc.PCIController) error {
+	cont := pciRoot
+	if pciBridge != nil {
+		cont = pciBridge
+	}
+	for i := 0; i < len(s.Desc.Filesystems); i++ {
+		if err := s.initFilesystemDesc(s.Desc.Filesystems[i], cont.CType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single shell word, source path comes from user input
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (s *SKVMGuestInstance) generateVirtiofsdStartScript(fs *desc.SGuestFilesystem) string {
+	socketPath := s.getVirtiofsdSocketPath(fs)
+	cmd := fmt.Sprintf("VIRTIOFSD_PID_FILE=%s\n", s.getVirtiofsdPidFilePath(fs))
+	cmd += "if [ -f $VIRTIOFSD_PID_FILE ]; then\n"
+	cmd += "  kill -9 `cat $VIRTIOFSD_PID_FILE` > /dev/null 2>&1\n"
+	cmd += "  rm -f $VIRTIOFSD_PID_FILE\n"
+	cmd += "fi\n"
+	cmd += fmt.Sprintf("mkdir -p %s\n", shellQuote(fs.SourcePath))
+	cmd += fmt.Sprintf("rm -f %s\n", socketPath)
+	cmd += fmt.Sprintf("nohup %s --socket-path=%s --shared-dir=%s --cache=auto",
+		options.HostOptions.VirtiofsdPath, socketPath, shellQuote(fs.SourcePath))
+	if fs.ReadOnly {
+		cmd += " --readonly"
+	}
+	cmd += fmt.Sprintf(" > %s 2>&1 &\n", s.getVirtiofsdLogPath(fs))
+	cmd += "echo $! > $VIRTIOFSD_PID_FILE\n"
+	// qemu fails to connect the chardev if virtiofsd socket isn't ready
+	cmd += "for i in $(seq 1 50); do\n"
+	cmd += fmt.Sprintf("  if [ -S %s ]; then\n", socketPath)
+	cmd += "    break\n"
+	cmd += "  fi\n"
+	cmd += "  sleep 0.1\n"
+	cmd += "done\n"
+	return cmd
+}
+
+func (s *SKVMGuestInstance) generateVirtiofsdStopScript(fs *desc.SGuestFilesystem) string {
+	cmd := fmt.Sprintf("VIRTIOFSD_PID_FILE=%s\n", s.getVirtiofsdPidFilePath(fs))
+	cmd += "if [ -f $VIRTIOFSD_PID_FILE ]; then\n"
+	cmd += "  kill `cat $VIRTIOFSD_PID_FILE` > /dev/null 2>&1\n"
+	cmd += "  rm -f $VIRTIOFSD_PID_FILE\n"
+	cmd += "fi\n"
+	cmd += fmt.Sprintf("rm -f %s\n", s.getVirtiofsdSocketPath(fs))
+	return cmd
+}
+
+func (s *SKVMGuestInstance) generateFilesystemsStartScript() string {
+	cmd := ""
+	for i := range s.Desc.Filesystems {
+		cmd += s.generateVirtiofsdStartScript(s.Desc.Filesystems[i])
+	}
+	return cmd
+}
+
+// stop all virtiofsd of this guest, include the ones hot detached
+// while their pid files left behind
+func (s *SKVMGuestInstance) generateFilesystemsStopScript() string {
+	cmd := fmt.Sprintf("for f in $(ls %s/virtiofsd-*.pid 2>/dev/null)\n", s.HomeDir())
+	cmd += "do\n"
+	cmd += "  kill `cat $f` > /dev/null 2>&1\n"
+	cmd += "  rm -f $f\n"
+	cmd += "done\n"
+	cmd += fmt.Sprintf("rm -f %s/virtiofsd-*.sock\n", s.HomeDir())
+	return cmd
+}
+
+func (s *SKVMGuestInstance) startVirtiofsd(fs *desc.SGuestFilesystem) error {
+	script := s.generateVirtiofsdStartScript(fs)
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("sh", "-c", script).Output()
+	if err != nil {
+		return errors.Wrapf(err, "start virtiofsd for %s: %s", fs.MountTag, out)
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) stopVirtiofsd(fs *desc.SGuestFilesystem) {
+	script := s.generateVirtiofsdStopScript(fs)
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("sh", "-c", script).Output()
+	if err != nil {
+		log.Errorf("stop virtiofsd for %s: %s %s", fs.MountTag, err, out)
+	}
+}
+
+func (s *SKVMGuestInstance) compareDescFilesystems(newDesc *desc.SGuestDesc) ([]*desc.SGuestFilesystem, []*desc.SGuestFilesystem) {
+	var delFs, addFs = []*desc.SGuestFilesystem{}, []*desc.SGuestFilesystem{}
+	for _, fs := range newDesc.Filesystems {
+		newFs := *fs
+		addFs = append(addFs, &newFs)
+	}
+	for _, oldFs := range s.Desc.Filesystems {
+		var find = false
+		for idx, fs := range addFs {
+			if oldFs.Id == fs.Id {
+				addFs = append(addFs[:idx], addFs[idx+1:]...)
+				find = true
+				break
+			}
+		}
+		if !find {
+			delFs = append(delFs, oldFs)
+		}
+	}
+	return delFs, addFs
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/arch"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+)
+
+type sFakeHugepageHost struct {
+	hostutils.IHost
+	hugepages bool
+}
+
+func (h *sFakeHugepageHost) IsHugepagesEnabled() bool {
+	return h.hugepages
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, s := range []string{
+		"/opt/share",
+		"/opt/it's shared",
+		"/opt/'; touch /tmp/pwned; '",
+		"/opt/$(id)`id`",
+	} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("run quoted %q: %v", s, err)
+		}
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) is read by shell as %q", s, out)
+		}
+	}
+}
+
+func TestGenerateVirtiofsdStartScript(t *testing.T) {
+	s := &SKVMGuestInstance{
+		sBaseGuestInstance: newBaseGuestInstance("guest-id", &SGuestManager{ServersPath: "/servers"}, api.HYPERVISOR_KVM),
+	}
+	fs := &desc.SGuestFilesystem{}
+	fs.Id = "0123456789"
+	fs.SourcePath = "/opt/a'b"
+	script := s.generateVirtiofsdStartScript(fs)
+	if !strings.Contains(script, `--shared-dir='/opt/a'\''b'`) {
+		t.Errorf("shared dir is not quoted in script %q", script)
+	}
+	if !strings.Contains(script, `mkdir -p '/opt/a'\''b'`) {
+		t.Errorf("source path is not quoted in script %q", script)
+	}
+}
+
+func TestGetMemObjectOptions(t *testing.T) {
+	for _, c := range []struct {
+		name         string
+		hugepages    bool
+		memclean     bool
+		filesystems  int
+		wantShare    bool
+		wantPrealloc bool
+	}{
+		{name: "default"},
+		{name: "virtiofs", filesystems: 1, wantShare: true},
+		{name: "memclean", memclean: true, wantShare: true, wantPrealloc: true},
+		{name: "virtiofs memclean", memclean: true, filesystems: 1, wantShare: true, wantPrealloc: true},
+		{name: "hugepages", hugepages: true, filesystems: 1, wantShare: true, wantPrealloc: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			manager := &SGuestManager{host: &sFakeHugepageHost{hugepages: c.hugepages}}
+			s := &SKVMGuestInstance{
+				archMan:            arch.NewArch(arch.Arch_x86_64),
+				sBaseGuestInstance: newBaseGuestInstance("guest-id", manager, api.HYPERVISOR_KVM),
+			}
+			s.Desc = &desc.SGuestDesc{}
+			s.Desc.Metadata = map[string]string{}
+			if c.memclean {
+				s.Desc.Metadata["enable_memclean"] = "true"
+			}
+			for i := 0; i < c.filesystems; i++ {
+				s.Desc.Filesystems = append(s.Desc.Filesystems, &desc.SGuestFilesystem{})
+			}
+			opts := s.getMemObjectOptions(1024, "uuid", nil)
+			if got := opts["share"] == "on"; got != c.wantShare {
+				t.Errorf("share = %v, want %v: %v", got, c.wantShare, opts)
+			}
+			if got := opts["prealloc"] == "on"; got != c.wantPrealloc {
+				t.Errorf("prealloc = %v, want %v: %v", got, c.wantPrealloc, opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+const (
+	VIRTIO_MEM_DEVICE_ID = "vmem0"
+	VIRTIO_MEM_OBJECT_ID = "memvmem0"
+
+	// default virtio-mem block size on x86_64, plugged size must be multiple of it
+	VIRTIO_MEM_BLOCK_SIZE_MB = 2
+
+	// qemu monitor may hang, don't let it block the hotplug task
+	VIRTIO_MEM_MONITOR_TIMEOUT = 10 * time.Second
+)
+
+func (s *SKVMGuestInstance) isVirtioMemEnabled() bool {
+	return s.Desc.Metadata[api.VM_METADATA_ENABLE_VIRTIO_MEM] == "true"
+}
+
+// virtio-mem is not used with hugepages or numa pinned memory,
+// those guests fallback to pc-dimm hotplug
+func (s *SKVMGuestInstance) isVirtioMemSupported() bool {
+	return s.isVirtioMemEnabled() && s.manager.host.IsX8664() &&
+		!s.manager.host.IsHugepagesEnabled() && len(s.Desc.CpuNumaPin) == 0
+}
+
+func alignVirtioMemSize(sizeMB int64) int64 {
+	return (sizeMB + VIRTIO_MEM_BLOCK_SIZE_MB - 1) / VIRTIO_MEM_BLOCK_SIZE_MB * VIRTIO_MEM_BLOCK_SIZE_MB
+}
+
+func (s *SKVMGuestInstance) getVirtioMemBootSize(memSizeMB int64) int64 {
+	bootSize := int64(options.HostOptions.VirtioMemBootMemSizeMb)
+	if bootSize <= 0 || bootSize > memSizeMB {
+		bootSize = memSizeMB
+	}
+	return bootSize
+}
+
+func (s *SKVMGuestInstance) newVirtioMemObject(regionSizeMB int64) *desc.SMemDesc {
+	memObj := desc.NewMemDesc(s.memObjectType(), VIRTIO_MEM_OBJECT_ID, nil, nil)
+	memObj.Options = map[string]string{
+		"size": fmt.Sprintf("%dM", regionSizeMB),
+		// region is only populated as guest plugs memory blocks
+		"reserve": "off",
+	}
+	if s.isMemShareRequired() {
+		memObj.Options["share"] = "on"
+	}
+	return memObj
+}
+
+// initVirtioMemDesc splits guest memory into boot memory and virtio-mem memory,
+// returns boot memory size
+func (s *SKVMGuestInstance) initVirtioMemDesc(memSizeMB int64) int64 {
+	bootSize := s.getVirtioMemBootSize(memSizeMB)
+	regionSize := int64(s.Desc.MemDesc.MaxMem) - bootSize
+	regionSize = regionSize / VIRTIO_MEM_BLOCK_SIZE_MB * VIRTIO_MEM_BLOCK_SIZE_MB
+	s.Desc.MemDesc.VirtioMem = &desc.SGuestVirtioMem{
+		MemObj:          s.newVirtioMemObject(regionSize),
+		RequestedSizeMB: alignVirtioMemSize(memSizeMB - bootSize),
+		RegionSizeMB:    regionSize,
+	}
+	return bootSize
+}
+
+func newVirtioMemPciDevice(pciRoot *desc.PCIController) *desc.PCIDevice {
+	return desc.NewPCIDevice(pciRoot.CType, "virtio-mem-pci", VIRTIO_MEM_DEVICE_ID)
+}
+
+func (s *SKVMGuestInstance) initVirtioMemDevice(pciRoot *desc.PCIController) {
+	if s.Desc.MemDesc.VirtioMem != nil {
+		s.Desc.MemDesc.VirtioMem.PCIDevice = newVirtioMemPciDevice(pciRoot)
+	}
+}
+
+func (s *SKVMGuestInstance) initVirtioMemDescFromMemoryInfo(info *monitor.PcdimmDeviceInfo) {
+	regionSize := info.MaxSize / 1024 / 1024
+	s.Desc.MemDesc.VirtioMem = &desc.SGuestVirtioMem{
+		MemObj:          s.newVirtioMemObject(regionSize),
+		RequestedSizeMB: info.RequestedSize / 1024 / 1024,
+		RegionSizeMB:    regionSize,
+	}
+}
+
+func (s *SKVMGuestInstance) setVirtioMemRequestedSize(sizeMB int64) error {
+	var errChan = make(chan error, 1)
+	s.Monitor.SetVirtioMemRequestedSize(s.Desc.MemDesc.VirtioMem.Id, sizeMB, func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Errorf(res)
+		} else {
+			errChan <- nil
+		}
+	})
+	return waitBalloonMonitor(errChan, VIRTIO_MEM_MONITOR_TIMEOUT)
+}
+
+// getVirtioMemSize returns memory size in MB plugged to guest
+func (s *SKVMGuestInstance) getVirtioMemSize() (int64, error) {
+	var (
+		sizeChan = make(chan int64, 1)
+		errChan  = make(chan error, 1)
+	)
+	s.Monitor.GetVirtioMemSize(s.Desc.MemDesc.VirtioMem.Id, func(res int64, err string) {
+		if len(err) > 0 {
+			errChan <- errors.Errorf(err)
+		} else {
+			sizeChan <- res / 1024 / 1024
+			errChan <- nil
+		}
+	})
+	if err := waitBalloonMonitor(errChan, VIRTIO_MEM_MONITOR_TIMEOUT); err != nil {
+		return 0, err
+	}
+	return <-sizeChan, nil
+}
+
+// sVirtioMemResizer plugs or unplugs virtio-mem memory through setSize and
+// polls the plugged size through getSize
+type sVirtioMemResizer struct {
+	setSize  func(sizeMB int64) error
+	getSize  func() (int64, error)
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// resize requests guest to plug or unplug memory and waits until guest
+// finished, returns memory size in MB actually plugged.
+func (r *sVirtioMemResizer) resize(requestedSizeMB int64) (int64, error) {
+	if err := r.setSize(requestedSizeMB); err != nil {
+		return -1, errors.Wrap(err, "set virtio-mem requested size")
+	}
+	var (
+		size     int64
+		err      error
+		deadline = time.Now().Add(r.timeout)
+	)
+	for {
+		size, err = r.getSize()
+		if err != nil {
+			return -1, errors.Wrap(err, "get virtio-mem size")
+		}
+		if size == requestedSizeMB || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(r.interval)
+	}
+	if size != requestedSizeMB {
+		// keep requested size consistent with memory guest holds,
+		// otherwise guest keeps trying unplug in background
+		if err := r.setSize(size); err != nil {
+			return size, errors.Wrap(err, "reset virtio-mem requested size")
+		}
+		return size, errors.Errorf("guest plugged %dMB of requested %dMB virtio-mem memory", size, requestedSizeMB)
+	}
+	return size, nil
+}
+
+// resizeVirtioMem requests guest to plug or unplug memory and waits until
+// guest finished, returns memory size in MB actually plugged.
+func (s *SKVMGuestInstance) resizeVirtioMem(requestedSizeMB int64) (int64, error) {
+	r := &sVirtioMemResizer{
+		setSize:  s.setVirtioMemRequestedSize,
+		getSize:  s.getVirtioMemSize,
+		timeout:  time.Duration(options.HostOptions.VirtioMemResizeTimeoutSecond) * time.Second,
+		interval: time.Second,
+	}
+	return r.resize(requestedSizeMB)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type sTestVirtioMem struct {
+	// plugged sizes returned by successive queries, the last one is repeated
+	sizes     []int64
+	requested []int64
+	setErr    error
+	getErr    error
+}
+
+func (m *sTestVirtioMem) setSize(sizeMB int64) error {
+	if m.setErr != nil {
+		return m.setErr
+	}
+	m.requested = append(m.requested, sizeMB)
+	return nil
+}
+
+func (m *sTestVirtioMem) getSize() (int64, error) {
+	if m.getErr != nil {
+		return 0, m.getErr
+	}
+	size := m.sizes[0]
+	if len(m.sizes) > 1 {
+		m.sizes = m.sizes[1:]
+	}
+	return size, nil
+}
+
+func TestResizeVirtioMem(t *testing.T) {
+	for _, c := range []struct {
+		name          string
+		mem           *sTestVirtioMem
+		requested     int64
+		wantSize      int64
+		wantRequested []int64
+		wantErr       bool
+	}{
+		{
+			name:          "guest plugs all requested memory",
+			mem:           &sTestVirtioMem{sizes: []int64{0, 512, 1024}},
+			requested:     1024,
+			wantSize:      1024,
+			wantRequested: []int64{1024},
+		},
+		{
+			name:          "guest releases only part of memory",
+			mem:           &sTestVirtioMem{sizes: []int64{2048, 1536}},
+			requested:     1024,
+			wantSize:      1536,
+			wantRequested: []int64{1024, 1536},
+			wantErr:       true,
+		},
+		{
+			name:      "set requested size fails",
+			mem:       &sTestVirtioMem{sizes: []int64{0}, setErr: errors.ErrTimeout},
+			requested: 1024,
+			wantSize:  -1,
+			wantErr:   true,
+		},
+		{
+			name:          "query size fails",
+			mem:           &sTestVirtioMem{getErr: errors.ErrTimeout},
+			requested:     1024,
+			wantSize:      -1,
+			wantRequested: []int64{1024},
+			wantErr:       true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := &sVirtioMemResizer{
+				setSize:  c.mem.setSize,
+				getSize:  c.mem.getSize,
+				timeout:  50 * time.Millisecond,
+				interval: time.Millisecond,
+			}
+			size, err := r.resize(c.requested)
+			if size != c.wantSize {
+				t.Errorf("size %d, want %d", size, c.wantSize)
+			}
+			if (err != nil) != c.wantErr {
+				t.Errorf("err %v, want err %v", err, c.wantErr)
+			}
+			if len(c.mem.requested) != len(c.wantRequested) {
+				t.Fatalf("requested sizes %v, want %v", c.mem.requested, c.wantRequested)
+			}
+			for i := range c.wantRequested {
+				if c.mem.requested[i] != c.wantRequested[i] {
+					t.Errorf("requested sizes %v, want %v", c.mem.requested, c.wantRequested)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	identity_modules "yunion.io/x/onecloud/pkg/mcclient/modules/identity"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+func (s *SKVMGuestInstance) isTpmEnabled() bool {
+	return s.Desc.Metadata[api.VM_METADATA_ENABLE_TPM] == "true"
+}
+
+func (s *SKVMGuestInstance) isSecureBootEnabled() bool {
+	return s.Desc.Metadata[api.VM_METADATA_SECURE_BOOT] == "true"
+}
+
+func (s *SKVMGuestInstance) getTpmSocketPath() string {
+	return path.Join(s.HomeDir(), "swtpm.sock")
+}
+
+func (s *SKVMGuestInstance) getTpmPidFilePath() string {
+	return path.Join(s.HomeDir(), "swtpm.pid")
+}
+
+func (s *SKVMGuestInstance) getTpmLogPath() string {
+	return path.Join(s.HomeDir(), "swtpm.log")
+}
+
+// vTPM state is stored beside the system disk on file based storage,
+// so it is shared by hosts of nfs/gpfs storage. Otherwise fallback to guest home dir.
+func (s *SKVMGuestInstance) getTpmStateDir() string {
+	if len(s.Desc.Disks) > 0 {
+		disk := s.Desc.Disks[0]
+		if len(disk.Path) > 0 && utils.IsInStringArray(disk.StorageType, api.FIEL_STORAGE) {
+			return path.Join(path.Dir(disk.Path), fmt.Sprintf("%s.vtpm", s.Id))
+		}
+	}
+	return path.Join(s.HomeDir(), "vtpm")
+}
+
+func (s *SKVMGuestInstance) isTpmStateShared() bool {
+	return len(s.Desc.Disks) > 0 && utils.IsInStringArray(s.Desc.Disks[0].StorageType, api.SHARED_FILE_STORAGE)
+}
+
+func (s *SKVMGuestInstance) initTpmDesc() {
+	if s.isTpmEnabled() {
+		s.Desc.Tpm = s.archMan.GenerateTpmDesc(s.getTpmSocketPath())
+	}
+}
+
+func (s *SKVMGuestInstance) generateSwtpmStartScript(needMigrate bool) string {
+	cmd := fmt.Sprintf("SWTPM_PID_FILE=%s\n", s.getTpmPidFilePath())
+	cmd += "if [ -f $SWTPM_PID_FILE ]; then\n"
+	cmd += "  kill -9 `cat $SWTPM_PID_FILE` > /dev/null 2>&1\n"
+	cmd += "  rm -f $SWTPM_PID_FILE\n"
+	cmd += "fi\n"
+	cmd += fmt.Sprintf("mkdir -p %s\n", s.getTpmStateDir())
+	cmd += fmt.Sprintf("rm -f %s\n", s.getTpmSocketPath())
+	cmd += fmt.Sprintf("%s socket --tpm2 --tpmstate dir=%s,mode=0600 --ctrl type=unixio,path=%s"+
+		" --pid file=$SWTPM_PID_FILE --log file=%s --daemon --terminate",
+		options.HostOptions.SwtpmPath, s.getTpmStateDir(), s.getTpmSocketPath(), s.getTpmLogPath())
+	if s.isTpmStateShared() {
+		// source and dest swtpm use the same state dir on shared storage,
+		// lock must be handed over along with live migration
+		if needMigrate {
+			cmd += " --migration incoming,release-lock-outgoing"
+		} else {
+			cmd += " --migration release-lock-outgoing"
+		}
+	}
+	cmd += "\n"
+	return cmd
+}
+
+func (s *SKVMGuestInstance) generateSwtpmStopScript() string {
+	cmd := fmt.Sprintf("SWTPM_PID_FILE=%s\n", s.getTpmPidFilePath())
+	cmd += "if [ -f $SWTPM_PID_FILE ]; then\n"
+	cmd += "  kill `cat $SWTPM_PID_FILE` > /dev/null 2>&1\n"
+	cmd += "  rm -f $SWTPM_PID_FILE\n"
+	cmd += "fi\n"
+	return cmd
+}
+
+// packTpmState packs vTPM state dir to gzipped tarball, encrypted with
+// encInfo if given
+func packTpmState(stateDir string, encInfo *apis.SEncryptInfo) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	err := filepath.Walk(stateDir, func(fp string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(stateDir, fp)
+		if err != nil {
+			return err
+		}
+		if name == "." || !(info.IsDir() || info.Mode().IsRegular()) {
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "pack %s", stateDir)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "close tar")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrap(err, "close gzip")
+	}
+	if encInfo == nil {
+		return buf.Bytes(), nil
+	}
+	data, err := tpmStateKey(encInfo).Encrypt(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt vtpm state")
+	}
+	return data, nil
+}
+
+// unpackTpmState extracts vTPM state packed by packTpmState to stateDir
+func unpackTpmState(data []byte, stateDir string, encInfo *apis.SEncryptInfo) error {
+	if encInfo != nil {
+		var err error
+		data, err = tpmStateKey(encInfo).Decrypt(data)
+		if err != nil {
+			return errors.Wrap(err, "decrypt vtpm state")
+		}
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "open gzip")
+	}
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return errors.Wrapf(err, "mkdir %s", stateDir)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read tar")
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return errors.Errorf("invalid file %q in vtpm state", hdr.Name)
+		}
+		fp := filepath.Join(stateDir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fp, 0700); err != nil {
+				return errors.Wrapf(err, "mkdir %s", fp)
+			}
+		case tar.TypeReg:
+			content, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return errors.Wrapf(err, "read %s", hdr.Name)
+			}
+			if err := ioutil.WriteFile(fp, content, 0600); err != nil {
+				return errors.Wrapf(err, "write %s", fp)
+			}
+		}
+	}
+}
+
+func tpmStateKey(encInfo *apis.SEncryptInfo) identity_modules.SEncryptKeySecret {
+	return identity_modules.SEncryptKeySecret{
+		Alg: encInfo.Alg,
+		Key: encInfo.Key,
+	}
+}
+
+// SaveTpmStateBackup saves vTPM state to backup storage as input describes.
+// It returns the name of the saved state, or empty if there is no state yet
+func (s *SKVMGuestInstance) SaveTpmStateBackup(ctx context.Context, input *api.InstanceBackupVtpmInput) (string, error) {
+	stateDir := s.getTpmStateDir()
+	if !fileutils2.Exists(stateDir) {
+		return "", nil
+	}
+	data, err := packTpmState(stateDir, input.EncryptInfo)
+	if err != nil {
+		return "", err
+	}
+	backupStorage, err := backupstorage.GetBackupStorage(input.BackupStorageId, input.BackupStorageAccessInfo)
+	if err != nil {
+		return "", errors.Wrap(err, "GetBackupStorage")
+	}
+	tmpPath := path.Join(s.HomeDir(), input.VtpmBackupId)
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return "", errors.Wrapf(err, "write %s", tmpPath)
+	}
+	defer os.Remove(tmpPath)
+	if err := backupStorage.SaveBackupInstanceFrom(ctx, tmpPath, input.VtpmBackupId); err != nil {
+		return "", errors.Wrap(err, "SaveBackupInstanceFrom")
+	}
+	return input.VtpmBackupId, nil
+}
+
+// restoreTpmStateBackup restores vTPM state saved by instance backup if the
+// guest has no vTPM state yet
+func (s *SKVMGuestInstance) restoreTpmStateBackup(ctx context.Context, input *api.InstanceBackupVtpmInput) error {
+	stateDir := s.getTpmStateDir()
+	if fileutils2.Exists(stateDir) {
+		return nil
+	}
+	backupStorage, err := backupstorage.GetBackupStorage(input.BackupStorageId, input.BackupStorageAccessInfo)
+	if err != nil {
+		return errors.Wrap(err, "GetBackupStorage")
+	}
+	tmpPath := path.Join(s.HomeDir(), input.VtpmBackupId)
+	if err := backupStorage.RestoreBackupInstanceTo(ctx, tmpPath, input.VtpmBackupId); err != nil {
+		return errors.Wrap(err, "RestoreBackupInstanceTo")
+	}
+	defer os.Remove(tmpPath)
+	if err := restoreTpmStateFile(tmpPath, stateDir, input.EncryptInfo); err != nil {
+		return err
+	}
+	log.Infof("guest %s vtpm state restored to %s", s.GetName(), stateDir)
+	return nil
+}
+
+// restoreTpmStateFile unpacks vTPM state file fetched from backup storage
+// to stateDir, leaving no partial state to be taken as restored on failure
+func restoreTpmStateFile(fp, stateDir string, encInfo *apis.SEncryptInfo) error {
+	data, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", fp)
+	}
+	if err := unpackTpmState(data, stateDir, encInfo); err != nil {
+		os.RemoveAll(stateDir)
+		return err
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) deleteTpmState(migrated bool) error {
+	// dest guest keeps using the state on shared storage
+	if migrated && s.isTpmStateShared() {
+		return nil
+	}
+	stateDir := s.getTpmStateDir()
+	if !fileutils2.Exists(stateDir) {
+		return nil
+	}
+	if out, err := procutils.NewRemoteCommandAsFarAsPossible("rm", "-rf", stateDir).Output(); err != nil {
+		return errors.Wrapf(err, "remove %s: %s", stateDir, out)
+	}
+	return nil
+}
+
+func (m *SGuestManager) SaveGuestTpmStateBackup(ctx context.Context, sid string, body jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	guest, _ := m.GetKVMServer(sid)
+	if guest == nil {
+		return nil, httperrors.NewNotFoundError("Not found guest by id %s", sid)
+	}
+	if !guest.isTpmEnabled() {
+		return nil, httperrors.NewBadRequestError("Guest %s vTPM not enabled", sid)
+	}
+	input := &api.InstanceBackupVtpmInput{}
+	if err := body.Unmarshal(input); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal vtpm backup input: %v", err)
+	}
+	if len(input.VtpmBackupId) == 0 {
+		return nil, httperrors.NewMissingParameterError("vtpm_backup_id")
+	}
+	vtpmBackupId, err := guest.SaveTpmStateBackup(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	ret := jsonutils.NewDict()
+	ret.Set("vtpm_backup_id", jsonutils.NewString(vtpmBackupId))
+	return ret, nil
+}
+
+// restoreGuestTpmState restores vTPM state of guest created from instance
+// backup, as told by deploy params
+func (m *SGuestManager) restoreGuestTpmState(ctx context.Context, guest GuestRuntimeInstance, body jsonutils.JSONObject) error {
+	if !body.Contains("vtpm_backup") {
+		return nil
+	}
+	kvm, ok := guest.(*SKVMGuestInstance)
+	if !ok || !kvm.isTpmEnabled() {
+		return nil
+	}
+	input := &api.InstanceBackupVtpmInput{}
+	if err := body.Unmarshal(input, "vtpm_backup"); err != nil {
+		return errors.Wrap(err, "unmarshal vtpm_backup")
+	}
+	return kvm.restoreTpmStateBackup(ctx, input)
+}
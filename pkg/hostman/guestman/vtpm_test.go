@@ -0,0 +1,252 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/arch"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+)
+
+func newTestTpmGuest(serversPath string, storageType string, diskPath string) *SKVMGuestInstance {
+	s := &SKVMGuestInstance{
+		archMan:            arch.NewArch(arch.Arch_x86_64),
+		sBaseGuestInstance: newBaseGuestInstance("guest-id", &SGuestManager{ServersPath: serversPath}, api.HYPERVISOR_KVM),
+	}
+	s.Desc = new(desc.SGuestDesc)
+	disk := &desc.SGuestDisk{}
+	disk.Path = diskPath
+	disk.StorageType = storageType
+	s.Desc.Disks = []*desc.SGuestDisk{disk}
+	return s
+}
+
+func newTestEncryptInfo(t *testing.T) *apis.SEncryptInfo {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return &apis.SEncryptInfo{
+		Key: base64.StdEncoding.EncodeToString(key),
+		Alg: seclib2.SYM_ENC_ALG_AES_256,
+	}
+}
+
+func writeTestTpmState(t *testing.T, stateDir string) map[string]string {
+	files := map[string]string{
+		"tpm2-00.permall": "permanent state",
+		"sub/tpm2-00.vol": "volatile state",
+	}
+	for name, content := range files {
+		fp := filepath.Join(stateDir, name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(content), 0600); err != nil {
+			t.Fatalf("write %s: %v", fp, err)
+		}
+	}
+	return files
+}
+
+func checkTestTpmState(t *testing.T, stateDir string, files map[string]string) {
+	for name, content := range files {
+		data, err := ioutil.ReadFile(filepath.Join(stateDir, name))
+		if err != nil {
+			t.Errorf("read restored %s: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("restored %s = %q, want %q", name, data, content)
+		}
+	}
+}
+
+func TestPackTpmState(t *testing.T) {
+	encInfo := newTestEncryptInfo(t)
+	for _, c := range []struct {
+		name    string
+		encInfo *apis.SEncryptInfo
+	}{
+		{"plain", nil},
+		{"encrypted", encInfo},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			srcDir := filepath.Join(tmpDir, "src")
+			files := writeTestTpmState(t, srcDir)
+			data, err := packTpmState(srcDir, c.encInfo)
+			if err != nil {
+				t.Fatalf("packTpmState: %v", err)
+			}
+			for _, content := range files {
+				if c.encInfo != nil && bytes.Contains(data, []byte(content)) {
+					t.Errorf("packed state contains plaintext %q", content)
+				}
+			}
+			dstDir := filepath.Join(tmpDir, "dst")
+			if err := unpackTpmState(data, dstDir, c.encInfo); err != nil {
+				t.Fatalf("unpackTpmState: %v", err)
+			}
+			checkTestTpmState(t, dstDir, files)
+		})
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		srcDir := filepath.Join(tmpDir, "src")
+		writeTestTpmState(t, srcDir)
+		data, err := packTpmState(srcDir, encInfo)
+		if err != nil {
+			t.Fatalf("packTpmState: %v", err)
+		}
+		if err := unpackTpmState(data, filepath.Join(tmpDir, "dst"), newTestEncryptInfo(t)); err == nil {
+			t.Errorf("unpack with wrong key should fail")
+		}
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		content := []byte("evil")
+		tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write(content)
+		tw.Close()
+		gw.Close()
+		tmpDir := t.TempDir()
+		if err := unpackTpmState(buf.Bytes(), filepath.Join(tmpDir, "dst"), nil); err == nil {
+			t.Errorf("unpack should reject path out of state dir")
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "evil")); err == nil {
+			t.Errorf("file written out of state dir")
+		}
+	})
+}
+
+func TestRestoreTpmState(t *testing.T) {
+	encInfo := newTestEncryptInfo(t)
+	tmpDir := t.TempDir()
+	files := writeTestTpmState(t, filepath.Join(tmpDir, "src"))
+	data, err := packTpmState(filepath.Join(tmpDir, "src"), encInfo)
+	if err != nil {
+		t.Fatalf("packTpmState: %v", err)
+	}
+	backup := filepath.Join(tmpDir, "backup.vtpm")
+	if err := ioutil.WriteFile(backup, data, 0600); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		stateDir := filepath.Join(tmpDir, "restored")
+		if err := restoreTpmStateFile(backup, stateDir, encInfo); err != nil {
+			t.Fatalf("restoreTpmStateFile: %v", err)
+		}
+		checkTestTpmState(t, stateDir, files)
+	})
+
+	t.Run("no partial state on failure", func(t *testing.T) {
+		stateDir := filepath.Join(tmpDir, "failed")
+		if err := restoreTpmStateFile(backup, stateDir, newTestEncryptInfo(t)); err == nil {
+			t.Fatalf("restore with wrong key should fail")
+		}
+		if _, err := os.Stat(stateDir); !os.IsNotExist(err) {
+			t.Errorf("state dir %s left after failed restore", stateDir)
+		}
+	})
+
+	t.Run("keep existing state", func(t *testing.T) {
+		s := newTestTpmGuest(tmpDir, api.STORAGE_LOCAL, "")
+		stateDir := s.getTpmStateDir()
+		existing := writeTestTpmState(t, stateDir)
+		// backup storage is not touched when state exists
+		input := &api.InstanceBackupVtpmInput{VtpmBackupId: "backup.vtpm", EncryptInfo: encInfo}
+		if err := s.restoreTpmStateBackup(context.Background(), input); err != nil {
+			t.Fatalf("restoreTpmStateBackup: %v", err)
+		}
+		checkTestTpmState(t, stateDir, existing)
+	})
+}
+
+func TestGenerateSwtpmStartScript(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, c := range []struct {
+		name        string
+		storageType string
+		needMigrate bool
+		stateDir    string
+		want        string
+	}{
+		{
+			name:        "local",
+			storageType: api.STORAGE_LOCAL,
+			stateDir:    "/disks/guest-id.vtpm",
+		},
+		{
+			name:        "local incoming",
+			storageType: api.STORAGE_LOCAL,
+			needMigrate: true,
+			stateDir:    "/disks/guest-id.vtpm",
+		},
+		{
+			name:        "nfs",
+			storageType: api.STORAGE_NFS,
+			stateDir:    "/disks/guest-id.vtpm",
+			want:        "--migration release-lock-outgoing",
+		},
+		{
+			name:        "nfs incoming",
+			storageType: api.STORAGE_NFS,
+			needMigrate: true,
+			stateDir:    "/disks/guest-id.vtpm",
+			want:        "--migration incoming,release-lock-outgoing",
+		},
+		{
+			name:        "rbd",
+			storageType: api.STORAGE_RBD,
+			needMigrate: true,
+			stateDir:    filepath.Join(tmpDir, "guest-id", "vtpm"),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestTpmGuest(tmpDir, c.storageType, "/disks/disk-id")
+			script := s.generateSwtpmStartScript(c.needMigrate)
+			if !strings.Contains(script, "--tpmstate dir="+c.stateDir+",") {
+				t.Errorf("script %q does not use state dir %s", script, c.stateDir)
+			}
+			if len(c.want) > 0 {
+				if !strings.Contains(script, c.want) {
+					t.Errorf("script %q does not contain %q", script, c.want)
+				}
+			} else if strings.Contains(script, "--migration") {
+				t.Errorf("script %q should not contain migration flags", script)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	WATCHDOG_DEVICE_ID = "watchdog0"
+
+	// memory dumps are kept under this dir of system disk storage
+	GUEST_CRASH_DUMP_DIR    = "crash_dumps"
+	GUEST_CRASH_DUMP_SUFFIX = ".dump"
+
+	// qemu monitor may hang, don't let it block crash handling forever
+	WATCHDOG_MONITOR_TIMEOUT = 30 * time.Second
+	// dumping memory blocks the monitor until the whole memory is written
+	GUEST_CRASH_DUMP_TIMEOUT = time.Hour
+)
+
+func (s *SKVMGuestInstance) getWatchdogModel() string {
+	return s.Desc.Metadata[api.VM_METADATA_WATCHDOG]
+}
+
+func (s *SKVMGuestInstance) getCrashAction() string {
+	action := s.Desc.Metadata[api.VM_METADATA_CRASH_ACTION]
+	if !utils.IsInStringArray(action, api.GUEST_CRASH_ACTIONS) {
+		return api.GUEST_CRASH_ACTION_NONE
+	}
+	return action
+}
+
+func newWatchdogDesc(pciRoot *desc.PCIController, model string) *desc.SGuestWatchdog {
+	watchdog := &desc.SGuestWatchdog{
+		Id:    WATCHDOG_DEVICE_ID,
+		Model: model,
+	}
+	if model == api.GUEST_WATCHDOG_I6300ESB {
+		watchdog.Pci = desc.NewPCIDevice(pciRoot.CType, model, WATCHDOG_DEVICE_ID)
+	}
+	return watchdog
+}
+
+func (s *SKVMGuestInstance) initWatchdogDesc(pciRoot *desc.PCIController) {
+	switch model := s.getWatchdogModel(); model {
+	case api.GUEST_WATCHDOG_I6300ESB:
+		s.Desc.Watchdog = newWatchdogDesc(pciRoot, model)
+	case api.GUEST_WATCHDOG_IB700:
+		// ib700 sits on isa bus which only exists on x86 machines
+		if !s.manager.host.IsX8664() {
+			log.Warningf("guest %s watchdog %s is not supported on this host", s.GetName(), model)
+			return
+		}
+		s.Desc.Watchdog = newWatchdogDesc(pciRoot, model)
+	}
+}
+
+// eventGuestCrashed handles WATCHDOG and GUEST_PANICKED events, executes the
+// crash action configured in guest metadata and reports the event to region
+func (s *SKVMGuestInstance) eventGuestCrashed(event *monitor.Event) {
+	eventName := strings.Trim(event.Event, "\"")
+	// qemu runc state event source qemu/src/qapi/run-state.json
+	params := jsonutils.NewDict()
+	if action, ok := event.Data["action"]; ok {
+		sAction, _ := action.(string)
+		params.Set("action", jsonutils.NewString(sAction))
+	}
+	if info, ok := event.Data["info"]; ok {
+		params.Set("info", jsonutils.Marshal(info))
+	}
+	params.Set("event", jsonutils.NewString(eventName))
+
+	// watchdog may expire again while previous crash action is in progress
+	if atomic.CompareAndSwapInt32(&s.crashHandling, 0, 1) {
+		s.handleCrash(eventName, params)
+	} else {
+		log.Warningf("Server %s receive %s while handling previous crash, skip crash action", s.GetName(), eventName)
+	}
+
+	_, err := modules.Servers.PerformAction(
+		hostutils.GetComputeSession(context.Background()),
+		s.GetId(), "event", params)
+	if err != nil {
+		log.Errorf("Server %s send event %s got error %s", s.GetId(), eventName, err)
+	}
+}
+
+// handleCrash executes the crash action and records the result in params,
+// it must be called with crashHandling set
+func (s *SKVMGuestInstance) handleCrash(eventName string, params *jsonutils.JSONDict) {
+	defer atomic.StoreInt32(&s.crashHandling, 0)
+
+	recoveryAction := s.getCrashAction()
+	params.Set("recovery_action", jsonutils.NewString(recoveryAction))
+	dumpPath, err := s.doCrashAction(recoveryAction)
+	if len(dumpPath) > 0 {
+		params.Set("dump_path", jsonutils.NewString(dumpPath))
+	}
+	if err != nil {
+		log.Errorf("Server %s on %s do crash action %s: %s", s.GetName(), eventName, recoveryAction, err)
+		params.Set("recovery_error", jsonutils.NewString(err.Error()))
+	}
+}
+
+// doCrashAction returns memory dump file path if guest memory dumped
+func (s *SKVMGuestInstance) doCrashAction(action string) (string, error) {
+	if !s.IsMonitorAlive() {
+		return "", errors.Errorf("monitor is not alive")
+	}
+	switch action {
+	case api.GUEST_CRASH_ACTION_RESET:
+		return "", s.resetCrashedGuest()
+	case api.GUEST_CRASH_ACTION_DUMP:
+		dumpPath, err := s.dumpGuestMemory()
+		if err != nil {
+			return "", errors.Wrap(err, "dump guest memory")
+		}
+		return dumpPath, s.resetCrashedGuest()
+	case api.GUEST_CRASH_ACTION_POWEROFF:
+		// qemu exits without waiting guest to shutdown,
+		// guest status is synced to region on monitor disconnect
+		s.Monitor.Quit(func(res string) {
+			log.Infof("Server %s quit on crash: %s", s.GetName(), res)
+		})
+	}
+	return "", nil
+}
+
+func (s *SKVMGuestInstance) resetCrashedGuest() error {
+	err := s.monitorSyncCall(s.Monitor.SystemReset, WATCHDOG_MONITOR_TIMEOUT)
+	if err != nil {
+		return errors.Wrap(err, "system reset")
+	}
+	// panicked guest is paused by qemu and left paused after reset is handled,
+	// cont is a no-op for running guest
+	var status string
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Second)
+		contChan := make(chan struct{})
+		s.Monitor.SimpleCommand("cont", func(string) { close(contChan) })
+		select {
+		case <-contChan:
+		case <-time.After(WATCHDOG_MONITOR_TIMEOUT):
+			return errors.Wrapf(errors.ErrTimeout, "cont after reset")
+		}
+		statusChan := make(chan string, 1)
+		s.Monitor.QueryStatus(func(res string) { statusChan <- res })
+		select {
+		case status = <-statusChan:
+		case <-time.After(WATCHDOG_MONITOR_TIMEOUT):
+			return errors.Wrapf(errors.ErrTimeout, "query status after reset")
+		}
+		if status == "running" {
+			return nil
+		}
+	}
+	return errors.Errorf("guest status %s after reset", status)
+}
+
+// getCrashDumpDir returns dir of guest memory dumps, which is on the storage
+// of system disk if it is file based. Otherwise fallback to host crash dump path.
+func (s *SKVMGuestInstance) getCrashDumpDir() string {
+	if len(s.Desc.Disks) > 0 {
+		disk := s.Desc.Disks[0]
+		if len(disk.Path) > 0 && utils.IsInStringArray(disk.StorageType, api.FIEL_STORAGE) {
+			return filepath.Join(filepath.Dir(disk.Path), GUEST_CRASH_DUMP_DIR, s.GetId())
+		}
+	}
+	return filepath.Join(options.HostOptions.GuestCrashDumpPath, s.GetId())
+}
+
+func (s *SKVMGuestInstance) dumpGuestMemory() (string, error) {
+	dir := s.getCrashDumpDir()
+	if err := procutils.NewRemoteCommandAsFarAsPossible("mkdir", "-p", dir).Run(); err != nil {
+		return "", errors.Wrapf(err, "mkdir -p %q", dir)
+	}
+	// make room for the new dump before writing it
+	if err := pruneCrashDumps(dir, options.HostOptions.GuestCrashDumpKeep-1); err != nil {
+		log.Errorf("Server %s prune memory dumps: %s", s.GetName(), err)
+	}
+	dumpPath := filepath.Join(dir, time.Now().Format("20060102150405")+GUEST_CRASH_DUMP_SUFFIX)
+	log.Infof("Server %s dump guest memory to %s", s.GetName(), dumpPath)
+	err := s.monitorSyncCall(func(cb monitor.StringCallback) {
+		s.Monitor.DumpGuestMemory(dumpPath, cb)
+	}, GUEST_CRASH_DUMP_TIMEOUT)
+	if err != nil {
+		os.Remove(dumpPath)
+		return "", err
+	}
+	return dumpPath, nil
+}
+
+// pruneCrashDumps removes oldest memory dumps in dir, keeps at most keep of them
+func pruneCrashDumps(dir string, keep int) error {
+	if keep < 0 {
+		keep = 0
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "read dir %s", dir)
+	}
+	dumps := make([]string, 0)
+	for _, file := range files {
+		if file.Mode().IsRegular() && strings.HasSuffix(file.Name(), GUEST_CRASH_DUMP_SUFFIX) {
+			dumps = append(dumps, file.Name())
+		}
+	}
+	// dump files are named by timestamp
+	sort.Strings(dumps)
+	for i := 0; i < len(dumps)-keep; i++ {
+		if err := os.Remove(filepath.Join(dir, dumps[i])); err != nil {
+			return errors.Wrapf(err, "remove %s", dumps[i])
+		}
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) deleteCrashDumps(migrated bool) error {
+	// dest guest keeps using the dumps on shared storage
+	if migrated && len(s.Desc.Disks) > 0 && utils.IsInStringArray(s.Desc.Disks[0].StorageType, api.SHARED_FILE_STORAGE) {
+		return nil
+	}
+	dir := s.getCrashDumpDir()
+	if out, err := procutils.NewRemoteCommandAsFarAsPossible("rm", "-rf", dir).Output(); err != nil {
+		return errors.Wrapf(err, "remove %s: %s", dir, out)
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) monitorSyncCall(call func(monitor.StringCallback), timeout time.Duration) error {
+	var errChan = make(chan error, 1)
+	call(func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Errorf(res)
+		} else {
+			errChan <- nil
+		}
+	})
+	return waitBalloonMonitor(errChan, timeout)
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+func TestPruneCrashDumps(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		keep int
+		want []string
+	}{
+		{"keep 2", 2, []string{"20240103000000.dump", "20240104000000.dump", "other.log"}},
+		{"keep more than existing", 10, []string{"20240101000000.dump", "20240102000000.dump", "20240103000000.dump", "20240104000000.dump", "other.log"}},
+		{"keep none", 0, []string{"other.log"}},
+		{"negative keep", -1, []string{"other.log"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"20240103000000.dump", "20240101000000.dump", "other.log", "20240104000000.dump", "20240102000000.dump"} {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+			}
+			if err := pruneCrashDumps(dir, c.keep); err != nil {
+				t.Fatalf("pruneCrashDumps: %v", err)
+			}
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("read dir: %v", err)
+			}
+			got := make([]string, 0)
+			for _, f := range files {
+				got = append(got, f.Name())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("files left %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestGetCrashDumpDir(t *testing.T) {
+	options.HostOptions.GuestCrashDumpPath = "/crash_dumps"
+	for _, c := range []struct {
+		name        string
+		storageType string
+		diskPath    string
+		want        string
+	}{
+		{"local", api.STORAGE_LOCAL, "/opt/cloud/workspace/disks/disk-id", "/opt/cloud/workspace/disks/crash_dumps/guest-id"},
+		{"nfs", api.STORAGE_NFS, "/nfs/storage/disk-id", "/nfs/storage/crash_dumps/guest-id"},
+		{"rbd", api.STORAGE_RBD, "rbd:pool/disk-id", "/crash_dumps/guest-id"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s := &SKVMGuestInstance{
+				sBaseGuestInstance: newBaseGuestInstance("guest-id", &SGuestManager{}, api.HYPERVISOR_KVM),
+			}
+			disk := &desc.SGuestDisk{}
+			disk.Path = c.diskPath
+			disk.StorageType = c.storageType
+			s.Desc = &desc.SGuestDesc{}
+			s.Desc.Uuid = "guest-id"
+			s.Desc.Disks = []*desc.SGuestDisk{disk}
+			if got := s.getCrashDumpDir(); got != c.want {
+				t.Errorf("getCrashDumpDir = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMonitorSyncCall(t *testing.T) {
+	s := &SKVMGuestInstance{}
+	err := s.monitorSyncCall(func(cb monitor.StringCallback) { cb("") }, time.Second)
+	if err != nil {
+		t.Errorf("succeeded call got %v", err)
+	}
+	err = s.monitorSyncCall(func(cb monitor.StringCallback) { cb("failed") }, time.Second)
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("failed call got %v", err)
+	}
+	// monitor never calls back
+	err = s.monitorSyncCall(func(cb monitor.StringCallback) {}, 10*time.Millisecond)
+	if errors.Cause(err) != errors.ErrTimeout {
+		t.Errorf("hung call got %v, want timeout", err)
+	}
+}
+
+func TestHandleCrashResetsCrashHandling(t *testing.T) {
+	s := &SKVMGuestInstance{
+		sBaseGuestInstance: newBaseGuestInstance("guest-id", &SGuestManager{}, api.HYPERVISOR_KVM),
+	}
+	s.Desc = &desc.SGuestDesc{}
+	s.Desc.Metadata = map[string]string{api.VM_METADATA_CRASH_ACTION: api.GUEST_CRASH_ACTION_RESET}
+	s.crashHandling = 1
+	params := jsonutils.NewDict()
+	// monitor is not connected, crash action fails
+	s.handleCrash("WATCHDOG", params)
+	if atomic.LoadInt32(&s.crashHandling) != 0 {
+		t.Errorf("crashHandling is not reset after crash action failed")
+	}
+	if !params.Contains("recovery_error") {
+		t.Errorf("recovery error is not reported: %s", params)
+	}
+}
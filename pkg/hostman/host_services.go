@@ -24,6 +24,7 @@ import (
 	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
 	"yunion.io/x/onecloud/pkg/cloudcommon/service"
 	"yunion.io/x/onecloud/pkg/hostman/downloader"
+	"yunion.io/x/onecloud/pkg/hostman/flowlog"
 	"yunion.io/x/onecloud/pkg/hostman/guestman"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/guesthandlers"
@@ -114,6 +115,8 @@ func (host *SHostService) RunService() {
 	// hostmetrics after guestmanager bootstrap
 	hostmetrics.Init()
 	hostmetrics.Start()
+	flowlog.Init()
+	flowlog.Start()
 
 	host.initHandlers(app)
 
@@ -139,6 +142,7 @@ func (host *SHostService) RunService() {
 		hostinfo.Stop()
 		storageman.Stop()
 		hostmetrics.Stop()
+		flowlog.Stop()
 		guestman.Stop()
 		hostutils.GetWorkManager().Stop()
 	})
@@ -110,6 +110,21 @@ func GuestdisksDescToDeployDesc(guestDisks []*desc.SGuestDisk) []*Disk {
 	return disks
 }
 
+func GuestfilesystemsDescToDeployDesc(guestFilesystems []*desc.SGuestFilesystem) []*Filesystem {
+	if len(guestFilesystems) == 0 {
+		return nil
+	}
+
+	filesystems := make([]*Filesystem, len(guestFilesystems))
+	for i, fs := range guestFilesystems {
+		filesystems[i] = new(Filesystem)
+		filesystems[i].MountTag = fs.MountTag
+		filesystems[i].Mountpoint = fs.MountPoint
+		filesystems[i].Readonly = fs.ReadOnly
+	}
+	return filesystems
+}
+
 func GuestnetworksDescToDeployDesc(guestnetworks []*desc.SGuestNetwork) []*Nic {
 	if len(guestnetworks) == 0 {
 		return nil
@@ -175,6 +190,7 @@ func GuestStructDescToDeployDesc(guestDesc *desc.SGuestDesc) *GuestDesc {
 	ret.Hostname = guestDesc.Hostname
 	ret.Nics = GuestnetworksDescToDeployDesc(guestDesc.Nics)
 	ret.Disks = GuestdisksDescToDeployDesc(guestDesc.Disks)
+	ret.Filesystems = GuestfilesystemsDescToDeployDesc(guestDesc.Filesystems)
 
 	return ret
 }
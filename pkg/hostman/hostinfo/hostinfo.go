@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -120,6 +121,8 @@ type SHostInfo struct {
 
 	cri             pod.CRI
 	containerCPUMap *pod.HostContainerCPUMap
+
+	memBalloonHeadroom int64
 }
 
 func (h *SHostInfo) GetContainerDeviceConfigurationFilePath() string {
@@ -266,6 +269,14 @@ func (h *SHostInfo) GetContainerCPUMap() *pod.HostContainerCPUMap {
 	return h.containerCPUMap
 }
 
+func (h *SHostInfo) SetMemBalloonHeadroom(sizeMb int) {
+	atomic.StoreInt64(&h.memBalloonHeadroom, int64(sizeMb))
+}
+
+func (h *SHostInfo) GetMemBalloonHeadroom() int {
+	return int(atomic.LoadInt64(&h.memBalloonHeadroom))
+}
+
 func (h *SHostInfo) setupOvnChassis() error {
 	opts := &options.HostOptions
 	if opts.BridgeDriver != hostbridge.DRV_OPEN_VSWITCH {
@@ -412,6 +423,7 @@ func (h *SHostInfo) prepareEnv() error {
 	for _, dirPath := range []string{
 		options.HostOptions.ServersPath,
 		options.HostOptions.MemorySnapshotsPath,
+		options.HostOptions.GuestCrashDumpPath,
 		options.HostOptions.LocalBackupTempPath,
 	} {
 		output, err := procutils.NewCommand("mkdir", "-p", dirPath).Output()
@@ -113,6 +113,7 @@ func (p *SHostPingTask) payload() api.SHostPingInput {
 	memFree := int(info.Available / 1024 / 1024)
 	memUsed := memTotal - memFree
 	data.MemoryUsedMb = memUsed
+	data.MemoryBalloonHeadroomMb = Instance().GetMemBalloonHeadroom()
 	return data
 }
 
@@ -63,6 +63,9 @@ type IHost interface {
 	IsKvmSupport() bool
 	IsNestedVirtualization() bool
 
+	// memory in MB reclaimable from guests by balloon controller
+	SetMemBalloonHeadroom(sizeMb int)
+
 	PutHostOnline() error
 	StartDHCPServer()
 
@@ -422,6 +422,14 @@ func (m *HmpMonitor) DriveMirror(callback StringCallback, drive, target, syncMod
 	m.Query(cmd, callback)
 }
 
+func (m *HmpMonitor) DriveBackupWithBitmap(drive, jobId, target, bitmap, syncBitmap string, compress bool, callback StringCallback) {
+	go callback("unsupported drive backup with dirty bitmap for hmp")
+}
+
+func (m *HmpMonitor) RemoveDirtyBitmap(drive, bitmap string, callback StringCallback) {
+	go callback("unsupported remove dirty bitmap for hmp")
+}
+
 func (m *HmpMonitor) DriveBackup(callback StringCallback, drive, target, syncMode, format string) {
 	cmd := "drive_backup -n"
 	if syncMode == "full" {
@@ -554,6 +562,54 @@ func (m *HmpMonitor) NetdevDel(id string, callback StringCallback) {
 	m.Query(cmd, callback)
 }
 
+func (m *HmpMonitor) ChardevAdd(id, backend string, params map[string]string, callback StringCallback) {
+	cmd := fmt.Sprintf("chardev-add %s,id=%s", backend, id)
+	for k, v := range params {
+		cmd += fmt.Sprintf(",%s=%s", k, v)
+	}
+	m.Query(cmd, callback)
+}
+
+func (m *HmpMonitor) ChardevRemove(id string, callback StringCallback) {
+	cmd := fmt.Sprintf("chardev-remove %s", id)
+	m.Query(cmd, callback)
+}
+
+func (m *HmpMonitor) SetBalloon(sizeMB int64, callback StringCallback) {
+	cmd := fmt.Sprintf("balloon %d", sizeMB)
+	m.Query(cmd, callback)
+}
+
+func (m *HmpMonitor) QueryBalloon(callback QueryBalloonCallback) {
+	go callback(nil, "unsupported query balloon for hmp")
+}
+
+func (m *HmpMonitor) SetBalloonStatsPollingInterval(devId string, intervalSec int, callback StringCallback) {
+	go callback("unsupported set balloon stats polling interval for hmp")
+}
+
+func (m *HmpMonitor) GetBalloonStats(devId string, callback BalloonStatsCallback) {
+	go callback(nil, "unsupported get balloon stats for hmp")
+}
+
+func (m *HmpMonitor) SetVirtioMemRequestedSize(devId string, sizeMB int64, callback StringCallback) {
+	cmd := fmt.Sprintf("qom-set /machine/peripheral/%s requested-size %dM", devId, sizeMB)
+	m.Query(cmd, callback)
+}
+
+func (m *HmpMonitor) GetVirtioMemSize(devId string, callback VirtioMemSizeCallback) {
+	go callback(0, "unsupported get virtio-mem size for hmp")
+}
+
+func (m *HmpMonitor) SystemReset(callback StringCallback) {
+	m.Query("system_reset", callback)
+}
+
+func (m *HmpMonitor) DumpGuestMemory(filePath string, callback StringCallback) {
+	cmd := fmt.Sprintf("dump-guest-memory %s", filePath)
+	m.Query(cmd, callback)
+}
+
 func (m *HmpMonitor) SaveState(stateFilePath string, callback StringCallback) {
 	cmd := fmt.Sprintf(`migrate -d "%s"`, getSaveStatefileUri(stateFilePath))
 	m.Query(cmd, callback)
@@ -50,6 +50,13 @@ type BlockJob struct {
 	SpeedMbps float64
 }
 
+type BlockDirtyBitmap struct {
+	Name        string
+	Count       int64
+	Granularity int64
+	Persistent  bool
+}
+
 type QemuBlock struct {
 	IoStatus  string `json:"io-status"`
 	Device    string
@@ -58,7 +65,9 @@ type QemuBlock struct {
 	Qdev      string
 	TrayOpen  bool
 	Type      string
-	Inserted  struct {
+	// deprecated since qemu 4.2, moved into inserted
+	DirtyBitmaps []BlockDirtyBitmap `json:"dirty-bitmaps"`
+	Inserted     struct {
 		Ro               bool
 		Drv              string
 		Encrypted        bool
@@ -80,6 +89,7 @@ type QemuBlock struct {
 		IopsSize         int64
 		DetectZeroes     string
 		WriteThreshold   int
+		DirtyBitmaps     []BlockDirtyBitmap `json:"dirty-bitmaps"`
 		Image            struct {
 			Filename              string
 			Format                string
@@ -105,6 +115,13 @@ type QemuBlock struct {
 	}
 }
 
+func (b *QemuBlock) GetDirtyBitmaps() []BlockDirtyBitmap {
+	if len(b.Inserted.DirtyBitmaps) > 0 {
+		return b.Inserted.DirtyBitmaps
+	}
+	return b.DirtyBitmaps
+}
+
 type MigrationInfo struct {
 	Status                *MigrationStatus  `json:"status,omitempty"`
 	RAM                   *MigrationStats   `json:"ram,omitempty"`
@@ -225,6 +242,8 @@ type Monitor interface {
 	BlockStream(drive string, callback StringCallback)
 	DriveMirror(callback StringCallback, drive, target, syncMode, format string, unmap, blockReplication bool, speed int64)
 	DriveBackup(callback StringCallback, drive, target, syncMode, format string)
+	DriveBackupWithBitmap(drive, jobId, target, bitmap, syncBitmap string, compress bool, callback StringCallback)
+	RemoveDirtyBitmap(drive, bitmap string, callback StringCallback)
 	BlockJobComplete(drive string, cb StringCallback)
 	BlockReopenImage(drive, newImagePath, format string, cb StringCallback)
 	SnapshotBlkdev(drive, newImagePath, format string, reuse bool, cb StringCallback)
@@ -252,6 +271,20 @@ type Monitor interface {
 	NetdevAdd(id, netType string, params map[string]string, callback StringCallback)
 	NetdevDel(id string, callback StringCallback)
 
+	ChardevAdd(id, backend string, params map[string]string, callback StringCallback)
+	ChardevRemove(id string, callback StringCallback)
+
+	SetBalloon(sizeMB int64, callback StringCallback)
+	QueryBalloon(callback QueryBalloonCallback)
+	SetBalloonStatsPollingInterval(devId string, intervalSec int, callback StringCallback)
+	GetBalloonStats(devId string, callback BalloonStatsCallback)
+
+	SetVirtioMemRequestedSize(devId string, sizeMB int64, callback StringCallback)
+	GetVirtioMemSize(devId string, callback VirtioMemSizeCallback)
+
+	SystemReset(callback StringCallback)
+	DumpGuestMemory(filePath string, callback StringCallback)
+
 	SaveState(statFilePath string, callback StringCallback)
 	QueryMachines(callback QueryMachinesCallback)
 	Quit(StringCallback)
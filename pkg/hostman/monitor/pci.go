@@ -109,6 +109,11 @@ type PcdimmDeviceInfo struct {
 	Memdev       string  `json:"memdev"`
 	Hotplugged   bool    `json:"hotplugged"`
 	Hotpluggable bool    `json:"hotpluggable"`
+
+	// virtio-mem device only
+	RequestedSize int64 `json:"requested-size"`
+	MaxSize       int64 `json:"max-size"`
+	BlockSize     int64 `json:"block-size"`
 }
 
 type QueryMemoryDevicesCallback func(memoryDevicesInfoList []MemoryDeviceInfo, err string)
@@ -139,6 +144,38 @@ type Memdev struct {
 
 type MemdevListCallback func(res []Memdev, err string)
 
+// BalloonInfo implements the "BalloonInfo" QMP API type.
+type BalloonInfo struct {
+	// guest memory size in bytes after ballooning
+	Actual int64 `json:"actual"`
+}
+
+type QueryBalloonCallback func(info *BalloonInfo, err string)
+
+// BalloonStats is the guest memory statistics reported by virtio-balloon,
+// unsupported stats are reported as -1.
+type BalloonStats struct {
+	SwapIn          int64 `json:"stat-swap-in"`
+	SwapOut         int64 `json:"stat-swap-out"`
+	MajorFaults     int64 `json:"stat-major-faults"`
+	MinorFaults     int64 `json:"stat-minor-faults"`
+	FreeMemory      int64 `json:"stat-free-memory"`
+	TotalMemory     int64 `json:"stat-total-memory"`
+	AvailableMemory int64 `json:"stat-available-memory"`
+	DiskCaches      int64 `json:"stat-disk-caches"`
+}
+
+// BalloonGuestStats is the value of balloon device property "guest-stats"
+type BalloonGuestStats struct {
+	Stats      BalloonStats `json:"stats"`
+	LastUpdate int64        `json:"last-update"`
+}
+
+type BalloonStatsCallback func(stats *BalloonGuestStats, err string)
+
+// VirtioMemSizeCallback returns memory size in bytes plugged to guest by virtio-mem device
+type VirtioMemSizeCallback func(size int64, err string)
+
 // CpuInstanceProperties -> CPUInstanceProperties (struct)
 
 // CPUInstanceProperties implements the "CpuInstanceProperties" QMP API type.
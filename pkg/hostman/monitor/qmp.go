@@ -819,6 +819,66 @@ func (m *QmpMonitor) DriveBackup(callback StringCallback, drive, target, syncMod
 	m.Query(cmd, cb)
 }
 
+// DriveBackupWithBitmap starts a backup job of drive to an existing target and adds
+// a persistent dirty bitmap in the same transaction, so the new bitmap tracks exactly
+// the writes after the backup point. Backup is incremental on syncBitmap if it is given.
+func (m *QmpMonitor) DriveBackupWithBitmap(drive, jobId, target, bitmap, syncBitmap string, compress bool, callback StringCallback) {
+	backupArgs := map[string]interface{}{
+		"job-id":   jobId,
+		"device":   drive,
+		"target":   target,
+		"mode":     "existing",
+		"format":   "qcow2",
+		"sync":     "full",
+		"compress": compress,
+	}
+	if len(syncBitmap) > 0 {
+		backupArgs["sync"] = "incremental"
+		backupArgs["bitmap"] = syncBitmap
+	}
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "transaction",
+			Args: map[string]interface{}{
+				"actions": []interface{}{
+					map[string]interface{}{
+						"type": "block-dirty-bitmap-add",
+						"data": map[string]interface{}{
+							"node":       drive,
+							"name":       bitmap,
+							"persistent": true,
+						},
+					},
+					map[string]interface{}{
+						"type": "drive-backup",
+						"data": backupArgs,
+					},
+				},
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) RemoveDirtyBitmap(drive, bitmap string, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-dirty-bitmap-remove",
+			Args: map[string]interface{}{
+				"node": drive,
+				"name": bitmap,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
 func (m *QmpMonitor) BlockStream(drive string, callback StringCallback) {
 	var (
 		speed = 5 * 100 * 1024 * 1024 // limit 500 MB/s
@@ -1029,6 +1089,171 @@ func (m *QmpMonitor) NetdevDel(id string, callback StringCallback) {
 	m.HumanMonitorCommand(cmd, callback)
 }
 
+func (m *QmpMonitor) ChardevAdd(id, backend string, params map[string]string, callback StringCallback) {
+	cmd := fmt.Sprintf("chardev-add %s,id=%s", backend, id)
+	for k, v := range params {
+		cmd += fmt.Sprintf(",%s=%s", k, v)
+	}
+	m.HumanMonitorCommand(cmd, callback)
+}
+
+func (m *QmpMonitor) ChardevRemove(id string, callback StringCallback) {
+	cmd := fmt.Sprintf("chardev-remove %s", id)
+	m.HumanMonitorCommand(cmd, callback)
+}
+
+func (m *QmpMonitor) SetBalloon(sizeMB int64, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "balloon",
+			Args: map[string]interface{}{
+				"value": sizeMB * 1024 * 1024,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) QueryBalloon(callback QueryBalloonCallback) {
+	var (
+		cb = func(res *Response) {
+			if res.ErrorVal != nil {
+				callback(nil, res.ErrorVal.Error())
+			} else {
+				info := new(BalloonInfo)
+				err := json.Unmarshal(res.Return, info)
+				if err != nil {
+					callback(nil, err.Error())
+				} else {
+					callback(info, "")
+				}
+			}
+		}
+		cmd = &Command{
+			Execute: "query-balloon",
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) SetBalloonStatsPollingInterval(devId string, intervalSec int, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "qom-set",
+			Args: map[string]interface{}{
+				"path":     fmt.Sprintf("/machine/peripheral/%s", devId),
+				"property": "guest-stats-polling-interval",
+				"value":    intervalSec,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) GetBalloonStats(devId string, callback BalloonStatsCallback) {
+	var (
+		cb = func(res *Response) {
+			if res.ErrorVal != nil {
+				callback(nil, res.ErrorVal.Error())
+			} else {
+				stats := new(BalloonGuestStats)
+				err := json.Unmarshal(res.Return, stats)
+				if err != nil {
+					callback(nil, err.Error())
+				} else {
+					callback(stats, "")
+				}
+			}
+		}
+		cmd = &Command{
+			Execute: "qom-get",
+			Args: map[string]interface{}{
+				"path":     fmt.Sprintf("/machine/peripheral/%s", devId),
+				"property": "guest-stats",
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) SetVirtioMemRequestedSize(devId string, sizeMB int64, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "qom-set",
+			Args: map[string]interface{}{
+				"path":     fmt.Sprintf("/machine/peripheral/%s", devId),
+				"property": "requested-size",
+				"value":    sizeMB * 1024 * 1024,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) GetVirtioMemSize(devId string, callback VirtioMemSizeCallback) {
+	var (
+		cb = func(res *Response) {
+			if res.ErrorVal != nil {
+				callback(0, res.ErrorVal.Error())
+			} else {
+				var size int64
+				err := json.Unmarshal(res.Return, &size)
+				if err != nil {
+					callback(0, err.Error())
+				} else {
+					callback(size, "")
+				}
+			}
+		}
+		cmd = &Command{
+			Execute: "qom-get",
+			Args: map[string]interface{}{
+				"path":     fmt.Sprintf("/machine/peripheral/%s", devId),
+				"property": "size",
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) SystemReset(callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "system_reset",
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+// DumpGuestMemory dumps guest memory in ELF format, monitor is blocked until dump finished
+func (m *QmpMonitor) DumpGuestMemory(filePath string, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "dump-guest-memory",
+			Args: map[string]interface{}{
+				"paging":   false,
+				"protocol": "file:" + filePath,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
 func (m *QmpMonitor) SaveState(stateFilePath string, callback StringCallback) {
 	var (
 		cb = func(res *Response) {
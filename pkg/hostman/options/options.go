@@ -69,6 +69,8 @@ type SHostOptions struct {
 	ServersPath         string `help:"Path for virtual server configuration files" default:"/opt/cloud/workspace/servers"`
 	ImageCachePath      string `help:"Path for storing image caches" default:"/opt/cloud/workspace/disks/image_cache"`
 	MemorySnapshotsPath string `help:"Path for memory snapshot stat files" default:"/opt/cloud/workspace/memory_snapshots"`
+	GuestCrashDumpPath  string `help:"Path for guest memory dump files on watchdog timeout or guest panic, used when system disk is not on file storage" default:"/opt/cloud/workspace/crash_dumps"`
+	GuestCrashDumpKeep  int    `help:"Count of latest memory dump files kept for each guest" default:"3"`
 	// ImageCacheLimit int    `help:"Maximal storage space for image caching, in GB" default:"20"`
 	AgentTempPath  string `help:"Path for ESXi agent"`
 	AgentTempLimit int    `help:"Maximal storage space for ESXi agent, in GB" default:"10"`
@@ -95,6 +97,11 @@ type SHostOptions struct {
 	ChntpwPath string `help:"path to chntpw tool" default:"/usr/local/bin/chntpw.static"`
 	OvmfPath   string `help:"Path to OVMF.fd" default:"/opt/cloud/contrib/OVMF.fd"`
 
+	SecbootOvmfCodePath string `help:"Path to OVMF code with secure boot and SMM support" default:"/opt/cloud/contrib/OVMF_CODE.secboot.fd"`
+	SecbootOvmfVarsPath string `help:"Path to OVMF vars template with secure boot keys enrolled" default:"/opt/cloud/contrib/OVMF_VARS.secboot.fd"`
+	SwtpmPath           string `help:"Path to swtpm binary for guest vTPM" default:"swtpm"`
+	VirtiofsdPath       string `help:"Path to virtiofsd binary for guest shared filesystems" default:"/usr/libexec/virtiofsd"`
+
 	LinuxDefaultRootUser    bool `help:"Default account for linux system is root"`
 	WindowsDefaultAdminUser bool `default:"true" help:"Default account for Windows system is Administrator"`
 
@@ -165,6 +172,10 @@ type SHostOptions struct {
 
 	ovnutils.SOvnOptions
 
+	OvnControllerLogPath string `help:"path of ovn-controller log where acl logs of security groups with flow log enabled are collected from" default:"$HOST_OVN_CONTROLLER_LOG_PATH|/var/log/openvswitch/ovn-controller.log"`
+	FlowLogPath          string `help:"Path for storing flow log records collected from acl logs" default:"/opt/cloud/workspace/flowlogs"`
+	FlowLogRetentionDays int    `help:"days of flow log records kept on disk, 0 to disable flow log collecting" default:"7"`
+
 	// EnableRemoteExecutor bool `help:"Enable remote executor" default:"false"`
 	HostHealthTimeout int `help:"host health timeout" default:"30"`
 	HostLeaseTimeout  int `help:"lease timeout" default:"10"`
@@ -200,9 +211,22 @@ type SHostOptions struct {
 
 	LocalBackupStoragePath string `help:"path for mounting backup nfs storage" default:"/opt/cloud/workspace/backupstorage"`
 	LocalBackupTempPath    string `help:"the local temporary directory for backup" default:"/opt/cloud/workspace/run/backups"`
+	LiveBackupTimeoutHours int    `help:"timeout in hours of running guest disk backup job" default:"24"`
 
 	BinaryMemcleanPath string `help:"execute binary memclean path" default:"/opt/yunion/bin/memclean"`
 
+	EnableBalloonController            bool `help:"enable balloon controller inflating guest balloons under host memory pressure" default:"true"`
+	BalloonControllerIntervalSecond    int  `help:"interval in seconds of balloon controller checking host memory" default:"10"`
+	BalloonStatsPollingIntervalSecond  int  `help:"interval in seconds of guest memory stats polling through balloon device" default:"5"`
+	BalloonHostMemLowWatermarkPercent  int  `help:"inflate guest balloons when host available memory below this percent" default:"10"`
+	BalloonHostMemHighWatermarkPercent int  `help:"deflate guest balloons when host available memory above this percent" default:"20"`
+	BalloonGuestMinMemPercent          int  `help:"default minimum memory percent guaranteed to guest without balloon_min_mem set" default:"50"`
+	BalloonStepSizeMb                  int  `help:"maximum memory size in MB changed for one guest per balloon adjustment" default:"512"`
+
+	VirtioMemBootMemSizeMb       int    `help:"boot memory size in MB of guests with virtio-mem, the rest is provided by virtio-mem device" default:"1024"`
+	VirtioMemQemuVersion         string `help:"qemu version to start guests with virtio-mem, requires 6.1.0 or later" default:"latest"`
+	VirtioMemResizeTimeoutSecond int    `help:"timeout in seconds waiting guest to plug or unplug virtio-mem memory" default:"60"`
+
 	MaxHotplugVCpuCount int  `help:"maximal possible vCPU count that the platform kvm supports"`
 	PcieRootPortCount   int  `help:"pcie root port count" default:"2"`
 	EnableQemuDebugLog  bool `help:"enable qemu debug logs" default:"false"`
@@ -30,6 +30,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/hostman/options"
 	"yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage"
+	_ "yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage/dedup"
 	_ "yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage/nfs"
 	_ "yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage/object"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
@@ -62,6 +63,67 @@ func cleanupDirOrFile(path string) {
 	}
 }
 
+func getBackingPath(backingPath string, encKey string) string {
+	if len(encKey) > 0 {
+		return qemuimg.GetQemuFilepath(backingPath, "sec0", qemuimg.EncryptFormatLuks)
+	}
+	return backingPath
+}
+
+// restoreBackupChain downloads the backups of chain into dir and links every
+// incremental backup onto its parent, returns the path of the last backup
+func restoreBackupChain(ctx context.Context, backupStorage backupstorage.IBackupStorage, dir string, chain []string, encKey string) (string, error) {
+	parentPath := ""
+	for _, backupId := range chain {
+		backupPath := path.Join(dir, backupId)
+		err := backupStorage.RestoreBackupTo(ctx, backupPath, backupId)
+		if err != nil {
+			return "", errors.Wrapf(err, "RestoreBackupTo %s", backupId)
+		}
+		if len(parentPath) > 0 {
+			img, err := qemuimg.NewQemuImage(backupPath)
+			if err != nil {
+				return "", errors.Wrapf(err, "NewQemuImage %s", backupPath)
+			}
+			if len(encKey) > 0 {
+				img.SetPassword(encKey)
+			}
+			err = img.Rebase(getBackingPath(parentPath, encKey), true)
+			if err != nil {
+				return "", errors.Wrapf(err, "rebase %s to %s", backupId, parentPath)
+			}
+		}
+		parentPath = backupPath
+	}
+	return parentPath, nil
+}
+
+func getBackupEncryptKey(ctx context.Context, diskBackup *SDiskBackup) (*identity_modules.SEncryptKeySecret, error) {
+	if len(diskBackup.EncryptKeyId) == 0 {
+		return nil, nil
+	}
+	session := auth.GetSession(ctx, diskBackup.UserCred, consts.GetRegion())
+	secKey, err := identity_modules.Credentials.GetEncryptKey(session, diskBackup.EncryptKeyId)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetEncryptKey")
+	}
+	return &secKey, nil
+}
+
+func saveBackupImage(ctx context.Context, backupStorage backupstorage.IBackupStorage, backupPath string, diskBackup *SDiskBackup) (int, error) {
+	newImage, err := qemuimg.NewQemuImage(backupPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "NewQemuImage backup")
+	}
+	newImageSizeMb := newImage.GetActualSizeMB()
+
+	err = backupStorage.SaveBackupFrom(ctx, backupPath, diskBackup.BackupId)
+	if err != nil {
+		return 0, errors.Wrap(err, "SaveBackupFrom")
+	}
+	return newImageSizeMb, nil
+}
+
 func doBackupDisk(ctx context.Context, snapshotPath string, diskBackup *SDiskBackup) (int, error) {
 	backupTmpDir, err := ensureBackupDir()
 	if err != nil {
@@ -69,41 +131,84 @@ func doBackupDisk(ctx context.Context, snapshotPath string, diskBackup *SDiskBac
 	}
 	defer cleanupDirOrFile(backupTmpDir)
 
+	backupStorage, err := backupstorage.GetBackupStorage(diskBackup.BackupStorageId, diskBackup.BackupStorageAccessInfo)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetBackupStorage")
+	}
+
 	backupPath := path.Join(backupTmpDir, diskBackup.BackupId)
 	img, err := qemuimg.NewQemuImage(snapshotPath)
 	if err != nil {
 		return 0, errors.Wrap(err, "NewQemuImage snapshot")
 	}
-	encKey := ""
-	if len(diskBackup.EncryptKeyId) > 0 {
-		session := auth.GetSession(ctx, diskBackup.UserCred, consts.GetRegion())
-		secKey, err := identity_modules.Credentials.GetEncryptKey(session, diskBackup.EncryptKeyId)
-		if err != nil {
-			return 0, errors.Wrap(err, "GetEncryptKey")
-		}
-		encKey = secKey.Key
+	secKey, err := getBackupEncryptKey(ctx, diskBackup)
+	if err != nil {
+		return 0, err
 	}
-	if len(encKey) > 0 {
-		img.SetPassword(encKey)
+	if secKey != nil {
+		img.SetPassword(secKey.Key)
+	}
+	if backupstorage.IsRawImageRequired(backupStorage) {
+		_, err = img.Clone(backupPath, qemuimgfmt.RAW, false)
+	} else {
+		_, err = img.Clone(backupPath, qemuimgfmt.QCOW2, true)
 	}
-	newImage, err := img.Clone(backupPath, qemuimgfmt.QCOW2, true)
 	if err != nil {
 		return 0, errors.Wrap(err, "unable to backup snapshot")
 	}
+	return saveBackupImage(ctx, backupStorage, backupPath, diskBackup)
+}
 
-	newImageSizeMb := newImage.GetActualSizeMB()
+// DoLiveBackupDisk creates an empty backup image of sizeMb, lets backupFunc fill it
+// from the running guest and saves it to backup storage, target passed to backupFunc
+// is the image path in qemu filename format
+func DoLiveBackupDisk(ctx context.Context, diskBackup *SDiskBackup, sizeMb int, backupFunc func(target string) error) (int, error) {
+	backupTmpDir, err := ensureBackupDir()
+	if err != nil {
+		return 0, errors.Wrap(err, "ensureBackupDir")
+	}
+	defer cleanupDirOrFile(backupTmpDir)
 
 	backupStorage, err := backupstorage.GetBackupStorage(diskBackup.BackupStorageId, diskBackup.BackupStorageAccessInfo)
 	if err != nil {
 		return 0, errors.Wrap(err, "GetBackupStorage")
 	}
-
-	err = backupStorage.SaveBackupFrom(ctx, backupPath, diskBackup.BackupId)
+	secKey, err := getBackupEncryptKey(ctx, diskBackup)
 	if err != nil {
-		return 0, errors.Wrap(err, "SaveBackupFrom")
+		return 0, err
 	}
-
-	return newImageSizeMb, nil
+	backupPath := path.Join(backupTmpDir, diskBackup.BackupId)
+	img, err := qemuimg.NewQemuImage(backupPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "NewQemuImage")
+	}
+	target := backupPath
+	if secKey != nil {
+		// guest opens the target with the disk secret, which is the backup key
+		err = img.CreateQcow2(sizeMb, false, "", secKey.Key, qemuimg.EncryptFormatLuks, secKey.Alg)
+		target = qemuimg.GetQemuFilepath(backupPath, "sec0", qemuimg.EncryptFormatLuks)
+	} else {
+		err = img.CreateQcow2(sizeMb, false, "", "", "", "")
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "CreateQcow2")
+	}
+	err = backupFunc(target)
+	if err != nil {
+		return 0, errors.Wrap(err, "backup running guest disk")
+	}
+	if backupstorage.IsRawImageRequired(backupStorage) {
+		if secKey != nil {
+			img.SetPassword(secKey.Key)
+		}
+		rawPath := backupPath + ".raw"
+		_, err = img.Clone(rawPath, qemuimgfmt.RAW, false)
+		if err != nil {
+			return 0, errors.Wrap(err, "convert backup to raw")
+		}
+		backupPath = rawPath
+	}
+	return saveBackupImage(ctx, backupStorage, backupPath, diskBackup)
 }
 
 func doRestoreDisk(ctx context.Context, diskInfo api.DiskAllocateInput, destImgPath string, format string) error {
@@ -117,21 +222,36 @@ func doRestoreDisk(ctx context.Context, diskInfo api.DiskAllocateInput, destImgP
 	if err != nil {
 		return errors.Wrap(err, "GetBackupStorage")
 	}
-	backupPath := path.Join(backupTmpDir, diskInfo.Backup.BackupId)
-	err = backupStorage.RestoreBackupTo(ctx, backupPath, diskInfo.Backup.BackupId)
+	encKey := ""
+	if diskInfo.Encryption {
+		encKey = diskInfo.EncryptInfo.Key
+	}
+	chain := diskInfo.Backup.BackupChain
+	if len(chain) == 0 {
+		chain = []string{diskInfo.Backup.BackupId}
+	}
+	backupPath, err := restoreBackupChain(ctx, backupStorage, backupTmpDir, chain, encKey)
 	if err != nil {
-		return errors.Wrap(err, "RestoreBackupTo")
+		return errors.Wrap(err, "restoreBackupChain")
 	}
 	img, err := qemuimg.NewQemuImage(backupPath)
 	if err != nil {
 		return errors.Wrap(err, "NewQemuImage")
 	}
-	if diskInfo.Encryption {
-		img.SetPassword(diskInfo.EncryptInfo.Key)
-	}
 	if len(format) == 0 {
 		format = qemuimgfmt.QCOW2.String()
 	}
+	if backupstorage.IsRawImageRequired(backupStorage) && len(encKey) > 0 {
+		// backup is saved decrypted, encrypt the restored disk again
+		err = img.Convert2Qcow2To(destImgPath, false, encKey, qemuimg.EncryptFormatLuks, diskInfo.EncryptInfo.Alg)
+		if err != nil {
+			return errors.Wrapf(err, "Convert2Qcow2To %s", destImgPath)
+		}
+		return nil
+	}
+	if len(encKey) > 0 {
+		img.SetPassword(encKey)
+	}
 	_, err = img.Clone(destImgPath, qemuimgfmt.String2ImageFormat(format), false)
 	if err != nil {
 		return errors.Wrapf(err, "Clone %s", destImgPath)
@@ -142,6 +262,7 @@ func doRestoreDisk(ctx context.Context, diskInfo api.DiskAllocateInput, destImgP
 const (
 	PackageDiskFilename     = "disk"
 	PackageMetadataFilename = "metadata"
+	PackageVtpmFilename     = "vtpm"
 )
 
 func DoInstancePackBackup(ctx context.Context, backupInfo SStoragePackInstanceBackup) (string, error) {
@@ -175,6 +296,14 @@ func DoInstancePackBackup(ctx context.Context, backupInfo SStoragePackInstanceBa
 			}
 		}
 	}
+	if len(backupInfo.VtpmBackupId) > 0 {
+		// download vtpm state
+		packageVtpmPath := path.Join(packagePath, PackageVtpmFilename)
+		err := backupStorage.RestoreBackupInstanceTo(ctx, packageVtpmPath, backupInfo.VtpmBackupId)
+		if err != nil {
+			return "", errors.Wrapf(err, "RestoreBackupInstanceTo %s %s", backupInfo.VtpmBackupId, packageVtpmPath)
+		}
+	}
 	{
 		// save snapshot metadata
 		packageMetadataPath := path.Join(packagePath, PackageMetadataFilename)
@@ -220,10 +349,10 @@ func DoInstancePackBackup(ctx context.Context, backupInfo SStoragePackInstanceBa
 	return finalPackageName, nil
 }
 
-func DoInstanceUnpackBackup(ctx context.Context, backupInfo SStorageUnpackInstanceBackup) ([]string, *api.InstanceBackupPackMetadata, error) {
+func DoInstanceUnpackBackup(ctx context.Context, backupInfo SStorageUnpackInstanceBackup) ([]string, string, *api.InstanceBackupPackMetadata, error) {
 	backupTmpDir, err := ensureBackupDir()
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "ensureBackupDir")
+		return nil, "", nil, errors.Wrap(err, "ensureBackupDir")
 	}
 	defer cleanupDirOrFile(backupTmpDir)
 
@@ -235,13 +364,13 @@ func DoInstanceUnpackBackup(ctx context.Context, backupInfo SStorageUnpackInstan
 
 	backupStorage, err := backupstorage.GetBackupStorage(backupInfo.BackupStorageId, backupInfo.BackupStorageAccessInfo)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "GetBackupStorage")
+		return nil, "", nil, errors.Wrap(err, "GetBackupStorage")
 	}
 
 	packageFilename := path.Join(backupTmpDir, packageName+".tar")
 	err = backupStorage.RestoreBackupInstanceTo(ctx, packageFilename, backupInfo.PackageName)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "RestoreBackupInstanceTo")
+		return nil, "", nil, errors.Wrap(err, "RestoreBackupInstanceTo")
 	}
 
 	// untar to temp dir
@@ -257,23 +386,23 @@ func DoInstanceUnpackBackup(ctx context.Context, backupInfo SStorageUnpackInstan
 	}
 	if output, err := procutils.NewCommand("tar", untarArgs...).Output(); err != nil {
 		log.Errorf("unable to 'tar -xf %s -C %s %s': %s", packageFilename, backupTmpDir, packageName, output)
-		return nil, nil, errors.Wrap(err, "unable to untar")
+		return nil, "", nil, errors.Wrap(err, "unable to untar")
 	}
 
 	// unpack metadata
 	packageMetadataPath := path.Join(packagePath, PackageMetadataFilename)
 	metadataBytes, err := ioutil.ReadFile(packageMetadataPath)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to read metadata file")
+		return nil, "", nil, errors.Wrap(err, "unable to read metadata file")
 	}
 	metadataJson, err := jsonutils.Parse(metadataBytes)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to parse string to json")
+		return nil, "", nil, errors.Wrap(err, "unable to parse string to json")
 	}
 	metadata := &api.InstanceBackupPackMetadata{}
 	err = metadataJson.Unmarshal(metadata)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "unmarshal backup metadata")
+		return nil, "", nil, errors.Wrap(err, "unmarshal backup metadata")
 	}
 
 	// copy disk files only if !metadataOnly
@@ -285,10 +414,21 @@ func DoInstanceUnpackBackup(ctx context.Context, backupInfo SStorageUnpackInstan
 			packageDiskPath := path.Join(packagePath, fmt.Sprintf("%s_%d", PackageDiskFilename, i))
 			err := backupStorage.SaveBackupFrom(ctx, packageDiskPath, backupId)
 			if err != nil {
-				return nil, nil, errors.Wrapf(err, "SaveBackupFrom %s %s", packageDiskPath, backupId)
+				return nil, "", nil, errors.Wrapf(err, "SaveBackupFrom %s %s", packageDiskPath, backupId)
 			}
 		}
 	}
 
-	return backupIds, metadata, nil
+	// vTPM state is saved as is, it is encrypted with key of the backup
+	vtpmBackupId := ""
+	packageVtpmPath := path.Join(packagePath, PackageVtpmFilename)
+	if !metadataOnly && fileutils2.Exists(packageVtpmPath) {
+		vtpmBackupId = fmt.Sprintf("%s.vtpm", db.DefaultUUIDGenerator())
+		err := backupStorage.SaveBackupInstanceFrom(ctx, packageVtpmPath, vtpmBackupId)
+		if err != nil {
+			return nil, "", nil, errors.Wrapf(err, "SaveBackupInstanceFrom %s %s", packageVtpmPath, vtpmBackupId)
+		}
+	}
+
+	return backupIds, vtpmBackupId, metadata, nil
 }
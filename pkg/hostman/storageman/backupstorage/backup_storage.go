@@ -54,6 +54,19 @@ type IBackupStorage interface {
 	IsOnline() (bool, string, error)
 }
 
+// IRawImageBackupStorage is implemented by backup storages that work on the content of
+// disks, images saved to them should be raw and unencrypted, e.g. to be deduplicated
+type IRawImageBackupStorage interface {
+	IsRawImageRequired() bool
+}
+
+func IsRawImageRequired(bs IBackupStorage) bool {
+	if rbs, ok := bs.(IRawImageBackupStorage); ok {
+		return rbs.IsRawImageRequired()
+	}
+	return false
+}
+
 var factories []IBackupStorageFactory
 var backupStoragePool map[string]IBackupStorage
 var backupStorageLock *sync.Mutex
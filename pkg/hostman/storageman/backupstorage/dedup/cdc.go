@@ -0,0 +1,107 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	// content defined chunking, a chunk is cut where the rolling gear hash of
+	// the last 64 bytes matches cdcMask, so that inserting or removing data only
+	// changes the chunks around, cut points of chunks after are kept
+	cdcMinChunkSize = 512 * 1024
+	cdcMaxChunkSize = 8 * 1024 * 1024
+	// 21 bits of hash, average chunk size is about minimal size + 2MB
+	cdcMask = uint64(1<<21-1) << 43
+)
+
+var gearTable [256]uint64
+
+func init() {
+	// a fixed table, chunks must be cut at the same points on every host
+	for i := range gearTable {
+		sum := sha256.Sum256([]byte{byte(i)})
+		gearTable[i] = binary.BigEndian.Uint64(sum[:8])
+	}
+}
+
+// findCutPoint returns length of the first chunk of data
+func findCutPoint(data []byte) int {
+	n := len(data)
+	if n <= cdcMinChunkSize {
+		return n
+	}
+	if n > cdcMaxChunkSize {
+		n = cdcMaxChunkSize
+	}
+	h := uint64(0)
+	for i := cdcMinChunkSize; i < n; i++ {
+		h = (h << 1) + gearTable[data[i]]
+		if h&cdcMask == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// sChunker splits a stream into content defined chunks
+type sChunker struct {
+	reader io.Reader
+	buf    []byte
+	start  int
+	end    int
+	eof    bool
+}
+
+func newChunker(reader io.Reader) *sChunker {
+	return &sChunker{
+		reader: reader,
+		buf:    make([]byte, 2*cdcMaxChunkSize),
+	}
+}
+
+func (c *sChunker) fill() error {
+	if c.eof || c.end-c.start >= cdcMaxChunkSize {
+		return nil
+	}
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	n, err := io.ReadFull(c.reader, c.buf[c.end:])
+	c.end += n
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		c.eof = true
+		return nil
+	}
+	return err
+}
+
+// Next returns next chunk of stream or io.EOF at the end, the chunk is only
+// valid until next call
+func (c *sChunker) Next() ([]byte, error) {
+	err := c.fill()
+	if err != nil {
+		return nil, err
+	}
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+	n := findCutPoint(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
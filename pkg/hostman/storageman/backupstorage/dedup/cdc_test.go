@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func chunkAll(t *testing.T, data []byte) [][]byte {
+	chunker := newChunker(bytes.NewReader(data))
+	chunks := make([][]byte, 0)
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %s", err)
+		}
+		chunks = append(chunks, append([]byte{}, chunk...))
+	}
+	return chunks
+}
+
+func TestChunker(t *testing.T) {
+	data := randomData(1, 32*1024*1024+17)
+	chunks := chunkAll(t, data)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatalf("chunks don't make up the data")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > cdcMaxChunkSize {
+			t.Errorf("chunk %d too large: %d", i, len(chunk))
+		}
+		if i < len(chunks)-1 && len(chunk) < cdcMinChunkSize {
+			t.Errorf("chunk %d too small: %d", i, len(chunk))
+		}
+	}
+
+	// cut points after a removal are kept
+	shifted := chunkAll(t, data[100:])
+	if !bytes.Equal(shifted[len(shifted)-1], chunks[len(chunks)-1]) {
+		t.Errorf("last chunk changed by removal at head")
+	}
+	if len(shifted) != len(chunks) {
+		t.Errorf("expect %d chunks, got %d", len(chunks), len(shifted))
+	}
+}
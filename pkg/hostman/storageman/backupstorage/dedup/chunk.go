@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	chunkFlagRaw = byte(0)
+	chunkFlagLz4 = byte(1)
+
+	// flag(1 byte) + length of raw data(4 bytes)
+	chunkHeaderSize = 5
+)
+
+var (
+	ErrChunkCorrupted = errors.Error("chunk corrupted")
+)
+
+// sChunkCodec compresses and encrypts chunks with keys derived from
+// the encrypt key of backup storage
+type sChunkCodec struct {
+	idKey  []byte
+	encKey []byte
+}
+
+func deriveKey(key, usage string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(usage))
+	return mac.Sum(nil)
+}
+
+func newChunkCodec(key string) *sChunkCodec {
+	return &sChunkCodec{
+		idKey:  deriveKey(key, "chunk-id"),
+		encKey: deriveKey(key, "chunk-encrypt"),
+	}
+}
+
+// chunkId is a keyed hash of the chunk content, so that the chunk ids
+// reveal nothing about the content without the key
+func (c *sChunkCodec) chunkId(data []byte) string {
+	mac := hmac.New(sha256.New, c.idKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *sChunkCodec) newAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.encKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "aes.NewCipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// encode returns nonce + sealed(header + payload), the payload is lz4
+// compressed unless the data is incompressible
+func (c *sChunkCodec) encode(data []byte) ([]byte, error) {
+	buf := make([]byte, chunkHeaderSize+lz4.CompressBlockBound(len(data)))
+	n, err := lz4.CompressBlock(data, buf[chunkHeaderSize:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "lz4.CompressBlock")
+	}
+	if n > 0 && n < len(data) {
+		buf[0] = chunkFlagLz4
+		buf = buf[:chunkHeaderSize+n]
+	} else {
+		buf[0] = chunkFlagRaw
+		buf = append(buf[:chunkHeaderSize], data...)
+	}
+	binary.BigEndian.PutUint32(buf[1:chunkHeaderSize], uint32(len(data)))
+
+	aead, err := c.newAEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(buf)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	return aead.Seal(nonce, nonce, buf, nil), nil
+}
+
+func (c *sChunkCodec) decode(blob []byte) ([]byte, error) {
+	aead, err := c.newAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < aead.NonceSize() {
+		return nil, errors.Wrap(ErrChunkCorrupted, "too short")
+	}
+	nonce, sealed := blob[:aead.NonceSize()], blob[aead.NonceSize():]
+	buf, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.Wrap(ErrChunkCorrupted, err.Error())
+	}
+	if len(buf) < chunkHeaderSize {
+		return nil, errors.Wrap(ErrChunkCorrupted, "missing header")
+	}
+	size := int(binary.BigEndian.Uint32(buf[1:chunkHeaderSize]))
+	payload := buf[chunkHeaderSize:]
+	switch buf[0] {
+	case chunkFlagRaw:
+		if len(payload) != size {
+			return nil, errors.Wrapf(ErrChunkCorrupted, "expect %d bytes, got %d", size, len(payload))
+		}
+		return payload, nil
+	case chunkFlagLz4:
+		data := make([]byte, size)
+		n, err := lz4.UncompressBlock(payload, data)
+		if err != nil {
+			return nil, errors.Wrap(ErrChunkCorrupted, err.Error())
+		}
+		if n != size {
+			return nil, errors.Wrapf(ErrChunkCorrupted, "expect %d bytes, got %d", size, n)
+		}
+		return data, nil
+	default:
+		return nil, errors.Wrapf(ErrChunkCorrupted, "unknown flag %d", buf[0])
+	}
+}
+
+func isZeroChunk(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
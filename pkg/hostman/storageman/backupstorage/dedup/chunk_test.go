@@ -0,0 +1,92 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+)
+
+func TestChunkCodec(t *testing.T) {
+	codec := newChunkCodec("backup storage key")
+
+	random := make([]byte, 4096)
+	rand.Read(random)
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"compressible", bytes.Repeat([]byte("dumplings"), 10000)},
+		{"incompressible", random},
+		{"empty", []byte{}},
+	}
+	for _, c := range cases {
+		blob, err := codec.encode(c.data)
+		if err != nil {
+			t.Errorf("%s: encode error %s", c.name, err)
+			continue
+		}
+		if len(c.data) > 0 && bytes.Contains(blob, c.data) {
+			t.Errorf("%s: plain data found in encoded chunk", c.name)
+		}
+		data, err := codec.decode(blob)
+		if err != nil {
+			t.Errorf("%s: decode error %s", c.name, err)
+			continue
+		}
+		if !bytes.Equal(data, c.data) {
+			t.Errorf("%s: encode/decode mismatch", c.name)
+		}
+	}
+}
+
+func TestChunkCodecKey(t *testing.T) {
+	data := []byte("This is a chunk of disk")
+	codec := newChunkCodec("key1")
+	other := newChunkCodec("key2")
+
+	if codec.chunkId(data) == other.chunkId(data) {
+		t.Errorf("chunk id should depend on key")
+	}
+	if codec.chunkId(data) != newChunkCodec("key1").chunkId(data) {
+		t.Errorf("chunk id should be stable")
+	}
+
+	blob, err := codec.encode(data)
+	if err != nil {
+		t.Fatalf("encode error %s", err)
+	}
+	if _, err := other.decode(blob); errors.Cause(err) != ErrChunkCorrupted {
+		t.Errorf("decode with wrong key should fail with ErrChunkCorrupted, got %v", err)
+	}
+	blob[len(blob)-1] ^= 0xff
+	if _, err := codec.decode(blob); errors.Cause(err) != ErrChunkCorrupted {
+		t.Errorf("decode of tampered chunk should fail with ErrChunkCorrupted, got %v", err)
+	}
+}
+
+func TestIsZeroChunk(t *testing.T) {
+	if !isZeroChunk(make([]byte, 1024)) {
+		t.Errorf("zero chunk not detected")
+	}
+	data := make([]byte, 1024)
+	data[1023] = 1
+	if isZeroChunk(data) {
+		t.Errorf("non-zero chunk detected as zero")
+	}
+}
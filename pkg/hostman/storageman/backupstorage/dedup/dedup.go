@@ -0,0 +1,492 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/storageman/backupstorage/nfs"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const (
+	// chunks newer than this are never garbage collected, they may be referenced
+	// by a backup whose save session has expired, e.g. saved by a hung host
+	chunkGCGracePeriod = 24 * time.Hour
+
+	// a save session is refreshed during saving, the session not refreshed
+	// in sessionTimeout is taken as dead
+	sessionRefreshInterval = time.Minute
+	sessionTimeout         = 10 * time.Minute
+	// wait for running gc before saving
+	sessionWaitInterval = 10 * time.Second
+	sessionWaitRetry    = 30
+
+	gcLockTimeout = time.Hour
+	// hosts taking over a stale gc lock at the same time rename their locks
+	// onto it, the owner is checked after the renames settle
+	gcLockSettle = 2 * time.Second
+
+	manifestVersion = 1
+)
+
+var (
+	ErrGarbageCollecting = errors.Error("garbage collecting")
+	ErrGCLockLost        = errors.Error("gc lock taken over by others")
+)
+
+// SChunk is a chunk of backup, an empty id stands for a chunk of zeros
+// which is not stored at all
+type SChunk struct {
+	Id   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+// SManifest describes a backup as a list of content defined chunks
+type SManifest struct {
+	Version int      `json:"version"`
+	Size    int64    `json:"size"`
+	Chunks  []SChunk `json:"chunks"`
+}
+
+// iSharedDir is the dir shared by hosts where chunks and manifests are stored
+type iSharedDir interface {
+	Mount() error
+	Umount() error
+}
+
+// SDedupBackupStorage is a content addressed backup storage on a nfs shared dir,
+// raw disk images are split into content defined chunks, each unique chunk is
+// stored only once, compressed and encrypted with the key of the backup storage
+type SDedupBackupStorage struct {
+	backupStorageId string
+	store           iSharedDir
+	root            string
+	codec           *sChunkCodec
+
+	gcGracePeriod time.Duration
+	gcLock        *sync.Mutex
+	gcLockSettle  time.Duration
+	// written in the gc lock file taken by this host
+	gcLockOwner string
+}
+
+func newDedupBackupStorage(backupStorageId, nfsHost, nfsSharedDir, encryptKey string) *SDedupBackupStorage {
+	store := nfs.NewNFSBackupStorage(backupStorageId, nfsHost, nfsSharedDir)
+	return &SDedupBackupStorage{
+		backupStorageId: backupStorageId,
+		store:           store,
+		root:            store.Path,
+		codec:           newChunkCodec(encryptKey),
+		gcGracePeriod:   chunkGCGracePeriod,
+		gcLock:          &sync.Mutex{},
+		gcLockSettle:    gcLockSettle,
+	}
+}
+
+// IsRawImageRequired implements backupstorage.IRawImageBackupStorage, only the
+// content of disk can be deduplicated, compressed or encrypted images never match
+func (s *SDedupBackupStorage) IsRawImageRequired() bool {
+	return true
+}
+
+func (s *SDedupBackupStorage) getChunkDir() string {
+	return path.Join(s.root, "chunks")
+}
+
+func (s *SDedupBackupStorage) getChunkPath(chunkId string) string {
+	return path.Join(s.getChunkDir(), chunkId[:2], chunkId)
+}
+
+func (s *SDedupBackupStorage) getManifestDir() string {
+	return path.Join(s.root, "manifests")
+}
+
+func (s *SDedupBackupStorage) getBackupManifestPath(backupId string) string {
+	return path.Join(s.getManifestDir(), backupId)
+}
+
+func (s *SDedupBackupStorage) getPackageManifestDir() string {
+	return path.Join(s.root, "packmanifests")
+}
+
+func (s *SDedupBackupStorage) getBackupInstanceManifestPath(backupInstanceId string) string {
+	return path.Join(s.getPackageManifestDir(), backupInstanceId)
+}
+
+func (s *SDedupBackupStorage) getSessionDir() string {
+	return path.Join(s.root, "sessions")
+}
+
+func (s *SDedupBackupStorage) getGCLockPath() string {
+	return path.Join(s.root, "gc.lock")
+}
+
+func (s *SDedupBackupStorage) mount() error {
+	err := s.store.Mount()
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{s.getChunkDir(), s.getManifestDir(), s.getPackageManifestDir(), s.getSessionDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			s.store.Umount()
+			return errors.Wrapf(err, "mkdir %s", dir)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temp file and renames it to filename,
+// readers never see a partially written chunk or manifest
+func writeFileAtomic(filename string, data []byte) error {
+	dir := path.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", dir)
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-"+path.Base(filename))
+	if err != nil {
+		return errors.Wrap(err, "TempFile")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "write %s", tmp.Name())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "close %s", tmp.Name())
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (s *SDedupBackupStorage) saveChunk(data []byte) (string, error) {
+	chunkId := s.codec.chunkId(data)
+	chunkPath := s.getChunkPath(chunkId)
+	if fileutils2.Exists(chunkPath) {
+		// refresh mtime so that gc of other hosts keeps the chunk
+		now := time.Now()
+		if err := os.Chtimes(chunkPath, now, now); err == nil {
+			return chunkId, nil
+		}
+	}
+	blob, err := s.codec.encode(data)
+	if err != nil {
+		return "", errors.Wrap(err, "encode chunk")
+	}
+	err = writeFileAtomic(chunkPath, blob)
+	if err != nil {
+		return "", errors.Wrapf(err, "write chunk %s", chunkId)
+	}
+	return chunkId, nil
+}
+
+func (s *SDedupBackupStorage) loadChunk(chunkId string) ([]byte, error) {
+	blob, err := ioutil.ReadFile(s.getChunkPath(chunkId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read chunk %s", chunkId)
+	}
+	data, err := s.codec.decode(blob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode chunk %s", chunkId)
+	}
+	if s.codec.chunkId(data) != chunkId {
+		return nil, errors.Wrapf(ErrChunkCorrupted, "checksum mismatch of chunk %s", chunkId)
+	}
+	return data, nil
+}
+
+func (s *SDedupBackupStorage) loadManifest(manifestPath string) (*SManifest, error) {
+	content, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read manifest %s", manifestPath)
+	}
+	obj, err := jsonutils.Parse(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse manifest %s", manifestPath)
+	}
+	manifest := &SManifest{}
+	err = obj.Unmarshal(manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal manifest %s", manifestPath)
+	}
+	return manifest, nil
+}
+
+func (s *SDedupBackupStorage) SaveBackupFrom(ctx context.Context, srcFilename string, backupId string) error {
+	return s.saveFile(ctx, srcFilename, s.getBackupManifestPath(backupId))
+}
+
+func (s *SDedupBackupStorage) SaveBackupInstanceFrom(ctx context.Context, srcFilename string, backupInstanceId string) error {
+	return s.saveFile(ctx, srcFilename, s.getBackupInstanceManifestPath(backupInstanceId))
+}
+
+func (s *SDedupBackupStorage) saveFile(ctx context.Context, srcFilename string, manifestPath string) error {
+	err := s.mount()
+	if err != nil {
+		return errors.Wrap(err, "unable to mount")
+	}
+	defer s.store.Umount()
+
+	src, err := os.Open(srcFilename)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", srcFilename)
+	}
+	defer src.Close()
+
+	session, err := s.beginSaveSession(ctx)
+	if err != nil {
+		return errors.Wrap(err, "beginSaveSession")
+	}
+	defer session.end()
+
+	manifest := SManifest{
+		Version: manifestVersion,
+		Chunks:  []SChunk{},
+	}
+	chunker := newChunker(src)
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "context")
+		}
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "read %s", srcFilename)
+		}
+		chunk := SChunk{Size: len(data)}
+		if !isZeroChunk(data) {
+			chunk.Id, err = s.saveChunk(data)
+			if err != nil {
+				return errors.Wrapf(err, "save chunk at offset %d", manifest.Size)
+			}
+		}
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		manifest.Size += int64(chunk.Size)
+		session.refresh()
+	}
+	err = writeFileAtomic(manifestPath, []byte(jsonutils.Marshal(manifest).String()))
+	if err != nil {
+		return errors.Wrap(err, "write manifest")
+	}
+	return nil
+}
+
+func (s *SDedupBackupStorage) RestoreBackupTo(ctx context.Context, targetFilename string, backupId string) error {
+	return s.restoreFile(ctx, targetFilename, s.getBackupManifestPath(backupId))
+}
+
+func (s *SDedupBackupStorage) RestoreBackupInstanceTo(ctx context.Context, targetFilename string, backupInstanceId string) error {
+	return s.restoreFile(ctx, targetFilename, s.getBackupInstanceManifestPath(backupInstanceId))
+}
+
+func (s *SDedupBackupStorage) restoreFile(ctx context.Context, targetFilename string, manifestPath string) error {
+	err := s.mount()
+	if err != nil {
+		return errors.Wrap(err, "unable to mount")
+	}
+	defer s.store.Umount()
+
+	manifest, err := s.loadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	target, err := os.OpenFile(targetFilename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", targetFilename)
+	}
+	defer target.Close()
+
+	offset := int64(0)
+	for _, chunk := range manifest.Chunks {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "context")
+		}
+		if len(chunk.Id) > 0 {
+			data, err := s.loadChunk(chunk.Id)
+			if err != nil {
+				return err
+			}
+			if len(data) != chunk.Size {
+				return errors.Wrapf(ErrChunkCorrupted, "size of chunk %s mismatch", chunk.Id)
+			}
+			_, err = target.WriteAt(data, offset)
+			if err != nil {
+				return errors.Wrapf(err, "write %s", targetFilename)
+			}
+		}
+		// zero chunk is left as a hole
+		offset += int64(chunk.Size)
+	}
+	err = target.Truncate(manifest.Size)
+	if err != nil {
+		return errors.Wrapf(err, "truncate %s", targetFilename)
+	}
+	return nil
+}
+
+func (s *SDedupBackupStorage) RemoveBackup(ctx context.Context, backupId string) error {
+	return s.removeFile(ctx, s.getBackupManifestPath(backupId))
+}
+
+func (s *SDedupBackupStorage) RemoveBackupInstance(ctx context.Context, backupInstanceId string) error {
+	return s.removeFile(ctx, s.getBackupInstanceManifestPath(backupInstanceId))
+}
+
+func (s *SDedupBackupStorage) removeFile(ctx context.Context, manifestPath string) error {
+	err := s.mount()
+	if err != nil {
+		return errors.Wrap(err, "unable to mount")
+	}
+	defer s.store.Umount()
+
+	if !fileutils2.Exists(manifestPath) {
+		return nil
+	}
+	err = os.Remove(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "remove manifest %s", manifestPath)
+	}
+	err = s.collectGarbage(ctx)
+	if err != nil {
+		// unreferenced chunks will be collected by the next removal
+		log.Errorf("collect garbage chunks of backup storage %s: %s", s.backupStorageId, err)
+	}
+	return nil
+}
+
+// collectGarbage removes the chunks that are no longer referenced by any manifest,
+// it never runs along with a save session, whose chunks may not be referenced yet
+func (s *SDedupBackupStorage) collectGarbage(ctx context.Context) error {
+	s.gcLock.Lock()
+	defer s.gcLock.Unlock()
+
+	locked, err := s.lockGC()
+	if err != nil {
+		return errors.Wrap(err, "lockGC")
+	}
+	if !locked {
+		log.Infof("backup storage %s: garbage collection is running on another host", s.backupStorageId)
+		return nil
+	}
+	defer s.unlockGC()
+
+	// sessions must be checked after gc lock is taken, a session begins after
+	// this check finds the lock and waits
+	active, err := s.hasActiveSession()
+	if err != nil {
+		return errors.Wrap(err, "hasActiveSession")
+	}
+	if active {
+		// chunks are collected by the next removal
+		log.Infof("backup storage %s: backup is being saved, garbage collection postponed", s.backupStorageId)
+		return nil
+	}
+
+	referenced := make(map[string]struct{})
+	for _, dir := range []string{s.getManifestDir(), s.getPackageManifestDir()} {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return errors.Wrapf(err, "read dir %s", dir)
+		}
+		for _, file := range files {
+			if file.IsDir() || file.Name()[0] == '.' {
+				continue
+			}
+			manifest, err := s.loadManifest(path.Join(dir, file.Name()))
+			if err != nil {
+				// never remove chunks with unreadable manifests around
+				return err
+			}
+			for _, chunk := range manifest.Chunks {
+				referenced[chunk.Id] = struct{}{}
+			}
+		}
+	}
+
+	removed := 0
+	refreshedAt := time.Now()
+	deadline := time.Now().Add(-s.gcGracePeriod)
+	err = filepath.Walk(s.getChunkDir(), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if time.Since(refreshedAt) > sessionRefreshInterval {
+			if err := s.refreshGCLock(); err != nil {
+				return err
+			}
+			refreshedAt = time.Now()
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if _, ok := referenced[info.Name()]; ok {
+			return nil
+		}
+		if info.ModTime().After(deadline) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove chunk %s", p)
+		}
+		removed++
+		return nil
+	})
+	log.Infof("backup storage %s: %d garbage chunks removed", s.backupStorageId, removed)
+	return err
+}
+
+func (s *SDedupBackupStorage) IsBackupExists(backupId string) (bool, error) {
+	return s.isFileExists(s.getBackupManifestPath(backupId))
+}
+
+func (s *SDedupBackupStorage) IsBackupInstanceExists(backupInstanceId string) (bool, error) {
+	return s.isFileExists(s.getBackupInstanceManifestPath(backupInstanceId))
+}
+
+func (s *SDedupBackupStorage) isFileExists(manifestPath string) (bool, error) {
+	err := s.mount()
+	if err != nil {
+		return false, errors.Wrap(err, "unable to mount")
+	}
+	defer s.store.Umount()
+
+	return fileutils2.Exists(manifestPath), nil
+}
+
+func (s *SDedupBackupStorage) IsOnline() (bool, string, error) {
+	err := s.mount()
+	if errors.Cause(err) == nfs.ErrorBackupStorageOffline {
+		return false, err.Error(), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	s.store.Umount()
+	return true, "", nil
+}
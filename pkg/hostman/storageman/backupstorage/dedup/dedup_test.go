@@ -0,0 +1,249 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type sLocalDir struct{}
+
+func (d sLocalDir) Mount() error  { return nil }
+func (d sLocalDir) Umount() error { return nil }
+
+func newTestStorage(t *testing.T) (*SDedupBackupStorage, string) {
+	root, err := ioutil.TempDir("", "dedup-test")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	s := &SDedupBackupStorage{
+		backupStorageId: "test",
+		store:           sLocalDir{},
+		root:            path.Join(root, "store"),
+		codec:           newChunkCodec("backup storage key"),
+		gcLock:          &sync.Mutex{},
+	}
+	return s, root
+}
+
+func randomData(seed int64, size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	filename := path.Join(dir, name)
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("write %s: %s", filename, err)
+	}
+	return filename
+}
+
+func countChunks(t *testing.T, s *SDedupBackupStorage) int {
+	cnt := 0
+	err := filepath.Walk(s.getChunkDir(), func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			cnt++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk chunks: %s", err)
+	}
+	return cnt
+}
+
+func TestSaveRestore(t *testing.T) {
+	s, root := newTestStorage(t)
+	defer os.RemoveAll(root)
+	ctx := context.Background()
+
+	// disk with zeros in the middle
+	data := append(randomData(1, 6*1024*1024), make([]byte, 9*1024*1024)...)
+	data = append(data, randomData(2, 3*1024*1024+123)...)
+	if err := s.SaveBackupFrom(ctx, writeTestFile(t, root, "disk", data), "backup1"); err != nil {
+		t.Fatalf("SaveBackupFrom: %s", err)
+	}
+	target := path.Join(root, "restored")
+	if err := s.RestoreBackupTo(ctx, target, "backup1"); err != nil {
+		t.Fatalf("RestoreBackupTo: %s", err)
+	}
+	restored, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read restored: %s", err)
+	}
+	if !bytes.Equal(restored, data) {
+		t.Errorf("restored data mismatch")
+	}
+	if exist, _ := s.IsBackupExists("backup1"); !exist {
+		t.Errorf("backup1 should exist")
+	}
+}
+
+func TestSaveDedup(t *testing.T) {
+	s, root := newTestStorage(t)
+	defer os.RemoveAll(root)
+	ctx := context.Background()
+
+	base := randomData(1, 24*1024*1024)
+	if err := s.SaveBackupFrom(ctx, writeTestFile(t, root, "base", base), "base"); err != nil {
+		t.Fatalf("SaveBackupFrom base: %s", err)
+	}
+	baseChunks := countChunks(t, s)
+
+	// data shifted by an insertion should still share most of the chunks
+	cloned := append(append(append([]byte{}, base[:1000]...), []byte("inserted by guest")...), base[1000:]...)
+	if err := s.SaveBackupFrom(ctx, writeTestFile(t, root, "cloned", cloned), "cloned"); err != nil {
+		t.Fatalf("SaveBackupFrom cloned: %s", err)
+	}
+	newChunks := countChunks(t, s) - baseChunks
+	if newChunks > 1 {
+		t.Errorf("expect at most 1 new chunk of %d, got %d", baseChunks, newChunks)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	s, root := newTestStorage(t)
+	defer os.RemoveAll(root)
+	ctx := context.Background()
+
+	shared := randomData(1, 12*1024*1024)
+	data1 := append(append([]byte{}, shared...), randomData(2, 12*1024*1024)...)
+	data2 := append(append([]byte{}, shared...), randomData(3, 12*1024*1024)...)
+	if err := s.SaveBackupFrom(ctx, writeTestFile(t, root, "disk1", data1), "backup1"); err != nil {
+		t.Fatalf("SaveBackupFrom: %s", err)
+	}
+	if err := s.SaveBackupFrom(ctx, writeTestFile(t, root, "disk2", data2), "backup2"); err != nil {
+		t.Fatalf("SaveBackupFrom: %s", err)
+	}
+	total := countChunks(t, s)
+
+	// a save is in progress, its chunks may not be referenced yet
+	session, err := s.beginSaveSession(ctx)
+	if err != nil {
+		t.Fatalf("beginSaveSession: %s", err)
+	}
+	if err := s.RemoveBackup(ctx, "backup1"); err != nil {
+		t.Fatalf("RemoveBackup: %s", err)
+	}
+	if cnt := countChunks(t, s); cnt != total {
+		t.Errorf("gc should be postponed during saving, %d chunks left of %d", cnt, total)
+	}
+	session.end()
+
+	if err := s.collectGarbage(ctx); err != nil {
+		t.Fatalf("collectGarbage: %s", err)
+	}
+	manifest, err := s.loadManifest(s.getBackupManifestPath("backup2"))
+	if err != nil {
+		t.Fatalf("loadManifest: %s", err)
+	}
+	if cnt := countChunks(t, s); cnt != len(manifest.Chunks) {
+		t.Errorf("expect %d chunks of backup2 left, got %d", len(manifest.Chunks), cnt)
+	}
+	target := path.Join(root, "restored")
+	if err := s.RestoreBackupTo(ctx, target, "backup2"); err != nil {
+		t.Fatalf("RestoreBackupTo: %s", err)
+	}
+	if restored, _ := ioutil.ReadFile(target); !bytes.Equal(restored, data2) {
+		t.Errorf("backup2 broken by gc")
+	}
+}
+
+func TestGCLock(t *testing.T) {
+	s, root := newTestStorage(t)
+	defer os.RemoveAll(root)
+	if err := s.mount(); err != nil {
+		t.Fatalf("mount: %s", err)
+	}
+
+	locked, err := s.lockGC()
+	if err != nil || !locked {
+		t.Fatalf("lockGC: %v %s", locked, err)
+	}
+	if locked, _ := s.lockGC(); locked {
+		t.Errorf("gc lock should be exclusive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.beginSaveSession(ctx); err == nil {
+		t.Errorf("save session should wait for gc")
+	}
+	s.unlockGC()
+	session, err := s.beginSaveSession(context.Background())
+	if err != nil {
+		t.Fatalf("beginSaveSession: %s", err)
+	}
+	if active, _ := s.hasActiveSession(); !active {
+		t.Errorf("session should be active")
+	}
+	session.end()
+	if active, _ := s.hasActiveSession(); active {
+		t.Errorf("session should be ended")
+	}
+}
+
+func TestGCLockTakeOver(t *testing.T) {
+	s, root := newTestStorage(t)
+	defer os.RemoveAll(root)
+	if err := s.mount(); err != nil {
+		t.Fatalf("mount: %s", err)
+	}
+	// another host sharing the dir
+	other := *s
+	other.gcLock = &sync.Mutex{}
+
+	if locked, err := other.lockGC(); err != nil || !locked {
+		t.Fatalf("lockGC: %v %s", locked, err)
+	}
+	// lock of a crashed gc
+	stale := time.Now().Add(-2 * gcLockTimeout)
+	if err := os.Chtimes(s.getGCLockPath(), stale, stale); err != nil {
+		t.Fatalf("Chtimes: %s", err)
+	}
+	if locked, err := s.lockGC(); err != nil || !locked {
+		t.Fatalf("take over stale lock: %v %s", locked, err)
+	}
+	if err := other.refreshGCLock(); errors.Cause(err) != ErrGCLockLost {
+		t.Errorf("refresh lock taken over: %v", err)
+	}
+	other.unlockGC()
+	if err := s.refreshGCLock(); err != nil {
+		t.Errorf("lock removed by former owner: %s", err)
+	}
+	s.unlockGC()
+	if locked, _ := s.isGCLocked(); locked {
+		t.Errorf("gc lock should be released")
+	}
+	files, _ := ioutil.ReadDir(s.root)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".gc.lock") {
+			t.Errorf("temporary lock %s left", file.Name())
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+)
+
+// Saving and garbage collection exclude each other on the shared dir: a save
+// publishes its session file and then checks the gc lock, a gc takes the gc
+// lock and then checks the session files, one of them always sees the other.
+
+type sSaveSession struct {
+	path        string
+	refreshedAt time.Time
+}
+
+func (s *SDedupBackupStorage) beginSaveSession(ctx context.Context) (*sSaveSession, error) {
+	for i := 0; i < sessionWaitRetry; i++ {
+		session := &sSaveSession{
+			path:        path.Join(s.getSessionDir(), stringutils.UUID4()),
+			refreshedAt: time.Now(),
+		}
+		err := writeFileAtomic(session.path, []byte{})
+		if err != nil {
+			return nil, errors.Wrap(err, "write session")
+		}
+		locked, err := s.isGCLocked()
+		if err != nil {
+			session.end()
+			return nil, errors.Wrap(err, "isGCLocked")
+		}
+		if !locked {
+			return session, nil
+		}
+		session.end()
+		log.Infof("backup storage %s: wait for garbage collection", s.backupStorageId)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "context")
+		case <-time.After(sessionWaitInterval):
+		}
+	}
+	return nil, errors.Wrapf(ErrGarbageCollecting, "backup storage %s", s.backupStorageId)
+}
+
+// refresh keeps the session alive during a long save
+func (session *sSaveSession) refresh() {
+	if time.Since(session.refreshedAt) < sessionRefreshInterval {
+		return
+	}
+	session.refreshedAt = time.Now()
+	err := os.Chtimes(session.path, session.refreshedAt, session.refreshedAt)
+	if err != nil {
+		log.Errorf("refresh save session %s: %s", session.path, err)
+	}
+}
+
+func (session *sSaveSession) end() {
+	err := os.Remove(session.path)
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("remove save session %s: %s", session.path, err)
+	}
+}
+
+func (s *SDedupBackupStorage) hasActiveSession() (bool, error) {
+	files, err := ioutil.ReadDir(s.getSessionDir())
+	if err != nil {
+		return false, errors.Wrapf(err, "read dir %s", s.getSessionDir())
+	}
+	for _, file := range files {
+		if file.IsDir() || file.Name()[0] == '.' {
+			continue
+		}
+		if time.Since(file.ModTime()) < sessionTimeout {
+			return true, nil
+		}
+		// session left by a crashed save
+		os.Remove(path.Join(s.getSessionDir(), file.Name()))
+	}
+	return false, nil
+}
+
+func (s *SDedupBackupStorage) isGCLocked() (bool, error) {
+	info, err := os.Stat(s.getGCLockPath())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "stat gc lock")
+	}
+	return time.Since(info.ModTime()) < gcLockTimeout, nil
+}
+
+// lockGC takes the gc lock of shared dir, returns false if it is held by others
+func (s *SDedupBackupStorage) lockGC() (bool, error) {
+	lockPath := s.getGCLockPath()
+	owner := stringutils.UUID4()
+	tmp := path.Join(path.Dir(lockPath), ".gc.lock."+owner)
+	if err := writeFileAtomic(tmp, []byte(owner)); err != nil {
+		return false, errors.Wrap(err, "write gc lock")
+	}
+	defer os.Remove(tmp)
+	// link fails if the lock exists, while a lock file is never seen without
+	// its owner
+	err := os.Link(tmp, lockPath)
+	if err == nil {
+		s.gcLockOwner = owner
+		return true, nil
+	}
+	if !os.IsExist(err) {
+		return false, errors.Wrap(err, "create gc lock")
+	}
+	locked, err := s.isGCLocked()
+	if err != nil {
+		return false, err
+	}
+	if locked {
+		return false, nil
+	}
+	// lock left by a crashed gc is replaced atomically, of the hosts doing
+	// so at the same time the last one wins
+	if err := os.Rename(tmp, lockPath); err != nil {
+		return false, errors.Wrap(err, "take over stale gc lock")
+	}
+	time.Sleep(s.gcLockSettle)
+	if cur, err := s.getGCLockOwner(); err != nil || cur != owner {
+		log.Infof("backup storage %s: stale gc lock taken over by others", s.backupStorageId)
+		return false, nil
+	}
+	s.gcLockOwner = owner
+	return true, nil
+}
+
+func (s *SDedupBackupStorage) getGCLockOwner() (string, error) {
+	content, err := ioutil.ReadFile(s.getGCLockPath())
+	if err != nil {
+		return "", errors.Wrap(err, "read gc lock")
+	}
+	return string(content), nil
+}
+
+// refreshGCLock keeps the gc lock from being taken as stale during a long gc,
+// the gc stops if the lock has been taken over by others in the meantime
+func (s *SDedupBackupStorage) refreshGCLock() error {
+	owner, err := s.getGCLockOwner()
+	if err != nil {
+		return err
+	}
+	if owner != s.gcLockOwner {
+		return errors.Wrapf(ErrGCLockLost, "backup storage %s", s.backupStorageId)
+	}
+	now := time.Now()
+	err = os.Chtimes(s.getGCLockPath(), now, now)
+	if err != nil {
+		log.Errorf("refresh gc lock of backup storage %s: %s", s.backupStorageId, err)
+	}
+	return nil
+}
+
+func (s *SDedupBackupStorage) unlockGC() {
+	defer func() { s.gcLockOwner = "" }()
+	owner, err := s.getGCLockOwner()
+	if err != nil || owner != s.gcLockOwner {
+		log.Errorf("backup storage %s: gc lock not owned, left as is", s.backupStorageId)
+		return
+	}
+	err = os.Remove(s.getGCLockPath())
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("remove gc lock of backup storage %s: %s", s.backupStorageId, err)
+	}
+}
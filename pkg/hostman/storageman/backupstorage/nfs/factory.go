@@ -37,7 +37,10 @@ func (factory *sNfsBackupStorageFactory) NewBackupStore(backupStroageId string,
 	if len(accessInfo.NfsSharedDir) == 0 {
 		return nil, errors.Wrap(httperrors.ErrInputParameter, "need nfs_shared_dir in backup_storage_access_info")
 	}
-	return newNFSBackupStorage(backupStroageId, accessInfo.NfsHost, accessInfo.NfsSharedDir), nil
+	if len(accessInfo.DedupEncryptKey) > 0 {
+		return nil, errors.Wrap(httperrors.ErrInputParameter, "dedup_encrypt_key is not expected in nfs backup_storage_access_info")
+	}
+	return NewNFSBackupStorage(backupStroageId, accessInfo.NfsHost, accessInfo.NfsSharedDir), nil
 }
 
 func init() {
@@ -41,7 +41,7 @@ type SNFSBackupStorage struct {
 	userNumber      int
 }
 
-func newNFSBackupStorage(backupStorageId, nfsHost, nfsSharedDir string) *SNFSBackupStorage {
+func NewNFSBackupStorage(backupStorageId, nfsHost, nfsSharedDir string) *SNFSBackupStorage {
 	return &SNFSBackupStorage{
 		BackupStorageId: backupStorageId,
 		NfsHost:         nfsHost,
@@ -122,6 +122,15 @@ func (s *SNFSBackupStorage) unMount() error {
 	return nil
 }
 
+// Mount mounts the nfs shared dir to Path, should be paired with Umount
+func (s *SNFSBackupStorage) Mount() error {
+	return s.checkAndMount()
+}
+
+func (s *SNFSBackupStorage) Umount() error {
+	return s.unMount()
+}
+
 func (s *SNFSBackupStorage) SaveBackupFrom(ctx context.Context, srcFilename string, backupId string) error {
 	return s.saveFile(ctx, srcFilename, backupId, s.getBackupDiskPath)
 }
@@ -372,7 +372,7 @@ func packInstanceBackup(ctx context.Context, params interface{}) (jsonutils.JSON
 func unpackInstanceBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
 	sbParams := params.(*storageman.SStorageUnpackInstanceBackup)
 
-	diskBackupIds, metadata, err := storageman.DoInstanceUnpackBackup(ctx, *sbParams)
+	diskBackupIds, vtpmBackupId, metadata, err := storageman.DoInstanceUnpackBackup(ctx, *sbParams)
 	if err != nil {
 		return nil, errors.Wrap(err, "DoInstanceUnpackBackup")
 	}
@@ -381,6 +381,9 @@ func unpackInstanceBackup(ctx context.Context, params interface{}) (jsonutils.JS
 	if diskBackupIds != nil {
 		ret.Set("disk_backup_ids", jsonutils.Marshal(diskBackupIds))
 	}
+	if len(vtpmBackupId) > 0 {
+		ret.Set("vtpm_backup_id", jsonutils.NewString(vtpmBackupId))
+	}
 	ret.Set("metadata", jsonutils.Marshal(metadata))
 	return ret, nil
 }
@@ -406,6 +409,7 @@ func storageDeleteBackup(ctx context.Context, w http.ResponseWriter, r *http.Req
 		BackupId:                backupId,
 		BackupStorageId:         backupStorageId,
 		BackupStorageAccessInfo: backupStorageAccessInfo.(*jsonutils.JSONDict),
+		BackupInstance:          jsonutils.QueryBoolean(body, "backup_instance", false),
 	})
 	hostutils.ResponseOk(ctx, w)
 }
@@ -427,7 +431,11 @@ func deleteBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject
 	if err != nil {
 		return nil, err
 	}
-	err = backupStorage.RemoveBackup(ctx, sbParams.BackupId)
+	if sbParams.BackupInstance {
+		err = backupStorage.RemoveBackupInstance(ctx, sbParams.BackupId)
+	} else {
+		err = backupStorage.RemoveBackup(ctx, sbParams.BackupId)
+	}
 	if err != nil {
 		return nil, err
 	}
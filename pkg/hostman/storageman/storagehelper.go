@@ -69,6 +69,11 @@ type SDiskBackup struct {
 
 	EncryptKeyId string `json:"encrypt_key_id"`
 
+	// disk id and parent backup id are only used by live backup of running guest
+	DiskId string `json:"disk_id"`
+	// parent backup of incremental backup, changed blocks are tracked by dirty bitmap
+	ParentBackupId string `json:"parent_backup_id"`
+
 	UserCred mcclient.TokenCredential
 }
 
@@ -76,6 +81,8 @@ type SStorageBackup struct {
 	BackupId                string
 	BackupStorageId         string
 	BackupStorageAccessInfo *jsonutils.JSONDict
+	// BackupInstance is set for files of instance backup, e.g. vTPM state
+	BackupInstance bool
 }
 
 type SStoragePackBackup struct {
@@ -91,6 +98,7 @@ type SStoragePackInstanceBackup struct {
 	BackupStorageId         string
 	BackupStorageAccessInfo *jsonutils.JSONDict
 	BackupIds               []string
+	VtpmBackupId            string
 	Metadata                api.InstanceBackupPackMetadata
 }
 
@@ -18,7 +18,9 @@ import (
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/appsrv/dispatcher"
 	app_common "yunion.io/x/onecloud/pkg/cloudcommon/app"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/cronjobrun"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/image/models"
@@ -43,6 +45,7 @@ func InitHandlers(app *appsrv.Application) {
 	quotas.AddQuotaHandler(&models.QuotaManager.SQuotaBaseManager, API_VERSION, app)
 	usages.AddUsageHandler(API_VERSION, app)
 	taskman.AddTaskHandler(API_VERSION, app)
+	cronman.AddCronJobHandler(API_VERSION, app)
 
 	app_common.ExportOptionsHandler(app, &options.Options)
 
@@ -54,6 +57,7 @@ func InitHandlers(app *appsrv.Application) {
 		db.UserCacheManager,
 		db.TenantCacheManager,
 		db.SharedResourceManager,
+		cronjobrun.CronJobRunManager,
 
 		models.ImageTagManager,
 		models.ImageMemberManager,
@@ -38,6 +38,8 @@ func init() {
 				"status",
 				"start_time",
 				"end_time",
+				"require_approval",
+				"planned_at",
 			},
 			[]string{},
 		),
@@ -34,6 +34,8 @@ func init() {
 			[]string{
 				"id",
 				"name",
+				"key_rotation_interval_days",
+				"key_rotated_at",
 			},
 			[]string{"tenant"},
 		),
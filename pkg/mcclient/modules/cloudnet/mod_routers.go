@@ -37,6 +37,8 @@ func init() {
 				"user",
 				"host",
 				"port",
+				"wg_health_status",
+				"wg_stale_peer_count",
 			},
 			[]string{"tenant"},
 		),
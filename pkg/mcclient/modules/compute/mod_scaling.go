@@ -33,7 +33,7 @@ func init() {
 		[]string{},
 	)
 	ScalingPolicy = modules.NewComputeManager("scalingpolicy", "scalingpolicies",
-		[]string{"ID", "Name", "Timer", "Cycle_Timer", "Alarm", "Action", "Number", "Unit", "Cooling_Time"},
+		[]string{"ID", "Name", "Timer", "Cycle_Timer", "Alarm", "Metric", "Action", "Number", "Unit", "Cooling_Time"},
 		[]string{},
 	)
 	ScalingActivity = modules.NewComputeManager("scalingactivity", "scalingactivities",
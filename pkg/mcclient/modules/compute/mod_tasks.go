@@ -105,3 +105,21 @@ func (this *TasksManager) List(session *mcclient.ClientSession, params jsonutils
 	}
 	return man.List(session, params)
 }
+
+// GetTaskTree returns the task trees of an object or a task
+func (this *TasksManager) GetTaskTree(session *mcclient.ClientSession, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	man, err := this.getManager(session, params)
+	if err != nil {
+		return nil, err
+	}
+	return man.Get(session, "tree", params)
+}
+
+// Retry reschedules a failed task at its failed stage
+func (this *TasksManager) Retry(session *mcclient.ClientSession, id string, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	man, err := this.getManager(session, params)
+	if err != nil {
+		return nil, err
+	}
+	return man.PerformAction(session, id, "retry", params)
+}
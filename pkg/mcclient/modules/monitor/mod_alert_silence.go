@@ -0,0 +1,38 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+var (
+	AlertSilenceManager       *modulebase.ResourceManager
+	AlertSilenceRecordManager *modulebase.ResourceManager
+)
+
+func init() {
+	silenceMan := modules.NewMonitorV2Manager("alertsilence", "alertsilences",
+		[]string{"id", "name", "enabled", "matchers", "start_time", "end_time", "schedule", "duration", "timezone", "suppressed_count", "last_suppressed_at"},
+		[]string{})
+	AlertSilenceManager = &silenceMan
+	recordMan := modules.NewMonitorV2Manager("alertsilencerecord", "alertsilencerecords",
+		[]string{"id", "silence_id", "alert_name", "level", "state", "res_type", "res_id", "metric", "created_at"},
+		[]string{"labels"})
+	AlertSilenceRecordManager = &recordMan
+	modules.Register(AlertSilenceManager)
+	modules.Register(AlertSilenceRecordManager)
+}
@@ -22,15 +22,20 @@ import (
 var (
 	ScheduledTask         modulebase.ResourceManager
 	ScheduledTaskActivity modulebase.ResourceManager
+	ScheduledTaskCalendar modulebase.ResourceManager
 )
 
 func init() {
 	ScheduledTask = modules.NewScheduledtaskManager("scheduledtask", "scheduledtasks",
-		[]string{"ID", "Name", "Scheduled_Type", "Timer", "Cycle_Timer", "Resource_Type", "Operation", "Label_Type", "Labels", "Timer_Desc"}, []string{},
+		[]string{"ID", "Name", "Scheduled_Type", "Timer", "Cycle_Timer", "Resource_Type", "Operation", "Label_Type", "Labels", "Timer_Desc", "Depends_On", "Calendar"}, []string{},
 	)
 	ScheduledTaskActivity = modules.NewScheduledtaskManager("scheudledtaskactivity", "scheduledtaskactivities",
 		[]string{"ID", "Status", "Scheduled_Task_Id", "Start_Time", "End_Time", "Reason"}, []string{},
 	)
+	ScheduledTaskCalendar = modules.NewScheduledtaskManager("scheduledtaskcalendar", "scheduledtaskcalendars",
+		[]string{"ID", "Name", "Timezone", "Dates", "Periods"}, []string{},
+	)
 	modules.Register(&ScheduledTask)
 	modules.Register(&ScheduledTaskActivity)
+	modules.Register(&ScheduledTaskCalendar)
 }
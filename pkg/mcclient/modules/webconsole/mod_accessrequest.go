@@ -0,0 +1,45 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
+
+var (
+	AccessRequest *AccessRequestManager
+)
+
+func init() {
+	AccessRequest = NewAccessRequestManager()
+
+	modulebase.Register(AccessRequest)
+}
+
+type AccessRequestManager struct {
+	modulebase.ResourceManager
+}
+
+func NewAccessRequestManager() *AccessRequestManager {
+	return &AccessRequestManager{
+		modulebase.ResourceManager{
+			BaseManager: *modulebase.NewBaseManager("webconsole", "", "webconsole", []string{
+				"id", "session_id", "protocol", "obj_id", "obj_type", "obj_name", "tag", "user", "user_id", "project",
+				"status", "approver", "comment", "approved_at", "expire_at", "created_at",
+			}, nil),
+			Keyword: "accessrequest", KeywordPlural: "accessrequests",
+		},
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webconsole
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"yunion.io/x/pkg/util/httputils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
+
+var (
+	SessionRecording *SessionRecordingManager
+)
+
+func init() {
+	SessionRecording = NewSessionRecordingManager()
+
+	modulebase.Register(SessionRecording)
+}
+
+type SessionRecordingManager struct {
+	modulebase.ResourceManager
+}
+
+func NewSessionRecordingManager() *SessionRecordingManager {
+	return &SessionRecordingManager{
+		modulebase.ResourceManager{
+			BaseManager: *modulebase.NewBaseManager("webconsole", "", "webconsole", []string{
+				"id", "session_id", "protocol", "format", "obj_id", "obj_type", "obj_name", "login_user",
+				"user", "user_id", "project", "project_id", "start_time", "end_time", "size", "status",
+			}, nil),
+			Keyword: "sessionrecording", KeywordPlural: "sessionrecordings",
+		},
+	}
+}
+
+// Playback returns the recorded stream of a session, caller should close the reader
+func (m *SessionRecordingManager) Playback(s *mcclient.ClientSession, id string) (io.ReadCloser, error) {
+	path := fmt.Sprintf("/%s/%s/playback", m.URLPath(), url.PathEscape(id))
+	resp, err := modulebase.RawRequest(m.ResourceManager, s, httputils.GET, path, nil, nil)
+	if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.Body, nil
+	}
+	_, _, err = s.ParseJSONResponse("", resp, err)
+	return nil, err
+}
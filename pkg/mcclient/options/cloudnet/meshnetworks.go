@@ -20,6 +20,8 @@ import (
 
 type MeshNetworkCreateOptions struct {
 	NAME string
+
+	KeyRotationIntervalDays int `help:"rotate wireguard keys every few days, 0 to disable"`
 }
 
 type MeshNetworkGetOptions struct {
@@ -29,6 +31,8 @@ type MeshNetworkGetOptions struct {
 type MeshNetworkUpdateOptions struct {
 	ID   string `json:"-"`
 	Name string
+
+	KeyRotationIntervalDays *int `help:"rotate wireguard keys every few days, 0 to disable"`
 }
 
 type MeshNetworkDeleteOptions struct {
@@ -42,3 +46,7 @@ type MeshNetworkListOptions struct {
 type MeshNetworkActionRealizeOptions struct {
 	ID string `json:"-"`
 }
+
+type MeshNetworkActionRotateKeysOptions struct {
+	ID string `json:"-"`
+}
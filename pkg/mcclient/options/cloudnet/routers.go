@@ -118,3 +118,7 @@ type RouterActionUnregisterIfnameOptions struct {
 type RouterActionRealizeOptions struct {
 	ID string `json:"-"`
 }
+
+type RouterWireguardPeersOptions struct {
+	ID string `json:"-"`
+}
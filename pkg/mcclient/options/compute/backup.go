@@ -26,6 +26,8 @@ type DiskBackupListOptions struct {
 	BackupStorageId  string `help:"backup storage id" json:"backup_storage_id"`
 	IsInstanceBackup *bool  `help:"if part of instance backup" json:"is_instance_backup"`
 	OrderByDiskName  string
+	ParentBackupId   string `help:"parent backup id" json:"parent_backup_id"`
+	BackupMode       string `help:"backup mode" choices:"full|incremental" json:"backup_mode"`
 }
 
 func (opts *DiskBackupListOptions) Params() (jsonutils.JSONObject, error) {
@@ -57,6 +59,8 @@ type DiskBackupCreateOptions struct {
 	options.BaseCreateOptions
 	DISKID          string `help:"disk id" json:"disk_id"`
 	BACKUPSTORAGEID string `help:"back storage id" json:"backup_storage_id"`
+	ParentBackupId  string `help:"parent backup id of incremental backup" json:"parent_backup_id"`
+	Incremental     bool   `help:"incremental backup based on the latest backup of disk" json:"incremental"`
 }
 
 func (opts *DiskBackupCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -112,10 +116,10 @@ func (opts *BackupStorageIdOptions) Params() (jsonutils.JSONObject, error) {
 
 type BackupStorageCreateOptions struct {
 	options.BaseCreateOptions
-	StorageType string `help:"storage type" choices:"nfs|object"`
+	StorageType string `help:"storage type" choices:"nfs|object|dedup"`
 
-	NfsHost      string `help:"nfs host, required when storage_type is nfs"`
-	NfsSharedDir string `help:"nfs shared dir, required when storage_type is nfs" `
+	NfsHost      string `help:"nfs host, required when storage_type is nfs or dedup"`
+	NfsSharedDir string `help:"nfs shared dir, required when storage_type is nfs or dedup" `
 
 	ObjectBucketUrl string `help:"object bucket url, required when storage_type is object"`
 	ObjectAccessKey string `help:"object storage access key, required when storage_type is object"`
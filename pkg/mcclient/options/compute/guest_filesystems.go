@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type GuestFilesystemCreateOptions struct {
+	NAME       string `help:"Name of the shared filesystem"`
+	SERVER     string `json:"guest_id" help:"Id or name of the kvm server"`
+	SourceType string `json:"source_type" choices:"host_path|storage" default:"storage" help:"Source of the shared directory"`
+	HostPath   string `json:"host_path" help:"Host directory to share, admin only"`
+	Storage    string `json:"storage_id" help:"Id or name of the NFS/GPFS storage to share"`
+	SubPath    string `json:"sub_path" help:"Sub directory of the storage, relative to project directory for non-admin users"`
+	MountTag   string `json:"mount_tag" help:"virtio-fs mount tag, default is the name"`
+	MountPoint string `json:"mount_point" help:"Auto mount the filesystem at this path inside linux guest"`
+	ReadOnly   bool   `json:"read_only" help:"Export the directory read-only"`
+}
+
+func (o *GuestFilesystemCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(o)
+}
+
+type GuestFilesystemListOptions struct {
+	options.BaseListOptions
+
+	Server  string `json:"guest_id" help:"Filter by server id or name"`
+	Storage string `json:"storage_id" help:"Filter by storage id or name"`
+}
+
+func (o *GuestFilesystemListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(o)
+}
+
+type GuestFilesystemIdOptions struct {
+	ID string `json:"-" help:"Id or name of the shared filesystem"`
+}
+
+func (o *GuestFilesystemIdOptions) GetId() string {
+	return o.ID
+}
+
+func (o *GuestFilesystemIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
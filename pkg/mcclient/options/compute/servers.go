@@ -422,9 +422,16 @@ type ServerCreateOptions struct {
 type ServerCreateOptionalOptions struct {
 	ServerConfigs
 
-	MemSpec        string `help:"Memory size Or Instance Type" metavar:"MEMSPEC" json:"-"`
-	CpuSockets     int    `help:"Cpu sockets"`
-	EnableMemclean bool   `help:"clean guest memory after guest exit" json:"enable_memclean"`
+	MemSpec         string `help:"Memory size Or Instance Type" metavar:"MEMSPEC" json:"-"`
+	CpuSockets      int    `help:"Cpu sockets"`
+	EnableMemclean  bool   `help:"clean guest memory after guest exit" json:"enable_memclean"`
+	EnableTpm       bool   `help:"enable vTPM 2.0 device" json:"enable_tpm"`
+	SecureBoot      bool   `help:"enable UEFI secure boot, requires UEFI bios" json:"secure_boot"`
+	EnableBalloon   bool   `help:"enable virtio-balloon with free page reporting" json:"enable_balloon"`
+	BalloonMinMem   int    `help:"minimum memory in MB guaranteed when host inflates balloon" json:"balloon_min_mem"`
+	EnableVirtioMem bool   `help:"provide memory by virtio-mem to support online memory resize" json:"enable_virtio_mem"`
+	Watchdog        string `help:"hardware watchdog model" choices:"i6300esb|ib700" json:"watchdog"`
+	CrashAction     string `help:"action on watchdog timeout or guest panic" choices:"reset|dump|poweroff|none" json:"crash_action"`
 
 	Keypair          string   `help:"SSH Keypair"`
 	Password         string   `help:"Default user password"`
@@ -562,6 +569,13 @@ func (opts *ServerCreateOptionalOptions) OptionalParams() (*computeapi.ServerCre
 		GuestImageID:       opts.GuestImageID,
 		Secgroups:          opts.Secgroups,
 		EnableMemclean:     opts.EnableMemclean,
+		EnableTpm:          opts.EnableTpm,
+		SecureBoot:         opts.SecureBoot,
+		EnableBalloon:      opts.EnableBalloon,
+		BalloonMinMem:      opts.BalloonMinMem,
+		EnableVirtioMem:    opts.EnableVirtioMem,
+		Watchdog:           opts.Watchdog,
+		CrashAction:        opts.CrashAction,
 	}
 
 	params.ProjectId = opts.Project
@@ -1475,6 +1489,26 @@ func (o *ServerVncOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(o), nil
 }
 
+type ServerHistoryOptions struct {
+	ServerIdOptions
+	At string `help:"time to query, e.g. 2024-01-02T15:04:05Z" required:"true"`
+}
+
+func (o *ServerHistoryOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(o), nil
+}
+
+type ServerFlowLogsOptions struct {
+	ServerIdOptions
+	Verdict string `help:"verdict of flows" choices:"allow|drop|reject"`
+	Since   string `help:"only flows after this time, e.g. 2024-01-02T15:04:05Z"`
+	Limit   int    `help:"max number of flows" default:"100"`
+}
+
+func (o *ServerFlowLogsOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(o), nil
+}
+
 type ServerIsoOptions struct {
 	ServerIdOptions
 	Ordinal int `help:"server iso ordinal, default 0"`
@@ -0,0 +1,145 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+// parseSilenceMatchers parses matchers like host=node01, zone!=zone1, tags.rack=~r0[1-3]
+func parseSilenceMatchers(strs []string) ([]monitor.AlertSilenceMatcher, error) {
+	ret := make([]monitor.AlertSilenceMatcher, 0, len(strs))
+	for _, str := range strs {
+		pos := strings.IndexAny(str, "=!")
+		if pos <= 0 || pos == len(str)-1 {
+			return nil, errors.Errorf("invalid matcher %q", str)
+		}
+		op := monitor.SILENCE_MATCH_EQUAL
+		switch {
+		case strings.HasPrefix(str[pos:], monitor.SILENCE_MATCH_NOT_EQUAL),
+			strings.HasPrefix(str[pos:], monitor.SILENCE_MATCH_REGEX),
+			strings.HasPrefix(str[pos:], monitor.SILENCE_MATCH_NOT_REGEX):
+			op = str[pos : pos+2]
+		case str[pos] != '=':
+			return nil, errors.Errorf("invalid matcher %q", str)
+		}
+		ret = append(ret, monitor.AlertSilenceMatcher{
+			Key:      strings.TrimSpace(str[:pos]),
+			Operator: op,
+			Value:    strings.TrimSpace(str[pos+len(op):]),
+		})
+	}
+	return ret, nil
+}
+
+type AlertSilenceListOptions struct {
+	options.BaseListOptions
+
+	Active    *bool `help:"only list silences in effect now" negative:"inactive"`
+	Recurring *bool `help:"only list silences with recurring maintenance windows" negative:"no-recurring"`
+}
+
+func (o *AlertSilenceListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(o)
+}
+
+type AlertSilenceShowOptions struct {
+	ID string `help:"ID or name of alert silence" json:"-"`
+}
+
+func (o *AlertSilenceShowOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(o)
+}
+
+func (o *AlertSilenceShowOptions) GetId() string {
+	return o.ID
+}
+
+type AlertSilenceCreateOptions struct {
+	apis.ScopedResourceCreateInput
+
+	NAME     string   `help:"name of alert silence"`
+	Matcher  []string `help:"label matcher, e.g. host=node01, zone!=zone1, tags.rack=~r0[1-3]" required:"true" json:"-"`
+	Start    string   `help:"start time of silence, default now" json:"start_time"`
+	End      string   `help:"end time of silence" json:"end_time"`
+	Schedule string   `help:"cron expression of recurring maintenance windows, e.g. '0 2 * * 6'" json:"schedule"`
+	Duration string   `help:"length of each maintenance window, e.g. 2h" json:"duration"`
+	Timezone string   `help:"time zone of schedule, e.g. Asia/Shanghai" json:"timezone"`
+	Desc     string   `help:"description" json:"description"`
+}
+
+func (o *AlertSilenceCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(o)
+	if err != nil {
+		return nil, err
+	}
+	matchers, err := parseSilenceMatchers(o.Matcher)
+	if err != nil {
+		return nil, err
+	}
+	params.Add(jsonutils.Marshal(matchers), "matchers")
+	return params, nil
+}
+
+type AlertSilenceUpdateOptions struct {
+	ID       string   `help:"ID or name of alert silence" json:"-"`
+	Name     string   `help:"new name of alert silence"`
+	Matcher  []string `help:"replace label matchers, e.g. host=node01" json:"-"`
+	Start    string   `help:"start time of silence" json:"start_time"`
+	End      string   `help:"end time of silence" json:"end_time"`
+	Schedule *string  `help:"cron expression of recurring maintenance windows, set empty to remove" json:"schedule"`
+	Duration string   `help:"length of each maintenance window, e.g. 2h" json:"duration"`
+	Timezone *string  `help:"time zone of schedule" json:"timezone"`
+	Desc     string   `help:"description" json:"description"`
+}
+
+func (o *AlertSilenceUpdateOptions) GetId() string {
+	return o.ID
+}
+
+func (o *AlertSilenceUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params, err := options.StructToParams(o)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.Matcher) > 0 {
+		matchers, err := parseSilenceMatchers(o.Matcher)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(jsonutils.Marshal(matchers), "matchers")
+	}
+	return params, nil
+}
+
+type AlertSilenceRecordListOptions struct {
+	options.BaseListOptions
+
+	Silence string `help:"ID or name of alert silence" json:"silence_id"`
+	AlertId string `help:"ID of alert" json:"alert_id"`
+	ResType string `help:"resource type" json:"res_type"`
+	ResId   string `help:"resource id" json:"res_id"`
+}
+
+func (o *AlertSilenceRecordListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(o)
+}
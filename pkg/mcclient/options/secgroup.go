@@ -94,6 +94,15 @@ func (opts *SecgroupIdOptions) Params() (jsonutils.JSONObject, error) {
 	return nil, nil
 }
 
+type SecgroupSetFlowLogOptions struct {
+	SecgroupIdOptions
+	Enable bool `help:"enable flow log, flow log is disabled if not set"`
+}
+
+func (opts *SecgroupSetFlowLogOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]bool{"enable": opts.Enable}), nil
+}
+
 type SecgroupMergeOptions struct {
 	SecgroupIdOptions
 	SECGROUPS []string `help:"source IDs or Names of secgroup"`
@@ -40,6 +40,7 @@ type AlertEngine struct {
 	evalHandler   evalHandler
 	ruleReader    ruleReader
 	resultHandler resultHandler
+	grouper       *notificationGrouper
 }
 
 func init() {
@@ -59,7 +60,8 @@ func (e *AlertEngine) Init() error {
 	e.Scheduler = newScheduler()
 	e.evalHandler = NewEvalHandler()
 	e.ruleReader = newRuleReader()
-	e.resultHandler = newResultHandler()
+	e.grouper = newNotificationGrouper()
+	e.resultHandler = newResultHandler(e.grouper)
 	return nil
 }
 
@@ -68,6 +70,7 @@ func (e *AlertEngine) Run(ctx context.Context) error {
 	alertGroup, ctx := errgroup.WithContext(ctx)
 	alertGroup.Go(func() error { return e.alertingTicker(ctx) })
 	alertGroup.Go(func() error { return e.runJobDispatcher(ctx) })
+	alertGroup.Go(func() error { return e.grouper.run(ctx) })
 
 	err := alertGroup.Wait()
 	return err
@@ -0,0 +1,435 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/sets"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/models"
+	"yunion.io/x/onecloud/pkg/monitor/options"
+)
+
+// groupableNotifierTypes are the message notifiers whose firing notifications
+// can be combined, action notifiers like auto scaling must see every alert.
+var groupableNotifierTypes = []string{
+	monitor.AlertNotificationTypeOneCloud,
+	monitor.AlertNotificationTypeDingding,
+	monitor.AlertNotificationTypeFeishu,
+}
+
+var alertLevelPriority = map[string]int{
+	"":          0,
+	"normal":    0,
+	"important": 1,
+	"fatal":     2,
+	"critical":  2,
+}
+
+// maxGroupNotifyRetries is how many times the entries of a failed group
+// notification are sent again before they are dropped
+const maxGroupNotifyRetries = 3
+
+// notifyGroupEntry is the latest firing evaluation of one alert rule in a group
+type notifyGroupEntry struct {
+	key     string
+	evalCtx *EvalContext
+	state   *notifierState
+	matches []*monitor.EvalMatch
+	retries int
+}
+
+// notifyGroup aggregates the firing alerts that share a notification
+// destination and the values of the group labels
+type notifyGroup struct {
+	key    string
+	labels map[string]string
+
+	entries   map[string]*notifyGroupEntry
+	nextFlush time.Time
+
+	lastSent         time.Time
+	lastFingerprints sets.String
+}
+
+func newNotifyGroup(key string, labels map[string]string) *notifyGroup {
+	return &notifyGroup{
+		key:     key,
+		labels:  labels,
+		entries: make(map[string]*notifyGroupEntry),
+	}
+}
+
+// notificationGrouper sits between the result handler and the notifiers: it
+// collects firing alerts by the labels of options.AlertingGroupBy during the
+// group wait window and sends one combined notification per group.
+type notificationGrouper struct {
+	lock   sync.Mutex
+	groups map[string]*notifyGroup
+}
+
+func newNotificationGrouper() *notificationGrouper {
+	return &notificationGrouper{
+		groups: make(map[string]*notifyGroup),
+	}
+}
+
+func isAlertGroupingEnabled() bool {
+	return len(options.Options.AlertingGroupBy) > 0
+}
+
+func getAlertGroupWait() time.Duration {
+	return time.Duration(options.Options.AlertingGroupWaitSeconds) * time.Second
+}
+
+func getAlertGroupInterval() time.Duration {
+	return time.Duration(options.Options.AlertingGroupIntervalSeconds) * time.Second
+}
+
+func getAlertRepeatInterval() time.Duration {
+	return time.Duration(options.Options.AlertingRepeatIntervalSeconds) * time.Second
+}
+
+// getGroupLabels picks the values of the group by labels out of the alert labels
+func getGroupLabels(labels map[string]string, groupBy []string) map[string]string {
+	ret := make(map[string]string, len(groupBy))
+	for _, k := range groupBy {
+		ret[k] = labels[k]
+	}
+	return ret
+}
+
+func formatGroupLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, labels[k]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func getAlertGroupKey(destination string, groupLabels map[string]string) string {
+	return destination + "{" + formatGroupLabels(groupLabels) + "}"
+}
+
+// getRuleRenderKey identifies how the matches of an alert rule are rendered:
+// a combined notification is rendered with the description of one rule, so
+// only rules on the same metric with the same condition are grouped together
+func getRuleRenderKey(rule *Rule) string {
+	desc := ""
+	if len(rule.RuleDescription) > 0 {
+		desc = jsonutils.Marshal(rule.RuleDescription[0].AlertRecordRule).String()
+	}
+	conf := ""
+	if rule.CustomizeConfig != nil {
+		conf = rule.CustomizeConfig.String()
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%s/%d/%d/%d/%s", desc, rule.Frequency, rule.For, rule.SilentPeriod, conf)))
+	return fmt.Sprintf("%x", sum)
+}
+
+// getAlertMatchFingerprint identifies a firing series of an alert rule, it is used
+// to tell whether a group has gained new alerts since its last notification
+func getAlertMatchFingerprint(alertId string, match *monitor.EvalMatch) string {
+	return fmt.Sprintf("%s/%s{%s}", alertId, match.Metric, formatGroupLabels(match.Tags))
+}
+
+// getNotifierDestination identifies where a notification is delivered to, alerts
+// of different owners are never combined because recipients may be resolved by scope
+func getNotifierDestination(noti *models.SNotification, alert *models.SCommonAlert) string {
+	settings := ""
+	if noti.Settings != nil {
+		settings = noti.Settings.String()
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", alert.DomainId, alert.ProjectId, noti.Type, settings)
+}
+
+// add puts the firing matches of evalCtx into their groups and reports whether
+// the notification has been taken over by the grouper
+func (g *notificationGrouper) add(evalCtx *EvalContext, state *notifierState) bool {
+	if !isAlertGroupingEnabled() || evalCtx.IsTestRun || !evalCtx.Firing {
+		return false
+	}
+	if len(state.destination) == 0 || !utils.IsInStringArray(state.notifier.GetType(), groupableNotifierTypes) {
+		return false
+	}
+	groupBy := options.Options.AlertingGroupBy
+	destination := state.destination + "/" + getRuleRenderKey(evalCtx.Rule)
+	groupMatches := make(map[string][]*monitor.EvalMatch)
+	groupLabels := make(map[string]map[string]string)
+	for _, match := range evalCtx.EvalMatches {
+		if _, ok := match.Tags[monitor.ALERT_RESOURCE_RECORD_SHIELD_KEY]; ok {
+			continue
+		}
+		labels := getGroupLabels(newAlertLabels(evalCtx, match, nil), groupBy)
+		key := getAlertGroupKey(destination, labels)
+		groupMatches[key] = append(groupMatches[key], match)
+		groupLabels[key] = labels
+	}
+	if len(groupMatches) == 0 {
+		return false
+	}
+
+	now := time.Now()
+	entryKey := evalCtx.Rule.Id + "/" + state.notifier.GetNotifierId()
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for key, matches := range groupMatches {
+		group, ok := g.groups[key]
+		if !ok {
+			group = newNotifyGroup(key, groupLabels[key])
+			g.groups[key] = group
+		}
+		if len(group.entries) == 0 {
+			if group.lastSent.IsZero() {
+				group.nextFlush = now.Add(getAlertGroupWait())
+			} else {
+				group.nextFlush = group.lastSent.Add(getAlertGroupInterval())
+			}
+		}
+		// a rule evaluated again within the window replaces its previous matches
+		group.entries[entryKey] = &notifyGroupEntry{
+			key:     entryKey,
+			evalCtx: evalCtx,
+			state:   state,
+			matches: matches,
+		}
+	}
+	return true
+}
+
+// resolve drops the pending matches of a recovered alert rule, so they are not
+// reported as firing after the recovery notification has been sent, and forgets
+// its notified matches, so the rule firing again is notified as a new alert
+func (g *notificationGrouper) resolve(evalCtx *EvalContext) {
+	if evalCtx.IsTestRun || evalCtx.Firing {
+		return
+	}
+	prefix := evalCtx.Rule.Id + "/"
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, group := range g.groups {
+		for key, entry := range group.entries {
+			if entry.evalCtx.Rule.Id == evalCtx.Rule.Id {
+				delete(group.entries, key)
+			}
+		}
+		for _, fp := range group.lastFingerprints.UnsortedList() {
+			if strings.HasPrefix(fp, prefix) {
+				group.lastFingerprints.Delete(fp)
+			}
+		}
+	}
+}
+
+// requeue puts the entries of a failed group notification back to the group
+// to be sent again after the group interval, unless the rule has been
+// evaluated again in the meantime or the entry has run out of retries
+func (g *notificationGrouper) requeue(now time.Time, group *notifyGroup, entries []*notifyGroupEntry) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	requeued := false
+	for _, entry := range entries {
+		if _, ok := group.entries[entry.key]; ok {
+			continue
+		}
+		entry.retries++
+		if entry.retries > maxGroupNotifyRetries {
+			log.Errorf("alert %s notification of group %s failed %d times, drop it", entry.evalCtx.Rule.Id, group.key, entry.retries)
+			continue
+		}
+		group.entries[entry.key] = entry
+		requeued = true
+	}
+	if requeued {
+		group.nextFlush = now.Add(getAlertGroupInterval())
+	}
+}
+
+func (g *notificationGrouper) run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			g.flush(now)
+		}
+	}
+}
+
+// flush sends the groups whose wait window has passed and forgets the groups
+// that have been quiet for longer than the repeat interval
+func (g *notificationGrouper) flush(now time.Time) {
+	type flushItem struct {
+		group   *notifyGroup
+		entries []*notifyGroupEntry
+	}
+	items := make([]flushItem, 0)
+	g.lock.Lock()
+	for key, group := range g.groups {
+		if len(group.entries) == 0 {
+			if now.Sub(group.lastSent) > getAlertRepeatInterval() {
+				delete(g.groups, key)
+			}
+			continue
+		}
+		if now.Before(group.nextFlush) {
+			continue
+		}
+		entries := make([]*notifyGroupEntry, 0, len(group.entries))
+		for _, entry := range group.entries {
+			entries = append(entries, entry)
+		}
+		group.entries = make(map[string]*notifyGroupEntry)
+		items = append(items, flushItem{group: group, entries: entries})
+	}
+	g.lock.Unlock()
+
+	for _, item := range items {
+		if err := g.sendGroup(now, item.group, item.entries); err != nil {
+			log.Errorf("send alert group %s notification: %v", item.group.key, err)
+			g.requeue(now, item.group, item.entries)
+		}
+	}
+}
+
+func getGroupFingerprints(entries []*notifyGroupEntry) sets.String {
+	ret := sets.NewString()
+	for _, entry := range entries {
+		for _, match := range entry.matches {
+			ret.Insert(getAlertMatchFingerprint(entry.evalCtx.Rule.Id, match))
+		}
+	}
+	return ret
+}
+
+// shouldSend reports whether a group has to be notified: it holds alerts that
+// have not been sent yet, or the repeat interval has passed since the last send
+func (group *notifyGroup) shouldSend(now time.Time, fingerprints sets.String) bool {
+	if group.lastSent.IsZero() || group.lastFingerprints == nil {
+		return true
+	}
+	if !group.lastFingerprints.HasAll(fingerprints.UnsortedList()...) {
+		return true
+	}
+	return now.Sub(group.lastSent) >= getAlertRepeatInterval()
+}
+
+func (g *notificationGrouper) sendGroup(now time.Time, group *notifyGroup, entries []*notifyGroupEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].evalCtx.StartTime.Before(entries[j].evalCtx.StartTime)
+	})
+	fingerprints := getGroupFingerprints(entries)
+	g.lock.Lock()
+	shouldSend := group.shouldSend(now, fingerprints)
+	lastSent := group.lastSent
+	g.lock.Unlock()
+	if !shouldSend {
+		log.Debugf("alert group %s has been notified at %s, skip duplicated notification", group.key, lastSent)
+		// the alerts are covered by the last group notification
+		markGroupEntriesCompleted(entries)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(options.Options.AlertingNotificationTimeoutSeconds)*time.Second)
+	defer cancel()
+	evalCtx := newGroupEvalContext(ctx, group, entries)
+	for _, entry := range entries {
+		if err := entry.state.state.SetToPending(); err != nil {
+			log.Errorf("SetToPending of alert %s notification: %v", entry.evalCtx.Rule.Id, err)
+		}
+	}
+	notifier := entries[len(entries)-1].state.notifier
+	log.Debugf("Sending group notification, type %s, id %s, group %s, alerts %d", notifier.GetType(), notifier.GetNotifierId(), group.key, len(entries))
+	if err := notifier.Notify(evalCtx, entries[len(entries)-1].state.state.GetParams()); err != nil {
+		return errors.Wrapf(err, "notify driver %s(%s)", notifier.GetType(), notifier.GetNotifierId())
+	}
+	g.lock.Lock()
+	group.lastSent = now
+	group.lastFingerprints = fingerprints
+	g.lock.Unlock()
+	markGroupEntriesCompleted(entries)
+	return nil
+}
+
+func markGroupEntriesCompleted(entries []*notifyGroupEntry) {
+	for _, entry := range entries {
+		if err := entry.state.state.UpdateSendTime(); err != nil {
+			log.Errorf("UpdateSendTime of alert %s notification: %v", entry.evalCtx.Rule.Id, err)
+			continue
+		}
+		if err := entry.state.state.SetToCompleted(); err != nil {
+			log.Errorf("SetToCompleted of alert %s notification: %v", entry.evalCtx.Rule.Id, err)
+		}
+	}
+}
+
+// newGroupEvalContext builds the eval context of a combined notification, so
+// every notifier renders a group the same way as a single alert. The entries
+// share the same rule render key, so the rule of the last one describes all.
+func newGroupEvalContext(ctx context.Context, group *notifyGroup, entries []*notifyGroupEntry) *EvalContext {
+	base := entries[len(entries)-1].evalCtx
+	evalCtx := *base
+	rule := *base.Rule
+	evalCtx.Rule = &rule
+	evalCtx.Ctx = ctx
+	evalCtx.Firing = true
+	evalCtx.NoDataFound = false
+	evalCtx.Error = nil
+	evalCtx.AlertOkEvalMatches = nil
+	evalCtx.EvalMatches = make([]*monitor.EvalMatch, 0)
+	evalCtx.StartTime = entries[0].evalCtx.StartTime
+
+	names := make([]string, 0)
+	messages := make([]string, 0)
+	for _, entry := range entries {
+		evalCtx.EvalMatches = append(evalCtx.EvalMatches, entry.matches...)
+		if entry.evalCtx.EndTime.After(evalCtx.EndTime) {
+			evalCtx.EndTime = entry.evalCtx.EndTime
+		}
+		if alertLevelPriority[entry.evalCtx.Rule.Level] > alertLevelPriority[rule.Level] {
+			rule.Level = entry.evalCtx.Rule.Level
+		}
+		if title := entry.evalCtx.GetRuleTitle(); !utils.IsInStringArray(title, names) {
+			names = append(names, title)
+		}
+		if msg := entry.evalCtx.Rule.Message; len(msg) > 0 && !utils.IsInStringArray(msg, messages) {
+			messages = append(messages, msg)
+		}
+	}
+	if len(names) > 1 {
+		rule.Title = fmt.Sprintf("[%s] %s", formatGroupLabels(group.labels), strings.Join(names, ","))
+	}
+	rule.Message = strings.Join(messages, "\n")
+	return &evalCtx
+}
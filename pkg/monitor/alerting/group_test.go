@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"yunion.io/x/pkg/util/sets"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/options"
+)
+
+func TestGetAlertGroupKey(t *testing.T) {
+	labels := map[string]string{
+		"host":       "host1",
+		"zone":       "zone1",
+		"alert_name": "cpu",
+	}
+	groupLabels := getGroupLabels(labels, []string{"zone", "host", "rack"})
+	assert.Equal(t, map[string]string{"host": "host1", "zone": "zone1", "rack": ""}, groupLabels)
+	assert.Equal(t, "dest{host=host1,rack=,zone=zone1}", getAlertGroupKey("dest", groupLabels))
+}
+
+func TestNotifyGroupShouldSend(t *testing.T) {
+	defer func(v int64) { options.Options.AlertingRepeatIntervalSeconds = v }(options.Options.AlertingRepeatIntervalSeconds)
+	options.Options.AlertingRepeatIntervalSeconds = 3600
+	now := time.Now()
+	group := newNotifyGroup("key", nil)
+	assert.True(t, group.shouldSend(now, sets.NewString("a")), "group never sent")
+
+	group.lastSent = now.Add(-time.Minute)
+	group.lastFingerprints = sets.NewString("a", "b")
+	assert.False(t, group.shouldSend(now, sets.NewString("a")), "no new alerts within repeat interval")
+	assert.True(t, group.shouldSend(now, sets.NewString("a", "c")), "new alert in group")
+	assert.True(t, group.shouldSend(now.Add(time.Hour), sets.NewString("a")), "repeat interval passed")
+}
+
+func TestNewGroupEvalContext(t *testing.T) {
+	newEntry := func(id, name, level string, start time.Time, host string) *notifyGroupEntry {
+		evalCtx := NewEvalContext(context.TODO(), nil, &Rule{Id: id, Name: name, Level: level})
+		evalCtx.StartTime = start
+		evalCtx.Firing = true
+		return &notifyGroupEntry{
+			evalCtx: evalCtx,
+			matches: []*monitor.EvalMatch{{Metric: "m", Tags: map[string]string{"host": host}}},
+		}
+	}
+	now := time.Now()
+	group := newNotifyGroup("key", map[string]string{"zone": "zone1"})
+
+	single := newGroupEvalContext(context.TODO(), group, []*notifyGroupEntry{
+		newEntry("a1", "cpu", "normal", now, "host1"),
+	})
+	assert.Equal(t, "cpu", single.GetRuleTitle())
+
+	entries := []*notifyGroupEntry{
+		newEntry("a1", "cpu", "normal", now, "host1"),
+		newEntry("a2", "mem", "fatal", now.Add(time.Second), "host2"),
+		newEntry("a3", "disk", "important", now.Add(2*time.Second), "host2"),
+	}
+	evalCtx := newGroupEvalContext(context.TODO(), group, entries)
+	assert.Equal(t, "[zone=zone1] cpu,mem,disk", evalCtx.GetRuleTitle())
+	assert.Equal(t, "fatal", evalCtx.Rule.Level)
+	assert.Equal(t, now, evalCtx.StartTime)
+	assert.Equal(t, 3, len(evalCtx.EvalMatches))
+	// the rules of the grouped alerts are left untouched
+	assert.Equal(t, "disk", entries[2].evalCtx.GetRuleTitle())
+	assert.Equal(t, "important", entries[2].evalCtx.Rule.Level)
+}
+
+func TestGetRuleRenderKey(t *testing.T) {
+	newRule := func(id, field, threshold string) *Rule {
+		return &Rule{
+			Id:        id,
+			Frequency: 60,
+			RuleDescription: []*RuleDescription{
+				{AlertRecordRule: monitor.AlertRecordRule{Measurement: "cpu", Field: field, Comparator: ">", Threshold: threshold}},
+			},
+		}
+	}
+	assert.Equal(t, getRuleRenderKey(newRule("a1", "usage_active", "80")), getRuleRenderKey(newRule("a2", "usage_active", "80")))
+	assert.NotEqual(t, getRuleRenderKey(newRule("a1", "usage_active", "80")), getRuleRenderKey(newRule("a2", "usage_active", "90")))
+	assert.NotEqual(t, getRuleRenderKey(newRule("a1", "usage_active", "80")), getRuleRenderKey(newRule("a2", "usage_idle", "80")))
+}
+
+func TestNotificationGrouperResolve(t *testing.T) {
+	g := newNotificationGrouper()
+	group := newNotifyGroup("key", nil)
+	group.lastSent = time.Now()
+	group.lastFingerprints = sets.NewString("a1/m{host=host1}", "a2/m{host=host1}")
+	g.groups[group.key] = group
+
+	evalCtx := NewEvalContext(context.TODO(), nil, &Rule{Id: "a1"})
+	g.resolve(evalCtx)
+	assert.Equal(t, []string{"a2/m{host=host1}"}, group.lastFingerprints.List())
+	assert.True(t, group.shouldSend(time.Now(), sets.NewString("a1/m{host=host1}")), "resolved alert fires again")
+}
+
+func TestNotificationGrouperRequeue(t *testing.T) {
+	defer func(v int64) { options.Options.AlertingGroupIntervalSeconds = v }(options.Options.AlertingGroupIntervalSeconds)
+	options.Options.AlertingGroupIntervalSeconds = 300
+
+	newEntry := func(key string, retries int) *notifyGroupEntry {
+		return &notifyGroupEntry{
+			key:     key,
+			evalCtx: NewEvalContext(context.TODO(), nil, &Rule{Id: key}),
+			retries: retries,
+		}
+	}
+	now := time.Now()
+	g := newNotificationGrouper()
+	group := newNotifyGroup("key", nil)
+	g.groups[group.key] = group
+	// a2 is evaluated again while the notification is being sent
+	newer := newEntry("a2", 0)
+	group.entries["a2"] = newer
+
+	g.requeue(now, group, []*notifyGroupEntry{
+		newEntry("a1", 0),
+		newEntry("a2", 0),
+		newEntry("a3", maxGroupNotifyRetries),
+	})
+	assert.Equal(t, 2, len(group.entries))
+	assert.Equal(t, 1, group.entries["a1"].retries)
+	assert.Equal(t, newer, group.entries["a2"])
+	assert.Equal(t, now.Add(300*time.Second), group.nextFlush)
+}
@@ -32,13 +32,19 @@ import (
 )
 
 type notificationService struct {
+	grouper *notificationGrouper
 }
 
-func newNotificationService() *notificationService {
-	return &notificationService{}
+func newNotificationService(grouper *notificationGrouper) *notificationService {
+	return &notificationService{
+		grouper: grouper,
+	}
 }
 
 func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
+	if n.grouper != nil {
+		n.grouper.resolve(evalCtx)
+	}
 	notifierStates, err := n.getNeededNotifiers(evalCtx.Rule.Notifications, evalCtx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get alert notifiers")
@@ -54,6 +60,8 @@ func (n *notificationService) SendIfNeeded(evalCtx *EvalContext) error {
 type notifierState struct {
 	notifier Notifier
 	state    *models.SAlertnotification
+	// destination is set when the notification may be combined by the grouper
+	destination string
 }
 
 type notifierStateSlice []*notifierState
@@ -88,6 +96,9 @@ func (n *notificationService) sendAndMarkAsComplete(evalCtx *EvalContext, state
 
 func (n *notificationService) sendNotifications(evalCtx *EvalContext, states notifierStateSlice) error {
 	for _, state := range states {
+		if n.grouper != nil && n.grouper.add(evalCtx, state) {
+			continue
+		}
 		if err := n.sendNotification(evalCtx, state); err != nil {
 			log.Errorf("failed to send %s(%s) notification: %v", state.notifier.GetType(), state.notifier.GetNotifierId(), err)
 			if evalCtx.IsTestRun {
@@ -104,8 +115,19 @@ func (n *notificationService) getNeededNotifiers(nIds []string, evalCtx *EvalCon
 		return nil, errors.Wrapf(err, "GetNotificationsWithDefault with %v", nIds)
 	}
 
+	silenced, allSilenced := n.applySilences(evalCtx)
+
+	var groupAlert *models.SCommonAlert
+	if n.grouper != nil && isAlertGroupingEnabled() && evalCtx.Firing && !evalCtx.IsTestRun {
+		groupAlert, err = models.CommonAlertManager.GetAlert(evalCtx.Rule.Id)
+		if err != nil {
+			log.Errorf("GetAlert %s for notification group: %v", evalCtx.Rule.Id, err)
+		}
+	}
+
 	var result notifierStateSlice
 	shouldNotify := false
+	suppressed := false
 	for _, obj := range notis {
 		not, err := InitNotifier(NotificationConfig{
 			Ctx:                   evalCtx.Ctx,
@@ -136,11 +158,19 @@ func (n *notificationService) getNeededNotifiers(nIds []string, evalCtx *EvalCon
 		}
 
 		if not.ShouldNotify(evalCtx.Ctx, evalCtx, state) {
+			if allSilenced {
+				suppressed = true
+				continue
+			}
 			shouldNotify = true
-			result = append(result, &notifierState{
+			ns := &notifierState{
 				notifier: not,
 				state:    state,
-			})
+			}
+			if groupAlert != nil {
+				ns.destination = getNotifierDestination(&obj, groupAlert)
+			}
+			result = append(result, ns)
 		}
 	}
 	if shouldNotify || evalCtx.Rule.State == monitor.AlertStateAlerting {
@@ -150,6 +180,9 @@ func (n *notificationService) getNeededNotifiers(nIds []string, evalCtx *EvalCon
 			}
 		}()
 	}
+	if (shouldNotify || suppressed) && len(silenced) > 0 {
+		go n.recordSilencedMatches(evalCtx, silenced)
+	}
 	if !shouldNotify && evalCtx.shouldUpdateAlertState() && evalCtx.NoDataFound {
 		go func() {
 			n.detachAlertResourceWhenNodata(evalCtx)
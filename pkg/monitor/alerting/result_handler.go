@@ -32,9 +32,9 @@ type defaultResultHandler struct {
 	notifier *notificationService
 }
 
-func newResultHandler() *defaultResultHandler {
+func newResultHandler(grouper *notificationGrouper) *defaultResultHandler {
 	return &defaultResultHandler{
-		notifier: newNotificationService(),
+		notifier: newNotificationService(grouper),
 	}
 }
 
@@ -0,0 +1,182 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/models"
+)
+
+// silencedMatch is an eval match whose notification is suppressed by an alert silence
+type silencedMatch struct {
+	silence *models.SAlertSilence
+	match   *monitor.EvalMatch
+	labels  map[string]string
+}
+
+// applySilences marks the eval matches suppressed by the active alert silences
+// as shielded, so they are left out of notifications, and reports whether
+// every match of the evaluation has been silenced.
+func (n *notificationService) applySilences(evalCtx *EvalContext) ([]silencedMatch, bool) {
+	if evalCtx.IsTestRun {
+		return nil, false
+	}
+	matches := evalCtx.EvalMatches
+	if !evalCtx.Firing {
+		matches = evalCtx.AlertOkEvalMatches
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	silences, err := models.AlertSilenceManager.GetActiveSilences(time.Now())
+	if err != nil {
+		log.Errorf("GetActiveSilences error: %v", err)
+		return nil, false
+	}
+	if len(silences) == 0 {
+		return nil, false
+	}
+	var projectId, domainId string
+	if alert, err := models.CommonAlertManager.GetAlert(evalCtx.Rule.Id); err == nil {
+		projectId, domainId = alert.ProjectId, alert.DomainId
+	}
+
+	resTags := make(map[string]map[string]string)
+	ret := make([]silencedMatch, 0)
+	shielded := 0
+	for _, match := range matches {
+		if _, ok := match.Tags[monitor.ALERT_RESOURCE_RECORD_SHIELD_KEY]; ok {
+			shielded++
+			continue
+		}
+		labels := newAlertLabels(evalCtx, match, resTags)
+		matchProjectId := projectId
+		if len(labels[monitor.SILENCE_LABEL_PROJECT_ID]) > 0 {
+			matchProjectId = labels[monitor.SILENCE_LABEL_PROJECT_ID]
+		}
+		for i := range silences {
+			if !silences[i].Match(labels, matchProjectId, domainId) {
+				continue
+			}
+			if match.Tags == nil {
+				match.Tags = make(map[string]string)
+			}
+			match.Tags[monitor.ALERT_RESOURCE_RECORD_SHIELD_KEY] = monitor.ALERT_RESOURCE_RECORD_SHIELD_VALUE
+			ret = append(ret, silencedMatch{
+				silence: &silences[i],
+				match:   match,
+				labels:  labels,
+			})
+			shielded++
+			break
+		}
+	}
+	return ret, len(ret) > 0 && shielded == len(matches)
+}
+
+func getEvalResType(evalCtx *EvalContext) string {
+	resType := monitor.METRIC_RES_TYPE_HOST
+	if len(evalCtx.Rule.RuleDescription) > 0 && len(evalCtx.Rule.RuleDescription[0].ResType) > 0 {
+		resType = evalCtx.Rule.RuleDescription[0].ResType
+	}
+	return resType
+}
+
+// newAlertLabels collects the labels silence matchers and notification groups
+// are evaluated against: the tags of the match, the attributes of the alert
+// and, unless resTags is nil, the tags of the monitored resource prefixed with tags.
+func newAlertLabels(evalCtx *EvalContext, match *monitor.EvalMatch, resTags map[string]map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range match.Tags {
+		labels[k] = v
+	}
+	resType := getEvalResType(evalCtx)
+	labels[monitor.SILENCE_LABEL_ALERT_ID] = evalCtx.Rule.Id
+	labels[monitor.SILENCE_LABEL_ALERT_NAME] = evalCtx.Rule.Name
+	labels[monitor.SILENCE_LABEL_LEVEL] = evalCtx.Rule.Level
+	labels[monitor.SILENCE_LABEL_RES_TYPE] = resType
+	labels[monitor.SILENCE_LABEL_METRIC] = match.Metric
+	if _, ok := labels[monitor.SILENCE_LABEL_PROJECT]; !ok {
+		labels[monitor.SILENCE_LABEL_PROJECT] = match.Tags["tenant"]
+	}
+	if _, ok := labels[monitor.SILENCE_LABEL_PROJECT_ID]; !ok {
+		labels[monitor.SILENCE_LABEL_PROJECT_ID] = match.Tags["tenant_id"]
+	}
+
+	resId := match.Tags[monitor.MEASUREMENT_TAG_ID[resType]]
+	if len(resId) == 0 || resTags == nil {
+		return labels
+	}
+	tags, ok := resTags[resId]
+	if !ok {
+		tags = getMonitorResourceTags(resId)
+		resTags[resId] = tags
+	}
+	for k, v := range tags {
+		labels[monitor.SILENCE_LABEL_TAG_PREFIX+k] = v
+	}
+	return labels
+}
+
+func getMonitorResourceTags(resId string) map[string]string {
+	resources, err := models.MonitorResourceManager.GetMonitorResources(monitor.MonitorResourceListInput{ResId: []string{resId}})
+	if err != nil {
+		log.Errorf("GetMonitorResources by res_id %s: %v", resId, err)
+		return nil
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	tags, err := resources[0].GetAllUserMetadata()
+	if err != nil {
+		log.Errorf("GetAllUserMetadata of monitor resource %s: %v", resId, err)
+		return nil
+	}
+	return tags
+}
+
+// recordSilencedMatches saves what every silence suppressed and bumps its counters
+func (n *notificationService) recordSilencedMatches(evalCtx *EvalContext, silenced []silencedMatch) {
+	now := time.Now().UTC()
+	counts := make(map[string]int)
+	silences := make(map[string]*models.SAlertSilence)
+	for _, s := range silenced {
+		record := &models.SAlertSilenceRecord{
+			AlertId:   evalCtx.Rule.Id,
+			AlertName: evalCtx.Rule.Name,
+			Level:     evalCtx.Rule.Level,
+			State:     string(evalCtx.Rule.State),
+			ResType:   s.labels[monitor.SILENCE_LABEL_RES_TYPE],
+			ResId:     s.match.Tags[monitor.MEASUREMENT_TAG_ID[s.labels[monitor.SILENCE_LABEL_RES_TYPE]]],
+			Metric:    s.match.Metric,
+			Labels:    jsonutils.Marshal(s.labels),
+		}
+		if err := models.AlertSilenceRecordManager.CreateRecord(evalCtx.Ctx, s.silence, record); err != nil {
+			log.Errorf("create silence record of alert %s: %v", evalCtx.Rule.Id, err)
+		}
+		counts[s.silence.Id]++
+		silences[s.silence.Id] = s.silence
+	}
+	for id, count := range counts {
+		if err := silences[id].IncSuppressed(count, now); err != nil {
+			log.Errorf("update suppressed count of silence %s: %v", id, err)
+		}
+	}
+}
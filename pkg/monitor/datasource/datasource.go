@@ -140,16 +140,25 @@ func (man *dataSourceManager) setDataSource(dsType string, url string) {
 func (man *dataSourceManager) initDefaultDataSource(ctx context.Context) error {
 	region := options.Options.Region
 	epType := options.Options.SessionEndpointType
+	dsSvc := options.Options.MonitorDataSource
+	if len(dsSvc) > 0 && len(options.Options.MonitorDataSourceUrl) > 0 {
+		if err := tsdb.IsValidDataSource(dsSvc); err != nil {
+			return errors.Wrapf(err, "invalid type %q", dsSvc)
+		}
+		man.setDataSource(dsSvc, options.Options.MonitorDataSourceUrl)
+		return nil
+	}
 	s := auth.GetAdminSession(ctx, region)
-	//dsSvc := options.Options.MonitorDataSource
 	if s == nil {
 		return errors.Errorf("get empty public session for region %s", region)
 	}
-	source, err := commontsdb.GetDefaultServiceSource(s, epType)
-	if err != nil {
-		return errors.Wrap(err, "get default TSDB source")
+	if len(dsSvc) == 0 {
+		source, err := commontsdb.GetDefaultServiceSource(s, epType)
+		if err != nil {
+			return errors.Wrap(err, "get default TSDB source")
+		}
+		dsSvc = source.Type
 	}
-	dsSvc := source.Type
 	if err := tsdb.IsValidDataSource(dsSvc); err != nil {
 		return errors.Wrapf(err, "invalid type %q", dsSvc)
 	}
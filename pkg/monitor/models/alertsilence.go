@@ -0,0 +1,487 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/pkg/util/timeutils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+var (
+	AlertSilenceManager *SAlertSilenceManager
+)
+
+type SAlertSilenceManager struct {
+	db.SEnabledResourceBaseManager
+	db.SStatusStandaloneResourceBaseManager
+	SMonitorScopedResourceManager
+}
+
+func init() {
+	AlertSilenceManager = &SAlertSilenceManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SAlertSilence{},
+			"alertsilence_tbl",
+			"alertsilence",
+			"alertsilences",
+		),
+	}
+
+	AlertSilenceManager.SetVirtualObject(AlertSilenceManager)
+}
+
+// SAlertSilence suppresses the notifications of every alert whose labels
+// satisfy all of the matchers, either between StartTime and EndTime or,
+// when Schedule is set, only inside the recurring maintenance windows.
+type SAlertSilence struct {
+	db.SEnabledResourceBase
+	db.SStatusStandaloneResourceBase
+	SMonitorScopedResource
+
+	Matchers jsonutils.JSONObject `length:"medium" nullable:"false" list:"user" create:"required" update:"user" json:"matchers"`
+
+	StartTime time.Time `list:"user" create:"optional" update:"user" json:"start_time"`
+	EndTime   time.Time `list:"user" create:"optional" update:"user" json:"end_time"`
+
+	// Schedule is a cron expression marking the start of each maintenance window
+	Schedule string `width:"128" charset:"ascii" list:"user" create:"optional" update:"user" json:"schedule"`
+	// Duration is the length of each maintenance window, e.g. 2h
+	Duration string `width:"16" charset:"ascii" list:"user" create:"optional" update:"user" json:"duration"`
+	Timezone string `width:"64" charset:"ascii" list:"user" create:"optional" update:"user" json:"timezone"`
+
+	SuppressedCount  int       `default:"0" list:"user" json:"suppressed_count"`
+	LastSuppressedAt time.Time `list:"user" json:"last_suppressed_at"`
+}
+
+func (manager *SAlertSilenceManager) ListItemExportKeys(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, keys stringutils2.SSortedStrings) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemExportKeys")
+	}
+	q, err = manager.SScopedResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SScopedResourceBaseManager.ListItemExportKeys")
+	}
+	return q, nil
+}
+
+func (manager *SAlertSilenceManager) ListItemFilter(
+	ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query monitor.AlertSilenceListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SScopedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ScopedResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SScopedResourceBaseManager.ListItemFilter")
+	}
+
+	if query.Recurring != nil {
+		if *query.Recurring {
+			q = q.IsNotEmpty("schedule")
+		} else {
+			q = q.IsNullOrEmpty("schedule")
+		}
+	}
+	if query.Active != nil {
+		silences := make([]SAlertSilence, 0)
+		err := db.FetchModelObjects(manager, q.Copy(), &silences)
+		if err != nil {
+			return nil, errors.Wrap(err, "FetchModelObjects")
+		}
+		now := time.Now()
+		ids := make([]string, 0)
+		for i := range silences {
+			if silences[i].IsActiveAt(now) == *query.Active {
+				ids = append(ids, silences[i].Id)
+			}
+		}
+		q = q.In("id", ids)
+	}
+	return q, nil
+}
+
+func (manager *SAlertSilenceManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input monitor.AlertSilenceListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SScopedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.ScopedResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SScopedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SAlertSilenceManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []monitor.AlertSilenceDetails {
+	rows := make([]monitor.AlertSilenceDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	scopedRows := manager.SScopedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	now := time.Now()
+	for i := range rows {
+		rows[i] = monitor.AlertSilenceDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+			ScopedResourceBaseInfo:          scopedRows[i],
+		}
+		silence := objs[i].(*SAlertSilence)
+		rows[i].Active = silence.IsActiveAt(now)
+		rows[i].Expired = !silence.EndTime.IsZero() && silence.EndTime.Before(now)
+		if !rows[i].Expired && len(silence.Schedule) > 0 {
+			rows[i].NextWindowStart = silence.nextWindowStart(now)
+		}
+	}
+	return rows
+}
+
+func (manager *SAlertSilenceManager) ValidateCreateData(
+	ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject,
+	data monitor.AlertSilenceCreateInput,
+) (monitor.AlertSilenceCreateInput, error) {
+	var err error
+	if len(data.Matchers) == 0 {
+		return data, httperrors.NewMissingParameterError("matchers")
+	}
+	if data.Matchers, err = validateSilenceMatchers(data.Matchers); err != nil {
+		return data, err
+	}
+	if len(data.StartTime) == 0 {
+		data.StartTime = timeutils.IsoTime(time.Now().UTC())
+	}
+	startTime, err := timeutils.ParseTimeStr(data.StartTime)
+	if err != nil {
+		return data, httperrors.NewInputParameterError("parse start_time: %s err", data.StartTime)
+	}
+	data.StartTime = timeutils.IsoTime(startTime)
+	if len(data.EndTime) > 0 {
+		endTime, err := timeutils.ParseTimeStr(data.EndTime)
+		if err != nil {
+			return data, httperrors.NewInputParameterError("parse end_time: %s err", data.EndTime)
+		}
+		if !endTime.After(startTime) {
+			return data, httperrors.NewInputParameterError("end_time is before start_time")
+		}
+		data.EndTime = timeutils.IsoTime(endTime)
+	} else if len(data.Schedule) == 0 {
+		return data, httperrors.NewMissingParameterError("end_time")
+	}
+	if err := validateSilenceSchedule(data.Schedule, data.Duration, data.Timezone); err != nil {
+		return data, err
+	}
+	if data.Enabled == nil && data.Disabled == nil {
+		data.SetEnabled()
+	}
+	data.StandaloneResourceCreateInput, err = manager.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, data.StandaloneResourceCreateInput)
+	if err != nil {
+		return data, errors.Wrap(err, "SStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	return data, nil
+}
+
+func (silence *SAlertSilence) ValidateUpdateData(
+	ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input monitor.AlertSilenceUpdateInput,
+) (monitor.AlertSilenceUpdateInput, error) {
+	var err error
+	if input.Matchers != nil {
+		if len(input.Matchers) == 0 {
+			return input, httperrors.NewInputParameterError("matchers is empty")
+		}
+		if input.Matchers, err = validateSilenceMatchers(input.Matchers); err != nil {
+			return input, err
+		}
+	}
+	startTime, endTime := silence.StartTime, silence.EndTime
+	if len(input.StartTime) > 0 {
+		if startTime, err = timeutils.ParseTimeStr(input.StartTime); err != nil {
+			return input, httperrors.NewInputParameterError("parse start_time: %s err", input.StartTime)
+		}
+		input.StartTime = timeutils.IsoTime(startTime)
+	}
+	if len(input.EndTime) > 0 {
+		if endTime, err = timeutils.ParseTimeStr(input.EndTime); err != nil {
+			return input, httperrors.NewInputParameterError("parse end_time: %s err", input.EndTime)
+		}
+		input.EndTime = timeutils.IsoTime(endTime)
+	}
+	if !endTime.IsZero() && !endTime.After(startTime) {
+		return input, httperrors.NewInputParameterError("end_time is before start_time")
+	}
+	schedule, duration, timezone := silence.Schedule, silence.Duration, silence.Timezone
+	if input.Schedule != nil {
+		schedule = *input.Schedule
+	}
+	if len(input.Duration) > 0 {
+		duration = input.Duration
+	}
+	if input.Timezone != nil {
+		timezone = *input.Timezone
+	}
+	if len(schedule) == 0 && endTime.IsZero() {
+		return input, httperrors.NewMissingParameterError("end_time")
+	}
+	if err := validateSilenceSchedule(schedule, duration, timezone); err != nil {
+		return input, err
+	}
+	input.StatusStandaloneResourceBaseUpdateInput, err = silence.SStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (silence *SAlertSilence) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(silence, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (silence *SAlertSilence) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(silence, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func validateSilenceMatchers(matchers []monitor.AlertSilenceMatcher) ([]monitor.AlertSilenceMatcher, error) {
+	for i := range matchers {
+		if len(matchers[i].Key) == 0 {
+			return nil, httperrors.NewInputParameterError("matchers[%d] key is empty", i)
+		}
+		if len(matchers[i].Operator) == 0 {
+			matchers[i].Operator = monitor.SILENCE_MATCH_EQUAL
+		}
+		switch matchers[i].Operator {
+		case monitor.SILENCE_MATCH_EQUAL, monitor.SILENCE_MATCH_NOT_EQUAL:
+		case monitor.SILENCE_MATCH_REGEX, monitor.SILENCE_MATCH_NOT_REGEX:
+			if _, err := compileSilenceRegex(matchers[i].Value); err != nil {
+				return nil, httperrors.NewInputParameterError("matchers[%d] invalid regex %q: %v", i, matchers[i].Value, err)
+			}
+		default:
+			return nil, httperrors.NewInputParameterError("matchers[%d] unsupported operator %q, support %v", i, matchers[i].Operator, monitor.SilenceMatchOperators)
+		}
+	}
+	return matchers, nil
+}
+
+func validateSilenceSchedule(schedule, duration, timezone string) error {
+	if len(schedule) == 0 {
+		return nil
+	}
+	if _, err := cronman.NewTimerCron(schedule, timezone); err != nil {
+		return httperrors.NewInputParameterError("invalid schedule %q: %v", schedule, err)
+	}
+	if len(duration) == 0 {
+		return httperrors.NewMissingParameterError("duration")
+	}
+	dur, err := time.ParseDuration(duration)
+	if err != nil {
+		return httperrors.NewInputParameterError("invalid duration %q: %v", duration, err)
+	}
+	if dur <= 0 {
+		return httperrors.NewInputParameterError("duration must be positive")
+	}
+	return nil
+}
+
+// compileSilenceRegex anchors the expression so that it has to match the whole label value
+func compileSilenceRegex(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+func matchSilenceLabels(matchers []monitor.AlertSilenceMatcher, labels map[string]string) bool {
+	for _, m := range matchers {
+		val := labels[m.Key]
+		var matched bool
+		switch m.Operator {
+		case monitor.SILENCE_MATCH_EQUAL, "":
+			matched = val == m.Value
+		case monitor.SILENCE_MATCH_NOT_EQUAL:
+			matched = val != m.Value
+		case monitor.SILENCE_MATCH_REGEX, monitor.SILENCE_MATCH_NOT_REGEX:
+			reg, err := compileSilenceRegex(m.Value)
+			if err != nil {
+				return false
+			}
+			matched = reg.MatchString(val) == (m.Operator == monitor.SILENCE_MATCH_REGEX)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// silenceWindowActive reports whether now falls into a window which starts
+// at one of the fire times of schedule and lasts for duration
+func silenceWindowActive(schedule *cronman.TimerCron, duration time.Duration, now time.Time) bool {
+	start := schedule.Next(now.Add(-duration))
+	return !start.IsZero() && !start.After(now)
+}
+
+func (silence *SAlertSilence) GetMatchers() ([]monitor.AlertSilenceMatcher, error) {
+	matchers := make([]monitor.AlertSilenceMatcher, 0)
+	if silence.Matchers == nil {
+		return matchers, nil
+	}
+	if err := silence.Matchers.Unmarshal(&matchers); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal matchers of silence %s", silence.Id)
+	}
+	return matchers, nil
+}
+
+func (silence *SAlertSilence) getSchedule() (*cronman.TimerCron, time.Duration, error) {
+	schedule, err := cronman.NewTimerCron(silence.Schedule, silence.Timezone)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "NewTimerCron %q", silence.Schedule)
+	}
+	duration, err := time.ParseDuration(silence.Duration)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "ParseDuration %q", silence.Duration)
+	}
+	return schedule, duration, nil
+}
+
+// IsActiveAt reports whether the silence suppresses notifications at time now
+func (silence *SAlertSilence) IsActiveAt(now time.Time) bool {
+	if !silence.GetEnabled() {
+		return false
+	}
+	if !silence.StartTime.IsZero() && now.Before(silence.StartTime) {
+		return false
+	}
+	if !silence.EndTime.IsZero() && !now.Before(silence.EndTime) {
+		return false
+	}
+	if len(silence.Schedule) == 0 {
+		return true
+	}
+	schedule, duration, err := silence.getSchedule()
+	if err != nil {
+		log.Errorf("silence %s(%s) schedule: %v", silence.Name, silence.Id, err)
+		return false
+	}
+	return silenceWindowActive(schedule, duration, now)
+}
+
+func (silence *SAlertSilence) nextWindowStart(now time.Time) time.Time {
+	schedule, _, err := silence.getSchedule()
+	if err != nil {
+		return time.Time{}
+	}
+	if now.Before(silence.StartTime) {
+		now = silence.StartTime
+	}
+	next := schedule.Next(now)
+	if !silence.EndTime.IsZero() && !next.Before(silence.EndTime) {
+		return time.Time{}
+	}
+	return next
+}
+
+// isInScope checks that a project or domain scoped silence only applies to
+// the alerts of its own project or domain
+func (silence *SAlertSilence) isInScope(projectId, domainId string) bool {
+	switch silence.GetResourceScope() {
+	case rbacscope.ScopeProject:
+		return silence.ProjectId == projectId
+	case rbacscope.ScopeDomain:
+		return silence.DomainId == domainId
+	}
+	return true
+}
+
+// Match reports whether the alert described by labels, which belongs to the
+// given project and domain, is suppressed by the silence
+func (silence *SAlertSilence) Match(labels map[string]string, projectId, domainId string) bool {
+	if !silence.isInScope(projectId, domainId) {
+		return false
+	}
+	matchers, err := silence.GetMatchers()
+	if err != nil {
+		log.Errorf("silence %s(%s) matchers: %v", silence.Name, silence.Id, err)
+		return false
+	}
+	return len(matchers) > 0 && matchSilenceLabels(matchers, labels)
+}
+
+// GetActiveSilences returns the enabled silences which are in effect at time now
+func (manager *SAlertSilenceManager) GetActiveSilences(now time.Time) ([]SAlertSilence, error) {
+	q := manager.Query().IsTrue("enabled")
+	q = q.Filter(sqlchemy.OR(sqlchemy.IsNull(q.Field("start_time")), sqlchemy.LE(q.Field("start_time"), now)))
+	q = q.Filter(sqlchemy.OR(sqlchemy.IsNull(q.Field("end_time")), sqlchemy.GT(q.Field("end_time"), now)))
+	silences := make([]SAlertSilence, 0)
+	if err := db.FetchModelObjects(manager, q, &silences); err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := make([]SAlertSilence, 0, len(silences))
+	for i := range silences {
+		if silences[i].IsActiveAt(now) {
+			ret = append(ret, silences[i])
+		}
+	}
+	return ret, nil
+}
+
+func (silence *SAlertSilence) IncSuppressed(count int, at time.Time) error {
+	_, err := db.Update(silence, func() error {
+		silence.SuppressedCount += count
+		silence.LastSuppressedAt = at
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+)
+
+func Test_matchSilenceLabels(t *testing.T) {
+	labels := map[string]string{
+		"host":      "node-02",
+		"zone":      "zone1",
+		"tags.rack": "r03",
+	}
+	tests := []struct {
+		name     string
+		matchers []monitor.AlertSilenceMatcher
+		want     bool
+	}{
+		{
+			name:     "equal",
+			matchers: []monitor.AlertSilenceMatcher{{Key: "zone", Operator: "=", Value: "zone1"}},
+			want:     true,
+		},
+		{
+			name:     "not equal",
+			matchers: []monitor.AlertSilenceMatcher{{Key: "zone", Operator: "!=", Value: "zone1"}},
+			want:     false,
+		},
+		{
+			name:     "missing label equals empty string",
+			matchers: []monitor.AlertSilenceMatcher{{Key: "project", Operator: "!=", Value: "system"}},
+			want:     true,
+		},
+		{
+			name:     "regex is anchored",
+			matchers: []monitor.AlertSilenceMatcher{{Key: "host", Operator: "=~", Value: "node-0"}},
+			want:     false,
+		},
+		{
+			name: "all matchers must match",
+			matchers: []monitor.AlertSilenceMatcher{
+				{Key: "host", Operator: "=~", Value: "node-0[1-3]"},
+				{Key: "tags.rack", Operator: "!~", Value: "r0[12]"},
+			},
+			want: true,
+		},
+		{
+			name: "one matcher fails",
+			matchers: []monitor.AlertSilenceMatcher{
+				{Key: "host", Operator: "=~", Value: "node-0[1-3]"},
+				{Key: "tags.rack", Operator: "=", Value: "r01"},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchSilenceLabels(tt.matchers, labels); got != tt.want {
+				t.Errorf("matchSilenceLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_silenceWindowActive(t *testing.T) {
+	// every Saturday at 02:00 for 2 hours
+	schedule, err := cronman.NewTimerCron("0 2 * * 6", "UTC")
+	if err != nil {
+		t.Fatalf("NewTimerCron: %v", err)
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{
+			name: "window start",
+			now:  time.Date(2023, 3, 4, 2, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "inside window",
+			now:  time.Date(2023, 3, 4, 3, 30, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "window end",
+			now:  time.Date(2023, 3, 4, 4, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "before window",
+			now:  time.Date(2023, 3, 4, 1, 59, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "other day",
+			now:  time.Date(2023, 3, 5, 3, 0, 0, 0, time.UTC),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := silenceWindowActive(schedule, 2*time.Hour, tt.now); got != tt.want {
+				t.Errorf("silenceWindowActive(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
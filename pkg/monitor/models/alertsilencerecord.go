@@ -0,0 +1,203 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/pkg/util/timeutils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+var (
+	AlertSilenceRecordManager *SAlertSilenceRecordManager
+)
+
+type SAlertSilenceRecordManager struct {
+	db.SStandaloneAnonResourceBaseManager
+	SMonitorScopedResourceManager
+}
+
+func init() {
+	AlertSilenceRecordManager = &SAlertSilenceRecordManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SAlertSilenceRecord{},
+			"alertsilencerecord_tbl",
+			"alertsilencerecord",
+			"alertsilencerecords",
+		),
+	}
+
+	AlertSilenceRecordManager.SetVirtualObject(AlertSilenceRecordManager)
+}
+
+func (manager *SAlertSilenceRecordManager) FilterByOwner(ctx context.Context, q *sqlchemy.SQuery, man db.FilterByOwnerProvider, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, scope rbacscope.TRbacScope) *sqlchemy.SQuery {
+	return manager.SMonitorScopedResourceManager.FilterByOwner(ctx, q, man, userCred, ownerId, scope)
+}
+
+// SAlertSilenceRecord records a notification suppressed by an alert silence
+type SAlertSilenceRecord struct {
+	db.SStandaloneAnonResourceBase
+	SMonitorScopedResource
+
+	SilenceId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true" json:"silence_id"`
+	AlertId   string `width:"36" charset:"ascii" nullable:"false" list:"user" json:"alert_id"`
+	AlertName string `width:"256" charset:"utf8" list:"user" json:"alert_name"`
+	Level     string `width:"36" charset:"ascii" list:"user" json:"level"`
+	State     string `width:"36" charset:"ascii" list:"user" json:"state"`
+	ResType   string `width:"36" charset:"ascii" list:"user" json:"res_type"`
+	ResId     string `width:"128" charset:"ascii" list:"user" json:"res_id"`
+	Metric    string `width:"256" charset:"utf8" list:"user" json:"metric"`
+
+	Labels jsonutils.JSONObject `length:"medium" list:"user" json:"labels"`
+}
+
+func (manager *SAlertSilenceRecordManager) ListItemFilter(
+	ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query monitor.AlertSilenceRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SScopedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ScopedResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SScopedResourceBaseManager.ListItemFilter")
+	}
+	if len(query.SilenceId) > 0 {
+		silence, err := AlertSilenceManager.FetchByIdOrName(ctx, userCred, query.SilenceId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return nil, httperrors.NewResourceNotFoundError2(AlertSilenceManager.Keyword(), query.SilenceId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("silence_id", silence.GetId())
+	}
+	if len(query.AlertId) > 0 {
+		q = q.Equals("alert_id", query.AlertId)
+	}
+	if len(query.ResType) > 0 {
+		q = q.Equals("res_type", query.ResType)
+	}
+	if len(query.ResId) > 0 {
+		q = q.Equals("res_id", query.ResId)
+	}
+	return q, nil
+}
+
+func (manager *SAlertSilenceRecordManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input monitor.AlertSilenceRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SStandaloneAnonResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SScopedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.ScopedResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SScopedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SAlertSilenceRecordManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []monitor.AlertSilenceRecordDetails {
+	rows := make([]monitor.AlertSilenceRecordDetails, len(objs))
+	stdRows := manager.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	scopedRows := manager.SScopedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	silenceIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = monitor.AlertSilenceRecordDetails{
+			StandaloneAnonResourceDetails: stdRows[i],
+			ScopedResourceBaseInfo:        scopedRows[i],
+		}
+		silenceIds[i] = objs[i].(*SAlertSilenceRecord).SilenceId
+	}
+	silences := make(map[string]SAlertSilence)
+	if err := db.FetchModelObjectsByIds(AlertSilenceManager, "id", silenceIds, &silences); err != nil {
+		log.Errorf("FetchModelObjectsByIds silences: %v", err)
+		return rows
+	}
+	for i := range rows {
+		if silence, ok := silences[silenceIds[i]]; ok {
+			rows[i].Silence = silence.Name
+		}
+	}
+	return rows
+}
+
+func (manager *SAlertSilenceRecordManager) ValidateCreateData(
+	ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject,
+	data *jsonutils.JSONDict,
+) (*jsonutils.JSONDict, error) {
+	return nil, httperrors.NewUnsupportOperationError("alert silence records are generated by the alerting engine")
+}
+
+// CreateRecord saves a notification of alert suppressed by silence, the
+// record shares the scope of the silence which suppressed it
+func (manager *SAlertSilenceRecordManager) CreateRecord(ctx context.Context, silence *SAlertSilence, record *SAlertSilenceRecord) error {
+	record.SilenceId = silence.Id
+	record.DomainId = silence.DomainId
+	record.ProjectId = silence.ProjectId
+	record.SetModelManager(manager, record)
+	if err := manager.TableSpec().Insert(ctx, record); err != nil {
+		return errors.Wrapf(err, "insert silence record of %s", silence.Id)
+	}
+	return nil
+}
+
+func (manager *SAlertSilenceRecordManager) DeleteRecordsOfThirtyDaysAgo(ctx context.Context, userCred mcclient.TokenCredential,
+	isStart bool) {
+	records := make([]SAlertSilenceRecord, 0)
+	query := manager.Query()
+	query = query.LE("created_at", timeutils.MysqlTime(time.Now().Add(-time.Hour*24*30)))
+	err := db.FetchModelObjects(manager, query, &records)
+	if err != nil {
+		log.Errorf("fetch silence records of thirty days ago err:%v", err)
+		return
+	}
+	for i := range records {
+		err := db.DeleteModel(ctx, userCred, &records[i])
+		if err != nil {
+			log.Errorf("delete expire silence record:%s err:%v", records[i].GetId(), err)
+		}
+	}
+}
@@ -22,6 +22,9 @@ type AlerterOptions struct {
 	common_options.CommonOptions
 	common_options.DBOptions
 
+	MonitorDataSource    string `help:"TSDB service type used to query metrics, e.g. prometheus, auto detect influxdb or victoria-metrics if empty"`
+	MonitorDataSourceUrl string `help:"URL of the TSDB data source, fetch from service catalog if empty"`
+
 	DataProxyTimeout                               int   `help:"query data source proxy timeout" default:"30"`
 	AlertingMinIntervalSeconds                     int64 `help:"alerting min schedule frequency" default:"10"`
 	AlertingMaxAttempts                            int   `help:"alerting engine max attempt" default:"3"`
@@ -31,6 +34,11 @@ type AlerterOptions struct {
 	InitAlertResourceAdminRoleUsersIntervalSeconds int   `help:"internal to init alert resource admin role users " default:"3600"`
 	MonitorResourceSyncIntervalSeconds             int   `help:"internal to sync monitor resource,unit: h " default:"1"`
 
+	AlertingGroupBy               []string `help:"labels to group firing alerts by before notification, e.g. alert_name, host, zone; grouping is disabled if empty"`
+	AlertingGroupWaitSeconds      int64    `help:"time to wait for more alerts of a new group before sending its first notification" default:"30"`
+	AlertingGroupIntervalSeconds  int64    `help:"time to wait before notifying new alerts of a group that has already been notified" default:"300"`
+	AlertingRepeatIntervalSeconds int64    `help:"time to wait before sending a group notification again if the group has no new alerts" default:"3600"`
+
 	APISyncIntervalSeconds  int `default:"3600"`
 	APIRunDelayMilliseconds int `default:"5000"`
 	APIListBatchSize        int `default:"1024"`
@@ -66,6 +66,8 @@ func InitHandlers(app *appsrv.Application) {
 		models.AlertPanelManager,
 		models.MonitorResourceManager,
 		models.AlertRecordShieldManager,
+		models.AlertSilenceManager,
+		models.AlertSilenceRecordManager,
 		models.GetMigrationAlertManager(),
 	} {
 		db.RegisterModelManager(manager)
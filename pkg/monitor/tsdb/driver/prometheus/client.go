@@ -0,0 +1,136 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"golang.org/x/net/context/ctxhttp"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb/driver/victoriametrics"
+)
+
+const (
+	ErrPrometheusInvalidResponse = errors.Error("Prometheus invalid response")
+
+	remoteReadVersion = "0.1.0"
+	userAgent         = "Cloudpods Monitor Service"
+)
+
+type Client interface {
+	// QueryRange evaluates a PromQL expression over a range of time by HTTP query API
+	QueryRange(ctx context.Context, httpCli *http.Client, query string, step time.Duration, timeRange *victoriametrics.TimeRange) (*victoriametrics.Response, error)
+	// Read fetches raw samples by remote-read API
+	Read(ctx context.Context, httpCli *http.Client, req *ReadRequest) (*ReadResponse, error)
+}
+
+type client struct {
+	endpointURL url.URL
+	// Prometheus HTTP query API is compatible with VictoriaMetrics
+	queryCli victoriametrics.Client
+}
+
+func NewClient(endpoint string) (Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url: %q", endpoint)
+	}
+	queryCli, err := victoriametrics.NewClient(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "new query client")
+	}
+	return &client{
+		endpointURL: *u,
+		queryCli:    queryCli,
+	}, nil
+}
+
+func (c *client) getAPIURL(reqPath string) string {
+	reqURL := c.endpointURL
+	reqURL.Path = path.Join(reqURL.Path, "/api/v1", reqPath)
+	return reqURL.String()
+}
+
+// QueryRange implements Client.
+func (c *client) QueryRange(ctx context.Context, httpCli *http.Client, query string, step time.Duration, tr *victoriametrics.TimeRange) (*victoriametrics.Response, error) {
+	return c.queryCli.QueryRange(ctx, httpCli, query, step, tr, false)
+}
+
+func (c *client) newProtoRequest(reqPath string, msg []byte) (*http.Request, error) {
+	reqURL := c.getAPIURL(reqPath)
+	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewReader(snappy.Encode(nil, msg)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "new HTTP request of: %s", reqURL)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	return req, nil
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return errors.Wrapf(ErrPrometheusInvalidResponse, "status code: %d, %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Read implements Client.
+func (c *client) Read(ctx context.Context, httpCli *http.Client, rr *ReadRequest) (*ReadResponse, error) {
+	req, err := c.newProtoRequest("/read", rr.Marshal())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Prometheus-Remote-Read-Version", remoteReadVersion)
+
+	resp, err := ctxhttp.Do(ctx, httpCli, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Do request")
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/x-protobuf") {
+		return nil, errors.Wrapf(ErrPrometheusInvalidResponse, "unsupported content type %q", ct)
+	}
+	compressed, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, errors.Wrap(err, "snappy decode")
+	}
+	ret := new(ReadResponse)
+	if err := ret.Unmarshal(data); err != nil {
+		return nil, errors.Wrap(err, "unmarshal read response")
+	}
+	if len(ret.Results) != len(rr.Queries) {
+		return nil, errors.Wrapf(ErrPrometheusInvalidResponse, "%d results for %d queries", len(ret.Results), len(rr.Queries))
+	}
+	return ret, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/snappy"
+)
+
+func TestClientRead(t *testing.T) {
+	want := ReadResponse{
+		Results: []QueryResult{
+			{
+				Timeseries: []TimeSeries{
+					{
+						Labels: []Label{
+							{Name: "__name__", Value: "cpu_usage_active"},
+							{Name: "host", Value: "node-1"},
+						},
+						Samples: []Sample{
+							{Value: 12.5, Timestamp: 1652169600000},
+							{Value: 0, Timestamp: 1652169660000},
+						},
+					},
+				},
+			},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/read" || r.Header.Get("Content-Encoding") != "snappy" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if _, err := snappy.Decode(nil, body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(snappy.Encode(nil, want.Marshal()))
+	}))
+	defer srv.Close()
+
+	cli, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := &ReadRequest{
+		Queries: []Query{
+			{
+				StartTimestampMs: 1652169600000,
+				EndTimestampMs:   1652169700000,
+				Matchers:         []LabelMatcher{{Type: MatchRegexp, Name: "__name__", Value: "cpu_.+"}},
+				Hints:            &ReadHints{Func: "series"},
+			},
+		},
+	}
+	got, err := cli.Read(context.Background(), srv.Client(), req)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("want %#v got %#v", want, *got)
+	}
+	if name := got.Results[0].Timeseries[0].GetLabel("host"); name != "node-1" {
+		t.Errorf("GetLabel host want node-1 got %s", name)
+	}
+}
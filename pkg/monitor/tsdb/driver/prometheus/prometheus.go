@@ -0,0 +1,292 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/promql/v2/pkg/labels"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/sets"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb/driver/victoriametrics"
+)
+
+func init() {
+	tsdb.RegisterTsdbQueryEndpoint(monitor.DataSourceTypePrometheus, NewPrometheusExecutor)
+}
+
+type PrometheusExecutor struct {
+	datasource *tsdb.DataSource
+}
+
+func NewPrometheusExecutor(datasource *tsdb.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &PrometheusExecutor{
+		datasource: datasource,
+	}, nil
+}
+
+func (e *PrometheusExecutor) getClient(ds *tsdb.DataSource) (Client, error) {
+	return NewClient(ds.Url)
+}
+
+// Query implements tsdb.TsdbQueryEndpoint.
+func (e *PrometheusExecutor) Query(ctx context.Context, ds *tsdb.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	cli, err := e.getClient(ds)
+	if err != nil {
+		return nil, errors.Wrap(err, "new Prometheus client")
+	}
+	httpCli, err := ds.GetHttpClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetHttpClient of data source")
+	}
+	tr := victoriametrics.NewTimeRange(tsdbQuery.TimeRange.GetFromAsSecondsEpoch(), tsdbQuery.TimeRange.GetToAsSecondsEpoch())
+
+	result := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
+	for _, query := range tsdbQuery.Queries {
+		step, err := getStep(ds, query, tsdbQuery.TimeRange)
+		if err != nil {
+			return nil, errors.Wrap(err, "get query step")
+		}
+		selects, err := buildPromQL(query, step)
+		if err != nil {
+			return nil, errors.Wrapf(err, "build PromQL of query %q", query.RefId)
+		}
+		rawQueries := make([]string, len(selects))
+		merger := newSeriesMerger(len(selects))
+		for i, sel := range selects {
+			rawQueries[i] = sel.expr
+			resp, err := cli.QueryRange(ctx, httpCli, sel.expr, step, tr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "query Prometheus range by: %s", sel.expr)
+			}
+			if err := merger.add(i, resp.Data.Result); err != nil {
+				return nil, errors.Wrapf(err, "parse result of: %s", sel.expr)
+			}
+		}
+		columns := make([]string, len(selects))
+		for i := range selects {
+			columns[i] = selects[i].column
+		}
+		ret := tsdb.NewQueryResult()
+		ret.RefId = query.RefId
+		ret.Series = merger.toSeries(columns, getGroupByTags(query))
+		ret.Meta = monitor.QueryResultMeta{
+			RawQuery: strings.Join(rawQueries, "; "),
+		}
+		result.Results[query.RefId] = ret
+	}
+	return result, nil
+}
+
+func getGroupByTags(query *tsdb.Query) []string {
+	ret := make([]string, 0)
+	for _, group := range query.GroupBy {
+		if group.Type == "tag" && len(group.Params) > 0 && group.Params[0] != "*" {
+			ret = append(ret, group.Params[0])
+		}
+	}
+	return ret
+}
+
+type seriesValues struct {
+	tags   map[string]string
+	values map[int64][]interface{}
+}
+
+// seriesMerger merges results of different selects into one series if they have the same labels,
+// each select becomes a column of the series
+type seriesMerger struct {
+	columns int
+	ids     []string
+	series  map[string]*seriesValues
+}
+
+func newSeriesMerger(columns int) *seriesMerger {
+	return &seriesMerger{
+		columns: columns,
+		ids:     make([]string, 0),
+		series:  make(map[string]*seriesValues),
+	}
+}
+
+func seriesId(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s->%s", k, tags[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func parseSampleValue(val interface{}) interface{} {
+	var str string
+	switch v := val.(type) {
+	case string:
+		str = v
+	case json.Number:
+		str = v.String()
+	default:
+		return nil
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) {
+		return nil
+	}
+	return &f
+}
+
+func (m *seriesMerger) add(column int, results []victoriametrics.ResponseDataResult) error {
+	for _, result := range results {
+		tags := make(map[string]string, len(result.Metric))
+		for k, v := range result.Metric {
+			if k != labels.MetricName {
+				tags[k] = v
+			}
+		}
+		id := seriesId(tags)
+		sv, ok := m.series[id]
+		if !ok {
+			sv = &seriesValues{
+				tags:   tags,
+				values: make(map[int64][]interface{}),
+			}
+			m.series[id] = sv
+			m.ids = append(m.ids, id)
+		}
+		for _, val := range result.Values {
+			if len(val) != 2 {
+				return errors.Errorf("invalid value %#v", val)
+			}
+			tsNumber, ok := val[0].(json.Number)
+			if !ok {
+				return errors.Errorf("invalid timestamp %#v", val[0])
+			}
+			ts, err := tsNumber.Float64()
+			if err != nil {
+				return errors.Wrapf(err, "parse timestamp %s", tsNumber)
+			}
+			// to influxdb timestamp format in millisecond
+			tsMs := int64(math.Round(ts * 1000))
+			vals, ok := sv.values[tsMs]
+			if !ok {
+				vals = make([]interface{}, m.columns)
+				sv.values[tsMs] = vals
+			}
+			vals[column] = parseSampleValue(val[1])
+		}
+	}
+	return nil
+}
+
+func (m *seriesMerger) toSeries(columns []string, groupByTags []string) monitor.TimeSeriesSlice {
+	ret := make(monitor.TimeSeriesSlice, 0, len(m.ids))
+	name := strings.Join(columns, ",")
+	for idx, id := range m.ids {
+		sv := m.series[id]
+		times := make([]int64, 0, len(sv.values))
+		for ts := range sv.values {
+			times = append(times, ts)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		points := make(monitor.TimeSeriesPoints, 0, len(times))
+		for _, ts := range times {
+			point := make(monitor.TimePoint, 0, m.columns+1)
+			point = append(point, sv.values[ts]...)
+			point = append(point, float64(ts))
+			points = append(points, point)
+		}
+		cols := append(append([]string{}, columns...), "time")
+		ret = append(ret, tsdb.NewTimeSeries(name, tsdb.FormatRawName(idx, name, groupByTags, sv.tags), cols, points, sv.tags))
+	}
+	return ret
+}
+
+// FilterMeasurement implements tsdb.TsdbQueryEndpoint, the fields of measurement are
+// fetched from the series metadata of remote-read API
+func (e *PrometheusExecutor) FilterMeasurement(ctx context.Context, ds *tsdb.DataSource, from, to string, ms *monitor.InfluxMeasurement, tagFilter *monitor.MetricQueryTag) (*monitor.InfluxMeasurement, error) {
+	cli, err := e.getClient(ds)
+	if err != nil {
+		return nil, errors.Wrap(err, "new Prometheus client")
+	}
+	httpCli, err := ds.GetHttpClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetHttpClient of data source")
+	}
+	msPrefix := fmt.Sprintf("%s_", ms.Measurement)
+	matchers := []LabelMatcher{
+		{
+			Type:  MatchRegexp,
+			Name:  labels.MetricName,
+			Value: fmt.Sprintf("%s.+", regexp.QuoteMeta(msPrefix)),
+		},
+	}
+	if tagFilter != nil {
+		tagMatchers, err := tagsToMatchers([]monitor.MetricQueryTag{*tagFilter})
+		if err != nil {
+			return nil, errors.Wrap(err, "tagsToMatchers")
+		}
+		matchers = append(matchers, tagMatchers...)
+	}
+	tr := tsdb.NewTimeRange(from, to)
+	query := Query{
+		StartTimestampMs: tr.GetFromAsMsEpoch(),
+		EndTimestampMs:   tr.GetToAsMsEpoch(),
+		Matchers:         matchers,
+		Hints: &ReadHints{
+			// only series are required
+			Func:    "series",
+			StartMs: tr.GetFromAsMsEpoch(),
+			EndMs:   tr.GetToAsMsEpoch(),
+		},
+	}
+	resp, err := cli.Read(ctx, httpCli, &ReadRequest{Queries: []Query{query}})
+	if err != nil {
+		return nil, errors.Wrap(err, "Prometheus remote read")
+	}
+
+	retFields := sets.NewString()
+	for _, ts := range resp.Results[0].Timeseries {
+		name := ts.GetLabel(labels.MetricName)
+		if !strings.HasPrefix(name, msPrefix) {
+			continue
+		}
+		retFields.Insert(strings.TrimPrefix(name, msPrefix))
+	}
+	retMs := new(monitor.InfluxMeasurement)
+	retMs.FieldKey = retFields.List()
+	if len(retMs.FieldKey) != 0 {
+		retMs.Measurement = ms.Measurement
+		retMs.Database = ms.Database
+		retMs.ResType = ms.ResType
+	}
+	return retMs, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"yunion.io/x/pkg/errors"
+)
+
+// Minimal protobuf messages of the Prometheus remote storage protocol,
+// check https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+
+type MatchType int32
+
+const (
+	MatchEqual     MatchType = 0
+	MatchNotEqual  MatchType = 1
+	MatchRegexp    MatchType = 2
+	MatchNotRegexp MatchType = 3
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value float64
+	// Timestamp in milliseconds
+	Timestamp int64
+}
+
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+type ReadHints struct {
+	StepMs   int64
+	Func     string
+	StartMs  int64
+	EndMs    int64
+	Grouping []string
+	By       bool
+	RangeMs  int64
+}
+
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []LabelMatcher
+	Hints            *ReadHints
+}
+
+type ReadRequest struct {
+	Queries []Query
+}
+
+type QueryResult struct {
+	Timeseries []TimeSeries
+}
+
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendInt64(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func (l Label) Marshal() []byte {
+	b := appendString(nil, 1, l.Name)
+	return appendString(b, 2, l.Value)
+}
+
+func (s Sample) Marshal() []byte {
+	var b []byte
+	if s.Value != 0 {
+		b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	}
+	return appendInt64(b, 2, s.Timestamp)
+}
+
+func (ts TimeSeries) Marshal() []byte {
+	var b []byte
+	for i := range ts.Labels {
+		b = appendMessage(b, 1, ts.Labels[i].Marshal())
+	}
+	for i := range ts.Samples {
+		b = appendMessage(b, 2, ts.Samples[i].Marshal())
+	}
+	return b
+}
+
+func (m LabelMatcher) Marshal() []byte {
+	b := appendInt64(nil, 1, int64(m.Type))
+	b = appendString(b, 2, m.Name)
+	return appendString(b, 3, m.Value)
+}
+
+func (h ReadHints) Marshal() []byte {
+	b := appendInt64(nil, 1, h.StepMs)
+	b = appendString(b, 2, h.Func)
+	b = appendInt64(b, 3, h.StartMs)
+	b = appendInt64(b, 4, h.EndMs)
+	for _, g := range h.Grouping {
+		b = appendString(b, 5, g)
+	}
+	if h.By {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(h.By))
+	}
+	return appendInt64(b, 7, h.RangeMs)
+}
+
+func (q Query) Marshal() []byte {
+	b := appendInt64(nil, 1, q.StartTimestampMs)
+	b = appendInt64(b, 2, q.EndTimestampMs)
+	for i := range q.Matchers {
+		b = appendMessage(b, 3, q.Matchers[i].Marshal())
+	}
+	if q.Hints != nil {
+		b = appendMessage(b, 4, q.Hints.Marshal())
+	}
+	return b
+}
+
+func (r ReadRequest) Marshal() []byte {
+	var b []byte
+	for i := range r.Queries {
+		b = appendMessage(b, 1, r.Queries[i].Marshal())
+	}
+	return b
+}
+
+func (r QueryResult) Marshal() []byte {
+	var b []byte
+	for i := range r.Timeseries {
+		b = appendMessage(b, 1, r.Timeseries[i].Marshal())
+	}
+	return b
+}
+
+func (r ReadResponse) Marshal() []byte {
+	var b []byte
+	for i := range r.Results {
+		b = appendMessage(b, 1, r.Results[i].Marshal())
+	}
+	return b
+}
+
+// consumeFields iterates over the fields of a message, unknown fields are skipped
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errors.Wrap(protowire.ParseError(n), "consume tag")
+		}
+		b = b[n:]
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return errors.Wrapf(err, "field %d", num)
+		}
+		if n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errors.Wrapf(protowire.ParseError(n), "consume field %d", num)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeBytes(typ protowire.Type, b []byte) ([]byte, int) {
+	if typ != protowire.BytesType {
+		return nil, 0
+	}
+	return protowire.ConsumeBytes(b)
+}
+
+func (l *Label) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		v, n := consumeBytes(typ, b)
+		if n <= 0 {
+			return n, nil
+		}
+		switch num {
+		case 1:
+			l.Name = string(v)
+		case 2:
+			l.Value = string(v)
+		}
+		return n, nil
+	})
+}
+
+func (s *Sample) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			s.Value = math.Float64frombits(v)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.Timestamp = int64(v)
+			return n, nil
+		}
+		return 0, nil
+	})
+}
+
+func (ts *TimeSeries) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		v, n := consumeBytes(typ, b)
+		if n <= 0 {
+			return n, nil
+		}
+		switch num {
+		case 1:
+			l := Label{}
+			if err := l.Unmarshal(v); err != nil {
+				return n, errors.Wrap(err, "label")
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			s := Sample{}
+			if err := s.Unmarshal(v); err != nil {
+				return n, errors.Wrap(err, "sample")
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return n, nil
+	})
+}
+
+func (r *QueryResult) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		v, n := consumeBytes(typ, b)
+		if n <= 0 || num != 1 {
+			return n, nil
+		}
+		ts := TimeSeries{}
+		if err := ts.Unmarshal(v); err != nil {
+			return n, errors.Wrap(err, "timeseries")
+		}
+		r.Timeseries = append(r.Timeseries, ts)
+		return n, nil
+	})
+}
+
+func (r *ReadResponse) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		v, n := consumeBytes(typ, b)
+		if n <= 0 || num != 1 {
+			return n, nil
+		}
+		qr := QueryResult{}
+		if err := qr.Unmarshal(v); err != nil {
+			return n, errors.Wrap(err, "query result")
+		}
+		r.Results = append(r.Results, qr)
+		return n, nil
+	})
+}
+
+// GetLabel returns the value of label name, empty string if not exists
+func (ts TimeSeries) GetLabel(name string) string {
+	for _, l := range ts.Labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
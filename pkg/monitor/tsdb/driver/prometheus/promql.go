@@ -0,0 +1,277 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+var (
+	regexpOperatorPattern = regexp.MustCompile(`^\/.*\/$`)
+
+	// influxdb aggregation function => prometheus range vector function
+	overTimeFuncs = map[string]string{
+		"mean":   "avg_over_time",
+		"max":    "max_over_time",
+		"min":    "min_over_time",
+		"sum":    "sum_over_time",
+		"count":  "count_over_time",
+		"last":   "last_over_time",
+		"stddev": "stddev_over_time",
+	}
+
+	// influxdb aggregation function => prometheus aggregation operator across series
+	aggregationOps = map[string]string{
+		"mean":  "avg",
+		"max":   "max",
+		"min":   "min",
+		"sum":   "sum",
+		"count": "sum",
+	}
+)
+
+type promSelect struct {
+	column string
+	expr   string
+}
+
+// influxRegexToProm converts an influxdb regular expression like /^vm-.*/ to a
+// prometheus one, which is always fully anchored
+func influxRegexToProm(val string) string {
+	if regexpOperatorPattern.MatchString(val) {
+		val = val[1 : len(val)-1]
+	}
+	if strings.HasPrefix(val, "^") {
+		val = val[1:]
+	} else {
+		val = ".*" + val
+	}
+	if strings.HasSuffix(val, "$") && !strings.HasSuffix(val, `\$`) {
+		val = val[:len(val)-1]
+	} else {
+		val = val + ".*"
+	}
+	return val
+}
+
+func newMatcher(name string, op string, value string) (LabelMatcher, error) {
+	m := LabelMatcher{Name: name, Value: value}
+	switch op {
+	case "=":
+		m.Type = MatchEqual
+	case "!=", "<>":
+		m.Type = MatchNotEqual
+	case "=~":
+		m.Type = MatchRegexp
+		m.Value = influxRegexToProm(value)
+	case "!~":
+		m.Type = MatchNotRegexp
+		m.Value = influxRegexToProm(value)
+	default:
+		return m, errors.Wrapf(errors.ErrNotSupported, "label matcher operator %q", op)
+	}
+	return m, nil
+}
+
+func tagsToMatchers(tags []monitor.MetricQueryTag) ([]LabelMatcher, error) {
+	ret := make([]LabelMatcher, 0, len(tags))
+	for i, tag := range tags {
+		if i > 0 && strings.EqualFold(tag.Condition, "OR") {
+			return nil, errors.Wrapf(errors.ErrNotSupported, "OR condition of tag %q", tag.Key)
+		}
+		op := tag.Operator
+		if op == "" {
+			if regexpOperatorPattern.MatchString(tag.Value) {
+				op = "=~"
+			} else {
+				op = "="
+			}
+		}
+		m, err := newMatcher(tag.Key, op, tag.Value)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+func (m LabelMatcher) String() string {
+	op := "="
+	switch m.Type {
+	case MatchNotEqual:
+		op = "!="
+	case MatchRegexp:
+		op = "=~"
+	case MatchNotRegexp:
+		op = "!~"
+	}
+	return fmt.Sprintf("%s%s%s", m.Name, op, strconv.Quote(m.Value))
+}
+
+func renderSelector(metric string, matchers []LabelMatcher) string {
+	parts := make([]string, len(matchers))
+	for i := range matchers {
+		parts[i] = matchers[i].String()
+	}
+	return fmt.Sprintf("%s{%s}", metric, strings.Join(parts, ","))
+}
+
+// rangeFunc applies a range vector function on expr, a subquery is used if expr
+// is not a plain selector
+func rangeFunc(fn string, expr string, isSelector bool, rng string, args ...string) string {
+	if isSelector {
+		expr = fmt.Sprintf("%s[%s]", expr, rng)
+	} else {
+		expr = fmt.Sprintf("(%s)[%s:]", expr, rng)
+	}
+	return fmt.Sprintf("%s(%s)", fn, strings.Join(append(args, expr), ", "))
+}
+
+func renderSelect(sel monitor.MetricQuerySelect, measurement string, matchers []LabelMatcher, step time.Duration) (promSelect, string, error) {
+	ret := promSelect{}
+	if len(sel) == 0 || sel[0].Type != "field" || len(sel[0].Params) == 0 {
+		return ret, "", errors.Wrap(errors.ErrNotSupported, "select without field")
+	}
+	field := sel[0].Params[0]
+	if field == "*" {
+		return ret, "", errors.Wrap(errors.ErrNotSupported, "select all fields")
+	}
+	ret.column = fmt.Sprintf("%s_%s", measurement, field)
+	expr := renderSelector(ret.column, matchers)
+	isSelector := true
+	aggOp := "avg"
+	rng := tsdb.FormatDuration(step)
+
+	for _, part := range sel[1:] {
+		switch part.Type {
+		case "mean", "max", "min", "sum", "count", "last", "stddev":
+			expr = rangeFunc(overTimeFuncs[part.Type], expr, isSelector, rng)
+			if op, ok := aggregationOps[part.Type]; ok {
+				aggOp = op
+			}
+		case "median":
+			expr = rangeFunc("quantile_over_time", expr, isSelector, rng, "0.5")
+		case "percentile":
+			if len(part.Params) == 0 {
+				return ret, "", errors.Errorf("percentile without nth")
+			}
+			nth, err := strconv.ParseFloat(part.Params[0], 64)
+			if err != nil {
+				return ret, "", errors.Wrapf(err, "invalid percentile %q", part.Params[0])
+			}
+			expr = rangeFunc("quantile_over_time", expr, isSelector, rng, strconv.FormatFloat(nth/100, 'f', -1, 64))
+		case "spread":
+			expr = fmt.Sprintf("%s - %s", rangeFunc("max_over_time", expr, isSelector, rng), rangeFunc("min_over_time", expr, isSelector, rng))
+		case "derivative", "non_negative_derivative":
+			fn := "deriv"
+			if part.Type == "non_negative_derivative" {
+				fn = "rate"
+			}
+			expr = rangeFunc(fn, expr, isSelector, rng)
+			if len(part.Params) > 0 && part.Params[0] != "" {
+				unit, err := time.ParseDuration(part.Params[0])
+				if err != nil {
+					return ret, "", errors.Wrapf(err, "invalid %s unit %q", part.Type, part.Params[0])
+				}
+				if unit != time.Second {
+					expr = fmt.Sprintf("(%s) * %s", expr, strconv.FormatFloat(unit.Seconds(), 'f', -1, 64))
+				}
+			}
+		case "abs":
+			expr = fmt.Sprintf("abs(%s)", expr)
+		case "math":
+			if len(part.Params) > 0 {
+				expr = fmt.Sprintf("(%s) %s", expr, strings.TrimSpace(part.Params[0]))
+			}
+		case "alias":
+			if len(part.Params) > 0 && part.Params[0] != "" {
+				ret.column = part.Params[0]
+			}
+			continue
+		default:
+			return ret, "", errors.Wrapf(errors.ErrNotSupported, "function %q", part.Type)
+		}
+		isSelector = false
+	}
+	ret.expr = expr
+	return ret, aggOp, nil
+}
+
+// getStep returns the resolution of the range query, the interval of 'GROUP BY time()'
+// takes precedence over the one calculated from time range
+func getStep(ds *tsdb.DataSource, query *tsdb.Query, tr *tsdb.TimeRange) (time.Duration, error) {
+	minInterval, err := tsdb.GetIntervalFrom(ds, query, time.Minute)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetIntervalFrom")
+	}
+	calculator := tsdb.NewIntervalCalculator(&tsdb.IntervalOptions{})
+	step := calculator.Calculate(tr, minInterval).Value
+	for _, group := range query.GroupBy {
+		if group.Type != "time" || len(group.Params) == 0 {
+			continue
+		}
+		if d, err := time.ParseDuration(group.Params[0]); err == nil {
+			step = d
+		}
+	}
+	if step < time.Minute {
+		step = time.Minute
+	}
+	return step, nil
+}
+
+// buildPromQL translates the influxdb styled query model to PromQL expressions, one for each select
+func buildPromQL(query *tsdb.Query, step time.Duration) ([]promSelect, error) {
+	matchers, err := tagsToMatchers(query.Tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "tagsToMatchers")
+	}
+	groupAll := false
+	groupTags := make([]string, 0)
+	for _, group := range query.GroupBy {
+		if group.Type != "tag" || len(group.Params) == 0 {
+			continue
+		}
+		if group.Params[0] == "*" {
+			groupAll = true
+		} else {
+			groupTags = append(groupTags, group.Params[0])
+		}
+	}
+
+	ret := make([]promSelect, 0, len(query.Selects))
+	for i, sel := range query.Selects {
+		ps, aggOp, err := renderSelect(sel, query.Measurement, matchers, step)
+		if err != nil {
+			return nil, errors.Wrapf(err, "render select %d", i)
+		}
+		if !groupAll {
+			// series are merged into groups like influxdb does
+			ps.expr = fmt.Sprintf("%s by (%s) (%s)", aggOp, strings.Join(groupTags, ", "), ps.expr)
+		}
+		ret = append(ret, ps)
+	}
+	return ret, nil
+}
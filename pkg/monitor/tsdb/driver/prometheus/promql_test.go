@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+func TestInfluxRegexToProm(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`/^vm-.*$/`, `vm-.*`},
+		{`/vm/`, `.*vm.*`},
+		{`/^vm/`, `vm.*`},
+		{`/price\$/`, `.*price\$.*`},
+	}
+	for _, c := range cases {
+		if got := influxRegexToProm(c.in); got != c.want {
+			t.Errorf("influxRegexToProm(%q) want %q got %q", c.in, c.want, got)
+		}
+	}
+}
+
+func TestBuildPromQL(t *testing.T) {
+	cases := []struct {
+		name    string
+		query   monitor.MetricQuery
+		want    []promSelect
+		wantErr bool
+	}{
+		{
+			name: "mean group by tag",
+			query: monitor.MetricQuery{
+				Measurement: "cpu",
+				Selects: []monitor.MetricQuerySelect{
+					{
+						{Type: "field", Params: []string{"usage_active"}},
+						{Type: "mean"},
+					},
+				},
+				Tags: []monitor.MetricQueryTag{
+					{Key: "res_type", Operator: "=", Value: "host"},
+					{Key: "host", Value: "/^node/", Condition: "AND"},
+				},
+				GroupBy: []monitor.MetricQueryPart{
+					{Type: "time", Params: []string{"$interval"}},
+					{Type: "tag", Params: []string{"host_id"}},
+				},
+			},
+			want: []promSelect{
+				{
+					column: "cpu_usage_active",
+					expr:   `avg by (host_id) (avg_over_time(cpu_usage_active{res_type="host",host=~"node.*"}[5m]))`,
+				},
+			},
+		},
+		{
+			name: "multiple selects group by all",
+			query: monitor.MetricQuery{
+				Measurement: "net",
+				Selects: []monitor.MetricQuerySelect{
+					{
+						{Type: "field", Params: []string{"bytes_recv"}},
+						{Type: "non_negative_derivative", Params: []string{"1s"}},
+						{Type: "math", Params: []string{"* 8"}},
+						{Type: "alias", Params: []string{"bps_recv"}},
+					},
+					{
+						{Type: "field", Params: []string{"err_in"}},
+						{Type: "max"},
+						{Type: "percentile", Params: []string{"95"}},
+					},
+				},
+				GroupBy: []monitor.MetricQueryPart{
+					{Type: "tag", Params: []string{"*"}},
+				},
+			},
+			want: []promSelect{
+				{
+					column: "bps_recv",
+					expr:   `(rate(net_bytes_recv{}[5m])) * 8`,
+				},
+				{
+					column: "net_err_in",
+					expr:   `quantile_over_time(0.95, (max_over_time(net_err_in{}[5m]))[5m:])`,
+				},
+			},
+		},
+		{
+			name: "or condition",
+			query: monitor.MetricQuery{
+				Measurement: "cpu",
+				Selects: []monitor.MetricQuerySelect{
+					{{Type: "field", Params: []string{"usage_active"}}},
+				},
+				Tags: []monitor.MetricQueryTag{
+					{Key: "host", Value: "a"},
+					{Key: "host", Value: "b", Condition: "OR"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		got, err := buildPromQL(&tsdb.Query{MetricQuery: c.query}, 5*time.Minute)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %d selects got %d", c.name, len(c.want), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: select %d want %#v got %#v", c.name, i, c.want[i], got[i])
+			}
+		}
+	}
+}
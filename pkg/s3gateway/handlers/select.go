@@ -18,11 +18,53 @@ import (
 	"context"
 	"net/http"
 
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 	"yunion.io/x/s3cli"
 
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/s3gateway/models"
+	"yunion.io/x/onecloud/pkg/s3gateway/s3select"
 )
 
-func selectObject(ctx context.Context, userCred mcclient.TokenCredential, hdr http.Header, bucket string, key string) (*s3cli.InitiateMultipartUploadResult, http.Header, error) {
-	return nil, nil, NotImplemented(ctx, "")
+// selectObject runs SelectObjectContent, errors returned before the response
+// starts are sent as normal S3 errors, later errors are sent as error events
+func selectObject(ctx context.Context, userCred mcclient.TokenCredential, bucketName string, key string, r *http.Request, w http.ResponseWriter) error {
+	request := s3cli.SelectObjectOptions{}
+	err := appsrv.FetchXml(r, &request)
+	if err != nil {
+		return errors.Wrap(httperrors.ErrBadRequest, "FetchXml")
+	}
+	selector, err := s3select.NewSelector(&request)
+	if err != nil {
+		return errors.Wrapf(httperrors.ErrBadRequest, "%s: %s", s3select.ErrorCode(err), err.Error())
+	}
+	bucket, err := models.BucketManager.GetByName(ctx, userCred, bucketName)
+	if err != nil {
+		return errors.Wrap(err, "models.BucketManager.GetByName")
+	}
+	iBucket, err := bucket.GetIBucket(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "bucket.GetIBucket")
+	}
+	_, err = cloudprovider.GetIObject(iBucket, key)
+	if err != nil {
+		return errors.Wrap(err, "cloudprovider.GetIObject")
+	}
+	stream, err := iBucket.GetObject(ctx, key, nil)
+	if err != nil {
+		return errors.Wrap(err, "iBucket.GetObject")
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	err = selector.Execute(ctx, stream, w)
+	if err != nil {
+		log.Errorf("select object %s/%s: %s", bucketName, key, err)
+	}
+	return nil
 }
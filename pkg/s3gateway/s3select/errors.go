@@ -0,0 +1,49 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"yunion.io/x/pkg/errors"
+)
+
+// error causes are named after the error codes of S3 Select,
+// they are sent as the error code of the error event
+const (
+	ErrParseSyntax            = errors.Error("ParseSyntaxError")
+	ErrUnsupportedSyntax      = errors.Error("UnsupportedSyntax")
+	ErrInvalidRequest         = errors.Error("InvalidRequestParameter")
+	ErrInvalidDataType        = errors.Error("InvalidDataType")
+	ErrCastFailed             = errors.Error("CastFailed")
+	ErrDivisionByZero         = errors.Error("DivisionByZero")
+	ErrEvaluatorInvalidArgs   = errors.Error("EvaluatorInvalidArguments")
+	ErrUnsupportedFunction    = errors.Error("UnsupportedFunction")
+	ErrInvalidColumnIndex     = errors.Error("InvalidColumnIndex")
+	ErrCSVParsing             = errors.Error("CSVParsingError")
+	ErrJSONParsing            = errors.Error("JSONParsingError")
+	ErrInternal               = errors.Error("InternalError")
+	ErrUnsupportedCompression = errors.Error("UnsupportedCompressionType")
+)
+
+// ErrorCode returns the S3 Select error code of err
+func ErrorCode(err error) string {
+	switch cause := errors.Cause(err); cause {
+	case ErrParseSyntax, ErrUnsupportedSyntax, ErrInvalidRequest, ErrInvalidDataType,
+		ErrCastFailed, ErrDivisionByZero, ErrEvaluatorInvalidArgs, ErrUnsupportedFunction,
+		ErrInvalidColumnIndex, ErrCSVParsing, ErrJSONParsing, ErrUnsupportedCompression:
+		return cause.Error()
+	default:
+		return ErrInternal.Error()
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"net/http"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/s3cli"
+)
+
+const (
+	// type of string header value in event stream
+	eventHeaderTypeString = 7
+
+	// prelude: total length, headers length and CRC of the prelude
+	eventPreludeLength    = 12
+	eventMessageCRCLength = 4
+)
+
+type sEventHeader struct {
+	name  string
+	value string
+}
+
+// sEventWriter encodes messages of AWS event stream, the response format of SelectObjectContent
+type sEventWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func newEventWriter(w io.Writer) *sEventWriter {
+	ew := &sEventWriter{w: w}
+	if flusher, ok := w.(http.Flusher); ok {
+		ew.flusher = flusher
+	}
+	return ew
+}
+
+func encodeEventMessage(headers []sEventHeader, payload []byte) []byte {
+	hdrBuf := &bytes.Buffer{}
+	for _, hdr := range headers {
+		hdrBuf.WriteByte(byte(len(hdr.name)))
+		hdrBuf.WriteString(hdr.name)
+		hdrBuf.WriteByte(eventHeaderTypeString)
+		binary.Write(hdrBuf, binary.BigEndian, uint16(len(hdr.value)))
+		hdrBuf.WriteString(hdr.value)
+	}
+	totalLen := eventPreludeLength + hdrBuf.Len() + len(payload) + eventMessageCRCLength
+
+	msg := bytes.NewBuffer(make([]byte, 0, totalLen))
+	binary.Write(msg, binary.BigEndian, uint32(totalLen))
+	binary.Write(msg, binary.BigEndian, uint32(hdrBuf.Len()))
+	binary.Write(msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(hdrBuf.Bytes())
+	msg.Write(payload)
+	binary.Write(msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func (ew *sEventWriter) writeMessage(headers []sEventHeader, payload []byte) error {
+	_, err := ew.w.Write(encodeEventMessage(headers, payload))
+	if err != nil {
+		return errors.Wrap(err, "write event")
+	}
+	if ew.flusher != nil {
+		ew.flusher.Flush()
+	}
+	return nil
+}
+
+func (ew *sEventWriter) writeRecords(payload []byte) error {
+	return ew.writeMessage([]sEventHeader{
+		{":event-type", "Records"},
+		{":content-type", "application/octet-stream"},
+		{":message-type", "event"},
+	}, payload)
+}
+
+func (ew *sEventWriter) writeXmlEvent(event string, v interface{}) error {
+	payload, err := xml.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "xml.Marshal %s", event)
+	}
+	return ew.writeMessage([]sEventHeader{
+		{":event-type", event},
+		{":content-type", "text/xml"},
+		{":message-type", "event"},
+	}, payload)
+}
+
+func (ew *sEventWriter) writeStats(stats s3cli.StatsMessage) error {
+	return ew.writeXmlEvent("Stats", &stats)
+}
+
+func (ew *sEventWriter) writeProgress(stats s3cli.StatsMessage) error {
+	return ew.writeXmlEvent("Progress", &s3cli.ProgressMessage{StatsMessage: stats})
+}
+
+// writeContinuation keeps the connection alive while no records are selected for a long time
+func (ew *sEventWriter) writeContinuation() error {
+	return ew.writeMessage([]sEventHeader{
+		{":event-type", "Cont"},
+		{":message-type", "event"},
+	}, nil)
+}
+
+func (ew *sEventWriter) writeEnd() error {
+	return ew.writeMessage([]sEventHeader{
+		{":event-type", "End"},
+		{":message-type", "event"},
+	}, nil)
+}
+
+func (ew *sEventWriter) writeError(code, message string) error {
+	return ew.writeMessage([]sEventHeader{
+		{":error-code", code},
+		{":error-message", message},
+		{":message-type", "error"},
+	}, nil)
+}
@@ -0,0 +1,510 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type iRecord interface {
+	// getColumn returns the value of column path, path[0] may be a positional name _N,
+	// a missing column is null
+	getColumn(path []string) (SValue, error)
+}
+
+type iExpr interface {
+	eval(rec iRecord) (SValue, error)
+}
+
+type sLiteral struct {
+	val SValue
+}
+
+func (e *sLiteral) eval(rec iRecord) (SValue, error) {
+	return e.val, nil
+}
+
+type sColumn struct {
+	path []string
+}
+
+func (e *sColumn) eval(rec iRecord) (SValue, error) {
+	return rec.getColumn(e.path)
+}
+
+type sNot struct {
+	x iExpr
+}
+
+func (e *sNot) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v.isNull() {
+		return nullValue, err
+	}
+	b, ok := v.toBool()
+	if !ok {
+		return nullValue, errors.Wrapf(ErrInvalidDataType, "NOT %s", v.String())
+	}
+	return newBool(!b), nil
+}
+
+type sNegate struct {
+	x iExpr
+}
+
+func (e *sNegate) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	return arithmetic("-", newInt(0), v)
+}
+
+// sLogical implements AND/OR with three-valued logic, null stands for unknown
+type sLogical struct {
+	op   string
+	l, r iExpr
+}
+
+func evalBool(x iExpr, rec iRecord) (bool, bool, error) {
+	v, err := x.eval(rec)
+	if err != nil {
+		return false, false, err
+	}
+	if v.isNull() {
+		return false, false, nil
+	}
+	b, ok := v.toBool()
+	if !ok {
+		return false, false, errors.Wrapf(ErrInvalidDataType, "%s is not a boolean", v.String())
+	}
+	return b, true, nil
+}
+
+func (e *sLogical) eval(rec iRecord) (SValue, error) {
+	lb, lok, err := evalBool(e.l, rec)
+	if err != nil {
+		return nullValue, err
+	}
+	if e.op == "AND" && lok && !lb {
+		return newBool(false), nil
+	}
+	if e.op == "OR" && lok && lb {
+		return newBool(true), nil
+	}
+	rb, rok, err := evalBool(e.r, rec)
+	if err != nil {
+		return nullValue, err
+	}
+	if e.op == "AND" {
+		if rok && !rb {
+			return newBool(false), nil
+		}
+	} else {
+		if rok && rb {
+			return newBool(true), nil
+		}
+	}
+	if !lok || !rok {
+		return nullValue, nil
+	}
+	return newBool(rb), nil
+}
+
+type sCompare struct {
+	op   string
+	l, r iExpr
+}
+
+func (e *sCompare) eval(rec iRecord) (SValue, error) {
+	lv, err := e.l.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	rv, err := e.r.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	if lv.isNull() || rv.isNull() {
+		return nullValue, nil
+	}
+	c, err := compareValues(lv, rv)
+	if err != nil {
+		return nullValue, err
+	}
+	switch e.op {
+	case "=":
+		return newBool(c == 0), nil
+	case "!=", "<>":
+		return newBool(c != 0), nil
+	case "<":
+		return newBool(c < 0), nil
+	case "<=":
+		return newBool(c <= 0), nil
+	case ">":
+		return newBool(c > 0), nil
+	case ">=":
+		return newBool(c >= 0), nil
+	}
+	return nullValue, errors.Wrapf(ErrUnsupportedSyntax, "operator %s", e.op)
+}
+
+type sArith struct {
+	op   string
+	l, r iExpr
+}
+
+func (e *sArith) eval(rec iRecord) (SValue, error) {
+	lv, err := e.l.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	rv, err := e.r.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	if e.op == "||" {
+		if lv.isNull() || rv.isNull() {
+			return nullValue, nil
+		}
+		return newString(lv.String() + rv.String()), nil
+	}
+	return arithmetic(e.op, lv, rv)
+}
+
+type sLike struct {
+	x       iExpr
+	pattern iExpr
+	escape  iExpr
+	not     bool
+
+	cachedPattern string
+	cachedRegexp  *regexp.Regexp
+}
+
+func likeToRegexp(pattern string, escape rune) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case escape != 0 && c == escape:
+			escaped = true
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		return nil, errors.Wrapf(ErrEvaluatorInvalidArgs, "LIKE pattern %q ends with escape character", pattern)
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func (e *sLike) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	pv, err := e.pattern.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	if v.isNull() || pv.isNull() {
+		return nullValue, nil
+	}
+	escape := rune(0)
+	if e.escape != nil {
+		ev, err := e.escape.eval(rec)
+		if err != nil {
+			return nullValue, err
+		}
+		if utf8.RuneCountInString(ev.String()) != 1 {
+			return nullValue, errors.Wrapf(ErrEvaluatorInvalidArgs, "LIKE escape should be a single character")
+		}
+		escape, _ = utf8.DecodeRuneInString(ev.String())
+	}
+	pattern := pv.String()
+	if e.cachedRegexp == nil || e.cachedPattern != pattern {
+		e.cachedRegexp, err = likeToRegexp(pattern, escape)
+		if err != nil {
+			return nullValue, err
+		}
+		e.cachedPattern = pattern
+	}
+	return newBool(e.cachedRegexp.MatchString(v.String()) != e.not), nil
+}
+
+type sIn struct {
+	x    iExpr
+	list []iExpr
+	not  bool
+}
+
+func (e *sIn) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v.isNull() {
+		return nullValue, err
+	}
+	hasNull := false
+	for _, item := range e.list {
+		iv, err := item.eval(rec)
+		if err != nil {
+			return nullValue, err
+		}
+		if iv.isNull() {
+			hasNull = true
+			continue
+		}
+		c, err := compareValues(v, iv)
+		if err == nil && c == 0 {
+			return newBool(!e.not), nil
+		}
+	}
+	if hasNull {
+		return nullValue, nil
+	}
+	return newBool(e.not), nil
+}
+
+type sBetween struct {
+	x, lo, hi iExpr
+	not       bool
+}
+
+func (e *sBetween) eval(rec iRecord) (SValue, error) {
+	ge := &sCompare{op: ">=", l: e.x, r: e.lo}
+	le := &sCompare{op: "<=", l: e.x, r: e.hi}
+	v, err := (&sLogical{op: "AND", l: ge, r: le}).eval(rec)
+	if err != nil || v.isNull() || !e.not {
+		return v, err
+	}
+	return newBool(!v.b), nil
+}
+
+type sIsNull struct {
+	x   iExpr
+	not bool
+}
+
+func (e *sIsNull) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	return newBool(v.isNull() != e.not), nil
+}
+
+type sCast struct {
+	x   iExpr
+	typ string
+}
+
+func (e *sCast) eval(rec iRecord) (SValue, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nullValue, err
+	}
+	return castValue(v, e.typ)
+}
+
+type sFunc struct {
+	name string
+	args []iExpr
+}
+
+var funcArgCount = map[string][2]int{
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"TRIM":             {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"SUBSTRING":        {2, 3},
+	"COALESCE":         {1, -1},
+	"NULLIF":           {2, 2},
+	"ABS":              {1, 1},
+}
+
+func newFunc(name string, args []iExpr) (*sFunc, error) {
+	cnt, ok := funcArgCount[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupportedFunction, "function %s", name)
+	}
+	if len(args) < cnt[0] || (cnt[1] >= 0 && len(args) > cnt[1]) {
+		return nil, errors.Wrapf(ErrEvaluatorInvalidArgs, "wrong number of arguments of %s", name)
+	}
+	return &sFunc{name: name, args: args}, nil
+}
+
+func (e *sFunc) eval(rec iRecord) (SValue, error) {
+	args := make([]SValue, len(e.args))
+	for i := range e.args {
+		v, err := e.args[i].eval(rec)
+		if err != nil {
+			return nullValue, err
+		}
+		args[i] = v
+	}
+	switch e.name {
+	case "COALESCE":
+		for _, v := range args {
+			if !v.isNull() {
+				return v, nil
+			}
+		}
+		return nullValue, nil
+	case "NULLIF":
+		if args[0].isNull() || args[1].isNull() {
+			return args[0], nil
+		}
+		if c, err := compareValues(args[0], args[1]); err == nil && c == 0 {
+			return nullValue, nil
+		}
+		return args[0], nil
+	}
+	if args[0].isNull() {
+		return nullValue, nil
+	}
+	switch e.name {
+	case "LOWER":
+		return newString(strings.ToLower(args[0].String())), nil
+	case "UPPER":
+		return newString(strings.ToUpper(args[0].String())), nil
+	case "TRIM":
+		return newString(strings.TrimSpace(args[0].String())), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return newInt(int64(utf8.RuneCountInString(args[0].String()))), nil
+	case "ABS":
+		n, ok := args[0].toNumber()
+		if !ok {
+			return nullValue, errors.Wrapf(ErrInvalidDataType, "ABS(%s)", args[0].String())
+		}
+		if compareNumber(n, newInt(0)) < 0 {
+			return arithmetic("-", newInt(0), n)
+		}
+		return n, nil
+	case "SUBSTRING":
+		// SQL positions start from 1
+		runes := []rune(args[0].String())
+		start, ok := args[1].toNumber()
+		if !ok || start.typ != typeInt {
+			return nullValue, errors.Wrapf(ErrEvaluatorInvalidArgs, "SUBSTRING start should be an integer")
+		}
+		from := start.i - 1
+		to := int64(len(runes))
+		if len(args) > 2 {
+			length, ok := args[2].toNumber()
+			if !ok || length.typ != typeInt || length.i < 0 {
+				return nullValue, errors.Wrapf(ErrEvaluatorInvalidArgs, "SUBSTRING length should be a non-negative integer")
+			}
+			to = from + length.i
+		}
+		if from < 0 {
+			from = 0
+		}
+		if to > int64(len(runes)) {
+			to = int64(len(runes))
+		}
+		if from >= to {
+			return newString(""), nil
+		}
+		return newString(string(runes[from:to])), nil
+	}
+	return nullValue, errors.Wrapf(ErrUnsupportedFunction, "function %s", e.name)
+}
+
+// sAggregate accumulates values of all selected records, eval returns the result
+type sAggregate struct {
+	fn  string
+	arg iExpr
+
+	count int64
+	acc   SValue
+}
+
+func (e *sAggregate) accumulate(rec iRecord) error {
+	if e.arg == nil {
+		// COUNT(*)
+		e.count++
+		return nil
+	}
+	v, err := e.arg.eval(rec)
+	if err != nil {
+		return err
+	}
+	if v.isNull() {
+		return nil
+	}
+	e.count++
+	switch e.fn {
+	case "SUM", "AVG":
+		n, ok := v.toNumber()
+		if !ok {
+			return errors.Wrapf(ErrInvalidDataType, "%s of non-number %q", e.fn, v.String())
+		}
+		if e.count == 1 {
+			e.acc = n
+		} else {
+			e.acc, err = arithmetic("+", e.acc, n)
+			if err != nil {
+				return err
+			}
+		}
+	case "MIN", "MAX":
+		if n, ok := v.toNumber(); ok {
+			v = n
+		}
+		if e.count == 1 {
+			e.acc = v
+			return nil
+		}
+		c, err := compareValues(v, e.acc)
+		if err != nil {
+			return err
+		}
+		if (e.fn == "MIN" && c < 0) || (e.fn == "MAX" && c > 0) {
+			e.acc = v
+		}
+	}
+	return nil
+}
+
+func (e *sAggregate) eval(rec iRecord) (SValue, error) {
+	switch e.fn {
+	case "COUNT":
+		return newInt(e.count), nil
+	case "AVG":
+		if e.count == 0 {
+			return nullValue, nil
+		}
+		return newFloat(e.acc.toFloat() / float64(e.count)), nil
+	default:
+		if e.count == 0 {
+			return nullValue, nil
+		}
+		return e.acc, nil
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"strings"
+	"unicode"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type tTokenType int
+
+const (
+	tokenEOF = tTokenType(iota)
+	tokenIdent
+	// double quoted identifier
+	tokenQuotedIdent
+	// single quoted string literal
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type sToken struct {
+	typ tTokenType
+	val string
+	pos int
+}
+
+// is tests whether the token is the keyword or symbol, keywords are case insensitive
+func (t sToken) is(val string) bool {
+	switch t.typ {
+	case tokenIdent:
+		return strings.EqualFold(t.val, val)
+	case tokenSymbol:
+		return t.val == val
+	}
+	return false
+}
+
+var twoCharSymbols = []string{"<=", ">=", "<>", "!=", "||"}
+
+func tokenize(expr string) ([]sToken, error) {
+	tokens := make([]sToken, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'':
+			// string literal, '' escapes a single quote
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(runes) {
+					return nil, errors.Wrapf(ErrParseSyntax, "unterminated string at %d", i)
+				}
+				if runes[j] == '\'' {
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						sb.WriteRune('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[j])
+				j++
+			}
+			tokens = append(tokens, sToken{typ: tokenString, val: sb.String(), pos: i})
+			i = j + 1
+		case c == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, errors.Wrapf(ErrParseSyntax, "unterminated quoted identifier at %d", i)
+			}
+			tokens = append(tokens, sToken{typ: tokenQuotedIdent, val: string(runes[i+1 : j]), pos: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					j = k
+					for j < len(runes) && unicode.IsDigit(runes[j]) {
+						j++
+					}
+				}
+			}
+			tokens = append(tokens, sToken{typ: tokenNumber, val: string(runes[i:j]), pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, sToken{typ: tokenIdent, val: string(runes[i:j]), pos: i})
+			i = j
+		default:
+			matched := false
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				for _, sym := range twoCharSymbols {
+					if two == sym {
+						tokens = append(tokens, sToken{typ: tokenSymbol, val: sym, pos: i})
+						i += 2
+						matched = true
+						break
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			if !strings.ContainsRune(",()*.=<>+-/%[]", c) {
+				return nil, errors.Wrapf(ErrParseSyntax, "unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, sToken{typ: tokenSymbol, val: string(c), pos: i})
+			i++
+		}
+	}
+	tokens = append(tokens, sToken{typ: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,575 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type sProjection struct {
+	expr iExpr
+	// name of the field in JSON output
+	name string
+}
+
+type sQuery struct {
+	selectAll   bool
+	projections []sProjection
+	where       iExpr
+	// -1 means no limit
+	limit int64
+
+	aggregates []*sAggregate
+}
+
+func (q *sQuery) isAggregate() bool {
+	return len(q.aggregates) > 0
+}
+
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "IS": true, "NULL": true, "LIKE": true,
+	"IN": true, "BETWEEN": true, "ESCAPE": true, "CAST": true, "TRUE": true, "FALSE": true,
+	"MISSING": true,
+}
+
+var aggregateFuncs = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+}
+
+type sParser struct {
+	tokens []sToken
+	pos    int
+
+	columns    []*sColumn
+	aggregates []*sAggregate
+
+	inAggregate bool
+	// column referenced outside of aggregate functions in current projection
+	plainColumn bool
+	allowAgg    bool
+}
+
+func (p *sParser) peek() sToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sParser) peekN(n int) sToken {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *sParser) next() sToken {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sParser) accept(val string) bool {
+	if p.peek().is(val) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sParser) expect(val string) error {
+	if !p.accept(val) {
+		return p.errorf("expect %s", val)
+	}
+	return nil
+}
+
+func (p *sParser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	near := t.val
+	if t.typ == tokenEOF {
+		near = "end of expression"
+	}
+	return errors.Wrapf(ErrParseSyntax, "%s near %q at %d", fmt.Sprintf(format, args...), near, t.pos)
+}
+
+func isReserved(t sToken) bool {
+	return t.typ == tokenIdent && reservedWords[strings.ToUpper(t.val)]
+}
+
+// parseQuery parses a SQL expression of S3 Select, e.g.
+// SELECT s.name, CAST(s.age AS INT) FROM S3Object s WHERE s.city = 'Beijing' LIMIT 10
+func parseQuery(expr string) (*sQuery, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &sParser{tokens: tokens}
+	q := &sQuery{limit: -1}
+
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	if err := p.parseProjections(q); err != nil {
+		return nil, err
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	if !p.accept("S3Object") {
+		return nil, p.errorf("only S3Object is supported in FROM clause")
+	}
+	if p.peek().is("[") {
+		p.next()
+		if !p.accept("*") || !p.accept("]") {
+			return nil, p.errorf("expect [*]")
+		}
+	}
+	alias := ""
+	if p.accept("AS") {
+		t := p.next()
+		if t.typ != tokenIdent && t.typ != tokenQuotedIdent {
+			return nil, p.errorf("expect table alias")
+		}
+		alias = t.val
+	} else if t := p.peek(); (t.typ == tokenIdent && !isReserved(t)) || t.typ == tokenQuotedIdent {
+		alias = p.next().val
+	}
+
+	if p.accept("WHERE") {
+		p.allowAgg = false
+		q.where, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("LIMIT") {
+		t := p.next()
+		if t.typ != tokenNumber {
+			return nil, p.errorf("expect number after LIMIT")
+		}
+		q.limit, err = strconv.ParseInt(t.val, 10, 64)
+		if err != nil || q.limit < 0 {
+			return nil, errors.Wrapf(ErrParseSyntax, "invalid LIMIT %s", t.val)
+		}
+	}
+	if p.peek().typ != tokenEOF {
+		return nil, p.errorf("unexpected token")
+	}
+
+	// column references may be qualified by table alias or S3Object
+	for _, col := range p.columns {
+		if len(col.path) > 1 && (strings.EqualFold(col.path[0], "S3Object") || (alias != "" && strings.EqualFold(col.path[0], alias))) {
+			col.path = col.path[1:]
+		}
+	}
+	for i := range q.projections {
+		if q.projections[i].name != "" {
+			continue
+		}
+		if col, ok := q.projections[i].expr.(*sColumn); ok && !strings.HasPrefix(col.path[len(col.path)-1], "[") {
+			q.projections[i].name = col.path[len(col.path)-1]
+		} else {
+			q.projections[i].name = fmt.Sprintf("_%d", i+1)
+		}
+	}
+	q.aggregates = p.aggregates
+	return q, nil
+}
+
+func (p *sParser) parseProjections(q *sQuery) error {
+	if p.accept("*") {
+		q.selectAll = true
+		return nil
+	}
+	// alias.*
+	if t := p.peek(); t.typ == tokenIdent && p.peekN(1).is(".") && p.peekN(2).is("*") {
+		p.pos += 3
+		q.selectAll = true
+		return nil
+	}
+	hasAgg, hasPlain := false, false
+	for {
+		p.allowAgg = true
+		p.plainColumn = false
+		aggCnt := len(p.aggregates)
+		expr, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		if len(p.aggregates) > aggCnt {
+			hasAgg = true
+		}
+		if p.plainColumn {
+			hasPlain = true
+		}
+		proj := sProjection{expr: expr}
+		if p.accept("AS") {
+			t := p.next()
+			if t.typ != tokenIdent && t.typ != tokenQuotedIdent {
+				return p.errorf("expect alias")
+			}
+			proj.name = t.val
+		} else if t := p.peek(); (t.typ == tokenIdent && !isReserved(t)) || t.typ == tokenQuotedIdent {
+			proj.name = p.next().val
+		}
+		q.projections = append(q.projections, proj)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if hasAgg && hasPlain {
+		return errors.Wrap(ErrUnsupportedSyntax, "aggregate functions cannot be mixed with columns without GROUP BY")
+	}
+	return nil
+}
+
+func (p *sParser) parseExpr() (iExpr, error) {
+	return p.parseOr()
+}
+
+func (p *sParser) parseOr() (iExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &sLogical{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sParser) parseAnd() (iExpr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &sLogical{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sParser) parseNot() (iExpr, error) {
+	if p.accept("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sNot{x: x}, nil
+	}
+	return p.parsePredicate()
+}
+
+var compareOps = []string{"=", "!=", "<>", "<", "<=", ">", ">="}
+
+func (p *sParser) parsePredicate() (iExpr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range compareOps {
+		if p.accept(op) {
+			r, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &sCompare{op: op, l: l, r: r}, nil
+		}
+	}
+	if p.accept("IS") {
+		not := p.accept("NOT")
+		if !p.accept("NULL") && !p.accept("MISSING") {
+			return nil, p.errorf("expect NULL")
+		}
+		return &sIsNull{x: l, not: not}, nil
+	}
+	not := false
+	if p.peek().is("NOT") && (p.peekN(1).is("LIKE") || p.peekN(1).is("IN") || p.peekN(1).is("BETWEEN")) {
+		p.next()
+		not = true
+	}
+	switch {
+	case p.accept("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		like := &sLike{x: l, pattern: pattern, not: not}
+		if p.accept("ESCAPE") {
+			like.escape, err = p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return like, nil
+	case p.accept("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		in := &sIn{x: l, not: not}
+		for {
+			item, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case p.accept("BETWEEN"):
+		lo, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &sBetween{x: l, lo: lo, hi: hi, not: not}, nil
+	}
+	return l, nil
+}
+
+func (p *sParser) parseAdditive() (iExpr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("+") && !t.is("-") && !t.is("||") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &sArith{op: t.val, l: l, r: r}
+	}
+}
+
+func (p *sParser) parseMultiplicative() (iExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("*") && !t.is("/") && !t.is("%") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &sArith{op: t.val, l: l, r: r}
+	}
+}
+
+func (p *sParser) parseUnary() (iExpr, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sNegate{x: x}, nil
+	}
+	if p.accept("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *sParser) parsePrimary() (iExpr, error) {
+	t := p.peek()
+	switch t.typ {
+	case tokenNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.val, 10, 64); err == nil {
+			return &sLiteral{val: newInt(i)}, nil
+		}
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrParseSyntax, "invalid number %s", t.val)
+		}
+		return &sLiteral{val: newFloat(f)}, nil
+	case tokenString:
+		p.next()
+		return &sLiteral{val: newString(t.val)}, nil
+	case tokenQuotedIdent:
+		return p.parseColumn()
+	case tokenSymbol:
+		if p.accept("(") {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tokenIdent:
+		switch {
+		case t.is("TRUE"):
+			p.next()
+			return &sLiteral{val: newBool(true)}, nil
+		case t.is("FALSE"):
+			p.next()
+			return &sLiteral{val: newBool(false)}, nil
+		case t.is("NULL"), t.is("MISSING"):
+			p.next()
+			return &sLiteral{val: nullValue}, nil
+		case t.is("CAST"):
+			return p.parseCast()
+		case isReserved(t):
+			return nil, p.errorf("unexpected keyword")
+		case p.peekN(1).is("("):
+			return p.parseFunc()
+		}
+		return p.parseColumn()
+	}
+	return nil, p.errorf("unexpected token")
+}
+
+func (p *sParser) parseColumn() (iExpr, error) {
+	col := &sColumn{path: []string{p.next().val}}
+	for {
+		if p.peek().is(".") && (p.peekN(1).typ == tokenIdent || p.peekN(1).typ == tokenQuotedIdent) {
+			p.next()
+			col.path = append(col.path, p.next().val)
+			continue
+		}
+		if p.peek().is("[") && p.peekN(1).typ == tokenNumber && p.peekN(2).is("]") {
+			p.next()
+			col.path = append(col.path, "["+p.next().val+"]")
+			p.next()
+			continue
+		}
+		break
+	}
+	p.columns = append(p.columns, col)
+	if !p.inAggregate {
+		p.plainColumn = true
+	}
+	return col, nil
+}
+
+func (p *sParser) parseCast() (iExpr, error) {
+	p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("AS"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.typ != tokenIdent {
+		return nil, p.errorf("expect type name")
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	typ := strings.ToUpper(t.val)
+	if _, err := castValue(newInt(0), typ); errors.Cause(err) == ErrUnsupportedSyntax {
+		return nil, err
+	}
+	return &sCast{x: x, typ: typ}, nil
+}
+
+func (p *sParser) parseFunc() (iExpr, error) {
+	name := strings.ToUpper(p.next().val)
+	p.next()
+	if aggregateFuncs[name] {
+		return p.parseAggregate(name)
+	}
+	args := make([]iExpr, 0)
+	if !p.accept(")") {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			// SUBSTRING(x FROM start FOR length)
+			if name == "SUBSTRING" && (p.accept("FROM") || p.accept("FOR")) {
+				continue
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	return newFunc(name, args)
+}
+
+func (p *sParser) parseAggregate(name string) (iExpr, error) {
+	if !p.allowAgg {
+		return nil, errors.Wrapf(ErrUnsupportedSyntax, "aggregate function %s is only allowed in SELECT list", name)
+	}
+	if p.inAggregate {
+		return nil, errors.Wrapf(ErrUnsupportedSyntax, "nested aggregate function %s", name)
+	}
+	agg := &sAggregate{fn: name}
+	if name == "COUNT" && p.accept("*") {
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		p.aggregates = append(p.aggregates, agg)
+		return agg, nil
+	}
+	p.inAggregate = true
+	arg, err := p.parseExpr()
+	p.inAggregate = false
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	agg.arg = arg
+	p.aggregates = append(p.aggregates, agg)
+	return agg, nil
+}
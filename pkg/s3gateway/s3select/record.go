@@ -0,0 +1,398 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/s3cli"
+)
+
+type iRecordReader interface {
+	// Read returns io.EOF when all records are consumed
+	Read() (iRecord, error)
+}
+
+type iRecordWriter interface {
+	// Write appends a record of the given fields to buf
+	Write(buf *bytes.Buffer, names []string, values []SValue) error
+}
+
+// iRecordFields is implemented by records to support SELECT *
+type iRecordFields interface {
+	fields() ([]string, []SValue)
+}
+
+// positional column name _N, N starts from 1
+func positionalIndex(name string) (int, bool) {
+	if !strings.HasPrefix(name, "_") {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(name[1:])
+	if err != nil || idx <= 0 {
+		return 0, false
+	}
+	return idx - 1, true
+}
+
+func singleRune(name, val, def string) (rune, error) {
+	if len(val) == 0 {
+		val = def
+	}
+	if utf8.RuneCountInString(val) != 1 {
+		return 0, errors.Wrapf(ErrInvalidRequest, "%s should be a single character", name)
+	}
+	r, _ := utf8.DecodeRuneInString(val)
+	return r, nil
+}
+
+type sCSVRecord struct {
+	header map[string]int
+	names  []string
+	values []string
+}
+
+func (rec *sCSVRecord) getColumn(path []string) (SValue, error) {
+	if len(path) != 1 {
+		return nullValue, nil
+	}
+	name := path[0]
+	if idx, ok := positionalIndex(name); ok {
+		if idx >= len(rec.values) {
+			return nullValue, nil
+		}
+		return newString(rec.values[idx]), nil
+	}
+	idx, ok := rec.header[name]
+	if !ok {
+		idx, ok = rec.header[strings.ToLower(name)]
+	}
+	if !ok || idx >= len(rec.values) {
+		return nullValue, nil
+	}
+	return newString(rec.values[idx]), nil
+}
+
+func (rec *sCSVRecord) fields() ([]string, []SValue) {
+	names := make([]string, len(rec.values))
+	values := make([]SValue, len(rec.values))
+	for i := range rec.values {
+		if i < len(rec.names) {
+			names[i] = rec.names[i]
+		} else {
+			names[i] = fmt.Sprintf("_%d", i+1)
+		}
+		values[i] = newString(rec.values[i])
+	}
+	return names, values
+}
+
+type sCSVReader struct {
+	reader     *csv.Reader
+	headerInfo s3cli.CSVFileHeaderInfo
+
+	headerRead bool
+	header     map[string]int
+	names      []string
+}
+
+func newCSVReader(r io.Reader, opts *s3cli.CSVInputOptions) (*sCSVReader, error) {
+	switch opts.RecordDelimiter {
+	case "", "\n", "\r\n":
+	default:
+		return nil, errors.Wrapf(ErrInvalidRequest, "unsupported CSV record delimiter %q", opts.RecordDelimiter)
+	}
+	if quote, err := singleRune("QuoteCharacter", opts.QuoteCharacter, `"`); err != nil {
+		return nil, err
+	} else if quote != '"' {
+		return nil, errors.Wrapf(ErrInvalidRequest, "unsupported CSV quote character %q", opts.QuoteCharacter)
+	}
+	if escape, err := singleRune("QuoteEscapeCharacter", opts.QuoteEscapeCharacter, `"`); err != nil {
+		return nil, err
+	} else if escape != '"' {
+		return nil, errors.Wrapf(ErrInvalidRequest, "unsupported CSV quote escape character %q", opts.QuoteEscapeCharacter)
+	}
+	delim, err := singleRune("FieldDelimiter", opts.FieldDelimiter, ",")
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = delim
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = false
+	if len(opts.Comments) > 0 {
+		reader.Comment, err = singleRune("Comments", opts.Comments, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	headerInfo := opts.FileHeaderInfo
+	switch strings.ToUpper(string(headerInfo)) {
+	case "", string(s3cli.CSVFileHeaderInfoNone):
+		headerInfo = s3cli.CSVFileHeaderInfoNone
+	case s3cli.CSVFileHeaderInfoIgnore:
+		headerInfo = s3cli.CSVFileHeaderInfoIgnore
+	case s3cli.CSVFileHeaderInfoUse:
+		headerInfo = s3cli.CSVFileHeaderInfoUse
+	default:
+		return nil, errors.Wrapf(ErrInvalidRequest, "invalid FileHeaderInfo %s", opts.FileHeaderInfo)
+	}
+	return &sCSVReader{reader: reader, headerInfo: headerInfo}, nil
+}
+
+func (r *sCSVReader) Read() (iRecord, error) {
+	if !r.headerRead {
+		r.headerRead = true
+		if r.headerInfo != s3cli.CSVFileHeaderInfoNone {
+			names, err := r.reader.Read()
+			if err != nil {
+				if err == io.EOF {
+					return nil, err
+				}
+				return nil, errors.Wrap(ErrCSVParsing, err.Error())
+			}
+			if r.headerInfo == s3cli.CSVFileHeaderInfoUse {
+				r.names = names
+				r.header = make(map[string]int, len(names)*2)
+				for i := len(names) - 1; i >= 0; i-- {
+					r.header[names[i]] = i
+				}
+				// unquoted column names are case insensitive
+				for i := len(names) - 1; i >= 0; i-- {
+					if _, ok := r.header[strings.ToLower(names[i])]; !ok {
+						r.header[strings.ToLower(names[i])] = i
+					}
+				}
+			}
+		}
+	}
+	values, err := r.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, errors.Wrap(ErrCSVParsing, err.Error())
+	}
+	return &sCSVRecord{header: r.header, names: r.names, values: values}, nil
+}
+
+type sJSONRecord struct {
+	raw json.RawMessage
+	doc interface{}
+}
+
+func (rec *sJSONRecord) getColumn(path []string) (SValue, error) {
+	cur := rec.doc
+	for _, key := range path {
+		switch val := cur.(type) {
+		case map[string]interface{}:
+			next, ok := val[key]
+			if !ok {
+				return nullValue, nil
+			}
+			cur = next
+		case []interface{}:
+			if !strings.HasPrefix(key, "[") {
+				return nullValue, nil
+			}
+			idx, err := strconv.Atoi(strings.Trim(key, "[]"))
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nullValue, nil
+			}
+			cur = val[idx]
+		default:
+			return nullValue, nil
+		}
+	}
+	return newJSONValue(cur), nil
+}
+
+// fields returns top level members of JSON object in the original order
+func (rec *sJSONRecord) fields() ([]string, []SValue) {
+	obj, ok := rec.doc.(map[string]interface{})
+	if !ok {
+		return []string{"_1"}, []SValue{newJSONValue(rec.doc)}
+	}
+	names := make([]string, 0, len(obj))
+	dec := json.NewDecoder(bytes.NewReader(rec.raw))
+	dec.UseNumber()
+	// skip the opening delimiter
+	dec.Token()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		names = append(names, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			break
+		}
+	}
+	values := make([]SValue, len(names))
+	for i := range names {
+		values[i] = newJSONValue(obj[names[i]])
+	}
+	return names, values
+}
+
+type sJSONReader struct {
+	decoder *json.Decoder
+	// pending records of a top level array
+	pending []json.RawMessage
+}
+
+func newJSONReader(r io.Reader, opts *s3cli.JSONInputOptions) (*sJSONReader, error) {
+	switch strings.ToUpper(string(opts.Type)) {
+	case "", string(s3cli.JSONDocumentType), s3cli.JSONLinesType:
+	default:
+		return nil, errors.Wrapf(ErrInvalidRequest, "invalid JSON type %s", opts.Type)
+	}
+	// both DOCUMENT and LINES are a stream of JSON values
+	return &sJSONReader{decoder: json.NewDecoder(bufio.NewReader(r))}, nil
+}
+
+func (r *sJSONReader) Read() (iRecord, error) {
+	for len(r.pending) == 0 {
+		var raw json.RawMessage
+		if err := r.decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, errors.Wrap(ErrJSONParsing, err.Error())
+		}
+		trimmed := bytes.TrimSpace(raw)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &r.pending); err != nil {
+				return nil, errors.Wrap(ErrJSONParsing, err.Error())
+			}
+			continue
+		}
+		r.pending = []json.RawMessage{raw}
+	}
+	raw := r.pending[0]
+	r.pending = r.pending[1:]
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	rec := &sJSONRecord{raw: raw}
+	if err := dec.Decode(&rec.doc); err != nil {
+		return nil, errors.Wrap(ErrJSONParsing, err.Error())
+	}
+	return rec, nil
+}
+
+type sCSVWriter struct {
+	fieldDelim  string
+	recordDelim string
+	quote       string
+	escape      string
+	quoteAlways bool
+}
+
+func newCSVWriter(opts *s3cli.CSVOutputOptions) (*sCSVWriter, error) {
+	w := &sCSVWriter{
+		fieldDelim:  opts.FieldDelimiter,
+		recordDelim: opts.RecordDelimiter,
+		quote:       opts.QuoteCharacter,
+		escape:      opts.QuoteEscapeCharacter,
+	}
+	if len(w.fieldDelim) == 0 {
+		w.fieldDelim = ","
+	}
+	if len(w.recordDelim) == 0 {
+		w.recordDelim = "\n"
+	}
+	if len(w.quote) == 0 {
+		w.quote = `"`
+	}
+	if len(w.escape) == 0 {
+		w.escape = w.quote
+	}
+	switch strings.ToLower(string(opts.QuoteFields)) {
+	case "", strings.ToLower(s3cli.CSVQuoteFieldsAsNeeded):
+	case strings.ToLower(string(s3cli.CSVQuoteFieldsAlways)):
+		w.quoteAlways = true
+	default:
+		return nil, errors.Wrapf(ErrInvalidRequest, "invalid QuoteFields %s", opts.QuoteFields)
+	}
+	return w, nil
+}
+
+func (w *sCSVWriter) needQuote(field string) bool {
+	return strings.Contains(field, w.fieldDelim) || strings.Contains(field, w.recordDelim) ||
+		strings.Contains(field, w.quote) || strings.ContainsAny(field, "\r\n")
+}
+
+func (w *sCSVWriter) Write(buf *bytes.Buffer, names []string, values []SValue) error {
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteString(w.fieldDelim)
+		}
+		field := v.String()
+		if w.quoteAlways || w.needQuote(field) {
+			buf.WriteString(w.quote)
+			buf.WriteString(strings.ReplaceAll(field, w.quote, w.escape+w.quote))
+			buf.WriteString(w.quote)
+		} else {
+			buf.WriteString(field)
+		}
+	}
+	buf.WriteString(w.recordDelim)
+	return nil
+}
+
+type sJSONWriter struct {
+	recordDelim string
+}
+
+func newJSONWriter(opts *s3cli.JSONOutputOptions) *sJSONWriter {
+	w := &sJSONWriter{recordDelim: opts.RecordDelimiter}
+	if len(w.recordDelim) == 0 {
+		w.recordDelim = "\n"
+	}
+	return w
+}
+
+func (w *sJSONWriter) Write(buf *bytes.Buffer, names []string, values []SValue) error {
+	buf.WriteByte('{')
+	for i := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(names[i])
+		if err != nil {
+			return errors.Wrap(ErrInternal, err.Error())
+		}
+		val, err := json.Marshal(values[i])
+		if err != nil {
+			return errors.Wrap(ErrInternal, err.Error())
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	buf.WriteString(w.recordDelim)
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/s3cli"
+)
+
+const (
+	// records are sent in batches of about this size
+	recordsBatchSize = 64 * 1024
+	// interval of progress and continuation messages
+	keepAliveInterval = 2 * time.Second
+)
+
+type sCountingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *sCountingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// SSelector runs a query of SelectObjectContent against the content of an object
+type SSelector struct {
+	opts  *s3cli.SelectObjectOptions
+	query *sQuery
+}
+
+// NewSelector validates the request and parses the SQL expression
+func NewSelector(opts *s3cli.SelectObjectOptions) (*SSelector, error) {
+	if len(opts.ExpressionType) > 0 && !strings.EqualFold(string(opts.ExpressionType), string(s3cli.QueryExpressionTypeSQL)) {
+		return nil, errors.Wrapf(ErrInvalidRequest, "unsupported expression type %s", opts.ExpressionType)
+	}
+	if len(opts.Expression) == 0 {
+		return nil, errors.Wrap(ErrInvalidRequest, "empty expression")
+	}
+	input := opts.InputSerialization
+	switch strings.ToUpper(string(input.CompressionType)) {
+	case "", string(s3cli.SelectCompressionNONE), s3cli.SelectCompressionGZIP, s3cli.SelectCompressionBZIP:
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedCompression, "%s", input.CompressionType)
+	}
+	if input.Parquet != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, "parquet input is not supported")
+	}
+	if (input.CSV == nil) == (input.JSON == nil) {
+		return nil, errors.Wrap(ErrInvalidRequest, "exactly one of CSV and JSON input serialization is required")
+	}
+	output := opts.OutputSerialization
+	if (output.CSV == nil) == (output.JSON == nil) {
+		return nil, errors.Wrap(ErrInvalidRequest, "exactly one of CSV and JSON output serialization is required")
+	}
+	s := &SSelector{opts: opts}
+	// validate serialization options in advance, so that errors are reported before streaming
+	if _, err := s.newRecordReader(bytes.NewReader(nil)); err != nil {
+		return nil, err
+	}
+	if _, err := s.newRecordWriter(); err != nil {
+		return nil, err
+	}
+	query, err := parseQuery(opts.Expression)
+	if err != nil {
+		return nil, err
+	}
+	s.query = query
+	return s, nil
+}
+
+func (s *SSelector) newRecordReader(r io.Reader) (iRecordReader, error) {
+	input := s.opts.InputSerialization
+	if input.CSV != nil {
+		return newCSVReader(r, input.CSV)
+	}
+	return newJSONReader(r, input.JSON)
+}
+
+func (s *SSelector) newRecordWriter() (iRecordWriter, error) {
+	output := s.opts.OutputSerialization
+	if output.CSV != nil {
+		return newCSVWriter(output.CSV)
+	}
+	return newJSONWriter(output.JSON), nil
+}
+
+func (s *SSelector) decompress(r io.Reader) (io.Reader, error) {
+	switch strings.ToUpper(string(s.opts.InputSerialization.CompressionType)) {
+	case s3cli.SelectCompressionGZIP:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidRequest, "invalid gzip content")
+		}
+		return gz, nil
+	case s3cli.SelectCompressionBZIP:
+		return bzip2.NewReader(r), nil
+	}
+	return r, nil
+}
+
+// Execute reads records from reader and writes selected records to w as an event stream,
+// errors after the stream starts are sent as an error event and returned
+func (s *SSelector) Execute(ctx context.Context, reader io.Reader, w io.Writer) error {
+	ew := newEventWriter(w)
+	err := s.execute(ctx, reader, ew)
+	if err != nil {
+		if werr := ew.writeError(ErrorCode(err), err.Error()); werr != nil {
+			log.Errorf("send select error event: %s", werr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SSelector) execute(ctx context.Context, reader io.Reader, ew *sEventWriter) error {
+	scanned := &sCountingReader{reader: reader}
+	decompressed, err := s.decompress(scanned)
+	if err != nil {
+		return err
+	}
+	processed := &sCountingReader{reader: decompressed}
+	recReader, err := s.newRecordReader(processed)
+	if err != nil {
+		return err
+	}
+	recWriter, err := s.newRecordWriter()
+	if err != nil {
+		return err
+	}
+
+	var returned int64
+	stats := func() s3cli.StatsMessage {
+		return s3cli.StatsMessage{
+			BytesScanned:   scanned.count,
+			BytesProcessed: processed.count,
+			BytesReturned:  returned,
+		}
+	}
+	buf := &bytes.Buffer{}
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		returned += int64(buf.Len())
+		err := ew.writeRecords(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+
+	q := s.query
+	lastSent := time.Now()
+	var count int64
+	// LIMIT counts output records, aggregates consume all records to produce one
+	for q.isAggregate() || q.limit < 0 || count < q.limit {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "select canceled")
+		}
+		rec, err := recReader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if q.where != nil {
+			v, err := q.where.eval(rec)
+			if err != nil {
+				return err
+			}
+			if !v.isTrue() {
+				if time.Since(lastSent) > keepAliveInterval {
+					if err := s.keepAlive(ew, stats()); err != nil {
+						return err
+					}
+					lastSent = time.Now()
+				}
+				continue
+			}
+		}
+		count++
+		if q.isAggregate() {
+			for _, agg := range q.aggregates {
+				if err := agg.accumulate(rec); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		names, values, err := s.project(rec)
+		if err != nil {
+			return err
+		}
+		if err := recWriter.Write(buf, names, values); err != nil {
+			return err
+		}
+		if buf.Len() >= recordsBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+			if s.opts.RequestProgress.Enabled {
+				if err := ew.writeProgress(stats()); err != nil {
+					return err
+				}
+			}
+			lastSent = time.Now()
+		}
+	}
+	if q.isAggregate() && q.limit != 0 {
+		// aggregate functions produce exactly one record
+		names, values, err := s.project(nil)
+		if err != nil {
+			return err
+		}
+		if err := recWriter.Write(buf, names, values); err != nil {
+			return err
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := ew.writeStats(stats()); err != nil {
+		return err
+	}
+	return ew.writeEnd()
+}
+
+func (s *SSelector) keepAlive(ew *sEventWriter, stats s3cli.StatsMessage) error {
+	if s.opts.RequestProgress.Enabled {
+		return ew.writeProgress(stats)
+	}
+	return ew.writeContinuation()
+}
+
+func (s *SSelector) project(rec iRecord) ([]string, []SValue, error) {
+	if s.query.selectAll {
+		names, values := rec.(iRecordFields).fields()
+		return names, values, nil
+	}
+	names := make([]string, len(s.query.projections))
+	values := make([]SValue, len(s.query.projections))
+	for i, proj := range s.query.projections {
+		v, err := proj.expr.eval(rec)
+		if err != nil {
+			return nil, nil, err
+		}
+		names[i] = proj.name
+		values[i] = v
+	}
+	return names, values, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"yunion.io/x/s3cli"
+)
+
+type sTestEvent struct {
+	headers map[string]string
+	payload []byte
+}
+
+func decodeEvents(t *testing.T, data []byte) []sTestEvent {
+	events := make([]sTestEvent, 0)
+	for len(data) > 0 {
+		totalLen := binary.BigEndian.Uint32(data[0:4])
+		hdrLen := binary.BigEndian.Uint32(data[4:8])
+		if crc32.ChecksumIEEE(data[0:8]) != binary.BigEndian.Uint32(data[8:12]) {
+			t.Fatalf("prelude crc mismatch")
+		}
+		msg := data[:totalLen]
+		if crc32.ChecksumIEEE(msg[:totalLen-4]) != binary.BigEndian.Uint32(msg[totalLen-4:]) {
+			t.Fatalf("message crc mismatch")
+		}
+		ev := sTestEvent{headers: map[string]string{}}
+		hdrs := msg[12 : 12+hdrLen]
+		for len(hdrs) > 0 {
+			nameLen := int(hdrs[0])
+			name := string(hdrs[1 : 1+nameLen])
+			valLen := int(binary.BigEndian.Uint16(hdrs[2+nameLen : 4+nameLen]))
+			ev.headers[name] = string(hdrs[4+nameLen : 4+nameLen+valLen])
+			hdrs = hdrs[4+nameLen+valLen:]
+		}
+		ev.payload = msg[12+hdrLen : totalLen-4]
+		events = append(events, ev)
+		data = data[totalLen:]
+	}
+	return events
+}
+
+func runSelect(t *testing.T, opts *s3cli.SelectObjectOptions, content io.Reader) (string, []sTestEvent, error) {
+	s, err := NewSelector(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	out := &bytes.Buffer{}
+	err = s.Execute(context.Background(), content, out)
+	events := decodeEvents(t, out.Bytes())
+	records := &bytes.Buffer{}
+	for _, ev := range events {
+		if ev.headers[":event-type"] == "Records" {
+			records.Write(ev.payload)
+		}
+	}
+	return records.String(), events, err
+}
+
+func csvOptions(expr string) *s3cli.SelectObjectOptions {
+	opts := &s3cli.SelectObjectOptions{
+		Expression:     expr,
+		ExpressionType: s3cli.QueryExpressionTypeSQL,
+	}
+	opts.InputSerialization.CSV = &s3cli.CSVInputOptions{FileHeaderInfo: s3cli.CSVFileHeaderInfoUse}
+	opts.OutputSerialization.CSV = &s3cli.CSVOutputOptions{}
+	return opts
+}
+
+const testCSV = `name,age,city
+Alice,30,Beijing
+Bob,25,"Shanghai, China"
+Carol,41,Beijing
+Dave,,Shenzhen
+`
+
+func TestSelectCSV(t *testing.T) {
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"SELECT * FROM S3Object", "Alice,30,Beijing\nBob,25,\"Shanghai, China\"\nCarol,41,Beijing\nDave,,Shenzhen\n"},
+		{"SELECT s.name FROM S3Object s WHERE s.city = 'Beijing'", "Alice\nCarol\n"},
+		{"SELECT name, age FROM S3Object WHERE CAST(age AS INT) > 28 LIMIT 1", "Alice,30\n"},
+		{"select _1 from S3Object where _3 like 'Sh%' and _2 <> ''", "Bob\n"},
+		{"SELECT UPPER(name) FROM S3Object WHERE age <> '' AND age BETWEEN 26 AND 40", "ALICE\n"},
+		{"SELECT name FROM S3Object WHERE name IN ('Bob', 'Dave')", "Bob\nDave\n"},
+		{"SELECT COUNT(*), SUM(CAST(age AS INT)), MAX(age) FROM S3Object WHERE age <> ''", "3,96,41\n"},
+		{"SELECT COUNT(*) FROM S3Object s WHERE s.city = 'Nowhere'", "0\n"},
+		{"SELECT COUNT(*) FROM S3Object LIMIT 2", "4\n"},
+		{"SELECT COUNT(*) FROM S3Object LIMIT 0", ""},
+	}
+	for _, c := range cases {
+		got, events, err := runSelect(t, csvOptions(c.expr), strings.NewReader(testCSV))
+		if err != nil {
+			t.Errorf("%s: %s", c.expr, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: want %q got %q", c.expr, c.want, got)
+		}
+		last := events[len(events)-1]
+		if last.headers[":event-type"] != "End" {
+			t.Errorf("%s: last event should be End", c.expr)
+		}
+		stats := s3cli.StatsMessage{}
+		if err := xml.Unmarshal(events[len(events)-2].payload, &stats); err != nil {
+			t.Errorf("%s: unmarshal stats: %s", c.expr, err)
+		} else if stats.BytesScanned != int64(len(testCSV)) || stats.BytesReturned != int64(len(c.want)) {
+			t.Errorf("%s: unexpected stats %#v", c.expr, stats)
+		}
+	}
+}
+
+func TestSelectJSON(t *testing.T) {
+	opts := &s3cli.SelectObjectOptions{
+		Expression: "SELECT s.id, s.info.tags[0] AS tag FROM S3Object[*] s WHERE s.info.size >= 10",
+	}
+	opts.InputSerialization.JSON = &s3cli.JSONInputOptions{Type: s3cli.JSONLinesType}
+	opts.InputSerialization.CompressionType = s3cli.SelectCompressionGZIP
+	opts.OutputSerialization.JSON = &s3cli.JSONOutputOptions{}
+
+	content := &bytes.Buffer{}
+	gz := gzip.NewWriter(content)
+	gz.Write([]byte(`{"id":1,"info":{"size":5,"tags":["a"]}}
+{"id":2,"info":{"size":12,"tags":["b","c"]}}
+[{"id":3,"info":{"size":10}}]
+`))
+	gz.Close()
+
+	got, _, err := runSelect(t, opts, content)
+	if err != nil {
+		t.Fatalf("select: %s", err)
+	}
+	want := "{\"id\":2,\"tag\":\"b\"}\n{\"id\":3,\"tag\":null}\n"
+	if got != want {
+		t.Errorf("want %q got %q", want, got)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	for _, expr := range []string{
+		"SELECT FROM S3Object",
+		"SELECT * FROM table",
+		"SELECT name, COUNT(*) FROM S3Object",
+		"SELECT * FROM S3Object WHERE COUNT(*) > 1",
+		"SELECT * FROM S3Object LIMIT x",
+		"SELECT NOSUCHFUNC(name) FROM S3Object",
+	} {
+		if _, err := NewSelector(csvOptions(expr)); err == nil {
+			t.Errorf("%s: expect error", expr)
+		}
+	}
+
+	_, events, err := runSelect(t, csvOptions("SELECT name FROM S3Object WHERE CAST(name AS INT) = 1"), strings.NewReader(testCSV))
+	if ErrorCode(err) != ErrCastFailed.Error() {
+		t.Fatalf("expect CastFailed, got %v", err)
+	}
+	last := events[len(events)-1]
+	if last.headers[":message-type"] != "error" || last.headers[":error-code"] != "CastFailed" {
+		t.Errorf("expect error event, got %v", last.headers)
+	}
+}
+
+func TestLikeToRegexp(t *testing.T) {
+	cases := []struct {
+		pattern string
+		escape  rune
+		input   string
+		match   bool
+	}{
+		{"a%", 0, "abc", true},
+		{"a_c", 0, "abc", true},
+		{"a_c", 0, "abbc", false},
+		{"100!%", '!', "100%", true},
+		{"100!%", '!', "1000", false},
+		{"a.b", 0, "axb", false},
+	}
+	for _, c := range cases {
+		re, err := likeToRegexp(c.pattern, c.escape)
+		if err != nil {
+			t.Fatalf("%s: %s", c.pattern, err)
+		}
+		if re.MatchString(c.input) != c.match {
+			t.Errorf("%s LIKE %s: want %v", c.input, c.pattern, c.match)
+		}
+	}
+}
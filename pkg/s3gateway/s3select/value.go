@@ -0,0 +1,338 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3select
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+type tValueType int
+
+const (
+	typeNull = tValueType(iota)
+	typeBool
+	typeInt
+	typeFloat
+	typeString
+	// nested object or array of JSON records
+	typeJSON
+)
+
+type SValue struct {
+	typ tValueType
+	b   bool
+	i   int64
+	f   float64
+	s   string
+	j   interface{}
+}
+
+var nullValue = SValue{typ: typeNull}
+
+func newBool(b bool) SValue {
+	return SValue{typ: typeBool, b: b}
+}
+
+func newInt(i int64) SValue {
+	return SValue{typ: typeInt, i: i}
+}
+
+func newFloat(f float64) SValue {
+	return SValue{typ: typeFloat, f: f}
+}
+
+func newString(s string) SValue {
+	return SValue{typ: typeString, s: s}
+}
+
+// newJSONValue converts a value decoded by json.Decoder with UseNumber
+func newJSONValue(v interface{}) SValue {
+	switch val := v.(type) {
+	case nil:
+		return nullValue
+	case bool:
+		return newBool(val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return newInt(i)
+		}
+		f, _ := val.Float64()
+		return newFloat(f)
+	case string:
+		return newString(val)
+	default:
+		return SValue{typ: typeJSON, j: val}
+	}
+}
+
+func (v SValue) isNull() bool {
+	return v.typ == typeNull
+}
+
+func (v SValue) isNumber() bool {
+	return v.typ == typeInt || v.typ == typeFloat
+}
+
+func (v SValue) isTrue() bool {
+	return v.typ == typeBool && v.b
+}
+
+func (v SValue) toFloat() float64 {
+	if v.typ == typeInt {
+		return float64(v.i)
+	}
+	return v.f
+}
+
+// String returns the text of value written to CSV output
+func (v SValue) String() string {
+	switch v.typ {
+	case typeBool:
+		return strconv.FormatBool(v.b)
+	case typeInt:
+		return strconv.FormatInt(v.i, 10)
+	case typeFloat:
+		return strconv.FormatFloat(v.f, 'f', -1, 64)
+	case typeString:
+		return v.s
+	case typeJSON:
+		b, _ := json.Marshal(v.j)
+		return string(b)
+	default:
+		return ""
+	}
+}
+
+func (v SValue) MarshalJSON() ([]byte, error) {
+	switch v.typ {
+	case typeBool:
+		return json.Marshal(v.b)
+	case typeInt:
+		return json.Marshal(v.i)
+	case typeFloat:
+		if math.IsInf(v.f, 0) || math.IsNaN(v.f) {
+			return json.Marshal(v.String())
+		}
+		return json.Marshal(v.f)
+	case typeString:
+		return json.Marshal(v.s)
+	case typeJSON:
+		return json.Marshal(v.j)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// toNumber converts value to number, text of CSV records are parsed
+func (v SValue) toNumber() (SValue, bool) {
+	switch v.typ {
+	case typeInt, typeFloat:
+		return v, true
+	case typeString:
+		s := strings.TrimSpace(v.s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return newInt(i), true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return newFloat(f), true
+		}
+	}
+	return nullValue, false
+}
+
+func compareNumber(a, b SValue) int {
+	if a.typ == typeInt && b.typ == typeInt {
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	}
+	af, bf := a.toFloat(), b.toFloat()
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+// compareValues compares two non-null values, a number compared with a string
+// is compared numerically if the string is a number, as CSV fields are text
+func compareValues(a, b SValue) (int, error) {
+	if a.isNumber() || b.isNumber() {
+		an, aok := a.toNumber()
+		bn, bok := b.toNumber()
+		if aok && bok {
+			return compareNumber(an, bn), nil
+		}
+	}
+	if a.typ == typeBool || b.typ == typeBool {
+		ab, aok := a.toBool()
+		bb, bok := b.toBool()
+		if aok && bok {
+			switch {
+			case ab == bb:
+				return 0, nil
+			case !ab:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	}
+	if a.typ == typeString && b.typ == typeString {
+		return strings.Compare(a.s, b.s), nil
+	}
+	if a.typ == typeJSON || b.typ == typeJSON {
+		return strings.Compare(a.String(), b.String()), nil
+	}
+	return 0, errors.Wrapf(ErrInvalidDataType, "cannot compare %s with %s", a.typeName(), b.typeName())
+}
+
+func (v SValue) toBool() (bool, bool) {
+	switch v.typ {
+	case typeBool:
+		return v.b, true
+	case typeString:
+		b, err := strconv.ParseBool(strings.TrimSpace(v.s))
+		return b, err == nil
+	}
+	return false, false
+}
+
+func (v SValue) typeName() string {
+	switch v.typ {
+	case typeNull:
+		return "NULL"
+	case typeBool:
+		return "BOOL"
+	case typeInt:
+		return "INT"
+	case typeFloat:
+		return "FLOAT"
+	case typeString:
+		return "STRING"
+	default:
+		return "JSON"
+	}
+}
+
+func castValue(v SValue, typ string) (SValue, error) {
+	if v.isNull() {
+		return v, nil
+	}
+	switch typ {
+	case "INT", "INTEGER":
+		n, ok := v.toNumber()
+		if !ok {
+			if b, ok := v.toBool(); ok && v.typ == typeBool {
+				if b {
+					return newInt(1), nil
+				}
+				return newInt(0), nil
+			}
+			return nullValue, errors.Wrapf(ErrCastFailed, "cannot cast %q to %s", v.String(), typ)
+		}
+		if n.typ == typeFloat {
+			return newInt(int64(n.f)), nil
+		}
+		return n, nil
+	case "FLOAT", "DECIMAL", "NUMERIC", "REAL", "DOUBLE":
+		n, ok := v.toNumber()
+		if !ok {
+			return nullValue, errors.Wrapf(ErrCastFailed, "cannot cast %q to %s", v.String(), typ)
+		}
+		return newFloat(n.toFloat()), nil
+	case "STRING", "VARCHAR", "CHAR", "TEXT":
+		return newString(v.String()), nil
+	case "BOOL", "BOOLEAN":
+		if v.isNumber() {
+			return newBool(v.toFloat() != 0), nil
+		}
+		b, ok := v.toBool()
+		if !ok {
+			return nullValue, errors.Wrapf(ErrCastFailed, "cannot cast %q to %s", v.String(), typ)
+		}
+		return newBool(b), nil
+	default:
+		return nullValue, errors.Wrapf(ErrUnsupportedSyntax, "unsupported cast type %s", typ)
+	}
+}
+
+func arithmetic(op string, a, b SValue) (SValue, error) {
+	if a.isNull() || b.isNull() {
+		return nullValue, nil
+	}
+	an, aok := a.toNumber()
+	bn, bok := b.toNumber()
+	if !aok || !bok {
+		return nullValue, errors.Wrapf(ErrInvalidDataType, "%s %s %s: operands should be numbers", a.String(), op, b.String())
+	}
+	if an.typ == typeInt && bn.typ == typeInt {
+		x, y := an.i, bn.i
+		switch op {
+		case "+":
+			return newInt(x + y), nil
+		case "-":
+			return newInt(x - y), nil
+		case "*":
+			return newInt(x * y), nil
+		case "/":
+			if y == 0 {
+				return nullValue, ErrDivisionByZero
+			}
+			return newInt(x / y), nil
+		case "%":
+			if y == 0 {
+				return nullValue, ErrDivisionByZero
+			}
+			return newInt(x % y), nil
+		}
+	}
+	x, y := an.toFloat(), bn.toFloat()
+	switch op {
+	case "+":
+		return newFloat(x + y), nil
+	case "-":
+		return newFloat(x - y), nil
+	case "*":
+		return newFloat(x * y), nil
+	case "/":
+		if y == 0 {
+			return nullValue, ErrDivisionByZero
+		}
+		return newFloat(x / y), nil
+	case "%":
+		if y == 0 {
+			return nullValue, ErrDivisionByZero
+		}
+		return newFloat(math.Mod(x, y)), nil
+	}
+	return nullValue, errors.Wrapf(ErrUnsupportedSyntax, "operator %s", op)
+}
+
+func (v SValue) GoString() string {
+	return fmt.Sprintf("%s(%s)", v.typeName(), v.String())
+}
@@ -77,6 +77,9 @@ type SScheduledTask struct {
 	ResourceType string `width:"32" charset:"ascii" create:"required" list:"user" get:"user"`
 	Operation    string `width:"32" charset:"ascii" create:"required" list:"user" get:"user"`
 	LabelType    string `width:"4" charset:"ascii" create:"required" list:"user" get:"user"`
+
+	// 黑名单日历, 落在其中的执行会被跳过
+	CalendarId string `width:"36" charset:"ascii" nullable:"true" list:"user" create:"optional"`
 }
 
 func (stm *SScheduledTaskManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.ScheduledTaskListInput) (*sqlchemy.SQuery, error) {
@@ -141,7 +144,9 @@ func (st *SScheduledTask) getMoreDetails(ctx context.Context, userCred mcclient.
 	case api.ST_TYPE_CYCLE:
 		out.CycleTimer = st.STimer.CycleTimerDetails()
 	}
-	out.TimerDesc = st.Description(ctx, st.CreatedAt, zone)
+	if st.ScheduledType != api.ST_TYPE_DEPENDENT {
+		out.TimerDesc = st.Description(ctx, st.CreatedAt, zone)
+	}
 	// fill label
 	stLabels, err := st.STLabels()
 	if err != nil {
@@ -154,6 +159,24 @@ func (st *SScheduledTask) getMoreDetails(ctx context.Context, userCred mcclient.
 		out.LabelDetails[i].IsolatedTime = stLabels[i].CreatedAt
 		out.LabelDetails[i].Label = stLabels[i].Label
 	}
+	// fill dependencies
+	deps, err := ScheduledTaskDependencyManager.fetchByTask(st.Id)
+	if err != nil {
+		return out, err
+	}
+	out.DependsOn = make([]api.ScheduledTaskDependencyDetail, len(deps))
+	for i := range deps {
+		out.DependsOn[i].Id = deps[i].DependsOnId
+		out.DependsOn[i].Condition = deps[i].Condition
+		if upstream, _ := ScheduledTaskManager.FetchById(deps[i].DependsOnId); upstream != nil {
+			out.DependsOn[i].Name = upstream.GetName()
+		}
+	}
+	if len(st.CalendarId) > 0 {
+		if calendar, _ := ScheduledTaskCalendarManager.FetchById(st.CalendarId); calendar != nil {
+			out.Calendar = calendar.GetName()
+		}
+	}
 	return out, nil
 }
 
@@ -163,7 +186,7 @@ func (stm *SScheduledTaskManager) ValidateCreateData(ctx context.Context, userCr
 	if err != nil {
 		return input, err
 	}
-	if !utils.IsInStringArray(input.ScheduledType, []string{api.ST_TYPE_TIMING, api.ST_TYPE_CYCLE}) {
+	if !utils.IsInStringArray(input.ScheduledType, []string{api.ST_TYPE_TIMING, api.ST_TYPE_CYCLE, api.ST_TYPE_DEPENDENT}) {
 		return input, httperrors.NewInputParameterError("unkown scheduled type '%s'", input.ScheduledType)
 	}
 	if !utils.IsInStringArray(input.ResourceType, []string{api.ST_RESOURCE_SERVER, api.ST_RESOURCE_CLOUDACCOUNT}) {
@@ -176,14 +199,37 @@ func (stm *SScheduledTaskManager) ValidateCreateData(ctx context.Context, userCr
 		return input, httperrors.NewInputParameterError("unkown label type '%s'", input.LabelType)
 	}
 	// check timer or cycletimer
-	if input.ScheduledType == api.ST_TYPE_TIMING {
+	switch input.ScheduledType {
+	case api.ST_TYPE_TIMING:
 		input.Timer, err = checkTimerCreateInput(input.Timer)
-	} else {
+	case api.ST_TYPE_CYCLE:
 		input.CycleTimer, err = checkCycleTimerCreateInput(input.CycleTimer)
+	case api.ST_TYPE_DEPENDENT:
+		if len(input.DependsOn) == 0 {
+			return input, httperrors.NewMissingParameterError("depends_on")
+		}
 	}
 	if err != nil {
 		return input, httperrors.NewInputParameterError("%v", err)
 	}
+	input.DependsOn, err = stm.fetchUpstreamTasks(ctx, userCred, input.DependsOn)
+	if err != nil {
+		return input, err
+	}
+	input.DependencyCondition, err = validateDependencyCondition(input.DependencyCondition)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Calendar) > 0 {
+		calendar, err := ScheduledTaskCalendarManager.FetchByIdOrName(ctx, userCred, input.Calendar)
+		if err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				return input, httperrors.NewResourceNotFoundError2(ScheduledTaskCalendarManager.Keyword(), input.Calendar)
+			}
+			return input, err
+		}
+		input.CalendarId = calendar.GetId()
+	}
 	return input, nil
 }
 
@@ -228,6 +274,13 @@ func (st *SScheduledTask) PostCreate(ctx context.Context, userCred mcclient.Toke
 		createFailed(err.Error())
 		return
 	}
+	if len(input.DependsOn) > 0 {
+		err := ScheduledTaskDependencyManager.SetDependencies(ctx, userCred, st, input.DependsOn, input.DependencyCondition)
+		if err != nil {
+			createFailed(err.Error())
+			return
+		}
+	}
 	switch st.ScheduledType {
 	case api.ST_TYPE_TIMING:
 		st.STimer = STimer{
@@ -249,7 +302,9 @@ func (st *SScheduledTask) PostCreate(ctx context.Context, userCred mcclient.Toke
 		st.SetWeekDays(input.CycleTimer.WeekDays)
 		st.SetMonthDays(input.CycleTimer.MonthDays)
 	}
-	st.Update(time.Time{})
+	if st.ScheduledType != api.ST_TYPE_DEPENDENT {
+		st.Update(time.Time{})
+	}
 	st.Status = api.ST_STATUS_READY
 	st.Enabled = tristate.True
 	// st.TimerDesc = st.Description(ctx)
@@ -343,6 +398,60 @@ func (st *SScheduledTask) PerformSetLabels(ctx context.Context, userCred mcclien
 	return nil, nil
 }
 
+func (st *SScheduledTask) PerformSetDependencies(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ScheduledTaskSetDependenciesInput) (jsonutils.JSONObject, error) {
+	if st.ScheduledType == api.ST_TYPE_DEPENDENT && len(input.DependsOn) == 0 {
+		return nil, httperrors.NewInputParameterError("scheduled task of type %s should depend on at least one task", api.ST_TYPE_DEPENDENT)
+	}
+	dependsOn, err := ScheduledTaskManager.fetchUpstreamTasks(ctx, userCred, input.DependsOn)
+	if err != nil {
+		return nil, err
+	}
+	condition, err := validateDependencyCondition(input.Condition)
+	if err != nil {
+		return nil, err
+	}
+	err = ScheduledTaskDependencyManager.SetDependencies(ctx, userCred, st, dependsOn, condition)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (st *SScheduledTask) PerformSetCalendar(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ScheduledTaskSetCalendarInput) (jsonutils.JSONObject, error) {
+	calendarId := ""
+	if len(input.Calendar) > 0 {
+		calendar, err := ScheduledTaskCalendarManager.FetchByIdOrName(ctx, userCred, input.Calendar)
+		if err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				return nil, httperrors.NewResourceNotFoundError2(ScheduledTaskCalendarManager.Keyword(), input.Calendar)
+			}
+			return nil, err
+		}
+		calendarId = calendar.GetId()
+	}
+	_, err := db.Update(st, func() error {
+		st.CalendarId = calendarId
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// blackedOut checks the calendar of the task at t
+func (st *SScheduledTask) blackedOut(t time.Time) (bool, string) {
+	if len(st.CalendarId) == 0 {
+		return false, ""
+	}
+	calendar, err := ScheduledTaskCalendarManager.fetchById(st.CalendarId)
+	if err != nil {
+		log.Errorf("scheduled task %s: %v", st.Name, err)
+		return false, ""
+	}
+	return calendar.IsBlackedOut(t)
+}
+
 func (st *SScheduledTask) PerformTrigger(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ScheduledTaskTriggerInput) (jsonutils.JSONObject, error) {
 	go func() {
 		log.Infof("start to execute scheduled task '%s'", st.Id)
@@ -371,6 +480,7 @@ func (st *SScheduledTask) CustomizeDelete(ctx context.Context, userCred mcclient
 			log.Errorf("unbale to delete scheduled task label: %s", err.Error())
 		}
 	}
+	ScheduledTaskDependencyManager.RemoveTask(ctx, userCred, st.Id)
 	return nil
 }
 
@@ -403,6 +513,12 @@ func (st *SScheduledTask) Execute(ctx context.Context, userCred mcclient.TokenCr
 	if err != nil {
 		return err
 	}
+	return st.executeActivity(ctx, userCred, sa)
+}
+
+func (st *SScheduledTask) executeActivity(ctx context.Context, userCred mcclient.TokenCredential, sa *SScheduledTaskActivity) (err error) {
+	// runs after the result of sa is recorded
+	defer st.runDependents(ctx, userCred)
 	over := false
 	defer func() {
 		if !over && err != nil {
@@ -450,6 +566,21 @@ func (st *SScheduledTask) Execute(ctx context.Context, userCred mcclient.TokenCr
 	for id := range res {
 		ids = append(ids, id)
 	}
+	if st.ScheduledType == api.ST_TYPE_DEPENDENT {
+		upstreams, err := st.upstreamResources()
+		if err != nil {
+			return errors.Wrap(err, "fetch resources of upstreams")
+		}
+		ids = filterUpstreamResources(ids, upstreams)
+		if len(ids) == 0 {
+			over = true
+			return sa.SetResult(api.ST_ACTIVITY_STATUS_SKIP, fmt.Sprintf("No %s succeeded in upstream scheduled tasks", st.ResourceType))
+		}
+	}
+	limits, err := ScheduledTaskLabelConcurrencyManager.Limits(labels)
+	if err != nil {
+		return errors.Wrap(err, "fetch label concurrency limits")
+	}
 
 	maxLimit := 20
 	type result struct {
@@ -460,10 +591,18 @@ func (st *SScheduledTask) Execute(ctx context.Context, userCred mcclient.TokenCr
 	workerQueue := make(chan struct{}, maxLimit)
 	results := make([]result, len(ids))
 	log.Infof("servers to scheduledtask: %v", ids)
+	limiter := getLabelConcurrencyLimiter()
 	for i, id := range ids {
 		workerQueue <- struct{}{}
 		go func(n int, id string) {
+			holder, err := limiter.Acquire(limits)
+			if err != nil {
+				results[n] = result{id, false, err.Error()}
+				<-workerQueue
+				return
+			}
 			ok, reason := action.Apply(id)
+			limiter.Release(holder)
 			log.Infof("exec successfully: %t, reason: %s", ok, reason)
 			if ok {
 				st.ExecuteNotify(ctx, userCred, res[id])
@@ -478,16 +617,18 @@ func (st *SScheduledTask) Execute(ctx context.Context, userCred mcclient.TokenCr
 	}
 	failedReasons := make([]string, 0, 1)
 	succeedIds := make([]string, 0, 1)
+	succeedResIds := make([]string, 0, 1)
 	displayStrs := res
 	for _, ret := range results {
 		if ret.succeed {
 			succeedIds = append(succeedIds, displayStrs[ret.id])
+			succeedResIds = append(succeedResIds, ret.id)
 			continue
 		}
 		failedReasons = append(failedReasons, fmt.Sprintf("\t%s: %s", displayStrs[ret.id], ret.reason))
 	}
 	if len(failedReasons) == 0 {
-		sa.Succeed()
+		sa.Succeed(succeedResIds)
 		return nil
 	}
 	if len(failedReasons) == len(ids) {
@@ -496,7 +637,7 @@ func (st *SScheduledTask) Execute(ctx context.Context, userCred mcclient.TokenCr
 		return nil
 	}
 	reason := fmt.Sprintf("Some %ss %s successfully:\n\t%s\n\n. Some %ss %s failed:\n%s", st.ResourceType, st.Operation, strings.Join(succeedIds, ";"), st.ResourceType, st.Operation, strings.Join(failedReasons, ";\n"))
-	sa.PartFail(reason)
+	sa.PartFail(reason, succeedResIds)
 	return nil
 }
 
@@ -520,6 +661,168 @@ func (st *SScheduledTask) NewActivity(ctx context.Context, reject bool) (*SSched
 	return sa, nil
 }
 
+func (st *SScheduledTask) NewSkippedActivity(ctx context.Context, reason string) (*SScheduledTaskActivity, error) {
+	now := time.Now()
+	sa := &SScheduledTaskActivity{
+		StartTime: now,
+		EndTime:   now,
+		Reason:    reason,
+	}
+	sa.Status = api.ST_ACTIVITY_STATUS_SKIP
+	sa.ScheduledTaskId = st.Id
+	err := ScheduledTaskActivityManager.TableSpec().Insert(ctx, sa)
+	if err != nil {
+		return nil, err
+	}
+	sa.SetModelManager(ScheduledTaskActivityManager, sa)
+	return sa, nil
+}
+
+// lastActivity returns the latest activity of the task in one of statuses,
+// or nil if there is none
+func (st *SScheduledTask) lastActivity(statuses []string) (*SScheduledTaskActivity, error) {
+	q := ScheduledTaskActivityManager.Query().Equals("scheduled_task_id", st.Id)
+	if len(statuses) > 0 {
+		q = q.In("status", statuses)
+	}
+	q = q.Desc("start_time").Limit(1)
+	sas := make([]SScheduledTaskActivity, 0, 1)
+	err := db.FetchModelObjects(ScheduledTaskActivityManager, q, &sas)
+	if err != nil {
+		return nil, err
+	}
+	if len(sas) == 0 {
+		return nil, nil
+	}
+	return &sas[0], nil
+}
+
+// upstreamsState checks whether every task st depends on has finished a run
+// meeting its condition since the last run of st
+func (st *SScheduledTask) upstreamsState() (upstreamState, string, error) {
+	deps, err := ScheduledTaskDependencyManager.fetchByTask(st.Id)
+	if err != nil {
+		return upstreamsPending, "", err
+	}
+	last, err := st.lastActivity(nil)
+	if err != nil {
+		return upstreamsPending, "", err
+	}
+	finished := []string{
+		api.ST_ACTIVITY_STATUS_SUCCEED,
+		api.ST_ACTIVITY_STATUS_PART_SUCCEED,
+		api.ST_ACTIVITY_STATUS_FAILED,
+		api.ST_ACTIVITY_STATUS_SKIP,
+	}
+	return checkUpstreams(deps, last, func(id string) (*SScheduledTaskActivity, error) {
+		obj, err := ScheduledTaskManager.FetchById(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch upstream %s", id)
+		}
+		return obj.(*SScheduledTask).lastActivity(finished)
+	})
+}
+
+// upstreamResources returns the resources the latest finished runs of the
+// upstreams operating the same type of resources as st succeeded on
+func (st *SScheduledTask) upstreamResources() ([]sUpstreamResources, error) {
+	deps, err := ScheduledTaskDependencyManager.fetchByTask(st.Id)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]sUpstreamResources, 0, len(deps))
+	for i := range deps {
+		obj, err := ScheduledTaskManager.FetchById(deps[i].DependsOnId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch upstream %s", deps[i].DependsOnId)
+		}
+		upstream := obj.(*SScheduledTask)
+		if upstream.ResourceType != st.ResourceType {
+			continue
+		}
+		sa, err := upstream.lastActivity([]string{
+			api.ST_ACTIVITY_STATUS_SUCCEED,
+			api.ST_ACTIVITY_STATUS_PART_SUCCEED,
+			api.ST_ACTIVITY_STATUS_FAILED,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ur := sUpstreamResources{condition: deps[i].Condition}
+		if sa != nil {
+			ur.succeed = sa.getSucceedResources()
+		}
+		ret = append(ret, ur)
+	}
+	return ret, nil
+}
+
+// runDependents triggers the downstream tasks whose upstream tasks are all
+// ready, so that a dependent task runs once per round of its upstreams.
+// Skipped runs are passed down to the dependents of downstream tasks
+func (st *SScheduledTask) runDependents(ctx context.Context, userCred mcclient.TokenCredential) {
+	deps, err := ScheduledTaskDependencyManager.fetchByUpstream(st.Id)
+	if err != nil {
+		log.Errorf("fetch dependents of scheduled task %s: %v", st.Id, err)
+		return
+	}
+	for i := range deps {
+		obj, err := ScheduledTaskManager.FetchById(deps[i].ScheduledTaskId)
+		if err != nil {
+			log.Errorf("fetch dependent scheduled task %s: %v", deps[i].ScheduledTaskId, err)
+			continue
+		}
+		downstream := obj.(*SScheduledTask)
+		if !downstream.GetEnabled() || downstream.Status != api.ST_STATUS_READY {
+			continue
+		}
+		sa, skipped, err := downstream.prepareDependentRun(ctx)
+		if err != nil {
+			log.Errorf("prepare dependent scheduled task %s: %v", downstream.Id, err)
+			continue
+		}
+		if skipped {
+			log.Infof("skip dependent scheduled task '%s' after '%s'", downstream.Id, st.Id)
+			downstream.runDependents(ctx, userCred)
+			continue
+		}
+		if sa == nil {
+			continue
+		}
+		go func() {
+			log.Infof("start to execute dependent scheduled task '%s' after '%s'", downstream.Id, st.Id)
+			err := downstream.executeActivity(ctx, userCred, sa)
+			if err != nil {
+				log.Errorf("fail to execute scheduled task '%s': %s", downstream.Id, err.Error())
+			}
+		}()
+	}
+}
+
+// prepareDependentRun checks the upstreams and creates the activity of the
+// run under the fan-in lock of st, see sDependentRun
+func (st *SScheduledTask) prepareDependentRun(ctx context.Context) (*SScheduledTaskActivity, bool, error) {
+	r := &sDependentRun{
+		lock:      newTriggerLock(st.Id),
+		upstreams: st.upstreamsState,
+		blackedOut: func() (bool, string) {
+			return st.blackedOut(time.Now())
+		},
+		executing: st.IsExecuted,
+		record: func(status, reason string) (*SScheduledTaskActivity, error) {
+			switch status {
+			case api.ST_ACTIVITY_STATUS_SKIP:
+				return st.NewSkippedActivity(ctx, reason)
+			case api.ST_ACTIVITY_STATUS_REJECT:
+				return st.NewActivity(ctx, true)
+			default:
+				return st.NewActivity(ctx, false)
+			}
+		},
+	}
+	return r.prepare()
+}
+
 func (st *SScheduledTask) ResourceOperation() ResourceOperation {
 	return ResourceOperationMap[fmt.Sprintf("%s.%s", st.ResourceType, st.Operation)]
 }
@@ -550,6 +853,7 @@ func (stm *SScheduledTaskManager) Timer(ctx context.Context, userCred mcclient.T
 	interval := 60 + 30
 	timeScope := stm.timeScope(time.Now(), time.Duration(interval)*time.Second)
 	q := stm.Query().Equals("status", api.ST_STATUS_READY).Equals("enabled", true).LT("next_time", timeScope.End).IsFalse("is_expired")
+	q = q.NotEquals("scheduled_type", api.ST_TYPE_DEPENDENT)
 	sts := make([]SScheduledTask, 0, 5)
 	err := db.FetchModelObjects(stm, q, &sts)
 	if err != nil {
@@ -590,12 +894,22 @@ func (stm *SScheduledTaskManager) Timer(ctx context.Context, userCred mcclient.T
 					return
 				}
 			}
-			err := st.Execute(ctx, userCred)
-			if err != nil {
-				log.Errorf("unable to execute scheduled task '%s'", st.Id)
+			if blackedOut, reason := st.blackedOut(timeScope.Median); blackedOut {
+				log.Infof("skip scheduled task '%s': %s", st.Id, reason)
+				_, err := st.NewSkippedActivity(ctx, reason)
+				if err != nil {
+					log.Errorf("unable to record skipped activity of scheduled task '%s': %v", st.Id, err)
+				} else {
+					st.runDependents(ctx, userCred)
+				}
+			} else {
+				err := st.Execute(ctx, userCred)
+				if err != nil {
+					log.Errorf("unable to execute scheduled task '%s'", st.Id)
+				}
 			}
 			st.Update(timeScope.End)
-			err = stm.TableSpec().InsertOrUpdate(ctx, &st)
+			err := stm.TableSpec().InsertOrUpdate(ctx, &st)
 			if err != nil {
 				log.Errorf("update Scheduled task whose id is %s error: %s", st.Id, err.Error())
 			}
@@ -55,6 +55,9 @@ type SScheduledTaskActivity struct {
 	StartTime       time.Time `list:"user"`
 	EndTime         time.Time `list:"user"`
 	Reason          string    `charset:"utf8" list:"user"`
+	// ids of resources operated successfully, dependent tasks operate only
+	// these resources
+	SucceedResources *jsonutils.JSONArray `nullable:"true" list:"user"`
 }
 
 func (sam *SScheduledTaskActivityManager) InitializeData() error {
@@ -160,10 +163,34 @@ func (sa *SScheduledTaskActivity) Fail(reason string) error {
 	return sa.SetResult(api.ST_ACTIVITY_STATUS_FAILED, reason)
 }
 
-func (sa *SScheduledTaskActivity) Succeed() error {
-	return sa.SetResult(api.ST_ACTIVITY_STATUS_SUCCEED, "")
+func (sa *SScheduledTaskActivity) Succeed(resIds []string) error {
+	return sa.setResultWithResources(api.ST_ACTIVITY_STATUS_SUCCEED, "", resIds)
 }
 
-func (sa *SScheduledTaskActivity) PartFail(reason string) error {
-	return sa.SetResult(api.ST_ACTIVITY_STATUS_PART_SUCCEED, reason)
+func (sa *SScheduledTaskActivity) PartFail(reason string, succeedResIds []string) error {
+	return sa.setResultWithResources(api.ST_ACTIVITY_STATUS_PART_SUCCEED, reason, succeedResIds)
+}
+
+func (sa *SScheduledTaskActivity) setResultWithResources(status, reason string, succeedResIds []string) error {
+	_, err := db.Update(sa, func() error {
+		sa.Status = status
+		sa.Reason = reason
+		sa.EndTime = time.Now()
+		sa.SucceedResources = jsonutils.NewStringArray(succeedResIds)
+		return nil
+	})
+	return err
+}
+
+func (sa *SScheduledTaskActivity) getSucceedResources() []string {
+	if sa.SucceedResources == nil {
+		return nil
+	}
+	ids, _ := sa.SucceedResources.GetArray()
+	ret := make([]string, 0, len(ids))
+	for i := range ids {
+		id, _ := ids[i].GetString()
+		ret = append(ret, id)
+	}
+	return ret
 }
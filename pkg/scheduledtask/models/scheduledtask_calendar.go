@@ -0,0 +1,199 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/scheduledtask"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const calendarDateFormat = "2006-01-02"
+
+var ScheduledTaskCalendarManager *SScheduledTaskCalendarManager
+
+func init() {
+	ScheduledTaskCalendarManager = &SScheduledTaskCalendarManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SScheduledTaskCalendar{},
+			"scheduledtaskcalendars_tbl",
+			"scheduledtaskcalendar",
+			"scheduledtaskcalendars",
+		),
+	}
+	ScheduledTaskCalendarManager.SetVirtualObject(ScheduledTaskCalendarManager)
+}
+
+// +onecloud:swagger-gen-model-singular=scheduledtaskcalendar
+// +onecloud:swagger-gen-model-singular=scheduledtaskcalendars
+type SScheduledTaskCalendarManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+// SScheduledTaskCalendar is a holiday or blackout calendar, runs of scheduled
+// tasks falling in it are skipped
+type SScheduledTaskCalendar struct {
+	db.SVirtualResourceBase
+
+	// 日期所在时区
+	Timezone string `width:"64" charset:"ascii" nullable:"false" default:"UTC" list:"user" create:"optional" update:"user"`
+	// 跳过的日期, 格式为2006-01-02
+	Dates jsonutils.JSONObject `length:"medium" list:"user" create:"optional" update:"user"`
+	// 跳过的时间段
+	Periods jsonutils.JSONObject `length:"medium" list:"user" create:"optional" update:"user"`
+}
+
+func validateCalendar(timezone string, dates []string, periods []api.CalendarPeriod) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return httperrors.NewInputParameterError("invalid timezone %q: %v", timezone, err)
+	}
+	for _, date := range dates {
+		if _, err := time.Parse(calendarDateFormat, date); err != nil {
+			return httperrors.NewInputParameterError("invalid date %q, expect format %s", date, calendarDateFormat)
+		}
+	}
+	for _, period := range periods {
+		if !period.StartTime.Before(period.EndTime) {
+			return httperrors.NewInputParameterError("period start time %s is not before end time %s", period.StartTime, period.EndTime)
+		}
+	}
+	return nil
+}
+
+func (cm *SScheduledTaskCalendarManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ScheduledTaskCalendarCreateInput) (api.ScheduledTaskCalendarCreateInput, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = cm.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.Timezone) == 0 {
+		input.Timezone = "UTC"
+	}
+	if err := validateCalendar(input.Timezone, input.Dates, input.Periods); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (c *SScheduledTaskCalendar) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ScheduledTaskCalendarUpdateInput) (api.ScheduledTaskCalendarUpdateInput, error) {
+	var err error
+	input.VirtualResourceBaseUpdateInput, err = c.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	timezone := input.Timezone
+	if len(timezone) == 0 {
+		timezone = c.Timezone
+	}
+	if err := validateCalendar(timezone, input.Dates, input.Periods); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (cm *SScheduledTaskCalendarManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.ScheduledTaskCalendarListInput) (*sqlchemy.SQuery, error) {
+	return cm.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.VirtualResourceListInput)
+}
+
+func (cm *SScheduledTaskCalendarManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.ScheduledTaskCalendarListInput) (*sqlchemy.SQuery, error) {
+	return cm.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.VirtualResourceListInput)
+}
+
+func (cm *SScheduledTaskCalendarManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ScheduledTaskCalendarDetails {
+	rows := make([]api.ScheduledTaskCalendarDetails, len(objs))
+	virRows := cm.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].VirtualResourceDetails = virRows[i]
+	}
+	return rows
+}
+
+func (c *SScheduledTaskCalendar) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	n, err := ScheduledTaskManager.Query().Equals("calendar_id", c.Id).CountWithError()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return httperrors.NewNotEmptyError("calendar is used by %d scheduled tasks", n)
+	}
+	return c.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (c *SScheduledTaskCalendar) getDates() []string {
+	dates := []string{}
+	if c.Dates != nil {
+		if err := c.Dates.Unmarshal(&dates); err != nil {
+			log.Errorf("calendar %s: unmarshal dates: %v", c.Name, err)
+		}
+	}
+	return dates
+}
+
+func (c *SScheduledTaskCalendar) getPeriods() []api.CalendarPeriod {
+	periods := []api.CalendarPeriod{}
+	if c.Periods != nil {
+		if err := c.Periods.Unmarshal(&periods); err != nil {
+			log.Errorf("calendar %s: unmarshal periods: %v", c.Name, err)
+		}
+	}
+	return periods
+}
+
+// IsBlackedOut returns whether t falls in the dates or periods of the
+// calendar, and the reason if so
+func (c *SScheduledTaskCalendar) IsBlackedOut(t time.Time) (bool, string) {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day := t.In(loc).Format(calendarDateFormat)
+	for _, date := range c.getDates() {
+		if date == day {
+			return true, fmt.Sprintf("%s is blacked out by calendar %s", date, c.Name)
+		}
+	}
+	for _, period := range c.getPeriods() {
+		if !t.Before(period.StartTime) && t.Before(period.EndTime) {
+			return true, fmt.Sprintf("%s is in blackout period %s - %s of calendar %s", t.Format(time.RFC3339), period.StartTime.Format(time.RFC3339), period.EndTime.Format(time.RFC3339), c.Name)
+		}
+	}
+	return false, ""
+}
+
+func (cm *SScheduledTaskCalendarManager) fetchById(id string) (*SScheduledTaskCalendar, error) {
+	obj, err := cm.FetchById(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch calendar %s", id)
+	}
+	return obj.(*SScheduledTaskCalendar), nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/scheduledtask"
+)
+
+func TestCalendarIsBlackedOut(t *testing.T) {
+	c := &SScheduledTaskCalendar{
+		Timezone: "Asia/Shanghai",
+		Dates:    jsonutils.Marshal([]string{"2023-01-02"}),
+		Periods: jsonutils.Marshal([]api.CalendarPeriod{
+			{
+				StartTime: time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC),
+			},
+		}),
+	}
+	c.Name = "holidays"
+	cases := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		// 2023-01-02 00:30 in Asia/Shanghai
+		{"date in calendar timezone", time.Date(2023, 1, 1, 16, 30, 0, 0, time.UTC), true},
+		// 2023-01-01 23:30 in Asia/Shanghai
+		{"day before in calendar timezone", time.Date(2023, 1, 1, 15, 30, 0, 0, time.UTC), false},
+		{"period start", time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC), true},
+		{"in period", time.Date(2023, 2, 1, 11, 0, 0, 0, time.UTC), true},
+		{"period end", time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC), false},
+		{"other day", time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC), false},
+	}
+	for _, tc := range cases {
+		got, reason := c.IsBlackedOut(tc.t)
+		if got != tc.want {
+			t.Errorf("%s: IsBlackedOut(%s) = %v, want %v", tc.name, tc.t, got, tc.want)
+		}
+		if got && reason == "" {
+			t.Errorf("%s: blacked out without reason", tc.name)
+		}
+	}
+
+	empty := &SScheduledTaskCalendar{Timezone: "UTC"}
+	if got, _ := empty.IsBlackedOut(time.Now()); got {
+		t.Errorf("empty calendar should not black out")
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/scheduledtask"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+var ScheduledTaskDependencyManager *SScheduledTaskDependencyManager
+
+func init() {
+	db.InitManager(func() {
+		ScheduledTaskDependencyManager = &SScheduledTaskDependencyManager{
+			SResourceBaseManager: db.NewResourceBaseManager(
+				SScheduledTaskDependency{},
+				"scheduledtaskdependencies_tbl",
+				"scheduledtaskdependency",
+				"scheduledtaskdependencies",
+			),
+		}
+	})
+}
+
+type SScheduledTaskDependencyManager struct {
+	db.SResourceBaseManager
+}
+
+// SScheduledTaskDependency means ScheduledTaskId runs after DependsOnId
+// finishes and meets Condition
+type SScheduledTaskDependency struct {
+	db.SResourceBase
+	ScheduledTaskId string `width:"36" charset:"ascii" nullable:"false" primary:"true"`
+	DependsOnId     string `width:"36" charset:"ascii" nullable:"false" primary:"true"`
+	Condition       string `width:"16" charset:"ascii" nullable:"false" default:"succeed"`
+}
+
+func (sdm *SScheduledTaskDependencyManager) fetchByTask(taskId string) ([]SScheduledTaskDependency, error) {
+	q := sdm.Query().Equals("scheduled_task_id", taskId)
+	deps := make([]SScheduledTaskDependency, 0, 1)
+	err := db.FetchModelObjects(sdm, q, &deps)
+	return deps, err
+}
+
+func (sdm *SScheduledTaskDependencyManager) fetchByUpstream(taskId string) ([]SScheduledTaskDependency, error) {
+	q := sdm.Query().Equals("depends_on_id", taskId)
+	deps := make([]SScheduledTaskDependency, 0, 1)
+	err := db.FetchModelObjects(sdm, q, &deps)
+	return deps, err
+}
+
+// reachable reports whether target can be reached from the upstream
+// chain of taskId
+func (sdm *SScheduledTaskDependencyManager) fetchUpstreamIds(taskId string) ([]string, error) {
+	deps, err := sdm.fetchByTask(taskId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(deps))
+	for i := range deps {
+		ids[i] = deps[i].DependsOnId
+	}
+	return ids, nil
+}
+
+// reachable reports whether target is taskId or one of its upstreams,
+// directly or not
+func reachable(upstreams func(taskId string) ([]string, error), taskId, target string, visited map[string]bool) (bool, error) {
+	if taskId == target {
+		return true, nil
+	}
+	if visited[taskId] {
+		return false, nil
+	}
+	visited[taskId] = true
+	ids, err := upstreams(taskId)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		ok, err := reachable(upstreams, id, target, visited)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func validateDependencyCondition(condition string) (string, error) {
+	if len(condition) == 0 {
+		return api.ST_DEPENDENCY_CONDITION_SUCCEED, nil
+	}
+	if !utils.IsInStringArray(condition, []string{api.ST_DEPENDENCY_CONDITION_SUCCEED, api.ST_DEPENDENCY_CONDITION_COMPLETE}) {
+		return "", httperrors.NewInputParameterError("unkown dependency condition '%s'", condition)
+	}
+	return condition, nil
+}
+
+func (stm *SScheduledTaskManager) fetchUpstreamTasks(ctx context.Context, userCred mcclient.TokenCredential, idOrNames []string) ([]string, error) {
+	ids := make([]string, 0, len(idOrNames))
+	for _, idOrName := range idOrNames {
+		obj, err := stm.FetchByIdOrName(ctx, userCred, idOrName)
+		if err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				return nil, httperrors.NewResourceNotFoundError2(stm.Keyword(), idOrName)
+			}
+			return nil, err
+		}
+		if !utils.IsInStringArray(obj.GetId(), ids) {
+			ids = append(ids, obj.GetId())
+		}
+	}
+	return ids, nil
+}
+
+// SetDependencies replaces the upstream tasks of st with dependsOn, which
+// are ids of scheduled tasks
+func (sdm *SScheduledTaskDependencyManager) SetDependencies(ctx context.Context, userCred mcclient.TokenCredential, st *SScheduledTask, dependsOn []string, condition string) error {
+	for _, id := range dependsOn {
+		ok, err := reachable(sdm.fetchUpstreamIds, id, st.Id, map[string]bool{})
+		if err != nil {
+			return errors.Wrap(err, "check dependency cycle")
+		}
+		if ok {
+			return httperrors.NewInputParameterError("scheduled task %s depending on %s would make a cycle", st.Name, id)
+		}
+	}
+	deps, err := sdm.fetchByTask(st.Id)
+	if err != nil {
+		return err
+	}
+	for i := range deps {
+		if utils.IsInStringArray(deps[i].DependsOnId, dependsOn) {
+			continue
+		}
+		err := deps[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "remove dependency on %s", deps[i].DependsOnId)
+		}
+	}
+	for _, id := range dependsOn {
+		dep := &SScheduledTaskDependency{
+			ScheduledTaskId: st.Id,
+			DependsOnId:     id,
+			Condition:       condition,
+		}
+		err := sdm.TableSpec().InsertOrUpdate(ctx, dep)
+		if err != nil {
+			return errors.Wrapf(err, "add dependency on %s", id)
+		}
+	}
+	return nil
+}
+
+// RemoveTask removes all dependencies from or to the task
+func (sdm *SScheduledTaskDependencyManager) RemoveTask(ctx context.Context, userCred mcclient.TokenCredential, taskId string) {
+	q := sdm.Query()
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.Equals(q.Field("scheduled_task_id"), taskId),
+		sqlchemy.Equals(q.Field("depends_on_id"), taskId),
+	))
+	deps := make([]SScheduledTaskDependency, 0, 1)
+	err := db.FetchModelObjects(sdm, q, &deps)
+	if err != nil {
+		log.Errorf("fetch dependencies of scheduled task %s: %v", taskId, err)
+		return
+	}
+	for i := range deps {
+		err := deps[i].Delete(ctx, userCred)
+		if err != nil {
+			log.Errorf("unable to delete scheduled task dependency: %v", err)
+		}
+	}
+}
+
+// conditionMet reports whether the status of an upstream activity meets the
+// dependency condition, skipped or rejected runs never trigger dependents.
+// A partly succeeded run meets succeed, the dependent task then operates
+// only the resources succeeded, see filterUpstreamResources
+func conditionMet(condition, status string) bool {
+	switch condition {
+	case api.ST_DEPENDENCY_CONDITION_COMPLETE:
+		return utils.IsInStringArray(status, []string{api.ST_ACTIVITY_STATUS_SUCCEED, api.ST_ACTIVITY_STATUS_PART_SUCCEED, api.ST_ACTIVITY_STATUS_FAILED})
+	default:
+		return utils.IsInStringArray(status, []string{api.ST_ACTIVITY_STATUS_SUCCEED, api.ST_ACTIVITY_STATUS_PART_SUCCEED})
+	}
+}
+
+// sUpstreamResources is the resources an upstream run succeeded on
+type sUpstreamResources struct {
+	condition string
+	succeed   []string
+}
+
+// filterUpstreamResources returns the ids of resources every upstream run of
+// succeed condition succeeded on, so that each resource goes down the chain
+// only when all steps before succeeded on it
+func filterUpstreamResources(ids []string, upstreams []sUpstreamResources) []string {
+	ret := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ok := true
+		for i := range upstreams {
+			if upstreams[i].condition != api.ST_DEPENDENCY_CONDITION_COMPLETE && !utils.IsInStringArray(id, upstreams[i].succeed) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+const (
+	// the lock is taken as leaked by a crashed service after the lease
+	triggerLockLease        = time.Minute
+	triggerLockPollInterval = 500 * time.Millisecond
+)
+
+type upstreamState int
+
+const (
+	// some upstream has not finished its run of the round yet
+	upstreamsPending upstreamState = iota
+	// all upstreams finished meeting their conditions
+	upstreamsReady
+	// all upstreams finished and some of them were skipped, so the round
+	// is skipped
+	upstreamsSkipped
+)
+
+// checkUpstreams checks the latest finished runs of the upstreams against
+// last, the latest run of the dependent task
+func checkUpstreams(deps []SScheduledTaskDependency, last *SScheduledTaskActivity, upstreamLast func(id string) (*SScheduledTaskActivity, error)) (upstreamState, string, error) {
+	if len(deps) == 0 {
+		return upstreamsPending, "", nil
+	}
+	var (
+		skipped []string
+		unmet   bool
+	)
+	for i := range deps {
+		sa, err := upstreamLast(deps[i].DependsOnId)
+		if err != nil {
+			return upstreamsPending, "", err
+		}
+		if sa == nil {
+			return upstreamsPending, "", nil
+		}
+		if last != nil && !sa.EndTime.After(last.StartTime) {
+			return upstreamsPending, "", nil
+		}
+		if sa.Status == api.ST_ACTIVITY_STATUS_SKIP {
+			skipped = append(skipped, deps[i].DependsOnId)
+		} else if !conditionMet(deps[i].Condition, sa.Status) {
+			unmet = true
+		}
+	}
+	if len(skipped) > 0 {
+		return upstreamsSkipped, fmt.Sprintf("upstream scheduled tasks %v are skipped", skipped), nil
+	}
+	if unmet {
+		return upstreamsPending, "", nil
+	}
+	return upstreamsReady, "", nil
+}
+
+// sDependentRun decides whether a dependent task runs when one of its
+// upstreams finishes.  The decision and the record of its activity are made
+// under lock shared by all services, so that a dependent task is triggered
+// only once when several upstream tasks finish at the same time
+type sDependentRun struct {
+	lock       sync.Locker
+	upstreams  func() (upstreamState, string, error)
+	blackedOut func() (bool, string)
+	executing  func() (bool, error)
+	// record creates activity of the run in status, with reason of skip
+	record func(status, reason string) (*SScheduledTaskActivity, error)
+}
+
+// prepare returns the activity to execute, or nil if the task should not run
+// now.  skipped is true when a skipped run is recorded, of which dependents
+// of the task should be notified in turn
+func (r *sDependentRun) prepare() (sa *SScheduledTaskActivity, skipped bool, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	state, reason, err := r.upstreams()
+	if err != nil {
+		return nil, false, err
+	}
+	switch state {
+	case upstreamsPending:
+		return nil, false, nil
+	case upstreamsSkipped:
+		_, err := r.record(api.ST_ACTIVITY_STATUS_SKIP, reason)
+		return nil, err == nil, err
+	}
+	if blackedOut, reason := r.blackedOut(); blackedOut {
+		_, err := r.record(api.ST_ACTIVITY_STATUS_SKIP, reason)
+		return nil, err == nil, err
+	}
+	exec, err := r.executing()
+	if err != nil {
+		return nil, false, err
+	}
+	if exec {
+		_, err := r.record(api.ST_ACTIVITY_STATUS_REJECT, "")
+		return nil, false, err
+	}
+	sa, err = r.record(api.ST_ACTIVITY_STATUS_EXEC, "")
+	return sa, false, err
+}
+
+// sTriggerLock serializes the fan-in check of a dependent task across all
+// scheduledtask services, holding the only slot of a label kept in db
+type sTriggerLock struct {
+	slots    iLabelSlots
+	label    string
+	holder   string
+	interval time.Duration
+}
+
+func newTriggerLock(taskId string) *sTriggerLock {
+	return &sTriggerLock{
+		slots:    ScheduledTaskLabelSlotManager,
+		label:    "trigger:" + taskId,
+		interval: triggerLockPollInterval,
+	}
+}
+
+func (l *sTriggerLock) Lock() {
+	holder := stringutils.UUID4()
+	limits := map[string]int{l.label: 1}
+	for {
+		ok, err := l.slots.tryAcquire(holder, limits, triggerLockLease)
+		if err != nil {
+			log.Errorf("acquire trigger lock %s: %v", l.label, err)
+		}
+		if ok {
+			l.holder = holder
+			return
+		}
+		time.Sleep(l.interval)
+	}
+}
+
+func (l *sTriggerLock) Unlock() {
+	if err := l.slots.release(l.holder); err != nil {
+		log.Errorf("release trigger lock %s: %v", l.label, err)
+	}
+	l.holder = ""
+}
@@ -0,0 +1,329 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/scheduledtask"
+)
+
+func TestReachable(t *testing.T) {
+	// c -> b -> a, d -> a, e -> e
+	graph := map[string][]string{
+		"b": {"a"},
+		"c": {"b"},
+		"d": {"a"},
+		"e": {"e"},
+	}
+	upstreams := func(id string) ([]string, error) {
+		return graph[id], nil
+	}
+	cases := []struct {
+		from, target string
+		want         bool
+	}{
+		{"a", "a", true},
+		{"c", "a", true},
+		{"c", "b", true},
+		{"a", "c", false},
+		{"d", "b", false},
+		{"e", "a", false},
+	}
+	for _, c := range cases {
+		got, err := reachable(upstreams, c.from, c.target, map[string]bool{})
+		if err != nil {
+			t.Fatalf("reachable(%s, %s): %v", c.from, c.target, err)
+		}
+		if got != c.want {
+			t.Errorf("reachable(%s, %s) = %v, want %v", c.from, c.target, got, c.want)
+		}
+	}
+}
+
+func TestCheckUpstreams(t *testing.T) {
+	base := time.Date(2023, 1, 2, 3, 0, 0, 0, time.UTC)
+	activity := func(status string, end time.Duration) *SScheduledTaskActivity {
+		sa := &SScheduledTaskActivity{EndTime: base.Add(end)}
+		sa.Status = status
+		return sa
+	}
+	deps := []SScheduledTaskDependency{
+		{DependsOnId: "a", Condition: api.ST_DEPENDENCY_CONDITION_SUCCEED},
+		{DependsOnId: "b", Condition: api.ST_DEPENDENCY_CONDITION_COMPLETE},
+	}
+	last := &SScheduledTaskActivity{StartTime: base}
+	cases := []struct {
+		name string
+		deps []SScheduledTaskDependency
+		last *SScheduledTaskActivity
+		ups  map[string]*SScheduledTaskActivity
+		want upstreamState
+	}{
+		{
+			name: "no dependency",
+			want: upstreamsPending,
+		},
+		{
+			name: "first run",
+			deps: deps,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SUCCEED, -time.Hour),
+				"b": activity(api.ST_ACTIVITY_STATUS_FAILED, -time.Hour),
+			},
+			want: upstreamsReady,
+		},
+		{
+			name: "upstream never ran",
+			deps: deps,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SUCCEED, time.Minute),
+			},
+			want: upstreamsPending,
+		},
+		{
+			name: "upstream finished before last run",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SUCCEED, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_SUCCEED, -time.Minute),
+			},
+			want: upstreamsPending,
+		},
+		{
+			name: "condition not met",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_FAILED, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_SUCCEED, time.Minute),
+			},
+			want: upstreamsPending,
+		},
+		{
+			name: "part succeeded meets succeed",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_PART_SUCCEED, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_FAILED, time.Minute),
+			},
+			want: upstreamsReady,
+		},
+		{
+			name: "upstream skipped",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SKIP, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_SUCCEED, time.Minute),
+			},
+			want: upstreamsSkipped,
+		},
+		{
+			name: "skipped upstream waits for others",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SKIP, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_SUCCEED, -time.Minute),
+			},
+			want: upstreamsPending,
+		},
+		{
+			name: "all ready",
+			deps: deps,
+			last: last,
+			ups: map[string]*SScheduledTaskActivity{
+				"a": activity(api.ST_ACTIVITY_STATUS_SUCCEED, time.Minute),
+				"b": activity(api.ST_ACTIVITY_STATUS_PART_SUCCEED, time.Minute),
+			},
+			want: upstreamsReady,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, reason, err := checkUpstreams(c.deps, c.last, func(id string) (*SScheduledTaskActivity, error) {
+				return c.ups[id], nil
+			})
+			if err != nil {
+				t.Fatalf("checkUpstreams: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("got state %d, want %d", got, c.want)
+			}
+			if (got == upstreamsSkipped) != (reason != "") {
+				t.Errorf("unexpected reason %q of state %d", reason, got)
+			}
+		})
+	}
+}
+
+func TestFilterUpstreamResources(t *testing.T) {
+	ids := []string{"vm1", "vm2", "vm3"}
+	cases := []struct {
+		name      string
+		upstreams []sUpstreamResources
+		want      []string
+	}{
+		{
+			name: "no upstream of same resource type",
+			want: ids,
+		},
+		{
+			name: "fan in",
+			upstreams: []sUpstreamResources{
+				{condition: api.ST_DEPENDENCY_CONDITION_SUCCEED, succeed: []string{"vm1", "vm2"}},
+				{condition: api.ST_DEPENDENCY_CONDITION_SUCCEED, succeed: []string{"vm2", "vm3"}},
+			},
+			want: []string{"vm2"},
+		},
+		{
+			name: "complete takes all",
+			upstreams: []sUpstreamResources{
+				{condition: api.ST_DEPENDENCY_CONDITION_SUCCEED, succeed: []string{"vm1", "vm3"}},
+				{condition: api.ST_DEPENDENCY_CONDITION_COMPLETE},
+			},
+			want: []string{"vm1", "vm3"},
+		},
+		{
+			name: "none succeeded",
+			upstreams: []sUpstreamResources{
+				{condition: api.ST_DEPENDENCY_CONDITION_SUCCEED},
+			},
+			want: []string{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := filterUpstreamResources(ids, c.upstreams)
+			if fmt.Sprint(got) != fmt.Sprint(c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDependentRunFanIn(t *testing.T) {
+	var (
+		recMu   sync.Mutex
+		records []string
+	)
+	recorded := func() int {
+		recMu.Lock()
+		defer recMu.Unlock()
+		return len(records)
+	}
+	// runs prepared by different services share only the slots in db
+	slots := newFakeLabelSlots()
+	newRun := func() *sDependentRun {
+		return &sDependentRun{
+			lock: &sTriggerLock{slots: slots, label: "trigger:b", interval: time.Millisecond},
+			upstreams: func() (upstreamState, string, error) {
+				// a run recorded consumes the round of upstreams
+				if recorded() > 0 {
+					return upstreamsPending, "", nil
+				}
+				// widen the window between check and record
+				time.Sleep(10 * time.Millisecond)
+				return upstreamsReady, "", nil
+			},
+			blackedOut: func() (bool, string) { return false, "" },
+			executing:  func() (bool, error) { return false, nil },
+			record: func(status, reason string) (*SScheduledTaskActivity, error) {
+				recMu.Lock()
+				defer recMu.Unlock()
+				records = append(records, status)
+				sa := &SScheduledTaskActivity{}
+				sa.Status = status
+				return sa, nil
+			},
+		}
+	}
+	var (
+		wg   sync.WaitGroup
+		runs int
+		mu   sync.Mutex
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sa, skipped, err := newRun().prepare()
+			if err != nil || skipped {
+				t.Errorf("prepare: skipped %v, err %v", skipped, err)
+				return
+			}
+			if sa != nil {
+				mu.Lock()
+				runs++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 || len(records) != 1 || records[0] != api.ST_ACTIVITY_STATUS_EXEC {
+		t.Errorf("got %d runs with records %v, want exactly one execution", runs, records)
+	}
+}
+
+func TestDependentRunPrepare(t *testing.T) {
+	cases := []struct {
+		name        string
+		state       upstreamState
+		blackedOut  bool
+		executing   bool
+		wantRun     bool
+		wantSkipped bool
+		wantRecord  string
+	}{
+		{name: "pending", state: upstreamsPending},
+		{name: "upstream skipped", state: upstreamsSkipped, wantSkipped: true, wantRecord: api.ST_ACTIVITY_STATUS_SKIP},
+		{name: "blacked out", state: upstreamsReady, blackedOut: true, wantSkipped: true, wantRecord: api.ST_ACTIVITY_STATUS_SKIP},
+		{name: "executing", state: upstreamsReady, executing: true, wantRecord: api.ST_ACTIVITY_STATUS_REJECT},
+		{name: "run", state: upstreamsReady, wantRun: true, wantRecord: api.ST_ACTIVITY_STATUS_EXEC},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			record := ""
+			r := &sDependentRun{
+				lock: &sync.Mutex{},
+				upstreams: func() (upstreamState, string, error) {
+					return c.state, "", nil
+				},
+				blackedOut: func() (bool, string) { return c.blackedOut, "" },
+				executing:  func() (bool, error) { return c.executing, nil },
+				record: func(status, reason string) (*SScheduledTaskActivity, error) {
+					record = status
+					sa := &SScheduledTaskActivity{}
+					sa.Status = status
+					return sa, nil
+				},
+			}
+			sa, skipped, err := r.prepare()
+			if err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+			if (sa != nil) != c.wantRun || skipped != c.wantSkipped || record != c.wantRecord {
+				t.Errorf("got run %v skipped %v record %q, want run %v skipped %v record %q",
+					sa != nil, skipped, record, c.wantRun, c.wantSkipped, c.wantRecord)
+			}
+		})
+	}
+}
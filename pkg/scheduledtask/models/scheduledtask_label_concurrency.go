@@ -0,0 +1,285 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/stringutils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/scheduledtask"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+var ScheduledTaskLabelConcurrencyManager *SScheduledTaskLabelConcurrencyManager
+
+func init() {
+	db.InitManager(func() {
+		ScheduledTaskLabelConcurrencyManager = &SScheduledTaskLabelConcurrencyManager{
+			SResourceBaseManager: db.NewResourceBaseManager(
+				SScheduledTaskLabelConcurrency{},
+				"scheduledtasklabelconcurrencies_tbl",
+				"scheduledtasklabelconcurrency",
+				"scheduledtasklabelconcurrencies",
+			),
+		}
+	})
+}
+
+type SScheduledTaskLabelConcurrencyManager struct {
+	db.SResourceBaseManager
+}
+
+// SScheduledTaskLabelConcurrency limits the number of resources operated at
+// the same time by all scheduled tasks with the label
+type SScheduledTaskLabelConcurrency struct {
+	db.SResourceBase
+	Label          string `width:"64" charset:"utf8" nullable:"false" primary:"true"`
+	MaxConcurrency int    `nullable:"false" default:"0"`
+}
+
+func (lcm *SScheduledTaskLabelConcurrencyManager) fetchAll() ([]SScheduledTaskLabelConcurrency, error) {
+	limits := make([]SScheduledTaskLabelConcurrency, 0, 1)
+	err := db.FetchModelObjects(lcm, lcm.Query(), &limits)
+	return limits, err
+}
+
+// Limits returns the concurrency limits of the labels that have one
+func (lcm *SScheduledTaskLabelConcurrencyManager) Limits(labels []string) (map[string]int, error) {
+	limits := map[string]int{}
+	if len(labels) == 0 {
+		return limits, nil
+	}
+	q := lcm.Query().In("label", labels)
+	lcs := make([]SScheduledTaskLabelConcurrency, 0, 1)
+	err := db.FetchModelObjects(lcm, q, &lcs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lcs {
+		if lcs[i].MaxConcurrency > 0 {
+			limits[lcs[i].Label] = lcs[i].MaxConcurrency
+		}
+	}
+	return limits, nil
+}
+
+func (stm *SScheduledTaskManager) PerformSetLabelConcurrency(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ScheduledTaskSetLabelConcurrencyInput) (jsonutils.JSONObject, error) {
+	if len(input.Label) == 0 {
+		return nil, httperrors.NewMissingParameterError("label")
+	}
+	if input.MaxConcurrency < 0 {
+		return nil, httperrors.NewInputParameterError("max_concurrency should not be negative")
+	}
+	lc := &SScheduledTaskLabelConcurrency{
+		Label:          input.Label,
+		MaxConcurrency: input.MaxConcurrency,
+	}
+	lc.SetModelManager(ScheduledTaskLabelConcurrencyManager, lc)
+	if input.MaxConcurrency == 0 {
+		q := ScheduledTaskLabelConcurrencyManager.Query().Equals("label", input.Label)
+		lcs := make([]SScheduledTaskLabelConcurrency, 0, 1)
+		err := db.FetchModelObjects(ScheduledTaskLabelConcurrencyManager, q, &lcs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range lcs {
+			err := lcs[i].Delete(ctx, userCred)
+			if err != nil {
+				return nil, errors.Wrapf(err, "remove concurrency limit of %s", input.Label)
+			}
+		}
+		return nil, nil
+	}
+	err := ScheduledTaskLabelConcurrencyManager.TableSpec().InsertOrUpdate(ctx, lc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "set concurrency limit of %s", input.Label)
+	}
+	return nil, nil
+}
+
+func (stm *SScheduledTaskManager) GetPropertyLabelConcurrency(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	lcs, err := ScheduledTaskLabelConcurrencyManager.fetchAll()
+	if err != nil {
+		return nil, err
+	}
+	out := api.ScheduledTaskLabelConcurrencyOutput{
+		Limits: make([]api.ScheduledTaskSetLabelConcurrencyInput, len(lcs)),
+	}
+	for i := range lcs {
+		out.Limits[i].Label = lcs[i].Label
+		out.Limits[i].MaxConcurrency = lcs[i].MaxConcurrency
+	}
+	return jsonutils.Marshal(out), nil
+}
+
+// SScheduledTaskLabelSlot is a slot of label concurrency held by an action
+// in progress.  Slots are kept in db to limit the actions across all
+// scheduledtask services
+type SScheduledTaskLabelSlot struct {
+	db.SResourceBase
+	Label  string `width:"64" charset:"utf8" nullable:"false" primary:"true"`
+	Slot   int    `nullable:"false" primary:"true"`
+	Holder string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	// slots not released by then are taken as leaked by a crashed service
+	ExpireAt time.Time `nullable:"false"`
+}
+
+type SScheduledTaskLabelSlotManager struct {
+	db.SResourceBaseManager
+}
+
+var ScheduledTaskLabelSlotManager *SScheduledTaskLabelSlotManager
+
+func init() {
+	db.InitManager(func() {
+		ScheduledTaskLabelSlotManager = &SScheduledTaskLabelSlotManager{
+			SResourceBaseManager: db.NewResourceBaseManager(
+				SScheduledTaskLabelSlot{},
+				"scheduledtasklabelslots_tbl",
+				"scheduledtasklabelslot",
+				"scheduledtasklabelslots",
+			),
+		}
+	})
+}
+
+const (
+	labelSlotLease        = time.Hour
+	labelSlotPollInterval = 5 * time.Second
+)
+
+type iLabelSlots interface {
+	// tryAcquire takes a free slot of every label in limits for holder,
+	// all or none of them, the slots expire after lease
+	tryAcquire(holder string, limits map[string]int, lease time.Duration) (bool, error)
+	release(holder string) error
+}
+
+func (lsm *SScheduledTaskLabelSlotManager) tryAcquire(holder string, limits map[string]int, lease time.Duration) (bool, error) {
+	now := time.Now()
+	table := lsm.TableSpec().Name()
+	labels := make([]string, 0, len(limits))
+	for label := range limits {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		_, err := sqlchemy.GetDB().Exec(fmt.Sprintf("delete from %s where label = ? and expire_at < ?", table), label, now)
+		if err != nil {
+			return false, errors.Wrapf(err, "purge expired slots of %s", label)
+		}
+		ok, err := lsm.tryAcquireLabel(holder, label, limits[label], now.Add(lease))
+		if err == nil && ok {
+			continue
+		}
+		if err1 := lsm.release(holder); err1 != nil {
+			log.Errorf("release label slots of %s: %v", holder, err1)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (lsm *SScheduledTaskLabelSlotManager) tryAcquireLabel(holder, label string, max int, expireAt time.Time) (bool, error) {
+	q := lsm.Query().Equals("label", label)
+	slots := make([]SScheduledTaskLabelSlot, 0, max)
+	err := db.FetchModelObjects(lsm, q, &slots)
+	if err != nil {
+		return false, errors.Wrapf(err, "fetch slots of %s", label)
+	}
+	taken := map[int]bool{}
+	for i := range slots {
+		taken[slots[i].Slot] = true
+	}
+	for i := 0; i < max; i++ {
+		if taken[i] {
+			continue
+		}
+		slot := &SScheduledTaskLabelSlot{
+			Label:    label,
+			Slot:     i,
+			Holder:   holder,
+			ExpireAt: expireAt,
+		}
+		// insert fails on conflict with the same slot taken by others
+		// in the meantime
+		err := lsm.TableSpec().Insert(context.Background(), slot)
+		if err == nil {
+			return true, nil
+		}
+		n, err1 := lsm.Query().Equals("label", label).Equals("slot", i).CountWithError()
+		if err1 != nil || n == 0 {
+			return false, errors.Wrapf(err, "take slot %d of %s", i, label)
+		}
+	}
+	return false, nil
+}
+
+func (lsm *SScheduledTaskLabelSlotManager) release(holder string) error {
+	_, err := sqlchemy.GetDB().Exec(fmt.Sprintf("delete from %s where holder = ?", lsm.TableSpec().Name()), holder)
+	return err
+}
+
+// labelLimiter limits the number of actions running at the same time on
+// resources of each label
+type labelLimiter struct {
+	slots    iLabelSlots
+	interval time.Duration
+}
+
+// Acquire waits for slots of all labels in limits, and returns the holder to
+// release them with
+func (l *labelLimiter) Acquire(limits map[string]int) (string, error) {
+	if len(limits) == 0 {
+		return "", nil
+	}
+	holder := stringutils.UUID4()
+	for {
+		ok, err := l.slots.tryAcquire(holder, limits, labelSlotLease)
+		if err != nil {
+			return "", errors.Wrap(err, "acquire label concurrency slots")
+		}
+		if ok {
+			return holder, nil
+		}
+		time.Sleep(l.interval)
+	}
+}
+
+func (l *labelLimiter) Release(holder string) {
+	if len(holder) == 0 {
+		return
+	}
+	if err := l.slots.release(holder); err != nil {
+		log.Errorf("release label concurrency slots of %s: %v", holder, err)
+	}
+}
+
+func getLabelConcurrencyLimiter() *labelLimiter {
+	return &labelLimiter{
+		slots:    ScheduledTaskLabelSlotManager,
+		interval: labelSlotPollInterval,
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLabelSlots is shared by limiters as the db is by services
+type fakeLabelSlots struct {
+	lock  sync.Mutex
+	slots map[string]map[string]bool
+}
+
+func newFakeLabelSlots() *fakeLabelSlots {
+	return &fakeLabelSlots{slots: map[string]map[string]bool{}}
+}
+
+func (s *fakeLabelSlots) tryAcquire(holder string, limits map[string]int, lease time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for label, max := range limits {
+		if len(s.slots[label]) >= max {
+			return false, nil
+		}
+	}
+	for label := range limits {
+		if s.slots[label] == nil {
+			s.slots[label] = map[string]bool{}
+		}
+		s.slots[label][holder] = true
+	}
+	return true, nil
+}
+
+func (s *fakeLabelSlots) release(holder string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, holders := range s.slots {
+		delete(holders, holder)
+	}
+	return nil
+}
+
+func TestLabelLimiter(t *testing.T) {
+	slots := newFakeLabelSlots()
+	// two limiters sharing slots stand for two services
+	limiters := []*labelLimiter{
+		{slots: slots, interval: time.Millisecond},
+		{slots: slots, interval: time.Millisecond},
+	}
+	limits := map[string]int{"web": 2, "db": 3}
+
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		running = map[string]int{}
+		peak    = map[string]int{}
+	)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(l *labelLimiter) {
+			defer wg.Done()
+			holder, err := l.Acquire(limits)
+			if err != nil {
+				t.Errorf("acquire: %v", err)
+				return
+			}
+			lock.Lock()
+			for label := range limits {
+				running[label]++
+				if running[label] > peak[label] {
+					peak[label] = running[label]
+				}
+			}
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			lock.Lock()
+			for label := range limits {
+				running[label]--
+			}
+			lock.Unlock()
+			l.Release(holder)
+		}(limiters[i%len(limiters)])
+	}
+	wg.Wait()
+	if peak["web"] > 2 || peak["db"] > 2 {
+		t.Errorf("peak concurrency %v exceeds limits %v", peak, limits)
+	}
+	if peak["web"] == 0 {
+		t.Errorf("nothing run")
+	}
+	for label, holders := range slots.slots {
+		if len(holders) > 0 {
+			t.Errorf("slots of %s not released: %v", label, holders)
+		}
+	}
+
+	holder, err := limiters[0].Acquire(nil)
+	if err != nil || holder != "" {
+		t.Errorf("acquire without limits: holder %q, err %v", holder, err)
+	}
+	limiters[0].Release(holder)
+}
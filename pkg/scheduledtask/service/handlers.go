@@ -30,12 +30,16 @@ func InitHandlers(app *appsrv.Application) {
 		db.UserCacheManager,
 		db.TenantCacheManager,
 		models.ScheduledTaskLabelManager,
+		models.ScheduledTaskDependencyManager,
+		models.ScheduledTaskLabelConcurrencyManager,
+		models.ScheduledTaskLabelSlotManager,
 	} {
 		db.RegisterModelManager(manager)
 	}
 	for _, manager := range []db.IModelManager{
 		models.ScheduledTaskActivityManager,
 		models.ScheduledTaskManager,
+		models.ScheduledTaskCalendarManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
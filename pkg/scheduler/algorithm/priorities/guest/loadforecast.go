@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/loadforecast"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// LoadForecastPriority scores hosts by the peak cpu and memory usage forecasted
+// from their history, so hosts that are quiet now but busy in the coming hours are avoided
+type LoadForecastPriority struct {
+	priorities.BasePriority
+}
+
+func (p *LoadForecastPriority) Name() string {
+	return "host_loadforecast"
+}
+
+func (p *LoadForecastPriority) Clone() core.Priority {
+	return &LoadForecastPriority{}
+}
+
+func (p *LoadForecastPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+
+	forecast := loadforecast.GetHostForecast(c.Getter().Id())
+	if forecast == nil {
+		return h.GetResult()
+	}
+	peak, ok := forecast.Peak(time.Now(), o.Options.LoadForecastHours)
+	if !ok {
+		return h.GetResult()
+	}
+	if peak > 100 {
+		peak = 100
+	}
+	h.SetScore(int(10 * (1 - peak/100)))
+	return h.GetResult()
+}
+
+func (p *LoadForecastPriority) ScoreIntervals() score.Intervals {
+	return score.NewIntervals(2, 4, 7)
+}
@@ -57,6 +57,7 @@ func defaultPriorities() sets.String {
 	return sets.NewString(
 		factory.RegisterPriority("guest-avoid-same-host", &priorityguest.AvoidSameHostPriority{}, 1),
 		factory.RegisterPriority("guest-lowload", &priorityguest.LowLoadPriority{}, 1),
+		factory.RegisterPriority("guest-loadforecast", &priorityguest.LoadForecastPriority{}, 1),
 		factory.RegisterPriority("guest-creating", &priorityguest.CreatingPriority{}, 1),
 		factory.RegisterPriority("guest-capacity", &priorityguest.CapacityPriority{}, 1),
 	)
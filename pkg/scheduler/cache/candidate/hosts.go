@@ -33,6 +33,19 @@ import (
 	o "yunion.io/x/onecloud/pkg/scheduler/options"
 )
 
+// balloon headroom is reported along with host ping every minute by default
+const balloonHeadroomExpire = 5 * time.Minute
+
+// balloonMemHeadroom returns memory in MB reclaimable by guest balloons that is
+// counted as free memory of host. Host reports zero while it is under memory
+// pressure, and the report is ignored if host stops pinging.
+func balloonMemHeadroom(desc *HostDesc, now time.Time) int64 {
+	if desc.MemBalloonHeadroom <= 0 || now.Sub(desc.LastPingAt) > balloonHeadroomExpire {
+		return 0
+	}
+	return int64(desc.MemBalloonHeadroom)
+}
+
 type hostGetter struct {
 	*baseHostGetter
 	h *HostDesc
@@ -826,6 +839,8 @@ func (b *HostBuilder) fillGuestsResourceInfo(desc *HostDesc, host *computemodels
 	if memSub < 0 {
 		memFreeSize += memSub
 	}
+	// memory reclaimable by guest balloons is available as overcommit headroom
+	memFreeSize += balloonMemHeadroom(desc, time.Now())
 	desc.FreeMemSize = memFreeSize
 
 	// free cpu count calculate
@@ -0,0 +1,48 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package candidate
+
+import (
+	"testing"
+	"time"
+
+	computemodels "yunion.io/x/onecloud/pkg/compute/models"
+)
+
+func TestBalloonMemHeadroom(t *testing.T) {
+	now := time.Now()
+	for _, c := range []struct {
+		name       string
+		headroom   int
+		lastPingAt time.Time
+		want       int64
+	}{
+		{"no balloon", 0, now, 0},
+		{"host under memory pressure reports zero", 0, now.Add(-time.Minute), 0},
+		{"reported headroom", 2048, now.Add(-time.Minute), 2048},
+		{"outdated report", 2048, now.Add(-balloonHeadroomExpire - time.Second), 0},
+		{"invalid report", -1024, now, 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			host := &computemodels.SHost{}
+			host.MemBalloonHeadroom = c.headroom
+			host.LastPingAt = c.lastPingAt
+			desc := &HostDesc{BaseHostDesc: &BaseHostDesc{SHost: host}}
+			if got := balloonMemHeadroom(desc, now); got != c.want {
+				t.Errorf("balloonMemHeadroom = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
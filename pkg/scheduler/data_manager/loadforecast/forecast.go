@@ -0,0 +1,142 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadforecast
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	hoursPerDay = 24
+
+	// dayDecay is the weight ratio of a sample to the sample of the same hour one day later
+	dayDecay = 0.8
+)
+
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+type profileBucket struct {
+	sum    float64
+	weight float64
+}
+
+func (b *profileBucket) add(val, weight float64) {
+	b.sum += val * weight
+	b.weight += weight
+}
+
+func (b profileBucket) value() (float64, bool) {
+	if b.weight <= 0 {
+		return 0, false
+	}
+	return b.sum / b.weight, true
+}
+
+// SeasonalProfile is the expected usage of every hour of the day, weekdays and
+// weekends are profiled apart because business hours only load hosts on weekdays
+type SeasonalProfile struct {
+	weekday [hoursPerDay]profileBucket
+	weekend [hoursPerDay]profileBucket
+	all     [hoursPerDay]profileBucket
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// NewSeasonalProfile builds the profile of the hourly samples before now, recent
+// days weigh more so that a changed workload is picked up within a few days
+func NewSeasonalProfile(samples []Sample, now time.Time) *SeasonalProfile {
+	p := new(SeasonalProfile)
+	for _, s := range samples {
+		if s.Time.After(now) {
+			continue
+		}
+		t := s.Time.Local()
+		days := int(now.Sub(s.Time) / (hoursPerDay * time.Hour))
+		weight := math.Pow(dayDecay, float64(days))
+		hour := t.Hour()
+		if isWeekend(t) {
+			p.weekend[hour].add(s.Value, weight)
+		} else {
+			p.weekday[hour].add(s.Value, weight)
+		}
+		p.all[hour].add(s.Value, weight)
+	}
+	return p
+}
+
+// Predict returns the expected usage at t
+func (p *SeasonalProfile) Predict(t time.Time) (float64, bool) {
+	t = t.Local()
+	hour := t.Hour()
+	bucket := p.weekday[hour]
+	if isWeekend(t) {
+		bucket = p.weekend[hour]
+	}
+	if val, ok := bucket.value(); ok {
+		return val, true
+	}
+	return p.all[hour].value()
+}
+
+// Peak returns the highest expected usage of the hours in [from, from+hours]
+func (p *SeasonalProfile) Peak(from time.Time, hours int) (float64, bool) {
+	peak := 0.0
+	found := false
+	for i := 0; i <= hours; i++ {
+		val, ok := p.Predict(from.Add(time.Duration(i) * time.Hour))
+		if !ok {
+			continue
+		}
+		if !found || val > peak {
+			peak = val
+			found = true
+		}
+	}
+	return peak, found
+}
+
+// HostForecast is the usage percent forecast of a host
+type HostForecast struct {
+	HostId string
+	CPU    *SeasonalProfile
+	Memory *SeasonalProfile
+}
+
+// Peak returns the highest expected cpu or memory usage percent of the host in
+// the coming hours
+func (f *HostForecast) Peak(from time.Time, hours int) (float64, bool) {
+	peak := 0.0
+	found := false
+	for _, p := range []*SeasonalProfile{f.CPU, f.Memory} {
+		if p == nil {
+			continue
+		}
+		val, ok := p.Peak(from, hours)
+		if !ok {
+			continue
+		}
+		if !found || val > peak {
+			peak = val
+			found = true
+		}
+	}
+	return peak, found
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadforecast
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+// businessHoursSamples is a week of hourly samples of a host saturated from
+// 9:00 to 18:00 on weekdays and idle otherwise
+func businessHoursSamples(now time.Time) []Sample {
+	samples := make([]Sample, 0)
+	for t := now.Add(-7 * 24 * time.Hour); t.Before(now); t = t.Add(time.Hour) {
+		val := 10.0
+		if !isWeekend(t) && t.Hour() >= 9 && t.Hour() < 18 {
+			val = 90.0
+		}
+		samples = append(samples, Sample{Time: t, Value: val})
+	}
+	return samples
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestSeasonalProfile(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 4, 17, 6, 0, 0, 0, time.Local)
+	p := NewSeasonalProfile(businessHoursSamples(now), now)
+
+	if val, _ := p.Predict(now); !floatEqual(val, 10) {
+		t.Errorf("predict at %s want 10, got %f", now, val)
+	}
+	if val, _ := p.Predict(now.Add(5 * time.Hour)); !floatEqual(val, 90) {
+		t.Errorf("predict at business hours want 90, got %f", val)
+	}
+	saturday := time.Date(2024, 4, 20, 11, 0, 0, 0, time.Local)
+	if val, _ := p.Predict(saturday); !floatEqual(val, 10) {
+		t.Errorf("predict at weekend want 10, got %f", val)
+	}
+	if peak, ok := p.Peak(now, 2); !ok || !floatEqual(peak, 10) {
+		t.Errorf("peak of next 2 hours want 10, got %f", peak)
+	}
+	if peak, ok := p.Peak(now, 8); !ok || !floatEqual(peak, 90) {
+		t.Errorf("peak of next 8 hours want 90, got %f", peak)
+	}
+
+	empty := NewSeasonalProfile(nil, now)
+	if _, ok := empty.Peak(now, 8); ok {
+		t.Errorf("empty profile should not have peak")
+	}
+}
+
+func TestSeasonalProfileDecay(t *testing.T) {
+	now := time.Date(2024, 4, 17, 6, 0, 0, 0, time.Local)
+	samples := []Sample{
+		{Time: now.Add(-2*24*time.Hour - time.Hour), Value: 20},
+		{Time: now.Add(-time.Hour), Value: 80},
+	}
+	p := NewSeasonalProfile(samples, now)
+	val, _ := p.Predict(now.Add(23 * time.Hour))
+	if val <= 50 || val >= 80 {
+		t.Errorf("recent samples should weigh more, got %f", val)
+	}
+}
+
+func TestParseHostSamples(t *testing.T) {
+	ret, err := jsonutils.ParseString(`{"series":[
+		{"name":"cpu.usage_active","tags":{"host_id":"h1"},"points":[[12.5,1713312000000],[null,1713315600000]]},
+		{"name":"cpu.usage_active","points":[[1,1713312000000]]}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := parseHostSamples(ret, "host_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || len(samples["h1"]) != 1 {
+		t.Fatalf("unexpected samples %v", samples)
+	}
+	if s := samples["h1"][0]; s.Value != 12.5 || !s.Time.Equal(time.UnixMilli(1713312000000)) {
+		t.Errorf("unexpected sample %v", s)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadforecast
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/wait"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	monitor_modules "yunion.io/x/onecloud/pkg/mcclient/modules/monitor"
+)
+
+const (
+	hostCPUMeasurement = "cpu"
+	hostCPUField       = "usage_active"
+	hostMemMeasurement = "mem"
+	hostMemField       = "used_percent"
+)
+
+var (
+	forecastManager *SLoadForecastManager
+)
+
+// Start periodically rebuilds the host load forecasts from the last historyDays
+// days of host metrics stored in the monitor tsdb.  The manager is set before
+// the sync goroutine starts, so it is never written while being read
+func Start(refreshInterval time.Duration, historyDays int) {
+	forecastManager = &SLoadForecastManager{
+		forecasts:       make(map[string]*HostForecast),
+		refreshInterval: refreshInterval,
+		historyDays:     historyDays,
+	}
+	go forecastManager.sync()
+}
+
+// GetHostForecast returns the load forecast of a host, nil if the host has no history
+func GetHostForecast(hostId string) *HostForecast {
+	if forecastManager == nil {
+		return nil
+	}
+	return forecastManager.Get(hostId)
+}
+
+type SLoadForecastManager struct {
+	// forecasts cache the forecast of every host, key is host id.
+	// The map is replaced as a whole by syncOnce and never modified after being published
+	forecasts       map[string]*HostForecast
+	lock            sync.RWMutex
+	refreshInterval time.Duration
+	historyDays     int
+}
+
+func (m *SLoadForecastManager) Get(hostId string) *HostForecast {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.forecasts[hostId]
+}
+
+func (m *SLoadForecastManager) syncOnce() {
+	log.Infof("LoadForecastManager start sync")
+	startTime := time.Now()
+
+	s := auth.GetAdminSession(context.Background(), consts.GetRegion())
+	end := time.Now()
+	start := end.Add(-time.Duration(m.historyDays) * hoursPerDay * time.Hour)
+	cpuSamples, err := fetchHostSamples(s, hostCPUMeasurement, hostCPUField, start, end)
+	if err != nil {
+		log.Errorf("LoadForecastManager fetch host cpu history error: %v", err)
+		return
+	}
+	memSamples, err := fetchHostSamples(s, hostMemMeasurement, hostMemField, start, end)
+	if err != nil {
+		log.Errorf("LoadForecastManager fetch host memory history error: %v", err)
+		return
+	}
+
+	forecasts := make(map[string]*HostForecast)
+	getForecast := func(hostId string) *HostForecast {
+		forecast, ok := forecasts[hostId]
+		if !ok {
+			forecast = &HostForecast{HostId: hostId}
+			forecasts[hostId] = forecast
+		}
+		return forecast
+	}
+	for hostId, samples := range cpuSamples {
+		getForecast(hostId).CPU = NewSeasonalProfile(samples, end)
+	}
+	for hostId, samples := range memSamples {
+		getForecast(hostId).Memory = NewSeasonalProfile(samples, end)
+	}
+	m.lock.Lock()
+	m.forecasts = forecasts
+	m.lock.Unlock()
+	log.Infof("LoadForecastManager end sync, %d hosts, consume %s", len(cpuSamples), time.Since(startTime))
+}
+
+func (m *SLoadForecastManager) sync() {
+	wait.Forever(m.syncOnce, m.refreshInterval)
+}
+
+// fetchHostSamples queries the hourly mean of a host metric field grouped by host
+func fetchHostSamples(s *mcclient.ClientSession, measurement, field string, start, end time.Time) (map[string][]Sample, error) {
+	tagId := monitor.MEASUREMENT_TAG_ID[monitor.METRIC_RES_TYPE_HOST]
+	input := monitor_modules.NewMetricQueryInput(measurement).
+		From(start).
+		To(end).
+		Interval("1h").
+		Scope("system").
+		SkipCheckSeries(true)
+	input.Selects().Select(field).MEAN()
+	input.GroupBy().TAG(tagId)
+
+	ret, err := monitor_modules.UnifiedMonitorManager.PerformQuery(s, input.ToQueryData())
+	if err != nil {
+		return nil, errors.Wrapf(err, "query %s.%s", measurement, field)
+	}
+	return parseHostSamples(ret, tagId)
+}
+
+func parseHostSamples(ret jsonutils.JSONObject, tagId string) (map[string][]Sample, error) {
+	series, err := ret.GetArray("series")
+	if err != nil {
+		if errors.Cause(err) == jsonutils.ErrJsonDictKeyNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "get series")
+	}
+	samples := make(map[string][]Sample)
+	for _, s := range series {
+		hostId, _ := s.GetString("tags", tagId)
+		if len(hostId) == 0 {
+			continue
+		}
+		points, _ := s.GetArray("points")
+		for _, point := range points {
+			vals, _ := point.GetArray()
+			if len(vals) < 2 {
+				continue
+			}
+			val, err := vals[0].Float()
+			if err != nil {
+				// null value of a time bucket without data
+				continue
+			}
+			ts, err := vals[len(vals)-1].Float()
+			if err != nil {
+				continue
+			}
+			samples[hostId] = append(samples[hostId], Sample{
+				Time:  time.UnixMilli(int64(ts)),
+				Value: val,
+			})
+		}
+	}
+	return samples, nil
+}
@@ -90,6 +90,11 @@ type SchedOptions struct {
 
 	SkuRefreshInterval string `help:"Server SKU refresh interval" default:"12h"`
 
+	// load forecast options
+	LoadForecastRefreshInterval string `help:"Host load forecast refresh interval" default:"30m"`
+	LoadForecastHistoryDays     int    `help:"Days of host metrics history to build host load forecast from" default:"7"`
+	LoadForecastHours           int    `help:"Hours ahead of host load forecast considered when scoring hosts" default:"8"`
+
 	OpenstackOptions
 }
 
@@ -42,6 +42,7 @@ import (
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudaccount"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudprovider"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudregion"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/loadforecast"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/netinterface"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/network"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/schedtag"
@@ -120,6 +121,7 @@ func StartService() error {
 			stopEverything := make(chan struct{})
 			ctx := context.Background()
 			go skuman.Start(utils.ToDuration(o.Options.SkuRefreshInterval))
+			loadforecast.Start(utils.ToDuration(o.Options.LoadForecastRefreshInterval), o.Options.LoadForecastHistoryDays)
 			go schedtag.Start(ctx, utils.ToDuration("30s"))
 
 			for _, f := range []func(ctx context.Context){
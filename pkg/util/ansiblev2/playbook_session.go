@@ -41,6 +41,8 @@ type IPlaybookSession interface {
 	GetRequirements() string
 	GetFiles() map[string][]byte
 	GetOutputWriter() io.Writer
+	GetResultWriter() io.Writer
+	IsCheckMode() bool
 	GetRolePublic() bool
 	GetTimeout() int
 	CheckAndSetRunning() bool
@@ -198,6 +200,9 @@ func (r runnable) Run(ctx context.Context) (err error) {
 		if config != "" {
 			args = append(args, "-e", "@"+config)
 		}
+		if r.IsCheckMode() {
+			args = append(args, "--check", "--diff")
+		}
 		if privateKey != "" {
 			args = append(args, "--private-key", privateKey)
 		}
@@ -206,6 +211,12 @@ func (r runnable) Run(ctx context.Context) (err error) {
 		cmd.Dir = tmpdir
 		cmd.Env = os.Environ()
 		cmd.Env = append(cmd.Env, "ANSIBLE_HOST_KEY_CHECKING=False")
+		resultWriter := r.GetResultWriter()
+		if resultWriter != nil {
+			// the json callback prints results of all plays to stdout
+			// as one document when the run completes
+			cmd.Env = append(cmd.Env, "ANSIBLE_STDOUT_CALLBACK=json")
+		}
 		// for debug
 		ioutil.WriteFile(path.Join(tmpdir, "run_cmd"), []byte(cmd.String()), os.ModePerm)
 		stdout, _ := cmd.StdoutPipe()
@@ -215,10 +226,28 @@ func (r runnable) Run(ctx context.Context) (err error) {
 			return
 		}
 		// Mix stdout, stderr
-		if writer := r.GetOutputWriter(); writer != nil {
-			go io.Copy(writer, stdout)
+		var (
+			writer        = r.GetOutputWriter()
+			stdoutWriter  = writer
+			stdoutCopying sync.WaitGroup
+		)
+		if writer != nil {
 			go io.Copy(writer, stderr)
 		}
+		if resultWriter != nil {
+			// stdout is the json document, which is left to the
+			// owner of result writer to render
+			stdoutWriter = resultWriter
+		}
+		if stdoutWriter != nil {
+			stdoutCopying.Add(1)
+			go func() {
+				defer stdoutCopying.Done()
+				io.Copy(stdoutWriter, stdout)
+			}()
+		}
+		// results must be fully read before wait closes the pipe
+		stdoutCopying.Wait()
 		if err1 := cmd.Wait(); err1 != nil {
 			errs = append(errs, errors.Wrapf(err1, "wait playbook %s", playbook))
 		}
@@ -231,6 +260,8 @@ type PlaybookSessionBase struct {
 
 	inventory    string
 	outputWriter io.Writer
+	resultWriter io.Writer
+	checkMode    bool
 	stateMux     *sync.Mutex
 	isRunning    bool
 	keepTmpdir   bool
@@ -257,6 +288,14 @@ func (pb *PlaybookSessionBase) GetOutputWriter() io.Writer {
 	return pb.outputWriter
 }
 
+func (pb *PlaybookSessionBase) GetResultWriter() io.Writer {
+	return pb.resultWriter
+}
+
+func (pb *PlaybookSessionBase) IsCheckMode() bool {
+	return pb.checkMode
+}
+
 func (pb *PlaybookSessionBase) CheckAndSetRunning() bool {
 	pb.stateMux.Lock()
 	if pb.isRunning {
@@ -0,0 +1,319 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansiblev2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+// PlaybookDiff is a change reported by a task run with --diff
+type PlaybookDiff struct {
+	BeforeHeader string `json:"before_header,omitempty"`
+	AfterHeader  string `json:"after_header,omitempty"`
+	Before       string `json:"before,omitempty"`
+	After        string `json:"after,omitempty"`
+	Prepared     string `json:"prepared,omitempty"`
+}
+
+// PlaybookTaskResult is the result of a task on one host.  Only changed or
+// failed tasks are recorded
+type PlaybookTaskResult struct {
+	Play    string         `json:"play"`
+	Task    string         `json:"task"`
+	Action  string         `json:"action"`
+	Changed bool           `json:"changed"`
+	Failed  bool           `json:"failed"`
+	Msg     string         `json:"msg,omitempty"`
+	Diffs   []PlaybookDiff `json:"diffs,omitempty"`
+}
+
+type PlaybookHostResult struct {
+	Host string `json:"host"`
+
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Failures    int `json:"failures"`
+	Unreachable int `json:"unreachable"`
+	Skipped     int `json:"skipped"`
+
+	Tasks []PlaybookTaskResult `json:"tasks"`
+}
+
+type jsonCallbackHostResult struct {
+	Action      string                   `json:"action"`
+	Changed     bool                     `json:"changed"`
+	Failed      bool                     `json:"failed"`
+	Unreachable bool                     `json:"unreachable"`
+	Msg         interface{}              `json:"msg"`
+	Diff        json.RawMessage          `json:"diff"`
+	Results     []jsonCallbackHostResult `json:"results"`
+}
+
+type jsonCallbackOutput struct {
+	Plays []struct {
+		Play struct {
+			Name string `json:"name"`
+		} `json:"play"`
+		Tasks []struct {
+			Task struct {
+				Name string `json:"name"`
+			} `json:"task"`
+			Hosts map[string]jsonCallbackHostResult `json:"hosts"`
+		} `json:"tasks"`
+	} `json:"plays"`
+	Stats map[string]struct {
+		Ok          int `json:"ok"`
+		Changed     int `json:"changed"`
+		Failures    int `json:"failures"`
+		Unreachable int `json:"unreachable"`
+		Skipped     int `json:"skipped"`
+	} `json:"stats"`
+}
+
+// ParsePlaybookResult parses output of ansible json stdout callback into per
+// host results, sorted by host name
+func ParsePlaybookResult(data []byte) ([]PlaybookHostResult, error) {
+	// skip anything printed before the json document
+	i := bytes.IndexByte(data, '{')
+	if i < 0 {
+		return nil, errors.Error("no json result found")
+	}
+	out := jsonCallbackOutput{}
+	if err := json.Unmarshal(data[i:], &out); err != nil {
+		return nil, errors.Wrap(err, "unmarshal json callback output")
+	}
+
+	hosts := map[string]*PlaybookHostResult{}
+	getHost := func(name string) *PlaybookHostResult {
+		h, ok := hosts[name]
+		if !ok {
+			h = &PlaybookHostResult{
+				Host:  name,
+				Tasks: []PlaybookTaskResult{},
+			}
+			hosts[name] = h
+		}
+		return h
+	}
+	for name, stats := range out.Stats {
+		h := getHost(name)
+		h.Ok = stats.Ok
+		h.Changed = stats.Changed
+		h.Failures = stats.Failures
+		h.Unreachable = stats.Unreachable
+		h.Skipped = stats.Skipped
+	}
+	for _, play := range out.Plays {
+		for _, task := range play.Tasks {
+			for name, res := range task.Hosts {
+				tr := PlaybookTaskResult{
+					Play:    play.Play.Name,
+					Task:    task.Task.Name,
+					Action:  res.Action,
+					Changed: res.Changed,
+					Failed:  res.Failed || res.Unreachable,
+					Msg:     stringify(res.Msg),
+					Diffs:   parseDiffs(res.Diff),
+				}
+				// loop results carry their own diffs
+				for _, item := range res.Results {
+					tr.Changed = tr.Changed || item.Changed
+					tr.Failed = tr.Failed || item.Failed || item.Unreachable
+					tr.Diffs = append(tr.Diffs, parseDiffs(item.Diff)...)
+				}
+				if !tr.Changed && !tr.Failed {
+					continue
+				}
+				h := getHost(name)
+				h.Tasks = append(h.Tasks, tr)
+			}
+		}
+	}
+
+	r := make([]PlaybookHostResult, 0, len(hosts))
+	for _, h := range hosts {
+		r = append(r, *h)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Host < r[j].Host
+	})
+	return r, nil
+}
+
+// parseDiffs parses the diff of task result, which is either a single diff or
+// a list of them
+func parseDiffs(data json.RawMessage) []PlaybookDiff {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	type rawDiff struct {
+		BeforeHeader string      `json:"before_header"`
+		AfterHeader  string      `json:"after_header"`
+		Before       interface{} `json:"before"`
+		After        interface{} `json:"after"`
+		Prepared     interface{} `json:"prepared"`
+	}
+	raws := []rawDiff{}
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil
+		}
+	} else {
+		raw := rawDiff{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil
+		}
+		raws = append(raws, raw)
+	}
+	var r []PlaybookDiff
+	for _, raw := range raws {
+		diff := PlaybookDiff{
+			BeforeHeader: raw.BeforeHeader,
+			AfterHeader:  raw.AfterHeader,
+			Before:       stringify(raw.Before),
+			After:        stringify(raw.After),
+			Prepared:     stringify(raw.Prepared),
+		}
+		if diff == (PlaybookDiff{}) {
+			continue
+		}
+		r = append(r, diff)
+	}
+	return r
+}
+
+// diffMaxCells bounds the work of line diff.  Contents larger than that are
+// shown as removed and added in whole
+const diffMaxCells = 1024 * 1024
+
+// FormatPlaybookResult renders per host results as a human readable summary
+// of changes, with diffs of changed tasks shown line by line
+func FormatPlaybookResult(hosts []PlaybookHostResult) string {
+	b := &strings.Builder{}
+	for _, h := range hosts {
+		fmt.Fprintf(b, "%s: ok=%d changed=%d failed=%d unreachable=%d skipped=%d\n",
+			h.Host, h.Ok, h.Changed, h.Failures, h.Unreachable, h.Skipped)
+		for _, task := range h.Tasks {
+			mark := "~"
+			if task.Failed {
+				mark = "!"
+			}
+			fmt.Fprintf(b, "  %s [%s] %s (%s)", mark, task.Play, task.Task, task.Action)
+			if task.Failed && task.Msg != "" {
+				fmt.Fprintf(b, ": %s", task.Msg)
+			}
+			b.WriteString("\n")
+			for _, diff := range task.Diffs {
+				formatDiff(b, diff)
+			}
+		}
+	}
+	return b.String()
+}
+
+func formatDiff(b *strings.Builder, diff PlaybookDiff) {
+	const indent = "      "
+	if diff.BeforeHeader != "" || diff.AfterHeader != "" {
+		fmt.Fprintf(b, "%s--- %s\n%s+++ %s\n", indent, diff.BeforeHeader, indent, diff.AfterHeader)
+	}
+	for _, line := range diffLines(splitLines(diff.Before), splitLines(diff.After)) {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	for _, line := range splitLines(diff.Prepared) {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns lines removed from a prefixed with "-" and lines added
+// in b prefixed with "+", in order.  Unchanged lines are left out
+func diffLines(a, b []string) []string {
+	var r []string
+	if len(a)*len(b) > diffMaxCells {
+		for _, line := range a {
+			r = append(r, "- "+line)
+		}
+		for _, line := range b {
+			r = append(r, "+ "+line)
+		}
+		return r
+	}
+	// lcs[i][j] is length of the longest common subsequence of a[i:], b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			r = append(r, "- "+a[i])
+			i++
+		default:
+			r = append(r, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		r = append(r, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		r = append(r, "+ "+b[j])
+	}
+	return r
+}
+
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansiblev2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlaybookResult(t *testing.T) {
+	output := `[WARNING]: something printed before the result
+{
+    "plays": [
+        {
+            "play": {"name": "Configure"},
+            "tasks": [
+                {
+                    "task": {"name": "Write conf"},
+                    "hosts": {
+                        "vm1": {
+                            "action": "template",
+                            "changed": true,
+                            "diff": [{"before_header": "/etc/a.conf", "after_header": "/etc/a.conf", "before": "a=1\n", "after": "a=2\n"}]
+                        },
+                        "vm0": {"action": "template", "changed": false, "diff": []}
+                    }
+                },
+                {
+                    "task": {"name": "Install packages"},
+                    "hosts": {
+                        "vm0": {
+                            "action": "package",
+                            "changed": true,
+                            "results": [
+                                {"changed": true, "diff": {"prepared": "installed: foo"}},
+                                {"changed": false}
+                            ]
+                        },
+                        "vm1": {"action": "package", "failed": true, "msg": "no package bar"}
+                    }
+                }
+            ]
+        }
+    ],
+    "stats": {
+        "vm0": {"ok": 2, "changed": 1, "failures": 0, "unreachable": 0, "skipped": 0},
+        "vm1": {"ok": 1, "changed": 1, "failures": 1, "unreachable": 0, "skipped": 0}
+    }
+}`
+	got, err := ParsePlaybookResult([]byte(output))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []PlaybookHostResult{
+		{
+			Host:    "vm0",
+			Ok:      2,
+			Changed: 1,
+			Tasks: []PlaybookTaskResult{
+				{
+					Play:    "Configure",
+					Task:    "Install packages",
+					Action:  "package",
+					Changed: true,
+					Diffs:   []PlaybookDiff{{Prepared: "installed: foo"}},
+				},
+			},
+		},
+		{
+			Host:     "vm1",
+			Ok:       1,
+			Changed:  1,
+			Failures: 1,
+			Tasks: []PlaybookTaskResult{
+				{
+					Play:    "Configure",
+					Task:    "Write conf",
+					Action:  "template",
+					Changed: true,
+					Diffs: []PlaybookDiff{
+						{
+							BeforeHeader: "/etc/a.conf",
+							AfterHeader:  "/etc/a.conf",
+							Before:       "a=1\n",
+							After:        "a=2\n",
+						},
+					},
+				},
+				{
+					Play:   "Configure",
+					Task:   "Install packages",
+					Action: "package",
+					Failed: true,
+					Msg:    "no package bar",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+
+	if _, err := ParsePlaybookResult([]byte("ERROR! no hosts")); err == nil {
+		t.Errorf("want error without json result")
+	}
+}
+
+func TestFormatPlaybookResult(t *testing.T) {
+	hosts := []PlaybookHostResult{
+		{
+			Host:    "vm0",
+			Ok:      2,
+			Changed: 1,
+			Tasks: []PlaybookTaskResult{
+				{
+					Play:    "Configure",
+					Task:    "Write conf",
+					Action:  "template",
+					Changed: true,
+					Diffs: []PlaybookDiff{
+						{
+							BeforeHeader: "/etc/a.conf",
+							AfterHeader:  "/etc/a.conf",
+							Before:       "a=1\nb=2\nc=3\n",
+							After:        "a=1\nb=4\nc=3\nd=5\n",
+						},
+						{Prepared: "installed: foo"},
+					},
+				},
+				{
+					Play:   "Configure",
+					Task:   "Install packages",
+					Action: "package",
+					Failed: true,
+					Msg:    "no package bar",
+				},
+			},
+		},
+		{
+			Host: "vm1",
+			Ok:   3,
+		},
+	}
+	want := `vm0: ok=2 changed=1 failed=0 unreachable=0 skipped=0
+  ~ [Configure] Write conf (template)
+      --- /etc/a.conf
+      +++ /etc/a.conf
+      - b=2
+      + b=4
+      + d=5
+      installed: foo
+  ! [Configure] Install packages (package): no package bar
+vm1: ok=3 changed=0 failed=0 unreachable=0 skipped=0
+`
+	if got := FormatPlaybookResult(hosts); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		a, b []string
+		want []string
+	}{
+		{nil, nil, nil},
+		{[]string{"x"}, nil, []string{"- x"}},
+		{nil, []string{"x"}, []string{"+ x"}},
+		{[]string{"a", "b", "c"}, []string{"a", "c"}, []string{"- b"}},
+		{[]string{"a", "c"}, []string{"b", "a", "c"}, []string{"+ b"}},
+		{[]string{"a", "b"}, []string{"a", "b"}, nil},
+	}
+	for _, c := range cases {
+		if got := diffLines(c.a, c.b); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", c.a, c.b, got, c.want)
+		}
+	}
+}
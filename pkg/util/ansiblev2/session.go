@@ -86,6 +86,20 @@ func (sess *Session) OutputWriter(w io.Writer) *Session {
 	return sess
 }
 
+// ResultWriter sets the writer receiving results of the run in format of
+// ansible json stdout callback.  See ParsePlaybookResult.  Stdout of the run
+// goes to it instead of output writer
+func (sess *Session) ResultWriter(w io.Writer) *Session {
+	sess.resultWriter = w
+	return sess
+}
+
+// CheckMode makes the run a dry run with diff of changes reported
+func (sess *Session) CheckMode(check bool) *Session {
+	sess.checkMode = check
+	return sess
+}
+
 func (sess *Session) KeepTmpdir(keep bool) *Session {
 	sess.keepTmpdir = keep
 	return sess
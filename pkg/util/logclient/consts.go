@@ -142,6 +142,7 @@ const (
 	ACT_HOST_IMPORT_LIBVIRT_SERVERS = "host_import_libvirt_servers"
 	ACT_GUEST_CREATE_FROM_IMPORT    = "guest_create_from_import"
 	ACT_GUEST_PANICKED              = "guest_panicked"
+	ACT_GUEST_WATCHDOG              = "guest_watchdog"
 	ACT_HOST_MAINTAINING            = "host_maintaining"
 
 	ACT_MKDIR          = "mkdir"
@@ -282,4 +283,6 @@ const (
 	ACT_CLEAN_PROJECT = "clean_project"
 
 	ACT_COLLECT_METRICS = "collect_metrics"
+
+	ACT_HARDWARE_HEALTH = "hardware_health"
 )
@@ -447,6 +447,10 @@ func init() {
 		EN("Guest Panicked").
 		CN("GuestPanicked"),
 	)
+	t.Set(ACT_GUEST_WATCHDOG, i18n.NewTableEntry().
+		EN("Guest Watchdog Timeout").
+		CN("虚拟机看门狗超时"),
+	)
 	t.Set(ACT_HOST_MAINTAINING, i18n.NewTableEntry().
 		EN("Host Maintaining").
 		CN("宿主机进入维护模式"),
@@ -1455,4 +1459,9 @@ func init() {
 		EN("Collect monitoring metrics").
 		CN("采集监控指标"),
 	)
+
+	t.Set(ACT_HARDWARE_HEALTH, i18n.NewTableEntry().
+		EN("Hardware Health").
+		CN("硬件健康状态"),
+	)
 }
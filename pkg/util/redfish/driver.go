@@ -85,6 +85,13 @@ type IRedfishDriver interface {
 	SetNTPConf(ctx context.Context, conf SNTPConf) error
 
 	GetConsoleJNLP(ctx context.Context) (string, error)
+
+	GetInventory(ctx context.Context) (SHardwareInventory, error)
+
+	GetEventService(ctx context.Context) (string, SEventService, error)
+	SubscribeEvents(ctx context.Context, destination string, eventContext string) (string, error)
+	UnsubscribeEvents(ctx context.Context, path string) error
+	ReadEventStream(ctx context.Context, handler func(SEventPayload)) error
 }
 
 var defaultFactory IRedfishDriverFactory
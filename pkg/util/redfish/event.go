@@ -0,0 +1,221 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+var subscribeEventTypes = []string{
+	"Alert",
+	"StatusChange",
+}
+
+func (r *SBaseRedfishClient) GetEventService(ctx context.Context) (string, SEventService, error) {
+	svc := SEventService{}
+	path, resp, err := r.GetResource(ctx, "EventService")
+	if err != nil {
+		return "", svc, errors.Wrap(err, "GetResource EventService")
+	}
+	err = resp.Unmarshal(&svc)
+	if err != nil {
+		return "", svc, errors.Wrap(err, "resp.Unmarshal")
+	}
+	return path, svc, nil
+}
+
+func (r *SBaseRedfishClient) getMembers(ctx context.Context, collection jsonutils.JSONObject) ([]jsonutils.JSONObject, error) {
+	collection = r.IRedfishDriver().GetParent(collection)
+	links, err := collection.GetArray(r.IRedfishDriver().MemberKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "find member list fail")
+	}
+	members := make([]jsonutils.JSONObject, 0, len(links))
+	for i := range links {
+		path, _ := links[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(path) == 0 {
+			continue
+		}
+		resp, err := r.Get(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "r.Get %s", path)
+		}
+		members = append(members, resp)
+	}
+	return members, nil
+}
+
+func (r *SBaseRedfishClient) getEventSubscriptions(ctx context.Context) (string, []SEventSubscription, error) {
+	path, resp, err := r.GetResource(ctx, "EventService", "Subscriptions")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "GetResource EventService Subscriptions")
+	}
+	members, err := r.getMembers(ctx, resp)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "getMembers")
+	}
+	subs := make([]SEventSubscription, 0, len(members))
+	for i := range members {
+		sub := SEventSubscription{}
+		err := members[i].Unmarshal(&sub)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "Unmarshal subscription")
+		}
+		sub.Path, _ = members[i].GetString(r.IRedfishDriver().LinkKey())
+		subs = append(subs, sub)
+	}
+	return path, subs, nil
+}
+
+// SubscribeEvents registers destination to receive Alert and StatusChange
+// events, replacing any previous subscription of the same destination so that
+// the call is idempotent. It returns the path of the new subscription.
+func (r *SBaseRedfishClient) SubscribeEvents(ctx context.Context, destination string, eventContext string) (string, error) {
+	_, svc, err := r.GetEventService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetEventService")
+	}
+	if !svc.ServiceEnabled {
+		return "", errors.Wrap(httperrors.ErrNotSupported, "event service disabled")
+	}
+	subsPath, subs, err := r.getEventSubscriptions(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "getEventSubscriptions")
+	}
+	for i := range subs {
+		if subs[i].Destination != destination || len(subs[i].Path) == 0 {
+			continue
+		}
+		_, _, err := r.Delete(ctx, subs[i].Path)
+		if err != nil {
+			return "", errors.Wrapf(err, "delete stale subscription %s", subs[i].Path)
+		}
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(destination), "Destination")
+	params.Add(jsonutils.NewString(eventContext), "Context")
+	params.Add(jsonutils.NewString("Redfish"), "Protocol")
+	eventTypes := make([]string, 0)
+	for _, et := range subscribeEventTypes {
+		if utils.IsInStringArray(et, svc.EventTypesForSubscription) {
+			eventTypes = append(eventTypes, et)
+		}
+	}
+	if len(eventTypes) > 0 {
+		params.Add(jsonutils.NewStringArray(eventTypes), "EventTypes")
+	}
+	hdr, _, err := r.Post(ctx, subsPath, params)
+	if err != nil {
+		return "", errors.Wrap(err, "r.Post")
+	}
+	subPath := hdr.Get("Location")
+	pos := strings.Index(subPath, r.IRedfishDriver().BasePath())
+	if pos > 0 {
+		subPath = subPath[pos:]
+	}
+	return subPath, nil
+}
+
+func (r *SBaseRedfishClient) UnsubscribeEvents(ctx context.Context, path string) error {
+	_, _, err := r.Delete(ctx, path)
+	if err != nil {
+		return errors.Wrap(err, "r.Delete")
+	}
+	return nil
+}
+
+// ReadEventStream connects to the server sent event stream of the event
+// service and calls handler for each received event until ctx is canceled
+// or the BMC closes the stream
+func (r *SBaseRedfishClient) ReadEventStream(ctx context.Context, handler func(SEventPayload)) error {
+	_, svc, err := r.GetEventService(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetEventService")
+	}
+	if !svc.ServiceEnabled || len(svc.ServerSentEventUri) == 0 {
+		return errors.Wrap(httperrors.ErrNotSupported, "server sent event not supported")
+	}
+	header := http.Header{}
+	r.setAuthHeader(header)
+	header.Set("Accept", "text/event-stream")
+	client := httputils.GetTimeoutClient(0)
+	resp, err := httputils.Request(client, ctx, httputils.GET, httputils.JoinPath(r.endpoint, svc.ServerSentEventUri), header, nil, r.IsDebug)
+	if err != nil {
+		return errors.Wrap(err, "httputils.Request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("server sent event stream status %d", resp.StatusCode)
+	}
+	return ParseEventStream(resp.Body, handler)
+}
+
+func ParseEventPayload(body jsonutils.JSONObject) (SEventPayload, error) {
+	payload := SEventPayload{}
+	err := body.Unmarshal(&payload)
+	if err != nil {
+		return payload, errors.Wrap(err, "Unmarshal event")
+	}
+	return payload, nil
+}
+
+// ParseEventStream splits a text/event-stream into events and hands over the
+// decoded data field of each event
+func ParseEventStream(reader io.Reader, handler func(SEventPayload)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	data := make([]string, 0)
+	dispatch := func() {
+		if len(data) == 0 {
+			return
+		}
+		body, err := jsonutils.ParseString(strings.Join(data, "\n"))
+		data = data[:0]
+		if err != nil {
+			log.Errorf("parse server sent event fail %s", err)
+			return
+		}
+		payload, err := ParseEventPayload(body)
+		if err != nil {
+			log.Errorf("ParseEventPayload fail %s", err)
+			return
+		}
+		handler(payload)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			dispatch()
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	dispatch()
+	return scanner.Err()
+}
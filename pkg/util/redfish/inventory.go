@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+)
+
+func (r *SBaseRedfishClient) getMemoryDimms(ctx context.Context) ([]SMemoryDimm, error) {
+	_, resp, err := r.GetResource(ctx, "Systems", "0", "Memory")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0 Memory")
+	}
+	members, err := r.getMembers(ctx, resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMembers")
+	}
+	dimms := make([]SMemoryDimm, 0, len(members))
+	for i := range members {
+		dimm := SMemoryDimm{}
+		err := members[i].Unmarshal(&dimm)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal dimm")
+		}
+		dimms = append(dimms, dimm)
+	}
+	return dimms, nil
+}
+
+func (r *SBaseRedfishClient) getStorageDrives(ctx context.Context) ([]SStorageDrive, error) {
+	_, resp, err := r.GetResource(ctx, "Systems", "0", "Storage")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0 Storage")
+	}
+	storages, err := r.getMembers(ctx, resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMembers")
+	}
+	drives := make([]SStorageDrive, 0)
+	for i := range storages {
+		links, _ := storages[i].GetArray("Drives")
+		for j := range links {
+			path, _ := links[j].GetString(r.IRedfishDriver().LinkKey())
+			if len(path) == 0 {
+				continue
+			}
+			driveJson, err := r.Get(ctx, path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "r.Get %s", path)
+			}
+			drive := SStorageDrive{}
+			err = driveJson.Unmarshal(&drive)
+			if err != nil {
+				return nil, errors.Wrap(err, "Unmarshal drive")
+			}
+			drives = append(drives, drive)
+		}
+	}
+	return drives, nil
+}
+
+func (r *SBaseRedfishClient) getFirmwares(ctx context.Context) ([]SFirmware, error) {
+	_, resp, err := r.GetResource(ctx, "UpdateService", "FirmwareInventory")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource UpdateService FirmwareInventory")
+	}
+	members, err := r.getMembers(ctx, resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMembers")
+	}
+	firmwares := make([]SFirmware, 0, len(members))
+	for i := range members {
+		fw := SFirmware{}
+		err := members[i].Unmarshal(&fw)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal firmware")
+		}
+		firmwares = append(firmwares, fw)
+	}
+	return firmwares, nil
+}
+
+func (r *SBaseRedfishClient) getPowerSupplies(ctx context.Context) ([]SPowerSupply, error) {
+	path := r.IRedfishDriver().GetPowerPath()
+	var resp jsonutils.JSONObject
+	var err error
+	if len(path) > 0 {
+		resp, err = r.Get(ctx, path)
+	} else {
+		_, resp, err = r.GetResource(ctx, "Chassis", "0", "Power")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get power")
+	}
+	psus := make([]SPowerSupply, 0)
+	err = resp.Unmarshal(&psus, "PowerSupplies")
+	if err != nil {
+		return nil, errors.Wrap(err, "resp.Unmarshal")
+	}
+	return psus, nil
+}
+
+// GetInventory collects DIMMs, drives, firmware versions and power supplies.
+// BMCs commonly implement only part of these collections, so a collection
+// that fails to load is left empty and recorded in FailedCollections, and
+// only a total failure is an error.
+func (r *SBaseRedfishClient) GetInventory(ctx context.Context) (SHardwareInventory, error) {
+	inv := SHardwareInventory{}
+	errs := make([]error, 0)
+	var err error
+	inv.Dimms, err = r.getMemoryDimms(ctx)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "getMemoryDimms"))
+		inv.FailedCollections = append(inv.FailedCollections, INVENTORY_DIMMS)
+	}
+	inv.Drives, err = r.getStorageDrives(ctx)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "getStorageDrives"))
+		inv.FailedCollections = append(inv.FailedCollections, INVENTORY_DRIVES)
+	}
+	inv.Firmwares, err = r.getFirmwares(ctx)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "getFirmwares"))
+		inv.FailedCollections = append(inv.FailedCollections, INVENTORY_FIRMWARES)
+	}
+	inv.PowerSupplies, err = r.getPowerSupplies(ctx)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "getPowerSupplies"))
+		inv.FailedCollections = append(inv.FailedCollections, INVENTORY_POWER_SUPPLIES)
+	}
+	if len(errs) == 4 {
+		return inv, errors.NewAggregate(errs)
+	}
+	for _, err := range errs {
+		log.Warningf("GetInventory of %s partially fail: %s", r.GetHost(), err)
+	}
+	return inv, nil
+}
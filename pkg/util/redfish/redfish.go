@@ -97,17 +97,21 @@ func (r *SBaseRedfishClient) GetEndpoint() string {
 	return r.endpoint
 }
 
-func (r *SBaseRedfishClient) request(ctx context.Context, method httputils.THttpMethod, path string, header http.Header, body jsonutils.JSONObject) (http.Header, jsonutils.JSONObject, error) {
-	urlStr := httputils.JoinPath(r.endpoint, path)
-	if header == nil {
-		header = http.Header{}
-	}
+func (r *SBaseRedfishClient) setAuthHeader(header http.Header) {
 	if len(r.SessionToken) > 0 {
 		header.Set("X-Auth-Token", r.SessionToken)
 	} else {
 		authStr := r.username + ":" + r.password
 		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(authStr)))
 	}
+}
+
+func (r *SBaseRedfishClient) request(ctx context.Context, method httputils.THttpMethod, path string, header http.Header, body jsonutils.JSONObject) (http.Header, jsonutils.JSONObject, error) {
+	urlStr := httputils.JoinPath(r.endpoint, path)
+	if header == nil {
+		header = http.Header{}
+	}
+	r.setAuthHeader(header)
 	// !!!always close http connection for redfish API server
 	header.Set("Connection", "Close")
 	header.Set("Odata-Version", "4.0")
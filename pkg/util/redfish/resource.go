@@ -130,3 +130,231 @@ type SNTPConf struct {
 	ProtocolEnabled bool     `json:"ProtocolEnabled,allowfalse"`
 	TimeZone        string   `json:"TimeZone"`
 }
+
+const (
+	HEALTH_OK       = "OK"
+	HEALTH_WARNING  = "Warning"
+	HEALTH_CRITICAL = "Critical"
+
+	STATE_ABSENT = "Absent"
+)
+
+const (
+	INVENTORY_DIMMS          = "Dimms"
+	INVENTORY_DRIVES         = "Drives"
+	INVENTORY_FIRMWARES      = "Firmwares"
+	INVENTORY_POWER_SUPPLIES = "PowerSupplies"
+)
+
+type SStatus struct {
+	State  string `json:"State"`
+	Health string `json:"Health"`
+}
+
+// IsPresent returns false for empty slots, e.g. DIMM sockets or drive bays
+// that are reported by the BMC but not populated
+func (s SStatus) IsPresent() bool {
+	return s.State != STATE_ABSENT
+}
+
+type SMemoryDimm struct {
+	Id                string  `json:"Id"`
+	Name              string  `json:"Name"`
+	Manufacturer      string  `json:"Manufacturer"`
+	PartNumber        string  `json:"PartNumber"`
+	SerialNumber      string  `json:"SerialNumber"`
+	MemoryDeviceType  string  `json:"MemoryDeviceType"`
+	CapacityMiB       int     `json:"CapacityMiB"`
+	OperatingSpeedMhz int     `json:"OperatingSpeedMhz"`
+	Status            SStatus `json:"Status"`
+}
+
+type SStorageDrive struct {
+	Id            string  `json:"Id"`
+	Name          string  `json:"Name"`
+	Manufacturer  string  `json:"Manufacturer"`
+	Model         string  `json:"Model"`
+	SerialNumber  string  `json:"SerialNumber"`
+	Revision      string  `json:"Revision"`
+	MediaType     string  `json:"MediaType"`
+	Protocol      string  `json:"Protocol"`
+	CapacityBytes int64   `json:"CapacityBytes"`
+	Status        SStatus `json:"Status"`
+}
+
+type SFirmware struct {
+	Id         string  `json:"Id"`
+	Name       string  `json:"Name"`
+	Version    string  `json:"Version"`
+	Updateable bool    `json:"Updateable"`
+	Status     SStatus `json:"Status"`
+}
+
+type SPowerSupply struct {
+	MemberId             string  `json:"MemberId"`
+	Name                 string  `json:"Name"`
+	Model                string  `json:"Model"`
+	SerialNumber         string  `json:"SerialNumber"`
+	FirmwareVersion      string  `json:"FirmwareVersion"`
+	PowerCapacityWatts   int     `json:"PowerCapacityWatts"`
+	LastPowerOutputWatts int     `json:"LastPowerOutputWatts"`
+	Status               SStatus `json:"Status"`
+}
+
+type SHardwareInventory struct {
+	Dimms         []SMemoryDimm   `json:"Dimms"`
+	Drives        []SStorageDrive `json:"Drives"`
+	Firmwares     []SFirmware     `json:"Firmwares"`
+	PowerSupplies []SPowerSupply  `json:"PowerSupplies"`
+
+	// collections failed to load, whose components are unknown
+	FailedCollections []string `json:"FailedCollections"`
+}
+
+func healthLevel(health string) int {
+	switch health {
+	case HEALTH_CRITICAL:
+		return 2
+	case HEALTH_WARNING:
+		return 1
+	}
+	return 0
+}
+
+func worseHealth(a, b string) string {
+	if healthLevel(b) > healthLevel(a) {
+		return b
+	}
+	return a
+}
+
+// Health returns the worst health of all present components
+func (inv SHardwareInventory) Health() string {
+	health := HEALTH_OK
+	for _, d := range inv.Dimms {
+		if d.Status.IsPresent() {
+			health = worseHealth(health, d.Status.Health)
+		}
+	}
+	for _, d := range inv.Drives {
+		if d.Status.IsPresent() {
+			health = worseHealth(health, d.Status.Health)
+		}
+	}
+	for _, p := range inv.PowerSupplies {
+		if p.Status.IsPresent() {
+			health = worseHealth(health, p.Status.Health)
+		}
+	}
+	return health
+}
+
+func (inv SHardwareInventory) ToMetrics() []influxdb.SKeyValue {
+	failed := func(st SStatus) bool {
+		return st.IsPresent() && healthLevel(st.Health) > 0
+	}
+	dimms, drives, psus := 0, 0, 0
+	for _, d := range inv.Dimms {
+		if failed(d.Status) {
+			dimms++
+		}
+	}
+	for _, d := range inv.Drives {
+		if failed(d.Status) {
+			drives++
+		}
+	}
+	for _, p := range inv.PowerSupplies {
+		if failed(p.Status) {
+			psus++
+		}
+	}
+	return []influxdb.SKeyValue{
+		{
+			Key:   "health",
+			Value: strconv.FormatInt(int64(healthLevel(inv.Health())), 10),
+		},
+		{
+			Key:   "failed_dimms",
+			Value: strconv.FormatInt(int64(dimms), 10),
+		},
+		{
+			Key:   "failed_drives",
+			Value: strconv.FormatInt(int64(drives), 10),
+		},
+		{
+			Key:   "failed_power_supplies",
+			Value: strconv.FormatInt(int64(psus), 10),
+		},
+	}
+}
+
+const (
+	EVENT_TYPE_ALERT = "alert"
+
+	EVENT_MODE_WEBHOOK = "webhook"
+	EVENT_MODE_SSE     = "sse"
+	EVENT_MODE_OFF     = "off"
+)
+
+type SEventService struct {
+	ServiceEnabled            bool     `json:"ServiceEnabled"`
+	ServerSentEventUri        string   `json:"ServerSentEventUri"`
+	EventTypesForSubscription []string `json:"EventTypesForSubscription"`
+}
+
+type SEventSubscription struct {
+	Id          string `json:"Id"`
+	Destination string `json:"Destination"`
+	Context     string `json:"Context"`
+	Protocol    string `json:"Protocol"`
+
+	Path string
+}
+
+type SEventRecord struct {
+	EventId         string `json:"EventId"`
+	EventType       string `json:"EventType"`
+	EventTimestamp  string `json:"EventTimestamp"`
+	Severity        string `json:"Severity"`
+	MessageSeverity string `json:"MessageSeverity"`
+	Message         string `json:"Message"`
+	MessageId       string `json:"MessageId"`
+}
+
+// SEventPayload is the body of a Redfish Event pushed to a subscription
+// destination or received from the server sent event stream
+type SEventPayload struct {
+	Id      string         `json:"Id"`
+	Name    string         `json:"Name"`
+	Context string         `json:"Context"`
+	Events  []SEventRecord `json:"Events"`
+}
+
+func (rec SEventRecord) ToEvent(now time.Time) SEvent {
+	created := now
+	if len(rec.EventTimestamp) > 0 {
+		if tm, err := time.Parse(time.RFC3339, rec.EventTimestamp); err == nil {
+			created = tm.UTC()
+		}
+	}
+	severity := rec.MessageSeverity
+	if len(severity) == 0 {
+		severity = rec.Severity
+	}
+	eventId := rec.EventId
+	if len(eventId) == 0 {
+		eventId = rec.MessageId
+	}
+	return SEvent{
+		Created:  created,
+		EventId:  eventId,
+		Message:  rec.Message,
+		Severity: severity,
+		Type:     EVENT_TYPE_ALERT,
+	}
+}
+
+func (e SEvent) IsAlert() bool {
+	return healthLevel(e.Severity) > 0
+}
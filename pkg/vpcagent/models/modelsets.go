@@ -230,6 +230,26 @@ func (mss *ModelSets) ApplyUpdates(mssNews apihelper.IModelSets) apihelper.Model
 	return r
 }
 
+// ApplyPatch applies the models of patch, those absent from it are kept
+func (mss *ModelSets) ApplyPatch(patch *ModelSets) apihelper.ModelSetsUpdateResult {
+	r := apihelper.ModelSetsUpdateResult{
+		Changed: false,
+		Correct: true,
+	}
+	mssList := mss.ModelSetList()
+	patchList := patch.ModelSetList()
+	for i, ms := range mssList {
+		msR := apihelper.ModelSetApplyPatch(ms, patchList[i])
+		if !r.Changed && msR.Changed {
+			r.Changed = true
+		}
+	}
+	if r.Changed {
+		r.Correct = mss.join()
+	}
+	return r
+}
+
 func (mss *ModelSets) FetchFromAPIMap(s *mcclient.ClientSession) (apihelper.IModelSets, error) {
 	mssNews := mss.NewEmpty()
 	ret, err := apimap.APIMap.GetVPCAgentTopo(s)
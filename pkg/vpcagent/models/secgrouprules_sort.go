@@ -53,11 +53,23 @@ func (el *Guest) OrderedSecurityGroupRules() []*SecurityGroupRule {
 	return rs
 }
 
+// FlowLogEnabled returns whether any security group of the guest has flow
+// log enabled
+func (el *Guest) FlowLogEnabled() bool {
+	for _, secgroup := range el.SecurityGroups {
+		if secgroup.EnableFlowLog {
+			return true
+		}
+	}
+	return el.AdminSecurityGroup != nil && el.AdminSecurityGroup.EnableFlowLog
+}
+
 func (el *SecurityGroup) securityGroupRules(basePriority int64) []*SecurityGroupRule {
 	rs := make([]*SecurityGroupRule, 0, len(el.SecurityGroupRules))
 	for _, r := range el.SecurityGroupRules {
 		r = r.Copy()
 		r.Priority += int(basePriority)
+		r.SecurityGroup = el
 		rs = append(rs, r)
 	}
 	return rs
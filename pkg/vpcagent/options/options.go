@@ -42,6 +42,10 @@ type VpcAgentOptions struct {
 	OvnWorkerCheckInterval int    `default:"180"`
 	OvnNorthDatabase       string `help:"address for accessing ovn north database.  Default to local unix socket"`
 	OvnUnderlayMtu         int    `help:"mtu of ovn underlay network" default:"1500"`
+	OvnAclLogMeterRate     int    `help:"max packets per second logged by acls of security groups with flow log enabled" default:"100"`
+
+	OvnIncremental        bool `help:"apply region change notifications to ovn north database per vpc, full sweep is still done every ovn_worker_check_interval seconds"`
+	OvnIncrementalMaxVpcs int  `help:"do full sweep when more vpcs than this are affected by region changes" default:"64"`
 
 	DhcpLeaseTime   int `default:"100663296" help:"DHCP lease time in seconds"`
 	DhcpRenewalTime int `default:"67108864" help:"DHCP renewal time in seconds"`
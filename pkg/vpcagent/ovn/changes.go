@@ -0,0 +1,266 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"sync"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/sets"
+
+	"yunion.io/x/onecloud/pkg/apihelper"
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/informer"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+// sResourceChange is a change of region resource notified by the informer
+type sResourceChange struct {
+	keyword string
+	obj     *jsonutils.JSONDict
+
+	// deleted is set when obj is removed from region
+	deleted bool
+	// stale is set when obj is the old side of an update.  It's only for
+	// finding the affected vpcs
+	stale bool
+}
+
+func (c sResourceChange) getString(key string) string {
+	val, _ := c.obj.GetString(key)
+	return val
+}
+
+// sChangeTracker collects the region changes for the worker to apply
+type sChangeTracker struct {
+	lock     *sync.Mutex
+	changes  []sResourceChange
+	watching bool
+
+	onChange func()
+}
+
+func newChangeTracker(onChange func()) *sChangeTracker {
+	return &sChangeTracker{
+		lock:     new(sync.Mutex),
+		onChange: onChange,
+	}
+}
+
+// Start watches the resources of model sets, watching is only done when all
+// of them are watched
+func (t *sChangeTracker) Start(ctx context.Context, region string, mss *agentmodels.ModelSets) {
+	s := auth.GetAdminSession(ctx, region)
+	informer.NewWatchManagerBySessionBg(s, func(watchMan *informer.SWatchManager) error {
+		for _, ms := range mss.ModelSetList() {
+			resMan, ok := ms.ModelManager().(informer.IResourceManager)
+			if !ok {
+				continue
+			}
+			handler := &sChangeHandler{tracker: t, keyword: resMan.GetKeyword()}
+			if err := watchMan.For(resMan).AddEventHandler(ctx, handler); err != nil {
+				return errors.Wrapf(err, "watch %s", resMan.GetKeyword())
+			}
+		}
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.watching = true
+		return nil
+	})
+}
+
+func (t *sChangeTracker) add(change sResourceChange) {
+	t.lock.Lock()
+	t.changes = append(t.changes, change)
+	t.lock.Unlock()
+
+	t.onChange()
+}
+
+// Pop returns the pending changes, ok is false if the tracker can't tell the
+// changes as it's not watching yet
+func (t *sChangeTracker) Pop() ([]sResourceChange, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	changes := t.changes
+	t.changes = nil
+	return changes, t.watching
+}
+
+type sChangeHandler struct {
+	tracker *sChangeTracker
+	keyword string
+}
+
+func (h *sChangeHandler) OnAdd(obj *jsonutils.JSONDict) {
+	h.tracker.add(sResourceChange{keyword: h.keyword, obj: obj})
+}
+
+func (h *sChangeHandler) OnUpdate(oldObj, newObj *jsonutils.JSONDict) {
+	h.tracker.add(sResourceChange{keyword: h.keyword, obj: oldObj, stale: true})
+	h.tracker.add(sResourceChange{keyword: h.keyword, obj: newObj})
+}
+
+func (h *sChangeHandler) OnDelete(obj *jsonutils.JSONDict) {
+	h.tracker.add(sResourceChange{keyword: h.keyword, obj: obj, deleted: true})
+}
+
+// newChangePatch makes model sets of the objects changed.  Deleted objects
+// are marked so that applying the patch removes them.  Objects managed by
+// cloud providers are not listed by api helper and are left out here too
+func newChangePatch(changes []sResourceChange) (*agentmodels.ModelSets, error) {
+	patch := agentmodels.NewModelSets()
+	sets := map[string]apihelper.IModelSet{}
+	for _, ms := range patch.ModelSetList() {
+		sets[ms.ModelManager().GetKeyword()] = ms
+	}
+	for _, change := range changes {
+		if change.stale || change.getString("manager_id") != "" {
+			continue
+		}
+		ms, ok := sets[change.keyword]
+		if !ok {
+			continue
+		}
+		obj := change.obj
+		if change.deleted {
+			obj = obj.Copy()
+			obj.Set("deleted", jsonutils.JSONTrue)
+		}
+		m := ms.NewModel()
+		if err := obj.Unmarshal(m); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal %s", change.keyword)
+		}
+		ms.AddModel(m)
+	}
+	return patch, nil
+}
+
+// sChangeScope is the vpcs affected by the changes
+type sChangeScope struct {
+	full   bool
+	vpcIds sets.String
+}
+
+func newChangeScope() *sChangeScope {
+	return &sChangeScope{
+		vpcIds: sets.NewString(),
+	}
+}
+
+func (scope *sChangeScope) addVpc(vpcId string) {
+	if vpcId != "" && vpcId != apis.DEFAULT_VPC_ID {
+		scope.vpcIds.Insert(vpcId)
+	}
+}
+
+func (scope *sChangeScope) addWire(mss *agentmodels.ModelSets, wireId string) {
+	if wire, ok := mss.Wires[wireId]; ok {
+		scope.addVpc(wire.VpcId)
+	}
+}
+
+func (scope *sChangeScope) addNetwork(mss *agentmodels.ModelSets, networkId string) {
+	if network, ok := mss.Networks[networkId]; ok {
+		scope.addWire(mss, network.WireId)
+	}
+}
+
+func (scope *sChangeScope) addGuest(mss *agentmodels.ModelSets, guestId string) {
+	if guest, ok := mss.Guests[guestId]; ok {
+		for _, guestnetwork := range guest.Guestnetworks {
+			scope.addNetwork(mss, guestnetwork.NetworkId)
+		}
+	}
+}
+
+func (scope *sChangeScope) addSecurityGroup(mss *agentmodels.ModelSets, secgroupId string) {
+	for _, guest := range mss.Guests {
+		if guest.AdminSecurityGroup != nil && guest.AdminSecurityGroup.Id == secgroupId {
+			scope.addGuest(mss, guest.Id)
+			continue
+		}
+		if _, ok := guest.SecurityGroups[secgroupId]; ok {
+			scope.addGuest(mss, guest.Id)
+		}
+	}
+}
+
+// resolve finds the vpcs affected by the change in the model sets, the
+// changes which can't be scoped by vpcs require a full run
+func (scope *sChangeScope) resolve(mss *agentmodels.ModelSets, change sResourceChange) {
+	id := change.getString("id")
+	switch change.keyword {
+	case mss.Vpcs.ModelManager().GetKeyword():
+		scope.addVpc(id)
+	case mss.Wires.ModelManager().GetKeyword():
+		scope.addVpc(change.getString("vpc_id"))
+	case mss.RouteTables.ModelManager().GetKeyword():
+		scope.addVpc(change.getString("vpc_id"))
+	case mss.Networks.ModelManager().GetKeyword():
+		scope.addNetwork(mss, id)
+		scope.addWire(mss, change.getString("wire_id"))
+	case mss.Guestnetworks.ModelManager().GetKeyword(),
+		mss.NetworkAddresses.ModelManager().GetKeyword(),
+		mss.Groupnetworks.ModelManager().GetKeyword(),
+		mss.LoadbalancerNetworks.ModelManager().GetKeyword():
+		scope.addNetwork(mss, change.getString("network_id"))
+	case mss.Guests.ModelManager().GetKeyword():
+		scope.addGuest(mss, id)
+	case mss.Guestsecgroups.ModelManager().GetKeyword(),
+		mss.Groupguests.ModelManager().GetKeyword():
+		scope.addGuest(mss, change.getString("guest_id"))
+	case mss.SecurityGroups.ModelManager().GetKeyword():
+		scope.addSecurityGroup(mss, id)
+	case mss.SecurityGroupRules.ModelManager().GetKeyword():
+		scope.addSecurityGroup(mss, change.getString("secgroup_id"))
+	case mss.Hosts.ModelManager().GetKeyword():
+		for _, guest := range mss.Guests {
+			if guest.HostId == id {
+				scope.addGuest(mss, guest.Id)
+			}
+		}
+	case mss.Elasticips.ModelManager().GetKeyword():
+		if change.getString("associate_type") == apis.EIP_ASSOCIATE_TYPE_SERVER {
+			scope.addGuest(mss, change.getString("associate_id"))
+		}
+		scope.addNetwork(mss, change.getString("network_id"))
+	default:
+		// dns records are claimed for all vpcs, loadbalancer listeners and
+		// acls are not tracked back to vpcs
+		scope.full = true
+	}
+}
+
+// resolveChangeScope finds the vpcs affected by the changes in the model sets
+// before and after the changes, so that both the removed and the added
+// objects are covered
+func resolveChangeScope(changes []sResourceChange, prev, mss *agentmodels.ModelSets) *sChangeScope {
+	scope := newChangeScope()
+	for _, change := range changes {
+		scope.resolve(prev, change)
+		scope.resolve(mss, change)
+		if scope.full {
+			log.Infof("ovn: %s change requires full run", change.keyword)
+			break
+		}
+	}
+	return scope
+}
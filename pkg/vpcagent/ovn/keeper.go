@@ -21,7 +21,6 @@ import (
 	"strings"
 
 	"yunion.io/x/log"
-	"yunion.io/x/ovsdb/cli_util"
 	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/ovsdb/types"
 	"yunion.io/x/pkg/errors"
@@ -40,14 +39,23 @@ import (
 const (
 	externalKeyOcVersion = "oc-version"
 	externalKeyOcRef     = "oc-ref"
+	externalKeyOcAclLog  = "oc-acl-log"
 )
 
+// iOvnNbCtl runs ovn-nbctl commands, it panics on failure
+type iOvnNbCtl interface {
+	Must(ctx context.Context, msg string, args []string) *ovnutil.CmdResult
+}
+
 type OVNNorthboundKeeper struct {
 	DB  ovn_nb.OVNNorthbound
-	cli *ovnutil.OvnNbCtl
+	cli iOvnNbCtl
+
+	// pinned rows are kept by sweep even if not claimed
+	pinned []types.IRow
 }
 
-func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthboundKeeper, error) {
+func DumpOVNNorthbound(ctx context.Context, cli iOvnNbCtl) (*OVNNorthboundKeeper, error) {
 	db := ovn_nb.OVNNorthbound{}
 	itbls := []types.ITable{
 		&db.LogicalSwitch,
@@ -59,15 +67,12 @@ func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthbou
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.Meter,
+		&db.MeterBand,
 	}
-	args := []string{"--format=json", "list", "<tbl>"}
 	for _, itbl := range itbls {
-		tbl := itbl.OvsdbTableName()
-		args[2] = tbl
-		res := cli.Must(ctx, "List "+tbl, args)
-		if err := cli_util.UnmarshalJSON([]byte(res.Output), itbl); err != nil {
-			return nil, errors.Wrapf(err, "Unmarshal %s:\n%s",
-				itbl.OvsdbTableName(), res.Output)
+		if err := dumpTable(ctx, cli, itbl); err != nil {
+			return nil, err
 		}
 	}
 	keeper := &OVNNorthboundKeeper{
@@ -88,6 +93,34 @@ func ovnCreateArgs(irow types.IRow, idRef string) []string {
 	return args
 }
 
+// ClaimAclLogMeter makes sure the meter limiting the rate of acl logs exists
+func (keeper *OVNNorthboundKeeper) ClaimAclLogMeter(ctx context.Context, rate int) error {
+	var (
+		db   = &keeper.DB
+		args []string
+	)
+	for i := range db.Meter {
+		meter := &db.Meter[i]
+		if meter.Name != aclLogMeterName {
+			continue
+		}
+		for _, bandUuid := range meter.Bands {
+			band := db.MeterBand.FindOneMatchNonZeros(&ovn_nb.MeterBand{
+				Uuid:   bandUuid,
+				Action: "drop",
+				Rate:   int64(rate),
+			})
+			if band != nil && len(meter.Bands) == 1 {
+				return nil
+			}
+		}
+		args = append(args, "--", "meter-del", aclLogMeterName)
+		break
+	}
+	args = append(args, "--", "meter-add", aclLogMeterName, "drop", fmt.Sprintf("%d", rate), "pktps")
+	return keeper.cli.Must(ctx, "ClaimAclLogMeter", args)
+}
+
 func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodels.Vpc) error {
 	var (
 		args      []string
@@ -745,6 +778,7 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 		if len(guestnetwork.Ip6Addr) > 0 {
 			enableIPv6 = true
 		}
+		flowLog := guest.FlowLogEnabled()
 		sgrs := guest.OrderedSecurityGroupRules()
 		for _, sgr := range sgrs {
 			// kvm not support peer secgroup
@@ -756,6 +790,11 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 			acl.ExternalIds = map[string]string{
 				externalKeyOcRef: ocAclRef,
 			}
+			// builtin rules are logged when any security group has
+			// flow log enabled
+			if sgr.SecurityGroup == nil && flowLog || sgr.SecurityGroup != nil && sgr.SecurityGroup.EnableFlowLog {
+				aclLog(acl, sgr)
+			}
 			acls = append(acls, acl)
 		}
 	}
@@ -1222,6 +1261,9 @@ func (keeper *OVNNorthboundKeeper) Mark(ctx context.Context) {
 			irow.RemoveExternalId(externalKeyOcVersion)
 		}
 	}
+	for _, irow := range keeper.pinned {
+		irow.SetExternalId(externalKeyOcVersion, ocVersionPinned)
+	}
 }
 
 func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
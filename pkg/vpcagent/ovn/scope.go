@@ -0,0 +1,216 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/ovsdb/cli_util"
+	"yunion.io/x/ovsdb/schema/ovn_nb"
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/sets"
+)
+
+const (
+	ocVersionPinned = "pinned"
+
+	// max number of find commands run by one ovn-nbctl call
+	findBatchSize = 64
+)
+
+// dumpTable lists all rows of the table
+func dumpTable(ctx context.Context, cli iOvnNbCtl, itbl types.ITable) error {
+	tbl := itbl.OvsdbTableName()
+	res := cli.Must(ctx, "List "+tbl, []string{"--format=json", "list", tbl})
+	if err := cli_util.UnmarshalJSON([]byte(res.Output), itbl); err != nil {
+		return errors.Wrapf(err, "Unmarshal %s:\n%s", tbl, res.Output)
+	}
+	return nil
+}
+
+// dumpRows lists the rows of the table by uuids
+func dumpRows(ctx context.Context, cli iOvnNbCtl, itbl types.ITable, uuids []string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+	tbl := itbl.OvsdbTableName()
+	args := append([]string{"--format=json", "list", tbl}, uuids...)
+	res := cli.Must(ctx, "List "+tbl, args)
+	if err := cli_util.UnmarshalJSON([]byte(res.Output), itbl); err != nil {
+		return errors.Wrapf(err, "Unmarshal %s:\n%s", tbl, res.Output)
+	}
+	return nil
+}
+
+// dumpFind finds the rows of the table matching any of the conditions.  The
+// finds are run in batches of commands of one ovn-nbctl call, each printing
+// its own json output
+func dumpFind(ctx context.Context, cli iOvnNbCtl, itbl types.ITable, conds []string) error {
+	tbl := itbl.OvsdbTableName()
+	for start := 0; start < len(conds); start += findBatchSize {
+		end := start + findBatchSize
+		if end > len(conds) {
+			end = len(conds)
+		}
+		args := []string{"--format=json"}
+		for i, cond := range conds[start:end] {
+			if i > 0 {
+				args = append(args, "--")
+			}
+			args = append(args, "find", tbl, cond)
+		}
+		res := cli.Must(ctx, "Find "+tbl, args)
+		dec := json.NewDecoder(strings.NewReader(res.Output))
+		for i := start; i < end; i++ {
+			var output json.RawMessage
+			if err := dec.Decode(&output); err != nil {
+				return errors.Wrapf(err, "decode output %d of %s:\n%s", i-start, tbl, res.Output)
+			}
+			if err := cli_util.UnmarshalJSON(output, itbl); err != nil {
+				return errors.Wrapf(err, "Unmarshal %s:\n%s", tbl, output)
+			}
+		}
+	}
+	return nil
+}
+
+func nameConds(names sets.String) []string {
+	conds := make([]string, 0, names.Len())
+	for _, name := range names.List() {
+		conds = append(conds, fmt.Sprintf("name=%q", name))
+	}
+	return conds
+}
+
+// DumpOVNNorthboundScoped dumps only the rows of the given vpcs and networks.
+// Claims and sweep on the returned keeper leave the rows of other vpcs alone.
+//
+// The networks of vpcs are also found by the router ports of the vpc router,
+// so that the switches of networks already deleted in region are swept
+func DumpOVNNorthboundScoped(ctx context.Context, cli iOvnNbCtl, vpcIds []string, networkIds []string) (*OVNNorthboundKeeper, error) {
+	var (
+		db = ovn_nb.OVNNorthbound{}
+
+		lrNames = sets.NewString()
+		lsNames = sets.NewString()
+
+		lrpUuids  = sets.NewString()
+		lsrUuids  = sets.NewString()
+		lspUuids  = sets.NewString()
+		aclUuids  = sets.NewString()
+		qosUuids  = sets.NewString()
+		dhcpUuids = sets.NewString()
+		dnsUuids  = sets.NewString()
+	)
+	for _, vpcId := range vpcIds {
+		lrNames.Insert(vpcLrName(vpcId), vpcExtLrName(vpcId))
+		lsNames.Insert(vpcExtLsName(vpcId), vpcHostLsName(vpcId), vpcEipLsName(vpcId))
+	}
+	for _, networkId := range networkIds {
+		lsNames.Insert(netLsName(networkId))
+	}
+
+	if err := dumpFind(ctx, cli, &db.LogicalRouter, nameConds(lrNames)); err != nil {
+		return nil, err
+	}
+	for i := range db.LogicalRouter {
+		lr := &db.LogicalRouter[i]
+		lrpUuids.Insert(lr.Ports...)
+		lsrUuids.Insert(lr.StaticRoutes...)
+	}
+	if err := dumpRows(ctx, cli, &db.LogicalRouterPort, lrpUuids.List()); err != nil {
+		return nil, err
+	}
+	if err := dumpRows(ctx, cli, &db.LogicalRouterStaticRoute, lsrUuids.List()); err != nil {
+		return nil, err
+	}
+	for i := range db.LogicalRouterPort {
+		lrp := &db.LogicalRouterPort[i]
+		if networkId := strings.TrimPrefix(lrp.Name, netRnpName("")); networkId != lrp.Name {
+			lsNames.Insert(netLsName(networkId))
+		}
+	}
+
+	if err := dumpFind(ctx, cli, &db.LogicalSwitch, nameConds(lsNames)); err != nil {
+		return nil, err
+	}
+	scopedLs := sets.NewString()
+	for i := range db.LogicalSwitch {
+		ls := &db.LogicalSwitch[i]
+		scopedLs.Insert(ls.Uuid)
+		lspUuids.Insert(ls.Ports...)
+		aclUuids.Insert(ls.Acls...)
+		qosUuids.Insert(ls.QosRules...)
+		dnsUuids.Insert(ls.DnsRecords...)
+	}
+	if err := dumpRows(ctx, cli, &db.LogicalSwitchPort, lspUuids.List()); err != nil {
+		return nil, err
+	}
+	for i := range db.LogicalSwitchPort {
+		lsp := &db.LogicalSwitchPort[i]
+		if lsp.Dhcpv4Options != nil {
+			dhcpUuids.Insert(*lsp.Dhcpv4Options)
+		}
+		if lsp.Dhcpv6Options != nil {
+			dhcpUuids.Insert(*lsp.Dhcpv6Options)
+		}
+	}
+	if err := dumpRows(ctx, cli, &db.ACL, aclUuids.List()); err != nil {
+		return nil, err
+	}
+	if err := dumpRows(ctx, cli, &db.QoS, qosUuids.List()); err != nil {
+		return nil, err
+	}
+	if err := dumpRows(ctx, cli, &db.DHCPOptions, dhcpUuids.List()); err != nil {
+		return nil, err
+	}
+
+	// meters and DNS rows are shared by all vpcs, DNS rows are kept for
+	// matching and pinned to survive the sweep unless only referred by
+	// switches in scope
+	for _, itbl := range []types.ITable{&db.Meter, &db.MeterBand, &db.DNS} {
+		if err := dumpTable(ctx, cli, itbl); err != nil {
+			return nil, err
+		}
+	}
+	var pinned []types.IRow
+	for i := range db.DNS {
+		dns := &db.DNS[i]
+		if !dnsUuids.Has(dns.Uuid) {
+			pinned = append(pinned, dns)
+			continue
+		}
+		referrers := ovn_nb.LogicalSwitchTable{}
+		if err := dumpFind(ctx, cli, &referrers, []string{"dns_records{>=}" + dns.Uuid}); err != nil {
+			return nil, err
+		}
+		for j := range referrers {
+			if !scopedLs.Has(referrers[j].Uuid) {
+				pinned = append(pinned, dns)
+				break
+			}
+		}
+	}
+
+	return &OVNNorthboundKeeper{
+		DB:     db,
+		cli:    cli,
+		pinned: pinned,
+	}, nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"yunion.io/x/ovsdb/schema/ovn_nb"
+
+	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
+)
+
+type sFakeRow map[string]interface{}
+
+// sFakeNbCtl answers list and find of the rows it has, all commands run are
+// recorded.  Commands fail with err when it's set
+type sFakeNbCtl struct {
+	tables map[string][]sFakeRow
+	cmds   [][]string
+	err    error
+}
+
+func newFakeNbCtl() *sFakeNbCtl {
+	return &sFakeNbCtl{
+		tables: map[string][]sFakeRow{},
+	}
+}
+
+func fakeUuid(uuid string) []interface{} {
+	return []interface{}{"uuid", uuid}
+}
+
+func fakeUuidSet(uuids ...string) []interface{} {
+	set := []interface{}{}
+	for _, uuid := range uuids {
+		set = append(set, fakeUuid(uuid))
+	}
+	return []interface{}{"set", set}
+}
+
+func fakeRowUuids(val interface{}) []string {
+	pair := val.([]interface{})
+	if pair[0] == "uuid" {
+		return []string{pair[1].(string)}
+	}
+	var uuids []string
+	for _, el := range pair[1].([]interface{}) {
+		uuids = append(uuids, el.([]interface{})[1].(string))
+	}
+	return uuids
+}
+
+func (cli *sFakeNbCtl) add(tbl string, rows ...sFakeRow) {
+	cli.tables[tbl] = append(cli.tables[tbl], rows...)
+}
+
+func (cli *sFakeNbCtl) addLr(uuid, name string, ports ...string) {
+	cli.add("Logical_Router", sFakeRow{
+		"_uuid":         fakeUuid(uuid),
+		"name":          name,
+		"ports":         fakeUuidSet(ports...),
+		"static_routes": fakeUuidSet(),
+	})
+}
+
+func (cli *sFakeNbCtl) addLs(uuid, name string, ports []string, dnsRecords ...string) {
+	cli.add("Logical_Switch", sFakeRow{
+		"_uuid":       fakeUuid(uuid),
+		"name":        name,
+		"ports":       fakeUuidSet(ports...),
+		"acls":        fakeUuidSet(),
+		"qos_rules":   fakeUuidSet(),
+		"dns_records": fakeUuidSet(dnsRecords...),
+	})
+}
+
+func (cli *sFakeNbCtl) addNamed(tbl, uuid, name string) {
+	cli.add(tbl, sFakeRow{
+		"_uuid": fakeUuid(uuid),
+		"name":  name,
+	})
+}
+
+func (cli *sFakeNbCtl) find(tbl, cond string) []sFakeRow {
+	var rows []sFakeRow
+	if i := strings.Index(cond, "{>=}"); i > 0 {
+		col, uuid := cond[:i], cond[i+4:]
+		for _, row := range cli.tables[tbl] {
+			for _, u := range fakeRowUuids(row[col]) {
+				if u == uuid {
+					rows = append(rows, row)
+					break
+				}
+			}
+		}
+		return rows
+	}
+	parts := strings.SplitN(cond, "=", 2)
+	val, err := strconv.Unquote(parts[1])
+	if err != nil {
+		panic(err)
+	}
+	for _, row := range cli.tables[tbl] {
+		if row[parts[0]] == val {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (cli *sFakeNbCtl) list(tbl string, uuids []string) []sFakeRow {
+	if len(uuids) == 0 {
+		return cli.tables[tbl]
+	}
+	var rows []sFakeRow
+	for _, row := range cli.tables[tbl] {
+		for _, uuid := range uuids {
+			if fakeRowUuids(row["_uuid"])[0] == uuid {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+func (cli *sFakeNbCtl) Must(ctx context.Context, msg string, args []string) *ovnutil.CmdResult {
+	cli.cmds = append(cli.cmds, args)
+	if cli.err != nil {
+		panic(cli.err)
+	}
+	if len(args) < 3 || args[0] != "--format=json" {
+		return &ovnutil.CmdResult{}
+	}
+	// commands separated by --
+	outputs := []string{}
+	cmd := []string{}
+	for _, arg := range append(args[1:], "--") {
+		if arg != "--" {
+			cmd = append(cmd, arg)
+			continue
+		}
+		var rows []sFakeRow
+		switch cmd[0] {
+		case "list":
+			rows = cli.list(cmd[1], cmd[2:])
+		case "find":
+			rows = cli.find(cmd[1], cmd[2])
+		}
+		outputs = append(outputs, fakeOutput(rows))
+		cmd = []string{}
+	}
+	return &ovnutil.CmdResult{Output: strings.Join(outputs, "\n")}
+}
+
+func fakeOutput(rows []sFakeRow) string {
+	list := map[string]interface{}{
+		"headings": []string{},
+		"data":     [][]interface{}{},
+	}
+	if len(rows) > 0 {
+		headings := []string{}
+		for col := range rows[0] {
+			headings = append(headings, col)
+		}
+		sort.Strings(headings)
+		data := [][]interface{}{}
+		for _, row := range rows {
+			cols := []interface{}{}
+			for _, col := range headings {
+				cols = append(cols, row[col])
+			}
+			data = append(data, cols)
+		}
+		list["headings"] = headings
+		list["data"] = data
+	}
+	output, _ := json.Marshal(list)
+	return string(output)
+}
+
+func TestDumpOVNNorthboundScoped(t *testing.T) {
+	cli := newFakeNbCtl()
+	cli.addLr("lr0", vpcLrName("vpc0"), "lrp0", "lrp-stale")
+	cli.addLr("lr1", vpcLrName("vpc1"), "lrp1")
+	cli.addNamed("Logical_Router_Port", "lrp0", netRnpName("net0"))
+	cli.addNamed("Logical_Router_Port", "lrp-stale", netRnpName("net-stale"))
+	cli.addNamed("Logical_Router_Port", "lrp1", netRnpName("net1"))
+	cli.addLs("ls0", netLsName("net0"), []string{"lsp0"}, "dns0", "dns-shared")
+	cli.addLs("ls-stale", netLsName("net-stale"), nil)
+	cli.addLs("ls1", netLsName("net1"), []string{"lsp1"}, "dns-shared")
+	cli.addNamed("Logical_Switch_Port", "lsp0", "lsp0")
+	cli.addNamed("Logical_Switch_Port", "lsp1", "lsp1")
+	cli.add("DNS", sFakeRow{"_uuid": fakeUuid("dns0")}, sFakeRow{"_uuid": fakeUuid("dns-shared")})
+
+	scoped, err := DumpOVNNorthboundScoped(context.Background(), cli, []string{"vpc0"}, []string{"net0"})
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	db := &scoped.DB
+	if len(db.LogicalRouter) != 1 || db.LogicalRouter[0].Uuid != "lr0" {
+		t.Errorf("logical routers: %#v", db.LogicalRouter)
+	}
+	if len(db.LogicalRouterPort) != 2 {
+		t.Errorf("logical router ports: %#v", db.LogicalRouterPort)
+	}
+	if len(db.LogicalSwitch) != 2 {
+		t.Errorf("stale switch of vpc router should be in scope: %#v", db.LogicalSwitch)
+	}
+	if len(db.LogicalSwitchPort) != 1 || db.LogicalSwitchPort[0].Uuid != "lsp0" {
+		t.Errorf("logical switch ports: %#v", db.LogicalSwitchPort)
+	}
+	if len(db.DNS) != 2 {
+		t.Errorf("dns: %#v", db.DNS)
+	}
+	if len(scoped.pinned) != 1 || scoped.pinned[0].(*ovn_nb.DNS).Uuid != "dns-shared" {
+		t.Errorf("pinned: %#v", scoped.pinned)
+	}
+}
+
+func TestDumpFindBatch(t *testing.T) {
+	cli := newFakeNbCtl()
+	conds := []string{}
+	for i := 0; i < findBatchSize+2; i++ {
+		name := "ls" + strconv.Itoa(i)
+		if i%2 == 0 {
+			cli.addLs(name, name, nil)
+		}
+		conds = append(conds, fmt.Sprintf("name=%q", name))
+	}
+	tbl := ovn_nb.LogicalSwitchTable{}
+	if err := dumpFind(context.Background(), cli, &tbl, conds); err != nil {
+		t.Fatalf("dumpFind: %v", err)
+	}
+	if len(cli.cmds) != 2 {
+		t.Errorf("want 2 calls, got %d", len(cli.cmds))
+	}
+	if len(tbl) != findBatchSize/2+1 {
+		t.Errorf("want %d switches, got %d", findBatchSize/2+1, len(tbl))
+	}
+}
@@ -37,6 +37,31 @@ const (
 	aclDirFromLport = "from-lport"
 )
 
+const (
+	aclLogMeterName = "acl-log"
+
+	aclLogSeverityAllow = "info"
+	aclLogSeverityDrop  = "warning"
+)
+
+// aclLog turns on logging of the acl converted from security group rule.  The
+// acl is named by the rule id which is reported along with the logged flows
+func aclLog(acl *ovn_nb.ACL, rule *agentmodels.SecurityGroupRule) {
+	acl.Log = true
+	acl.Meter = ptr(aclLogMeterName)
+	if rule.Id != "" {
+		acl.Name = ptr(rule.Id)
+	}
+	if acl.Action == "drop" {
+		acl.Severity = ptr(aclLogSeverityDrop)
+	} else {
+		acl.Severity = ptr(aclLogSeverityAllow)
+	}
+	// log is a boolean column not compared when false, mark logged acls so
+	// that they are replaced when flow log is turned off
+	acl.ExternalIds[externalKeyOcAclLog] = "true"
+}
+
 func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule, enableIPv6 bool) (*ovn_nb.ACL, error) {
 	var (
 		dir    string
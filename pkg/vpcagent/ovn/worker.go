@@ -40,7 +40,12 @@ import (
 type Worker struct {
 	opts *options.Options
 
-	apih *apihelper.APIHelper
+	apih     *apihelper.APIHelper
+	changes  *sChangeTracker
+	changeCh chan struct{}
+
+	// cli overrides ovn-nbctl of OvnNorthDatabase when set
+	cli iOvnNbCtl
 }
 
 func NewWorker(opts *options.Options) worker.IWorker {
@@ -63,6 +68,10 @@ func NewWorker(opts *options.Options) worker.IWorker {
 		opts: opts,
 		apih: apih,
 	}
+	if opts.OvnIncremental {
+		w.changeCh = make(chan struct{}, 1)
+		w.changes = newChangeTracker(w.notifyChange)
+	}
 	return w
 }
 
@@ -76,6 +85,10 @@ func (w *Worker) Start(ctx context.Context, app *appsrv.Application, prefix stri
 	wg.Add(1)
 	go w.apih.Start(ctx, app, httputils.JoinPath(prefix, "api"))
 
+	if w.changes != nil {
+		w.changes.Start(ctx, w.opts.Region, agentmodels.NewModelSets())
+	}
+
 	tickDuration := time.Duration(w.opts.OvnWorkerCheckInterval) * time.Second
 	tick := time.NewTimer(tickDuration)
 	defer tick.Stop()
@@ -89,6 +102,23 @@ func (w *Worker) Start(ctx context.Context, app *appsrv.Application, prefix stri
 			if err := w.run(ctx, mss); err != nil {
 				log.Errorf("ovn: %v", err)
 			}
+		case <-w.changeCh:
+			if mss != nil {
+				var err error
+				mss, err = w.runNotified(ctx, mss)
+				if err != nil {
+					// the changes are in mss but not in north database,
+					// a full run is made right away to apply them
+					log.Errorf("ovn: %v, schedule full run", err)
+					if !tick.Stop() {
+						select {
+						case <-tick.C:
+						default:
+						}
+					}
+					tick.Reset(0)
+				}
+			}
 		case <-tick.C:
 			if mss != nil {
 				log.Infof("ovn: tick check")
@@ -103,6 +133,65 @@ func (w *Worker) Start(ctx context.Context, app *appsrv.Application, prefix stri
 	}
 }
 
+// notifyChange wakes up the worker for the changes tracked
+func (w *Worker) notifyChange() {
+	select {
+	case w.changeCh <- struct{}{}:
+	default:
+	}
+}
+
+// runNotified applies the changes notified to mss and claims the affected
+// vpcs, the model sets with changes applied are returned.  Api helper sync is
+// scheduled instead when the changes can't be applied.  The error of claiming
+// is returned along with the model sets changed, the caller should make a
+// full run for them
+func (w *Worker) runNotified(ctx context.Context, mss *agentmodels.ModelSets) (*agentmodels.ModelSets, error) {
+	changes, ok := w.changes.Pop()
+	if len(changes) == 0 {
+		return mss, nil
+	}
+	if !ok {
+		w.apih.ScheduleSync()
+		return mss, nil
+	}
+	patch, err := newChangePatch(changes)
+	if err != nil {
+		log.Errorf("ovn: %v", err)
+		w.apih.ScheduleSync()
+		return mss, nil
+	}
+	// relations are dropped by Copy and joined again by ApplyPatch
+	next := mss.Copy().(*agentmodels.ModelSets)
+	r := next.ApplyPatch(patch)
+	if !r.Changed {
+		return mss, nil
+	}
+	if !r.Correct {
+		log.Warningf("ovn: %d changes can't be applied, schedule sync", len(changes))
+		w.apih.ScheduleSync()
+		return mss, nil
+	}
+	if err := w.runChanges(ctx, mss, next, changes); err != nil {
+		return next, errors.Wrapf(err, "run %d changes", len(changes))
+	}
+	return next, nil
+}
+
+// runChanges applies the changes from prev to mss to ovn north database.  It
+// falls back to a full run when changes can't be scoped to a few vpcs
+func (w *Worker) runChanges(ctx context.Context, prev, mss *agentmodels.ModelSets, changes []sResourceChange) error {
+	scope := resolveChangeScope(changes, prev, mss)
+	if scope.full || scope.vpcIds.Len() > w.opts.OvnIncrementalMaxVpcs {
+		return w.run(ctx, mss)
+	}
+	if scope.vpcIds.Len() == 0 {
+		log.Infof("ovn: no vpc affected by %d changes", len(changes))
+		return nil
+	}
+	return w.runIncremental(ctx, mss, scope.vpcIds.List())
+}
+
 func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error) {
 	defer func() {
 		if panicVal := recover(); panicVal != nil {
@@ -116,85 +205,148 @@ func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error
 		}
 	}()
 
-	dbUrl := w.opts.OvnNorthDatabase
-	if db, err := ovsutils.NormalizeDbHost(dbUrl); err != nil {
+	ovnnbctl, err := w.nbctl()
+	if err != nil {
 		return err
-	} else {
-		dbUrl = db
 	}
-
-	ovnnbctl := ovnutil.NewOvnNbCtl(dbUrl)
 	ovndb, err := DumpOVNNorthbound(ctx, ovnnbctl)
 	if err != nil {
 		return err
 	}
 
 	ovndb.Mark(ctx)
+	ovndb.ClaimAclLogMeter(ctx, w.opts.OvnAclLogMeterRate)
+	for _, vpc := range mss.Vpcs {
+		if vpc.Id == apis.DEFAULT_VPC_ID {
+			continue
+		}
+		w.claimVpc(ctx, ovndb, vpc, mss)
+	}
 	for _, vpc := range mss.Vpcs {
 		if vpc.Id == apis.DEFAULT_VPC_ID {
 			continue
 		}
-		ovndb.ClaimVpc(ctx, vpc)
-		if vpcHasEipgw(vpc) {
-			ovndb.ClaimVpcEipgw(ctx, vpc)
+		ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
+	}
+	ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
+	ovndb.Sweep(ctx)
+	return nil
+}
+
+// runIncremental claims and sweeps only the rows of the given vpcs
+func (w *Worker) runIncremental(ctx context.Context, mss *agentmodels.ModelSets, vpcIds []string) (err error) {
+	defer func() {
+		if panicVal := recover(); panicVal != nil {
+			if panicErr, ok := panicVal.(runtime.Error); ok {
+				err = errors.Wrap(panicErr, string(debug.Stack()))
+			} else if panicErr, ok := panicVal.(error); ok {
+				err = panicErr
+			} else {
+				panic(panicVal)
+			}
+		}
+	}()
+
+	ovnnbctl, err := w.nbctl()
+	if err != nil {
+		return err
+	}
+
+	vpcs := agentmodels.Vpcs{}
+	networkIds := []string{}
+	for _, vpcId := range vpcIds {
+		vpc, ok := mss.Vpcs[vpcId]
+		if !ok {
+			// deleted vpc, its rows are swept
+			continue
+		}
+		vpcs[vpcId] = vpc
+		for networkId := range vpc.Networks {
+			networkIds = append(networkIds, networkId)
 		}
-		for _, network := range vpc.Networks {
-			ovndb.ClaimNetwork(ctx, network, w.opts)
-			for _, guestnetwork := range network.Guestnetworks {
-				if guestnetwork.Guest == nil {
+	}
+	log.Infof("ovn: incremental run for vpcs %v", vpcIds)
+
+	ovndb, err := DumpOVNNorthboundScoped(ctx, ovnnbctl, vpcIds, networkIds)
+	if err != nil {
+		return err
+	}
+	ovndb.Mark(ctx)
+	ovndb.ClaimAclLogMeter(ctx, w.opts.OvnAclLogMeterRate)
+	for _, vpc := range vpcs {
+		w.claimVpc(ctx, ovndb, vpc, mss)
+	}
+	for _, vpc := range vpcs {
+		ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
+	}
+	ovndb.ClaimDnsRecords(ctx, vpcs, mss.DnsRecords)
+	ovndb.Sweep(ctx)
+	return nil
+}
+
+func (w *Worker) nbctl() (iOvnNbCtl, error) {
+	if w.cli != nil {
+		return w.cli, nil
+	}
+	dbUrl, err := ovsutils.NormalizeDbHost(w.opts.OvnNorthDatabase)
+	if err != nil {
+		return nil, err
+	}
+	return ovnutil.NewOvnNbCtl(dbUrl), nil
+}
+
+func (w *Worker) claimVpc(ctx context.Context, ovndb *OVNNorthboundKeeper, vpc *agentmodels.Vpc, mss *agentmodels.ModelSets) {
+	ovndb.ClaimVpc(ctx, vpc)
+	if vpcHasEipgw(vpc) {
+		ovndb.ClaimVpcEipgw(ctx, vpc)
+	}
+	for _, network := range vpc.Networks {
+		ovndb.ClaimNetwork(ctx, network, w.opts)
+		for _, guestnetwork := range network.Guestnetworks {
+			if guestnetwork.Guest == nil {
+				continue
+			}
+
+			if vpcHasDistgw(vpc) {
+				var (
+					guest   = guestnetwork.Guest
+					network = guestnetwork.Network
+					vpc     = network.Vpc
+					host    = guest.Host
+				)
+				if host.OvnVersion == "" {
+					// Just in case.  This should never happen
+					log.Errorf("host %s(%s) of vpc guestnetwork (%s,%s) has no ovn support",
+						host.Id, host.Name, guestnetwork.NetworkId, guestnetwork.IpAddr)
 					continue
 				}
-
-				if vpcHasDistgw(vpc) {
-					var (
-						guest   = guestnetwork.Guest
-						network = guestnetwork.Network
-						vpc     = network.Vpc
-						host    = guest.Host
-					)
-					if host.OvnVersion == "" {
-						// Just in case.  This should never happen
-						log.Errorf("host %s(%s) of vpc guestnetwork (%s,%s) has no ovn support",
-							host.Id, host.Name, guestnetwork.NetworkId, guestnetwork.IpAddr)
+				if host.OvnMappedIpAddr == "" {
+					// trigger ovn mapped ip addr allocation
+					// apiVersion := "v2"
+					s := auth.GetAdminSession(ctx, w.opts.Region)
+					j, err := mcclient_modules.Hosts.Update(s, host.Id, nil)
+					if err != nil {
+						log.Errorf("host %s(%s) dummy update err: %v", host.Id, host.Name, err)
 						continue
 					}
+					j.Unmarshal(host) // update local copy in place
 					if host.OvnMappedIpAddr == "" {
-						// trigger ovn mapped ip addr allocation
-						// apiVersion := "v2"
-						s := auth.GetAdminSession(ctx, w.opts.Region)
-						j, err := mcclient_modules.Hosts.Update(s, host.Id, nil)
-						if err != nil {
-							log.Errorf("host %s(%s) dummy update err: %v", host.Id, host.Name, err)
-							continue
-						}
-						j.Unmarshal(host) // update local copy in place
-						if host.OvnMappedIpAddr == "" {
-							log.Errorf("host %s(%s) has no mapped addr", host.Id, host.Name)
-							continue
-						}
+						log.Errorf("host %s(%s) has no mapped addr", host.Id, host.Name)
+						continue
 					}
-
-					ovndb.ClaimVpcHost(ctx, vpc, host)
 				}
-				ovndb.ClaimGuestnetwork(ctx, guestnetwork, w.opts)
-			}
-			for _, groupnetwork := range network.Groupnetworks {
-				ovndb.ClaimGroupnetwork(ctx, groupnetwork)
-			}
-			for _, loadbalancerNetwork := range network.LoadbalancerNetworks {
-				ovndb.ClaimLoadbalancerNetwork(ctx, loadbalancerNetwork)
+
+				ovndb.ClaimVpcHost(ctx, vpc, host)
 			}
+			ovndb.ClaimGuestnetwork(ctx, guestnetwork, w.opts)
 		}
-		routes := resolveRoutes(vpc, mss)
-		ovndb.ClaimRoutes(ctx, vpc, routes)
-	}
-	for _, vpc := range mss.Vpcs {
-		if vpc.Id == apis.DEFAULT_VPC_ID {
-			continue
+		for _, groupnetwork := range network.Groupnetworks {
+			ovndb.ClaimGroupnetwork(ctx, groupnetwork)
+		}
+		for _, loadbalancerNetwork := range network.LoadbalancerNetworks {
+			ovndb.ClaimLoadbalancerNetwork(ctx, loadbalancerNetwork)
 		}
-		ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
 	}
-	ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
-	ovndb.Sweep(ctx)
-	return nil
+	routes := resolveRoutes(vpc, mss)
+	ovndb.ClaimRoutes(ctx, vpc, routes)
 }
@@ -0,0 +1,132 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/options"
+)
+
+func TestWorkerRunNotified(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cli = newFakeNbCtl()
+		mss = agentmodels.NewModelSets()
+		w   = &Worker{
+			opts:    &options.Options{},
+			changes: newChangeTracker(func() {}),
+			cli:     cli,
+		}
+	)
+	w.opts.OvnIncrementalMaxVpcs = 64
+	w.changes.watching = true
+
+	// vpc1 is already in north database
+	cli.addLr("lr1", vpcLrName("vpc1"), "lrp1")
+	cli.addNamed("Logical_Router_Port", "lrp1", netRnpName("net1"))
+	cli.addLs("ls1", netLsName("net1"), []string{"lsp1"})
+	cli.addNamed("Logical_Switch_Port", "lsp1", netNrpName("net1"))
+
+	on := func(keyword string) *sChangeHandler {
+		return &sChangeHandler{tracker: w.changes, keyword: keyword}
+	}
+	obj := func(kv map[string]interface{}) *jsonutils.JSONDict {
+		return jsonutils.Marshal(kv).(*jsonutils.JSONDict)
+	}
+	var (
+		vpcKw  = mss.Vpcs.ModelManager().GetKeyword()
+		wireKw = mss.Wires.ModelManager().GetKeyword()
+		netKw  = mss.Networks.ModelManager().GetKeyword()
+	)
+	for _, i := range []string{"0", "1"} {
+		on(vpcKw).OnAdd(obj(map[string]interface{}{"id": "vpc" + i, "update_version": 1}))
+		on(wireKw).OnAdd(obj(map[string]interface{}{"id": "wire" + i, "vpc_id": "vpc" + i, "update_version": 1}))
+		on(netKw).OnAdd(obj(map[string]interface{}{
+			"id":             "net" + i,
+			"wire_id":        "wire" + i,
+			"guest_gateway":  "10.0." + i + ".1",
+			"guest_ip_mask":  24,
+			"update_version": 1,
+		}))
+	}
+	mss, _ = w.runNotified(ctx, mss)
+	if len(mss.Vpcs) != 2 || len(mss.Vpcs["vpc0"].Networks) != 1 {
+		t.Fatalf("changes not applied: %#v", mss.Vpcs)
+	}
+
+	cli.cmds = nil
+	on(netKw).OnUpdate(
+		obj(map[string]interface{}{"id": "net0", "wire_id": "wire0", "guest_gateway": "10.0.0.1", "guest_ip_mask": 24, "update_version": 1}),
+		obj(map[string]interface{}{"id": "net0", "wire_id": "wire0", "guest_gateway": "10.0.0.1", "guest_ip_mask": 16, "update_version": 2}),
+	)
+	mss, _ = w.runNotified(ctx, mss)
+	if mask := mss.Networks["net0"].GuestIpMask; mask != 16 {
+		t.Errorf("network update not applied, mask %d", mask)
+	}
+
+	claimed := false
+	for _, args := range cli.cmds {
+		s := strings.Join(args, " ")
+		for _, other := range []string{"vpc1", "net1", "lr1", "lrp1", "ls1", "lsp1"} {
+			if strings.Contains(s, other) {
+				t.Errorf("vpc1 touched by change of vpc0: %s", s)
+			}
+		}
+		if strings.Contains(s, "create Logical_Switch") && strings.Contains(s, netLsName("net0")) {
+			claimed = true
+		}
+	}
+	if !claimed {
+		t.Errorf("network of vpc0 not claimed: %v", cli.cmds)
+	}
+
+	cli.cmds = nil
+	on(netKw).OnDelete(obj(map[string]interface{}{"id": "net0", "wire_id": "wire0", "update_version": 2}))
+	mss, _ = w.runNotified(ctx, mss)
+	if _, ok := mss.Networks["net0"]; ok {
+		t.Errorf("network delete not applied")
+	}
+	if len(cli.cmds) == 0 {
+		t.Errorf("vpc0 not claimed after network delete")
+	}
+
+	// changes failed to be claimed are kept in the model sets returned
+	cli.err = errors.Error("ovn-nbctl failed")
+	on(netKw).OnAdd(obj(map[string]interface{}{
+		"id":             "net2",
+		"wire_id":        "wire0",
+		"guest_gateway":  "10.0.2.1",
+		"guest_ip_mask":  24,
+		"update_version": 1,
+	}))
+	mss, err := w.runNotified(ctx, mss)
+	if err == nil {
+		t.Errorf("error of claiming not returned")
+	}
+	if _, ok := mss.Networks["net2"]; !ok {
+		t.Errorf("changes failed to be claimed are dropped")
+	}
+	cli.err = nil
+	if err := w.run(ctx, mss); err != nil {
+		t.Errorf("full run: %v", err)
+	}
+}
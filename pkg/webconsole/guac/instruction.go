@@ -42,6 +42,13 @@ func (i *Instruction) String() string {
 	return ret
 }
 
+// ParseInstructions parses the complete instructions in buf, an incomplete
+// trailing instruction is ignored
+func ParseInstructions(buf []byte) ([]*Instruction, error) {
+	ret, _, err := parse(buf)
+	return ret, err
+}
+
 func parse(buf []byte) ([]*Instruction, []byte, error) {
 	ret := []*Instruction{}
 	if len(buf) == 0 {
@@ -0,0 +1,280 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	npk "yunion.io/x/onecloud/pkg/mcclient/modules/notify"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+	o "yunion.io/x/onecloud/pkg/webconsole/options"
+)
+
+var accessRequestManager *SAccessRequestManager
+
+func GetAccessRequestManager() *SAccessRequestManager {
+	if accessRequestManager != nil {
+		return accessRequestManager
+	}
+	accessRequestManager = &SAccessRequestManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SAccessRequest{},
+			"access_request_tbl",
+			"accessrequest",
+			"accessrequests",
+		),
+	}
+	accessRequestManager.SetVirtualObject(accessRequestManager)
+	return accessRequestManager
+}
+
+type SAccessRequestManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+// SAccessRequest is a request to connect to a resource requiring approval,
+// the webconsole session is only activated after the request is approved
+type SAccessRequest struct {
+	db.SStandaloneAnonResourceBase
+
+	SessionId string `width:"128" charset:"ascii" list:"user" index:"true"`
+	Protocol  string `width:"32" charset:"ascii" list:"user"`
+
+	ObjId   string `width:"128" charset:"ascii" list:"user" index:"true"`
+	ObjName string `width:"128" charset:"utf8" list:"user"`
+	ObjType string `width:"40" charset:"ascii" list:"user"`
+	// the tag of the resource which requires approval
+	Tag string `width:"256" charset:"utf8" list:"user"`
+
+	UserId    string `width:"128" charset:"ascii" list:"user" index:"true"`
+	User      string `width:"128" charset:"utf8" list:"user"`
+	ProjectId string `width:"128" charset:"ascii" list:"user"`
+	Project   string `width:"128" charset:"utf8" list:"user"`
+
+	Status     string    `width:"16" charset:"ascii" list:"user"`
+	ApproverId string    `width:"128" charset:"ascii" list:"user"`
+	Approver   string    `width:"128" charset:"utf8" list:"user"`
+	Comment    string    `width:"256" charset:"utf8" list:"user"`
+	ApprovedAt time.Time `nullable:"true" list:"user"`
+	// the approved session is disconnected at expire time
+	ExpireAt time.Time `nullable:"true" list:"user"`
+}
+
+func (m *SAccessRequestManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.AccessRequestListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := m.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.ListItemFilter")
+	}
+	if len(input.SessionId) > 0 {
+		q = q.Equals("session_id", input.SessionId)
+	}
+	if len(input.ObjId) > 0 {
+		q = q.Equals("obj_id", input.ObjId)
+	}
+	if len(input.UserId) > 0 {
+		q = q.Equals("user_id", input.UserId)
+	}
+	if len(input.Status) > 0 {
+		q = q.In("status", input.Status)
+	}
+	return q, nil
+}
+
+func (m *SAccessRequestManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.AccessRequestListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := m.SStandaloneAnonResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (m *SAccessRequestManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.AccessRequestDetails {
+	rows := make([]api.AccessRequestDetails, len(objs))
+	stdRows := m.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.AccessRequestDetails{
+			StandaloneAnonResourceDetails: stdRows[i],
+		}
+	}
+	return rows
+}
+
+func (m *SAccessRequestManager) ValidateCreateData(
+	ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject,
+	data *jsonutils.JSONDict,
+) (*jsonutils.JSONDict, error) {
+	return nil, httperrors.NewUnsupportOperationError("access requests are created by connecting to resources requiring approval")
+}
+
+// CreateRequest saves the pending access request and notifies the approvers
+func (m *SAccessRequestManager) CreateRequest(ctx context.Context, userCred mcclient.TokenCredential, req *SAccessRequest) error {
+	req.UserId = userCred.GetUserId()
+	req.User = userCred.GetUserName()
+	req.ProjectId = userCred.GetTenantId()
+	req.Project = userCred.GetTenantName()
+	req.Status = api.ACCESS_REQUEST_STATUS_PENDING
+	req.SetModelManager(m, req)
+	if err := m.TableSpec().Insert(ctx, req); err != nil {
+		return errors.Wrapf(err, "insert access request of session %s", req.SessionId)
+	}
+
+	data := api.AccessRequestNotifyData{
+		Id:        req.Id,
+		SessionId: req.SessionId,
+		ObjId:     req.ObjId,
+		ObjName:   req.ObjName,
+		ObjType:   req.ObjType,
+		Tag:       req.Tag,
+		User:      req.User,
+		Project:   req.Project,
+		CreatedAt: req.CreatedAt,
+	}
+	notifyclient.SystemNotifyWithCtx(ctx, npk.NotifyPriorityImportant, api.ACCESS_REQUEST_EVENT, jsonutils.Marshal(data))
+	return nil
+}
+
+// IsPendingExpired tells whether the request has waited for approval too long
+func (req *SAccessRequest) IsPendingExpired() bool {
+	return req.isPendingExpired(time.Now())
+}
+
+func (req *SAccessRequest) isPendingExpired(now time.Time) bool {
+	return req.Status == api.ACCESS_REQUEST_STATUS_PENDING &&
+		now.Sub(req.CreatedAt) > time.Duration(o.Options.SessionApprovalPendingMinutes)*time.Minute
+}
+
+// IsActive tells whether the session of the request is allowed to connect
+func (req *SAccessRequest) IsActive() bool {
+	return req.isActive(time.Now())
+}
+
+func (req *SAccessRequest) isActive(now time.Time) bool {
+	return req.Status == api.ACCESS_REQUEST_STATUS_APPROVED && now.Before(req.ExpireAt)
+}
+
+// checkApprover refuses to decide a request which is not pending or is
+// requested by the approver self
+func (req *SAccessRequest) checkApprover(userCred mcclient.TokenCredential) error {
+	if req.Status != api.ACCESS_REQUEST_STATUS_PENDING {
+		return httperrors.NewInvalidStatusError("access request is %s", req.Status)
+	}
+	if req.UserId == userCred.GetUserId() {
+		return httperrors.NewForbiddenError("not allow to approve access request of yourself")
+	}
+	return nil
+}
+
+// approve fills the approval of the request, the access time is limited by
+// SessionApprovalAccessMinutes
+func (req *SAccessRequest) approve(userCred mcclient.TokenCredential, input api.AccessRequestApproveInput, now time.Time) {
+	maxMinutes := o.Options.SessionApprovalAccessMinutes
+	if input.AccessMinutes <= 0 || input.AccessMinutes > maxMinutes {
+		input.AccessMinutes = maxMinutes
+	}
+	req.Status = api.ACCESS_REQUEST_STATUS_APPROVED
+	req.ApproverId = userCred.GetUserId()
+	req.Approver = userCred.GetUserName()
+	req.Comment = input.Comment
+	req.ApprovedAt = now
+	req.ExpireAt = now.Add(time.Duration(input.AccessMinutes) * time.Minute)
+}
+
+func (req *SAccessRequest) setStatus(status string) error {
+	_, err := db.Update(req, func() error {
+		req.Status = status
+		return nil
+	})
+	return err
+}
+
+func (req *SAccessRequest) validatePending(userCred mcclient.TokenCredential) error {
+	if req.IsPendingExpired() {
+		if err := req.setStatus(api.ACCESS_REQUEST_STATUS_EXPIRED); err != nil {
+			return errors.Wrap(err, "set expired")
+		}
+	}
+	return req.checkApprover(userCred)
+}
+
+func (req *SAccessRequest) PerformApprove(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.AccessRequestApproveInput,
+) (jsonutils.JSONObject, error) {
+	if err := req.validatePending(userCred); err != nil {
+		return nil, err
+	}
+	_, err := db.Update(req, func() error {
+		req.approve(userCred, input, time.Now())
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update access request")
+	}
+	db.OpsLog.LogEvent(req, "approve", input.Comment, userCred)
+	return nil, nil
+}
+
+func (req *SAccessRequest) PerformReject(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.AccessRequestRejectInput,
+) (jsonutils.JSONObject, error) {
+	if err := req.validatePending(userCred); err != nil {
+		return nil, err
+	}
+	_, err := db.Update(req, func() error {
+		req.Status = api.ACCESS_REQUEST_STATUS_REJECTED
+		req.ApproverId = userCred.GetUserId()
+		req.Approver = userCred.GetUserName()
+		req.Comment = input.Comment
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update access request")
+	}
+	db.OpsLog.LogEvent(req, "reject", input.Comment, userCred)
+	return nil, nil
+}
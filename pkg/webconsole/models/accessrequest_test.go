@@ -0,0 +1,101 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	o "yunion.io/x/onecloud/pkg/webconsole/options"
+)
+
+func TestAccessRequestLifecycle(t *testing.T) {
+	o.Options.SessionApprovalPendingMinutes = 30
+	o.Options.SessionApprovalAccessMinutes = 60
+
+	requester := &mcclient.SSimpleToken{UserId: "u1", User: "alice"}
+	approver := &mcclient.SSimpleToken{UserId: "u2", User: "bob"}
+	created := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	newRequest := func() *SAccessRequest {
+		req := &SAccessRequest{
+			UserId: requester.UserId,
+			User:   requester.User,
+			Status: api.ACCESS_REQUEST_STATUS_PENDING,
+		}
+		req.CreatedAt = created
+		return req
+	}
+
+	t.Run("request", func(t *testing.T) {
+		req := newRequest()
+		if req.isActive(created) {
+			t.Errorf("pending request should not be active")
+		}
+		if req.isPendingExpired(created.Add(29 * time.Minute)) {
+			t.Errorf("request should not expire within pending minutes")
+		}
+		if err := req.checkApprover(requester); err == nil {
+			t.Errorf("requester should not approve own request")
+		}
+		if err := req.checkApprover(approver); err != nil {
+			t.Errorf("checkApprover: %v", err)
+		}
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		now := created.Add(5 * time.Minute)
+		for _, c := range []struct {
+			minutes int
+			want    time.Duration
+		}{
+			{10, 10 * time.Minute},
+			{0, 60 * time.Minute},
+			{120, 60 * time.Minute},
+		} {
+			req := newRequest()
+			req.approve(approver, api.AccessRequestApproveInput{Comment: "ok", AccessMinutes: c.minutes}, now)
+			if req.Status != api.ACCESS_REQUEST_STATUS_APPROVED || req.Approver != "bob" || req.ApproverId != "u2" {
+				t.Errorf("unexpected approved request %#v", req)
+			}
+			if got := req.ExpireAt.Sub(now); got != c.want {
+				t.Errorf("access %d minutes: expire after %s, want %s", c.minutes, got, c.want)
+			}
+			if !req.isActive(now) || !req.isActive(req.ExpireAt.Add(-time.Second)) {
+				t.Errorf("approved request should be active before expire")
+			}
+			if err := req.checkApprover(approver); err == nil {
+				t.Errorf("approved request should not be approved again")
+			}
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		req := newRequest()
+		if !req.isPendingExpired(created.Add(31 * time.Minute)) {
+			t.Errorf("pending request should expire after pending minutes")
+		}
+		now := created.Add(5 * time.Minute)
+		req.approve(approver, api.AccessRequestApproveInput{AccessMinutes: 10}, now)
+		if req.isPendingExpired(created.Add(31 * time.Minute)) {
+			t.Errorf("approved request should not be pending expired")
+		}
+		if req.isActive(req.ExpireAt) || req.isActive(req.ExpireAt.Add(time.Minute)) {
+			t.Errorf("approved request should be inactive after expire")
+		}
+	})
+}
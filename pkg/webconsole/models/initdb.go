@@ -27,6 +27,8 @@ func InitDB() error {
 		 * initialization order matters, do not change the order
 		 */
 		GetCommandLogManager(),
+		GetSessionRecordingManager(),
+		GetAccessRequestManager(),
 	} {
 		err := manager.InitializeData()
 		if err != nil {
@@ -0,0 +1,204 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+	o "yunion.io/x/onecloud/pkg/webconsole/options"
+)
+
+var sessionRecordingManager *SSessionRecordingManager
+
+func GetSessionRecordingManager() *SSessionRecordingManager {
+	if sessionRecordingManager != nil {
+		return sessionRecordingManager
+	}
+	sessionRecordingManager = &SSessionRecordingManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SSessionRecording{},
+			"session_recording_tbl",
+			"sessionrecording",
+			"sessionrecordings",
+		),
+	}
+	sessionRecordingManager.SetVirtualObject(sessionRecordingManager)
+	return sessionRecordingManager
+}
+
+type SSessionRecordingManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+// SSessionRecording is a full recording of a webconsole session, the recorded
+// stream is saved in a file under the session recording dir
+type SSessionRecording struct {
+	db.SStandaloneAnonResourceBase
+
+	SessionId string `width:"128" charset:"ascii" list:"user" index:"true"`
+	Protocol  string `width:"32" charset:"ascii" list:"user"`
+	Format    string `width:"32" charset:"ascii" list:"user"`
+
+	ObjId     string `width:"128" charset:"ascii" list:"user" index:"true"`
+	ObjName   string `width:"128" charset:"utf8" list:"user"`
+	ObjType   string `width:"40" charset:"ascii" list:"user"`
+	LoginUser string `width:"128" charset:"utf8" list:"user"`
+
+	UserId    string `width:"128" charset:"ascii" list:"user" index:"true"`
+	User      string `width:"128" charset:"utf8" list:"user"`
+	ProjectId string `width:"128" charset:"ascii" list:"user"`
+	Project   string `width:"128" charset:"utf8" list:"user"`
+	DomainId  string `width:"128" charset:"ascii" list:"user"`
+	Domain    string `width:"128" charset:"utf8" list:"user"`
+
+	StartTime time.Time `list:"user"`
+	EndTime   time.Time `nullable:"true" list:"user"`
+	Size      int64     `nullable:"false" default:"0" list:"user"`
+	Status    string    `width:"16" charset:"ascii" list:"user"`
+
+	FilePath string `width:"512" charset:"utf8"`
+}
+
+func (m *SSessionRecordingManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.SessionRecordingListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := m.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.ListItemFilter")
+	}
+	if len(input.SessionId) > 0 {
+		q = q.Equals("session_id", input.SessionId)
+	}
+	if len(input.ObjId) > 0 {
+		q = q.Equals("obj_id", input.ObjId)
+	}
+	if len(input.ObjType) > 0 {
+		q = q.Equals("obj_type", input.ObjType)
+	}
+	if len(input.UserId) > 0 {
+		q = q.Equals("user_id", input.UserId)
+	}
+	if len(input.Format) > 0 {
+		q = q.In("format", input.Format)
+	}
+	if len(input.Protocol) > 0 {
+		q = q.In("protocol", input.Protocol)
+	}
+	return q, nil
+}
+
+func (m *SSessionRecordingManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	input api.SessionRecordingListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := m.SStandaloneAnonResourceBaseManager.OrderByExtraFields(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneAnonResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (m *SSessionRecordingManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.SessionRecordingDetails {
+	rows := make([]api.SessionRecordingDetails, len(objs))
+	stdRows := m.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.SessionRecordingDetails{
+			StandaloneAnonResourceDetails: stdRows[i],
+		}
+	}
+	return rows
+}
+
+func (m *SSessionRecordingManager) ValidateCreateData(
+	ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject,
+	data *jsonutils.JSONDict,
+) (*jsonutils.JSONDict, error) {
+	return nil, httperrors.NewUnsupportOperationError("session recordings are generated by webconsole sessions")
+}
+
+func (m *SSessionRecordingManager) CreateRecording(ctx context.Context, record *SSessionRecording) error {
+	record.Status = api.SESSION_RECORDING_STATUS_RECORDING
+	record.SetModelManager(m, record)
+	if err := m.TableSpec().Insert(ctx, record); err != nil {
+		return errors.Wrapf(err, "insert recording of session %s", record.SessionId)
+	}
+	return nil
+}
+
+// PurgeExpiredRecordings deletes the recordings and their files older than the retention days
+func (m *SSessionRecordingManager) PurgeExpiredRecordings(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	if o.Options.SessionRecordingRetentionDays <= 0 {
+		return
+	}
+	q := m.Query().LE("start_time", time.Now().AddDate(0, 0, -o.Options.SessionRecordingRetentionDays))
+	records := make([]SSessionRecording, 0)
+	if err := db.FetchModelObjects(m, q, &records); err != nil {
+		log.Errorf("fetch expired session recordings error: %v", err)
+		return
+	}
+	for i := range records {
+		if err := records[i].Delete(ctx, userCred); err != nil {
+			log.Errorf("delete expired session recording %s error: %v", records[i].Id, err)
+		}
+	}
+	if len(records) > 0 {
+		log.Infof("purged %d session recordings older than %d days", len(records), o.Options.SessionRecordingRetentionDays)
+	}
+}
+
+// Finish marks the recording as finished with the final size of the recorded file
+func (r *SSessionRecording) Finish(size int64) error {
+	_, err := db.Update(r, func() error {
+		r.EndTime = time.Now()
+		r.Size = size
+		r.Status = api.SESSION_RECORDING_STATUS_FINISHED
+		return nil
+	})
+	return err
+}
+
+func (r *SSessionRecording) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if len(r.FilePath) > 0 {
+		if err := os.Remove(r.FilePath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove recording file %s", r.FilePath)
+		}
+	}
+	return r.SStandaloneAnonResourceBase.Delete(ctx, userCred)
+}
@@ -35,6 +35,15 @@ type WebConsoleOptions struct {
 	RdpSessionTimeoutMinutes int `help:"rdp timeout session" default:"-1"`
 
 	EnableWatermark bool `help:"enable water mark" default:"true"`
+
+	EnableSessionRecording        bool   `help:"record ssh, pty, rdp and vnc sessions for playback" default:"false"`
+	SessionRecordingDir           string `help:"directory to save session recordings" default:"/opt/cloud/workspace/webconsole/recordings"`
+	SessionRecordingRetentionDays int    `help:"days to keep session recordings, 0 means keep forever" default:"90"`
+
+	EnableSessionApproval         bool     `help:"require approval to connect to servers and hosts with the approval tags" default:"false"`
+	SessionApprovalTags           []string `help:"tags of servers and hosts requiring approval, in key=value or key format, e.g. user:env=production"`
+	SessionApprovalPendingMinutes int      `help:"minutes an access request waits for approval before expired" default:"30"`
+	SessionApprovalAccessMinutes  int      `help:"max minutes an approved session lasts before forced disconnected" default:"60"`
 }
 
 func OnOptionsChange(oldO, newO interface{}) bool {
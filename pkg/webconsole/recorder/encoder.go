@@ -0,0 +1,184 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+)
+
+// streamEncoder encodes the session stream into the frames of a recording
+// format, nil is returned for the events the format does not record
+type streamEncoder interface {
+	Ext() string
+	Header(start time.Time, title string, cols, rows int) []byte
+	Output(elapsed time.Duration, data []byte) []byte
+	Input(elapsed time.Duration, data []byte) []byte
+	Resize(elapsed time.Duration, cols, rows int) []byte
+}
+
+func newStreamEncoder(format string) (streamEncoder, error) {
+	switch format {
+	case api.SESSION_RECORDING_FORMAT_ASCIICAST:
+		return &asciicastEncoder{}, nil
+	case api.SESSION_RECORDING_FORMAT_GUACAMOLE:
+		return &guacamoleEncoder{}, nil
+	case api.SESSION_RECORDING_FORMAT_FBS:
+		return &fbsEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unsupported recording format %q", format)
+}
+
+// asciicastEncoder writes asciinema v2 recordings,
+// ref: https://docs.asciinema.org/manual/asciicast/v2/
+type asciicastEncoder struct {
+	// incomplete utf8 sequences left by the previous chunk of each stream
+	pendingOutput []byte
+	pendingInput  []byte
+}
+
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env"`
+}
+
+func (e *asciicastEncoder) Ext() string {
+	return "cast"
+}
+
+func (e *asciicastEncoder) Header(start time.Time, title string, cols, rows int) []byte {
+	header, _ := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	return append(header, '\n')
+}
+
+func (e *asciicastEncoder) event(elapsed time.Duration, code string, data string) []byte {
+	line, _ := json.Marshal([]interface{}{math.Round(elapsed.Seconds()*1e6) / 1e6, code, data})
+	return append(line, '\n')
+}
+
+func (e *asciicastEncoder) Output(elapsed time.Duration, data []byte) []byte {
+	var complete []byte
+	complete, e.pendingOutput = splitUTF8(append(e.pendingOutput, data...))
+	if len(complete) == 0 {
+		return nil
+	}
+	return e.event(elapsed, "o", string(complete))
+}
+
+func (e *asciicastEncoder) Input(elapsed time.Duration, data []byte) []byte {
+	var complete []byte
+	complete, e.pendingInput = splitUTF8(append(e.pendingInput, data...))
+	if len(complete) == 0 {
+		return nil
+	}
+	return e.event(elapsed, "i", string(complete))
+}
+
+func (e *asciicastEncoder) Resize(elapsed time.Duration, cols, rows int) []byte {
+	return e.event(elapsed, "r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// splitUTF8 splits the trailing incomplete utf8 sequence off the buffer, so a
+// multi-byte character read across two chunks is not turned into U+FFFD
+func splitUTF8(buf []byte) ([]byte, []byte) {
+	for i := 1; i < utf8.UTFMax && i <= len(buf); i++ {
+		c := buf[len(buf)-i]
+		if c < utf8.RuneSelf {
+			break
+		}
+		if utf8.RuneStart(c) {
+			if !utf8.FullRune(buf[len(buf)-i:]) {
+				return buf[:len(buf)-i], append([]byte{}, buf[len(buf)-i:]...)
+			}
+			break
+		}
+	}
+	return buf, nil
+}
+
+// guacamoleEncoder writes the instructions sent by guacd as is, which is the
+// same format as the recordings of guacd and can be played by guacamole-common-js.
+// The sync instructions in the stream carry the timestamps of the frames.
+type guacamoleEncoder struct{}
+
+func (e *guacamoleEncoder) Ext() string {
+	return "guac"
+}
+
+func (e *guacamoleEncoder) Header(time.Time, string, int, int) []byte {
+	return nil
+}
+
+func (e *guacamoleEncoder) Output(_ time.Duration, data []byte) []byte {
+	return data
+}
+
+// Input records the key instructions of the user, like the recording-include-keys option of guacd
+func (e *guacamoleEncoder) Input(_ time.Duration, data []byte) []byte {
+	return data
+}
+
+func (e *guacamoleEncoder) Resize(time.Duration, int, int) []byte {
+	return nil
+}
+
+// fbsEncoder writes the rfb stream sent by the vnc server in FBS 001.000 format,
+// which is used by rfbproxy and can be played by noVNC
+type fbsEncoder struct{}
+
+const fbsHeader = "FBS 001.000\n"
+
+func (e *fbsEncoder) Ext() string {
+	return "fbs"
+}
+
+func (e *fbsEncoder) Header(time.Time, string, int, int) []byte {
+	return []byte(fbsHeader)
+}
+
+// Output writes a block of length, data padded to 4 bytes and milliseconds since start
+func (e *fbsEncoder) Output(elapsed time.Duration, data []byte) []byte {
+	padded := (len(data) + 3) &^ 3
+	block := make([]byte, 4+padded+4)
+	binary.BigEndian.PutUint32(block, uint32(len(data)))
+	copy(block[4:], data)
+	binary.BigEndian.PutUint32(block[4+padded:], uint32(elapsed.Milliseconds()))
+	return block
+}
+
+func (e *fbsEncoder) Input(time.Duration, []byte) []byte {
+	return nil
+}
+
+func (e *fbsEncoder) Resize(time.Duration, int, int) []byte {
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAsciicastEncoder(t *testing.T) {
+	e := &asciicastEncoder{}
+	start := time.Unix(1713312000, 0)
+	if got := string(e.Header(start, "root@vm", 120, 32)); got != `{"version":2,"width":120,"height":32,"timestamp":1713312000,"title":"root@vm","env":{"TERM":"xterm-256color"}}`+"\n" {
+		t.Errorf("unexpected header %s", got)
+	}
+	if got := string(e.Output(1500*time.Millisecond, []byte("ls\r\n"))); got != `[1.5,"o","ls\r\n"]`+"\n" {
+		t.Errorf("unexpected output event %s", got)
+	}
+	if got := string(e.Input(2*time.Second, []byte("\x03"))); got != `[2,"i","\u0003"]`+"\n" {
+		t.Errorf("unexpected input event %s", got)
+	}
+	if got := string(e.Resize(3*time.Second, 80, 24)); got != `[3,"r","80x24"]`+"\n" {
+		t.Errorf("unexpected resize event %s", got)
+	}
+
+	// a chinese character split across two chunks
+	char := []byte("中")
+	if got := e.Output(4*time.Second, append([]byte("a"), char[:2]...)); string(got) != `[4,"o","a"]`+"\n" {
+		t.Errorf("unexpected output of incomplete utf8 %s", got)
+	}
+	if got := e.Output(5*time.Second, char[2:]); string(got) != `[5,"o","中"]`+"\n" {
+		t.Errorf("unexpected output of completed utf8 %s", got)
+	}
+}
+
+func TestSplitUTF8(t *testing.T) {
+	char := []byte("中")
+	cases := []struct {
+		buf      []byte
+		complete []byte
+		rest     []byte
+	}{
+		{[]byte("abc"), []byte("abc"), nil},
+		{char, char, nil},
+		{char[:1], []byte{}, char[:1]},
+		{append([]byte("a"), char[:2]...), []byte("a"), char[:2]},
+		{[]byte{0xff}, []byte{0xff}, nil},
+	}
+	for _, c := range cases {
+		complete, rest := splitUTF8(c.buf)
+		if !bytes.Equal(complete, c.complete) || !bytes.Equal(rest, c.rest) {
+			t.Errorf("splitUTF8(%v) want %v %v, got %v %v", c.buf, c.complete, c.rest, complete, rest)
+		}
+	}
+}
+
+func TestFbsEncoder(t *testing.T) {
+	e := &fbsEncoder{}
+	if got := string(e.Header(time.Now(), "", 0, 0)); got != "FBS 001.000\n" {
+		t.Errorf("unexpected header %q", got)
+	}
+	want := []byte{0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o', 0, 0, 0, 0, 0, 0x04, 0xd2}
+	if got := e.Output(1234*time.Millisecond, []byte("hello")); !bytes.Equal(got, want) {
+		t.Errorf("want block %v, got %v", want, got)
+	}
+	if got := e.Input(time.Second, []byte("key")); got != nil {
+		t.Errorf("fbs should not record input, got %v", got)
+	}
+}
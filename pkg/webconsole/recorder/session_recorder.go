@@ -0,0 +1,173 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/webconsole/models"
+	o "yunion.io/x/onecloud/pkg/webconsole/options"
+)
+
+// SessionRecorder records the whole stream of a session for playback
+type SessionRecorder interface {
+	// Output records the data sent to the user
+	Output(data []byte)
+	// Input records the data sent by the user
+	Input(data []byte)
+	// Resize records the change of the terminal size
+	Resize(cols, rows int)
+	Close()
+}
+
+type noopSessionRecorder struct{}
+
+func NewNoopSessionRecorder() SessionRecorder {
+	return noopSessionRecorder{}
+}
+
+func (r noopSessionRecorder) Output([]byte)   {}
+func (r noopSessionRecorder) Input([]byte)    {}
+func (r noopSessionRecorder) Resize(int, int) {}
+func (r noopSessionRecorder) Close()          {}
+
+type sessionRecorder struct {
+	lock    *sync.Mutex
+	encoder streamEncoder
+	file    *os.File
+	writer  *bufio.Writer
+	start   time.Time
+	size    int64
+	record  *models.SSessionRecording
+	closed  bool
+}
+
+// NewSessionRecorder creates the recording file of the session in the given format
+// and saves the recording record which is finished when the recorder is closed
+func NewSessionRecorder(s *mcclient.ClientSession, obj *Object, sessionId, protocol, format string, cols, rows int) (SessionRecorder, error) {
+	encoder, err := newStreamEncoder(format)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	dir := filepath.Join(o.Options.SessionRecordingDir, start.Format("2006-01-02"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "mkdir %s", dir)
+	}
+	fp := filepath.Join(dir, fmt.Sprintf("%s-%d.%s", sessionId, start.UnixNano(), encoder.Ext()))
+	file, err := os.OpenFile(fp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create recording file %s", fp)
+	}
+
+	userCred := s.GetToken()
+	record := &models.SSessionRecording{
+		SessionId: sessionId,
+		Protocol:  protocol,
+		Format:    format,
+		UserId:    userCred.GetUserId(),
+		User:      userCred.GetUserName(),
+		ProjectId: userCred.GetTenantId(),
+		Project:   userCred.GetTenantName(),
+		DomainId:  userCred.GetProjectDomainId(),
+		Domain:    userCred.GetProjectDomain(),
+		StartTime: start,
+		FilePath:  fp,
+	}
+	title := protocol
+	if obj != nil {
+		record.ObjId = obj.Id
+		record.ObjName = obj.Name
+		record.ObjType = obj.Type
+		record.LoginUser = obj.LoginUser
+		title = fmt.Sprintf("%s@%s", obj.LoginUser, obj.Name)
+	}
+	if err := models.GetSessionRecordingManager().CreateRecording(s.GetContext(), record); err != nil {
+		file.Close()
+		os.Remove(fp)
+		return nil, errors.Wrap(err, "CreateRecording")
+	}
+
+	r := &sessionRecorder{
+		lock:    new(sync.Mutex),
+		encoder: encoder,
+		file:    file,
+		writer:  bufio.NewWriterSize(file, 32*1024),
+		start:   start,
+		record:  record,
+	}
+	r.write(encoder.Header(start, title, cols, rows))
+	return r, nil
+}
+
+func (r *sessionRecorder) write(data []byte) {
+	if r.closed || len(data) == 0 {
+		return
+	}
+	n, err := r.writer.Write(data)
+	r.size += int64(n)
+	if err != nil {
+		log.Errorf("write session recording %s error: %v", r.record.FilePath, err)
+	}
+}
+
+func (r *sessionRecorder) Output(data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.write(r.encoder.Output(time.Since(r.start), data))
+}
+
+func (r *sessionRecorder) Input(data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.write(r.encoder.Input(time.Since(r.start), data))
+}
+
+func (r *sessionRecorder) Resize(cols, rows int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.write(r.encoder.Resize(time.Since(r.start), cols, rows))
+}
+
+func (r *sessionRecorder) Close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.closed = true
+	if err := r.writer.Flush(); err != nil {
+		log.Errorf("flush session recording %s error: %v", r.record.FilePath, err)
+	}
+	if err := r.file.Close(); err != nil {
+		log.Errorf("close session recording %s error: %v", r.record.FilePath, err)
+	}
+	if err := r.record.Finish(r.size); err != nil {
+		log.Errorf("finish session recording %s error: %v", r.record.Id, err)
+	}
+}
@@ -23,8 +23,10 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
 	"yunion.io/x/onecloud/pkg/webconsole/guac"
 	"yunion.io/x/onecloud/pkg/webconsole/options"
+	"yunion.io/x/onecloud/pkg/webconsole/recorder"
 	"yunion.io/x/onecloud/pkg/webconsole/session"
 )
 
@@ -77,6 +79,7 @@ func (s *RDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	defer ws.Close()
+	s.Session.RegisterCloseHook(func() { ws.Close() })
 
 	tunnel, err := guac.NewGuacamoleTunnel(
 		s.Host,
@@ -100,6 +103,9 @@ func (s *RDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rec := s.Session.NewSessionRecorder(api.SESSION_RECORDING_FORMAT_GUACAMOLE, s.Width, s.Height)
+	defer rec.Close()
+
 	done := make(chan bool, 4)
 	timer := time.NewTimer(time.Microsecond * 100)
 	setDone := func() {
@@ -117,7 +123,9 @@ func (s *RDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if options.Options.RdpSessionTimeoutMinutes > 0 && timer != nil {
 				timer.Reset(time.Duration(options.Options.RdpSessionTimeoutMinutes) * time.Minute)
 			}
-			err = ws.WriteMessage(websocket.TextMessage, []byte(ins.String()))
+			data := []byte(ins.String())
+			rec.Output(data)
+			err = ws.WriteMessage(websocket.TextMessage, data)
 			if err != nil {
 				log.Errorf("Failed writing to guacd %s: %v", ins.String(), err)
 				return
@@ -141,6 +149,7 @@ func (s *RDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if options.Options.RdpSessionTimeoutMinutes > 0 && timer != nil {
 				timer.Reset(time.Duration(options.Options.RdpSessionTimeoutMinutes) * time.Minute)
 			}
+			recordKeyInstructions(rec, p)
 			_, err = tunnel.Write(p)
 			if err != nil {
 				log.Errorf("Failed writing to guacd: %v", err)
@@ -183,3 +192,17 @@ func (s *RDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	stop <- true
 	log.Infof("rdp %s@%s:%d complete", s.Username, s.Host, s.Port)
 }
+
+// recordKeyInstructions records the keys pressed by the user, other client
+// instructions like mouse moves are left out as guacd does
+func recordKeyInstructions(rec recorder.SessionRecorder, p []byte) {
+	instructions, err := guac.ParseInstructions(p)
+	if err != nil {
+		return
+	}
+	for _, ins := range instructions {
+		if ins.Opcode == "key" {
+			rec.Input([]byte(ins.String()))
+		}
+	}
+}
@@ -30,7 +30,9 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
 	"yunion.io/x/onecloud/pkg/webconsole/options"
+	"yunion.io/x/onecloud/pkg/webconsole/recorder"
 	"yunion.io/x/onecloud/pkg/webconsole/session"
 )
 
@@ -48,6 +50,7 @@ type WebsocketServer struct {
 	conn      *ssh.Client
 	sftp      *sftp.Client
 	timer     *time.Timer
+	recorder  recorder.SessionRecorder
 }
 
 func NewSshServer(s *session.SSession) (*WebsocketServer, error) {
@@ -64,9 +67,10 @@ func NewSshServer(s *session.SSession) (*WebsocketServer, error) {
 }
 
 type WebSocketBufferWriter struct {
-	s    *session.SSession
-	ws   *websocket.Conn
-	lock sync.Mutex
+	s        *session.SSession
+	ws       *websocket.Conn
+	recorder recorder.SessionRecorder
+	lock     sync.Mutex
 }
 
 func (w *WebSocketBufferWriter) Write(p []byte) (int, error) {
@@ -74,6 +78,7 @@ func (w *WebSocketBufferWriter) Write(p []byte) (int, error) {
 	defer w.lock.Unlock()
 
 	go w.s.GetRecorder().Write("", string(p))
+	w.recorder.Output(p)
 	err := w.ws.WriteMessage(websocket.BinaryMessage, p)
 	if err != nil {
 		return 0, err
@@ -134,10 +139,14 @@ func (s *WebsocketServer) initWs(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return errors.Wrapf(err, "upgrade")
 	}
+	ws := s.ws
+	s.Session.RegisterCloseHook(func() { ws.Close() })
 
+	s.recorder = s.Session.NewSessionRecorder(api.SESSION_RECORDING_FORMAT_ASCIICAST, 120, 32)
 	wsWriter := WebSocketBufferWriter{
-		s:  s.Session,
-		ws: s.ws,
+		s:        s.Session,
+		ws:       s.ws,
+		recorder: s.recorder,
 	}
 
 	s.session.Stdout = &wsWriter
@@ -165,6 +174,9 @@ func (s *WebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	err := s.initWs(w, r)
 	if err != nil {
 		log.Errorf("initWs error: %v", err)
+		if s.recorder != nil {
+			s.recorder.Close()
+		}
 		return
 	}
 
@@ -210,12 +222,14 @@ func (s *WebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				if err != nil {
 					log.Errorf("resize %dx%d error: %v", input.Data.Cols, input.Data.Rows, err)
 				}
+				s.recorder.Resize(input.Data.Cols, input.Data.Rows)
 			case "input":
 				if input.Data.Base64 {
 					data, _ := base64.StdEncoding.DecodeString(input.Data.Data)
 					input.Data.Data = string(data)
 				}
 				go s.Session.GetRecorder().Write(input.Data.Data, "")
+				s.recorder.Input([]byte(input.Data.Data))
 				_, err = s.StdinPipe.Write([]byte(input.Data.Data))
 				if err != nil {
 					log.Errorf("write %s error: %v", input.Data.Data, err)
@@ -236,6 +250,7 @@ func (s *WebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		delSftpClient(s.Session.Id)
 		s.sftp.Close()
 		s.conn.Close()
+		s.recorder.Close()
 	}()
 
 	stop := make(chan bool)
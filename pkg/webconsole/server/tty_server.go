@@ -20,6 +20,8 @@ import (
 
 	"yunion.io/x/log"
 
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+	"yunion.io/x/onecloud/pkg/webconsole/recorder"
 	"yunion.io/x/onecloud/pkg/webconsole/session"
 )
 
@@ -61,12 +63,13 @@ func (server *TTYServer) initEventHandler(s *session.SSession) {
 			log.Errorf("Create Pty error: %v", err)
 			return err
 		}
-		initSocketHandler(so, p)
+		s.RegisterCloseHook(func() { so.Disconnect() })
+		initSocketHandler(so, p, s.NewSessionRecorder(api.SESSION_RECORDING_FORMAT_ASCIICAST, 80, 24))
 		return nil
 	})
 }
 
-func initSocketHandler(so socketio.Socket, p *session.Pty) {
+func initSocketHandler(so socketio.Socket, p *session.Pty, rec recorder.SessionRecorder) {
 	// handle command output
 	go func() {
 		for !p.Exit {
@@ -74,11 +77,12 @@ func initSocketHandler(so socketio.Socket, p *session.Pty) {
 				data, err := p.Read()
 				if err != nil {
 					log.Errorf("[%s] read data error: %v", so.Id(), err)
-					cleanUp(so, p)
+					cleanUp(so, p, rec)
 				} else {
 					// log.Errorf("--p.Pty.output data: %q", data)
 					so.Emit(OUTPUT_EVENT, string(data))
 					go p.Session.GetRecorder().Write("", string(data))
+					rec.Output(data)
 				}
 				continue
 			}
@@ -111,6 +115,7 @@ func initSocketHandler(so socketio.Socket, p *session.Pty) {
 		} else {
 			p.Pty.Write([]byte(data))
 			go p.Session.GetRecorder().Write(data, "")
+			rec.Input([]byte(data))
 		}
 	})
 
@@ -118,7 +123,7 @@ func initSocketHandler(so socketio.Socket, p *session.Pty) {
 	so.On(RESIZE_EVENT, func(colRow []uint16) {
 		if len(colRow) != 2 {
 			log.Errorf("Invalid window size: %v", colRow)
-			cleanUp(so, p)
+			cleanUp(so, p, rec)
 			return
 		}
 		//size, err := pty.GetsizeFull(p.Pty)
@@ -131,23 +136,25 @@ func initSocketHandler(so socketio.Socket, p *session.Pty) {
 			Rows: colRow[1],
 		}
 		p.Resize(&newSize)
+		rec.Resize(int(newSize.Cols), int(newSize.Rows))
 	})
 
 	// handle disconnection
 	so.On(ON_DISCONNECTION, func(msg string) {
 		log.Infof("[%s] closed: %s", so.Id(), msg)
-		cleanUp(so, p)
+		cleanUp(so, p, rec)
 	})
 
 	// handle error
 	so.On(ON_ERROR, func(err error) {
 		log.Errorf("[%s] on error: %v", so.Id(), err)
-		cleanUp(so, p)
+		cleanUp(so, p, rec)
 	})
 }
 
-func cleanUp(so socketio.Socket, p *session.Pty) {
+func cleanUp(so socketio.Socket, p *session.Pty, rec recorder.SessionRecorder) {
 	so.Disconnect()
 	p.Stop()
 	p.Exit = true
+	rec.Close()
 }
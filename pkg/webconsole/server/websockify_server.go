@@ -24,6 +24,8 @@ import (
 
 	"yunion.io/x/log"
 
+	api "yunion.io/x/onecloud/pkg/apis/webconsole"
+	"yunion.io/x/onecloud/pkg/webconsole/recorder"
 	"yunion.io/x/onecloud/pkg/webconsole/session"
 )
 
@@ -45,6 +47,8 @@ type WebsockifyServer struct {
 	Session    *session.SSession
 	TargetHost string
 	TargetPort int64
+
+	recorder recorder.SessionRecorder
 }
 
 func NewWebsockifyServer(s *session.SSession) (*WebsockifyServer, error) {
@@ -60,6 +64,7 @@ func NewWebsockifyServer(s *session.SSession) (*WebsockifyServer, error) {
 		Session:    s,
 		TargetHost: info.Host,
 		TargetPort: info.Port,
+		recorder:   recorder.NewNoopSessionRecorder(),
 	}
 	return server, nil
 }
@@ -93,6 +98,14 @@ func (s *WebsockifyServer) doProxy(wsConn *websocket.Conn, tcpConn net.Conn) {
 		wsConn.Close()
 		tcpConn.Close()
 	})
+	s.Session.RegisterCloseHook(func() {
+		wsConn.Close()
+		tcpConn.Close()
+	})
+	// only the rfb stream of vnc is recorded, spice is not supported
+	if s.Session.GetProtocol() == session.VNC {
+		s.recorder = s.Session.NewSessionRecorder(api.SESSION_RECORDING_FORMAT_FBS, 0, 0)
+	}
 	go s.wsToTcp(wsConn, tcpConn)
 	s.tcpToWs(wsConn, tcpConn)
 }
@@ -122,6 +135,7 @@ func (s *WebsockifyServer) wsToTcp(wsConn *websocket.Conn, tcpConn net.Conn) {
 			return
 		}
 
+		// the fbs format only records the stream of the vnc server, client input is not recorded
 		_, err = tcpConn.Write(data)
 		if err != nil {
 			log.Errorf("Write to tcp socket error: %v", err)
@@ -151,6 +165,7 @@ func (s *WebsockifyServer) tcpToWs(wsConn *websocket.Conn, tcpConn net.Conn) {
 			return
 		}
 
+		s.recorder.Output(buffer[0:n])
 		err = s.WriteToWs(wsConn, buffer[0:n])
 		if err != nil {
 			log.Errorf("Write to websocket error: %v", err)
@@ -162,5 +177,6 @@ func (s *WebsockifyServer) tcpToWs(wsConn *websocket.Conn, tcpConn net.Conn) {
 func (s *WebsockifyServer) onExit(wsConn *websocket.Conn, tcpConn net.Conn) {
 	wsConn.Close()
 	tcpConn.Close()
+	s.recorder.Close()
 	s.Session.Close()
 }
@@ -16,16 +16,22 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/gotypes"
 	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/util/rbacscope"
 	"yunion.io/x/pkg/util/regutils"
 
 	webconsole_api "yunion.io/x/onecloud/pkg/apis/webconsole"
@@ -63,9 +69,12 @@ func initHandlers(app *appsrv.Application) {
 	app.AddHandler("GET", ApiPathPrefix+"sftp/<session-id>/list", server.HandleSftpList)
 	app.AddHandler("GET", ApiPathPrefix+"sftp/<session-id>/download", server.HandleSftpDownload)
 	app.AddHandler("POST", ApiPathPrefix+"sftp/<session-id>/upload", server.HandleSftpUpload)
+	app.AddHandler("GET", ApiPathPrefix+"sessionrecordings/<resid>/playback", auth.Authenticate(handleSessionRecordingPlayback))
 
 	for _, man := range []db.IModelManager{
 		models.GetCommandLogManager(),
+		models.GetSessionRecordingManager(),
+		models.GetAccessRequestManager(),
 	} {
 		db.RegisterModelManager(man)
 		handler := db.NewModelHandler(man)
@@ -269,7 +278,7 @@ func handleBaremetalShell(ctx context.Context, w http.ResponseWriter, r *http.Re
 		httperrors.GeneralServerError(ctx, w, err)
 		return
 	}
-	handleCommandSession(ctx, cmd, w)
+	handleDataSession(ctx, session.WrapHostCommandSession(cmd, hostId), w, "tty", nil, false)
 }
 
 func handleClimcShell(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -357,6 +366,12 @@ func handleDataSession(ctx context.Context, sData session.ISessionData, w http.R
 		httperrors.GeneralServerError(ctx, w, err)
 		return
 	}
+	accessReq, err := s.RequestApprovalIfNeeded(ctx)
+	if err != nil {
+		s.Close()
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
 	dispInfo, err := sData.GetDisplayInfo(ctx)
 	if err != nil {
 		httperrors.GeneralServerError(ctx, w, err)
@@ -384,6 +399,9 @@ func handleDataSession(ctx context.Context, sData session.ISessionData, w http.R
 		ConnectParams: params,
 		Session:       s.Id,
 	}
+	if accessReq != nil {
+		resp.AccessRequest = accessReq.Id
+	}
 	sendJSON(w, resp.JSON(resp))
 }
 
@@ -398,3 +416,41 @@ func sendJSON(w http.ResponseWriter, body jsonutils.JSONObject) {
 	}
 	appsrv.SendJSON(w, ret)
 }
+
+// handleSessionRecordingPlayback streams the recorded file of a session, only
+// system admins are allowed to review the recordings
+func handleSessionRecordingPlayback(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	params, _, _ := appsrv.FetchEnv(ctx, w, r)
+	userCred := auth.FetchUserCredential(ctx, policy.FilterPolicyCredential)
+	if !policy.PolicyManager.Allow(rbacscope.ScopeSystem, userCred, webconsole_api.SERVICE_TYPE, "sessionrecordings", policy.PolicyActionGet).Result.IsAllow() {
+		httperrors.ForbiddenError(ctx, w, "not allow to play session recordings")
+		return
+	}
+	man := models.GetSessionRecordingManager()
+	obj, err := man.FetchById(params["<resid>"])
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			httperrors.NotFoundError(ctx, w, "session recording %s not found", params["<resid>"])
+			return
+		}
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	record := obj.(*models.SSessionRecording)
+	f, err := os.Open(record.FilePath)
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, errors.Wrapf(err, "open recording file of %s", record.Id))
+		return
+	}
+	defer f.Close()
+
+	contentType := "application/octet-stream"
+	if record.Format == webconsole_api.SESSION_RECORDING_FORMAT_ASCIICAST {
+		contentType = "application/x-asciicast"
+	}
+	w.Header().Add("Content-Disposition", "attachment;filename*=utf-8''"+url.QueryEscape(filepath.Base(record.FilePath)))
+	w.Header().Add("Content-Type", contentType)
+	if _, err := io.Copy(w, f); err != nil {
+		log.Errorf("send recording file of %s error: %v", record.Id, err)
+	}
+}
@@ -105,6 +105,7 @@ func start() {
 	cron := cronman.InitCronJobManager(true, o.Options.CronJobWorkerCount)
 
 	cron.AddJobEveryFewHour("AutoPurgeSplitable", 4, 30, 0, db.AutoPurgeSplitable, false)
+	cron.AddJobEveryFewHour("PurgeExpiredSessionRecordings", 6, 0, 0, models.GetSessionRecordingManager().PurgeExpiredRecordings, false)
 
 	cron.Start()
 	defer cron.Stop()
@@ -0,0 +1,162 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	compute_api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/webconsole/command"
+	"yunion.io/x/onecloud/pkg/webconsole/models"
+	o "yunion.io/x/onecloud/pkg/webconsole/options"
+)
+
+// SApprovalTarget is the server or host a session connects to
+type SApprovalTarget struct {
+	Id       string
+	Name     string
+	Type     string
+	Metadata map[string]string
+}
+
+// IApprovalTarget is implemented by the session data connecting to a server or
+// host, whose tags decide whether the connection requires approval
+type IApprovalTarget interface {
+	GetApprovalTarget(ctx context.Context) (*SApprovalTarget, error)
+}
+
+func newServerApprovalTarget(guest *compute_api.ServerDetails) *SApprovalTarget {
+	return &SApprovalTarget{
+		Id:       guest.Id,
+		Name:     guest.Name,
+		Type:     "server",
+		Metadata: guest.Metadata,
+	}
+}
+
+func newHostApprovalTarget(host *compute_api.HostDetails) *SApprovalTarget {
+	return &SApprovalTarget{
+		Id:       host.Id,
+		Name:     host.Name,
+		Type:     "host",
+		Metadata: host.Metadata,
+	}
+}
+
+// SHostCommandSession is a command session connecting to a host without ssh,
+// e.g. the ipmi sol console of a baremetal, which requires approval as the ssh
+// session of the host does
+type SHostCommandSession struct {
+	*RandomSessionData
+	hostId string
+}
+
+func WrapHostCommandSession(cmd command.ICommand, hostId string) *SHostCommandSession {
+	return &SHostCommandSession{
+		RandomSessionData: WrapCommandSession(cmd),
+		hostId:            hostId,
+	}
+}
+
+// GetApprovalTarget implements IApprovalTarget interface
+func (s *SHostCommandSession) GetApprovalTarget(ctx context.Context) (*SApprovalTarget, error) {
+	hostDetails, err := FetchHostInfo(ctx, s.GetClientSession(), s.hostId)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchHostInfo")
+	}
+	return newHostApprovalTarget(hostDetails), nil
+}
+
+// matchApprovalTag returns the first tag matched by the metadata, a tag is
+// either key=value or a bare key matching any value
+func matchApprovalTag(metadata map[string]string, tags []string) (string, bool) {
+	for _, tag := range tags {
+		parts := strings.SplitN(tag, "=", 2)
+		val, ok := metadata[parts[0]]
+		if !ok {
+			continue
+		}
+		if len(parts) == 1 || val == parts[1] {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// RequestApprovalIfNeeded creates a pending access request if the session
+// connects to a resource with approval tags, the session can't be connected
+// until the request is approved
+func (s *SSession) RequestApprovalIfNeeded(ctx context.Context) (*models.SAccessRequest, error) {
+	if !o.Options.EnableSessionApproval || len(o.Options.SessionApprovalTags) == 0 {
+		return nil, nil
+	}
+	data, ok := s.ISessionData.(IApprovalTarget)
+	if !ok {
+		return nil, nil
+	}
+	target, err := data.GetApprovalTarget(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetApprovalTarget")
+	}
+	if target == nil {
+		return nil, nil
+	}
+	tag, ok := matchApprovalTag(target.Metadata, o.Options.SessionApprovalTags)
+	if !ok {
+		return nil, nil
+	}
+	req := &models.SAccessRequest{
+		SessionId: s.Id,
+		Protocol:  s.GetProtocol(),
+		ObjId:     target.Id,
+		ObjName:   target.Name,
+		ObjType:   target.Type,
+		Tag:       tag,
+	}
+	if err := models.GetAccessRequestManager().CreateRequest(ctx, s.GetClientSession().GetToken(), req); err != nil {
+		return nil, errors.Wrap(err, "CreateRequest")
+	}
+	s.AccessRequestId = req.Id
+	return req, nil
+}
+
+// checkApproval refuses the session whose access request is not approved, an
+// approved session is closed at the expire time of the request
+func (s *SSession) checkApproval() error {
+	if len(s.AccessRequestId) == 0 {
+		return nil
+	}
+	obj, err := models.GetAccessRequestManager().FetchById(s.AccessRequestId)
+	if err != nil {
+		return errors.Wrapf(err, "fetch access request %s", s.AccessRequestId)
+	}
+	req := obj.(*models.SAccessRequest)
+	if !req.IsActive() {
+		return errors.Errorf("access request %s is %s", req.Id, req.Status)
+	}
+	s.expireOnce.Do(func() {
+		log.Infof("session %s is approved by %s until %s", s.Id, req.Approver, req.ExpireAt)
+		time.AfterFunc(time.Until(req.ExpireAt), func() {
+			log.Infof("access request %s of session %s expired, disconnect", req.Id, s.Id)
+			s.Close()
+		})
+	})
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"testing"
+)
+
+func TestMatchApprovalTag(t *testing.T) {
+	metadata := map[string]string{
+		"user:env":   "production",
+		"user:owner": "ops",
+	}
+	tests := []struct {
+		name  string
+		tags  []string
+		want  string
+		match bool
+	}{
+		{"key and value", []string{"user:env=production"}, "user:env=production", true},
+		{"value not match", []string{"user:env=test"}, "", false},
+		{"bare key", []string{"user:env=test", "user:owner"}, "user:owner", true},
+		{"key not exists", []string{"user:critical"}, "", false},
+		{"no tags", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, match := matchApprovalTag(metadata, tt.tags)
+			if got != tt.want || match != tt.match {
+				t.Errorf("matchApprovalTag() = %q, %v, want %q, %v", got, match, tt.want, tt.match)
+			}
+		})
+	}
+}
@@ -214,6 +214,15 @@ func (info *RemoteConsoleInfo) GetRecordObject() *recorder.Object {
 	return nil
 }
 
+// GetApprovalTarget implements IApprovalTarget interface
+func (info *RemoteConsoleInfo) GetApprovalTarget(ctx context.Context) (*SApprovalTarget, error) {
+	guestDetails, err := FetchServerInfo(ctx, info.GetClientSession(), info.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchServerInfo")
+	}
+	return newServerApprovalTarget(guestDetails), nil
+}
+
 func (info *RemoteConsoleInfo) GetDisplayInfo(ctx context.Context) (*SDisplayInfo, error) {
 	userInfo, err := fetchUserInfo(ctx, info.GetClientSession())
 	if err != nil {
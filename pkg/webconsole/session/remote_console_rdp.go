@@ -122,6 +122,14 @@ func (info *RemoteRDPConsoleInfo) GetRecordObject() *recorder.Object {
 	return nil
 }
 
+// GetApprovalTarget implements IApprovalTarget interface
+func (info *RemoteRDPConsoleInfo) GetApprovalTarget(ctx context.Context) (*SApprovalTarget, error) {
+	if info.guestDetails == nil {
+		return nil, nil
+	}
+	return newServerApprovalTarget(info.guestDetails), nil
+}
+
 func (info *RemoteRDPConsoleInfo) GetDisplayInfo(ctx context.Context) (*SDisplayInfo, error) {
 	userInfo, err := fetchUserInfo(ctx, info.GetClientSession())
 	if err != nil {
@@ -90,6 +90,10 @@ func (man *SSessionManager) Get(accessToken string) (*SSession, bool) {
 		return nil, false
 	}
 	s := obj.(*SSession)
+	if err := s.checkApproval(); err != nil {
+		log.Warningf("Session %s can't be accessed: %v", s.Id, err)
+		return nil, false
+	}
 	protocol := s.GetProtocol()
 	if protocol != SPICE && time.Since(s.AccessedAt) < AccessInterval {
 		log.Warningf("Protol: %q, Token: %s, Session: %s can't be accessed during %s, last accessed at: %s", s.GetProtocol(), accessToken, s.Id, AccessInterval, s.AccessedAt)
@@ -144,6 +148,12 @@ type SSession struct {
 	AccessedAt    time.Time
 	duplicateHook func()
 	recorder      recorder.Recoder
+
+	// AccessRequestId is the access request to be approved before connecting
+	AccessRequestId string
+	expireOnce      sync.Once
+	closeHooks      []func()
+	closeHookLock   sync.Mutex
 }
 
 func (s *SSession) GetConnectParams(params url.Values, dispInfo *SDisplayInfo) (string, error) {
@@ -178,6 +188,13 @@ func (s *SSession) GetConnectParams(params url.Values, dispInfo *SDisplayInfo) (
 }
 
 func (s *SSession) Close() error {
+	s.closeHookLock.Lock()
+	hooks := s.closeHooks
+	s.closeHooks = nil
+	s.closeHookLock.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
 	if err := s.ISessionData.Cleanup(); err != nil {
 		log.Errorf("Clean up command error: %v", err)
 	}
@@ -193,6 +210,15 @@ func (s *SSession) RegisterDuplicateHook(f func()) {
 	s.duplicateHook = f
 }
 
+// RegisterCloseHook registers the function disconnecting the connection of
+// the session, which is called when the session is closed
+func (s *SSession) RegisterCloseHook(f func()) {
+	s.closeHookLock.Lock()
+	defer s.closeHookLock.Unlock()
+
+	s.closeHooks = append(s.closeHooks, f)
+}
+
 func (s *SSession) GetRecorder() recorder.Recoder {
 	if s.recorder == nil {
 		s.recorder = recorder.NewCmdRecorder(s.GetClientSession(), s.GetRecordObject(), s.GetId(), s.AccessedAt)
@@ -200,3 +226,17 @@ func (s *SSession) GetRecorder() recorder.Recoder {
 	}
 	return s.recorder
 }
+
+// NewSessionRecorder starts a full recording of the session in the format, a
+// recorder doing nothing is returned if session recording is disabled or fails
+func (s *SSession) NewSessionRecorder(format string, cols, rows int) recorder.SessionRecorder {
+	if !o.Options.EnableSessionRecording {
+		return recorder.NewNoopSessionRecorder()
+	}
+	r, err := recorder.NewSessionRecorder(s.GetClientSession(), s.GetRecordObject(), s.Id, s.GetProtocol(), format, cols, rows)
+	if err != nil {
+		log.Errorf("start %s recording of session %s error: %v", format, s.Id, err)
+		return recorder.NewNoopSessionRecorder()
+	}
+	return r
+}
@@ -97,6 +97,17 @@ func (s *SSshSession) GetRecordObject() *recorder.Object {
 	return recorder.NewObject(s.id, s.name, "server", s.Username, jsonutils.Marshal(map[string]interface{}{"ip": s.Host, "port": s.Port}))
 }
 
+// GetApprovalTarget implements IApprovalTarget interface
+func (s *SSshSession) GetApprovalTarget(ctx context.Context) (*SApprovalTarget, error) {
+	if s.guestDetails != nil {
+		return newServerApprovalTarget(s.guestDetails), nil
+	}
+	if s.hostDetails != nil {
+		return newHostApprovalTarget(s.hostDetails), nil
+	}
+	return nil, nil
+}
+
 func (s *SSshSession) GetCommand() *exec.Cmd {
 	return nil
 }